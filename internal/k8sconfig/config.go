@@ -15,14 +15,21 @@
 package k8sconfig
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"time"
 
+	"k8s.io/client-go/dynamic"
 	k8s "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/util/flowcontrol"
 )
 
 // AuthType describes the type of authentication to use for the K8s API
@@ -56,6 +63,47 @@ type APIConfig struct {
 	// token provided to the agent pod), or `kubeConfig` to use credentials
 	// from `~/.kube/config`.
 	AuthType AuthType `mapstructure:"auth_type"`
+	// Server overrides how the K8s API server is reached. It is not read
+	// from configuration since its settings may clash with other settings of
+	// a component, components set it from their own settings instead.
+	Server ServerConfig `mapstructure:"-"`
+	// Client sets how requests to the K8s API server are rate limited and
+	// timed out. Like Server, components set it from their own settings.
+	Client ClientConfig `mapstructure:"-"`
+}
+
+// ServerConfig overrides the K8s API server address and how its certificate
+// is verified. Empty settings keep the ones derived from the auth type.
+type ServerConfig struct {
+	// Address of the K8s API server (e.g. https://proxy.example.com:8443),
+	// overriding the one discovered from the environment or, with
+	// `kubeConfig`, from the kubeconfig.
+	Host string
+	// Path to the CA certificate used to verify the certificate of the K8s
+	// API server, overriding the one of the service account or kubeconfig.
+	CAFile string
+	// Whether to skip verifying the certificate of the K8s API server.
+	InsecureSkipVerify bool
+	// Context of the kubeconfig to use with `kubeConfig`, overriding its
+	// current context.
+	Context string
+}
+
+// ClientConfig sets the client-side rate limit and timeout of requests to the
+// K8s API server. Zero settings keep the defaults of client-go.
+type ClientConfig struct {
+	// Sustained number of requests per second to the K8s API server, 5 by
+	// default.
+	QPS float32
+	// Number of requests to the K8s API server allowed at once above QPS,
+	// 10 by default.
+	Burst int
+	// Timeout of each request to the K8s API server, including watches,
+	// which are re-established once it elapses. No timeout by default.
+	Timeout time.Duration
+	// OnThrottle, if set, is called with the time a request waited for the
+	// client-side rate limit whenever it had to wait for it.
+	OnThrottle func(wait time.Duration)
 }
 
 // Validate validates the K8s API config
@@ -64,6 +112,31 @@ func (c APIConfig) Validate() error {
 		return fmt.Errorf("invalid authType for kubernetes: %v", c.AuthType)
 	}
 
+	if c.Server.Host != "" {
+		u, err := url.Parse(c.Server.Host)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid api_server_host %q, must be an http or https URL", c.Server.Host)
+		}
+	}
+
+	if c.Server.Context != "" && c.AuthType != AuthTypeKubeConfig {
+		return fmt.Errorf("a kubeconfig context can only be set with auth_type=%s", AuthTypeKubeConfig)
+	}
+
+	if c.Server.CAFile != "" && c.Server.InsecureSkipVerify {
+		return errors.New("ca_file cannot be set along with insecure_skip_verify since the certificate is not verified")
+	}
+
+	if c.Client.QPS < 0 {
+		return fmt.Errorf("api_qps must not be negative, got %v", c.Client.QPS)
+	}
+	if c.Client.Burst < 0 {
+		return fmt.Errorf("api_burst must not be negative, got %d", c.Client.Burst)
+	}
+	if c.Client.Timeout < 0 {
+		return fmt.Errorf("api_timeout must not be negative, got %s", c.Client.Timeout)
+	}
+
 	return nil
 }
 
@@ -74,8 +147,8 @@ func createRestConfig(apiConf APIConfig) (*rest.Config, error) {
 
 	authType := apiConf.AuthType
 
-	var k8sHost string
-	if authType != AuthTypeKubeConfig {
+	k8sHost := apiConf.Server.Host
+	if authType != AuthTypeKubeConfig && k8sHost == "" {
 		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
 		if len(host) == 0 || len(port) == 0 {
 			return nil, fmt.Errorf("unable to load k8s config, KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT must be defined")
@@ -86,26 +159,36 @@ func createRestConfig(apiConf APIConfig) (*rest.Config, error) {
 	switch authType {
 	case AuthTypeKubeConfig:
 		loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-		configOverrides := &clientcmd.ConfigOverrides{}
+		configOverrides := &clientcmd.ConfigOverrides{
+			ClusterInfo:    clientcmdapi.Cluster{Server: apiConf.Server.Host},
+			CurrentContext: apiConf.Server.Context,
+		}
 		authConf, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
 			loadingRules, configOverrides).ClientConfig()
 
 		if err != nil {
 			return nil, fmt.Errorf("error connecting to k8s with auth_type=%s: %w", AuthTypeKubeConfig, err)
 		}
+		applyTLSOverrides(authConf, apiConf.Server)
 	case AuthTypeNone:
 		authConf = &rest.Config{
 			Host: k8sHost,
 		}
-		authConf.Insecure = true
+		// Without a CA, the certificate of the API server cannot be verified.
+		authConf.Insecure = apiConf.Server.CAFile == ""
+		authConf.CAFile = apiConf.Server.CAFile
 	case AuthTypeServiceAccount:
 		// This should work for most clusters but other auth types can be added
 		authConf, err = rest.InClusterConfig()
 		if err != nil {
 			return nil, err
 		}
+		authConf.Host = k8sHost
+		applyTLSOverrides(authConf, apiConf.Server)
 	}
 
+	applyClientSettings(authConf, apiConf.Client)
+
 	authConf.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
 		// Don't use system proxy settings since the API is local to the
 		// cluster
@@ -118,6 +201,72 @@ func createRestConfig(apiConf APIConfig) (*rest.Config, error) {
 	return authConf, nil
 }
 
+// applyTLSOverrides overrides how the certificate of the API server is
+// verified according to the CA file and insecure settings of server.
+func applyTLSOverrides(authConf *rest.Config, server ServerConfig) {
+	switch {
+	case server.InsecureSkipVerify:
+		// Client-go refuses to skip verification when a CA is set.
+		authConf.Insecure = true
+		authConf.CAFile = ""
+		authConf.CAData = nil
+	case server.CAFile != "":
+		authConf.CAFile = server.CAFile
+		authConf.CAData = nil
+	}
+}
+
+// throttleThreshold is how long a request has to wait for the client-side
+// rate limit to be considered throttled, the same as the threshold above which
+// client-go logs throttled requests.
+const throttleThreshold = 50 * time.Millisecond
+
+// applyClientSettings sets the rate limit and timeout of client on authConf.
+func applyClientSettings(authConf *rest.Config, client ClientConfig) {
+	if client.QPS != 0 {
+		authConf.QPS = client.QPS
+	}
+	if client.Burst != 0 {
+		authConf.Burst = client.Burst
+	}
+	if client.Timeout != 0 {
+		authConf.Timeout = client.Timeout
+	}
+	if client.OnThrottle == nil {
+		return
+	}
+
+	// Client-go ignores QPS and Burst when a rate limiter is set, so the
+	// observed one is created with the same defaults.
+	qps, burst := authConf.QPS, authConf.Burst
+	if qps == 0 {
+		qps = rest.DefaultQPS
+	}
+	if burst == 0 {
+		burst = rest.DefaultBurst
+	}
+	authConf.RateLimiter = &observedRateLimiter{
+		RateLimiter: flowcontrol.NewTokenBucketRateLimiter(qps, burst),
+		onThrottle:  client.OnThrottle,
+	}
+}
+
+// observedRateLimiter reports the requests waiting for the rate limit longer
+// than throttleThreshold to onThrottle.
+type observedRateLimiter struct {
+	flowcontrol.RateLimiter
+	onThrottle func(time.Duration)
+}
+
+func (l *observedRateLimiter) Wait(ctx context.Context) error {
+	start := time.Now()
+	err := l.RateLimiter.Wait(ctx)
+	if wait := time.Since(start); wait > throttleThreshold {
+		l.onThrottle(wait)
+	}
+	return err
+}
+
 // MakeClient can take configuration if needed for other types of auth
 func MakeClient(apiConf APIConfig) (k8s.Interface, error) {
 	if err := apiConf.Validate(); err != nil {
@@ -136,3 +285,22 @@ func MakeClient(apiConf APIConfig) (k8s.Interface, error) {
 
 	return client, nil
 }
+
+// MakeDynamicClient can take configuration if needed for other types of auth
+func MakeDynamicClient(apiConf APIConfig) (dynamic.Interface, error) {
+	if err := apiConf.Validate(); err != nil {
+		return nil, err
+	}
+
+	authConf, err := createRestConfig(apiConf)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := dynamic.NewForConfig(authConf)
+	if err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
@@ -0,0 +1,277 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sconfig
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      APIConfig
+		expectedErr string
+	}{
+		{
+			name:   "service account",
+			config: APIConfig{AuthType: AuthTypeServiceAccount},
+		},
+		{
+			name:        "invalid auth type",
+			config:      APIConfig{AuthType: "token"},
+			expectedErr: "invalid authType for kubernetes: token",
+		},
+		{
+			name: "api server host with ca file",
+			config: APIConfig{
+				AuthType: AuthTypeServiceAccount,
+				Server: ServerConfig{
+					Host:   "https://proxy.example.com:8443",
+					CAFile: "/etc/proxy/ca.crt",
+				},
+			},
+		},
+		{
+			name: "insecure api server host",
+			config: APIConfig{
+				AuthType: AuthTypeNone,
+				Server: ServerConfig{
+					Host:               "https://proxy.example.com:8443",
+					InsecureSkipVerify: true,
+				},
+			},
+		},
+		{
+			name:        "api server host without scheme",
+			config:      APIConfig{AuthType: AuthTypeNone, Server: ServerConfig{Host: "proxy.example.com:8443"}},
+			expectedErr: `invalid api_server_host "proxy.example.com:8443"`,
+		},
+		{
+			name: "ca file and insecure",
+			config: APIConfig{
+				AuthType: AuthTypeKubeConfig,
+				Server: ServerConfig{
+					CAFile:             "/etc/proxy/ca.crt",
+					InsecureSkipVerify: true,
+				},
+			},
+			expectedErr: "ca_file cannot be set along with insecure_skip_verify",
+		},
+		{
+			name:   "kubeconfig context",
+			config: APIConfig{AuthType: AuthTypeKubeConfig, Server: ServerConfig{Context: "other"}},
+		},
+		{
+			name:        "context without kubeconfig",
+			config:      APIConfig{AuthType: AuthTypeServiceAccount, Server: ServerConfig{Context: "other"}},
+			expectedErr: "a kubeconfig context can only be set with auth_type=kubeConfig",
+		},
+		{
+			name:   "client settings",
+			config: APIConfig{AuthType: AuthTypeServiceAccount, Client: ClientConfig{QPS: 50, Burst: 100, Timeout: time.Minute}},
+		},
+		{
+			name:        "negative qps",
+			config:      APIConfig{AuthType: AuthTypeServiceAccount, Client: ClientConfig{QPS: -1}},
+			expectedErr: "api_qps must not be negative, got -1",
+		},
+		{
+			name:        "negative burst",
+			config:      APIConfig{AuthType: AuthTypeServiceAccount, Client: ClientConfig{Burst: -1}},
+			expectedErr: "api_burst must not be negative, got -1",
+		},
+		{
+			name:        "negative timeout",
+			config:      APIConfig{AuthType: AuthTypeServiceAccount, Client: ClientConfig{Timeout: -time.Second}},
+			expectedErr: "api_timeout must not be negative, got -1s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestCreateRestConfigNoAuth(t *testing.T) {
+	// The explicit host is used even outside of a cluster.
+	os.Unsetenv("KUBERNETES_SERVICE_HOST")
+
+	conf, err := createRestConfig(APIConfig{
+		AuthType: AuthTypeNone,
+		Server:   ServerConfig{Host: "https://proxy.example.com:8443"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.Host != "https://proxy.example.com:8443" || !conf.Insecure {
+		t.Fatalf("unexpected config: host %q, insecure %v", conf.Host, conf.Insecure)
+	}
+
+	conf, err = createRestConfig(APIConfig{
+		AuthType: AuthTypeNone,
+		Server:   ServerConfig{Host: "https://proxy.example.com:8443", CAFile: "/etc/proxy/ca.crt"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.Insecure || conf.CAFile != "/etc/proxy/ca.crt" {
+		t.Fatalf("unexpected config: insecure %v, ca file %q", conf.Insecure, conf.CAFile)
+	}
+
+	if _, err = createRestConfig(APIConfig{AuthType: AuthTypeNone}); err == nil {
+		t.Fatal("expected an error without a host")
+	}
+}
+
+func TestCreateRestConfigClientSettings(t *testing.T) {
+	conf, err := createRestConfig(APIConfig{
+		AuthType: AuthTypeNone,
+		Server:   ServerConfig{Host: "https://proxy.example.com:8443"},
+		Client:   ClientConfig{QPS: 50, Burst: 100, Timeout: time.Minute},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.QPS != 50 || conf.Burst != 100 || conf.Timeout != time.Minute || conf.RateLimiter != nil {
+		t.Fatalf("unexpected config: qps %v, burst %d, timeout %s, rate limiter %v",
+			conf.QPS, conf.Burst, conf.Timeout, conf.RateLimiter)
+	}
+
+	var throttled []time.Duration
+	conf, err = createRestConfig(APIConfig{
+		AuthType: AuthTypeNone,
+		Server:   ServerConfig{Host: "https://proxy.example.com:8443"},
+		Client: ClientConfig{QPS: 10, Burst: 1, OnThrottle: func(wait time.Duration) {
+			throttled = append(throttled, wait)
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.RateLimiter == nil || conf.RateLimiter.QPS() != 10 {
+		t.Fatalf("expected an observed rate limiter with the configured qps, got %v", conf.RateLimiter)
+	}
+	// The burst is used by the first request, the second waits for the
+	// next token, a tenth of a second later.
+	for i := 0; i < 2; i++ {
+		if err = conf.RateLimiter.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(throttled) != 1 || throttled[0] <= throttleThreshold {
+		t.Fatalf("expected the second request to be throttled, got %v", throttled)
+	}
+}
+
+const testKubeConfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: https://cluster.example.com
+    certificate-authority-data: Y2VydGlmaWNhdGU=
+- name: other
+  cluster:
+    server: https://other.example.com
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+- name: other
+  context:
+    cluster: other
+    user: test
+current-context: test
+users:
+- name: test
+  user:
+    token: test-token
+`
+
+func TestCreateRestConfigKubeConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "k8sconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config")
+	if err = ioutil.WriteFile(path, []byte(testKubeConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Setenv("KUBECONFIG", os.Getenv("KUBECONFIG"))
+	os.Setenv("KUBECONFIG", path)
+
+	conf, err := createRestConfig(APIConfig{AuthType: AuthTypeKubeConfig})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.Host != "https://cluster.example.com" || conf.Insecure || string(conf.CAData) != "certificate" {
+		t.Fatalf("unexpected config: host %q, insecure %v, ca data %q", conf.Host, conf.Insecure, conf.CAData)
+	}
+
+	conf, err = createRestConfig(APIConfig{
+		AuthType: AuthTypeKubeConfig,
+		Server:   ServerConfig{Host: "https://proxy.example.com:8443", CAFile: "/etc/proxy/ca.crt"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.Host != "https://proxy.example.com:8443" || conf.CAFile != "/etc/proxy/ca.crt" || conf.CAData != nil {
+		t.Fatalf("unexpected config: host %q, ca file %q, ca data %q", conf.Host, conf.CAFile, conf.CAData)
+	}
+	if conf.BearerToken != "test-token" {
+		t.Fatalf("credentials of the kubeconfig not used, got token %q", conf.BearerToken)
+	}
+
+	conf, err = createRestConfig(APIConfig{
+		AuthType: AuthTypeKubeConfig,
+		Server:   ServerConfig{InsecureSkipVerify: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !conf.Insecure || conf.CAData != nil || conf.CAFile != "" {
+		t.Fatalf("unexpected config: insecure %v, ca file %q, ca data %q", conf.Insecure, conf.CAFile, conf.CAData)
+	}
+
+	conf, err = createRestConfig(APIConfig{
+		AuthType: AuthTypeKubeConfig,
+		Server:   ServerConfig{Context: "other"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.Host != "https://other.example.com" {
+		t.Fatalf("context of the kubeconfig not used, got host %q", conf.Host)
+	}
+}
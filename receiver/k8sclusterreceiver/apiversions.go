@@ -0,0 +1,136 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclusterreceiver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
+)
+
+const (
+	apiVersionAutoscalingV1      = "autoscaling/v1"
+	apiVersionAutoscalingV2beta1 = "autoscaling/v2beta1"
+	apiVersionAutoscalingV2beta2 = "autoscaling/v2beta2"
+)
+
+// kindAPIVersions are the API versions a kind can be watched at.
+type kindAPIVersions struct {
+	// resource is the name of the resource of the kind, e.g. pods.
+	resource string
+	// versions are the supported group versions, the default one first.
+	versions []string
+}
+
+// supportedAPIVersions are the API versions the kinds that can be set with
+// api_versions can be watched at, keyed by lower-cased kind.
+var supportedAPIVersions = map[string]kindAPIVersions{
+	"pod":                   {"pods", []string{"v1"}},
+	"node":                  {"nodes", []string{"v1"}},
+	"namespace":             {"namespaces", []string{"v1"}},
+	"replicationcontroller": {"replicationcontrollers", []string{"v1"}},
+	"resourcequota":         {"resourcequotas", []string{"v1"}},
+	"service":               {"services", []string{"v1"}},
+	"daemonset":             {"daemonsets", []string{"apps/v1"}},
+	"deployment":            {"deployments", []string{"apps/v1"}},
+	"replicaset":            {"replicasets", []string{"apps/v1"}},
+	"statefulset":           {"statefulsets", []string{"apps/v1"}},
+	"job":                   {"jobs", []string{"batch/v1"}},
+	"cronjob":               {"cronjobs", []string{"batch/v1beta1"}},
+	"horizontalpodautoscaler": {"horizontalpodautoscalers", []string{
+		apiVersionAutoscalingV2beta1, apiVersionAutoscalingV1, apiVersionAutoscalingV2beta2,
+	}},
+}
+
+// apiVersion returns the API version the given lower-cased kind is watched at.
+func (cfg *Config) apiVersion(kind string) string {
+	if v, ok := cfg.APIVersions[kind]; ok {
+		return v
+	}
+	return supportedAPIVersions[kind].versions[0]
+}
+
+// validateAPIVersions checks that the API versions set with api_versions are
+// supported by the receiver.
+func validateAPIVersions(versions map[string]string) error {
+	for kind, version := range versions {
+		supported, ok := supportedAPIVersions[kind]
+		if !ok {
+			kinds := make([]string, 0, len(supportedAPIVersions))
+			for k := range supportedAPIVersions {
+				kinds = append(kinds, k)
+			}
+			sort.Strings(kinds)
+			return fmt.Errorf("api_versions: unsupported kind %q, must be one of: %s",
+				kind, strings.Join(kinds, ", "))
+		}
+		if !utils.StringSliceToMap(supported.versions)[version] {
+			return fmt.Errorf("api_versions: unsupported version %q of %q, must be one of: %s",
+				version, kind, strings.Join(supported.versions, ", "))
+		}
+	}
+	return nil
+}
+
+// checkServedAPIVersions checks that the API server serves the kinds set with
+// api_versions at the configured versions, so that the receiver fails to start
+// rather than failing to watch them.
+// isResourceServed returns whether the resource is served by the API server,
+// and if so whether it is namespaced.
+func isResourceServed(client discovery.DiscoveryInterface, gvr schema.GroupVersionResource) (bool, error) {
+	resources, err := client.ServerResourcesForGroupVersion(gvr.GroupVersion().String())
+	if err != nil {
+		return false, err
+	}
+	for _, r := range resources.APIResources {
+		if r.Name == gvr.Resource {
+			return r.Namespaced, nil
+		}
+	}
+	return false, fmt.Errorf("no %s resource in %s", gvr.Resource, gvr.GroupVersion())
+}
+
+func checkServedAPIVersions(client discovery.DiscoveryInterface, versions map[string]string) error {
+	kinds := make([]string, 0, len(versions))
+	for kind := range versions {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	for _, kind := range kinds {
+		version, resource := versions[kind], supportedAPIVersions[kind].resource
+		resources, err := client.ServerResourcesForGroupVersion(version)
+		if err != nil {
+			return fmt.Errorf("api_versions: %s of %q is not served by the API server: %w", version, kind, err)
+		}
+		served := false
+		for _, r := range resources.APIResources {
+			if r.Name == resource {
+				served = true
+				break
+			}
+		}
+		if !served {
+			return fmt.Errorf("api_versions: %s of %q is not served by the API server: no %s resource",
+				version, kind, resource)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2020 OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclusterreceiver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"k8s.io/api/autoscaling/v2beta2"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPinnedAPIVersionIsWatched(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	minReplicas := int32(1)
+	_, err := client.AutoscalingV2beta2().HorizontalPodAutoscalers("test").Create(context.Background(),
+		&v2beta2.HorizontalPodAutoscaler{
+			ObjectMeta: v1.ObjectMeta{Name: "hpa", Namespace: "test", UID: "hpa-uid"},
+			Spec:       v2beta2.HorizontalPodAutoscalerSpec{MinReplicas: &minReplicas, MaxReplicas: 5},
+		}, v1.CreateOptions{})
+	require.NoError(t, err)
+
+	maxReplicas := func(config *Config) []int64 {
+		rw := newResourceWatcher(zap.NewNop(), client, nil, config, 10*time.Second)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		rw.startWatchingResources(ctx)
+		defer rw.initialSyncDone.Store(true)
+
+		var out []int64
+		for _, md := range rw.dataCollector.CollectMetricData(time.Now()) {
+			for _, m := range md.Metrics {
+				if m.MetricDescriptor.Name == "k8s.hpa.max_replicas" {
+					out = append(out, m.Timeseries[0].Points[0].GetInt64Value())
+				}
+			}
+		}
+		return out
+	}
+
+	require.Empty(t, maxReplicas(&Config{}))
+	require.Equal(t, []int64{5}, maxReplicas(&Config{
+		APIVersions: map[string]string{"horizontalpodautoscaler": apiVersionAutoscalingV2beta2},
+	}))
+}
+
+func TestCheckServedAPIVersions(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.Resources = []*v1.APIResourceList{
+		{
+			GroupVersion: apiVersionAutoscalingV2beta2,
+			APIResources: []v1.APIResource{{Name: "horizontalpodautoscalers"}},
+		},
+		{
+			GroupVersion: "apps/v1",
+			APIResources: []v1.APIResource{{Name: "deployments"}},
+		},
+	}
+
+	require.NoError(t, checkServedAPIVersions(client.Discovery(), map[string]string{
+		"horizontalpodautoscaler": apiVersionAutoscalingV2beta2,
+		"deployment":              "apps/v1",
+	}))
+
+	err := checkServedAPIVersions(client.Discovery(), map[string]string{
+		"horizontalpodautoscaler": apiVersionAutoscalingV1,
+	})
+	require.EqualError(t, err, `api_versions: autoscaling/v1 of "horizontalpodautoscaler" is not served by the API server: GroupVersion "autoscaling/v1" not found`)
+
+	err = checkServedAPIVersions(client.Discovery(), map[string]string{
+		"statefulset": "apps/v1",
+	})
+	require.EqualError(t, err, `api_versions: apps/v1 of "statefulset" is not served by the API server: no statefulsets resource`)
+}
@@ -0,0 +1,137 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclusterreceiver
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+)
+
+// changeBatcher collects the objects changed within a debounce period, so
+// that the metrics of all of them are pushed at once when the period elapses.
+// An object changed several times within the period is pushed once, with its
+// latest metrics. Removals trigger a push as well, of no object, so that the
+// deletion markers of removed objects are pushed along.
+type changeBatcher struct {
+	debounce time.Duration
+	push     func(ctx context.Context, objs []runtime.Object)
+
+	mu sync.Mutex
+	// ctx is nil until the batcher is started, changes are ignored until then.
+	ctx context.Context
+	// Changed objects keyed by type and namespace/name.
+	pending map[string]runtime.Object
+	// removals is set if objects were removed since the previous push.
+	removals bool
+	timer    *time.Timer
+}
+
+func newChangeBatcher(debounce time.Duration, push func(ctx context.Context, objs []runtime.Object)) *changeBatcher {
+	return &changeBatcher{
+		debounce: debounce,
+		push:     push,
+		pending:  map[string]runtime.Object{},
+	}
+}
+
+// start starts recording changes, which are pushed until ctx is done.
+func (b *changeBatcher) start(ctx context.Context) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.ctx = ctx
+}
+
+// changed records that obj was added or updated.
+func (b *changeBatcher) changed(obj interface{}) {
+	o, ok := obj.(runtime.Object)
+	if !ok {
+		return
+	}
+	key, err := changeKey(obj)
+	if err != nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ctx == nil {
+		return
+	}
+	b.pending[key] = o
+	b.schedule()
+}
+
+// removed records that obj was deleted. Deleted objects no longer have
+// metrics, so a pending change of obj is discarded, but a push is still
+// triggered.
+func (b *changeBatcher) removed(obj interface{}) {
+	key, err := changeKey(obj)
+	if err != nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ctx == nil {
+		return
+	}
+	delete(b.pending, key)
+	b.removals = true
+	b.schedule()
+}
+
+// schedule schedules a push once the debounce period elapses, unless one is
+// already scheduled. Must be called with the lock held.
+func (b *changeBatcher) schedule() {
+	if b.timer == nil {
+		b.timer = time.AfterFunc(b.debounce, b.flush)
+	}
+}
+
+// flush pushes the metrics of the pending objects.
+func (b *changeBatcher) flush() {
+	b.mu.Lock()
+	ctx := b.ctx
+	objs := make([]runtime.Object, 0, len(b.pending))
+	for _, o := range b.pending {
+		objs = append(objs, o)
+	}
+	removals := b.removals
+	b.pending = map[string]runtime.Object{}
+	b.removals = false
+	b.timer = nil
+	b.mu.Unlock()
+
+	if (len(objs) == 0 && !removals) || ctx.Err() != nil {
+		return
+	}
+	b.push(ctx, objs)
+}
+
+func changeKey(obj interface{}) (string, error) {
+	if d, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = d.Obj
+	}
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%T/%s", obj, key), nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclusterreceiver
+
+import (
+	"context"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenterror"
+	"go.uber.org/zap"
+)
+
+var _ component.MetricsReceiver = (*multiClusterReceiver)(nil)
+var _ component.LogsReceiver = (*multiClusterReceiver)(nil)
+
+// multiClusterReceiver runs the receivers of the clusters set by clusters,
+// which watch and push the data of their cluster independently of each other.
+type multiClusterReceiver struct {
+	receivers []component.Receiver
+}
+
+func (mr *multiClusterReceiver) Start(ctx context.Context, host component.Host) error {
+	for i, r := range mr.receivers {
+		if err := r.Start(ctx, host); err != nil {
+			for _, started := range mr.receivers[:i] {
+				_ = started.Shutdown(ctx)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (mr *multiClusterReceiver) Shutdown(ctx context.Context) error {
+	var errs []error
+	for _, r := range mr.receivers {
+		if err := r.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return componenterror.CombineErrors(errs)
+}
+
+// newClusterReceivers creates a receiver per cluster of the configuration with
+// newClusterReceiver, a single one if clusters is not set.
+func newClusterReceivers(
+	logger *zap.Logger, cfg *Config,
+	newClusterReceiver func(logger *zap.Logger, cfg *Config) (component.Receiver, error)) (component.Receiver, error) {
+	if len(cfg.Clusters) == 0 {
+		return newClusterReceiver(logger, cfg)
+	}
+
+	mr := &multiClusterReceiver{}
+	for _, clusterCfg := range cfg.clusterConfigs() {
+		r, err := newClusterReceiver(logger.With(zap.String("cluster", clusterCfg.ClusterName)), clusterCfg)
+		if err != nil {
+			return nil, err
+		}
+		mr.receivers = append(mr.receivers, r)
+	}
+	return mr, nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Program metricsmanifest prints the manifest of the metrics the k8s_cluster
+// receiver can emit, e.g. to validate dashboards against it.
+//
+//	go run ./cmd/metricsmanifest -format yaml
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/collection"
+)
+
+func main() {
+	format := flag.String("format", "json", "Output format, json or yaml")
+	flag.Parse()
+
+	out, err := marshal(collection.MetricManifest(), *format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Stdout.Write(out)
+}
+
+func marshal(manifest []collection.MetricInfo, format string) ([]byte, error) {
+	switch format {
+	case "json":
+		out, err := json.MarshalIndent(manifest, "", "  ")
+		return append(out, '\n'), err
+	case "yaml":
+		return yaml.Marshal(manifest)
+	}
+	return nil, fmt.Errorf("unsupported format %q, must be json or yaml", format)
+}
@@ -0,0 +1,136 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"sort"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
+)
+
+var podAntiAffinityViolationMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name: "k8s.pod.anti_affinity_violation",
+	Description: "Whether the pod runs on the same node as a pod matching its required " +
+		"pod anti-affinity (1) or not (0)",
+	Unit: "1",
+	Type: metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+// getAntiAffinityViolationMetricsForPods reports, for every scheduled pod with
+// required pod anti-affinity, whether another pod on the same node matches one
+// of its anti-affinity terms. This is a best-effort heuristic: only pods on the
+// same node are compared, so violations across larger topology domains (e.g.
+// zones) are not detected.
+func getAntiAffinityViolationMetricsForPods(
+	pods cache.Store, reported func(*corev1.Pod) bool,
+	extractionRules FieldExtractionRules) []*resourceMetrics {
+	if pods == nil {
+		return nil
+	}
+
+	var antiAffine []*corev1.Pod
+	podsByNode := map[string][]*corev1.Pod{}
+	for _, obj := range pods.List() {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok || pod.Spec.NodeName == "" || isPodTerminal(pod) {
+			continue
+		}
+		podsByNode[pod.Spec.NodeName] = append(podsByNode[pod.Spec.NodeName], pod)
+		if len(requiredAntiAffinityTerms(pod)) > 0 && reported(pod) {
+			antiAffine = append(antiAffine, pod)
+		}
+	}
+	sort.Slice(antiAffine, func(i, j int) bool {
+		return antiAffine[i].UID < antiAffine[j].UID
+	})
+
+	out := make([]*resourceMetrics, 0, len(antiAffine))
+	for _, pod := range antiAffine {
+		violated := false
+		for _, other := range podsByNode[pod.Spec.NodeName] {
+			if other.UID != pod.UID && matchesAntiAffinity(pod, other) {
+				violated = true
+				break
+			}
+		}
+
+		out = append(out, &resourceMetrics{
+			resource: getResourceForPod(pod, extractionRules),
+			metrics: []*metricspb.Metric{
+				{
+					MetricDescriptor: podAntiAffinityViolationMetric,
+					Timeseries: []*metricspb.TimeSeries{
+						utils.GetInt64TimeSeries(boolToInt64(violated)),
+					},
+				},
+			},
+		})
+	}
+	return out
+}
+
+func requiredAntiAffinityTerms(pod *corev1.Pod) []corev1.PodAffinityTerm {
+	if pod.Spec.Affinity == nil || pod.Spec.Affinity.PodAntiAffinity == nil {
+		return nil
+	}
+	return pod.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+}
+
+// matchesAntiAffinity returns true if other matches any of the required
+// anti-affinity terms of pod.
+func matchesAntiAffinity(pod *corev1.Pod, other *corev1.Pod) bool {
+	for _, term := range requiredAntiAffinityTerms(pod) {
+		if !termAppliesToNamespace(term, pod.Namespace, other.Namespace) {
+			continue
+		}
+		// A nil selector matches no pods.
+		if term.LabelSelector == nil {
+			continue
+		}
+		selector, err := v1.LabelSelectorAsSelector(term.LabelSelector)
+		if err != nil {
+			continue
+		}
+		if selector.Matches(labels.Set(other.Labels)) {
+			return true
+		}
+	}
+	return false
+}
+
+// termAppliesToNamespace returns true if a term of a pod in podNamespace
+// applies to pods in namespace. Terms without namespaces apply to the
+// namespace of the pod.
+func termAppliesToNamespace(term corev1.PodAffinityTerm, podNamespace, namespace string) bool {
+	if len(term.Namespaces) == 0 {
+		return namespace == podNamespace
+	}
+	for _, ns := range term.Namespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+func isPodTerminal(pod *corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+}
@@ -0,0 +1,88 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func newAntiAffinePod(name, nodeName string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: "test-namespace",
+			UID:       types.UID(name + "-uid"),
+			Labels:    map[string]string{"app": "db"},
+		},
+		Spec: corev1.PodSpec{
+			NodeName: nodeName,
+			Affinity: &corev1.Affinity{
+				PodAntiAffinity: &corev1.PodAntiAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+						{
+							LabelSelector: &v1.LabelSelector{
+								MatchLabels: map[string]string{"app": "db"},
+							},
+							TopologyKey: corev1.LabelHostname,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestAntiAffinityViolationMetrics(t *testing.T) {
+	h := newTestHarness(t, nil, WithAntiAffinityViolations(true))
+	h.seed(
+		newAntiAffinePod("db-0", "node-1"),
+		newAntiAffinePod("db-1", "node-1"),
+		newAntiAffinePod("db-2", "node-2"),
+	)
+
+	pod := func(name string) map[string]string {
+		return map[string]string{"k8s.pod.name": name}
+	}
+	const metric = "k8s.pod.anti_affinity_violation"
+	h.requireInt64Value(metric, pod("db-0"), 1)
+	h.requireInt64Value(metric, pod("db-1"), 1)
+	h.requireInt64Value(metric, pod("db-2"), 0)
+
+	// Pods in other namespaces and completed pods are not considered.
+	other := newAntiAffinePod("db-3", "node-2")
+	other.Namespace = "other-namespace"
+	completed := newAntiAffinePod("db-4", "node-2")
+	completed.Status.Phase = corev1.PodSucceeded
+	h.seed(other, completed)
+	h.requireInt64Value(metric, pod("db-2"), 0)
+	h.requireInt64Value(metric, pod("db-3"), 0)
+	h.requireNoMetric(metric, pod("db-4"))
+
+	// Pods without required anti-affinity are not reported.
+	plain := newPodWithContainer("1", &corev1.PodSpec{NodeName: "node-1"}, &corev1.PodStatus{})
+	h.seed(plain)
+	h.requireNoMetric(metric, pod(plain.Name))
+
+	h.remove(newAntiAffinePod("db-1", "node-1"))
+	h.requireInt64Value(metric, pod("db-0"), 0)
+
+	h = newTestHarness(t, nil)
+	h.seed(newAntiAffinePod("db-0", "node-1"), newAntiAffinePod("db-1", "node-1"))
+	h.requireNoMetric(metric, nil)
+}
@@ -0,0 +1,61 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Styles of resource attribute keys.
+const (
+	// AttributeKeyStyleDot keeps the dotted keys of the OpenTelemetry
+	// semantic conventions, e.g. k8s.pod.name.
+	AttributeKeyStyleDot = "dot"
+	// AttributeKeyStyleUnderscore separates the words of keys with
+	// underscores, e.g. k8s_pod_name.
+	AttributeKeyStyleUnderscore = "underscore"
+	// AttributeKeyStyleCamelCase joins the words of keys in lower camel case,
+	// e.g. k8sPodName.
+	AttributeKeyStyleCamelCase = "camel_case"
+)
+
+// styleAttributeKey returns key in the given style, one of the
+// AttributeKeyStyle constants. Words of keys are separated by any character
+// other than letters and digits, e.g. the dots of k8s.pod.name or the slashes
+// of extracted annotation keys.
+func styleAttributeKey(key string, style string) string {
+	switch style {
+	case AttributeKeyStyleUnderscore:
+		return sanitizeLabelKey(key)
+	case AttributeKeyStyleCamelCase:
+		words := strings.FieldsFunc(key, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		var b strings.Builder
+		b.Grow(len(key))
+		for i, word := range words {
+			if i > 0 {
+				runes := []rune(word)
+				runes[0] = unicode.ToUpper(runes[0])
+				word = string(runes)
+			}
+			b.WriteString(word)
+		}
+		return b.String()
+	default:
+		return key
+	}
+}
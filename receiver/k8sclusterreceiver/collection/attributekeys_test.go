@@ -0,0 +1,69 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestStyleAttributeKey(t *testing.T) {
+	tests := []struct {
+		key        string
+		underscore string
+		camelCase  string
+	}{
+		{key: "k8s.pod.name", underscore: "k8s_pod_name", camelCase: "k8sPodName"},
+		{key: "k8s.pod.uid", underscore: "k8s_pod_uid", camelCase: "k8sPodUid"},
+		{key: "k8s_pod_name", underscore: "k8s_pod_name", camelCase: "k8sPodName"},
+		{key: "example.com/owner-team", underscore: "example_com_owner_team", camelCase: "exampleComOwnerTeam"},
+		{key: "host", underscore: "host", camelCase: "host"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			require.Equal(t, tt.key, styleAttributeKey(tt.key, AttributeKeyStyleDot))
+			require.Equal(t, tt.underscore, styleAttributeKey(tt.key, AttributeKeyStyleUnderscore))
+			require.Equal(t, tt.camelCase, styleAttributeKey(tt.key, AttributeKeyStyleCamelCase))
+		})
+	}
+}
+
+func TestDataCollectorAttributeKeyStyle(t *testing.T) {
+	h := newTestHarness(t, nil,
+		WithAttributeKeyStyle(AttributeKeyStyleCamelCase),
+		WithResourceAttributeKeys(map[string]string{"k8s.namespace.name": "namespace"}),
+	)
+	h.seed(&corev1.Namespace{
+		ObjectMeta: v1.ObjectMeta{
+			Name:        "test-namespace",
+			Namespace:   "test-namespace",
+			UID:         types.UID("test-namespace-uid"),
+			ClusterName: "test-cluster",
+		},
+	})
+
+	found := h.metrics("k8s.namespace.phase", nil)
+	require.Equal(t, 1, len(found))
+	require.Equal(t, map[string]string{
+		"namespace":       "test-namespace",
+		"k8sNamespaceUid": "test-namespace-uid",
+		"k8sClusterName":  "test-cluster",
+	}, found[0].resource.Labels)
+}
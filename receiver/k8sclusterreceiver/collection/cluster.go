@@ -0,0 +1,499 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
+)
+
+// Nodes whose resources are included in the cluster capacity metrics.
+const (
+	// ClusterCapacityNodesAll includes all nodes.
+	ClusterCapacityNodesAll = "all"
+	// ClusterCapacityNodesReady includes nodes whose Ready condition is true.
+	ClusterCapacityNodesReady = "ready"
+	// ClusterCapacityNodesSchedulable includes Ready nodes that are not
+	// cordoned.
+	ClusterCapacityNodesSchedulable = "schedulable"
+)
+
+// clusterCapacityResources are the resources whose capacity and allocatable
+// quantities are summed across nodes.
+var clusterCapacityResources = []corev1.ResourceName{
+	corev1.ResourceCPU,
+	corev1.ResourceMemory,
+}
+
+// getCapacityMetricsForCluster returns the capacity and allocatable
+// quantities of cpu and memory summed across the nodes selected by
+// capacityNodes, one of the ClusterCapacityNodes constants.
+func getCapacityMetricsForCluster(ms *metadataStore, u units, capacityNodes string) []*resourceMetrics {
+	if ms.nodes == nil {
+		return nil
+	}
+
+	capacity := map[corev1.ResourceName]*resource.Quantity{}
+	allocatable := map[corev1.ResourceName]*resource.Quantity{}
+	for _, rn := range clusterCapacityResources {
+		capacity[rn] = &resource.Quantity{}
+		allocatable[rn] = &resource.Quantity{}
+	}
+
+	for _, obj := range ms.nodes.List() {
+		node, ok := obj.(*corev1.Node)
+		if !ok || !includeInClusterCapacity(node, capacityNodes) {
+			continue
+		}
+		for _, rn := range clusterCapacityResources {
+			if q, ok := node.Status.Capacity[rn]; ok {
+				capacity[rn].Add(q)
+			}
+			if q, ok := node.Status.Allocatable[rn]; ok {
+				allocatable[rn].Add(q)
+			}
+		}
+	}
+
+	metrics := make([]*metricspb.Metric, 0, 2*len(clusterCapacityResources))
+	for _, rn := range clusterCapacityResources {
+		metrics = append(metrics,
+			u.getResourceMetric("k8s.cluster.capacity_"+string(rn),
+				"Total "+string(rn)+" capacity of the nodes of the cluster", rn, *capacity[rn]),
+			u.getResourceMetric("k8s.cluster.allocatable_"+string(rn),
+				"Total "+string(rn)+" allocatable to pods on the nodes of the cluster", rn, *allocatable[rn]),
+		)
+	}
+
+	return []*resourceMetrics{
+		{
+			resource: getResourceForCluster(),
+			metrics:  metrics,
+		},
+	}
+}
+
+func includeInClusterCapacity(node *corev1.Node, capacityNodes string) bool {
+	switch capacityNodes {
+	case ClusterCapacityNodesAll:
+		return true
+	case ClusterCapacityNodesSchedulable:
+		return !node.Spec.Unschedulable && nodeConditionValue(node, corev1.NodeReady) == 1
+	default:
+		return nodeConditionValue(node, corev1.NodeReady) == 1
+	}
+}
+
+var clusterPendingPodsMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.cluster.pending_pods",
+	Description: "Number of pending pods of the cluster by the reason they are pending",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+	LabelKeys:   []*metricspb.LabelKey{{Key: "reason"}},
+})
+
+// Reasons pending pods are counted under.
+const (
+	// pendingReasonScheduled is the reason of pending pods that have been
+	// scheduled, e.g. whose images are being pulled.
+	pendingReasonScheduled = "scheduled"
+	// pendingReasonNotScheduled is the reason of pending pods that have not
+	// been considered by the scheduler yet.
+	pendingReasonNotScheduled = "not_scheduled"
+	// pendingReasonSchedulingGated is the reason of pending pods kept from
+	// being scheduled by their scheduling gates, by design.
+	pendingReasonSchedulingGated = "scheduling_gated"
+	pendingReasonOther           = "other"
+)
+
+// pendingReasonPatterns buckets the messages of the PodScheduled condition of
+// unschedulable pods, e.g. "0/3 nodes are available: 3 Insufficient cpu.",
+// into reasons. Messages are matched lower-cased against the patterns in
+// order, so a pod is counted under the first reason its message mentions.
+var pendingReasonPatterns = []struct {
+	reason   string
+	patterns []string
+}{
+	{"insufficient_cpu", []string{"insufficient cpu"}},
+	{"insufficient_memory", []string{"insufficient memory"}},
+	{"insufficient_resources", []string{"insufficient "}},
+	{"volume", []string{"persistentvolumeclaim", "volume"}},
+	{"node_affinity", []string{"node affinity", "node selector"}},
+	{"pod_affinity", []string{"pod affinity", "anti-affinity"}},
+	{"taint", []string{"taint"}},
+	{"topology_spread", []string{"topology spread"}},
+}
+
+// pendingReasons are all the reasons pending pods are counted under. A time
+// series is reported for each of them so that series do not come and go.
+var pendingReasons = func() []string {
+	reasons := []string{pendingReasonScheduled, pendingReasonNotScheduled, pendingReasonSchedulingGated}
+	for _, p := range pendingReasonPatterns {
+		reasons = append(reasons, p.reason)
+	}
+	return append(reasons, pendingReasonOther)
+}()
+
+// getPendingPodsMetricsForCluster returns the number of pods in the Pending
+// phase by the reason they are pending. Pods the scheduler failed to schedule
+// are bucketed by the message of their PodScheduled condition.
+func getPendingPodsMetricsForCluster(ms *metadataStore) []*resourceMetrics {
+	if ms.pods == nil {
+		return nil
+	}
+
+	pending := map[string]int64{}
+	for _, obj := range ms.pods.List() {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok || pod.Status.Phase != corev1.PodPending {
+			continue
+		}
+		pending[podPendingReason(pod)]++
+	}
+
+	series := make([]*metricspb.TimeSeries, 0, len(pendingReasons))
+	for _, reason := range pendingReasons {
+		series = append(series, utils.GetInt64TimeSeriesWithLabels(
+			pending[reason], []*metricspb.LabelValue{{Value: reason, HasValue: true}},
+		))
+	}
+
+	return []*resourceMetrics{
+		{
+			resource: getResourceForCluster(),
+			metrics: []*metricspb.Metric{
+				{
+					MetricDescriptor: clusterPendingPodsMetric,
+					Timeseries:       series,
+				},
+			},
+		},
+	}
+}
+
+// podPendingReason returns the reason a pending pod is counted under.
+func podPendingReason(pod *corev1.Pod) string {
+	if pod.Spec.NodeName != "" {
+		return pendingReasonScheduled
+	}
+	if isPodSchedulingGated(pod) {
+		return pendingReasonSchedulingGated
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type != corev1.PodScheduled {
+			continue
+		}
+		if cond.Status == corev1.ConditionTrue {
+			return pendingReasonScheduled
+		}
+		if cond.Status != corev1.ConditionFalse {
+			break
+		}
+		message := strings.ToLower(cond.Message)
+		for _, p := range pendingReasonPatterns {
+			for _, pattern := range p.patterns {
+				if strings.Contains(message, pattern) {
+					return p.reason
+				}
+			}
+		}
+		return pendingReasonOther
+	}
+	return pendingReasonNotScheduled
+}
+
+var clusterContainersByRegistryMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.cluster.containers_by_registry",
+	Description: "Number of running containers of the cluster by the registry of their image",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+	LabelKeys:   []*metricspb.LabelKey{{Key: "registry"}},
+})
+
+// defaultImageRegistry is the registry of images whose reference does not
+// include one.
+const defaultImageRegistry = "docker.io"
+
+// getContainersByRegistryMetricsForCluster returns the number of running
+// containers by the registry host of the image in their spec, so that images
+// pulled from unexpected registries can be detected.
+func getContainersByRegistryMetricsForCluster(ms *metadataStore) []*resourceMetrics {
+	if ms.pods == nil {
+		return nil
+	}
+
+	containers := map[string]int64{}
+	for _, obj := range ms.pods.List() {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok {
+			continue
+		}
+		images := make(map[string]string, len(pod.Spec.InitContainers)+len(pod.Spec.Containers))
+		for _, c := range pod.Spec.InitContainers {
+			images[c.Name] = c.Image
+		}
+		for _, c := range pod.Spec.Containers {
+			images[c.Name] = c.Image
+		}
+		for _, statuses := range [][]corev1.ContainerStatus{
+			pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses,
+		} {
+			for _, cs := range statuses {
+				if image, ok := images[cs.Name]; ok && cs.State.Running != nil {
+					containers[imageRegistry(image)]++
+				}
+			}
+		}
+	}
+	if len(containers) == 0 {
+		return nil
+	}
+
+	registries := make([]string, 0, len(containers))
+	for registry := range containers {
+		registries = append(registries, registry)
+	}
+	sort.Strings(registries)
+
+	series := make([]*metricspb.TimeSeries, 0, len(registries))
+	for _, registry := range registries {
+		series = append(series, utils.GetInt64TimeSeriesWithLabels(
+			containers[registry], []*metricspb.LabelValue{{Value: registry, HasValue: true}},
+		))
+	}
+
+	return []*resourceMetrics{
+		{
+			resource: getResourceForCluster(),
+			metrics: []*metricspb.Metric{
+				{
+					MetricDescriptor: clusterContainersByRegistryMetric,
+					Timeseries:       series,
+				},
+			},
+		},
+	}
+}
+
+// imageRegistry returns the registry host of an image reference. As with
+// docker, the first component of the reference is the registry if it
+// contains a "." or a ":" or is "localhost", e.g. "gcr.io/project/app:1.0"
+// or "localhost:5000/app". Otherwise, e.g. for "nginx" or "org/app@sha256:...",
+// it is docker.io, which is also reported for its legacy index.docker.io host.
+func imageRegistry(image string) string {
+	i := strings.IndexByte(image, '/')
+	if i < 0 {
+		return defaultImageRegistry
+	}
+	host := strings.ToLower(image[:i])
+	if host != "localhost" && !strings.ContainsAny(host, ".:") || host == "index.docker.io" {
+		return defaultImageRegistry
+	}
+	return host
+}
+
+var clusterLastCollectionTimestampMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.cluster.last_collection_timestamp",
+	Description: "Unix time at which the receiver last collected metrics, in seconds",
+	Unit:        "s",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var clusterScrapeHeartbeatMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.cluster.scrape_heartbeat",
+	Description: "Reported on every collection regardless of the objects of the cluster, always 1",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+// getSelfMetricsForCluster returns the metrics about a collection at the
+// given time. They do not depend on the objects of the cluster.
+func getSelfMetricsForCluster(currentTime time.Time) []*resourceMetrics {
+	return []*resourceMetrics{
+		{
+			resource: getResourceForCluster(),
+			metrics: []*metricspb.Metric{
+				{
+					MetricDescriptor: clusterLastCollectionTimestampMetric,
+					Timeseries: []*metricspb.TimeSeries{
+						utils.GetInt64TimeSeries(currentTime.Unix()),
+					},
+				},
+				{
+					MetricDescriptor: clusterScrapeHeartbeatMetric,
+					Timeseries: []*metricspb.TimeSeries{
+						utils.GetInt64TimeSeries(1),
+					},
+				},
+			},
+		},
+	}
+}
+
+// getResourceForCluster returns a proto representation of the cluster. It is
+// used for metrics that are computed across objects at collection time rather
+// than being tied to a single object.
+func getResourceForCluster() *resourcepb.Resource {
+	return &resourcepb.Resource{
+		Type:   k8sType,
+		Labels: map[string]string{},
+	}
+}
+
+var clusterPodsByPriorityClassMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.cluster.pods_by_priority_class",
+	Description: "Number of non-terminated pods of the cluster by their priority class",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+	LabelKeys:   []*metricspb.LabelKey{{Key: "priority_class"}},
+})
+
+// noPriorityClass is the priority class pods without one are counted under.
+const noPriorityClass = "none"
+
+// getPodsByPriorityClassMetricsForCluster returns the number of pods that
+// have not terminated by the name of their priority class, so that the share
+// of high priority workloads can be told apart from best effort ones.
+func getPodsByPriorityClassMetricsForCluster(ms *metadataStore) []*resourceMetrics {
+	if ms.pods == nil {
+		return nil
+	}
+
+	pods := map[string]int64{}
+	for _, obj := range ms.pods.List() {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok || isPodTerminal(pod) {
+			continue
+		}
+		class := pod.Spec.PriorityClassName
+		if class == "" {
+			class = noPriorityClass
+		}
+		pods[class]++
+	}
+	if len(pods) == 0 {
+		return nil
+	}
+
+	classes := make([]string, 0, len(pods))
+	for class := range pods {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+
+	series := make([]*metricspb.TimeSeries, 0, len(classes))
+	for _, class := range classes {
+		series = append(series, utils.GetInt64TimeSeriesWithLabels(
+			pods[class], []*metricspb.LabelValue{{Value: class, HasValue: true}},
+		))
+	}
+
+	return []*resourceMetrics{
+		{
+			resource: getResourceForCluster(),
+			metrics: []*metricspb.Metric{
+				{
+					MetricDescriptor: clusterPodsByPriorityClassMetric,
+					Timeseries:       series,
+				},
+			},
+		},
+	}
+}
+
+var clusterTotalContainerRestartsMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name: "k8s.cluster.total_container_restarts",
+	Description: "Sum of the restarts of all the containers of the pods of the cluster, " +
+		"rising while containers are crash looping",
+	Unit: "1",
+	Type: metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var namespaceContainerRestartsMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.namespace.container_restarts",
+	Description: "Sum of the restarts of all the containers of the pods of the namespace",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+// getContainerRestartMetricsForCluster returns the sum of the restart counts
+// of the containers of all pods of the cluster, and of each namespace.
+// Namespaces without pods are reported with zero restarts. Like
+// k8s.container.restarts, the sums are not monotonic: they drop when pods
+// are deleted or their restart counts are reset.
+func getContainerRestartMetricsForCluster(ms *metadataStore) []*resourceMetrics {
+	if ms.pods == nil {
+		return nil
+	}
+
+	byNamespace := map[string]int64{}
+	if ms.namespaces != nil {
+		for _, obj := range ms.namespaces.List() {
+			if ns, ok := obj.(*corev1.Namespace); ok {
+				byNamespace[ns.Name] = 0
+			}
+		}
+	}
+	var total int64
+	for _, obj := range ms.pods.List() {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok {
+			continue
+		}
+		var restarts int64
+		for _, cs := range pod.Status.ContainerStatuses {
+			restarts += int64(cs.RestartCount)
+		}
+		byNamespace[pod.Namespace] += restarts
+		total += restarts
+	}
+
+	namespaces := make([]string, 0, len(byNamespace))
+	for namespace := range byNamespace {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	out := make([]*resourceMetrics, 0, len(namespaces)+1)
+	out = append(out, &resourceMetrics{
+		resource: getResourceForCluster(),
+		metrics: []*metricspb.Metric{
+			{
+				MetricDescriptor: clusterTotalContainerRestartsMetric,
+				Timeseries:       []*metricspb.TimeSeries{utils.GetInt64TimeSeries(total)},
+			},
+		},
+	})
+	for _, namespace := range namespaces {
+		out = append(out, &resourceMetrics{
+			resource: getResourceForNamespaceName(ms.namespaces, namespace),
+			metrics: []*metricspb.Metric{
+				{
+					MetricDescriptor: namespaceContainerRestartsMetric,
+					Timeseries:       []*metricspb.TimeSeries{utils.GetInt64TimeSeries(byNamespace[namespace])},
+				},
+			},
+		})
+	}
+	return out
+}
@@ -0,0 +1,340 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/testutils"
+)
+
+func newNodeWithResources(name string, ready corev1.ConditionStatus, cpu, memory string) *corev1.Node {
+	resources := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(cpu),
+		corev1.ResourceMemory: resource.MustParse(memory),
+	}
+	allocatable := resources.DeepCopy()
+	allocatable[corev1.ResourceCPU] = resource.MustParse("500m")
+	return &corev1.Node{
+		ObjectMeta: v1.ObjectMeta{Name: name, UID: types.UID(name + "-uid")},
+		Status: corev1.NodeStatus{
+			Capacity:    resources,
+			Allocatable: allocatable,
+			Conditions:  []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}},
+		},
+	}
+}
+
+func TestClusterCapacityMetrics(t *testing.T) {
+	cordoned := newNodeWithResources("node-3", corev1.ConditionTrue, "4", "4Gi")
+	cordoned.Spec.Unschedulable = true
+	nodes := []*corev1.Node{
+		newNodeWithResources("node-1", corev1.ConditionTrue, "2", "1Gi"),
+		newNodeWithResources("node-2", corev1.ConditionTrue, "1500m", "2Gi"),
+		cordoned,
+		newNodeWithResources("node-4", corev1.ConditionFalse, "8", "8Gi"),
+	}
+
+	tests := []struct {
+		name          string
+		capacityNodes string
+		expected      map[string]int64
+	}{
+		{
+			name:          "ready",
+			capacityNodes: ClusterCapacityNodesReady,
+			expected: map[string]int64{
+				"k8s.cluster.capacity_cpu":       7500,
+				"k8s.cluster.allocatable_cpu":    1500,
+				"k8s.cluster.capacity_memory":    7 << 30,
+				"k8s.cluster.allocatable_memory": 7 << 30,
+			},
+		},
+		{
+			name:          "schedulable",
+			capacityNodes: ClusterCapacityNodesSchedulable,
+			expected: map[string]int64{
+				"k8s.cluster.capacity_cpu":       3500,
+				"k8s.cluster.allocatable_cpu":    1000,
+				"k8s.cluster.capacity_memory":    3 << 30,
+				"k8s.cluster.allocatable_memory": 3 << 30,
+			},
+		},
+		{
+			name:          "all",
+			capacityNodes: ClusterCapacityNodesAll,
+			expected: map[string]int64{
+				"k8s.cluster.capacity_cpu":       15500,
+				"k8s.cluster.allocatable_cpu":    2000,
+				"k8s.cluster.capacity_memory":    15 << 30,
+				"k8s.cluster.allocatable_memory": 15 << 30,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHarness(t, nil, WithClusterCapacityNodes(tt.capacityNodes))
+			for _, node := range nodes {
+				h.seed(node)
+			}
+			for name, value := range tt.expected {
+				h.requireInt64Value(name, map[string]string{}, value)
+			}
+		})
+	}
+}
+
+func TestClusterCapacityMetricsUnits(t *testing.T) {
+	h := newTestHarness(t, nil, WithUnits(map[string]string{"cpu": "cores"}))
+	h.seed(newNodeWithResources("node-1", corev1.ConditionTrue, "2", "1Gi"))
+
+	m := h.requireMetric("k8s.cluster.capacity_cpu", nil)
+	require.Equal(t, "{cores}", m.MetricDescriptor.Unit)
+	require.Equal(t, 2.0, m.Timeseries[0].Points[0].GetDoubleValue())
+}
+
+func newPendingPod(name, nodeName string, conds ...corev1.PodCondition) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "test-namespace", UID: types.UID(name + "-uid")},
+		Spec:       corev1.PodSpec{NodeName: nodeName},
+		Status:     corev1.PodStatus{Phase: corev1.PodPending, Conditions: conds},
+	}
+}
+
+func unschedulable(message string) corev1.PodCondition {
+	return corev1.PodCondition{
+		Type:    corev1.PodScheduled,
+		Status:  corev1.ConditionFalse,
+		Reason:  corev1.PodReasonUnschedulable,
+		Message: message,
+	}
+}
+
+func TestClusterPendingPodsMetric(t *testing.T) {
+	h := newTestHarness(t, nil)
+	running := newPendingPod("running", "node-1")
+	running.Status.Phase = corev1.PodRunning
+	h.seed(
+		running,
+		newPendingPod("pulling", "node-1", corev1.PodCondition{Type: corev1.PodScheduled, Status: corev1.ConditionTrue}),
+		newPendingPod("new", ""),
+		newPendingPod("cpu-1", "", unschedulable("0/3 nodes are available: 3 Insufficient cpu.")),
+		newPendingPod("cpu-2", "", unschedulable("0/3 nodes are available: 1 Insufficient cpu, 2 Insufficient memory.")),
+		newPendingPod("memory", "", unschedulable("0/3 nodes are available: 3 Insufficient memory.")),
+		newPendingPod("gpu", "", unschedulable("0/3 nodes are available: 3 Insufficient nvidia.com/gpu.")),
+		newPendingPod("taint", "", unschedulable(
+			"0/2 nodes are available: 2 node(s) had taint {dedicated: infra}, that the pod didn't tolerate.")),
+		newPendingPod("selector", "", unschedulable("0/2 nodes are available: 2 node(s) didn't match node selector.")),
+		newPendingPod("pvc", "", unschedulable(`persistentvolumeclaim "data" not found`)),
+		newPendingPod("zone", "", unschedulable("0/2 nodes are available: 2 node(s) had volume node affinity conflict.")),
+		newPendingPod("anti-affinity", "", unschedulable(
+			"0/2 nodes are available: 2 node(s) didn't match pod anti-affinity rules.")),
+		newPendingPod("error", "", corev1.PodCondition{
+			Type:    corev1.PodScheduled,
+			Status:  corev1.ConditionFalse,
+			Reason:  "SchedulerError",
+			Message: "binding rejected",
+		}),
+		newPendingPod("gated", "", corev1.PodCondition{
+			Type:    corev1.PodScheduled,
+			Status:  corev1.ConditionFalse,
+			Reason:  "SchedulingGated",
+			Message: "Scheduling is blocked due to non-empty scheduling gates",
+		}),
+	)
+
+	m := h.requireMetric("k8s.cluster.pending_pods", map[string]string{})
+	require.Equal(t, "reason", m.MetricDescriptor.LabelKeys[0].Key)
+	actual := map[string]int64{}
+	for _, ts := range m.Timeseries {
+		actual[ts.LabelValues[0].Value] = ts.Points[0].GetInt64Value()
+	}
+	require.Equal(t, map[string]int64{
+		"scheduled":              1,
+		"not_scheduled":          1,
+		"scheduling_gated":       1,
+		"insufficient_cpu":       2,
+		"insufficient_memory":    1,
+		"insufficient_resources": 1,
+		"volume":                 2,
+		"node_affinity":          1,
+		"pod_affinity":           1,
+		"taint":                  1,
+		"topology_spread":        0,
+		"other":                  1,
+	}, actual)
+}
+
+func TestImageRegistry(t *testing.T) {
+	for image, registry := range map[string]string{
+		"nginx":                                 "docker.io",
+		"nginx:1.19":                            "docker.io",
+		"library/nginx@sha256:0123456789abcdef": "docker.io",
+		"docker.io/library/nginx":               "docker.io",
+		"index.docker.io/library/nginx":         "docker.io",
+		"gcr.io/project/app:1.0":                "gcr.io",
+		"123456789012.dkr.ecr.us-east-1.amazonaws.com/app": "123456789012.dkr.ecr.us-east-1.amazonaws.com",
+		"localhost/app":                "localhost",
+		"registry.local:5000/team/app": "registry.local:5000",
+	} {
+		require.Equal(t, registry, imageRegistry(image), image)
+	}
+}
+
+func TestClusterContainersByRegistryMetric(t *testing.T) {
+	newPod := func(name string, images map[string]bool) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "test-namespace", UID: types.UID(name + "-uid")},
+		}
+		for image, running := range images {
+			c := corev1.Container{Name: image, Image: image}
+			pod.Spec.Containers = append(pod.Spec.Containers, c)
+			cs := corev1.ContainerStatus{Name: image, Image: "resolved/" + image}
+			if running {
+				cs.State.Running = &corev1.ContainerStateRunning{}
+			} else {
+				cs.State.Waiting = &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}
+			}
+			pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, cs)
+		}
+		return pod
+	}
+
+	h := newTestHarness(t, nil)
+	h.requireNoMetric("k8s.cluster.containers_by_registry", nil)
+
+	h.seed(
+		newPod("pod-1", map[string]bool{"nginx:1.19": true, "gcr.io/project/sidecar:1.0": true}),
+		newPod("pod-2", map[string]bool{"gcr.io/project/app:1.0": true, "quay.io/org/app:2.0": false}),
+	)
+
+	m := h.requireMetric("k8s.cluster.containers_by_registry", map[string]string{})
+	require.Equal(t, "registry", m.MetricDescriptor.LabelKeys[0].Key)
+	actual := map[string]int64{}
+	for _, ts := range m.Timeseries {
+		actual[ts.LabelValues[0].Value] = ts.Points[0].GetInt64Value()
+	}
+	require.Equal(t, map[string]int64{"docker.io": 1, "gcr.io": 2}, actual)
+}
+
+func TestClusterLastCollectionTimestampMetric(t *testing.T) {
+	// Reported without any watched object.
+	h := newTestHarness(t, nil)
+	for i := 0; i < 3; i++ {
+		mds := h.dc.CollectSelfMetricData(h.now)
+		require.Equal(t, 1, len(mds))
+		testutils.AssertResource(t, mds[0].Resource, k8sType, map[string]string{})
+		testutils.AssertMetrics(t, mds[0].Metrics[0], "k8s.cluster.last_collection_timestamp",
+			metricspb.MetricDescriptor_GAUGE_INT64, h.now.Unix())
+		require.Equal(t, h.now.Unix(), mds[0].Metrics[0].Timeseries[0].Points[0].Timestamp.Seconds)
+		h.advance(30 * time.Second)
+	}
+
+	h = newTestHarness(t, nil, WithDisabledMetrics([]string{
+		"k8s.cluster.last_collection_timestamp", "k8s.cluster.scrape_heartbeat",
+	}))
+	require.Empty(t, h.dc.CollectSelfMetricData(h.now))
+}
+
+func TestClusterScrapeHeartbeatMetric(t *testing.T) {
+	// Nothing is collected from the empty stores, but the heartbeat is
+	// reported on every collection.
+	h := newTestHarness(t, nil)
+	h.seed(&corev1.Pod{ObjectMeta: v1.ObjectMeta{Name: "pod", Namespace: "test-namespace", UID: "pod-uid"}})
+	h.remove(&corev1.Pod{ObjectMeta: v1.ObjectMeta{Name: "pod", Namespace: "test-namespace", UID: "pod-uid"}})
+	h.requireNoMetric("k8s.pod.phase", nil)
+
+	for i := 0; i < 2; i++ {
+		mds := h.dc.CollectSelfMetricData(h.now)
+		require.Equal(t, 1, len(mds))
+		testutils.AssertMetrics(t, mds[0].Metrics[1], "k8s.cluster.scrape_heartbeat",
+			metricspb.MetricDescriptor_GAUGE_INT64, 1)
+		h.advance(30 * time.Second)
+	}
+}
+
+func TestClusterPodsByPriorityClassMetric(t *testing.T) {
+	h := newTestHarness(t, nil)
+	h.requireNoMetric("k8s.cluster.pods_by_priority_class", nil)
+
+	newPod := func(name, class string, phase corev1.PodPhase) *corev1.Pod {
+		pod := newPendingPod(name, "node-1")
+		pod.Spec.PriorityClassName = class
+		pod.Status.Phase = phase
+		return pod
+	}
+	h.seed(
+		newPod("critical-1", "system-cluster-critical", corev1.PodRunning),
+		newPod("critical-2", "system-cluster-critical", corev1.PodPending),
+		newPod("batch", "low-priority", corev1.PodRunning),
+		newPod("default", "", corev1.PodRunning),
+		// Terminated pods are not counted.
+		newPod("done", "low-priority", corev1.PodSucceeded),
+	)
+
+	m := h.requireMetric("k8s.cluster.pods_by_priority_class", map[string]string{})
+	require.Equal(t, "priority_class", m.MetricDescriptor.LabelKeys[0].Key)
+	actual := map[string]int64{}
+	for _, ts := range m.Timeseries {
+		actual[ts.LabelValues[0].Value] = ts.Points[0].GetInt64Value()
+	}
+	require.Equal(t, map[string]int64{
+		"system-cluster-critical": 2,
+		"low-priority":            1,
+		"none":                    1,
+	}, actual)
+}
+
+func TestClusterContainerRestartsMetrics(t *testing.T) {
+	h := newTestHarness(t, nil)
+	h.requireNoMetric("k8s.cluster.total_container_restarts", nil)
+
+	newPod := func(name, namespace string, restarts ...int32) *corev1.Pod {
+		pod := newPendingPod(name, "node-1")
+		pod.Namespace = namespace
+		for i, r := range restarts {
+			pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, corev1.ContainerStatus{
+				Name:         fmt.Sprint("container-", i),
+				RestartCount: r,
+			})
+		}
+		return pod
+	}
+	quiet := &corev1.Namespace{ObjectMeta: v1.ObjectMeta{Name: "quiet", UID: "quiet-uid"}}
+	h.seed(
+		newPod("web-1", "frontend", 3, 1),
+		newPod("web-2", "frontend", 2),
+		newPod("db", "backend", 10),
+		newPod("cache", "backend"),
+		// Namespaces without pods are reported with no restarts.
+		quiet,
+	)
+
+	h.requireInt64Value("k8s.cluster.total_container_restarts", nil, 16)
+	for namespace, restarts := range map[string]int64{"frontend": 6, "backend": 10, "quiet": 0} {
+		h.requireInt64Value("k8s.namespace.container_restarts",
+			map[string]string{"k8s.namespace.name": namespace}, restarts)
+	}
+}
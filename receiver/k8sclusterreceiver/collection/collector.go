@@ -15,21 +15,34 @@
 package collection
 
 import (
+	"errors"
 	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"go.opentelemetry.io/collector/consumer/consumerdata"
 	"go.uber.org/zap"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	"k8s.io/api/autoscaling/v2beta1"
+	"k8s.io/api/autoscaling/v2beta2"
 	batchv1 "k8s.io/api/batch/v1"
 	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/cache"
 
 	metadata "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/experimentalmetricmetadata"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/observability"
 )
 
 // TODO: Consider moving some of these constants to
@@ -55,14 +68,31 @@ const (
 	k8sKeyHPAName                   = "k8s.hpa.name"
 	k8sKeyResourceQuotaName         = "k8s.resourcequota.name"
 
+	// Resource labels keys for the scopes of ResourceQuotas.
+	k8sKeyResourceQuotaScopes          = "k8s.resourcequota.scopes"
+	k8sKeyResourceQuotaPriorityClasses = "k8s.resourcequota.priority_classes"
+
 	// Kubernetes resource kinds
-	k8sKindCronJob               = "CronJob"
-	k8sKindDaemonSet             = "DaemonSet"
-	k8sKindDeployment            = "Deployment"
-	k8sKindJob                   = "Job"
-	k8sKindReplicationController = "ReplicationController"
-	k8sKindReplicaSet            = "ReplicaSet"
-	k8sStatefulSet               = "StatefulSet"
+	k8sKindCronJob                        = "CronJob"
+	k8sKindDaemonSet                      = "DaemonSet"
+	k8sKindDeployment                     = "Deployment"
+	k8sKindHPA                            = "HorizontalPodAutoscaler"
+	k8sKindIngress                        = "Ingress"
+	k8sKindJob                            = "Job"
+	k8sKindMutatingWebhookConfiguration   = "MutatingWebhookConfiguration"
+	k8sKindNamespace                      = "Namespace"
+	k8sKindNode                           = "Node"
+	k8sKindPersistentVolume               = "PersistentVolume"
+	k8sKindPersistentVolumeClaim          = "PersistentVolumeClaim"
+	k8sKindPod                            = "Pod"
+	k8sKindPodDisruptionBudget            = "PodDisruptionBudget"
+	k8sKindPriorityClass                  = "PriorityClass"
+	k8sKindReplicationController          = "ReplicationController"
+	k8sKindReplicaSet                     = "ReplicaSet"
+	k8sKindResourceQuota                  = "ResourceQuota"
+	k8sKindService                        = "Service"
+	k8sKindValidatingWebhookConfiguration = "ValidatingWebhookConfiguration"
+	k8sStatefulSet                        = "StatefulSet"
 )
 
 // DataCollector wraps around a metricsStore and a metadaStore exposing
@@ -73,27 +103,217 @@ type DataCollector struct {
 	logger                 *zap.Logger
 	metricsStore           *metricsStore
 	metadataStore          *metadataStore
+	crdStore               *crdStore
+	customResourceStore    *customResourceStore
+	openShiftStore         *openShiftStore
 	nodeConditionsToReport []string
+	// units in which resource quantities are reported.
+	units units
+	// aggregatePodsByOwner reports metrics of pods managed by a workload
+	// per workload rather than per pod.
+	aggregatePodsByOwner bool
+	// includedResourceAttributes is the set of default keys of the resource
+	// labels that are emitted. All are emitted if empty.
+	includedResourceAttributes map[string]bool
+	// resourceAttributeKeys maps resource label keys to the keys they are
+	// reported with.
+	resourceAttributeKeys map[string]string
+	// attributeKeyStyle is the AttributeKeyStyle of resource label keys
+	// that are not in resourceAttributeKeys.
+	attributeKeyStyle string
+	// samplingRules are the sampling rules of kinds of which only a subset
+	// of the objects is collected, keyed by lower-cased kind.
+	samplingRules map[string]SamplingRule
+	// excludeManagedBy selects the managed objects that are not collected,
+	// if any.
+	excludeManagedBy *ManagedByRule
+	// excludedPodPhases are the phases of the pods that are not collected.
+	excludedPodPhases map[corev1.PodPhase]bool
+	// expressionMetrics are the metrics computed from the fields of objects,
+	// keyed by lower-cased kind.
+	expressionMetrics map[string][]expressionMetric
+	// maxAttributeValueLength is the length in bytes beyond which attribute
+	// values are truncated, or 0 if they are not.
+	maxAttributeValueLength int
+	// datapointAttributeKeys are the keys of resource labels placed on
+	// datapoints, in addition to or instead of the resource depending on
+	// datapointAttributesMode.
+	datapointAttributeKeys  []string
+	datapointAttributesMode string
+	// fieldExtractionRules describe the labels and annotations of objects
+	// extracted to resource labels of their metrics.
+	fieldExtractionRules FieldExtractionRules
+	// reportAntiAffinityViolations reports whether pods with required pod
+	// anti-affinity share their node with a pod they are anti-affine to.
+	reportAntiAffinityViolations bool
+	// reportSecurityContext reports the security context flags of
+	// containers.
+	reportSecurityContext bool
+	// reportContainerStatusReasons reports the reasons containers are
+	// waiting or terminated.
+	reportContainerStatusReasons bool
+	// reportTransitionTimes reports the time at which objects entered the
+	// state reported by their state metrics.
+	reportTransitionTimes bool
+	// reportObjectKind reports whether resources carry the kind of the object
+	// they were built for as k8s.object.kind.
+	reportObjectKind bool
+	// reportObjectVersion reports the resource version and generation of the
+	// object resources were built for as resource labels.
+	reportObjectVersion bool
+	// reportObjectCountDelta reports the change of the number of objects
+	// of each kind between collections, as tracked by objectCounts.
+	reportObjectCountDelta bool
+	objectCounts           *objectCounts
+	// reportContainerRestartRate reports the number of restarts of each
+	// container between collections, as tracked by restartCounts.
+	reportContainerRestartRate bool
+	restartCounts              *restartCounts
+	// reportNodeResources reports the capacity and allocatable amount of
+	// every resource of nodes.
+	reportNodeResources bool
+	// reportInformerCache reports the number and estimated size of the
+	// objects in the informer caches, as set up in objectCounts.
+	reportInformerCache bool
+	// clusterName is set as the k8s.cluster.name resource label of metrics
+	// without one, if not empty.
+	clusterName string
+	// resourceQuotaThreshold is the utilization ratio above which
+	// ResourceQuotas are counted by k8s.cluster.resource_quotas_over_threshold.
+	resourceQuotaThreshold float64
+	// skipIrrelevantUpdates reports whether metrics of updated objects are
+	// only rebuilt if fields they are built from changed, as tracked by
+	// relevantHashes.
+	skipIrrelevantUpdates bool
+	relevantHashes        *relevantHashes
+	// clusterCapacityNodes selects the nodes included in the cluster
+	// capacity metrics.
+	clusterCapacityNodes string
+	// labelInfoKinds are the lower-cased kinds for which a labels info
+	// metric is reported.
+	labelInfoKinds map[string]bool
+	// nodeTypeLabels are the node labels identifying spot nodes and the
+	// instance type of nodes.
+	nodeTypeLabels nodeTypeLabels
+	// disabledMetrics is the set of names of metrics that are not emitted.
+	disabledMetrics map[string]bool
+	// reportImageMetadata reports the digest and registry of the images of
+	// containers, along with the attributes resolved by imageEnricher, if
+	// set.
+	reportImageMetadata bool
+	imageEnricher       ImageEnricher
+	// collectionSchedule tracks the kinds collected at intervals of their
+	// own, if any.
+	collectionSchedule *collectionSchedule
+	// metricOverrides change how metrics are emitted, keyed by their original
+	// name.
+	metricOverrides map[string]metricOverride
+	// gaugesAsDouble reports integer gauges as DOUBLE gauges.
+	gaugesAsDouble bool
+	// throttledKinds tracks kinds for which the object limit has already
+	// been logged.
+	throttledKinds sync.Map
+	// readyTransitions counts the transitions of the Ready condition of
+	// nodes.
+	readyTransitions *readyTransitions
+	// informerRelists counts the relists of informers.
+	informerRelists *informerRelists
+	// resourceVersionSources return the last resource versions observed
+	// by informers.
+	resourceVersionSources *resourceVersionSources
 }
 
 // newDataCollector returns a DataCollector.
-func NewDataCollector(logger *zap.Logger, nodeConditionsToReport []string) *DataCollector {
-	return &DataCollector{
+func NewDataCollector(logger *zap.Logger, nodeConditionsToReport []string, opts ...Option) *DataCollector {
+	dc := &DataCollector{
 		logger: logger,
 		metricsStore: &metricsStore{
 			metricsCache: map[types.UID][]consumerdata.MetricsData{},
 		},
 		metadataStore:          &metadataStore{},
+		crdStore:               &crdStore{instances: map[string]cache.Store{}},
+		customResourceStore:    newCustomResourceStore(nil),
+		openShiftStore:         &openShiftStore{},
 		nodeConditionsToReport: nodeConditionsToReport,
+		readyTransitions:       &readyTransitions{nodes: map[types.UID]*nodeReadyState{}},
+		informerRelists:        &informerRelists{kinds: map[string]int64{}},
+		resourceVersionSources: &resourceVersionSources{kinds: map[string]func() string{}},
+		objectCounts:           &objectCounts{stores: map[string]cache.Store{}, last: map[string]int64{}},
+		restartCounts:          &restartCounts{last: map[restartKey]int32{}},
+		resourceQuotaThreshold: DefaultResourceQuotaThreshold,
+		relevantHashes:         &relevantHashes{hashes: map[types.UID]uint64{}},
+	}
+
+	for _, opt := range opts {
+		opt(dc)
 	}
+
+	return dc
 }
 
 // SetupMetadataStore initializes a metadata store for the kubernetes object.
 func (dc *DataCollector) SetupMetadataStore(o runtime.Object, store cache.Store) {
 	dc.metadataStore.setupStore(o, store)
+	dc.objectCounts.setupStore(reflect.TypeOf(o).Elem().Name(), store)
+}
+
+// SetupCRDStore sets the informer cache of CustomResourceDefinitions.
+func (dc *DataCollector) SetupCRDStore(store cache.Store) {
+	dc.crdStore.Lock()
+	defer dc.crdStore.Unlock()
+	dc.crdStore.crds = store
+}
+
+// SetupCustomResourceStore sets the informer cache of the custom resources
+// of a CustomResourceDefinition, enabling instance counts for it.
+func (dc *DataCollector) SetupCustomResourceStore(crdName string, store cache.Store) {
+	dc.crdStore.Lock()
+	defer dc.crdStore.Unlock()
+	dc.crdStore.instances[crdName] = store
+}
+
+// RemoveCustomResourceStore stops counting the custom resources of a
+// CustomResourceDefinition.
+func (dc *DataCollector) RemoveCustomResourceStore(crdName string) {
+	dc.crdStore.Lock()
+	defer dc.crdStore.Unlock()
+	delete(dc.crdStore.instances, crdName)
+}
+
+// SetupCustomResourceMetricsStore sets the informer cache of the custom
+// resources of the given group, version and resource, for which metrics are
+// reported as configured with WithCustomResourceMetrics.
+func (dc *DataCollector) SetupCustomResourceMetricsStore(gvr schema.GroupVersionResource, store cache.Store) {
+	dc.customResourceStore.Lock()
+	defer dc.customResourceStore.Unlock()
+	for _, source := range dc.customResourceStore.sources {
+		if source.metrics.GVR == gvr {
+			source.store = store
+		}
+	}
+}
+
+// SetupOpenShiftStore sets the informer cache of the OpenShift resources of
+// the given group, version and resource, ClusterResourceQuotaGVR or
+// DeploymentConfigGVR, for which metrics are reported.
+func (dc *DataCollector) SetupOpenShiftStore(gvr schema.GroupVersionResource, store cache.Store) {
+	dc.openShiftStore.Lock()
+	defer dc.openShiftStore.Unlock()
+	switch gvr {
+	case ClusterResourceQuotaGVR:
+		dc.openShiftStore.clusterResourceQuotas = store
+	case DeploymentConfigGVR:
+		dc.openShiftStore.deploymentConfigs = store
+	}
 }
 
 func (dc *DataCollector) RemoveFromMetricsStore(obj interface{}) {
+	if node, ok := obj.(*corev1.Node); ok {
+		dc.readyTransitions.forget(node.UID)
+	}
+	if key, err := dc.metricsStore.getKeyForObject(obj.(runtime.Object)); err == nil {
+		dc.relevantHashes.forget(key)
+	}
 	if err := dc.metricsStore.remove(obj.(runtime.Object)); err != nil {
 		dc.logger.Error(
 			"failed to remove from metric cache",
@@ -104,7 +324,34 @@ func (dc *DataCollector) RemoveFromMetricsStore(obj interface{}) {
 }
 
 func (dc *DataCollector) UpdateMetricsStore(obj interface{}, rm []*resourceMetrics) {
+	rm = removeDisabledMetrics(rm, dc.disabledMetrics)
+	if dc.reportObjectKind {
+		addObjectKindLabels(rm)
+	}
+	setClusterNameLabels(rm, dc.clusterName)
+	filterResourceLabels(rm, dc.includedResourceAttributes)
+	if dc.reportObjectVersion {
+		addObjectVersionLabels(obj, rm)
+	}
+	renameResourceLabels(rm, dc.resourceAttributeKeys, dc.attributeKeyStyle)
+	placeDatapointAttributes(rm, dc.datapointAttributeKeys, dc.datapointAttributesMode)
+	truncateAttributeValues(rm, dc.maxAttributeValueLength)
+	applyMetricOverrides(rm, dc.metricOverrides)
 	if err := dc.metricsStore.update(obj.(runtime.Object), rm); err != nil {
+		if err == errObjectLimitReached {
+			dc.recordThrottledObject(getObjectKind(obj))
+			return
+		}
+		var malformed *malformedMetricsError
+		if errors.As(err, &malformed) {
+			observability.RecordMetricsRejected(getObjectKind(obj), int64(len(malformed.names)))
+			dc.logger.Warn(
+				"Malformed metrics of object not cached",
+				zap.String("kind", getObjectKind(obj)),
+				zap.Strings("metrics", malformed.names),
+			)
+			return
+		}
 		dc.logger.Error(
 			"failed to update metric cache",
 			zap.String("obj", reflect.TypeOf(obj).String()),
@@ -113,19 +360,237 @@ func (dc *DataCollector) UpdateMetricsStore(obj interface{}, rm []*resourceMetri
 	}
 }
 
+// recordThrottledObject records an object that was not cached due to the
+// object limit of its kind. The limit being reached is logged once per kind.
+func (dc *DataCollector) recordThrottledObject(kind string) {
+	observability.RecordObjectThrottled(kind)
+	if _, logged := dc.throttledKinds.LoadOrStore(kind, true); logged {
+		return
+	}
+	dc.logger.Warn(
+		"Object limit reached, new objects of this kind will not be collected",
+		zap.String("kind", kind),
+		zap.Int("max_objects", dc.metricsStore.maxObjects[strings.ToLower(kind)]),
+	)
+}
+
 func (dc *DataCollector) CollectMetricData(currentTime time.Time) []consumerdata.MetricsData {
-	return dc.metricsStore.getMetricData(currentTime)
+	var out []consumerdata.MetricsData
+	dc.FlushMetricData(currentTime, 0, func(mds []consumerdata.MetricsData) {
+		out = append(out, mds...)
+	})
+	return out
+}
+
+// FlushMetricData collects the same metrics as CollectMetricData, but passes
+// the cached metrics to flush in batches as soon as flushThreshold metrics
+// have been collected since the previous batch, while iterating over the
+// cache, rather than building them all at once. The metrics computed across
+// objects are passed last, in a batch of their own. With a flushThreshold of
+// 0, the cached metrics are passed in a single batch. The cache is not locked
+// while flush runs, so that updates of the cache do not wait for it.
+func (dc *DataCollector) FlushMetricData(
+	currentTime time.Time, flushThreshold int, flush func([]consumerdata.MetricsData)) {
+	due := dc.collectionSchedule.due(currentTime)
+	dc.metricsStore.flushMetricData(currentTime, flushThreshold, due, func(mds []consumerdata.MetricsData) {
+		if dc.gaugesAsDouble {
+			for _, md := range mds {
+				convertGaugesToDouble(md.Metrics)
+			}
+		}
+		flush(mds)
+	})
+	recordInformerCacheObjects(dc.objectCounts)
+	if !due("") {
+		return
+	}
+	if out := dc.collectCrossObjectMetricData(currentTime); len(out) > 0 {
+		flush(out)
+	}
+}
+
+// CollectPeriodicMetricData returns the metrics of CollectMetricData that do
+// not come from the cache of the metrics of each object, for when only the
+// metrics of changed objects are collected otherwise: the pending deletion
+// markers and the metrics computed across objects. The entries of deleted
+// objects whose grace period has elapsed are evicted first.
+func (dc *DataCollector) CollectPeriodicMetricData(currentTime time.Time) []consumerdata.MetricsData {
+	out := dc.CollectDeletionMarkerData(currentTime)
+	recordInformerCacheObjects(dc.objectCounts)
+	return append(out, dc.collectCrossObjectMetricData(currentTime)...)
+}
+
+// CollectDeletionMarkerData returns the pending deletion markers, which are
+// only collected once, after evicting the entries of deleted objects whose
+// grace period has elapsed.
+func (dc *DataCollector) CollectDeletionMarkerData(currentTime time.Time) []consumerdata.MetricsData {
+	out := dc.metricsStore.collectDeletionMarkers(currentTime)
+	if dc.gaugesAsDouble {
+		for _, md := range out {
+			convertGaugesToDouble(md.Metrics)
+		}
+	}
+	return out
+}
+
+// collectCrossObjectMetricData returns the metrics computed across objects.
+// They are not cached since they depend on the state of the informer caches
+// at the time of collection.
+func (dc *DataCollector) collectCrossObjectMetricData(currentTime time.Time) []consumerdata.MetricsData {
+	rms := dc.crdStore.getMetricsForCRDs()
+	rms = append(rms, dc.customResourceStore.getMetricsForCustomResources()...)
+	rms = append(rms, dc.openShiftStore.getMetricsForOpenShift()...)
+	rms = append(rms, getMetricsForServiceEndpoints(dc.metadataStore)...)
+	rms = append(rms, getReadyEndpointMetricsForServices(dc.metadataStore)...)
+	rms = append(rms, getPodNotReadyMetricsForDaemonSets(dc.metadataStore)...)
+	rms = append(rms, getSchedulingMetricsForWorkloads(dc.metadataStore)...)
+	rms = append(rms, getMaxUnreadyPodAgeMetricsForWorkloads(dc.metadataStore, currentTime)...)
+	rms = append(rms, getImageVersionMetricsForWorkloads(dc.metadataStore)...)
+	rms = append(rms, getConfigDataMetricsForNamespaces(dc.metadataStore)...)
+	rms = append(rms, getEvictionMetricsForNodes(dc.metadataStore)...)
+	rms = append(rms, getAllocationMetricsForNodes(dc.metadataStore, dc.units)...)
+	rms = append(rms, getRenewAgeMetricsForLeases(dc.metadataStore.leases, currentTime)...)
+	rms = append(rms, getUnboundPVCMetricsForStatefulSets(dc.metadataStore)...)
+	rms = append(rms, getRolloutMetricsForDeployments(dc.metadataStore.deployments, currentTime)...)
+	rms = append(rms, getCapacityMetricsForCluster(dc.metadataStore, dc.units, dc.clusterCapacityNodes)...)
+	rms = append(rms, getPendingPodsMetricsForCluster(dc.metadataStore)...)
+	rms = append(rms, getContainersByRegistryMetricsForCluster(dc.metadataStore)...)
+	rms = append(rms, getPodsByPriorityClassMetricsForCluster(dc.metadataStore)...)
+	rms = append(rms, getContainerRestartMetricsForCluster(dc.metadataStore)...)
+	rms = append(rms, getRBACMetricsForCluster(dc.metadataStore)...)
+	rms = append(rms, getInformerRelistsMetricsForCluster(dc.informerRelists)...)
+	rms = append(rms, getLastResourceVersionMetricsForCluster(dc.resourceVersionSources)...)
+	rms = append(rms, dc.metricsStore.getOldestCachedObjectMetricsForCluster(currentTime)...)
+	rms = append(rms, getResourceQuotaThresholdMetricsForCluster(dc.metadataStore, dc.resourceQuotaThreshold)...)
+	rms = append(rms, getTerminationMetricsForPods(
+		dc.metadataStore.pods, currentTime, dc.isPodReported, dc.fieldExtractionRules)...)
+	if dc.reportObjectCountDelta {
+		rms = append(rms, getObjectCountDeltaMetricsForCluster(dc.objectCounts)...)
+	}
+	if dc.reportInformerCache {
+		rms = append(rms, getInformerCacheMetricsForCluster(dc.objectCounts)...)
+	}
+	if dc.reportContainerRestartRate {
+		rms = append(rms, getRestartRateMetricsForContainers(
+			dc.restartCounts, dc.metadataStore.pods, dc.isPodReported, dc.fieldExtractionRules)...)
+	}
+	if dc.reportAntiAffinityViolations {
+		rms = append(rms, getAntiAffinityViolationMetricsForPods(
+			dc.metadataStore.pods, dc.isPodReported, dc.fieldExtractionRules)...)
+	}
+	if dc.aggregatePodsByOwner {
+		rms = append(rms, getMetricsForWorkloads(dc.metadataStore)...)
+	}
+	return dc.toMetricData(rms, currentTime)
+}
+
+// CollectSelfMetricData returns the metrics about the collection itself
+// rather than about the state of the cluster, e.g. the time of the
+// collection. They are expected to be collected along with every
+// CollectMetricData, so that a receiver that stopped collecting can be told
+// apart from a cluster without objects.
+func (dc *DataCollector) CollectSelfMetricData(currentTime time.Time) []consumerdata.MetricsData {
+	return dc.toMetricData(getSelfMetricsForCluster(currentTime), currentTime)
+}
+
+// toMetricData applies the configured transformations to metrics computed at
+// collection time and returns them as metrics data stamped with currentTime.
+func (dc *DataCollector) toMetricData(rms []*resourceMetrics, currentTime time.Time) []consumerdata.MetricsData {
+	rms = removeDisabledMetrics(rms, dc.disabledMetrics)
+	if dc.reportObjectKind {
+		addObjectKindLabels(rms)
+	}
+	setClusterNameLabels(rms, dc.clusterName)
+	filterResourceLabels(rms, dc.includedResourceAttributes)
+	renameResourceLabels(rms, dc.resourceAttributeKeys, dc.attributeKeyStyle)
+	placeDatapointAttributes(rms, dc.datapointAttributeKeys, dc.datapointAttributesMode)
+	truncateAttributeValues(rms, dc.maxAttributeValueLength)
+	applyMetricOverrides(rms, dc.metricOverrides)
+
+	out := toMetricsData(rms, dc.metricsStore.node)
+	for _, md := range out {
+		applyCurrentTime(md.Metrics, currentTime)
+		if dc.gaugesAsDouble {
+			convertGaugesToDouble(md.Metrics)
+		}
+	}
+	return out
+}
+
+// CollectMetricDataForObjects returns the cached metrics of the given objects
+// only. Metrics computed across objects are not included. Objects without
+// cached metrics, e.g. since they have been removed, are skipped, as are
+// objects whose metrics have not changed if WithSkipUnchanged is enabled.
+func (dc *DataCollector) CollectMetricDataForObjects(objs []runtime.Object, currentTime time.Time) []consumerdata.MetricsData {
+	out := dc.metricsStore.getMetricDataForObjects(objs, currentTime)
+	if dc.gaugesAsDouble {
+		for _, md := range out {
+			convertGaugesToDouble(md.Metrics)
+		}
+	}
+	return out
 }
 
 // SyncMetrics updates the metric store with latest metrics from the kubernetes object.
 func (dc *DataCollector) SyncMetrics(obj interface{}) {
+	if !dc.skipIrrelevantUpdates {
+		dc.syncMetrics(obj)
+		return
+	}
+
+	o, ok := obj.(runtime.Object)
+	if !ok {
+		return
+	}
+	key, err := dc.metricsStore.getKeyForObject(o)
+	if err != nil {
+		dc.syncMetrics(obj)
+		return
+	}
+	hash, ok := dc.relevantFieldsHash(o)
+	if ok && dc.relevantHashes.unchanged(key, hash) && dc.metricsStore.isCached(key) {
+		return
+	}
+	dc.syncMetrics(obj)
+	if ok && dc.metricsStore.isCached(key) {
+		dc.relevantHashes.set(key, hash)
+	}
+}
+
+func (dc *DataCollector) syncMetrics(obj interface{}) {
 	var rm []*resourceMetrics
 
 	switch o := obj.(type) {
 	case *corev1.Pod:
-		rm = getMetricsForPod(o)
+		if !dc.isPodReported(o) {
+			// Metrics of the pod are reported with those of its workload. The
+			// pod is removed in case it was cached before it got an owner.
+			dc.RemoveFromMetricsStore(o)
+			return
+		}
+		rm = getMetricsForPod(o, dc.units, dc.fieldExtractionRules)
+		addOwnerLabels(o, rm, dc.metadataStore)
+		if dc.reportSecurityContext {
+			addSecurityContextMetrics(o, rm)
+		}
+		if dc.reportContainerStatusReasons {
+			addStatusReasonMetrics(o, rm)
+		}
+		if dc.reportImageMetadata {
+			addImageLabels(o, rm, dc.imageEnricher)
+		}
+		if dc.reportTransitionTimes {
+			addPodTransitionTimeLabels(o, rm)
+		}
 	case *corev1.Node:
-		rm = getMetricsForNode(o, dc.nodeConditionsToReport)
+		rm = getMetricsForNode(o, dc.nodeConditionsToReport, dc.nodeTypeLabels)
+		rm[0].metrics = append(rm[0].metrics, getReadyTransitionsMetric(dc.readyTransitions.observe(o)))
+		if dc.reportNodeResources {
+			rm[0].metrics = append(rm[0].metrics, getResourceMetricsForNode(o)...)
+		}
+		if dc.reportTransitionTimes {
+			addNodeTransitionTimeLabels(o, rm, dc.nodeConditionsToReport)
+		}
 	case *corev1.Namespace:
 		rm = getMetricsForNamespace(o)
 	case *corev1.ReplicationController:
@@ -146,6 +611,26 @@ func (dc *DataCollector) SyncMetrics(obj interface{}) {
 		rm = getMetricsForCronJob(o)
 	case *v2beta1.HorizontalPodAutoscaler:
 		rm = getMetricsForHPA(o)
+	case *autoscalingv1.HorizontalPodAutoscaler:
+		rm = getMetricsForHPA(hpaFromV1(o))
+	case *v2beta2.HorizontalPodAutoscaler:
+		rm = getMetricsForHPA(hpaFromV2beta2(o))
+	case *admissionregistrationv1.MutatingWebhookConfiguration:
+		rm = getMetricsForMutatingWebhookConfiguration(o)
+	case *admissionregistrationv1.ValidatingWebhookConfiguration:
+		rm = getMetricsForValidatingWebhookConfiguration(o)
+	case *corev1.Service:
+		rm = getMetricsForService(o)
+	case *networkingv1.Ingress:
+		rm = getMetricsForIngress(o)
+	case *policyv1beta1.PodDisruptionBudget:
+		rm = getMetricsForPodDisruptionBudget(o)
+	case *schedulingv1.PriorityClass:
+		rm = getMetricsForPriorityClass(o)
+	case *corev1.PersistentVolume:
+		rm = getMetricsForPersistentVolume(o)
+	case *corev1.PersistentVolumeClaim:
+		rm = getMetricsForPersistentVolumeClaim(o)
 	default:
 		return
 	}
@@ -154,9 +639,90 @@ func (dc *DataCollector) SyncMetrics(obj interface{}) {
 		return
 	}
 
+	kind := getObjectKind(obj)
+	if o, ok := obj.(v1.Object); ok && dc.labelInfoKinds[strings.ToLower(kind)] {
+		if m := getLabelInfoMetric(kind, o.GetLabels()); m != nil {
+			rm[0].metrics = append(rm[0].metrics, m)
+		}
+	}
+	rm[0].metrics = append(rm[0].metrics, dc.getExpressionMetrics(strings.ToLower(kind), obj)...)
+	addExtractedFields(kind, obj, rm, dc.fieldExtractionRules)
+
 	dc.UpdateMetricsStore(obj, rm)
 }
 
+// isPodReported returns false if metrics of the pod are reported with those
+// of its workload rather than individually.
+func (dc *DataCollector) isPodReported(pod *corev1.Pod) bool {
+	return !dc.aggregatePodsByOwner || v1.GetControllerOf(pod) == nil
+}
+
+// CachedKinds are the lower-cased kinds of the objects whose metrics are
+// cached, sorted, e.g. as accepted by WithMaxObjects.
+var CachedKinds = func() []string {
+	kinds := []string{
+		k8sKindCronJob, k8sKindDaemonSet, k8sKindDeployment, k8sKindHPA, k8sKindIngress, k8sKindJob,
+		k8sKindMutatingWebhookConfiguration, k8sKindNamespace, k8sKindNode, k8sKindPersistentVolume,
+		k8sKindPersistentVolumeClaim, k8sKindPod, k8sKindPodDisruptionBudget, k8sKindPriorityClass,
+		k8sKindReplicationController, k8sKindReplicaSet, k8sKindResourceQuota, k8sKindService,
+		k8sKindValidatingWebhookConfiguration, k8sStatefulSet,
+	}
+	for i, kind := range kinds {
+		kinds[i] = strings.ToLower(kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}()
+
+// getObjectKind returns the Kubernetes kind of a supported object. Objects
+// received from typed informers do not have their TypeMeta populated, so
+// the kind is derived from the Go type.
+func getObjectKind(obj interface{}) string {
+	switch obj.(type) {
+	case *corev1.Pod:
+		return k8sKindPod
+	case *corev1.Node:
+		return k8sKindNode
+	case *corev1.Namespace:
+		return k8sKindNamespace
+	case *corev1.ReplicationController:
+		return k8sKindReplicationController
+	case *corev1.ResourceQuota:
+		return k8sKindResourceQuota
+	case *corev1.Service:
+		return k8sKindService
+	case *appsv1.Deployment:
+		return k8sKindDeployment
+	case *appsv1.ReplicaSet:
+		return k8sKindReplicaSet
+	case *appsv1.DaemonSet:
+		return k8sKindDaemonSet
+	case *appsv1.StatefulSet:
+		return k8sStatefulSet
+	case *batchv1.Job:
+		return k8sKindJob
+	case *batchv1beta1.CronJob:
+		return k8sKindCronJob
+	case *v2beta1.HorizontalPodAutoscaler, *autoscalingv1.HorizontalPodAutoscaler, *v2beta2.HorizontalPodAutoscaler:
+		return k8sKindHPA
+	case *admissionregistrationv1.MutatingWebhookConfiguration:
+		return k8sKindMutatingWebhookConfiguration
+	case *admissionregistrationv1.ValidatingWebhookConfiguration:
+		return k8sKindValidatingWebhookConfiguration
+	case *networkingv1.Ingress:
+		return k8sKindIngress
+	case *policyv1beta1.PodDisruptionBudget:
+		return k8sKindPodDisruptionBudget
+	case *schedulingv1.PriorityClass:
+		return k8sKindPriorityClass
+	case *corev1.PersistentVolume:
+		return k8sKindPersistentVolume
+	case *corev1.PersistentVolumeClaim:
+		return k8sKindPersistentVolumeClaim
+	}
+	return ""
+}
+
 // SyncMetadata updates the metric store with latest metrics from the kubernetes object
 func (dc *DataCollector) SyncMetadata(obj interface{}) map[metadata.ResourceID]*KubernetesMetadata {
 	km := map[metadata.ResourceID]*KubernetesMetadata{}
@@ -181,6 +747,10 @@ func (dc *DataCollector) SyncMetadata(obj interface{}) map[metadata.ResourceID]*
 		km = getMetadataForCronJob(o)
 	case *v2beta1.HorizontalPodAutoscaler:
 		km = getMetadataForHPA(o)
+	case *autoscalingv1.HorizontalPodAutoscaler:
+		km = getMetadataForHPA(hpaFromV1(o))
+	case *v2beta2.HorizontalPodAutoscaler:
+		km = getMetadataForHPA(hpaFromV2beta2(o))
 	}
 
 	return km
@@ -15,8 +15,12 @@
 package collection
 
 import (
+	"fmt"
+	"regexp"
 	"testing"
+	"time"
 
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -293,3 +297,338 @@ func TestDataCollectorSyncMetadata(t *testing.T) {
 		})
 	}
 }
+
+func TestDataCollectorResourceAttributeKeys(t *testing.T) {
+	dc := NewDataCollector(zap.NewNop(), []string{}, WithResourceAttributeKeys(map[string]string{
+		"k8s.namespace.name": "namespace",
+		"k8s.pod.name":       "pod",
+	}))
+
+	pod := newPodWithContainer(
+		"1",
+		podSpecWithContainer("container-name"),
+		podStatusWithContainer("container-name", containerIDWithPreifx("container-id")),
+	)
+	dc.SyncMetrics(pod)
+
+	// The pod and container resources, and the cluster for the age of the
+	// oldest cached object.
+	mds := dc.CollectMetricData(time.Now())
+	require.Equal(t, 3, len(mds))
+
+	testutils.AssertResource(t, mds[0].Resource, k8sType,
+		map[string]string{
+			"k8s.pod.uid":      "test-pod-1-uid",
+			"pod":              "test-pod-1",
+			"k8s.node.name":    "test-node",
+			"namespace":        "test-namespace",
+			"k8s.cluster.name": "test-cluster",
+		},
+	)
+
+	testutils.AssertResource(t, mds[1].Resource, "container",
+		map[string]string{
+			"container.id":         "container-id",
+			"k8s.container.name":   "container-name",
+			"container.image.name": "container-image-name",
+			"k8s.pod.uid":          "test-pod-1-uid",
+			"pod":                  "test-pod-1",
+			"k8s.node.name":        "test-node",
+			"namespace":            "test-namespace",
+			"k8s.cluster.name":     "test-cluster",
+		},
+	)
+}
+
+func TestDataCollectorIncludedResourceAttributes(t *testing.T) {
+	dc := NewDataCollector(zap.NewNop(), []string{},
+		WithIncludedResourceAttributes([]string{"k8s.pod.name", "k8s.container.name"}),
+		WithResourceAttributeKeys(map[string]string{"k8s.pod.name": "pod"}),
+		WithObjectKind(true),
+	)
+
+	pod := newPodWithContainer(
+		"1",
+		podSpecWithContainer("container-name"),
+		podStatusWithContainer("container-name", containerIDWithPreifx("container-id")),
+	)
+	dc.SyncMetrics(pod)
+
+	mds := dc.CollectMetricData(time.Now())
+	require.Equal(t, 3, len(mds))
+
+	// Included attributes are matched before being renamed, and the object
+	// kind is reported regardless.
+	testutils.AssertResource(t, mds[0].Resource, k8sType,
+		map[string]string{
+			"pod":             "test-pod-1",
+			"k8s.object.kind": "Pod",
+		},
+	)
+	testutils.AssertResource(t, mds[1].Resource, "container",
+		map[string]string{
+			"k8s.container.name": "container-name",
+			"pod":                "test-pod-1",
+			"k8s.object.kind":    "Container",
+		},
+	)
+	// Metrics computed at collection time are filtered as well.
+	testutils.AssertResource(t, mds[2].Resource, k8sType, map[string]string{"k8s.object.kind": "Cluster"})
+}
+
+func TestDataCollectorMaxAttributeValueLength(t *testing.T) {
+	h := newTestHarness(t, nil,
+		WithMaxAttributeValueLength(16),
+		WithFieldExtractionRules(FieldExtractionRules{Annotations: []FieldExtractionRule{
+			{Kind: "pod", KeyRegex: regexp.MustCompile(`^mycompany\.com/(.*)$`), TagName: "$1"},
+		}}),
+		WithLabelInfoKinds([]string{"pod"}),
+	)
+
+	pod := newPodWithContainer("1", &corev1.PodSpec{}, &corev1.PodStatus{})
+	pod.Annotations = map[string]string{
+		"mycompany.com/short": "short",
+		"mycompany.com/exact": "exactly-16-bytes",
+		"mycompany.com/long":  "a value longer than the limit",
+	}
+	pod.Labels = map[string]string{"description": "a label longer than the limit"}
+	h.seed(pod)
+
+	podRes := map[string]string{"k8s.pod.uid": "test-pod-1-uid"}
+	rm := h.metrics("k8s.pod.phase", podRes)
+	require.Equal(t, 1, len(rm))
+	labels := rm[0].resource.Labels
+	require.Equal(t, "short", labels["short"])
+	require.Equal(t, "exactly-16-bytes", labels["exact"])
+	require.Equal(t, "a value longe...", labels["long"])
+	require.Equal(t, 16, len(labels["long"]))
+
+	m := h.requireMetric("k8s.pod.labels", podRes)
+	require.Equal(t, "a label longe...", m.Timeseries[0].LabelValues[0].Value)
+
+	// Multi-byte characters are not split.
+	require.Equal(t, "ab...", truncateValue("abé€def", 6))
+	require.Equal(t, "abé...", truncateValue("abé€def", 8))
+}
+
+func TestDataCollectorGaugeValueType(t *testing.T) {
+	pod := newPodWithContainer(
+		"1",
+		podSpecWithContainer("container-name"),
+		podStatusWithContainer("container-name", containerIDWithPreifx("container-id")),
+	)
+	pod.Status.Phase = corev1.PodRunning
+
+	phaseMetric := func(valueType string) *metricspb.Metric {
+		dc := NewDataCollector(zap.NewNop(), []string{}, WithGaugeValueType(valueType))
+		dc.SyncMetrics(pod)
+
+		mds := dc.CollectMetricData(time.Now())
+		require.Equal(t, 3, len(mds))
+		require.Equal(t, "k8s.pod.phase", mds[0].Metrics[0].MetricDescriptor.Name)
+		return mds[0].Metrics[0]
+	}
+
+	m := phaseMetric(GaugeValueTypeInt)
+	require.Equal(t, metricspb.MetricDescriptor_GAUGE_INT64, m.MetricDescriptor.Type)
+	require.Equal(t, int64(2), m.Timeseries[0].Points[0].GetInt64Value())
+
+	m = phaseMetric(GaugeValueTypeDouble)
+	require.Equal(t, metricspb.MetricDescriptor_GAUGE_DOUBLE, m.MetricDescriptor.Type)
+	require.IsType(t, &metricspb.Point_DoubleValue{}, m.Timeseries[0].Points[0].Value)
+	require.Equal(t, float64(2), m.Timeseries[0].Points[0].GetDoubleValue())
+
+	// Descriptors shared between metrics are not modified.
+	require.Equal(t, metricspb.MetricDescriptor_GAUGE_INT64, podPhaseMetric.Type)
+}
+
+func TestDataCollectorDisabledMetrics(t *testing.T) {
+	h := newTestHarness(t, nil, WithDisabledMetrics([]string{
+		"k8s.container.restarts",
+		"k8s.cluster.capacity_cpu",
+	}))
+	h.seed(
+		newPodWithContainer("0", podSpecWithContainer("container-name"),
+			podStatusWithContainer("container-name", containerIDWithPreifx("container-id"))),
+		newNodeWithResources("node-0", corev1.ConditionTrue, "2", "4Gi"),
+	)
+
+	h.requireNoMetric("k8s.container.restarts", nil)
+	h.requireMetric("k8s.container.ready", nil)
+	h.requireMetric("k8s.pod.phase", nil)
+
+	// Metrics computed at collection time can be disabled as well.
+	h.requireNoMetric("k8s.cluster.capacity_cpu", nil)
+	h.requireMetric("k8s.cluster.capacity_memory", nil)
+}
+
+func TestDataCollectorGroupByNamespace(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts []Option
+		key  string
+	}{
+		{name: "default keys", key: "k8s.namespace.name"},
+		{
+			name: "renamed key",
+			opts: []Option{WithResourceAttributeKeys(map[string]string{"k8s.namespace.name": "namespace"})},
+			key:  "namespace",
+		},
+		{
+			name: "styled key",
+			opts: []Option{WithAttributeKeyStyle(AttributeKeyStyleUnderscore)},
+			key:  "k8s_namespace_name",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHarness(t, nil, tt.opts...)
+			for i, ns := range []string{"b", "a", "b"} {
+				pod := newPodWithContainer(string(rune('0'+i)), podSpecWithContainer("container-name"),
+					podStatusWithContainer("container-name", containerIDWithPreifx("container-id")))
+				pod.Namespace = ns
+				h.seed(pod)
+			}
+			h.seed(newNodeWithResources("node-0", corev1.ConditionTrue, "2", "4Gi"))
+
+			md := h.dc.CollectMetrics(h.now)
+			resources := md.ResourceMetrics().Len()
+			batches := h.dc.GroupByNamespace(md)
+			require.Equal(t, 3, len(batches))
+
+			var total int
+			for i, want := range []string{"", "a", "b"} {
+				rms := batches[i].ResourceMetrics()
+				require.NotZero(t, rms.Len())
+				for j := 0; j < rms.Len(); j++ {
+					ns, _ := rms.At(j).Resource().Attributes().Get(tt.key)
+					require.Equal(t, want, ns.StringVal())
+				}
+				total += rms.Len()
+			}
+			require.Equal(t, resources, total)
+			// Pods and containers of both pods of namespace b, and the
+			// container restarts of the namespace.
+			require.Equal(t, 5, batches[2].ResourceMetrics().Len())
+		})
+	}
+}
+
+func TestDataCollectorSkipIrrelevantUpdates(t *testing.T) {
+	// rebuilt returns whether syncing obj rebuilt the cached metrics of the
+	// object with the given UID.
+	rebuilt := func(dc *DataCollector, obj interface{}, uid types.UID) bool {
+		before := dc.metricsStore.metricsCache[uid]
+		dc.SyncMetrics(obj)
+		after := dc.metricsStore.metricsCache[uid]
+		require.NotEmpty(t, after)
+		return &before[0] != &after[0]
+	}
+
+	node := newNodeWithResources("node-0", corev1.ConditionTrue, "2", "4Gi")
+	node.ResourceVersion = "1"
+	// heartbeat returns the node updated the way kubelets update the status
+	// of their node periodically, without any change to metrics.
+	heartbeat := func(version string) *corev1.Node {
+		updated := node.DeepCopy()
+		updated.ResourceVersion = version
+		for i := range updated.Status.Conditions {
+			updated.Status.Conditions[i].LastHeartbeatTime = v1.NewTime(time.Now())
+		}
+		return updated
+	}
+
+	dc := NewDataCollector(zap.NewNop(), []string{"Ready"}, WithSkipIrrelevantUpdates(true))
+	dc.SyncMetrics(node)
+	require.False(t, rebuilt(dc, heartbeat("2"), node.UID))
+
+	// Changes of relevant fields rebuild metrics.
+	cordoned := heartbeat("3")
+	cordoned.Spec.Unschedulable = true
+	require.True(t, rebuilt(dc, cordoned, node.UID))
+	require.False(t, rebuilt(dc, cordoned, node.UID))
+
+	// Objects removed and added again are rebuilt.
+	dc.RemoveFromMetricsStore(cordoned)
+	require.Empty(t, dc.metricsStore.metricsCache[node.UID])
+	dc.SyncMetrics(cordoned)
+	require.NotEmpty(t, dc.metricsStore.metricsCache[node.UID])
+
+	// Metrics are rebuilt on every update by default.
+	dc = NewDataCollector(zap.NewNop(), []string{"Ready"})
+	dc.SyncMetrics(node)
+	require.True(t, rebuilt(dc, heartbeat("2"), node.UID))
+
+	// Labels are only relevant if read.
+	pod := newPodWithContainer("1", podSpecWithContainer("container-name"),
+		podStatusWithContainer("container-name", containerIDWithPreifx("container-id")))
+	relabeled := pod.DeepCopy()
+	relabeled.Labels = map[string]string{"team": "infra"}
+
+	dc = NewDataCollector(zap.NewNop(), nil, WithSkipIrrelevantUpdates(true))
+	dc.SyncMetrics(pod)
+	require.False(t, rebuilt(dc, relabeled, pod.UID))
+
+	dc = NewDataCollector(zap.NewNop(), nil,
+		WithSkipIrrelevantUpdates(true), WithLabelInfoKinds([]string{"pod"}))
+	dc.SyncMetrics(pod)
+	require.True(t, rebuilt(dc, relabeled, pod.UID))
+}
+
+func BenchmarkSyncMetricsHeartbeat(b *testing.B) {
+	node := newNodeWithResources("node-0", corev1.ConditionTrue, "2", "4Gi")
+	for _, skip := range []bool{false, true} {
+		b.Run(fmt.Sprintf("skip_irrelevant_updates=%v", skip), func(b *testing.B) {
+			dc := NewDataCollector(zap.NewNop(), []string{"Ready"}, WithSkipIrrelevantUpdates(skip))
+			dc.SyncMetrics(node)
+			updates := make([]*corev1.Node, 2)
+			for i := range updates {
+				updates[i] = node.DeepCopy()
+				updates[i].ResourceVersion = fmt.Sprint(i)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				dc.SyncMetrics(updates[i%2])
+			}
+		})
+	}
+}
+
+func TestDataCollectorSourceNode(t *testing.T) {
+	h := newTestHarness(t, nil)
+	h.seed(newDeployment("1"))
+	for _, md := range append(h.collect(), h.dc.CollectSelfMetricData(h.now)...) {
+		require.Nil(t, md.Node)
+	}
+
+	h = newTestHarness(t, nil, WithSourceNode("test-cluster"), WithDeletionMarkers(true))
+	dep := newDeployment("1")
+	h.seed(dep)
+	mds := append(h.collect(), h.dc.CollectSelfMetricData(h.now)...)
+	h.remove(dep)
+	mds = append(mds, h.collect()...)
+	require.NotEmpty(t, mds)
+	for _, md := range mds {
+		require.NotNil(t, md.Node)
+		require.Equal(t, "test-cluster", md.Node.Attributes["k8s.cluster.name"])
+		require.NotZero(t, md.Node.Identifier.Pid)
+		require.Equal(t, "k8s_cluster", md.Node.ServiceInfo.Name)
+	}
+}
+
+func TestDataCollectorClusterName(t *testing.T) {
+	h := newTestHarness(t, nil, WithSourceNode("test-cluster"))
+	h.seed(newDeployment("1"), newNodeWithResources("node-0", corev1.ConditionTrue, "2", "4Gi"))
+	mds := h.collect()
+	require.NotEmpty(t, mds)
+	for _, md := range mds {
+		require.Equal(t, "test-cluster", md.Resource.Labels["k8s.cluster.name"])
+	}
+
+	// The cluster name is filtered along with other resource attributes.
+	h = newTestHarness(t, nil, WithSourceNode("test-cluster"),
+		WithIncludedResourceAttributes([]string{"k8s.deployment.name"}))
+	h.seed(newDeployment("1"))
+	m := h.metrics("k8s.deployment.desired", nil)
+	require.Len(t, m, 1)
+	require.NotContains(t, m[0].resource.Labels, "k8s.cluster.name")
+}
@@ -0,0 +1,170 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"sort"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	"go.opentelemetry.io/collector/translator/conventions"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
+)
+
+var namespaceConfigMapCountMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.namespace.configmap_count",
+	Description: "Number of ConfigMaps in the namespace",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var namespaceConfigMapDataSizeMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.namespace.configmap_data_size",
+	Description: "Approximate size of the data of all ConfigMaps in the namespace, keys and values included",
+	Unit:        "By",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var namespaceSecretCountMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.namespace.secret_count",
+	Description: "Number of Secrets in the namespace",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+// namespaceConfigData holds the aggregated ConfigMaps and Secrets of a
+// namespace.
+type namespaceConfigData struct {
+	configMaps     int64
+	configMapBytes int64
+	secrets        int64
+}
+
+// getConfigDataMetricsForNamespaces returns the number and size of the
+// ConfigMaps and the number of Secrets of every namespace, for the kinds that
+// are watched. Only the metadata of Secrets is used.
+func getConfigDataMetricsForNamespaces(ms *metadataStore) []*resourceMetrics {
+	if ms.configMaps == nil && ms.secrets == nil {
+		return nil
+	}
+
+	byNamespace := map[string]*namespaceConfigData{}
+	get := func(namespace string) *namespaceConfigData {
+		if byNamespace[namespace] == nil {
+			byNamespace[namespace] = &namespaceConfigData{}
+		}
+		return byNamespace[namespace]
+	}
+
+	// Namespaces without any ConfigMap or Secret are reported with zero
+	// values.
+	if ms.namespaces != nil {
+		for _, obj := range ms.namespaces.List() {
+			if ns, ok := obj.(*corev1.Namespace); ok {
+				get(ns.Name)
+			}
+		}
+	}
+	if ms.configMaps != nil {
+		for _, obj := range ms.configMaps.List() {
+			cm, ok := obj.(*corev1.ConfigMap)
+			if !ok {
+				continue
+			}
+			data := get(cm.Namespace)
+			data.configMaps++
+			data.configMapBytes += configMapDataSize(cm)
+		}
+	}
+	if ms.secrets != nil {
+		for _, obj := range ms.secrets.List() {
+			if secret, ok := obj.(*corev1.Secret); ok {
+				get(secret.Namespace).secrets++
+			}
+		}
+	}
+
+	namespaces := make([]string, 0, len(byNamespace))
+	for namespace := range byNamespace {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	out := make([]*resourceMetrics, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		data := byNamespace[namespace]
+		var metrics []*metricspb.Metric
+		if ms.configMaps != nil {
+			metrics = append(metrics,
+				&metricspb.Metric{
+					MetricDescriptor: namespaceConfigMapCountMetric,
+					Timeseries:       []*metricspb.TimeSeries{utils.GetInt64TimeSeries(data.configMaps)},
+				},
+				&metricspb.Metric{
+					MetricDescriptor: namespaceConfigMapDataSizeMetric,
+					Timeseries:       []*metricspb.TimeSeries{utils.GetInt64TimeSeries(data.configMapBytes)},
+				},
+			)
+		}
+		if ms.secrets != nil {
+			metrics = append(metrics, &metricspb.Metric{
+				MetricDescriptor: namespaceSecretCountMetric,
+				Timeseries:       []*metricspb.TimeSeries{utils.GetInt64TimeSeries(data.secrets)},
+			})
+		}
+
+		out = append(out, &resourceMetrics{
+			resource: getResourceForNamespaceName(ms.namespaces, namespace),
+			metrics:  metrics,
+		})
+	}
+	return out
+}
+
+// configMapDataSize returns the number of bytes of the keys and values of
+// the data and binary data of the ConfigMap.
+func configMapDataSize(cm *corev1.ConfigMap) int64 {
+	var size int64
+	for k, v := range cm.Data {
+		size += int64(len(k) + len(v))
+	}
+	for k, v := range cm.BinaryData {
+		size += int64(len(k) + len(v))
+	}
+	return size
+}
+
+// getResourceForNamespaceName returns the resource of the namespace with the
+// given name, including its UID if the namespace is in the store.
+func getResourceForNamespaceName(namespaces cache.Store, name string) *resourcepb.Resource {
+	labels := map[string]string{
+		conventions.AttributeK8sNamespace: name,
+	}
+	if namespaces != nil {
+		if obj, ok, _ := namespaces.GetByKey(name); ok {
+			if ns, ok := obj.(*corev1.Namespace); ok {
+				labels[k8sKeyNamespaceUID] = string(ns.UID)
+				labels[conventions.AttributeK8sCluster] = ns.ClusterName
+			}
+		}
+	}
+	return &resourcepb.Resource{
+		Type:   k8sType,
+		Labels: labels,
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestConfigDataMetricsForNamespaces(t *testing.T) {
+	h := newTestHarness(t, nil)
+	h.seed(
+		&corev1.Namespace{ObjectMeta: v1.ObjectMeta{Name: "app", UID: "app-uid"}},
+		&corev1.Namespace{ObjectMeta: v1.ObjectMeta{Name: "empty", UID: "empty-uid"}},
+		&corev1.ConfigMap{
+			ObjectMeta: v1.ObjectMeta{Name: "cm-1", Namespace: "app"},
+			Data:       map[string]string{"key": "value"},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: v1.ObjectMeta{Name: "cm-2", Namespace: "app"},
+			BinaryData: map[string][]byte{"bin": {0, 1, 2}},
+		},
+		&corev1.Secret{
+			ObjectMeta: v1.ObjectMeta{Name: "secret", Namespace: "app"},
+			Data:       map[string][]byte{"password": []byte("s3cr3t")},
+		},
+	)
+
+	app := map[string]string{"k8s.namespace.name": "app", "k8s.namespace.uid": "app-uid"}
+	h.requireInt64Value("k8s.namespace.configmap_count", app, 2)
+	h.requireInt64Value("k8s.namespace.configmap_data_size", app,
+		int64(len("key")+len("value")+len("bin")+3))
+	h.requireInt64Value("k8s.namespace.secret_count", app, 1)
+
+	empty := map[string]string{"k8s.namespace.name": "empty"}
+	h.requireInt64Value("k8s.namespace.configmap_count", empty, 0)
+	h.requireInt64Value("k8s.namespace.configmap_data_size", empty, 0)
+	h.requireInt64Value("k8s.namespace.secret_count", empty, 0)
+
+	h.remove(&corev1.ConfigMap{ObjectMeta: v1.ObjectMeta{Name: "cm-2", Namespace: "app"}})
+	h.requireInt64Value("k8s.namespace.configmap_count", app, 1)
+	h.requireInt64Value("k8s.namespace.configmap_data_size", app, int64(len("key")+len("value")))
+
+	// Only the kinds that are watched are reported.
+	h.dc.metadataStore.configMaps = nil
+	h.requireNoMetric("k8s.namespace.configmap_count", app)
+	h.requireInt64Value("k8s.namespace.secret_count", app, 1)
+
+	h.dc.metadataStore.secrets = nil
+	require.Nil(t, getConfigDataMetricsForNamespaces(h.dc.metadataStore))
+}
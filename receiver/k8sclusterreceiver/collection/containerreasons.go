@@ -0,0 +1,143 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"go.opentelemetry.io/collector/translator/conventions"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
+)
+
+var containerWaitingReasonMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.container.status.waiting_reason",
+	Description: "Whether the container is waiting for the reason (1) or not (0)",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+	LabelKeys:   []*metricspb.LabelKey{{Key: "reason"}},
+})
+
+var containerTerminatedReasonMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.container.status.terminated_reason",
+	Description: "Whether the container is terminated for the reason (1) or not (0)",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+	LabelKeys:   []*metricspb.LabelKey{{Key: "reason"}},
+})
+
+var containerLastTerminatedReasonMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name: "k8s.container.status.last_terminated_reason",
+	Description: "Whether the previous run of the container terminated for the reason (1) " +
+		"or not (0), e.g. OOMKilled for a container restarting in CrashLoopBackOff",
+	Unit:      "1",
+	Type:      metricspb.MetricDescriptor_GAUGE_INT64,
+	LabelKeys: []*metricspb.LabelKey{{Key: "reason"}},
+})
+
+// containerReasonOther is the reason containers waiting or terminated for a
+// reason that is not listed are reported under.
+const containerReasonOther = "Other"
+
+// containerWaitingReasons are the reasons set by the kubelet for waiting
+// containers. A time series is reported for each of them so that series do
+// not come and go.
+var containerWaitingReasons = []string{
+	"ContainerCreating",
+	"PodInitializing",
+	"CrashLoopBackOff",
+	"ErrImagePull",
+	"ImagePullBackOff",
+	"InvalidImageName",
+	"CreateContainerConfigError",
+	"CreateContainerError",
+	containerReasonOther,
+}
+
+// containerTerminatedReasons are the reasons set by the kubelet for
+// terminated containers, see containerWaitingReasons.
+var containerTerminatedReasons = []string{
+	"Completed",
+	"Error",
+	"OOMKilled",
+	"ContainerCannotRun",
+	"DeadlineExceeded",
+	"Evicted",
+	containerReasonOther,
+}
+
+// addStatusReasonMetrics adds the status reason metrics of the containers of
+// the pod to their resource metrics, as returned by getMetricsForPod.
+func addStatusReasonMetrics(pod *corev1.Pod, rms []*resourceMetrics) {
+	statuses := make(map[string]corev1.ContainerStatus, len(pod.Status.ContainerStatuses))
+	for _, cs := range pod.Status.ContainerStatuses {
+		statuses[cs.Name] = cs
+	}
+	for _, rm := range rms {
+		name, ok := rm.resource.Labels[conventions.AttributeK8sContainer]
+		if !ok {
+			continue
+		}
+		if cs, ok := statuses[name]; ok {
+			rm.metrics = append(rm.metrics, getStatusReasonMetricsForContainer(cs)...)
+		}
+	}
+}
+
+// getStatusReasonMetricsForContainer returns the reason the container is
+// waiting or terminated, and the reason its previous run terminated.
+func getStatusReasonMetricsForContainer(cs corev1.ContainerStatus) []*metricspb.Metric {
+	var waiting, terminated, lastTerminated string
+	if cs.State.Waiting != nil {
+		waiting = cs.State.Waiting.Reason
+	}
+	if cs.State.Terminated != nil {
+		terminated = cs.State.Terminated.Reason
+	}
+	if cs.LastTerminationState.Terminated != nil {
+		lastTerminated = cs.LastTerminationState.Terminated.Reason
+	}
+
+	return []*metricspb.Metric{
+		{
+			MetricDescriptor: containerWaitingReasonMetric,
+			Timeseries:       getContainerReasonTimeSeries(containerWaitingReasons, waiting),
+		},
+		{
+			MetricDescriptor: containerTerminatedReasonMetric,
+			Timeseries:       getContainerReasonTimeSeries(containerTerminatedReasons, terminated),
+		},
+		{
+			MetricDescriptor: containerLastTerminatedReasonMetric,
+			Timeseries:       getContainerReasonTimeSeries(containerTerminatedReasons, lastTerminated),
+		},
+	}
+}
+
+// getContainerReasonTimeSeries returns a time series for each of the reasons,
+// set for the given reason only. Reasons that are not listed are reported
+// under containerReasonOther, and no reason is set if it is empty.
+func getContainerReasonTimeSeries(reasons []string, reason string) []*metricspb.TimeSeries {
+	matched := reason == ""
+	series := make([]*metricspb.TimeSeries, 0, len(reasons))
+	for _, r := range reasons {
+		set := r == reason || (r == containerReasonOther && !matched)
+		matched = matched || set
+		series = append(series, utils.GetInt64TimeSeriesWithLabels(
+			boolToInt64(set), []*metricspb.LabelValue{{Value: r, HasValue: true}},
+		))
+	}
+	return series
+}
@@ -0,0 +1,93 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestContainerStatusReasonMetrics(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{Name: "pod", Namespace: "test-namespace", UID: types.UID("pod-uid")},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name:        "oom",
+					ContainerID: "containerd://oom",
+					State: corev1.ContainerState{
+						Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"},
+					},
+					LastTerminationState: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled"},
+					},
+				},
+				{
+					Name:        "unknown",
+					ContainerID: "containerd://unknown",
+					State: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{Reason: "Unknown"},
+					},
+				},
+				{
+					Name:        "running",
+					ContainerID: "containerd://running",
+					State:       corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+				},
+			},
+		},
+	}
+
+	h := newTestHarness(t, nil, WithContainerStatusReasons(true))
+	h.seed(pod)
+
+	// reasonsSet returns the reasons set for the container by the metric.
+	reasonsSet := func(name, container string) []string {
+		m := h.requireMetric(name, map[string]string{"k8s.container.name": container})
+		var set []string
+		for _, ts := range m.Timeseries {
+			if ts.Points[0].GetInt64Value() == 1 {
+				set = append(set, ts.LabelValues[0].Value)
+			}
+		}
+		return set
+	}
+
+	for _, tt := range []struct {
+		container                           string
+		waiting, terminated, lastTerminated []string
+	}{
+		{"oom", []string{"CrashLoopBackOff"}, nil, []string{"OOMKilled"}},
+		{"unknown", nil, []string{"Other"}, nil},
+		{"running", nil, nil, nil},
+	} {
+		require.Equal(t, tt.waiting, reasonsSet("k8s.container.status.waiting_reason", tt.container))
+		require.Equal(t, tt.terminated, reasonsSet("k8s.container.status.terminated_reason", tt.container))
+		require.Equal(t, tt.lastTerminated, reasonsSet("k8s.container.status.last_terminated_reason", tt.container))
+	}
+
+	// Every reason is reported so that series do not come and go.
+	m := h.requireMetric("k8s.container.status.waiting_reason", map[string]string{"k8s.container.name": "running"})
+	require.Equal(t, len(containerWaitingReasons), len(m.Timeseries))
+
+	// The metrics are only reported if enabled.
+	h = newTestHarness(t, nil)
+	h.seed(pod)
+	h.requireNoMetric("k8s.container.status.waiting_reason", nil)
+}
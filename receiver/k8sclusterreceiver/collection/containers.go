@@ -38,7 +38,7 @@ const (
 	containerStatusTerminated = "terminated"
 )
 
-var containerRestartMetric = &metricspb.MetricDescriptor{
+var containerRestartMetric = registerMetric(&metricspb.MetricDescriptor{
 	Name: "k8s.container.restarts",
 	Description: "How many times the container has restarted in the recent past. " +
 		"This value is pulled directly from the K8s API and the value can go indefinitely high" +
@@ -49,13 +49,13 @@ var containerRestartMetric = &metricspb.MetricDescriptor{
 		" not try and analyze the value beyond that.",
 	Unit: "1",
 	Type: metricspb.MetricDescriptor_GAUGE_INT64,
-}
+})
 
-var containerReadyMetric = &metricspb.MetricDescriptor{
+var containerReadyMetric = registerMetric(&metricspb.MetricDescriptor{
 	Name:        "k8s.container.ready",
 	Description: "Whether a container has passed its readiness probe (0 for no, 1 for yes)",
 	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
-}
+})
 
 // getStatusMetricsForContainer returns metrics about the status of the container.
 func getStatusMetricsForContainer(cs corev1.ContainerStatus) []*metricspb.Metric {
@@ -86,7 +86,7 @@ func boolToInt64(b bool) int64 {
 
 // getSpecMetricsForContainer metricizes values from the container spec.
 // This includes values like resource requests and limits.
-func getSpecMetricsForContainer(c corev1.Container) []*metricspb.Metric {
+func getSpecMetricsForContainer(c corev1.Container, u units) []*metricspb.Metric {
 	metrics := make([]*metricspb.Metric, 0)
 
 	for _, t := range []struct {
@@ -108,22 +108,8 @@ func getSpecMetricsForContainer(c corev1.Container) []*metricspb.Metric {
 		},
 	} {
 		for k, v := range t.rl {
-			val := v.Value()
-			if k == corev1.ResourceCPU {
-				val = v.MilliValue()
-			}
-
 			metrics = append(metrics,
-				&metricspb.Metric{
-					MetricDescriptor: &metricspb.MetricDescriptor{
-						Name:        fmt.Sprintf("k8s.container.%s_%s", k, t.typ),
-						Description: t.description,
-						Type:        metricspb.MetricDescriptor_GAUGE_INT64,
-					},
-					Timeseries: []*metricspb.TimeSeries{
-						utils.GetInt64TimeSeries(val),
-					},
-				},
+				u.getResourceMetric(fmt.Sprintf("k8s.container.%s_%s", k, t.typ), t.description, k, v),
 			)
 		}
 	}
@@ -131,6 +117,41 @@ func getSpecMetricsForContainer(c corev1.Container) []*metricspb.Metric {
 	return metrics
 }
 
+var containerHasLivenessProbeMetric = registerProbeMetric("liveness")
+var containerHasReadinessProbeMetric = registerProbeMetric("readiness")
+var containerHasStartupProbeMetric = registerProbeMetric("startup")
+
+func registerProbeMetric(probe string) *metricspb.MetricDescriptor {
+	return registerMetric(&metricspb.MetricDescriptor{
+		Name:        "k8s.container.has_" + probe + "_probe",
+		Description: "Whether the container has a " + probe + " probe (1) or not (0)",
+		Unit:        "1",
+		Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+	})
+}
+
+// getProbeMetricsForContainer returns whether the container spec configures
+// each kind of probe.
+func getProbeMetricsForContainer(c corev1.Container) []*metricspb.Metric {
+	metrics := make([]*metricspb.Metric, 0, 3)
+	for _, p := range []struct {
+		descriptor *metricspb.MetricDescriptor
+		probe      *corev1.Probe
+	}{
+		{containerHasLivenessProbeMetric, c.LivenessProbe},
+		{containerHasReadinessProbeMetric, c.ReadinessProbe},
+		{containerHasStartupProbeMetric, c.StartupProbe},
+	} {
+		metrics = append(metrics, &metricspb.Metric{
+			MetricDescriptor: p.descriptor,
+			Timeseries: []*metricspb.TimeSeries{
+				utils.GetInt64TimeSeries(boolToInt64(p.probe != nil)),
+			},
+		})
+	}
+	return metrics
+}
+
 // getResourceForContainer returns a proto representation of the pod.
 func getResourceForContainer(labels map[string]string) *resourcepb.Resource {
 	return &resourcepb.Resource{
@@ -0,0 +1,182 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"sort"
+	"sync"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
+)
+
+const (
+	// Resource labels keys for CustomResourceDefinitions.
+	k8sKeyCRDUID   = "k8s.crd.uid"
+	k8sKeyCRDName  = "k8s.crd.name"
+	k8sKeyCRDGroup = "k8s.crd.group"
+	k8sKeyCRDKind  = "k8s.crd.kind"
+)
+
+var crdCountMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.crd.count",
+	Description: "Number of CustomResourceDefinitions installed in the cluster",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var crdInstanceCountMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.crd.instance_count",
+	Description: "Number of custom resources of this CustomResourceDefinition",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+// crdStore keeps track of the informer cache of CustomResourceDefinitions
+// and of the caches of custom resources whose instances are counted.
+type crdStore struct {
+	sync.RWMutex
+	crds cache.Store
+	// instances holds the custom resources cache of a CRD, keyed by CRD name.
+	instances map[string]cache.Store
+}
+
+// crd holds the fields of a CustomResourceDefinition relevant to collection.
+type crd struct {
+	uid            string
+	name           string
+	group          string
+	kind           string
+	plural         string
+	storageVersion string
+}
+
+// parseCRD returns the relevant fields of an unstructured CustomResourceDefinition.
+func parseCRD(obj interface{}) (*crd, bool) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, false
+	}
+
+	c := &crd{
+		uid:  string(u.GetUID()),
+		name: u.GetName(),
+	}
+	c.group, _, _ = unstructured.NestedString(u.Object, "spec", "group")
+	c.kind, _, _ = unstructured.NestedString(u.Object, "spec", "names", "kind")
+	c.plural, _, _ = unstructured.NestedString(u.Object, "spec", "names", "plural")
+
+	versions, _, _ := unstructured.NestedSlice(u.Object, "spec", "versions")
+	for _, v := range versions {
+		version, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if storage, _, _ := unstructured.NestedBool(version, "storage"); storage {
+			c.storageVersion, _, _ = unstructured.NestedString(version, "name")
+			break
+		}
+	}
+
+	return c, true
+}
+
+// GetCustomResourceGVR returns the name of a CustomResourceDefinition along
+// with the group, storage version and resource of its custom resources.
+func GetCustomResourceGVR(obj interface{}) (string, schema.GroupVersionResource, bool) {
+	c, ok := parseCRD(obj)
+	if !ok || c.storageVersion == "" {
+		return "", schema.GroupVersionResource{}, false
+	}
+	return c.name, schema.GroupVersionResource{
+		Group:    c.group,
+		Version:  c.storageVersion,
+		Resource: c.plural,
+	}, true
+}
+
+// getMetricsForCRDs returns the number of CRDs in the cluster and, for CRDs
+// whose custom resources are tracked, the number of instances.
+func (cs *crdStore) getMetricsForCRDs() []*resourceMetrics {
+	cs.RLock()
+	defer cs.RUnlock()
+
+	if cs.crds == nil {
+		return nil
+	}
+
+	crds := cs.crds.List()
+	out := []*resourceMetrics{
+		{
+			resource: getResourceForCluster(),
+			metrics: []*metricspb.Metric{
+				{
+					MetricDescriptor: crdCountMetric,
+					Timeseries: []*metricspb.TimeSeries{
+						utils.GetInt64TimeSeries(int64(len(crds))),
+					},
+				},
+			},
+		},
+	}
+
+	for _, obj := range crds {
+		c, ok := parseCRD(obj)
+		if !ok {
+			continue
+		}
+
+		instances, ok := cs.instances[c.name]
+		if !ok {
+			continue
+		}
+
+		out = append(out, &resourceMetrics{
+			resource: getResourceForCRD(c),
+			metrics: []*metricspb.Metric{
+				{
+					MetricDescriptor: crdInstanceCountMetric,
+					Timeseries: []*metricspb.TimeSeries{
+						utils.GetInt64TimeSeries(int64(len(instances.List()))),
+					},
+				},
+			},
+		})
+	}
+
+	// Keep output stable across collections.
+	sort.Slice(out[1:], func(i, j int) bool {
+		return out[i+1].resource.Labels[k8sKeyCRDName] < out[j+1].resource.Labels[k8sKeyCRDName]
+	})
+
+	return out
+}
+
+func getResourceForCRD(c *crd) *resourcepb.Resource {
+	return &resourcepb.Resource{
+		Type: k8sType,
+		Labels: map[string]string{
+			k8sKeyCRDUID:   c.uid,
+			k8sKeyCRDName:  c.name,
+			k8sKeyCRDGroup: c.group,
+			k8sKeyCRDKind:  c.kind,
+		},
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"testing"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/testutils"
+)
+
+func TestCRDMetrics(t *testing.T) {
+	dc := NewDataCollector(zap.NewNop(), nil)
+
+	// No metrics unless CRDs are being watched.
+	require.Empty(t, dc.CollectMetricData(time.Now()))
+
+	dc.SetupCRDStore(&testutils.MockStore{
+		Cache: map[string]interface{}{
+			"certificates.cert-manager.io": newCRD("certificates", "cert-manager.io", "Certificate", "v1"),
+			"issuers.cert-manager.io":      newCRD("issuers", "cert-manager.io", "Issuer", "v1"),
+			"kafkas.kafka.strimzi.io":      newCRD("kafkas", "kafka.strimzi.io", "Kafka", "v1beta2"),
+		},
+	})
+	dc.SetupCustomResourceStore("certificates.cert-manager.io", &testutils.MockStore{
+		Cache: map[string]interface{}{
+			"default/cert-1": &unstructured.Unstructured{},
+			"default/cert-2": &unstructured.Unstructured{},
+		},
+	})
+	dc.SetupCustomResourceStore("kafkas.kafka.strimzi.io", &testutils.MockStore{
+		Cache: map[string]interface{}{},
+	})
+
+	mds := dc.CollectMetricData(time.Now())
+	require.Len(t, mds, 3)
+
+	testutils.AssertResource(t, mds[0].Resource, k8sType, map[string]string{})
+	require.Len(t, mds[0].Metrics, 1)
+	testutils.AssertMetrics(t, mds[0].Metrics[0], "k8s.crd.count",
+		metricspb.MetricDescriptor_GAUGE_INT64, 3)
+
+	testutils.AssertResource(t, mds[1].Resource, k8sType, map[string]string{
+		"k8s.crd.uid":   "certificates.cert-manager.io-uid",
+		"k8s.crd.name":  "certificates.cert-manager.io",
+		"k8s.crd.group": "cert-manager.io",
+		"k8s.crd.kind":  "Certificate",
+	})
+	testutils.AssertMetrics(t, mds[1].Metrics[0], "k8s.crd.instance_count",
+		metricspb.MetricDescriptor_GAUGE_INT64, 2)
+
+	testutils.AssertResource(t, mds[2].Resource, k8sType, map[string]string{
+		"k8s.crd.uid":   "kafkas.kafka.strimzi.io-uid",
+		"k8s.crd.name":  "kafkas.kafka.strimzi.io",
+		"k8s.crd.group": "kafka.strimzi.io",
+		"k8s.crd.kind":  "Kafka",
+	})
+	testutils.AssertMetrics(t, mds[2].Metrics[0], "k8s.crd.instance_count",
+		metricspb.MetricDescriptor_GAUGE_INT64, 0)
+
+	// Stop counting instances of a CRD.
+	dc.RemoveCustomResourceStore("kafkas.kafka.strimzi.io")
+	require.Len(t, dc.CollectMetricData(time.Now()), 2)
+}
+
+func TestGetCustomResourceGVR(t *testing.T) {
+	name, gvr, ok := GetCustomResourceGVR(newCRD("kafkas", "kafka.strimzi.io", "Kafka", "v1beta2"))
+	require.True(t, ok)
+	require.Equal(t, "kafkas.kafka.strimzi.io", name)
+	require.Equal(t, schema.GroupVersionResource{
+		Group:    "kafka.strimzi.io",
+		Version:  "v1beta2",
+		Resource: "kafkas",
+	}, gvr)
+
+	_, _, ok = GetCustomResourceGVR(newCRD("kafkas", "kafka.strimzi.io", "Kafka", ""))
+	require.False(t, ok)
+}
+
+func newCRD(plural, group, kind, storageVersion string) *unstructured.Unstructured {
+	name := plural + "." + group
+	versions := []interface{}{
+		map[string]interface{}{
+			"name":    "v1alpha1",
+			"served":  true,
+			"storage": false,
+		},
+	}
+	if storageVersion != "" {
+		versions = append(versions, map[string]interface{}{
+			"name":    storageVersion,
+			"served":  true,
+			"storage": true,
+		})
+	}
+
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apiextensions.k8s.io/v1",
+			"kind":       "CustomResourceDefinition",
+			"metadata": map[string]interface{}{
+				"name": name,
+				"uid":  name + "-uid",
+			},
+			"spec": map[string]interface{}{
+				"group": group,
+				"names": map[string]interface{}{
+					"kind":   kind,
+					"plural": plural,
+				},
+				"versions": versions,
+			},
+		},
+	}
+}
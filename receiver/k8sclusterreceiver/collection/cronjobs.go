@@ -30,12 +30,19 @@ const (
 	cronJobKeyConcurrencyPolicy = "concurrency_policy"
 )
 
-var activeJobs = &metricspb.MetricDescriptor{
+var activeJobs = registerMetric(&metricspb.MetricDescriptor{
 	Name:        "k8s.cronjob.active_jobs",
 	Description: "The number of actively running jobs for a cronjob",
 	Unit:        "1",
 	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
-}
+})
+
+var cronJobLastScheduleTimeMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.cronjob.last_schedule_time",
+	Description: "Unix time at which a job of the cronjob was last scheduled",
+	Unit:        "s",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
 
 func getMetricsForCronJob(cj *batchv1beta1.CronJob) []*resourceMetrics {
 	metrics := []*metricspb.Metric{
@@ -46,6 +53,15 @@ func getMetricsForCronJob(cj *batchv1beta1.CronJob) []*resourceMetrics {
 			},
 		},
 	}
+	// Not reported until a job of the cronjob has been scheduled.
+	if t := cj.Status.LastScheduleTime; t != nil {
+		metrics = append(metrics, &metricspb.Metric{
+			MetricDescriptor: cronJobLastScheduleTimeMetric,
+			Timeseries: []*metricspb.TimeSeries{
+				utils.GetInt64TimeSeries(t.Unix()),
+			},
+		})
+	}
 
 	return []*resourceMetrics{
 		{
@@ -16,6 +16,7 @@ package collection
 
 import (
 	"testing"
+	"time"
 
 	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
 	"github.com/stretchr/testify/require"
@@ -34,7 +35,7 @@ func TestCronJobMetrics(t *testing.T) {
 
 	require.Equal(t, 1, len(actualResourceMetrics))
 
-	require.Equal(t, 1, len(actualResourceMetrics[0].metrics))
+	require.Equal(t, 2, len(actualResourceMetrics[0].metrics))
 	testutils.AssertResource(t, actualResourceMetrics[0].resource, k8sType,
 		map[string]string{
 			"k8s.cronjob.uid":    "test-cronjob-1-uid",
@@ -46,6 +47,14 @@ func TestCronJobMetrics(t *testing.T) {
 
 	testutils.AssertMetrics(t, actualResourceMetrics[0].metrics[0], "k8s.cronjob.active_jobs",
 		metricspb.MetricDescriptor_GAUGE_INT64, 2)
+
+	testutils.AssertMetrics(t, actualResourceMetrics[0].metrics[1], "k8s.cronjob.last_schedule_time",
+		metricspb.MetricDescriptor_GAUGE_INT64, 1600000000)
+
+	// The last schedule time is not reported until a job is scheduled.
+	cj.Status.LastScheduleTime = nil
+	actualResourceMetrics = getMetricsForCronJob(cj)
+	require.Equal(t, 1, len(actualResourceMetrics[0].metrics))
 }
 
 func TestCronJobMetadata(t *testing.T) {
@@ -91,7 +100,8 @@ func newCronJob(id string) *batchv1beta1.CronJob {
 			ConcurrencyPolicy: "concurrency_policy",
 		},
 		Status: batchv1beta1.CronJobStatus{
-			Active: []corev1.ObjectReference{{}, {}},
+			Active:           []corev1.ObjectReference{{}, {}},
+			LastScheduleTime: &v1.Time{Time: time.Unix(1600000000, 0)},
 		},
 	}
 }
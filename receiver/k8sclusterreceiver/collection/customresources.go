@@ -0,0 +1,256 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	"go.opentelemetry.io/collector/translator/conventions"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/jsonpath"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
+)
+
+const (
+	// Resource labels keys for custom resources.
+	k8sKeyCustomResourceUID   = "k8s.custom_resource.uid"
+	k8sKeyCustomResourceName  = "k8s.custom_resource.name"
+	k8sKeyCustomResourceGroup = "k8s.custom_resource.group"
+	k8sKeyCustomResourceKind  = "k8s.custom_resource.kind"
+)
+
+// CustomResourceMetrics are the gauges and resource attributes reported for
+// every custom resource of a group, version and resource, read from its
+// fields with JSONPath expressions.
+type CustomResourceMetrics struct {
+	GVR     schema.GroupVersionResource
+	Metrics []CustomResourceMetric
+	// Attributes are the resource attributes read from the fields of each
+	// custom resource, keyed by attribute key.
+	Attributes map[string]*FieldPath
+}
+
+// CustomResourceMetric is a gauge whose value is read from a field of each
+// custom resource.
+type CustomResourceMetric struct {
+	Name        string
+	Description string
+	Unit        string
+	Path        *FieldPath
+}
+
+// FieldPath is a JSONPath expression, as supported by kubectl, reading a field
+// of an object, e.g. "{.status.replicas}". The braces and the leading dot
+// are optional.
+type FieldPath struct {
+	source string
+	path   *jsonpath.JSONPath
+}
+
+func (p *FieldPath) String() string {
+	return p.source
+}
+
+// ParseFieldPath parses a JSONPath expression, see FieldPath.
+func ParseFieldPath(s string) (*FieldPath, error) {
+	template := s
+	if !strings.Contains(template, "{") {
+		template = "{." + strings.TrimPrefix(template, ".") + "}"
+	}
+	path := jsonpath.New(s).AllowMissingKeys(true)
+	if err := path.Parse(template); err != nil {
+		return nil, err
+	}
+	return &FieldPath{source: s, path: path}, nil
+}
+
+// find returns the first value the path matches in obj, if any. It is not
+// safe for concurrent use.
+func (p *FieldPath) find(obj map[string]interface{}) (interface{}, bool) {
+	results, err := p.path.FindResults(obj)
+	if err != nil {
+		return nil, false
+	}
+	for _, values := range results {
+		for _, v := range values {
+			if v.Kind() == reflect.Interface && v.IsNil() {
+				continue
+			}
+			return v.Interface(), true
+		}
+	}
+	return nil, false
+}
+
+// findNumber returns the value the path matches in obj as a number. Booleans
+// and the "True" and "False" strings of conditions are 1 and 0, other strings
+// are parsed as numbers.
+func (p *FieldPath) findNumber(obj map[string]interface{}) (float64, error) {
+	v, ok := p.find(obj)
+	if !ok {
+		return 0, fmt.Errorf("path %s matches no field", p.source)
+	}
+	switch v := v.(type) {
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		switch strings.ToLower(v) {
+		case "true":
+			return 1, nil
+		case "false":
+			return 0, nil
+		}
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f, nil
+		}
+	}
+	return 0, fmt.Errorf("field matched by path %s is not a number", p.source)
+}
+
+// findString returns the value the path matches in obj formatted as a string.
+func (p *FieldPath) findString(obj map[string]interface{}) (string, bool) {
+	v, ok := p.find(obj)
+	if !ok {
+		return "", false
+	}
+	if s, ok := v.(string); ok {
+		return s, true
+	}
+	return fmt.Sprint(v), true
+}
+
+// customResourceStore keeps track of the informer caches of the custom
+// resources metrics are reported for. It is locked while reporting metrics
+// since evaluating field paths is not safe for concurrent use.
+type customResourceStore struct {
+	sync.Mutex
+	sources []*customResourceSource
+}
+
+type customResourceSource struct {
+	metrics     CustomResourceMetrics
+	descriptors []*metricspb.MetricDescriptor
+	// store is only set once the informer of the custom resources is set up.
+	store cache.Store
+}
+
+func newCustomResourceStore(crms []CustomResourceMetrics) *customResourceStore {
+	cs := &customResourceStore{}
+	for _, crm := range crms {
+		source := &customResourceSource{metrics: crm}
+		for _, m := range crm.Metrics {
+			source.descriptors = append(source.descriptors, &metricspb.MetricDescriptor{
+				Name:        m.Name,
+				Description: m.Description,
+				Unit:        m.Unit,
+				Type:        metricspb.MetricDescriptor_GAUGE_DOUBLE,
+			})
+		}
+		cs.sources = append(cs.sources, source)
+	}
+	return cs
+}
+
+// getMetricsForCustomResources returns the metrics of every custom resource
+// in the informer caches. Metrics whose field is missing or not a number are
+// left out.
+func (cs *customResourceStore) getMetricsForCustomResources() []*resourceMetrics {
+	cs.Lock()
+	defer cs.Unlock()
+
+	var out []*resourceMetrics
+	for _, source := range cs.sources {
+		if source.store == nil {
+			continue
+		}
+
+		objs := source.store.List()
+		// Keep output stable across collections.
+		sort.Slice(objs, func(i, j int) bool {
+			return customResourceKey(objs[i]) < customResourceKey(objs[j])
+		})
+		for _, obj := range objs {
+			u, ok := obj.(*unstructured.Unstructured)
+			if !ok {
+				continue
+			}
+
+			var metrics []*metricspb.Metric
+			for i, m := range source.metrics.Metrics {
+				v, err := m.Path.findNumber(u.Object)
+				if err != nil {
+					continue
+				}
+				metrics = append(metrics, &metricspb.Metric{
+					MetricDescriptor: source.descriptors[i],
+					Timeseries:       []*metricspb.TimeSeries{utils.GetDoubleTimeSeries(v)},
+				})
+			}
+			if len(metrics) == 0 {
+				continue
+			}
+			out = append(out, &resourceMetrics{
+				resource: getResourceForCustomResource(u, source.metrics),
+				metrics:  metrics,
+			})
+		}
+	}
+	return out
+}
+
+func customResourceKey(obj interface{}) string {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u.GetNamespace() + "/" + u.GetName()
+	}
+	return ""
+}
+
+func getResourceForCustomResource(u *unstructured.Unstructured, crm CustomResourceMetrics) *resourcepb.Resource {
+	labels := map[string]string{
+		k8sKeyCustomResourceUID:   string(u.GetUID()),
+		k8sKeyCustomResourceName:  u.GetName(),
+		k8sKeyCustomResourceGroup: crm.GVR.Group,
+		k8sKeyCustomResourceKind:  u.GetKind(),
+	}
+	if namespace := u.GetNamespace(); namespace != "" {
+		labels[conventions.AttributeK8sNamespace] = namespace
+	}
+	for key, path := range crm.Attributes {
+		if v, ok := path.findString(u.Object); ok {
+			labels[key] = v
+		}
+	}
+	return &resourcepb.Resource{
+		Type:   k8sType,
+		Labels: labels,
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"testing"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/testutils"
+)
+
+func TestCustomResourceMetrics(t *testing.T) {
+	certificatesGVR := schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+	dc := NewDataCollector(zap.NewNop(), nil, WithCustomResourceMetrics([]CustomResourceMetrics{
+		{
+			GVR: certificatesGVR,
+			Metrics: []CustomResourceMetric{
+				{
+					Name: "certmanager.certificate.ready",
+					Unit: "1",
+					Path: mustParseFieldPath(t, `{.status.conditions[?(@.type=="Ready")].status}`),
+				},
+				{
+					Name: "certmanager.certificate.revision",
+					Unit: "1",
+					Path: mustParseFieldPath(t, "status.revision"),
+				},
+			},
+			Attributes: map[string]*FieldPath{
+				"certmanager.certificate.issuer": mustParseFieldPath(t, "{.spec.issuerRef.name}"),
+			},
+		},
+	}))
+
+	// No metrics until the custom resources are watched.
+	require.Empty(t, dc.CollectMetricData(time.Now()))
+
+	dc.SetupCustomResourceMetricsStore(certificatesGVR, &testutils.MockStore{
+		Cache: map[string]interface{}{
+			"test/cert-1": newCertificate("cert-1", "True", int64(3)),
+			"test/cert-2": newCertificate("cert-2", "False", nil),
+			// Custom resources with no metric are left out.
+			"test/cert-3": &unstructured.Unstructured{Object: map[string]interface{}{
+				"kind":     "Certificate",
+				"metadata": map[string]interface{}{"namespace": "test", "name": "cert-3"},
+			}},
+		},
+	})
+
+	mds := dc.CollectMetricData(time.Now())
+	require.Len(t, mds, 2)
+
+	testutils.AssertResource(t, mds[0].Resource, k8sType, map[string]string{
+		"k8s.custom_resource.uid":        "cert-1-uid",
+		"k8s.custom_resource.name":       "cert-1",
+		"k8s.custom_resource.group":      "cert-manager.io",
+		"k8s.custom_resource.kind":       "Certificate",
+		"k8s.namespace.name":             "test",
+		"certmanager.certificate.issuer": "letsencrypt",
+	})
+	require.Len(t, mds[0].Metrics, 2)
+	requireDoubleGauge(t, mds[0].Metrics[0], "certmanager.certificate.ready", 1.0)
+	requireDoubleGauge(t, mds[0].Metrics[1], "certmanager.certificate.revision", 3.0)
+
+	require.Equal(t, "cert-2", mds[1].Resource.Labels["k8s.custom_resource.name"])
+	require.Len(t, mds[1].Metrics, 1)
+	requireDoubleGauge(t, mds[1].Metrics[0], "certmanager.certificate.ready", 0.0)
+}
+
+func TestFieldPathFindNumber(t *testing.T) {
+	obj := map[string]interface{}{
+		"status": map[string]interface{}{
+			"replicas": int64(3),
+			"ratio":    0.5,
+			"paused":   true,
+			"size":     "42",
+			"phase":    "Running",
+		},
+	}
+	tests := []struct {
+		path     string
+		expected float64
+		err      string
+	}{
+		{path: "{.status.replicas}", expected: 3},
+		{path: ".status.ratio", expected: 0.5},
+		{path: "status.ratio", expected: 0.5},
+		{path: "{.status.paused}", expected: 1},
+		{path: "{.status.size}", expected: 42},
+		{path: "{.status.phase}", err: "field matched by path {.status.phase} is not a number"},
+		{path: "{.status.missing}", err: "path {.status.missing} matches no field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			v, err := mustParseFieldPath(t, tt.path).findNumber(obj)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, v)
+		})
+	}
+
+	_, err := ParseFieldPath("{.status.replicas")
+	require.Error(t, err)
+}
+
+func requireDoubleGauge(t *testing.T, m *metricspb.Metric, name string, value float64) {
+	require.Equal(t, name, m.MetricDescriptor.Name)
+	require.Equal(t, metricspb.MetricDescriptor_GAUGE_DOUBLE, m.MetricDescriptor.Type)
+	require.Equal(t, value, m.Timeseries[0].Points[0].GetDoubleValue())
+}
+
+func mustParseFieldPath(t *testing.T, s string) *FieldPath {
+	path, err := ParseFieldPath(s)
+	require.NoError(t, err)
+	return path
+}
+
+func newCertificate(name, ready string, revision interface{}) *unstructured.Unstructured {
+	status := map[string]interface{}{
+		"conditions": []interface{}{
+			map[string]interface{}{"type": "Issuing", "status": "False"},
+			map[string]interface{}{"type": "Ready", "status": ready},
+		},
+	}
+	if revision != nil {
+		status["revision"] = revision
+	}
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "cert-manager.io/v1",
+			"kind":       "Certificate",
+			"metadata": map[string]interface{}{
+				"namespace": "test",
+				"name":      name,
+				"uid":       name + "-uid",
+			},
+			"spec": map[string]interface{}{
+				"issuerRef": map[string]interface{}{"name": "letsencrypt"},
+			},
+			"status": status,
+		},
+	}
+}
@@ -19,37 +19,80 @@ import (
 	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
 	"go.opentelemetry.io/collector/translator/conventions"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 
 	metadata "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/experimentalmetricmetadata"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
 )
 
-var daemonSetCurrentScheduledMetric = &metricspb.MetricDescriptor{
+var daemonSetCurrentScheduledMetric = registerMetric(&metricspb.MetricDescriptor{
 	Name:        "k8s.daemonset.current_scheduled_nodes",
 	Description: "Number of nodes that are running at least 1 daemon pod and are supposed to run the daemon pod",
 	Unit:        "1",
 	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
-}
+})
 
-var daemonSetDesiredScheduledMetric = &metricspb.MetricDescriptor{
+var daemonSetDesiredScheduledMetric = registerMetric(&metricspb.MetricDescriptor{
 	Name:        "k8s.daemonset.desired_scheduled_nodes",
 	Description: "Number of nodes that should be running the daemon pod (including nodes currently running the daemon pod)",
 	Unit:        "1",
 	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
-}
+})
 
-var daemonSetMisScheduledMetric = &metricspb.MetricDescriptor{
+var daemonSetMisScheduledMetric = registerMetric(&metricspb.MetricDescriptor{
 	Name:        "k8s.daemonset.misscheduled_nodes",
 	Description: "Number of nodes that are running the daemon pod, but are not supposed to run the daemon pod",
 	Unit:        "1",
 	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
-}
+})
 
-var daemonSetReadyMetric = &metricspb.MetricDescriptor{
+var daemonSetReadyMetric = registerMetric(&metricspb.MetricDescriptor{
 	Name:        "k8s.daemonset.ready_nodes",
 	Description: "Number of nodes that should be running the daemon pod and have one or more of the daemon pod running and ready",
 	Unit:        "1",
 	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var daemonSetAvailableMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.daemonset.available_nodes",
+	Description: "Number of nodes that should be running the daemon pod and have one or more of the daemon pod running and available",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var daemonSetUnavailableMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.daemonset.unavailable_nodes",
+	Description: "Number of nodes that should be running the daemon pod and have none of the daemon pod running and available",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var daemonSetUpdatedScheduledMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.daemonset.updated_scheduled_nodes",
+	Description: "Number of nodes that are running the updated daemon pod",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var daemonSetPodNotReadyMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name: "k8s.daemonset.pod_not_ready",
+	Description: "Set to 1 for each node supposed to run the daemon pod or running it " +
+		"on which the pod is missing or not ready",
+	Unit:      "1",
+	Type:      metricspb.MetricDescriptor_GAUGE_INT64,
+	LabelKeys: []*metricspb.LabelKey{{Key: "node"}},
+})
+
+// daemonSetDefaultTolerations are the taints the DaemonSet controller adds
+// tolerations for to every daemon pod.
+var daemonSetDefaultTolerations = []corev1.Toleration{
+	{Key: corev1.TaintNodeNotReady, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute},
+	{Key: corev1.TaintNodeUnreachable, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute},
+	{Key: corev1.TaintNodeDiskPressure, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+	{Key: corev1.TaintNodeMemoryPressure, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+	{Key: corev1.TaintNodePIDPressure, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+	{Key: corev1.TaintNodeUnschedulable, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
 }
 
 func getMetricsForDaemonSet(ds *appsv1.DaemonSet) []*resourceMetrics {
@@ -78,6 +121,24 @@ func getMetricsForDaemonSet(ds *appsv1.DaemonSet) []*resourceMetrics {
 				utils.GetInt64TimeSeries(int64(ds.Status.NumberReady)),
 			},
 		},
+		{
+			MetricDescriptor: daemonSetAvailableMetric,
+			Timeseries: []*metricspb.TimeSeries{
+				utils.GetInt64TimeSeries(int64(ds.Status.NumberAvailable)),
+			},
+		},
+		{
+			MetricDescriptor: daemonSetUnavailableMetric,
+			Timeseries: []*metricspb.TimeSeries{
+				utils.GetInt64TimeSeries(int64(ds.Status.NumberUnavailable)),
+			},
+		},
+		{
+			MetricDescriptor: daemonSetUpdatedScheduledMetric,
+			Timeseries: []*metricspb.TimeSeries{
+				utils.GetInt64TimeSeries(int64(ds.Status.UpdatedNumberScheduled)),
+			},
+		},
 	}
 
 	return []*resourceMetrics{
@@ -88,6 +149,111 @@ func getMetricsForDaemonSet(ds *appsv1.DaemonSet) []*resourceMetrics {
 	}
 }
 
+// getPodNotReadyMetricsForDaemonSets returns, for every DaemonSet, the nodes
+// that are supposed to run its pod or are running it on which the pod is
+// missing or not ready. Nodes with a ready pod are left out, so that the
+// number of time series does not grow with the number of healthy nodes.
+// Whether a node is supposed to run the daemon pod is approximated from the
+// node selector and tolerations of the pod template, node affinity is not
+// taken into account.
+func getPodNotReadyMetricsForDaemonSets(ms *metadataStore) []*resourceMetrics {
+	if ms.pods == nil || ms.nodes == nil || ms.daemonSets == nil {
+		return nil
+	}
+
+	// Readiness of the daemon pods keyed by DaemonSet UID and node name.
+	ready := map[types.UID]map[string]bool{}
+	for _, obj := range ms.pods.List() {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok || pod.Spec.NodeName == "" {
+			continue
+		}
+		owner := utils.FindOwnerWithKind(pod.OwnerReferences, k8sKindDaemonSet)
+		if owner == nil {
+			continue
+		}
+		if ready[owner.UID] == nil {
+			ready[owner.UID] = map[string]bool{}
+		}
+		// A node may briefly run two daemon pods, e.g. while one of them is
+		// being replaced, a single ready one is enough.
+		ready[owner.UID][pod.Spec.NodeName] = ready[owner.UID][pod.Spec.NodeName] ||
+			podConditionValue(pod, corev1.PodReady) == 1
+	}
+
+	nodes := ms.nodes.List()
+	var out []*resourceMetrics
+	for _, obj := range ms.daemonSets.List() {
+		ds, ok := obj.(*appsv1.DaemonSet)
+		if !ok {
+			continue
+		}
+
+		podsReady := ready[ds.UID]
+		var timeseries []*metricspb.TimeSeries
+		for _, nodeObj := range nodes {
+			node, ok := nodeObj.(*corev1.Node)
+			if !ok {
+				continue
+			}
+			podReady, running := podsReady[node.Name]
+			if podReady || (!running && !shouldRunDaemonPod(ds, node)) {
+				continue
+			}
+			timeseries = append(timeseries, utils.GetInt64TimeSeriesWithLabels(
+				1,
+				[]*metricspb.LabelValue{{Value: node.Name, HasValue: true}},
+			))
+		}
+		if len(timeseries) == 0 {
+			continue
+		}
+
+		out = append(out, &resourceMetrics{
+			resource: getResourceForDaemonSet(ds),
+			metrics: []*metricspb.Metric{
+				{
+					MetricDescriptor: daemonSetPodNotReadyMetric,
+					Timeseries:       timeseries,
+				},
+			},
+		})
+	}
+	return out
+}
+
+// shouldRunDaemonPod returns true if the node matches the node selector of
+// the daemon pod template and the pod tolerates all NoSchedule and NoExecute
+// taints of the node.
+func shouldRunDaemonPod(ds *appsv1.DaemonSet, node *corev1.Node) bool {
+	for k, v := range ds.Spec.Template.Spec.NodeSelector {
+		if node.Labels[k] != v {
+			return false
+		}
+	}
+
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		if !toleratesTaint(ds.Spec.Template.Spec.Tolerations, taint) &&
+			!toleratesTaint(daemonSetDefaultTolerations, taint) {
+			return false
+		}
+	}
+	return true
+}
+
+func toleratesTaint(tolerations []corev1.Toleration, taint *corev1.Taint) bool {
+	for i := range tolerations {
+		if tolerations[i].ToleratesTaint(taint) {
+			return true
+		}
+	}
+	return false
+}
+
 func getResourceForDaemonSet(ds *appsv1.DaemonSet) *resourcepb.Resource {
 	return &resourcepb.Resource{
 		Type: k8sType,
@@ -20,8 +20,10 @@ import (
 	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
 	"github.com/stretchr/testify/require"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/testutils"
 )
@@ -32,7 +34,7 @@ func TestDaemonsetMetrics(t *testing.T) {
 	actualResourceMetrics := getMetricsForDaemonSet(ds)
 
 	require.Equal(t, 1, len(actualResourceMetrics))
-	require.Equal(t, 4, len(actualResourceMetrics[0].metrics))
+	require.Equal(t, 7, len(actualResourceMetrics[0].metrics))
 
 	rm := actualResourceMetrics[0]
 	testutils.AssertResource(t, rm.resource, k8sType,
@@ -55,6 +57,93 @@ func TestDaemonsetMetrics(t *testing.T) {
 
 	testutils.AssertMetrics(t, rm.metrics[3], "k8s.daemonset.ready_nodes",
 		metricspb.MetricDescriptor_GAUGE_INT64, 2)
+
+	testutils.AssertMetrics(t, rm.metrics[4], "k8s.daemonset.available_nodes",
+		metricspb.MetricDescriptor_GAUGE_INT64, 2)
+
+	testutils.AssertMetrics(t, rm.metrics[5], "k8s.daemonset.unavailable_nodes",
+		metricspb.MetricDescriptor_GAUGE_INT64, 3)
+
+	testutils.AssertMetrics(t, rm.metrics[6], "k8s.daemonset.updated_scheduled_nodes",
+		metricspb.MetricDescriptor_GAUGE_INT64, 4)
+}
+
+func TestDaemonSetPodNotReadyMetrics(t *testing.T) {
+	ds := newDaemonset("1")
+
+	newDaemonPod := func(nodeName string, ready corev1.ConditionStatus) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "test-daemonset-1-" + nodeName,
+				Namespace: "test-namespace",
+				UID:       types.UID("test-daemonset-1-" + nodeName + "-uid"),
+				OwnerReferences: []v1.OwnerReference{
+					{Kind: "DaemonSet", Name: ds.Name, UID: ds.UID},
+				},
+			},
+			Spec: corev1.PodSpec{NodeName: nodeName},
+			Status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}},
+			},
+		}
+	}
+	newNodeWithName := func(name string, taints ...corev1.Taint) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: v1.ObjectMeta{Name: name},
+			Spec:       corev1.NodeSpec{Taints: taints},
+		}
+	}
+
+	ms := &metadataStore{
+		pods:       cache.NewStore(cache.MetaNamespaceKeyFunc),
+		nodes:      cache.NewStore(cache.MetaNamespaceKeyFunc),
+		daemonSets: cache.NewStore(cache.MetaNamespaceKeyFunc),
+	}
+	require.NoError(t, ms.pods.Add(newDaemonPod("node-1", corev1.ConditionTrue)))
+	require.NoError(t, ms.pods.Add(newDaemonPod("node-2", corev1.ConditionFalse)))
+	require.NoError(t, ms.nodes.Add(newNodeWithName("node-1")))
+	require.NoError(t, ms.nodes.Add(newNodeWithName("node-2")))
+	require.NoError(t, ms.daemonSets.Add(ds))
+
+	nodeValues := func() map[string]int64 {
+		rms := getPodNotReadyMetricsForDaemonSets(ms)
+		if len(rms) == 0 {
+			return nil
+		}
+		require.Equal(t, 1, len(rms))
+		require.Equal(t, "test-daemonset-1-uid", rms[0].resource.Labels["k8s.daemonset.uid"])
+		require.Equal(t, 1, len(rms[0].metrics))
+		m := rms[0].metrics[0]
+		require.Equal(t, "k8s.daemonset.pod_not_ready", m.MetricDescriptor.Name)
+
+		values := map[string]int64{}
+		for _, ts := range m.Timeseries {
+			values[ts.LabelValues[0].Value] = ts.Points[0].GetInt64Value()
+		}
+		return values
+	}
+
+	// Nodes with a ready daemon pod are left out.
+	require.Equal(t, map[string]int64{"node-2": 1}, nodeValues())
+
+	// Nodes without a daemon pod are reported if they are supposed to run
+	// one, which excludes nodes with taints the pod does not tolerate.
+	require.NoError(t, ms.nodes.Add(newNodeWithName("node-3")))
+	require.NoError(t, ms.nodes.Add(newNodeWithName("node-4",
+		corev1.Taint{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule})))
+	require.NoError(t, ms.nodes.Add(newNodeWithName("node-5",
+		corev1.Taint{Key: corev1.TaintNodeUnreachable, Effect: corev1.TaintEffectNoExecute})))
+	require.Equal(t, map[string]int64{"node-2": 1, "node-3": 1, "node-5": 1}, nodeValues())
+
+	ds.Spec.Template.Spec.Tolerations = []corev1.Toleration{
+		{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "gpu", Effect: corev1.TaintEffectNoSchedule},
+	}
+	ds.Spec.Template.Spec.NodeSelector = map[string]string{"kubernetes.io/os": "linux"}
+	require.Equal(t, map[string]int64{"node-2": 1}, nodeValues())
+
+	// DaemonSets ready on all their nodes have no metric.
+	require.NoError(t, ms.pods.Update(newDaemonPod("node-2", corev1.ConditionTrue)))
+	require.Nil(t, nodeValues())
 }
 
 func newDaemonset(id string) *appsv1.DaemonSet {
@@ -70,6 +159,9 @@ func newDaemonset(id string) *appsv1.DaemonSet {
 			NumberMisscheduled:     1,
 			DesiredNumberScheduled: 5,
 			NumberReady:            2,
+			NumberAvailable:        2,
+			NumberUnavailable:      3,
+			UpdatedNumberScheduled: 4,
 		},
 	}
 }
@@ -0,0 +1,106 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Modes of placing resource labels on datapoints.
+const (
+	// DatapointAttributesModeCopy places the labels on datapoints in addition
+	// to the resource.
+	DatapointAttributesModeCopy = "copy"
+	// DatapointAttributesModeMove places the labels on datapoints instead of
+	// the resource.
+	DatapointAttributesModeMove = "move"
+)
+
+// placeDatapointAttributes adds the resource labels with the given keys to
+// the time series of every metric of the given resource metrics, in the order
+// of keys. Keys are the keys resource labels are reported with, i.e. after
+// renaming. With DatapointAttributesModeMove, the labels are removed from the
+// resource. Resources without a label are left as is, and labels are not
+// added to metrics already having a label with the same key.
+func placeDatapointAttributes(rms []*resourceMetrics, keys []string, mode string) {
+	if len(keys) == 0 {
+		return
+	}
+
+	for _, rm := range rms {
+		if rm.resource == nil {
+			continue
+		}
+		var labelKeys []string
+		var labelValues []string
+		for _, k := range keys {
+			if v, ok := rm.resource.Labels[k]; ok {
+				labelKeys = append(labelKeys, k)
+				labelValues = append(labelValues, v)
+			}
+		}
+		if len(labelKeys) == 0 {
+			continue
+		}
+
+		for _, m := range rm.metrics {
+			addDatapointLabels(m, labelKeys, labelValues)
+		}
+		if mode == DatapointAttributesModeMove {
+			for _, k := range labelKeys {
+				delete(rm.resource.Labels, k)
+			}
+		}
+	}
+}
+
+// addDatapointLabels adds the given labels to all the time series of m. The
+// descriptor of m is copied before being changed since descriptors are
+// shared between the metrics of all objects of a kind.
+func addDatapointLabels(m *metricspb.Metric, keys []string, values []string) {
+	existing := make(map[string]bool, len(m.MetricDescriptor.GetLabelKeys()))
+	for _, lk := range m.MetricDescriptor.GetLabelKeys() {
+		existing[lk.Key] = true
+	}
+
+	var added []int
+	for i, k := range keys {
+		if !existing[k] {
+			added = append(added, i)
+		}
+	}
+	if len(added) == 0 {
+		return
+	}
+
+	descriptor := proto.Clone(m.MetricDescriptor).(*metricspb.MetricDescriptor)
+	for _, i := range added {
+		descriptor.LabelKeys = append(descriptor.LabelKeys, &metricspb.LabelKey{Key: keys[i]})
+	}
+	m.MetricDescriptor = descriptor
+
+	for _, ts := range m.Timeseries {
+		// Label values may be shared between time series, so they are not
+		// appended to in place.
+		ts.LabelValues = ts.LabelValues[:len(ts.LabelValues):len(ts.LabelValues)]
+		for _, i := range added {
+			ts.LabelValues = append(ts.LabelValues, &metricspb.LabelValue{
+				Value:    values[i],
+				HasValue: true,
+			})
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// datapointLabels returns the labels of the single time series of m.
+func datapointLabels(t *testing.T, m *metricspb.Metric) map[string]string {
+	require.Equal(t, 1, len(m.Timeseries))
+	require.Equal(t, len(m.MetricDescriptor.LabelKeys), len(m.Timeseries[0].LabelValues))
+	labels := map[string]string{}
+	for i, lk := range m.MetricDescriptor.LabelKeys {
+		labels[lk.Key] = m.Timeseries[0].LabelValues[i].Value
+	}
+	return labels
+}
+
+func TestDataCollectorDatapointAttributes(t *testing.T) {
+	t.Run("copy", func(t *testing.T) {
+		h := newTestHarness(t, nil,
+			WithDatapointAttributes([]string{"k8s.namespace.name", "k8s.pod.name"}, DatapointAttributesModeCopy))
+		h.seed(newPodWithContainer("1", podSpecWithContainer("container-name"), podStatusWithContainer("container-name", containerIDWithPreifx("container-id"))))
+
+		found := h.metrics("k8s.pod.phase", map[string]string{"k8s.pod.uid": "test-pod-1-uid"})
+		require.Equal(t, 1, len(found))
+		require.Equal(t, "test-namespace", found[0].resource.Labels["k8s.namespace.name"])
+		require.Equal(t, "test-pod-1", found[0].resource.Labels["k8s.pod.name"])
+		require.Equal(t, map[string]string{
+			"k8s.namespace.name": "test-namespace",
+			"k8s.pod.name":       "test-pod-1",
+		}, datapointLabels(t, found[0].metric))
+	})
+
+	t.Run("move", func(t *testing.T) {
+		h := newTestHarness(t, nil,
+			WithResourceAttributeKeys(map[string]string{"k8s.namespace.name": "namespace"}),
+			WithDatapointAttributes([]string{"namespace"}, DatapointAttributesModeMove))
+		h.seed(
+			newPodWithContainer("1", podSpecWithContainer("container-name"), podStatusWithContainer("container-name", containerIDWithPreifx("container-id"))),
+			newPodWithContainer("2", podSpecWithContainer("container-name"), podStatusWithContainer("container-name", containerIDWithPreifx("container-id"))),
+		)
+
+		for _, uid := range []string{"test-pod-1-uid", "test-pod-2-uid"} {
+			found := h.metrics("k8s.pod.phase", map[string]string{"k8s.pod.uid": uid})
+			require.Equal(t, 1, len(found))
+			require.NotContains(t, found[0].resource.Labels, "namespace")
+			require.Equal(t, map[string]string{"namespace": "test-namespace"}, datapointLabels(t, found[0].metric))
+		}
+		// Shared descriptors are left untouched.
+		require.Empty(t, podPhaseMetric.LabelKeys)
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		h := newTestHarness(t, []string{"Ready"},
+			WithDatapointAttributes([]string{"k8s.namespace.name"}, DatapointAttributesModeMove))
+		h.seed(newNodeWithResources("node-1", corev1.ConditionTrue, "4", "8Gi"))
+
+		m := h.requireMetric("k8s.node.condition_ready", map[string]string{"k8s.node.name": "node-1"})
+		require.Empty(t, datapointLabels(t, m))
+	})
+}
@@ -15,30 +15,172 @@
 package collection
 
 import (
+	"sort"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
 	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
 	"go.opentelemetry.io/collector/translator/conventions"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
 
 	metadata "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/experimentalmetricmetadata"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
 )
 
+var deploymentReadyMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.deployment.ready",
+	Description: "Total number of ready pods targeted by this deployment",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var deploymentUpdatedMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.deployment.updated",
+	Description: "Total number of non-terminated pods targeted by this deployment that have the desired template spec",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var deploymentUnavailableMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name: "k8s.deployment.unavailable",
+	Description: "Total number of unavailable pods targeted by this deployment. " +
+		"This is the total number of pods that are still required for the deployment to have 100% available capacity",
+	Unit: "1",
+	Type: metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var deploymentRolloutStuckDurationMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name: "k8s.deployment.rollout_stuck_duration",
+	Description: "Time since the ongoing rollout of the deployment last made progress, " +
+		"0 if there is no ongoing rollout",
+	Unit: "s",
+	Type: metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+// deploymentReasonNewReplicaSetAvailable is the reason of the Progressing
+// condition of deployments whose rollout is complete.
+const deploymentReasonNewReplicaSetAvailable = "NewReplicaSetAvailable"
+
 func getMetricsForDeployment(dep *appsv1.Deployment) []*resourceMetrics {
 	if dep.Spec.Replicas == nil {
 		return nil
 	}
 
+	metrics := getReplicaMetrics(
+		"deployment",
+		*dep.Spec.Replicas,
+		dep.Status.AvailableReplicas,
+	)
+
+	metrics = append(metrics,
+		&metricspb.Metric{
+			MetricDescriptor: deploymentReadyMetric,
+			Timeseries: []*metricspb.TimeSeries{
+				utils.GetInt64TimeSeries(int64(dep.Status.ReadyReplicas)),
+			},
+		},
+		&metricspb.Metric{
+			MetricDescriptor: deploymentUpdatedMetric,
+			Timeseries: []*metricspb.TimeSeries{
+				utils.GetInt64TimeSeries(int64(dep.Status.UpdatedReplicas)),
+			},
+		},
+		&metricspb.Metric{
+			MetricDescriptor: deploymentUnavailableMetric,
+			Timeseries: []*metricspb.TimeSeries{
+				utils.GetInt64TimeSeries(int64(dep.Status.UnavailableReplicas)),
+			},
+		},
+	)
+
 	return []*resourceMetrics{
 		{
 			resource: getResourceForDeployment(dep),
-			metrics: getReplicaMetrics(
-				"deployment",
-				*dep.Spec.Replicas,
-				dep.Status.AvailableReplicas,
-			),
+			metrics:  metrics,
 		},
 	}
 }
 
+// getRolloutMetricsForDeployments returns, for every deployment, for how long
+// its ongoing rollout has not made progress at the given time. A rollout is
+// ongoing while the deployment controller has not observed the latest
+// generation of the deployment or the Progressing condition does not report
+// the new ReplicaSet as available. The controller updates the condition every
+// time the rollout progresses, so the duration is measured from its last
+// update, falling back to its last transition or to the creation of the
+// deployment.
+func getRolloutMetricsForDeployments(deployments cache.Store, now time.Time) []*resourceMetrics {
+	if deployments == nil {
+		return nil
+	}
+
+	var deps []*appsv1.Deployment
+	for _, obj := range deployments.List() {
+		if dep, ok := obj.(*appsv1.Deployment); ok {
+			deps = append(deps, dep)
+		}
+	}
+	sort.Slice(deps, func(i, j int) bool {
+		return deps[i].UID < deps[j].UID
+	})
+
+	out := make([]*resourceMetrics, 0, len(deps))
+	for _, dep := range deps {
+		var stuck time.Duration
+		if since, ongoing := deploymentRolloutProgressedAt(dep); ongoing {
+			stuck = now.Sub(since)
+			if stuck < 0 {
+				stuck = 0
+			}
+		}
+
+		out = append(out, &resourceMetrics{
+			resource: getResourceForDeployment(dep),
+			metrics: []*metricspb.Metric{
+				{
+					MetricDescriptor: deploymentRolloutStuckDurationMetric,
+					Timeseries: []*metricspb.TimeSeries{
+						utils.GetInt64TimeSeries(int64(stuck / time.Second)),
+					},
+				},
+			},
+		})
+	}
+	return out
+}
+
+// deploymentRolloutProgressedAt returns when the ongoing rollout of a
+// deployment last made progress, and false if there is no ongoing rollout.
+func deploymentRolloutProgressedAt(dep *appsv1.Deployment) (time.Time, bool) {
+	var progressing *appsv1.DeploymentCondition
+	for i := range dep.Status.Conditions {
+		if dep.Status.Conditions[i].Type == appsv1.DeploymentProgressing {
+			progressing = &dep.Status.Conditions[i]
+			break
+		}
+	}
+
+	observed := dep.Status.ObservedGeneration >= dep.Generation
+	switch {
+	case progressing == nil:
+		if observed {
+			return time.Time{}, false
+		}
+		return dep.CreationTimestamp.Time, true
+	case observed && progressing.Status == corev1.ConditionTrue &&
+		progressing.Reason == deploymentReasonNewReplicaSetAvailable:
+		return time.Time{}, false
+	case !progressing.LastUpdateTime.IsZero():
+		return progressing.LastUpdateTime.Time, true
+	case !progressing.LastTransitionTime.IsZero():
+		return progressing.LastTransitionTime.Time, true
+	default:
+		return dep.CreationTimestamp.Time, true
+	}
+}
+
 func getResourceForDeployment(dep *appsv1.Deployment) *resourcepb.Resource {
 	return &resourcepb.Resource{
 		Type: k8sType,
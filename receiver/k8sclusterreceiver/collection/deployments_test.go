@@ -16,12 +16,15 @@ package collection
 
 import (
 	"testing"
+	"time"
 
 	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
 	"github.com/stretchr/testify/require"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/testutils"
 )
@@ -32,7 +35,7 @@ func TestDeploymentMetrics(t *testing.T) {
 	actualResourceMetrics := getMetricsForDeployment(dep)
 
 	require.Equal(t, 1, len(actualResourceMetrics))
-	require.Equal(t, 2, len(actualResourceMetrics[0].metrics))
+	require.Equal(t, 5, len(actualResourceMetrics[0].metrics))
 
 	rm := actualResourceMetrics[0]
 	testutils.AssertResource(t, rm.resource, k8sType,
@@ -49,6 +52,125 @@ func TestDeploymentMetrics(t *testing.T) {
 
 	testutils.AssertMetrics(t, rm.metrics[1], "k8s.deployment.available",
 		metricspb.MetricDescriptor_GAUGE_INT64, 3)
+
+	testutils.AssertMetrics(t, rm.metrics[2], "k8s.deployment.ready",
+		metricspb.MetricDescriptor_GAUGE_INT64, 3)
+
+	testutils.AssertMetrics(t, rm.metrics[3], "k8s.deployment.updated",
+		metricspb.MetricDescriptor_GAUGE_INT64, 10)
+
+	testutils.AssertMetrics(t, rm.metrics[4], "k8s.deployment.unavailable",
+		metricspb.MetricDescriptor_GAUGE_INT64, 7)
+}
+
+func TestDeploymentMetricsSteady(t *testing.T) {
+	dep := newDeployment("1")
+	dep.Status = appsv1.DeploymentStatus{
+		Replicas:          10,
+		ReadyReplicas:     10,
+		AvailableReplicas: 10,
+		UpdatedReplicas:   10,
+	}
+
+	rm := getMetricsForDeployment(dep)[0]
+	require.Equal(t, 5, len(rm.metrics))
+
+	for i, want := range []struct {
+		name  string
+		value int64
+	}{
+		{"k8s.deployment.desired", 10},
+		{"k8s.deployment.available", 10},
+		{"k8s.deployment.ready", 10},
+		{"k8s.deployment.updated", 10},
+		{"k8s.deployment.unavailable", 0},
+	} {
+		testutils.AssertMetrics(t, rm.metrics[i], want.name,
+			metricspb.MetricDescriptor_GAUGE_INT64, want.value)
+	}
+}
+
+func TestDeploymentRolloutStuckDurationMetric(t *testing.T) {
+	now := time.Now()
+	progressing := func(reason string, lastUpdate time.Time) []appsv1.DeploymentCondition {
+		return []appsv1.DeploymentCondition{{
+			Type:               appsv1.DeploymentProgressing,
+			Status:             corev1.ConditionTrue,
+			Reason:             reason,
+			LastUpdateTime:     v1.NewTime(lastUpdate),
+			LastTransitionTime: v1.NewTime(lastUpdate.Add(-time.Hour)),
+		}}
+	}
+
+	complete := newDeployment("1")
+	complete.Generation = 2
+	complete.Status.ObservedGeneration = 2
+	complete.Status.Conditions = progressing("NewReplicaSetAvailable", now.Add(-time.Hour))
+
+	// The rollout last made progress 10 minutes ago.
+	stuck := newDeployment("2")
+	stuck.Generation = 3
+	stuck.Status.ObservedGeneration = 3
+	stuck.Status.Conditions = progressing("ReplicaSetUpdated", now.Add(-10*time.Minute))
+
+	// The new generation has not been observed by the controller yet.
+	unobserved := newDeployment("3")
+	unobserved.Generation = 4
+	unobserved.Status.ObservedGeneration = 3
+	unobserved.Status.Conditions = progressing("NewReplicaSetAvailable", now.Add(-30*time.Second))
+
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	for _, dep := range []*appsv1.Deployment{complete, stuck, unobserved} {
+		require.NoError(t, store.Add(dep))
+	}
+
+	rms := getRolloutMetricsForDeployments(store, now)
+	require.Equal(t, 3, len(rms))
+	for i, want := range []struct {
+		dep   *appsv1.Deployment
+		value int64
+	}{
+		{complete, 0},
+		{stuck, 600},
+		{unobserved, 30},
+	} {
+		require.Equal(t, string(want.dep.UID), rms[i].resource.Labels["k8s.deployment.uid"])
+		testutils.AssertMetrics(t, rms[i].metrics[0], "k8s.deployment.rollout_stuck_duration",
+			metricspb.MetricDescriptor_GAUGE_INT64, want.value)
+	}
+
+	require.Nil(t, getRolloutMetricsForDeployments(nil, now))
+}
+
+func TestDeploymentMetricsMidRollout(t *testing.T) {
+	dep := newDeployment("1")
+
+	// A rollout where new pods have been created from the updated template
+	// but are not yet available.
+	dep.Status = appsv1.DeploymentStatus{
+		Replicas:            12,
+		ReadyReplicas:       9,
+		AvailableReplicas:   8,
+		UpdatedReplicas:     4,
+		UnavailableReplicas: 2,
+	}
+
+	rm := getMetricsForDeployment(dep)[0]
+	require.Equal(t, 5, len(rm.metrics))
+
+	for i, want := range []struct {
+		name  string
+		value int64
+	}{
+		{"k8s.deployment.desired", 10},
+		{"k8s.deployment.available", 8},
+		{"k8s.deployment.ready", 9},
+		{"k8s.deployment.updated", 4},
+		{"k8s.deployment.unavailable", 2},
+	} {
+		testutils.AssertMetrics(t, rm.metrics[i], want.name,
+			metricspb.MetricDescriptor_GAUGE_INT64, want.value)
+	}
 }
 
 func newDeployment(id string) *appsv1.Deployment {
@@ -64,7 +186,10 @@ func newDeployment(id string) *appsv1.Deployment {
 			Replicas: &desired,
 		},
 		Status: appsv1.DeploymentStatus{
-			AvailableReplicas: 3,
+			AvailableReplicas:   3,
+			ReadyReplicas:       3,
+			UpdatedReplicas:     10,
+			UnavailableReplicas: 7,
 		},
 	}
 }
@@ -0,0 +1,37 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"fmt"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+)
+
+// metricDescriptors is the registry of the descriptors of the metrics the
+// collectors report, keyed by metric name. Descriptors are registered with
+// registerMetric where they are defined.
+var metricDescriptors = map[string]*metricspb.MetricDescriptor{}
+
+// registerMetric adds the descriptor of a metric reported by the collectors
+// to the registry the manifest is built from, and returns it. Metric names
+// must be unique.
+func registerMetric(d *metricspb.MetricDescriptor) *metricspb.MetricDescriptor {
+	if _, ok := metricDescriptors[d.Name]; ok {
+		panic(fmt.Sprintf("metric %s registered twice", d.Name))
+	}
+	metricDescriptors[d.Name] = d
+	return d
+}
@@ -0,0 +1,194 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"sort"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	"go.opentelemetry.io/collector/translator/conventions"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1beta1 "k8s.io/api/discovery/v1beta1"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
+)
+
+const (
+	k8sKeyServiceUID  = "k8s.service.uid"
+	k8sKeyServiceName = "k8s.service.name"
+)
+
+var servicesWithNoReadyEndpointsMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.cluster.services_with_no_ready_endpoints",
+	Description: "Number of services in the cluster without any ready endpoint",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+// getMetricsForServiceEndpoints returns the number of services without any
+// ready endpoint across their EndpointSlices. ExternalName services are not
+// backed by endpoints and are ignored.
+func getMetricsForServiceEndpoints(ms *metadataStore) []*resourceMetrics {
+	if ms.services == nil || ms.endpointSlices == nil {
+		return nil
+	}
+
+	// Services with at least one ready endpoint, keyed by namespace/name.
+	ready := map[string]bool{}
+	for _, obj := range ms.endpointSlices.List() {
+		slice, ok := obj.(*discoveryv1beta1.EndpointSlice)
+		if !ok {
+			continue
+		}
+		service, ok := slice.Labels[discoveryv1beta1.LabelServiceName]
+		if !ok || !hasReadyEndpoint(slice) {
+			continue
+		}
+		ready[utils.GetIDForCache(slice.Namespace, service)] = true
+	}
+
+	var notReady int64
+	for _, obj := range ms.services.List() {
+		svc, ok := obj.(*corev1.Service)
+		if !ok || svc.Spec.Type == corev1.ServiceTypeExternalName {
+			continue
+		}
+		if !ready[utils.GetIDForCache(svc.Namespace, svc.Name)] {
+			notReady++
+		}
+	}
+
+	return []*resourceMetrics{
+		{
+			resource: getResourceForCluster(),
+			metrics: []*metricspb.Metric{
+				{
+					MetricDescriptor: servicesWithNoReadyEndpointsMetric,
+					Timeseries: []*metricspb.TimeSeries{
+						utils.GetInt64TimeSeries(notReady),
+					},
+				},
+			},
+		},
+	}
+}
+
+var serviceReadyEndpointsMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.service.ready_endpoints",
+	Description: "Number of ready endpoints of the service across its EndpointSlices by address type",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+	LabelKeys:   []*metricspb.LabelKey{{Key: "address_type"}},
+})
+
+// getReadyEndpointMetricsForServices returns the number of ready endpoints of
+// each service by the address type (IPv4, IPv6 or FQDN) of its EndpointSlices,
+// so that the address families a dual-stack service is reachable over can be
+// checked. Address types none of the slices of a service have are not
+// reported, while those whose endpoints are all not ready are reported as 0.
+// Services without any slice, e.g. ExternalName services, are not reported.
+func getReadyEndpointMetricsForServices(ms *metadataStore) []*resourceMetrics {
+	if ms.services == nil || ms.endpointSlices == nil {
+		return nil
+	}
+
+	// Ready endpoints by address type, keyed by service namespace/name.
+	ready := map[string]map[string]int64{}
+	for _, obj := range ms.endpointSlices.List() {
+		slice, ok := obj.(*discoveryv1beta1.EndpointSlice)
+		if !ok {
+			continue
+		}
+		service, ok := slice.Labels[discoveryv1beta1.LabelServiceName]
+		if !ok {
+			continue
+		}
+		id := utils.GetIDForCache(slice.Namespace, service)
+		if ready[id] == nil {
+			ready[id] = map[string]int64{}
+		}
+		ready[id][string(slice.AddressType)] += countReadyEndpoints(slice)
+	}
+
+	var out []*resourceMetrics
+	for _, obj := range ms.services.List() {
+		svc, ok := obj.(*corev1.Service)
+		if !ok {
+			continue
+		}
+		byType := ready[utils.GetIDForCache(svc.Namespace, svc.Name)]
+		if len(byType) == 0 {
+			continue
+		}
+
+		addressTypes := make([]string, 0, len(byType))
+		for typ := range byType {
+			addressTypes = append(addressTypes, typ)
+		}
+		sort.Strings(addressTypes)
+		timeseries := make([]*metricspb.TimeSeries, 0, len(addressTypes))
+		for _, typ := range addressTypes {
+			timeseries = append(timeseries, utils.GetInt64TimeSeriesWithLabels(byType[typ],
+				[]*metricspb.LabelValue{{Value: typ, HasValue: true}}))
+		}
+
+		out = append(out, &resourceMetrics{
+			resource: getResourceForService(svc),
+			metrics: []*metricspb.Metric{
+				{
+					MetricDescriptor: serviceReadyEndpointsMetric,
+					Timeseries:       timeseries,
+				},
+			},
+		})
+	}
+	return out
+}
+
+func getResourceForService(svc *corev1.Service) *resourcepb.Resource {
+	return &resourcepb.Resource{
+		Type: k8sType,
+		Labels: map[string]string{
+			k8sKeyServiceUID:                  string(svc.UID),
+			k8sKeyServiceName:                 svc.Name,
+			conventions.AttributeK8sNamespace: svc.Namespace,
+			conventions.AttributeK8sCluster:   svc.ClusterName,
+		},
+	}
+}
+
+// countReadyEndpoints returns the number of ready endpoints of the slice,
+// counting those in an unknown state as ready like hasReadyEndpoint does.
+func countReadyEndpoints(slice *discoveryv1beta1.EndpointSlice) int64 {
+	var n int64
+	for _, ep := range slice.Endpoints {
+		if ep.Conditions.Ready == nil || *ep.Conditions.Ready {
+			n++
+		}
+	}
+	return n
+}
+
+// hasReadyEndpoint returns true if any endpoint of the slice is ready. As
+// recommended by the API, endpoints in an unknown state are considered ready.
+func hasReadyEndpoint(slice *discoveryv1beta1.EndpointSlice) bool {
+	for _, ep := range slice.Endpoints {
+		if ep.Conditions.Ready == nil || *ep.Conditions.Ready {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"testing"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1beta1 "k8s.io/api/discovery/v1beta1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/testutils"
+)
+
+func TestServicesWithNoReadyEndpoints(t *testing.T) {
+	services := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	endpointSlices := cache.NewStore(cache.MetaNamespaceKeyFunc)
+
+	for _, svc := range []*corev1.Service{
+		newService("ready"),
+		newService("partially-ready"),
+		newService("not-ready"),
+		newService("no-slices"),
+		withServiceType(newService("external"), corev1.ServiceTypeExternalName),
+	} {
+		require.NoError(t, services.Add(svc))
+	}
+
+	for _, slice := range []*discoveryv1beta1.EndpointSlice{
+		newEndpointSlice("ready-1", "ready", boolPtr(true)),
+		newEndpointSlice("partially-ready-1", "partially-ready", boolPtr(false)),
+		newEndpointSlice("partially-ready-2", "partially-ready", nil),
+		newEndpointSlice("not-ready-1", "not-ready", boolPtr(false), boolPtr(false)),
+		newEndpointSlice("not-ready-2", "not-ready"),
+	} {
+		require.NoError(t, endpointSlices.Add(slice))
+	}
+
+	ms := &metadataStore{services: services, endpointSlices: endpointSlices}
+	rms := getMetricsForServiceEndpoints(ms)
+	require.Equal(t, 1, len(rms))
+	testutils.AssertResource(t, rms[0].resource, k8sType, map[string]string{})
+	require.Equal(t, 1, len(rms[0].metrics))
+	testutils.AssertMetrics(t, rms[0].metrics[0], "k8s.cluster.services_with_no_ready_endpoints",
+		metricspb.MetricDescriptor_GAUGE_INT64, 2)
+
+	// Not reported unless EndpointSlices are watched.
+	require.Nil(t, getMetricsForServiceEndpoints(&metadataStore{services: services}))
+
+	dc := NewDataCollector(zap.NewNop(), []string{})
+	dc.SetupMetadataStore(&corev1.Service{}, services)
+	dc.SetupMetadataStore(&discoveryv1beta1.EndpointSlice{}, endpointSlices)
+	mds := dc.CollectMetricData(time.Now())
+	// Along with the ready endpoints of each of the 3 services with slices.
+	require.Equal(t, 4, len(mds))
+	require.Equal(t, "k8s.cluster.services_with_no_ready_endpoints", mds[0].Metrics[0].MetricDescriptor.Name)
+}
+
+func TestServiceReadyEndpointsByAddressType(t *testing.T) {
+	services := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	endpointSlices := cache.NewStore(cache.MetaNamespaceKeyFunc)
+
+	dualStack := newService("dual-stack")
+	for _, svc := range []*corev1.Service{dualStack, newService("no-slices")} {
+		require.NoError(t, services.Add(svc))
+	}
+	for _, slice := range []*discoveryv1beta1.EndpointSlice{
+		newEndpointSlice("dual-stack-ipv4-1", "dual-stack", boolPtr(true), nil, boolPtr(false)),
+		newEndpointSlice("dual-stack-ipv4-2", "dual-stack", boolPtr(true)),
+		withAddressType(newEndpointSlice("dual-stack-ipv6-1", "dual-stack", boolPtr(false)),
+			discoveryv1beta1.AddressTypeIPv6),
+	} {
+		require.NoError(t, endpointSlices.Add(slice))
+	}
+
+	rms := getReadyEndpointMetricsForServices(&metadataStore{services: services, endpointSlices: endpointSlices})
+	require.Equal(t, 1, len(rms))
+	testutils.AssertResource(t, rms[0].resource, k8sType, map[string]string{
+		"k8s.service.uid":    "dual-stack-uid",
+		"k8s.service.name":   "dual-stack",
+		"k8s.namespace.name": "test-namespace",
+		"k8s.cluster.name":   "",
+	})
+	require.Equal(t, 1, len(rms[0].metrics))
+	m := rms[0].metrics[0]
+	require.Equal(t, "k8s.service.ready_endpoints", m.MetricDescriptor.Name)
+	require.Equal(t, 2, len(m.Timeseries))
+	for i, want := range []struct {
+		addressType string
+		ready       int64
+	}{{"IPv4", 3}, {"IPv6", 0}} {
+		require.Equal(t, want.addressType, m.Timeseries[i].LabelValues[0].Value)
+		require.Equal(t, want.ready, m.Timeseries[i].Points[0].GetInt64Value())
+	}
+
+	// Not reported unless EndpointSlices are watched.
+	require.Nil(t, getReadyEndpointMetricsForServices(&metadataStore{services: services}))
+}
+
+func newService(name string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: "test-namespace",
+			UID:       types.UID(name + "-uid"),
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: map[string]string{"app": name},
+		},
+	}
+}
+
+func withServiceType(svc *corev1.Service, typ corev1.ServiceType) *corev1.Service {
+	svc.Spec.Type = typ
+	return svc
+}
+
+func newEndpointSlice(name, service string, ready ...*bool) *discoveryv1beta1.EndpointSlice {
+	slice := &discoveryv1beta1.EndpointSlice{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: "test-namespace",
+			UID:       types.UID(name + "-uid"),
+			Labels:    map[string]string{discoveryv1beta1.LabelServiceName: service},
+		},
+		AddressType: discoveryv1beta1.AddressTypeIPv4,
+	}
+	for _, r := range ready {
+		slice.Endpoints = append(slice.Endpoints, discoveryv1beta1.Endpoint{
+			Addresses:  []string{"10.0.0.1"},
+			Conditions: discoveryv1beta1.EndpointConditions{Ready: r},
+		})
+	}
+	return slice
+}
+
+func withAddressType(
+	slice *discoveryv1beta1.EndpointSlice, typ discoveryv1beta1.AddressType) *discoveryv1beta1.EndpointSlice {
+	slice.AddressType = typ
+	return slice
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
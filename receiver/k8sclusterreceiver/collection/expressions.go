@@ -0,0 +1,344 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
+)
+
+// ExpressionMetricKinds are the lower-cased kinds expression metrics can be
+// defined for, i.e. those whose objects have metrics of their own.
+var ExpressionMetricKinds = []string{
+	"cronjob", "daemonset", "deployment", "horizontalpodautoscaler", "job", "namespace", "node", "pod",
+	"replicaset", "replicationcontroller", "resourcequota", "statefulset",
+}
+
+// ExpressionMetric is a gauge computed from the fields of the objects of a
+// kind, reported along with the other metrics of each object.
+type ExpressionMetric struct {
+	// Kind is the lower-cased kind of the objects, one of
+	// ExpressionMetricKinds.
+	Kind        string
+	Name        string
+	Description string
+	Unit        string
+	Expression  *Expression
+}
+
+// Expression is an arithmetic expression over the numeric fields of an
+// object, e.g. "status.replicas - status.readyReplicas". It supports decimal
+// numbers, field paths made of the JSON names of the fields separated by
+// dots, the + - * / operators, unary minus and parentheses. It has neither
+// variables nor function calls, so evaluating it cannot have side effects.
+type Expression struct {
+	source string
+	root   exprNode
+}
+
+// errDivisionByZero is returned when evaluating an expression divides by 0.
+var errDivisionByZero = errors.New("division by zero")
+
+func (e *Expression) String() string {
+	return e.source
+}
+
+// ParseExpression parses an expression, see Expression.
+func ParseExpression(s string) (*Expression, error) {
+	p := &exprParser{src: s}
+	p.next()
+	root, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokenEnd {
+		return nil, fmt.Errorf("unexpected %q at offset %d", p.tok.text, p.tok.pos)
+	}
+	return &Expression{source: s, root: root}, nil
+}
+
+// Evaluate evaluates the expression over the fields of obj, as returned by
+// runtime.DefaultUnstructuredConverter. Missing fields are 0, since the API
+// omits fields with a value of 0, e.g. status.readyReplicas.
+func (e *Expression) Evaluate(obj map[string]interface{}) (float64, error) {
+	return e.root.eval(obj)
+}
+
+type exprNode interface {
+	eval(obj map[string]interface{}) (float64, error)
+}
+
+type numberNode float64
+
+func (n numberNode) eval(map[string]interface{}) (float64, error) {
+	return float64(n), nil
+}
+
+type fieldNode []string
+
+func (n fieldNode) eval(obj map[string]interface{}) (float64, error) {
+	var v interface{} = obj
+	for _, name := range n {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return 0, fmt.Errorf("field %s is not a number", strings.Join(n, "."))
+		}
+		if v, ok = m[name]; !ok {
+			return 0, nil
+		}
+	}
+	switch v := v.(type) {
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case nil:
+		return 0, nil
+	}
+	return 0, fmt.Errorf("field %s is not a number", strings.Join(n, "."))
+}
+
+type negNode struct {
+	operand exprNode
+}
+
+func (n negNode) eval(obj map[string]interface{}) (float64, error) {
+	v, err := n.operand.eval(obj)
+	return -v, err
+}
+
+type binaryNode struct {
+	op          byte
+	left, right exprNode
+}
+
+func (n binaryNode) eval(obj map[string]interface{}) (float64, error) {
+	l, err := n.left.eval(obj)
+	if err != nil {
+		return 0, err
+	}
+	r, err := n.right.eval(obj)
+	if err != nil {
+		return 0, err
+	}
+	switch n.op {
+	case '+':
+		return l + r, nil
+	case '-':
+		return l - r, nil
+	case '*':
+		return l * r, nil
+	default:
+		if r == 0 {
+			return 0, errDivisionByZero
+		}
+		return l / r, nil
+	}
+}
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenNumber
+	tokenField
+	tokenOperator
+	tokenInvalid
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// exprParser is a recursive descent parser of expressions.
+type exprParser struct {
+	src string
+	pos int
+	tok token
+}
+
+// next scans the next token into p.tok.
+func (p *exprParser) next() {
+	for p.pos < len(p.src) && p.src[p.pos] == ' ' {
+		p.pos++
+	}
+	start := p.pos
+	if p.pos == len(p.src) {
+		p.tok = token{kind: tokenEnd, text: "end of expression", pos: start}
+		return
+	}
+
+	c := p.src[p.pos]
+	switch {
+	case strings.IndexByte("+-*/()", c) >= 0:
+		p.pos++
+		p.tok = token{kind: tokenOperator, text: string(c), pos: start}
+	case isDigit(c) || c == '.':
+		for p.pos < len(p.src) && (isDigit(p.src[p.pos]) || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		p.tok = token{kind: tokenNumber, text: p.src[start:p.pos], pos: start}
+	case isIdentStart(c):
+		for p.pos < len(p.src) && (isIdentStart(p.src[p.pos]) || isDigit(p.src[p.pos]) || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		p.tok = token{kind: tokenField, text: p.src[start:p.pos], pos: start}
+	default:
+		p.pos++
+		p.tok = token{kind: tokenInvalid, text: string(c), pos: start}
+	}
+}
+
+// parseSum parses terms separated by + and -.
+func (p *exprParser) parseSum() (exprNode, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind == tokenOperator && (p.tok.text == "+" || p.tok.text == "-") {
+		op := p.tok.text[0]
+		p.next()
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseProduct parses factors separated by * and /.
+func (p *exprParser) parseProduct() (exprNode, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind == tokenOperator && (p.tok.text == "*" || p.tok.text == "/") {
+		op := p.tok.text[0]
+		p.next()
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseFactor parses a number, a field path, a negated factor or a
+// parenthesized expression.
+func (p *exprParser) parseFactor() (exprNode, error) {
+	tok := p.tok
+	switch {
+	case tok.kind == tokenNumber:
+		v, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at offset %d", tok.text, tok.pos)
+		}
+		p.next()
+		return numberNode(v), nil
+	case tok.kind == tokenField:
+		path := strings.Split(tok.text, ".")
+		for _, name := range path {
+			if name == "" {
+				return nil, fmt.Errorf("invalid field path %q at offset %d", tok.text, tok.pos)
+			}
+		}
+		p.next()
+		return fieldNode(path), nil
+	case tok.kind == tokenOperator && tok.text == "-":
+		p.next()
+		operand, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		return negNode{operand: operand}, nil
+	case tok.kind == tokenOperator && tok.text == "(":
+		p.next()
+		inner, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokenOperator || p.tok.text != ")" {
+			return nil, fmt.Errorf("expected \")\" at offset %d, got %q", p.tok.pos, p.tok.text)
+		}
+		p.next()
+		return inner, nil
+	}
+	return nil, fmt.Errorf("unexpected %q at offset %d", tok.text, tok.pos)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// getExpressionMetrics returns the expression metrics of the kind of obj
+// evaluated over obj. Metrics whose expression fails to evaluate, e.g. since
+// a field is not a number, are left out.
+func (dc *DataCollector) getExpressionMetrics(kind string, obj interface{}) []*metricspb.Metric {
+	ems := dc.expressionMetrics[kind]
+	if len(ems) == 0 {
+		return nil
+	}
+	ro, ok := obj.(runtime.Object)
+	if !ok {
+		return nil
+	}
+	fields, err := runtime.DefaultUnstructuredConverter.ToUnstructured(ro)
+	if err != nil {
+		dc.logger.Debug("Failed to convert object to evaluate expression metrics", zap.Error(err))
+		return nil
+	}
+
+	out := make([]*metricspb.Metric, 0, len(ems))
+	for _, em := range ems {
+		v, err := em.metric.Expression.Evaluate(fields)
+		if err != nil {
+			dc.logger.Debug("Failed to evaluate expression metric",
+				zap.String("metric", em.metric.Name), zap.Error(err))
+			continue
+		}
+		out = append(out, &metricspb.Metric{
+			MetricDescriptor: em.descriptor,
+			Timeseries:       []*metricspb.TimeSeries{utils.GetDoubleTimeSeries(v)},
+		})
+	}
+	return out
+}
+
+// expressionMetric is an ExpressionMetric along with its descriptor.
+type expressionMetric struct {
+	metric     ExpressionMetric
+	descriptor *metricspb.MetricDescriptor
+}
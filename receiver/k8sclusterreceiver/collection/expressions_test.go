@@ -0,0 +1,113 @@
+// Copyright 2020 OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateExpression(t *testing.T) {
+	obj := map[string]interface{}{
+		"spec":   map[string]interface{}{"replicas": int64(10), "paused": true},
+		"status": map[string]interface{}{"readyReplicas": int64(4), "conditions": []interface{}{}},
+	}
+
+	tests := []struct {
+		expression  string
+		want        float64
+		expectedErr string
+	}{
+		{expression: "spec.replicas - status.readyReplicas", want: 6},
+		{expression: "1 + 2 * 3", want: 7},
+		{expression: "(1 + 2) * 3", want: 9},
+		{expression: "10 - 4 - 3", want: 3},
+		{expression: "-status.readyReplicas / 8", want: -0.5},
+		{expression: "spec.paused", want: 1},
+		// Fields left out by the API are 0.
+		{expression: "status.availableReplicas + 1", want: 1},
+		{expression: "status.conditions", expectedErr: "field status.conditions is not a number"},
+		{expression: "spec.replicas.value", expectedErr: "field spec.replicas.value is not a number"},
+		{expression: "spec.replicas / status.availableReplicas", expectedErr: "division by zero"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			expr, err := ParseExpression(tt.expression)
+			require.NoError(t, err)
+
+			got, err := expr.Evaluate(obj)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseInvalidExpression(t *testing.T) {
+	tests := []struct {
+		expression  string
+		expectedErr string
+	}{
+		{expression: "", expectedErr: `unexpected "end of expression" at offset 0`},
+		{expression: "spec.replicas -", expectedErr: `unexpected "end of expression" at offset 15`},
+		{expression: "(spec.replicas", expectedErr: `expected ")" at offset 14, got "end of expression"`},
+		{expression: "spec.replicas)", expectedErr: `unexpected ")" at offset 13`},
+		{expression: "1.2.3", expectedErr: `invalid number "1.2.3" at offset 0`},
+		{expression: "spec..replicas", expectedErr: `invalid field path "spec..replicas" at offset 0`},
+		{expression: "len(spec)", expectedErr: `unexpected "(" at offset 3`},
+		{expression: "spec.replicas % 2", expectedErr: `unexpected "%" at offset 14`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			_, err := ParseExpression(tt.expression)
+			require.EqualError(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestExpressionMetrics(t *testing.T) {
+	notReady, err := ParseExpression("spec.replicas - status.readyReplicas")
+	require.NoError(t, err)
+	invalid, err := ParseExpression("metadata.name")
+	require.NoError(t, err)
+
+	h := newTestHarness(t, nil, WithExpressionMetrics([]ExpressionMetric{
+		{Kind: "deployment", Name: "k8s.deployment.not_ready", Unit: "1", Expression: notReady},
+		{Kind: "deployment", Name: "k8s.deployment.invalid", Unit: "1", Expression: invalid},
+		{Kind: "statefulset", Name: "k8s.statefulset.not_ready", Unit: "1", Expression: notReady},
+	}))
+	dep := newDeployment("1")
+	h.seed(dep)
+
+	labels := map[string]string{"k8s.deployment.uid": string(dep.UID)}
+	m := h.requireMetric("k8s.deployment.not_ready", labels)
+	require.Equal(t, metricspb.MetricDescriptor_GAUGE_DOUBLE, m.MetricDescriptor.Type)
+	require.Equal(t, 7.0, m.Timeseries[0].Points[0].GetDoubleValue())
+
+	// Metrics whose expression fails to evaluate are left out, as are those
+	// of other kinds.
+	h.requireNoMetric("k8s.deployment.invalid", labels)
+	h.requireNoMetric("k8s.statefulset.not_ready", nil)
+
+	dep.Status.ReadyReplicas = 10
+	h.seed(dep)
+	m = h.requireMetric("k8s.deployment.not_ready", labels)
+	require.Equal(t, 0.0, m.Timeseries[0].Points[0].GetDoubleValue())
+}
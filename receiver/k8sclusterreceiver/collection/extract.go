@@ -0,0 +1,235 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FieldExtractionKinds are the lower-cased kinds labels and annotations can
+// be extracted from, the same as ExpressionMetricKinds.
+var FieldExtractionKinds = ExpressionMetricKinds
+
+// FieldExtractionRules describe the labels and annotations of objects
+// extracted to resource labels of their metrics, as configured for the
+// k8sattributes processor.
+type FieldExtractionRules struct {
+	Labels      []FieldExtractionRule
+	Annotations []FieldExtractionRule
+	// ExcludedAnnotations are the keys of annotations never extracted.
+	ExcludedAnnotations []string
+}
+
+// FieldExtractionRule extracts a label or an annotation of the objects of a
+// kind, selected by Key, or those selected by KeyRegex.
+type FieldExtractionRule struct {
+	// Kind is the lower-cased kind of the objects, one of
+	// FieldExtractionKinds.
+	Kind string
+	// TagName is the name of the resource label. With KeyRegex, it is
+	// expanded with the submatches of the key, e.g. "$1". It defaults to
+	// k8s.<kind>.labels.<key> or k8s.<kind>.annotations.<key>.
+	TagName  string
+	Key      string
+	KeyRegex *regexp.Regexp
+	// Regex, if set, extracts its submatch named "value" from the value,
+	// which is not extracted if it does not match.
+	Regex *regexp.Regexp
+	// JSON parses the value as a JSON object instead and extracts each of
+	// its fields to a label named TagName followed by the field name.
+	JSON bool
+}
+
+func (fr FieldExtractionRules) isEmpty() bool {
+	return len(fr.Labels) == 0 && len(fr.Annotations) == 0
+}
+
+// readsLabels returns whether labels of objects of the lower-cased kind are
+// extracted.
+func (fr FieldExtractionRules) readsLabels(kind string) bool {
+	return hasRuleForKind(fr.Labels, kind)
+}
+
+// readsAnnotations returns whether annotations of objects of the lower-cased
+// kind are extracted.
+func (fr FieldExtractionRules) readsAnnotations(kind string) bool {
+	return hasRuleForKind(fr.Annotations, kind)
+}
+
+func hasRuleForKind(rules []FieldExtractionRule, kind string) bool {
+	for _, rule := range rules {
+		if rule.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// addExtractedFields adds the labels extracted from the labels and
+// annotations of obj, of the given kind, to the resources of its metrics.
+// Extracted labels never override labels already set, e.g. those
+// identifying the object. The resources of pods, which are also reported by
+// metrics computed across objects, are built with their extracted labels by
+// getResourceForPod instead.
+func addExtractedFields(kind string, obj interface{}, rms []*resourceMetrics, rules FieldExtractionRules) {
+	if rules.isEmpty() || kind == k8sKindPod {
+		return
+	}
+	o, ok := obj.(v1.Object)
+	if !ok {
+		return
+	}
+
+	extracted := getExtractedFields(strings.ToLower(kind), o, rules)
+	if len(extracted) == 0 {
+		return
+	}
+
+	for _, rm := range rms {
+		if rm.resource == nil {
+			continue
+		}
+		if rm.resource.Labels == nil {
+			rm.resource.Labels = map[string]string{}
+		}
+		for k, v := range extracted {
+			if _, ok := rm.resource.Labels[k]; !ok {
+				rm.resource.Labels[k] = v
+			}
+		}
+	}
+}
+
+// getExtractedFields returns the labels extracted from the labels and
+// annotations of o, of the given lower-cased kind.
+func getExtractedFields(kind string, o v1.Object, rules FieldExtractionRules) map[string]string {
+	extracted := map[string]string{}
+	if rules.isEmpty() {
+		return extracted
+	}
+	extractFields(extracted, kind, "labels", o.GetLabels(), rules.Labels, nil)
+	extractFields(extracted, kind, "annotations", o.GetAnnotations(), rules.Annotations, rules.ExcludedAnnotations)
+	return extracted
+}
+
+// extractFields adds the fields of the given kind of object extracted by the
+// rules for the kind to out. fieldType is either labels or annotations.
+func extractFields(
+	out map[string]string, kind, fieldType string, fields map[string]string,
+	rules []FieldExtractionRule, excluded []string) {
+	if len(fields) == 0 {
+		return
+	}
+	isExcluded := make(map[string]bool, len(excluded))
+	for _, key := range excluded {
+		isExcluded[key] = true
+	}
+
+	// Keys are sorted so that the value extracted to a tag name is stable
+	// should several keys match.
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, rule := range rules {
+		if rule.Kind != kind {
+			continue
+		}
+		for _, key := range keys {
+			if isExcluded[key] {
+				continue
+			}
+
+			var name string
+			switch {
+			case rule.KeyRegex != nil:
+				match := rule.KeyRegex.FindStringSubmatchIndex(key)
+				if match == nil {
+					continue
+				}
+				name = string(rule.KeyRegex.ExpandString(nil, rule.TagName, key, match))
+			case key == rule.Key:
+				name = rule.TagName
+			default:
+				continue
+			}
+			if rule.JSON {
+				for field, value := range parseJSONField(fields[key]) {
+					if _, ok := out[name+field]; !ok {
+						out[name+field] = value
+					}
+				}
+				continue
+			}
+			if name == "" {
+				name = labelInfoMetricPrefixes[kind] + "." + fieldType + "." + key
+			}
+
+			value, ok := extractValue(fields[key], rule.Regex)
+			if !ok {
+				continue
+			}
+			if _, ok := out[name]; !ok {
+				out[name] = value
+			}
+		}
+	}
+}
+
+// extractValue returns the submatch named "value" of regex in value, or
+// value itself if regex is nil.
+func extractValue(value string, regex *regexp.Regexp) (string, bool) {
+	if regex == nil {
+		return value, true
+	}
+	match := regex.FindStringSubmatch(value)
+	if match == nil {
+		return "", false
+	}
+	for i, name := range regex.SubexpNames() {
+		if name == "value" {
+			return match[i], true
+		}
+	}
+	return "", false
+}
+
+// parseJSONField returns the fields of a JSON object. Values that are not
+// strings are returned in their JSON encoding. Values that are not valid JSON
+// objects yield no fields.
+func parseJSONField(v string) map[string]string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(v), &fields); err != nil {
+		return nil
+	}
+
+	out := make(map[string]string, len(fields))
+	for field, raw := range fields {
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			out[field] = s
+			continue
+		}
+		out[field] = string(raw)
+	}
+	return out
+}
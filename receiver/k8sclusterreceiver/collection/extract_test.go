@@ -0,0 +1,130 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldExtractionRules(t *testing.T) {
+	pod := newPodWithContainer("0", podSpecWithContainer("container-name"),
+		podStatusWithContainer("container-name", containerIDWithPreifx("container-id")))
+	pod.Labels = map[string]string{
+		"team":                      "payments",
+		"app.kubernetes.io/part-of": "checkout",
+		"app.kubernetes.io/version": "1.2.3",
+	}
+	pod.Annotations = map[string]string{
+		"mycompany.com/deploy":                             "env=prod,region=eu",
+		"kubectl.kubernetes.io/last-applied-configuration": "{}",
+	}
+	dep := newDeployment("0")
+	dep.Labels = map[string]string{"team": "checkout"}
+
+	h := newTestHarness(t, nil, WithFieldExtractionRules(FieldExtractionRules{
+		Labels: []FieldExtractionRule{
+			{Kind: "pod", Key: "team"},
+			{Kind: "pod", KeyRegex: regexp.MustCompile(`^app\.kubernetes\.io/(.+)$`), TagName: "app.$1"},
+			{Kind: "deployment", Key: "team", TagName: "team"},
+			// Labels identifying the object are not overridden.
+			{Kind: "pod", Key: "team", TagName: "k8s.pod.name"},
+		},
+		Annotations: []FieldExtractionRule{
+			{Kind: "pod", Key: "mycompany.com/deploy", TagName: "env", Regex: regexp.MustCompile(`env=(?P<value>[^,]+)`)},
+			{Kind: "pod", Key: "mycompany.com/deploy", TagName: "zone", Regex: regexp.MustCompile(`zone=(?P<value>[^,]+)`)},
+			{Kind: "pod", KeyRegex: regexp.MustCompile(`^kubectl\.kubernetes\.io/`)},
+		},
+		ExcludedAnnotations: []string{"kubectl.kubernetes.io/last-applied-configuration"},
+	}))
+	h.seed(pod, dep)
+
+	for _, name := range []string{"k8s.pod.phase", "k8s.container.ready"} {
+		m := h.metrics(name, nil)
+		require.Len(t, m, 1)
+		resource := m[0].resource.Labels
+		require.Equal(t, "payments", resource["k8s.pod.labels.team"])
+		require.Equal(t, "checkout", resource["app.part-of"])
+		require.Equal(t, "1.2.3", resource["app.version"])
+		require.Equal(t, "test-pod-0", resource["k8s.pod.name"])
+		require.Equal(t, "prod", resource["env"])
+		require.NotContains(t, resource, "zone")
+		require.NotContains(t, resource, "k8s.pod.annotations.kubectl.kubernetes.io/last-applied-configuration")
+		require.NotContains(t, resource, "team")
+	}
+
+	m := h.metrics("k8s.deployment.desired", nil)
+	require.Len(t, m, 1)
+	require.Equal(t, "checkout", m[0].resource.Labels["team"])
+}
+
+func TestExtractFieldsJSON(t *testing.T) {
+	annotations := map[string]string{
+		"mycompany.com/telemetry": `{"team": "payments", "service": "checkout", "tier": 1}`,
+		"mycompany.com/invalid":   "not json",
+	}
+
+	out := map[string]string{}
+	extractFields(out, "pod", "annotations", annotations, []FieldExtractionRule{
+		{Kind: "pod", Key: "mycompany.com/telemetry", JSON: true},
+		{Kind: "pod", Key: "mycompany.com/telemetry", TagName: "telemetry.", JSON: true},
+		{Kind: "pod", Key: "mycompany.com/invalid", JSON: true},
+	}, nil)
+	require.Equal(t, map[string]string{
+		"team":              "payments",
+		"service":           "checkout",
+		"tier":              "1",
+		"telemetry.team":    "payments",
+		"telemetry.service": "checkout",
+		"telemetry.tier":    "1",
+	}, out)
+
+	// Excluded annotations are not parsed either.
+	out = map[string]string{}
+	extractFields(out, "pod", "annotations", annotations, []FieldExtractionRule{
+		{Kind: "pod", Key: "mycompany.com/telemetry", JSON: true},
+	}, []string{"mycompany.com/telemetry"})
+	require.Empty(t, out)
+}
+
+func TestPodFieldsExtractedToResources(t *testing.T) {
+	pod := newPodWithContainer(
+		"1",
+		podSpecWithContainer("container-name"),
+		podStatusWithContainer("container-name", containerIDWithPreifx("container-id")),
+	)
+	pod.Annotations = map[string]string{
+		"mycompany.com/telemetry":     `{"team": "payments", "k8s.pod.name": "spoofed"}`,
+		"telemetry.mycompany.com/env": "prod",
+	}
+	rules := FieldExtractionRules{Annotations: []FieldExtractionRule{
+		{Kind: "pod", Key: "mycompany.com/telemetry", JSON: true},
+		{Kind: "pod", KeyRegex: regexp.MustCompile(`^telemetry\.mycompany\.com/(.*)$`), TagName: "$1"},
+	}}
+
+	rms := getMetricsForPod(pod, nil, rules)
+	require.Equal(t, 2, len(rms))
+	for _, rm := range rms {
+		require.Equal(t, "payments", rm.resource.Labels["team"])
+		require.Equal(t, "prod", rm.resource.Labels["env"])
+		// Labels identifying the pod cannot be overridden.
+		require.Equal(t, "test-pod-1", rm.resource.Labels["k8s.pod.name"])
+	}
+
+	// Metrics computed across pods carry the extracted labels as well.
+	require.Equal(t, "payments", getResourceForPod(pod, rules).Labels["team"])
+}
@@ -0,0 +1,147 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumerdata"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+)
+
+// testHarness stands in for the informers of the receiver in collector
+// tests. Seeded objects are added to a store per kind, which is handed to
+// the DataCollector like an informer store, and synced the same way the
+// watcher syncs objects on add and update events.
+type testHarness struct {
+	t      *testing.T
+	dc     *DataCollector
+	stores map[reflect.Type]cache.Store
+	// now is the time at which metrics are collected.
+	now time.Time
+}
+
+// newTestHarness returns a testHarness around a DataCollector created with
+// the given node conditions and options.
+func newTestHarness(t *testing.T, nodeConditionsToReport []string, opts ...Option) *testHarness {
+	return &testHarness{
+		t:      t,
+		dc:     NewDataCollector(zap.NewNop(), nodeConditionsToReport, opts...),
+		stores: map[reflect.Type]cache.Store{},
+		now:    time.Now(),
+	}
+}
+
+// store returns the store of objects of the same kind as o, setting it up
+// with the DataCollector on first use.
+func (h *testHarness) store(o runtime.Object) cache.Store {
+	typ := reflect.TypeOf(o)
+	store, ok := h.stores[typ]
+	if !ok {
+		store = cache.NewStore(cache.MetaNamespaceKeyFunc)
+		h.dc.SetupMetadataStore(o, store)
+		h.stores[typ] = store
+	}
+	return store
+}
+
+// seed adds or updates the objects in their stores and syncs their metrics.
+func (h *testHarness) seed(objs ...runtime.Object) {
+	for _, o := range objs {
+		require.NoError(h.t, h.store(o).Update(o))
+		h.dc.SyncMetrics(o)
+	}
+}
+
+// remove deletes the objects from their stores and from the metrics store.
+func (h *testHarness) remove(objs ...runtime.Object) {
+	for _, o := range objs {
+		require.NoError(h.t, h.store(o).Delete(o))
+		h.dc.RemoveFromMetricsStore(o)
+	}
+}
+
+// advance moves the collection time of the harness forward by d.
+func (h *testHarness) advance(d time.Duration) {
+	h.now = h.now.Add(d)
+}
+
+// collect returns the metrics reported at the collection time of the harness.
+func (h *testHarness) collect() []consumerdata.MetricsData {
+	return h.dc.CollectMetricData(h.now)
+}
+
+// harnessMetric is a collected metric along with the resource it was
+// reported on.
+type harnessMetric struct {
+	resource *resourcepb.Resource
+	metric   *metricspb.Metric
+}
+
+// metrics returns the collected metrics with the given name, optionally
+// restricted to resources having all the given resource labels.
+func (h *testHarness) metrics(name string, resourceLabels map[string]string) []harnessMetric {
+	var out []harnessMetric
+	for _, md := range h.collect() {
+		if !hasResourceLabels(md.Resource, resourceLabels) {
+			continue
+		}
+		for _, m := range md.Metrics {
+			if m.MetricDescriptor.Name == name {
+				out = append(out, harnessMetric{resource: md.Resource, metric: m})
+			}
+		}
+	}
+	return out
+}
+
+// requireMetric returns the single collected metric with the given name on
+// a resource with the given resource labels, failing the test otherwise.
+func (h *testHarness) requireMetric(name string, resourceLabels map[string]string) *metricspb.Metric {
+	found := h.metrics(name, resourceLabels)
+	require.Equal(h.t, 1, len(found), "expected a single %s metric on %v", name, resourceLabels)
+	return found[0].metric
+}
+
+// requireInt64Value asserts the value of the single, unlabelled time series
+// of the metric with the given name on a resource with the given labels.
+func (h *testHarness) requireInt64Value(name string, resourceLabels map[string]string, want int64) {
+	m := h.requireMetric(name, resourceLabels)
+	require.Equal(h.t, 1, len(m.Timeseries))
+	require.Empty(h.t, m.Timeseries[0].LabelValues)
+	require.Equal(h.t, want, m.Timeseries[0].Points[0].GetInt64Value(), name)
+}
+
+// requireNoMetric asserts that no metric with the given name is reported on
+// a resource with the given resource labels.
+func (h *testHarness) requireNoMetric(name string, resourceLabels map[string]string) {
+	require.Empty(h.t, h.metrics(name, resourceLabels), name)
+}
+
+func hasResourceLabels(r *resourcepb.Resource, labels map[string]string) bool {
+	for k, v := range labels {
+		if r == nil || r.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
@@ -18,39 +18,41 @@ import (
 	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
 	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
 	"go.opentelemetry.io/collector/translator/conventions"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	"k8s.io/api/autoscaling/v2beta1"
+	"k8s.io/api/autoscaling/v2beta2"
 
 	metadata "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/experimentalmetricmetadata"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
 )
 
-var hpaMaxReplicasMetric = &metricspb.MetricDescriptor{
+var hpaMaxReplicasMetric = registerMetric(&metricspb.MetricDescriptor{
 	Name:        "k8s.hpa.max_replicas",
 	Description: "Maximum number of replicas to which the autoscaler can scale up",
 	Unit:        "1",
 	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
-}
+})
 
-var hpaMinReplicasMetric = &metricspb.MetricDescriptor{
+var hpaMinReplicasMetric = registerMetric(&metricspb.MetricDescriptor{
 	Name:        "k8s.hpa.min_replicas",
 	Description: "Minimum number of replicas to which the autoscaler can scale down",
 	Unit:        "1",
 	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
-}
+})
 
-var hpaCurrentReplicasMetric = &metricspb.MetricDescriptor{
+var hpaCurrentReplicasMetric = registerMetric(&metricspb.MetricDescriptor{
 	Name:        "k8s.hpa.current_replicas",
 	Description: "Current number of pod replicas managed by this autoscaler",
 	Unit:        "1",
 	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
-}
+})
 
-var hpaDesiredReplicasMetric = &metricspb.MetricDescriptor{
+var hpaDesiredReplicasMetric = registerMetric(&metricspb.MetricDescriptor{
 	Name:        "k8s.hpa.desired_replicas",
 	Description: "Desired number of pod replicas managed by this autoscaler",
 	Unit:        "1",
 	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
-}
+})
 
 func getMetricsForHPA(hpa *v2beta1.HorizontalPodAutoscaler) []*resourceMetrics {
 	metrics := []*metricspb.Metric{
@@ -105,3 +107,35 @@ func getMetadataForHPA(hpa *v2beta1.HorizontalPodAutoscaler) map[metadata.Resour
 		metadata.ResourceID(hpa.UID): getGenericMetadata(&hpa.ObjectMeta, "HPA"),
 	}
 }
+
+// hpaFromV1 returns the fields of an autoscaling/v1 HPA metrics are reported
+// from as a v2beta1 HPA.
+func hpaFromV1(hpa *autoscalingv1.HorizontalPodAutoscaler) *v2beta1.HorizontalPodAutoscaler {
+	return &v2beta1.HorizontalPodAutoscaler{
+		ObjectMeta: hpa.ObjectMeta,
+		Spec: v2beta1.HorizontalPodAutoscalerSpec{
+			MinReplicas: hpa.Spec.MinReplicas,
+			MaxReplicas: hpa.Spec.MaxReplicas,
+		},
+		Status: v2beta1.HorizontalPodAutoscalerStatus{
+			CurrentReplicas: hpa.Status.CurrentReplicas,
+			DesiredReplicas: hpa.Status.DesiredReplicas,
+		},
+	}
+}
+
+// hpaFromV2beta2 returns the fields of an autoscaling/v2beta2 HPA metrics are
+// reported from as a v2beta1 HPA.
+func hpaFromV2beta2(hpa *v2beta2.HorizontalPodAutoscaler) *v2beta1.HorizontalPodAutoscaler {
+	return &v2beta1.HorizontalPodAutoscaler{
+		ObjectMeta: hpa.ObjectMeta,
+		Spec: v2beta1.HorizontalPodAutoscalerSpec{
+			MinReplicas: hpa.Spec.MinReplicas,
+			MaxReplicas: hpa.Spec.MaxReplicas,
+		},
+		Status: v2beta1.HorizontalPodAutoscalerStatus{
+			CurrentReplicas: hpa.Status.CurrentReplicas,
+			DesiredReplicas: hpa.Status.DesiredReplicas,
+		},
+	}
+}
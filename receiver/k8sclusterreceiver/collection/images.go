@@ -0,0 +1,114 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"strings"
+
+	"go.opentelemetry.io/collector/translator/conventions"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// Keys of the resource labels of containers describing their image.
+	containerKeyImageDigest   = "container.image.digest"
+	containerKeyImageRegistry = "container.image.registry"
+)
+
+// ImageInfo describes the image a container runs.
+type ImageInfo struct {
+	// Image reference of the container spec, e.g. nginx:1.19.
+	Image string
+	// Reference of the image resolved by the container runtime of the node,
+	// e.g. docker-pullable://nginx@sha256:..., as reported in the status of
+	// the container.
+	ImageID string
+	// Digest of the image, e.g. sha256:..., resolved from ImageID, if any.
+	Digest string
+	// Host of the registry of the image, e.g. docker.io.
+	Registry string
+}
+
+// ImageEnricher resolves further attributes of the images of containers,
+// e.g. their age or known vulnerabilities, added to the resource labels of
+// containers. It is called whenever the metrics of a pod are updated, so it
+// is expected to answer from a cache, resolving unknown images in the
+// background. It must be safe for concurrent use.
+type ImageEnricher interface {
+	// EnrichImage returns the attributes of the image, keyed by attribute
+	// key. Attributes that containers already have are left as is.
+	EnrichImage(image ImageInfo) map[string]string
+}
+
+// SetImageEnricher sets the enricher resolving the attributes of container
+// images when image metadata is reported. It is expected to be called before
+// metrics are synced.
+func (dc *DataCollector) SetImageEnricher(enricher ImageEnricher) {
+	dc.imageEnricher = enricher
+}
+
+// addImageLabels adds the digest and registry of the images of the
+// containers of the pod to their resource labels, along with the attributes
+// resolved by the enricher, if any.
+func addImageLabels(pod *corev1.Pod, rms []*resourceMetrics, enricher ImageEnricher) {
+	images := make(map[string]ImageInfo, len(pod.Status.ContainerStatuses))
+	for _, cs := range pod.Status.ContainerStatuses {
+		images[cs.Name] = getImageInfo(cs)
+	}
+	for _, rm := range rms {
+		name, ok := rm.resource.Labels[conventions.AttributeK8sContainer]
+		if !ok {
+			continue
+		}
+		image, ok := images[name]
+		if !ok {
+			continue
+		}
+		if image.Digest != "" {
+			rm.resource.Labels[containerKeyImageDigest] = image.Digest
+		}
+		rm.resource.Labels[containerKeyImageRegistry] = image.Registry
+		if enricher == nil {
+			continue
+		}
+		for k, v := range enricher.EnrichImage(image) {
+			if _, exists := rm.resource.Labels[k]; !exists {
+				rm.resource.Labels[k] = v
+			}
+		}
+	}
+}
+
+// getImageInfo returns the image of the container with the status. The
+// image ID is either a reference by digest, e.g. docker.io/library/nginx@sha256:...,
+// optionally prefixed by a scheme, e.g. docker-pullable://, or the digest of
+// a local image, e.g. sha256:.... The registry is that of the reference by
+// digest, if any, or of the image of the spec otherwise.
+func getImageInfo(cs corev1.ContainerStatus) ImageInfo {
+	image := ImageInfo{Image: cs.Image, ImageID: cs.ImageID}
+	ref := cs.ImageID
+	if i := strings.Index(ref, "://"); i >= 0 {
+		ref = ref[i+len("://"):]
+	}
+	repository := cs.Image
+	if i := strings.LastIndexByte(ref, '@'); i >= 0 {
+		repository = ref[:i]
+		image.Digest = ref[i+1:]
+	} else if strings.HasPrefix(ref, "sha256:") {
+		image.Digest = ref
+	}
+	image.Registry = imageRegistry(repository)
+	return image
+}
@@ -0,0 +1,108 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestGetImageInfo(t *testing.T) {
+	tests := []struct {
+		name    string
+		image   string
+		imageID string
+		want    ImageInfo
+	}{
+		{
+			name:    "docker pullable",
+			image:   "nginx:1.19",
+			imageID: "docker-pullable://nginx@sha256:abc",
+			want:    ImageInfo{Digest: "sha256:abc", Registry: "docker.io"},
+		},
+		{
+			name:    "containerd",
+			image:   "gcr.io/project/app:1.0",
+			imageID: "gcr.io/project/app@sha256:def",
+			want:    ImageInfo{Digest: "sha256:def", Registry: "gcr.io"},
+		},
+		{
+			name:    "local image",
+			image:   "localhost:5000/app:dev",
+			imageID: "docker://sha256:123",
+			want:    ImageInfo{Digest: "sha256:123", Registry: "localhost:5000"},
+		},
+		{
+			name:  "not pulled yet",
+			image: "quay.io/org/app:2.0",
+			want:  ImageInfo{Registry: "quay.io"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.want.Image = tt.image
+			tt.want.ImageID = tt.imageID
+			require.Equal(t, tt.want, getImageInfo(corev1.ContainerStatus{Image: tt.image, ImageID: tt.imageID}))
+		})
+	}
+}
+
+// fakeImageEnricher reports the age of the images it knows of.
+type fakeImageEnricher map[string]string
+
+func (e fakeImageEnricher) EnrichImage(image ImageInfo) map[string]string {
+	age, ok := e[image.Digest]
+	if !ok {
+		return nil
+	}
+	return map[string]string{"container.image.age": age, "container.image.registry": "overridden"}
+}
+
+func TestDataCollectorImageMetadata(t *testing.T) {
+	newPod := func() *corev1.Pod {
+		pod := newPodWithContainer("1", podSpecWithContainer("container-name"), podStatusWithContainer("container-name", containerIDWithPreifx("container-id")))
+		pod.Status.ContainerStatuses[0].Image = "gcr.io/project/app:1.0"
+		pod.Status.ContainerStatuses[0].ImageID = "docker-pullable://gcr.io/project/app@sha256:abc"
+		return pod
+	}
+	labels := map[string]string{"k8s.container.name": "container-name"}
+
+	t.Run("disabled", func(t *testing.T) {
+		h := newTestHarness(t, nil)
+		h.seed(newPod())
+		found := h.metrics("k8s.container.ready", labels)
+		require.Equal(t, 1, len(found))
+		require.NotContains(t, found[0].resource.Labels, "container.image.digest")
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		h := newTestHarness(t, nil, WithImageMetadata(true))
+		h.dc.SetImageEnricher(fakeImageEnricher{"sha256:abc": "30d"})
+		h.seed(newPod())
+		found := h.metrics("k8s.container.ready", labels)
+		require.Equal(t, 1, len(found))
+		require.Equal(t, "sha256:abc", found[0].resource.Labels["container.image.digest"])
+		// Attributes already set are not overridden by the enricher.
+		require.Equal(t, "gcr.io", found[0].resource.Labels["container.image.registry"])
+		require.Equal(t, "30d", found[0].resource.Labels["container.image.age"])
+
+		// Pod resources are left as is.
+		found = h.metrics("k8s.pod.phase", nil)
+		require.Equal(t, 1, len(found))
+		require.NotContains(t, found[0].resource.Labels, "container.image.digest")
+	})
+}
@@ -0,0 +1,108 @@
+// Copyright 2020 OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"sort"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/observability"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
+)
+
+var clusterInformerCacheObjectsMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.cluster.informer_cache_objects",
+	Description: "Number of objects of a kind held in the informer cache of the receiver",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+	LabelKeys:   []*metricspb.LabelKey{{Key: "kind"}},
+})
+
+var clusterInformerCacheSizeMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name: "k8s.cluster.informer_cache_size",
+	Description: "Rough estimate of the memory held by the objects of a kind in the informer cache " +
+		"of the receiver, as the size of their protobuf encoding",
+	Unit:      "By",
+	Type:      metricspb.MetricDescriptor_GAUGE_INT64,
+	LabelKeys: []*metricspb.LabelKey{{Key: "kind"}},
+})
+
+// sizer is implemented by the Kubernetes API types, returning the size of
+// their protobuf encoding.
+type sizer interface {
+	Size() int
+}
+
+// getInformerCacheMetricsForCluster returns the number of objects in the
+// informer cache of every watched kind, and an estimate of their size. The
+// in-memory size of objects is several times their encoded size, but grows
+// along with it, which is enough to correlate the memory of the receiver
+// with the objects it caches.
+func getInformerCacheMetricsForCluster(oc *objectCounts) []*resourceMetrics {
+	oc.Lock()
+	defer oc.Unlock()
+
+	if len(oc.stores) == 0 {
+		return nil
+	}
+	kinds := make([]string, 0, len(oc.stores))
+	for kind := range oc.stores {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	objects := make([]*metricspb.TimeSeries, 0, len(kinds))
+	sizes := make([]*metricspb.TimeSeries, 0, len(kinds))
+	for _, kind := range kinds {
+		objs := oc.stores[kind].List()
+		var size int64
+		for _, obj := range objs {
+			if s, ok := obj.(sizer); ok {
+				size += int64(s.Size())
+			}
+		}
+		labels := []*metricspb.LabelValue{{Value: kind, HasValue: true}}
+		objects = append(objects, utils.GetInt64TimeSeriesWithLabels(int64(len(objs)), labels))
+		sizes = append(sizes, utils.GetInt64TimeSeriesWithLabels(size, labels))
+	}
+
+	return []*resourceMetrics{
+		{
+			resource: getResourceForCluster(),
+			metrics: []*metricspb.Metric{
+				{
+					MetricDescriptor: clusterInformerCacheObjectsMetric,
+					Timeseries:       objects,
+				},
+				{
+					MetricDescriptor: clusterInformerCacheSizeMetric,
+					Timeseries:       sizes,
+				},
+			},
+		},
+	}
+}
+
+// recordInformerCacheObjects records the number of objects in the informer
+// cache of every watched kind as an internal metric, whether or not they are
+// reported as metrics of the cluster.
+func recordInformerCacheObjects(oc *objectCounts) {
+	oc.Lock()
+	defer oc.Unlock()
+	for kind, store := range oc.stores {
+		observability.RecordCachedObjects(kind, int64(len(store.ListKeys())))
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2020 OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestInformerCacheMetrics(t *testing.T) {
+	h := newTestHarness(t, nil, WithInformerCacheMetrics(true))
+	pods := []*corev1.Pod{
+		newPendingPod("pod-1", "node-1"),
+		newPendingPod("pod-2", "node-1"),
+		newPendingPod("pod-3", "node-1"),
+	}
+	h.seed(pods[0], pods[1], pods[2], newNodeWithResources("node-1", corev1.ConditionTrue, "2", "1Gi"))
+
+	byKind := func(name string) map[string]int64 {
+		m := h.requireMetric(name, nil)
+		out := map[string]int64{}
+		for _, ts := range m.Timeseries {
+			out[ts.LabelValues[0].Value] = ts.Points[0].GetInt64Value()
+		}
+		return out
+	}
+
+	require.Equal(t, map[string]int64{"Node": 1, "Pod": 3}, byKind("k8s.cluster.informer_cache_objects"))
+	sizes := byKind("k8s.cluster.informer_cache_size")
+	require.Equal(t, int64(pods[0].Size()+pods[1].Size()+pods[2].Size()), sizes["Pod"])
+	require.Greater(t, sizes["Node"], int64(0))
+
+	h.remove(pods[0])
+	require.Equal(t, map[string]int64{"Node": 1, "Pod": 2}, byKind("k8s.cluster.informer_cache_objects"))
+}
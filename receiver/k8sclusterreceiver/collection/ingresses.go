@@ -0,0 +1,173 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"strconv"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	"go.opentelemetry.io/collector/translator/conventions"
+	networkingv1 "k8s.io/api/networking/v1"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
+)
+
+const (
+	// Resource labels keys for Ingresses.
+	k8sKeyIngressUID   = "k8s.ingress.uid"
+	k8sKeyIngressName  = "k8s.ingress.name"
+	k8sKeyIngressClass = "k8s.ingress.class"
+)
+
+var ingressRulesMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.ingress.rules",
+	Description: "Number of rules of the ingress",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var ingressHostsMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.ingress.hosts",
+	Description: "Number of distinct hosts the rules of the ingress apply to",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var ingressPathMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name: "k8s.ingress.path",
+	Description: "Path of a rule of the ingress with the backend it routes to, always 1. " +
+		"The default backend is reported without host nor path",
+	Unit: "1",
+	Type: metricspb.MetricDescriptor_GAUGE_INT64,
+	LabelKeys: []*metricspb.LabelKey{
+		{Key: "host"}, {Key: "path"}, {Key: "path_type"},
+		{Key: "service_name"}, {Key: "service_port"}, {Key: "resource_kind"}, {Key: "resource_name"},
+	},
+})
+
+var ingressTLSMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.ingress.tls",
+	Description: "Whether TLS is configured for the ingress (1) or not (0)",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var ingressLoadBalancerIngressMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.ingress.load_balancer_ingress",
+	Description: "Number of ingress points of the load balancer of the ingress",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+func getMetricsForIngress(ing *networkingv1.Ingress) []*resourceMetrics {
+	hosts := map[string]bool{}
+	var paths []*metricspb.TimeSeries
+	if ing.Spec.DefaultBackend != nil {
+		paths = append(paths, getIngressPathTimeSeries("", "", "", *ing.Spec.DefaultBackend))
+	}
+	for _, rule := range ing.Spec.Rules {
+		if rule.Host != "" {
+			hosts[rule.Host] = true
+		}
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			var pathType string
+			if path.PathType != nil {
+				pathType = string(*path.PathType)
+			}
+			paths = append(paths, getIngressPathTimeSeries(rule.Host, path.Path, pathType, path.Backend))
+		}
+	}
+
+	metrics := []*metricspb.Metric{
+		{
+			MetricDescriptor: ingressRulesMetric,
+			Timeseries:       []*metricspb.TimeSeries{utils.GetInt64TimeSeries(int64(len(ing.Spec.Rules)))},
+		},
+		{
+			MetricDescriptor: ingressHostsMetric,
+			Timeseries:       []*metricspb.TimeSeries{utils.GetInt64TimeSeries(int64(len(hosts)))},
+		},
+		{
+			MetricDescriptor: ingressTLSMetric,
+			Timeseries:       []*metricspb.TimeSeries{utils.GetInt64TimeSeries(boolToInt64(len(ing.Spec.TLS) > 0))},
+		},
+		{
+			MetricDescriptor: ingressLoadBalancerIngressMetric,
+			Timeseries: []*metricspb.TimeSeries{
+				utils.GetInt64TimeSeries(int64(len(ing.Status.LoadBalancer.Ingress))),
+			},
+		},
+	}
+	if len(paths) > 0 {
+		metrics = append(metrics, &metricspb.Metric{
+			MetricDescriptor: ingressPathMetric,
+			Timeseries:       paths,
+		})
+	}
+
+	return []*resourceMetrics{
+		{
+			resource: getResourceForIngress(ing),
+			metrics:  metrics,
+		},
+	}
+}
+
+// getIngressPathTimeSeries returns the time series of a path routed to the
+// backend, either a service or a resource of the namespace of the ingress.
+func getIngressPathTimeSeries(host, path, pathType string, backend networkingv1.IngressBackend) *metricspb.TimeSeries {
+	var serviceName, servicePort, resourceKind, resourceName string
+	if svc := backend.Service; svc != nil {
+		serviceName = svc.Name
+		servicePort = svc.Port.Name
+		if servicePort == "" {
+			servicePort = strconv.Itoa(int(svc.Port.Number))
+		}
+	}
+	if res := backend.Resource; res != nil {
+		resourceKind = res.Kind
+		resourceName = res.Name
+	}
+
+	return utils.GetInt64TimeSeriesWithLabels(1, []*metricspb.LabelValue{
+		{Value: host, HasValue: true},
+		{Value: path, HasValue: true},
+		{Value: pathType, HasValue: true},
+		{Value: serviceName, HasValue: true},
+		{Value: servicePort, HasValue: true},
+		{Value: resourceKind, HasValue: true},
+		{Value: resourceName, HasValue: true},
+	})
+}
+
+func getResourceForIngress(ing *networkingv1.Ingress) *resourcepb.Resource {
+	labels := map[string]string{
+		k8sKeyIngressUID:                  string(ing.UID),
+		k8sKeyIngressName:                 ing.Name,
+		conventions.AttributeK8sNamespace: ing.Namespace,
+		conventions.AttributeK8sCluster:   ing.ClusterName,
+	}
+	if ing.Spec.IngressClassName != nil {
+		labels[k8sKeyIngressClass] = *ing.Spec.IngressClassName
+	}
+	return &resourcepb.Resource{
+		Type:   k8sType,
+		Labels: labels,
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/testutils"
+)
+
+func TestIngressMetrics(t *testing.T) {
+	class := "nginx"
+	prefix := networkingv1.PathTypePrefix
+	apiGroup := "storage.example.com"
+	serviceBackend := func(name string, port networkingv1.ServiceBackendPort) networkingv1.IngressBackend {
+		return networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{Name: name, Port: port}}
+	}
+	ing := &networkingv1.Ingress{
+		ObjectMeta: v1.ObjectMeta{
+			Name: "ing", Namespace: "test-namespace", UID: types.UID("ing-uid"), ClusterName: "test-cluster",
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: &class,
+			DefaultBackend: &networkingv1.IngressBackend{
+				Resource: &corev1.TypedLocalObjectReference{APIGroup: &apiGroup, Kind: "StorageBucket", Name: "static"},
+			},
+			TLS: []networkingv1.IngressTLS{{Hosts: []string{"example.com"}, SecretName: "tls"}},
+			Rules: []networkingv1.IngressRule{
+				{
+					Host: "example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{
+							{Path: "/api", PathType: &prefix, Backend: serviceBackend("api", networkingv1.ServiceBackendPort{Number: 8080})},
+							{Path: "/", Backend: serviceBackend("web", networkingv1.ServiceBackendPort{Name: "http"})},
+						},
+					}},
+				},
+				// Rules without HTTP paths still count.
+				{Host: "example.com"},
+			},
+		},
+		Status: networkingv1.IngressStatus{LoadBalancer: corev1.LoadBalancerStatus{
+			Ingress: []corev1.LoadBalancerIngress{{IP: "192.0.2.1"}},
+		}},
+	}
+
+	rms := getMetricsForIngress(ing)
+	require.Equal(t, 1, len(rms))
+	testutils.AssertResource(t, rms[0].resource, k8sType,
+		map[string]string{
+			"k8s.ingress.uid":    "ing-uid",
+			"k8s.ingress.name":   "ing",
+			"k8s.ingress.class":  "nginx",
+			"k8s.namespace.name": "test-namespace",
+			"k8s.cluster.name":   "test-cluster",
+		},
+	)
+	require.Equal(t, 5, len(rms[0].metrics))
+	testutils.AssertMetrics(t, rms[0].metrics[0], "k8s.ingress.rules", metricspb.MetricDescriptor_GAUGE_INT64, 2)
+	testutils.AssertMetrics(t, rms[0].metrics[1], "k8s.ingress.hosts", metricspb.MetricDescriptor_GAUGE_INT64, 1)
+	testutils.AssertMetrics(t, rms[0].metrics[2], "k8s.ingress.tls", metricspb.MetricDescriptor_GAUGE_INT64, 1)
+	testutils.AssertMetrics(t, rms[0].metrics[3], "k8s.ingress.load_balancer_ingress",
+		metricspb.MetricDescriptor_GAUGE_INT64, 1)
+
+	paths := rms[0].metrics[4]
+	require.Equal(t, "k8s.ingress.path", paths.MetricDescriptor.Name)
+	var got [][]string
+	for _, ts := range paths.Timeseries {
+		require.Equal(t, int64(1), ts.Points[0].GetInt64Value())
+		var labels []string
+		for _, lv := range ts.LabelValues {
+			labels = append(labels, lv.Value)
+		}
+		got = append(got, labels)
+	}
+	require.Equal(t, [][]string{
+		{"", "", "", "", "", "StorageBucket", "static"},
+		{"example.com", "/api", "Prefix", "api", "8080", "", ""},
+		{"example.com", "/", "", "web", "http", "", ""},
+	}, got)
+
+	// Ingresses without rules still report their counts.
+	ing.Spec = networkingv1.IngressSpec{}
+	rms = getMetricsForIngress(ing)
+	require.Equal(t, 4, len(rms[0].metrics))
+	testutils.AssertMetrics(t, rms[0].metrics[2], "k8s.ingress.tls", metricspb.MetricDescriptor_GAUGE_INT64, 0)
+	require.NotContains(t, rms[0].resource.Labels, "k8s.ingress.class")
+}
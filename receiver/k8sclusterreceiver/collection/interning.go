@@ -0,0 +1,116 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"strconv"
+	"strings"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+)
+
+// maxInternedValues is the number of strings, and of descriptors, beyond which
+// the tables of a stringInterner are reset, so that values of objects long
+// gone, e.g. pod names, do not pile up. Values interned before a reset stay
+// shared by the metrics they were interned for.
+const maxInternedValues = 1 << 20
+
+// stringInterner deduplicates the strings and metric descriptors of cached
+// metrics. Objects received from informers each hold their own copy of
+// strings like namespace, node and label names, which are copied along into
+// the labels of their metrics, so that on large clusters the same strings are
+// cached many times over. Interned values must not be modified since they are
+// shared between objects. Not safe for concurrent use, it is only used with
+// the lock of the metrics store held.
+type stringInterner struct {
+	strings     map[string]string
+	descriptors map[string]*metricspb.MetricDescriptor
+}
+
+func newStringInterner() *stringInterner {
+	return &stringInterner{
+		strings:     map[string]string{},
+		descriptors: map[string]*metricspb.MetricDescriptor{},
+	}
+}
+
+// intern returns the interned copy of s.
+func (si *stringInterner) intern(s string) string {
+	if interned, ok := si.strings[s]; ok {
+		return interned
+	}
+	if len(si.strings) >= maxInternedValues {
+		si.strings = map[string]string{}
+	}
+	si.strings[s] = s
+	return s
+}
+
+// internDescriptor returns the interned descriptor equal to d. Most
+// descriptors are already shared, but some are built for each object, e.g.
+// those of label info metrics.
+func (si *stringInterner) internDescriptor(d *metricspb.MetricDescriptor) *metricspb.MetricDescriptor {
+	if d == nil {
+		return nil
+	}
+	var b strings.Builder
+	for _, s := range []string{d.Name, d.Description, d.Unit, strconv.Itoa(int(d.Type))} {
+		b.WriteString(s)
+		b.WriteByte(0)
+	}
+	for _, k := range d.LabelKeys {
+		b.WriteString(k.GetKey())
+		b.WriteByte(0)
+		b.WriteString(k.GetDescription())
+		b.WriteByte(0)
+	}
+	key := b.String()
+
+	if interned, ok := si.descriptors[key]; ok {
+		return interned
+	}
+	if len(si.descriptors) >= maxInternedValues {
+		si.descriptors = map[string]*metricspb.MetricDescriptor{}
+	}
+	si.descriptors[key] = d
+	return d
+}
+
+// internResourceMetrics replaces the resource labels, descriptors and label
+// values of the time series of rms with their interned copies.
+func (si *stringInterner) internResourceMetrics(rms []*resourceMetrics) {
+	for _, rm := range rms {
+		if r := rm.resource; r != nil {
+			r.Type = si.intern(r.Type)
+			// Keys of a map keep the strings they were inserted with, so
+			// the labels are copied to a map keyed by the interned keys.
+			labels := make(map[string]string, len(r.Labels))
+			for k, v := range r.Labels {
+				labels[si.intern(k)] = si.intern(v)
+			}
+			r.Labels = labels
+		}
+		for _, m := range rm.metrics {
+			m.MetricDescriptor = si.internDescriptor(m.MetricDescriptor)
+			for _, ts := range m.Timeseries {
+				for _, lv := range ts.LabelValues {
+					if lv != nil {
+						lv.Value = si.intern(lv.Value)
+					}
+				}
+			}
+		}
+	}
+}
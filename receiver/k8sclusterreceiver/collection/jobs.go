@@ -24,40 +24,40 @@ import (
 	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
 )
 
-var podsActiveMetric = &metricspb.MetricDescriptor{
+var podsActiveMetric = registerMetric(&metricspb.MetricDescriptor{
 	Name:        "k8s.job.active_pods",
 	Description: "The number of actively running pods for a job",
 	Unit:        "1",
 	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
-}
+})
 
-var podsDesiredCompletedMetric = &metricspb.MetricDescriptor{
+var podsDesiredCompletedMetric = registerMetric(&metricspb.MetricDescriptor{
 	Name:        "k8s.job.desired_successful_pods",
 	Description: "The desired number of successfully finished pods the job should be run with",
 	Unit:        "1",
 	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
-}
+})
 
-var podsFailedMetric = &metricspb.MetricDescriptor{
+var podsFailedMetric = registerMetric(&metricspb.MetricDescriptor{
 	Name:        "k8s.job.failed_pods",
 	Description: "The number of pods which reached phase Failed for a job",
 	Unit:        "1",
 	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
-}
+})
 
-var podsMaxParallelMetric = &metricspb.MetricDescriptor{
+var podsMaxParallelMetric = registerMetric(&metricspb.MetricDescriptor{
 	Name:        "k8s.job.max_parallel_pods",
 	Description: "The max desired number of pods the job should run at any given time",
 	Unit:        "1",
 	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
-}
+})
 
-var podsSuccessfulMetric = &metricspb.MetricDescriptor{
+var podsSuccessfulMetric = registerMetric(&metricspb.MetricDescriptor{
 	Name:        "k8s.job.successful_pods",
 	Description: "The number of pods which reached phase Succeeded for a job",
 	Unit:        "1",
 	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
-}
+})
 
 func getMetricsForJob(j *batchv1.Job) []*resourceMetrics {
 	metrics := make([]*metricspb.Metric, 0, 5)
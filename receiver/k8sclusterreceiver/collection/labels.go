@@ -0,0 +1,115 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
+)
+
+// labelInfoMetricPrefixes maps the lower-cased kinds for which a labels info
+// metric can be reported to the prefix of the metrics of that kind.
+var labelInfoMetricPrefixes = map[string]string{
+	strings.ToLower(k8sKindCronJob):               "k8s.cronjob",
+	strings.ToLower(k8sKindDaemonSet):             "k8s.daemonset",
+	strings.ToLower(k8sKindDeployment):            "k8s.deployment",
+	strings.ToLower(k8sKindHPA):                   "k8s.hpa",
+	strings.ToLower(k8sKindJob):                   "k8s.job",
+	strings.ToLower(k8sKindNamespace):             "k8s.namespace",
+	strings.ToLower(k8sKindNode):                  "k8s.node",
+	strings.ToLower(k8sKindPod):                   "k8s.pod",
+	strings.ToLower(k8sKindReplicaSet):            "k8s.replicaset",
+	strings.ToLower(k8sKindReplicationController): "k8s.replication_controller",
+	strings.ToLower(k8sKindResourceQuota):         "k8s.resource_quota",
+	strings.ToLower(k8sStatefulSet):               "k8s.statefulset",
+}
+
+// ValidateLabelInfoKinds returns an error if a labels info metric cannot be
+// reported for one of the given lower-cased kinds.
+func ValidateLabelInfoKinds(kinds []string) error {
+	for _, kind := range kinds {
+		if _, ok := labelInfoMetricPrefixes[kind]; !ok {
+			supported := make([]string, 0, len(labelInfoMetricPrefixes))
+			for k := range labelInfoMetricPrefixes {
+				supported = append(supported, k)
+			}
+			sort.Strings(supported)
+			return fmt.Errorf("unsupported kind %q, must be one of: %s",
+				kind, strings.Join(supported, ", "))
+		}
+	}
+	return nil
+}
+
+// getLabelInfoMetric returns a k8s.<kind>.labels metric with a value of 1
+// whose single time series carries all the given object labels, or nil if
+// there are no labels. Label keys are sanitized so that they are valid metric
+// label keys. Should several keys sanitize to the same key, the value of the
+// first key in lexicographic order is kept.
+func getLabelInfoMetric(kind string, labels map[string]string) *metricspb.Metric {
+	prefix, ok := labelInfoMetricPrefixes[strings.ToLower(kind)]
+	if !ok || len(labels) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	seen := make(map[string]bool, len(keys))
+	labelKeys := make([]*metricspb.LabelKey, 0, len(keys))
+	labelValues := make([]*metricspb.LabelValue, 0, len(keys))
+	for _, k := range keys {
+		key := sanitizeLabelKey(k)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		labelKeys = append(labelKeys, &metricspb.LabelKey{Key: key})
+		labelValues = append(labelValues, &metricspb.LabelValue{Value: labels[k], HasValue: true})
+	}
+
+	return &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:        prefix + ".labels",
+			Description: fmt.Sprintf("Labels of the %s, always 1", strings.ToLower(kind)),
+			Unit:        "1",
+			Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+			LabelKeys:   labelKeys,
+		},
+		Timeseries: []*metricspb.TimeSeries{
+			utils.GetInt64TimeSeriesWithLabels(1, labelValues),
+		},
+	}
+}
+
+// sanitizeLabelKey replaces characters other than letters, digits and
+// underscores, e.g. the dots and slashes of prefixed Kubernetes label keys,
+// with underscores.
+func sanitizeLabelKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, key)
+}
@@ -0,0 +1,67 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestLabelInfoMetrics(t *testing.T) {
+	h := newTestHarness(t, nil, WithLabelInfoKinds([]string{"pod"}))
+
+	pod := newPodWithContainer("1", &corev1.PodSpec{}, &corev1.PodStatus{})
+	pod.Labels = map[string]string{
+		"app.kubernetes.io/name": "web",
+		"team":                   "payments",
+	}
+	unlabelled := newPodWithContainer("2", &corev1.PodSpec{}, &corev1.PodStatus{})
+	unlabelled.Labels = nil
+	h.seed(pod, unlabelled, newDeployment("1"))
+
+	m := h.requireMetric("k8s.pod.labels", map[string]string{"k8s.pod.name": pod.Name})
+	require.Equal(t, metricspb.MetricDescriptor_GAUGE_INT64, m.MetricDescriptor.Type)
+	require.Equal(t, []*metricspb.LabelKey{
+		{Key: "app_kubernetes_io_name"},
+		{Key: "team"},
+	}, m.MetricDescriptor.LabelKeys)
+	require.Equal(t, 1, len(m.Timeseries))
+	require.Equal(t, []*metricspb.LabelValue{
+		{Value: "web", HasValue: true},
+		{Value: "payments", HasValue: true},
+	}, m.Timeseries[0].LabelValues)
+	require.Equal(t, int64(1), m.Timeseries[0].Points[0].GetInt64Value())
+
+	// Objects without labels and kinds that are not enabled have no labels
+	// metric.
+	h.requireNoMetric("k8s.pod.labels", map[string]string{"k8s.pod.name": unlabelled.Name})
+	h.requireNoMetric("k8s.deployment.labels", nil)
+}
+
+func TestGetLabelInfoMetricSanitizesKeys(t *testing.T) {
+	m := getLabelInfoMetric(k8sKindDeployment, map[string]string{
+		"a.b": "first",
+		"a/b": "second",
+		"c-d": "other",
+	})
+	require.Equal(t, "k8s.deployment.labels", m.MetricDescriptor.Name)
+	require.Equal(t, []*metricspb.LabelKey{{Key: "a_b"}, {Key: "c_d"}}, m.MetricDescriptor.LabelKeys)
+	require.Equal(t, "first", m.Timeseries[0].LabelValues[0].Value)
+
+	require.Nil(t, getLabelInfoMetric(k8sKindMutatingWebhookConfiguration, map[string]string{"a": "b"}))
+}
@@ -0,0 +1,96 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"sort"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	conventions "go.opentelemetry.io/collector/translator/conventions"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
+)
+
+const k8sKeyLeaseName = "k8s.lease.name"
+
+var leaseRenewAgeMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.lease.renew_age",
+	Description: "Time since the node lease was last renewed by the kubelet",
+	Unit:        "s",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+// getRenewAgeMetricsForLeases returns the time since the node leases were
+// last renewed. Kubelets renew the lease of their node, named after the node,
+// much more frequently than they update the node status, so a growing renew
+// age signals a node failure before the node is marked NotReady. Leases that
+// have never been renewed are not reported.
+func getRenewAgeMetricsForLeases(leases cache.Store, now time.Time) []*resourceMetrics {
+	if leases == nil {
+		return nil
+	}
+
+	var renewed []*coordinationv1.Lease
+	for _, obj := range leases.List() {
+		lease, ok := obj.(*coordinationv1.Lease)
+		if !ok || lease.Namespace != corev1.NamespaceNodeLease || lease.Spec.RenewTime == nil {
+			continue
+		}
+		renewed = append(renewed, lease)
+	}
+	sort.Slice(renewed, func(i, j int) bool {
+		return renewed[i].Name < renewed[j].Name
+	})
+
+	out := make([]*resourceMetrics, 0, len(renewed))
+	for _, lease := range renewed {
+		age := now.Sub(lease.Spec.RenewTime.Time)
+		if age < 0 {
+			age = 0
+		}
+
+		out = append(out, &resourceMetrics{
+			resource: getResourceForNodeLease(lease),
+			metrics: []*metricspb.Metric{
+				{
+					MetricDescriptor: leaseRenewAgeMetric,
+					Timeseries: []*metricspb.TimeSeries{
+						utils.GetInt64TimeSeries(int64(age / time.Second)),
+					},
+				},
+			},
+		})
+	}
+	return out
+}
+
+// getResourceForNodeLease returns a proto representation of a node lease,
+// which is named after its node.
+func getResourceForNodeLease(lease *coordinationv1.Lease) *resourcepb.Resource {
+	return &resourcepb.Resource{
+		Type: k8sType,
+		Labels: map[string]string{
+			k8sKeyLeaseName:                   lease.Name,
+			k8sKeyNodeName:                    lease.Name,
+			conventions.AttributeK8sNamespace: lease.Namespace,
+			conventions.AttributeK8sCluster:   lease.ClusterName,
+		},
+	}
+}
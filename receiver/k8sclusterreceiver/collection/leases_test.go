@@ -0,0 +1,71 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"testing"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/stretchr/testify/require"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/testutils"
+)
+
+func newNodeLease(name string, renewTime *time.Time) *coordinationv1.Lease {
+	lease := &coordinationv1.Lease{
+		ObjectMeta: v1.ObjectMeta{Name: name, Namespace: corev1.NamespaceNodeLease},
+	}
+	if renewTime != nil {
+		lease.Spec.RenewTime = &v1.MicroTime{Time: *renewTime}
+	}
+	return lease
+}
+
+func TestLeaseRenewAgeMetrics(t *testing.T) {
+	now := time.Now()
+	fresh := now.Add(-5 * time.Second)
+	stale := now.Add(-3 * time.Minute)
+
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	require.NoError(t, store.Add(newNodeLease("node-1", &fresh)))
+	require.NoError(t, store.Add(newNodeLease("node-2", &stale)))
+	require.NoError(t, store.Add(newNodeLease("node-3", nil)))
+	leaderElection := newNodeLease("controller", &stale)
+	leaderElection.Namespace = "kube-system"
+	require.NoError(t, store.Add(leaderElection))
+
+	rms := getRenewAgeMetricsForLeases(store, now)
+	require.Equal(t, 2, len(rms))
+
+	testutils.AssertResource(t, rms[0].resource, k8sType, map[string]string{
+		"k8s.lease.name":     "node-1",
+		"k8s.node.name":      "node-1",
+		"k8s.namespace.name": corev1.NamespaceNodeLease,
+		"k8s.cluster.name":   "",
+	})
+	testutils.AssertMetrics(t, rms[0].metrics[0], "k8s.lease.renew_age",
+		metricspb.MetricDescriptor_GAUGE_INT64, 5)
+
+	require.Equal(t, "node-2", rms[1].resource.Labels["k8s.node.name"])
+	testutils.AssertMetrics(t, rms[1].metrics[0], "k8s.lease.renew_age",
+		metricspb.MetricDescriptor_GAUGE_INT64, 180)
+
+	require.Nil(t, getRenewAgeMetricsForLeases(nil, now))
+}
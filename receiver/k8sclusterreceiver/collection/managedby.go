@@ -0,0 +1,66 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"strings"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultManagedByLabel is the label recording the tool managing an object.
+const DefaultManagedByLabel = "app.kubernetes.io/managed-by"
+
+// ManagedByRule selects the objects managed by operators or other tools,
+// e.g. to leave out the objects they generate. Values and kinds are matched
+// case-insensitively.
+type ManagedByRule struct {
+	// Label is the key of the label whose value is the manager of an object.
+	Label string
+	// Values are the values of Label of the selected objects, e.g. Helm.
+	Values []string
+	// OwnerKinds are the kinds of owners of the selected objects, e.g.
+	// Prometheus for the StatefulSets of the Prometheus operator.
+	OwnerKinds []string
+}
+
+// matches returns true if the object is managed according to the rule.
+func (r ManagedByRule) matches(obj v1.Object) bool {
+	if value, ok := obj.GetLabels()[r.Label]; ok {
+		for _, v := range r.Values {
+			if strings.EqualFold(value, v) {
+				return true
+			}
+		}
+	}
+	for _, ref := range obj.GetOwnerReferences() {
+		for _, kind := range r.OwnerKinds {
+			if strings.EqualFold(ref.Kind, kind) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsExcluded returns true if the object is managed according to the rule of
+// WithExcludeManagedBy, in which case it should be neither synced nor cached.
+func (dc *DataCollector) IsExcluded(obj interface{}) bool {
+	if dc.excludeManagedBy == nil {
+		return false
+	}
+	o, ok := obj.(v1.Object)
+	return ok && dc.excludeManagedBy.matches(o)
+}
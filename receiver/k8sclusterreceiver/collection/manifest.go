@@ -0,0 +1,459 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"reflect"
+	"sort"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"go.opentelemetry.io/collector/consumer/consumerdata"
+	"go.uber.org/zap"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/autoscaling/v2beta1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1beta1 "k8s.io/api/discovery/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+)
+
+// MetricInfo describes a metric the receiver can emit.
+type MetricInfo struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Unit        string `json:"unit"`
+	Description string `json:"description"`
+	// ResourceType is the type of the resources the metric is reported on,
+	// k8s or container.
+	ResourceType string `json:"resource_type"`
+	// ResourceAttributes are the keys of the labels of the resources the
+	// metric is reported on.
+	ResourceAttributes []string `json:"resource_attributes"`
+	// Attributes are the label keys of the time series of the metric.
+	Attributes []string `json:"attributes,omitempty"`
+}
+
+// manifestNodeConditions are the node conditions for which metrics are listed
+// in the manifest, those set by the kubelet.
+var manifestNodeConditions = []string{
+	string(corev1.NodeReady),
+	string(corev1.NodeMemoryPressure),
+	string(corev1.NodeDiskPressure),
+	string(corev1.NodePIDPressure),
+	string(corev1.NodeNetworkUnavailable),
+}
+
+// MetricManifest returns the metrics the receiver can emit, sorted by name,
+// with the default units. It lists the metrics of the registry of metric
+// descriptors, along with those whose names depend on the objects of the
+// cluster, which are listed for the common values only, i.e. cpu and memory
+// for resource quantities and the conditions set by the kubelet for nodes.
+// The resources metrics are reported on are derived by collecting the metrics
+// of a set of objects of every supported kind with all optional metrics
+// enabled.
+func MetricManifest() []MetricInfo {
+	now := time.Now()
+	opts := []Option{
+		WithAntiAffinityViolations(true),
+		WithContainerRestartRate(true),
+		WithContainerStatusReasons(true),
+		WithNodeResources(true),
+		WithInformerCacheMetrics(true),
+		WithDeletionMarkers(true),
+		WithSecurityContext(true),
+		WithObjectCountDelta(true),
+		WithLabelInfoKinds(labelInfoKinds()),
+	}
+
+	infos := map[string]*MetricInfo{}
+	// Metrics of pods managed by workloads are reported either per pod or
+	// per workload.
+	for _, aggregation := range []string{PodAggregationNone, PodAggregationOwner} {
+		dc := NewDataCollector(zap.NewNop(), manifestNodeConditions,
+			append(opts, WithPodAggregation(aggregation))...)
+		objs := manifestObjects(now)
+		seedManifestObjects(dc, objs)
+
+		addMetricInfos(infos, dc.CollectMetricData(now))
+		addMetricInfos(infos, dc.CollectSelfMetricData(now))
+		// Deletion markers are collected once objects have been removed.
+		dc.RemoveFromMetricsStore(objs[0])
+		addMetricInfos(infos, dc.CollectMetricData(now))
+	}
+
+	for _, d := range metricDescriptors {
+		if _, ok := infos[d.Name]; !ok {
+			infos[d.Name] = newMetricInfo(d)
+		}
+	}
+
+	out := make([]MetricInfo, 0, len(infos))
+	for _, info := range infos {
+		sort.Strings(info.ResourceAttributes)
+		out = append(out, *info)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+func labelInfoKinds() []string {
+	kinds := make([]string, 0, len(labelInfoMetricPrefixes))
+	for kind := range labelInfoMetricPrefixes {
+		kinds = append(kinds, kind)
+	}
+	return kinds
+}
+
+// seedManifestObjects adds the objects to informer-like stores of the
+// DataCollector and syncs their metrics.
+func seedManifestObjects(dc *DataCollector, objs []runtime.Object) {
+	stores := map[reflect.Type]cache.Store{}
+	for _, o := range objs {
+		typ := reflect.TypeOf(o)
+		store, ok := stores[typ]
+		if !ok {
+			store = cache.NewStore(cache.MetaNamespaceKeyFunc)
+			stores[typ] = store
+			dc.SetupMetadataStore(o, store)
+		}
+		_ = store.Add(o)
+	}
+	for _, o := range objs {
+		dc.SyncMetrics(o)
+	}
+
+	crd := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "certificates.cert-manager.io", "uid": "crd-uid"},
+		"spec": map[string]interface{}{
+			"group": "cert-manager.io",
+			"names": map[string]interface{}{"kind": "Certificate", "plural": "certificates"},
+		},
+	}}
+	crds := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	_ = crds.Add(crd)
+	dc.SetupCRDStore(crds)
+	dc.SetupCustomResourceStore(crd.GetName(), cache.NewStore(cache.MetaNamespaceKeyFunc))
+
+	quota := map[string]interface{}{"pods": "10"}
+	clusterQuotas := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	_ = clusterQuotas.Add(&unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "clusterquota", "uid": "clusterquota-uid"},
+		"status": map[string]interface{}{
+			"total": map[string]interface{}{"hard": quota, "used": quota},
+			"namespaces": []interface{}{
+				map[string]interface{}{
+					"namespace": "default",
+					"status":    map[string]interface{}{"hard": quota, "used": quota},
+				},
+			},
+		},
+	}})
+	dc.SetupOpenShiftStore(ClusterResourceQuotaGVR, clusterQuotas)
+	deploymentConfigs := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	_ = deploymentConfigs.Add(&unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"namespace": "default", "name": "deploymentconfig", "uid": "deploymentconfig-uid",
+		},
+		"spec":   map[string]interface{}{"replicas": int64(1)},
+		"status": map[string]interface{}{"availableReplicas": int64(1)},
+	}})
+	dc.SetupOpenShiftStore(DeploymentConfigGVR, deploymentConfigs)
+
+	dc.SetupResourceVersionSource(k8sKindPod, func() string { return "1" })
+	dc.RecordInformerRelist(k8sKindPod)
+}
+
+// addMetricInfos adds the metrics of mds to infos, merging the attributes of
+// metrics reported on several resources.
+func addMetricInfos(infos map[string]*MetricInfo, mds []consumerdata.MetricsData) {
+	for _, md := range mds {
+		for _, m := range md.Metrics {
+			d := m.MetricDescriptor
+			info, ok := infos[d.Name]
+			if !ok {
+				info = newMetricInfo(d)
+				info.ResourceType = md.Resource.GetType()
+				infos[d.Name] = info
+			}
+			for k := range md.Resource.GetLabels() {
+				if !containsString(info.ResourceAttributes, k) {
+					info.ResourceAttributes = append(info.ResourceAttributes, k)
+				}
+			}
+		}
+	}
+}
+
+// newMetricInfo returns the description of the metric of d, without the
+// resources it is reported on.
+func newMetricInfo(d *metricspb.MetricDescriptor) *MetricInfo {
+	info := &MetricInfo{
+		Name:        d.Name,
+		Type:        d.Type.String(),
+		Unit:        d.Unit,
+		Description: d.Description,
+		// Listed even if empty since cluster metrics are reported on a
+		// resource without labels.
+		ResourceAttributes: []string{},
+	}
+	for _, k := range d.LabelKeys {
+		info.Attributes = append(info.Attributes, k.Key)
+	}
+	return info
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// manifestObjects returns objects of every supported kind, set up so that
+// every metric of their kind is reported. The first object is removed to
+// collect deletion markers.
+func manifestObjects(now time.Time) []runtime.Object {
+	meta := func(name string) v1.ObjectMeta {
+		return v1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			UID:               types.UID(name + "-uid"),
+			ClusterName:       "cluster",
+			Labels:            map[string]string{"app": name},
+			CreationTimestamp: v1.NewTime(now.Add(-time.Hour)),
+		}
+	}
+	clusterMeta := func(name string) v1.ObjectMeta {
+		om := meta(name)
+		om.Namespace = ""
+		return om
+	}
+	ownedBy := func(om v1.ObjectMeta, kind, name string) v1.ObjectMeta {
+		controller := true
+		om.OwnerReferences = []v1.OwnerReference{
+			{Kind: kind, Name: name, UID: types.UID(name + "-uid"), Controller: &controller},
+		}
+		return om
+	}
+	resources := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("1"),
+		corev1.ResourceMemory: resource.MustParse("1Gi"),
+	}
+	nodeResources := resources.DeepCopy()
+	nodeResources["example.com/gpu"] = resource.MustParse("1")
+	replicas := int32(1)
+	lastScheduleTime := v1.NewTime(now.Add(-time.Minute))
+	deletionTimestamp := v1.NewTime(now.Add(-time.Minute))
+	renewTime := v1.NewMicroTime(now)
+
+	newPod := func(om v1.ObjectMeta) *corev1.Pod {
+		probe := &corev1.Probe{Handler: corev1.Handler{TCPSocket: &corev1.TCPSocketAction{}}}
+		return &corev1.Pod{
+			ObjectMeta: om,
+			Spec: corev1.PodSpec{
+				NodeName: "node",
+				Containers: []corev1.Container{{
+					Name:           "container",
+					Image:          "nginx",
+					Resources:      corev1.ResourceRequirements{Requests: resources, Limits: resources},
+					LivenessProbe:  probe,
+					ReadinessProbe: probe,
+					StartupProbe:   probe,
+				}},
+				ReadinessGates: []corev1.PodReadinessGate{{ConditionType: "example.com/gate"}},
+				Volumes: []corev1.Volume{
+					{Name: "data", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+				},
+				Affinity: &corev1.Affinity{PodAntiAffinity: &corev1.PodAntiAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{
+						LabelSelector: &v1.LabelSelector{MatchLabels: om.Labels},
+						TopologyKey:   corev1.LabelHostname,
+					}},
+				}},
+			},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:        "container",
+					Image:       "nginx",
+					ContainerID: "docker://" + string(om.UID),
+					State:       corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+				}},
+			},
+		}
+	}
+	terminating := newPod(meta("terminating"))
+	terminating.DeletionTimestamp = &deletionTimestamp
+
+	failurePolicy := admissionregistrationv1.Fail
+	ready := true
+
+	return []runtime.Object{
+		&corev1.Namespace{ObjectMeta: clusterMeta("deleted")},
+		&corev1.Node{
+			ObjectMeta: clusterMeta("node"),
+			Spec: corev1.NodeSpec{
+				Unschedulable: true,
+				Taints:        []corev1.Taint{{Key: "key", Effect: corev1.TaintEffectNoSchedule}},
+			},
+			Status: corev1.NodeStatus{
+				Capacity:    nodeResources,
+				Allocatable: nodeResources,
+				Conditions:  []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+			},
+		},
+		&corev1.Namespace{
+			ObjectMeta: clusterMeta("default"),
+			Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceActive},
+		},
+		newPod(meta("pod")),
+		terminating,
+		newPod(ownedBy(meta("replicaset-pod"), k8sKindReplicaSet, "replicaset")),
+		newPod(ownedBy(meta("daemonset-pod"), k8sKindDaemonSet, "daemonset")),
+		&appsv1.Deployment{ObjectMeta: meta("deployment"), Spec: appsv1.DeploymentSpec{Replicas: &replicas}},
+		&appsv1.ReplicaSet{
+			ObjectMeta: ownedBy(meta("replicaset"), k8sKindDeployment, "deployment"),
+			Spec:       appsv1.ReplicaSetSpec{Replicas: &replicas},
+		},
+		&appsv1.DaemonSet{ObjectMeta: meta("daemonset")},
+		&appsv1.StatefulSet{
+			ObjectMeta: meta("statefulset"),
+			Spec: appsv1.StatefulSetSpec{
+				Replicas:             &replicas,
+				VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{ObjectMeta: v1.ObjectMeta{Name: "data"}}},
+			},
+		},
+		&corev1.ReplicationController{
+			ObjectMeta: meta("replicationcontroller"),
+			Spec:       corev1.ReplicationControllerSpec{Replicas: &replicas},
+		},
+		&batchv1.Job{
+			ObjectMeta: meta("job"),
+			Spec:       batchv1.JobSpec{Completions: &replicas, Parallelism: &replicas},
+		},
+		&batchv1beta1.CronJob{
+			ObjectMeta: meta("cronjob"),
+			Status:     batchv1beta1.CronJobStatus{LastScheduleTime: &lastScheduleTime},
+		},
+		&v2beta1.HorizontalPodAutoscaler{
+			ObjectMeta: meta("hpa"),
+			Spec:       v2beta1.HorizontalPodAutoscalerSpec{MinReplicas: &replicas, MaxReplicas: replicas},
+		},
+		&corev1.ResourceQuota{
+			ObjectMeta: meta("resourcequota"),
+			Status:     corev1.ResourceQuotaStatus{Hard: resources, Used: resources},
+		},
+		&corev1.Service{
+			ObjectMeta: meta("service"),
+			Spec: corev1.ServiceSpec{
+				Type:      corev1.ServiceTypeLoadBalancer,
+				ClusterIP: "10.0.0.1",
+				Ports:     []corev1.ServicePort{{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80}},
+			},
+		},
+		&networkingv1.Ingress{
+			ObjectMeta: meta("ingress"),
+			Spec: networkingv1.IngressSpec{
+				Rules: []networkingv1.IngressRule{{
+					Host: "example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{
+							Path: "/",
+							Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{
+								Name: "service", Port: networkingv1.ServiceBackendPort{Number: 80},
+							}},
+						}},
+					}},
+				}},
+			},
+		},
+		&discoveryv1beta1.EndpointSlice{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "service-endpoints",
+				Namespace: "default",
+				UID:       "service-endpoints-uid",
+				Labels:    map[string]string{discoveryv1beta1.LabelServiceName: "service"},
+			},
+			AddressType: discoveryv1beta1.AddressTypeIPv4,
+			Endpoints: []discoveryv1beta1.Endpoint{
+				{Conditions: discoveryv1beta1.EndpointConditions{Ready: &ready}},
+			},
+		},
+		&policyv1beta1.PodDisruptionBudget{ObjectMeta: meta("poddisruptionbudget")},
+		&schedulingv1.PriorityClass{ObjectMeta: clusterMeta("priorityclass"), Value: 1000},
+		&corev1.PersistentVolume{
+			ObjectMeta: clusterMeta("persistentvolume"),
+			Spec: corev1.PersistentVolumeSpec{
+				Capacity:         corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+				ClaimRef:         &corev1.ObjectReference{Name: "persistentvolumeclaim", Namespace: "default"},
+				StorageClassName: "standard",
+			},
+			Status: corev1.PersistentVolumeStatus{Phase: corev1.VolumeBound},
+		},
+		&corev1.PersistentVolumeClaim{
+			ObjectMeta: meta("persistentvolumeclaim"),
+			Spec: corev1.PersistentVolumeClaimSpec{
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+				},
+				VolumeName: "persistentvolume",
+			},
+			Status: corev1.PersistentVolumeClaimStatus{
+				Phase:    corev1.ClaimBound,
+				Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+			},
+		},
+		// The claim of the stateful set, pending.
+		&corev1.PersistentVolumeClaim{
+			ObjectMeta: meta("data-statefulset-0"),
+			Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+		},
+		&corev1.ConfigMap{ObjectMeta: meta("configmap"), Data: map[string]string{"key": "value"}},
+		&corev1.Secret{ObjectMeta: meta("secret")},
+		&coordinationv1.Lease{
+			ObjectMeta: v1.ObjectMeta{Name: "node", Namespace: corev1.NamespaceNodeLease, UID: "lease-uid"},
+			Spec:       coordinationv1.LeaseSpec{RenewTime: &renewTime},
+		},
+		&admissionregistrationv1.MutatingWebhookConfiguration{
+			ObjectMeta: clusterMeta("mutating"),
+			Webhooks:   []admissionregistrationv1.MutatingWebhook{{Name: "webhook", FailurePolicy: &failurePolicy}},
+		},
+		&admissionregistrationv1.ValidatingWebhookConfiguration{
+			ObjectMeta: clusterMeta("validating"),
+			Webhooks:   []admissionregistrationv1.ValidatingWebhook{{Name: "webhook", FailurePolicy: &failurePolicy}},
+		},
+		&rbacv1.Role{ObjectMeta: meta("role")},
+		&rbacv1.ClusterRole{ObjectMeta: clusterMeta(clusterAdminRole)},
+		&rbacv1.RoleBinding{ObjectMeta: meta("rolebinding")},
+		&rbacv1.ClusterRoleBinding{ObjectMeta: clusterMeta("clusterrolebinding")},
+	}
+}
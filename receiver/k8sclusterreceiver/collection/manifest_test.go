@@ -0,0 +1,89 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricManifest(t *testing.T) {
+	manifest := MetricManifest()
+	require.True(t, sort.SliceIsSorted(manifest, func(i, j int) bool {
+		return manifest[i].Name < manifest[j].Name
+	}))
+
+	byName := map[string]MetricInfo{}
+	for _, info := range manifest {
+		byName[info.Name] = info
+	}
+
+	require.Equal(t, MetricInfo{
+		Name:         "k8s.deployment.rollout_stuck_duration",
+		Type:         "GAUGE_INT64",
+		Unit:         "s",
+		Description:  deploymentRolloutStuckDurationMetric.Description,
+		ResourceType: k8sType,
+		ResourceAttributes: []string{
+			"k8s.cluster.name", "k8s.deployment.name", "k8s.deployment.uid", "k8s.namespace.name",
+		},
+	}, byName["k8s.deployment.rollout_stuck_duration"])
+
+	cpuRequest := byName["k8s.container.cpu_request"]
+	require.Equal(t, "{millicores}", cpuRequest.Unit)
+	require.Equal(t, containerType, cpuRequest.ResourceType)
+	require.Contains(t, cpuRequest.ResourceAttributes, "k8s.container.name")
+
+	require.Equal(t, []string{"reason"}, byName["k8s.cluster.pending_pods"].Attributes)
+
+	// Every registered metric is listed along with the resources it is
+	// reported on, so objects must be set up to report every metric.
+	for name, d := range metricDescriptors {
+		info, ok := byName[name]
+		require.True(t, ok, "%s not in the manifest", name)
+		require.Equal(t, d.Type.String(), info.Type, name)
+		require.Equal(t, d.Unit, info.Unit, name)
+		require.NotEmpty(t, info.ResourceType, "%s not collected from the objects of the manifest", name)
+	}
+
+	// Metrics of optional kinds and features are included.
+	for _, name := range []string{
+		"k8s.cluster.informer_cache_objects",
+		"k8s.cluster.last_collection_timestamp",
+		"k8s.container.restart_rate",
+		"k8s.container.status.last_terminated_reason",
+		"k8s.crd.instance_count",
+		"k8s.lease.renew_age",
+		"k8s.node.allocatable",
+		"k8s.node.capacity",
+		"k8s.node.condition_memory_pressure",
+		"k8s.object.deleted",
+		"k8s.pod.anti_affinity_violation",
+		"k8s.ingress.path",
+		"k8s.persistentvolume.storage_capacity",
+		"k8s.persistentvolumeclaim.storage_request",
+		"k8s.pod.labels",
+		"k8s.pod_disruption_budget.disruptions_allowed",
+		"k8s.priority_class.global_default",
+		"k8s.rbac.cluster_admin_binding_count",
+		"k8s.service.load_balancer_ingress",
+		"k8s.service.ready_endpoints",
+		"k8s.workload.pods",
+	} {
+		require.Contains(t, byName, name)
+	}
+}
@@ -17,7 +17,10 @@ package collection
 import (
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1beta1 "k8s.io/api/discovery/v1beta1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/cache"
 )
@@ -26,19 +29,79 @@ import (
 // This store is used while collecting metadata about Pods to be able
 // to correlate other Kubernetes objects with a Pod.
 type metadataStore struct {
-	services    cache.Store
-	jobs        cache.Store
-	replicaSets cache.Store
+	pods         cache.Store
+	nodes        cache.Store
+	namespaces   cache.Store
+	services     cache.Store
+	jobs         cache.Store
+	replicaSets  cache.Store
+	daemonSets   cache.Store
+	deployments  cache.Store
+	statefulSets cache.Store
+	// resourceQuotas is only set if ResourceQuotas are watched.
+	resourceQuotas cache.Store
+	// endpointSlices is only set if EndpointSlices are watched.
+	endpointSlices cache.Store
+	// configMaps and secrets are only set if ConfigMaps and Secrets
+	// respectively are watched.
+	configMaps cache.Store
+	secrets    cache.Store
+	// persistentVolumeClaims is only set if PersistentVolumeClaims are
+	// watched.
+	persistentVolumeClaims cache.Store
+	// leases is only set if node Leases are watched.
+	leases cache.Store
+	// roles, clusterRoles, roleBindings and clusterRoleBindings are only
+	// set if RBAC objects are watched.
+	roles               cache.Store
+	clusterRoles        cache.Store
+	roleBindings        cache.Store
+	clusterRoleBindings cache.Store
 }
 
-// setupStore tracks metadata of services, jobs and replicasets.
+// setupStore tracks metadata of pods, nodes, namespaces, services, jobs,
+// replicasets, daemonsets, deployments, statefulsets, resourcequotas,
+// endpointslices, configmaps, secrets, persistentvolumeclaims, leases and RBAC
+// objects.
 func (ms *metadataStore) setupStore(o runtime.Object, store cache.Store) {
 	switch o.(type) {
+	case *corev1.Pod:
+		ms.pods = store
+	case *corev1.Node:
+		ms.nodes = store
+	case *corev1.Namespace:
+		ms.namespaces = store
+	case *corev1.ConfigMap:
+		ms.configMaps = store
+	case *corev1.Secret:
+		ms.secrets = store
 	case *corev1.Service:
 		ms.services = store
 	case *batchv1.Job:
 		ms.jobs = store
 	case *appsv1.ReplicaSet:
 		ms.replicaSets = store
+	case *appsv1.DaemonSet:
+		ms.daemonSets = store
+	case *appsv1.Deployment:
+		ms.deployments = store
+	case *appsv1.StatefulSet:
+		ms.statefulSets = store
+	case *corev1.PersistentVolumeClaim:
+		ms.persistentVolumeClaims = store
+	case *corev1.ResourceQuota:
+		ms.resourceQuotas = store
+	case *discoveryv1beta1.EndpointSlice:
+		ms.endpointSlices = store
+	case *coordinationv1.Lease:
+		ms.leases = store
+	case *rbacv1.Role:
+		ms.roles = store
+	case *rbacv1.ClusterRole:
+		ms.clusterRoles = store
+	case *rbacv1.RoleBinding:
+		ms.roleBindings = store
+	case *rbacv1.ClusterRoleBinding:
+		ms.clusterRoleBindings = store
 	}
 }
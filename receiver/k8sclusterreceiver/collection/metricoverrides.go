@@ -0,0 +1,91 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"sort"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// MetricOverride changes how a metric is emitted. Empty fields leave the
+// metric as is.
+type MetricOverride struct {
+	// Name the metric is emitted with.
+	Name string
+	// Unit the metric is emitted with. The values are not converted.
+	Unit string
+	// Attributes with constant values added to every datapoint of the
+	// metric.
+	Attributes map[string]string
+}
+
+// metricOverride is a MetricOverride with its attributes sorted by key, so
+// that they are added in a stable order.
+type metricOverride struct {
+	name            string
+	unit            string
+	attributeKeys   []string
+	attributeValues []string
+}
+
+func newMetricOverride(o MetricOverride) metricOverride {
+	mo := metricOverride{name: o.Name, unit: o.Unit}
+	for k := range o.Attributes {
+		mo.attributeKeys = append(mo.attributeKeys, k)
+	}
+	sort.Strings(mo.attributeKeys)
+	for _, k := range mo.attributeKeys {
+		mo.attributeValues = append(mo.attributeValues, o.Attributes[k])
+	}
+	return mo
+}
+
+// applyMetricOverrides renames the metrics of the given resource metrics,
+// changes their unit and adds constant labels to their time series as set by
+// the overrides keyed by metric name. It is expected to be called once all
+// other transformations are applied, since they refer to metrics by their
+// original names. Labels are not added to metrics already having a label with
+// the same key.
+func applyMetricOverrides(rms []*resourceMetrics, overrides map[string]metricOverride) {
+	if len(overrides) == 0 {
+		return
+	}
+
+	for _, rm := range rms {
+		for _, m := range rm.metrics {
+			mo, ok := overrides[m.MetricDescriptor.GetName()]
+			if !ok {
+				continue
+			}
+			if mo.name != "" || mo.unit != "" {
+				// Descriptors are shared between the metrics of all objects
+				// of a kind, so they are copied before being changed.
+				descriptor := proto.Clone(m.MetricDescriptor).(*metricspb.MetricDescriptor)
+				if mo.name != "" {
+					descriptor.Name = mo.name
+				}
+				if mo.unit != "" {
+					descriptor.Unit = mo.unit
+				}
+				m.MetricDescriptor = descriptor
+			}
+			if len(mo.attributeKeys) > 0 {
+				addDatapointLabels(m, mo.attributeKeys, mo.attributeValues)
+			}
+		}
+	}
+}
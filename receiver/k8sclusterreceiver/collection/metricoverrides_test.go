@@ -0,0 +1,73 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestDataCollectorMetricOverrides(t *testing.T) {
+	h := newTestHarness(t, nil,
+		WithDisabledMetrics([]string{"k8s.container.restarts"}),
+		WithMetricOverrides(map[string]MetricOverride{
+			"k8s.pod.phase": {
+				Name:       "kube_pod_status_phase",
+				Unit:       "{phase}",
+				Attributes: map[string]string{"team": "platform", "env": "prod"},
+			},
+			// Metrics are disabled by their original names.
+			"k8s.container.restarts":     {Name: "kube_pod_container_status_restarts_total"},
+			"k8s.container.cpu_limit":    {Unit: "m"},
+			"k8s.container.memory_limit": {},
+		}))
+	h.seed(newPodWithContainer("1", podSpecWithContainer("container-name"), podStatusWithContainer("container-name", containerIDWithPreifx("container-id"))))
+
+	labels := map[string]string{"k8s.pod.uid": "test-pod-1-uid"}
+	h.requireNoMetric("k8s.pod.phase", labels)
+	h.requireNoMetric("k8s.container.restarts", nil)
+	h.requireNoMetric("kube_pod_container_status_restarts_total", nil)
+
+	m := h.requireMetric("kube_pod_status_phase", labels)
+	require.Equal(t, "{phase}", m.MetricDescriptor.Unit)
+	require.Equal(t, podPhaseMetric.Description, m.MetricDescriptor.Description)
+	require.Equal(t, "env", m.MetricDescriptor.LabelKeys[0].Key)
+	require.Equal(t, map[string]string{"env": "prod", "team": "platform"}, datapointLabels(t, m))
+
+	cpuLimit := h.requireMetric("k8s.container.cpu_limit", nil)
+	require.Equal(t, "m", cpuLimit.MetricDescriptor.Unit)
+
+	// Shared descriptors are left untouched.
+	require.Equal(t, "k8s.pod.phase", podPhaseMetric.Name)
+	require.Empty(t, podPhaseMetric.LabelKeys)
+}
+
+func TestDataCollectorMetricOverridesCollectionTime(t *testing.T) {
+	dc := NewDataCollector(zap.NewNop(), nil, WithMetricOverrides(map[string]MetricOverride{
+		"k8s.cluster.last_collection_timestamp": {Name: "kube_last_collection_timestamp"},
+	}))
+
+	var names []string
+	for _, md := range dc.CollectSelfMetricData(time.Now()) {
+		for _, m := range md.Metrics {
+			names = append(names, m.MetricDescriptor.Name)
+		}
+	}
+	require.Contains(t, names, "kube_last_collection_timestamp")
+	require.NotContains(t, names, "k8s.cluster.last_collection_timestamp")
+}
@@ -15,16 +15,25 @@
 package collection
 
 import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
 	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
 	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
 	"go.opentelemetry.io/collector/consumer/consumerdata"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/observability"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
 )
 
@@ -35,6 +44,99 @@ import (
 type metricsStore struct {
 	sync.RWMutex
 	metricsCache map[types.UID][]consumerdata.MetricsData
+	// maxObjects is the maximum number of objects cached per kind, keyed
+	// by lower-cased kind. Kinds without an entry are not limited.
+	maxObjects map[string]int
+	// kindCounts is the number of cached objects of each limited kind.
+	kindCounts map[string]int
+	// dropZeroValues is the set of metric names for which datapoints with
+	// a value of zero are not emitted.
+	dropZeroValues map[string]bool
+	// uidFallback enables caching objects without a UID under a key derived
+	// from their kind, namespace and name.
+	uidFallback bool
+	// skipUnchanged enables omitting the metrics of objects whose cached
+	// metrics have not changed since they were last collected.
+	skipUnchanged bool
+	// contentHashes is the hash of the cached metrics of each object and
+	// collectedHashes the hash of the metrics of each object as of the last
+	// time they were collected. Only tracked when skipUnchanged is set.
+	contentHashes   map[types.UID]uint64
+	collectedHashes map[types.UID]uint64
+	// fullResyncInterval is how often the metrics of all objects are
+	// collected even if skipUnchanged is set, or 0 if they never are.
+	// lastFullResync is when they last were.
+	fullResyncInterval time.Duration
+	lastFullResync     time.Time
+	// deletionGracePeriod is how long the metrics of deleted objects are
+	// still collected after their deletion.
+	deletionGracePeriod time.Duration
+	// deleted holds the deleted objects whose metrics are still collected
+	// until their deletion grace period has elapsed.
+	deleted map[types.UID]deletedObject
+	// emitDeletionMarkers enables collecting a deletion marker for each
+	// resource of an object once, when its metrics are evicted.
+	emitDeletionMarkers bool
+	// deletionMarkers are the deletion markers to be collected next.
+	deletionMarkers []consumerdata.MetricsData
+	// updatedAt is the time each cached object was last updated at.
+	updatedAt map[types.UID]time.Time
+	// kinds is the kind of each cached object, e.g. Pod.
+	kinds map[types.UID]string
+	// now returns the current time, time.Now if not set.
+	now func() time.Time
+	// warmupIntervals is the number of first collections over which the
+	// collected share of cached objects ramps up to all of them.
+	warmupIntervals int
+	// collections is the number of collections so far, counted up to
+	// warmupIntervals only.
+	collections int
+	// node is the OpenCensus node set on all the metrics data, identifying
+	// their source. Not set if nil.
+	node *commonpb.Node
+	// interner deduplicates the strings of cached metrics, if set.
+	interner *stringInterner
+}
+
+var clusterOldestCachedObjectAgeMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name: "k8s.cluster.oldest_cached_object_age",
+	Description: "Time since the cached object updated the longest time ago was last updated, " +
+		"climbing without bound if deleted objects are not removed from the cache",
+	Unit: "s",
+	Type: metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var objectDeletedMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.object.deleted",
+	Description: "Emitted once with a value of 1 for each resource of an object after the object has been deleted",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+// deletedObject is an object whose cached metrics are kept after its deletion.
+type deletedObject struct {
+	// Lower-cased kind of the object.
+	kind      string
+	deletedAt time.Time
+}
+
+// errObjectLimitReached is returned when an object is not cached because
+// the limit for its kind has been reached.
+var errObjectLimitReached = errors.New("object limit reached")
+
+// errMissingUID is returned when an object without a UID is not cached
+// since no fallback key is derived for it.
+var errMissingUID = errors.New("object has no UID")
+
+// malformedMetricsError is returned when metrics of an object are not cached
+// since they are malformed. The other metrics of the object are cached.
+type malformedMetricsError struct {
+	// names are the names of the metrics that were not cached.
+	names []string
+}
+
+func (e *malformedMetricsError) Error() string {
+	return fmt.Sprintf("malformed metrics not cached: %s", strings.Join(e.names, ", "))
 }
 
 // This probably wouldn't be required once the new OTLP ResourceMetrics
@@ -49,61 +151,722 @@ func (ms *metricsStore) update(obj runtime.Object, rms []*resourceMetrics) error
 	ms.Lock()
 	defer ms.Unlock()
 
-	key, err := utils.GetUIDForObject(obj)
+	key, err := ms.getKeyForObject(obj)
 	if err != nil {
 		return err
 	}
 
+	// An object updated during its deletion grace period, e.g. since it
+	// was relisted, is no longer considered deleted.
+	delete(ms.deleted, key)
+
+	// Objects already in the cache are always updated, the limit only
+	// applies to new objects.
+	if _, ok := ms.metricsCache[key]; !ok {
+		kind := strings.ToLower(getObjectKind(obj))
+		if limit, limited := ms.maxObjects[kind]; limited {
+			if ms.kindCounts[kind] >= limit {
+				return errObjectLimitReached
+			}
+			ms.kindCounts[kind]++
+		}
+	}
+
+	rms, rejected := removeMalformedMetrics(rms)
+	if ms.interner != nil {
+		ms.interner.internResourceMetrics(rms)
+	}
+	ms.metricsCache[key] = toMetricsData(rms, ms.node)
+	if ms.updatedAt == nil {
+		ms.updatedAt = map[types.UID]time.Time{}
+	}
+	ms.updatedAt[key] = ms.currentTime()
+	if ms.kinds == nil {
+		ms.kinds = map[types.UID]string{}
+	}
+	ms.kinds[key] = getObjectKind(obj)
+	if ms.skipUnchanged {
+		ms.contentHashes[key] = hashMetricsData(ms.metricsCache[key])
+	}
+	if len(rejected) > 0 {
+		return &malformedMetricsError{names: rejected}
+	}
+	return nil
+}
+
+// removeMalformedMetrics returns rms without the metrics that cannot be read
+// from the cache without panicking, i.e. metrics without a descriptor, time
+// series or points, along with the names of the removed metrics. Metrics read
+// from the cache are expected to have a point in every time series.
+func removeMalformedMetrics(rms []*resourceMetrics) ([]*resourceMetrics, []string) {
+	var rejected []string
+	out := rms[:0:0]
+	for _, rm := range rms {
+		if rm == nil {
+			continue
+		}
+
+		metrics := rm.metrics[:0:0]
+		for _, m := range rm.metrics {
+			if isMalformedMetric(m) {
+				rejected = append(rejected, m.GetMetricDescriptor().GetName())
+				continue
+			}
+			metrics = append(metrics, m)
+		}
+		if len(metrics) < len(rm.metrics) {
+			rm = &resourceMetrics{resource: rm.resource, metrics: metrics}
+		}
+		out = append(out, rm)
+	}
+	return out, rejected
+}
+
+func isMalformedMetric(m *metricspb.Metric) bool {
+	if m == nil || m.MetricDescriptor == nil || len(m.Timeseries) == 0 {
+		return true
+	}
+	for _, ts := range m.Timeseries {
+		if ts == nil || len(ts.Points) == 0 || ts.Points[0] == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// hashMetricsData returns a hash of the content of mds. Timestamps are not
+// set on cached metrics and hence do not affect the hash.
+func hashMetricsData(mds []consumerdata.MetricsData) uint64 {
+	h := fnv.New64a()
+	opts := proto.MarshalOptions{Deterministic: true}
+	write := func(m proto.Message) {
+		// Marshaling only fails for invalid messages, in which case their
+		// content is simply not hashed.
+		b, _ := opts.Marshal(m)
+		h.Write(b)
+		// Separate messages so that their boundaries affect the hash.
+		h.Write([]byte{0})
+	}
+	for _, md := range mds {
+		write(md.Node)
+		write(md.Resource)
+		for _, metric := range md.Metrics {
+			write(metric)
+		}
+	}
+	return h.Sum64()
+}
+
+// getKeyForObject returns the key under which metrics of obj are cached, its
+// UID. If obj has no UID and uidFallback is enabled, the key is derived from
+// the kind, namespace and name of obj instead.
+func (ms *metricsStore) getKeyForObject(obj runtime.Object) (types.UID, error) {
+	key, err := utils.GetUIDForObject(obj)
+	if err != nil || key != "" {
+		return key, err
+	}
+	if !ms.uidFallback {
+		return key, errMissingUID
+	}
+
+	om := obj.(metav1.ObjectMetaAccessor).GetObjectMeta()
+	return types.UID(fmt.Sprintf("%s/%s/%s",
+		strings.ToLower(getObjectKind(obj)), om.GetNamespace(), om.GetName())), nil
+}
+
+// toMetricsData returns rms as metrics data with the given node, which is
+// shared by all of them and hence must not be modified.
+func toMetricsData(rms []*resourceMetrics, node *commonpb.Node) []consumerdata.MetricsData {
 	mds := make([]consumerdata.MetricsData, len(rms))
 	for i, rm := range rms {
+		mds[i].Node = node
 		mds[i].Resource = rm.resource
 		mds[i].Metrics = rm.metrics
 	}
+	return mds
+}
+
+// removeDisabledMetrics returns the given resource metrics without the metrics
+// whose names are in disabled. Resources left without metrics are omitted.
+func removeDisabledMetrics(rms []*resourceMetrics, disabled map[string]bool) []*resourceMetrics {
+	if len(disabled) == 0 {
+		return rms
+	}
 
-	ms.metricsCache[key] = mds
-	return nil
+	out := make([]*resourceMetrics, 0, len(rms))
+	for _, rm := range rms {
+		metrics := make([]*metricspb.Metric, 0, len(rm.metrics))
+		for _, m := range rm.metrics {
+			if !disabled[m.MetricDescriptor.GetName()] {
+				metrics = append(metrics, m)
+			}
+		}
+		if len(metrics) == 0 {
+			continue
+		}
+		rm.metrics = metrics
+		out = append(out, rm)
+	}
+	return out
+}
+
+// filterResourceLabels removes the resource labels of the given resource
+// metrics whose keys are not in included, except for the object kind set by
+// addObjectKindLabels. Nothing is removed if included is empty. It is
+// expected to be called before resource labels are renamed.
+func filterResourceLabels(rms []*resourceMetrics, included map[string]bool) {
+	if len(included) == 0 {
+		return
+	}
+
+	for _, rm := range rms {
+		labels := make(map[string]string, len(included))
+		for k, v := range rm.resource.Labels {
+			if included[k] || k == k8sKeyObjectKind {
+				labels[k] = v
+			}
+		}
+		rm.resource.Labels = labels
+	}
+}
+
+// renameResourceLabels renames the resource labels of the given resource
+// metrics according to keys, which maps default label keys to the keys to
+// use instead. Labels without an entry in keys are renamed to the given
+// AttributeKeyStyle.
+func renameResourceLabels(rms []*resourceMetrics, keys map[string]string, style string) {
+	if len(keys) == 0 && (style == "" || style == AttributeKeyStyleDot) {
+		return
+	}
+
+	for _, rm := range rms {
+		labels := make(map[string]string, len(rm.resource.Labels))
+		for k, v := range rm.resource.Labels {
+			if newKey, ok := keys[k]; ok {
+				k = newKey
+			} else {
+				k = styleAttributeKey(k, style)
+			}
+			labels[k] = v
+		}
+		rm.resource.Labels = labels
+	}
+}
+
+// TruncatedValueSuffix is appended to attribute values truncated to the
+// maximum attribute value length.
+const TruncatedValueSuffix = "..."
+
+// truncateAttributeValues truncates the values of resource labels and of
+// time series labels of the given resource metrics that are longer than
+// maxLength bytes. A maxLength of 0 disables truncation.
+func truncateAttributeValues(rms []*resourceMetrics, maxLength int) {
+	if maxLength <= 0 {
+		return
+	}
+
+	for _, rm := range rms {
+		for k, v := range rm.resource.Labels {
+			rm.resource.Labels[k] = truncateValue(v, maxLength)
+		}
+		for _, m := range rm.metrics {
+			for _, ts := range m.Timeseries {
+				for _, lv := range ts.LabelValues {
+					lv.Value = truncateValue(lv.Value, maxLength)
+				}
+			}
+		}
+	}
+}
+
+// truncateValue returns v if it is at most maxLength bytes long. Otherwise,
+// it returns a prefix of v followed by TruncatedValueSuffix that is at most
+// maxLength bytes long, without splitting multi-byte characters.
+func truncateValue(v string, maxLength int) string {
+	if len(v) <= maxLength {
+		return v
+	}
+
+	n := maxLength - len(TruncatedValueSuffix)
+	if n < 0 {
+		n = 0
+	}
+	for n > 0 && !utf8.RuneStart(v[n]) {
+		n--
+	}
+	return v[:n] + TruncatedValueSuffix
 }
 
-// removes entry from metric cache when resources are deleted.
+// isCached returns whether metrics of the object with the given key are
+// cached and the object is not deleted.
+func (ms *metricsStore) isCached(key types.UID) bool {
+	ms.RLock()
+	defer ms.RUnlock()
+	_, cached := ms.metricsCache[key]
+	_, deleted := ms.deleted[key]
+	return cached && !deleted
+}
+
+// removes entry from metric cache when resources are deleted. With a
+// deletion grace period, the entry is only marked as deleted and evicted once
+// the grace period has elapsed.
 func (ms *metricsStore) remove(obj runtime.Object) error {
 	ms.Lock()
 	defer ms.Unlock()
 
-	key, err := utils.GetUIDForObject(obj)
+	key, err := ms.getKeyForObject(obj)
 	if err != nil {
 		return err
 	}
 
-	delete(ms.metricsCache, key)
+	if _, ok := ms.metricsCache[key]; !ok {
+		return nil
+	}
+
+	ms.removeKey(key, strings.ToLower(getObjectKind(obj)))
 	return nil
 }
 
-// getMetricData returns metricsCache stored in the cache at a given point in time.
-func (ms *metricsStore) getMetricData(currentTime time.Time) []consumerdata.MetricsData {
+// removeKey evicts the entry of the object with the given key and lower-cased
+// kind from the cache, or marks it as deleted if a deletion grace period is
+// set. Must be called with the lock held.
+func (ms *metricsStore) removeKey(key types.UID, kind string) {
+	if ms.deletionGracePeriod > 0 {
+		if _, ok := ms.deleted[key]; !ok {
+			ms.deleted[key] = deletedObject{kind: kind, deletedAt: ms.currentTime()}
+		}
+		return
+	}
+	ms.evict(key, kind)
+}
+
+// evict removes the entry of the object with the given key and lower-cased
+// kind from the cache. Must be called with the lock held.
+func (ms *metricsStore) evict(key types.UID, kind string) {
+	if ms.emitDeletionMarkers {
+		for _, md := range ms.metricsCache[key] {
+			ms.deletionMarkers = append(ms.deletionMarkers, getDeletionMarker(md))
+		}
+	}
+
+	if _, limited := ms.maxObjects[kind]; limited {
+		ms.kindCounts[kind]--
+	}
+
+	delete(ms.metricsCache, key)
+	delete(ms.contentHashes, key)
+	delete(ms.collectedHashes, key)
+	delete(ms.deleted, key)
+	delete(ms.updatedAt, key)
+	delete(ms.kinds, key)
+}
+
+func (ms *metricsStore) currentTime() time.Time {
+	if ms.now != nil {
+		return ms.now()
+	}
+	return time.Now()
+}
+
+// getOldestCachedObjectMetricsForCluster returns the time elapsed at the
+// given time since the cached object updated the longest time ago was last
+// updated. Objects are only updated as they change, so the age also keeps
+// growing for objects that do not change, but it only climbs without bound
+// if the same object stays the oldest, e.g. since its deletion was missed.
+// Nothing is reported while the cache is empty.
+func (ms *metricsStore) getOldestCachedObjectMetricsForCluster(currentTime time.Time) []*resourceMetrics {
 	ms.RLock()
 	defer ms.RUnlock()
 
+	if len(ms.updatedAt) == 0 {
+		return nil
+	}
+	var oldest time.Time
+	for _, t := range ms.updatedAt {
+		if oldest.IsZero() || t.Before(oldest) {
+			oldest = t
+		}
+	}
+
+	return []*resourceMetrics{
+		{
+			resource: getResourceForCluster(),
+			metrics: []*metricspb.Metric{
+				{
+					MetricDescriptor: clusterOldestCachedObjectAgeMetric,
+					Timeseries: []*metricspb.TimeSeries{
+						utils.GetInt64TimeSeries(int64(currentTime.Sub(oldest).Seconds())),
+					},
+				},
+			},
+		},
+	}
+}
+
+// getDeletionMarker returns the deletion marker of the resource of md.
+func getDeletionMarker(md consumerdata.MetricsData) consumerdata.MetricsData {
+	out := consumerdata.MetricsData{
+		Metrics: []*metricspb.Metric{
+			{
+				MetricDescriptor: objectDeletedMetric,
+				Timeseries: []*metricspb.TimeSeries{
+					utils.GetInt64TimeSeries(1),
+				},
+			},
+		},
+	}
+	if md.Node != nil {
+		out.Node = proto.Clone(md.Node).(*commonpb.Node)
+	}
+	if md.Resource != nil {
+		out.Resource = proto.Clone(md.Resource).(*resourcepb.Resource)
+	}
+	return out
+}
+
+// evictExpired evicts the entries of deleted objects whose deletion grace
+// period has elapsed at the given time. Must be called with the lock held.
+func (ms *metricsStore) evictExpired(currentTime time.Time) {
+	for key, d := range ms.deleted {
+		if !currentTime.Before(d.deletedAt.Add(ms.deletionGracePeriod)) {
+			ms.evict(key, d.kind)
+		}
+	}
+}
+
+// getMetricData returns metricsCache stored in the cache at a given point in time.
+// The returned data is a copy of the cache, so that it can be modified and
+// shared between consumers without affecting the cache or other snapshots.
+// If skipUnchanged is set, metrics of objects that have not changed since the
+// previous call are omitted, except once every fullResyncInterval. During
+// warm-up, only a growing share of the objects is included. Deleted objects
+// whose deletion grace period has elapsed are evicted first. Pending deletion
+// markers are collected once.
+func (ms *metricsStore) getMetricData(currentTime time.Time) []consumerdata.MetricsData {
 	var out []consumerdata.MetricsData
+	ms.flushMetricData(currentTime, 0, nil, func(mds []consumerdata.MetricsData) {
+		out = mds
+	})
+	return out
+}
+
+// flushMetricData collects the cached metrics like getMetricData does, but
+// passes them to flush in batches as soon as the number of metrics collected
+// since the previous batch reaches flushThreshold, rather than all at once.
+// This bounds the metrics held during collection on large clusters. With a
+// flushThreshold of 0, flush is called once with all the metrics. Batches are
+// at least flushThreshold metrics large, except for the last one, and the
+// metrics of a resource are never split. Unless due is nil, only the objects
+// of the kinds it reports as due are collected.
+//
+// The lock is only held while the objects to collect are listed and each
+// batch is built, not while flush runs, so that a slow consumer does not hold
+// up updates of the cache. Objects removed between two batches are skipped,
+// and objects updated in between are collected as updated.
+func (ms *metricsStore) flushMetricData(
+	currentTime time.Time, flushThreshold int, due func(kind string) bool, flush func([]consumerdata.MetricsData)) {
+	// All the points share a single timestamp.
+	ts := timestamppb.New(currentTime)
+
+	markers, keys, fullResync := ms.startCollection(currentTime, due)
+	// Deletion markers are only collected once, in the first batch.
+	out := make([]consumerdata.MetricsData, 0, len(markers))
+	numMetrics := 0
+	for _, md := range markers {
+		setTimestamp(md.Metrics, ts)
+		out = append(out, md)
+		numMetrics += len(md.Metrics)
+	}
+
+	flushed := false
+	for {
+		out, keys = ms.collectBatch(out, numMetrics, keys, flushThreshold, ts, fullResync)
+		if len(out) == 0 && (flushed || flushThreshold > 0) {
+			return
+		}
+		flush(out)
+		flushed = true
+		if len(keys) == 0 {
+			return
+		}
+		out, numMetrics = nil, 0
+	}
+}
+
+// startCollection evicts the objects whose grace period has elapsed and
+// returns the pending deletion markers, which are no longer pending
+// afterwards, the keys of the objects to collect at currentTime and whether
+// this is a full resync.
+func (ms *metricsStore) startCollection(
+	currentTime time.Time, due func(kind string) bool) ([]consumerdata.MetricsData, []types.UID, bool) {
+	// Tracking collections modifies the store.
+	ms.Lock()
+	defer ms.Unlock()
+
+	ms.evictExpired(currentTime)
+	observability.RecordMetricsStoreObjects(int64(len(ms.metricsCache)))
+
+	// During warm-up, the nth collection only includes the objects hashed
+	// into the first n of warmupIntervals buckets.
+	warmupBuckets := 0
+	if ms.collections < ms.warmupIntervals {
+		ms.collections++
+		if ms.collections < ms.warmupIntervals {
+			warmupBuckets = ms.collections
+		}
+	}
+
+	// Unchanged objects are collected again on full resyncs, e.g. so that
+	// backends dropping stale series eventually get them back.
+	fullResync := ms.fullResyncInterval > 0 &&
+		!currentTime.Before(ms.lastFullResync.Add(ms.fullResyncInterval))
+	if fullResync {
+		ms.lastFullResync = currentTime
+	}
+
+	markers := ms.deletionMarkers
+	ms.deletionMarkers = nil
+
+	keys := make([]types.UID, 0, len(ms.metricsCache))
+	for key := range ms.metricsCache {
+		if warmupBuckets > 0 && warmupBucket(key, ms.warmupIntervals) >= warmupBuckets {
+			continue
+		}
+		if due != nil && !due(ms.kinds[key]) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return markers, keys, fullResync
+}
+
+// collectBatch appends copies of the cached metrics of the objects with the
+// given keys to out, which already holds numMetrics metrics, until it holds
+// at least flushThreshold metrics, or all of them with a flushThreshold of 0,
+// and returns it along with the keys left to collect.
+func (ms *metricsStore) collectBatch(out []consumerdata.MetricsData, numMetrics int,
+	keys []types.UID, flushThreshold int, ts *timestamppb.Timestamp, fullResync bool) ([]consumerdata.MetricsData, []types.UID) {
+	// Tracking collected hashes modifies the store.
+	ms.Lock()
+	defer ms.Unlock()
+
+	for len(keys) > 0 && (flushThreshold <= 0 || numMetrics < flushThreshold) {
+		key := keys[0]
+		keys = keys[1:]
+		mds, ok := ms.metricsCache[key]
+		if !ok {
+			continue
+		}
+		if ms.skipUnchanged {
+			hash := ms.contentHashes[key]
+			if collected, ok := ms.collectedHashes[key]; ok && collected == hash && !fullResync {
+				continue
+			}
+			ms.collectedHashes[key] = hash
+		}
 
-	for _, mds := range ms.metricsCache {
 		for _, md := range mds {
 			// Set datapoint timestamp to be time of retrieval from cache.
-			applyCurrentTime(md.Metrics, currentTime)
+			md = copyMetricsDataAt(md, ts)
+			if len(ms.dropZeroValues) > 0 {
+				md.Metrics = filterZeroValues(md.Metrics, ms.dropZeroValues)
+			}
+			out = append(out, md)
+			numMetrics += len(md.Metrics)
+		}
+	}
+	return out, keys
+}
+
+// collectDeletionMarkers evicts the entries of deleted objects whose grace
+// period has elapsed at currentTime and returns the pending deletion markers,
+// which are no longer pending afterwards.
+func (ms *metricsStore) collectDeletionMarkers(currentTime time.Time) []consumerdata.MetricsData {
+	ms.Lock()
+	defer ms.Unlock()
+
+	ms.evictExpired(currentTime)
+	markers := ms.deletionMarkers
+	ms.deletionMarkers = nil
+
+	ts := timestamppb.New(currentTime)
+	for _, md := range markers {
+		setTimestamp(md.Metrics, ts)
+	}
+	return markers
+}
+
+// warmupBucket returns the warm-up bucket, out of the given number of buckets,
+// of the object with the given key.
+func warmupBucket(key types.UID, buckets int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % uint32(buckets))
+}
+
+// getMetricDataForObjects returns a copy of the cached metrics of the given
+// objects at a given point in time. Objects without cached metrics are
+// skipped. If skipUnchanged is set, objects whose metrics have not changed
+// since they were last collected are skipped as well.
+func (ms *metricsStore) getMetricDataForObjects(objs []runtime.Object, currentTime time.Time) []consumerdata.MetricsData {
+	ms.Lock()
+	defer ms.Unlock()
+
+	ts := timestamppb.New(currentTime)
+	var out []consumerdata.MetricsData
+	for _, obj := range objs {
+		key, err := ms.getKeyForObject(obj)
+		if err != nil {
+			continue
+		}
+		mds, ok := ms.metricsCache[key]
+		if !ok {
+			continue
+		}
+		if ms.skipUnchanged {
+			hash := ms.contentHashes[key]
+			if collected, ok := ms.collectedHashes[key]; ok && collected == hash {
+				continue
+			}
+			ms.collectedHashes[key] = hash
+		}
+
+		for _, md := range mds {
+			md = copyMetricsDataAt(md, ts)
+			if len(ms.dropZeroValues) > 0 {
+				md.Metrics = filterZeroValues(md.Metrics, ms.dropZeroValues)
+			}
 			out = append(out, md)
 		}
 	}
+	return out
+}
+
+// copyMetricsDataAt returns a copy of cached metrics whose points have the
+// given timestamp. Copying is done by hand rather than with proto.Clone, which
+// relies on reflection, since it is done for every cached metric on every
+// collection. Only what is modified after collection, i.e. resources and
+// points, is copied: descriptors, label values and point values, which are
+// replaced rather than modified, are shared with the cache.
+func copyMetricsDataAt(md consumerdata.MetricsData, ts *timestamppb.Timestamp) consumerdata.MetricsData {
+	out := consumerdata.MetricsData{}
+	if md.Node != nil {
+		out.Node = proto.Clone(md.Node).(*commonpb.Node)
+	}
+	if md.Resource != nil {
+		labels := make(map[string]string, len(md.Resource.Labels))
+		for k, v := range md.Resource.Labels {
+			labels[k] = v
+		}
+		out.Resource = &resourcepb.Resource{Type: md.Resource.Type, Labels: labels}
+	}
+	if md.Metrics == nil {
+		return out
+	}
+
+	// Time series and points are allocated at once for all the metrics.
+	numSeries, numPoints := 0, 0
+	for _, metric := range md.Metrics {
+		if metric == nil {
+			continue
+		}
+		numSeries += len(metric.Timeseries)
+		for _, series := range metric.Timeseries {
+			numPoints += len(series.Points)
+		}
+	}
+	metrics := make([]metricspb.Metric, len(md.Metrics))
+	seriesPtrs := make([]*metricspb.TimeSeries, numSeries)
+	series := make([]metricspb.TimeSeries, numSeries)
+	pointPtrs := make([]*metricspb.Point, numPoints)
+	points := make([]metricspb.Point, numPoints)
 
+	out.Metrics = make([]*metricspb.Metric, len(md.Metrics))
+	si, pi := 0, 0
+	for i, metric := range md.Metrics {
+		if metric == nil {
+			continue
+		}
+		m := &metrics[i]
+		m.MetricDescriptor = metric.MetricDescriptor
+		m.Resource = metric.Resource
+		m.Timeseries = seriesPtrs[si : si+len(metric.Timeseries) : si+len(metric.Timeseries)]
+		for j, src := range metric.Timeseries {
+			dst := &series[si]
+			si++
+			dst.StartTimestamp = src.StartTimestamp
+			dst.LabelValues = src.LabelValues
+			dst.Points = pointPtrs[pi : pi+len(src.Points) : pi+len(src.Points)]
+			for k, point := range src.Points {
+				p := &points[pi]
+				pi++
+				p.Timestamp = point.Timestamp
+				p.Value = point.Value
+				dst.Points[k] = p
+			}
+			if len(dst.Points) > 0 {
+				dst.Points[0].Timestamp = ts
+			}
+			m.Timeseries[j] = dst
+		}
+		out.Metrics[i] = m
+	}
 	return out
 }
 
 func applyCurrentTime(metrics []*metricspb.Metric, t time.Time) []*metricspb.Metric {
-	currentTime := timestamppb.New(t)
+	setTimestamp(metrics, timestamppb.New(t))
+	return metrics
+}
+
+// setTimestamp sets the timestamp of the points of the metrics.
+func setTimestamp(metrics []*metricspb.Metric, ts *timestamppb.Timestamp) {
 	for _, metric := range metrics {
 		if metric != nil {
 			for i := range metric.Timeseries {
-				metric.Timeseries[i].Points[0].Timestamp = currentTime
+				metric.Timeseries[i].Points[0].Timestamp = ts
 			}
 		}
 	}
-	return metrics
+}
+
+// filterZeroValues returns metrics without the timeseries whose value is zero,
+// for metrics whose names are in the given set. Metrics left without any
+// timeseries are omitted. Cached metrics are not modified.
+func filterZeroValues(metrics []*metricspb.Metric, names map[string]bool) []*metricspb.Metric {
+	out := make([]*metricspb.Metric, 0, len(metrics))
+	for _, metric := range metrics {
+		if metric == nil || !names[metric.MetricDescriptor.GetName()] {
+			out = append(out, metric)
+			continue
+		}
+
+		timeseries := make([]*metricspb.TimeSeries, 0, len(metric.Timeseries))
+		for _, ts := range metric.Timeseries {
+			if !isZeroValue(ts.Points[0]) {
+				timeseries = append(timeseries, ts)
+			}
+		}
+
+		if len(timeseries) == 0 {
+			continue
+		}
+
+		out = append(out, &metricspb.Metric{
+			MetricDescriptor: metric.MetricDescriptor,
+			Resource:         metric.Resource,
+			Timeseries:       timeseries,
+		})
+	}
+	return out
+}
+
+func isZeroValue(p *metricspb.Point) bool {
+	switch v := p.Value.(type) {
+	case *metricspb.Point_Int64Value:
+		return v.Int64Value == 0
+	case *metricspb.Point_DoubleValue:
+		return v.DoubleValue == 0
+	}
+	return false
 }
@@ -15,14 +15,20 @@
 package collection
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/collector/consumer/consumerdata"
+	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
 )
 
 func TestMetricsStoreOperations(t *testing.T) {
@@ -81,3 +87,525 @@ func TestMetricsStoreOperations(t *testing.T) {
 	require.Equal(t, expectedMetricData, len(ms.getMetricData(time.Now())))
 
 }
+
+func TestMetricsStoreUIDFallback(t *testing.T) {
+	newPodWithoutUID := func() *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: v1.ObjectMeta{Name: "test-pod", Namespace: "test-namespace"}}
+	}
+
+	strict := metricsStore{metricsCache: map[types.UID][]consumerdata.MetricsData{}}
+	require.Equal(t, errMissingUID, strict.update(newPodWithoutUID(), []*resourceMetrics{{}}))
+	require.Equal(t, 0, len(strict.metricsCache))
+
+	ms := NewDataCollector(zap.NewNop(), nil, WithUIDFallback(true)).metricsStore
+	require.NoError(t, ms.update(newPodWithoutUID(), []*resourceMetrics{{}}))
+	require.NoError(t, ms.update(&corev1.Pod{ObjectMeta: v1.ObjectMeta{UID: "test-pod-uid"}}, []*resourceMetrics{{}}))
+	require.Equal(t, 2, len(ms.metricsCache))
+	require.NotNil(t, ms.metricsCache["pod/test-namespace/test-pod"])
+
+	require.NoError(t, ms.remove(newPodWithoutUID()))
+	require.Equal(t, 1, len(ms.metricsCache))
+	require.Nil(t, ms.metricsCache["pod/test-namespace/test-pod"])
+}
+
+func TestMetricsStoreSkipUnchanged(t *testing.T) {
+	ms := NewDataCollector(zap.NewNop(), nil, WithSkipUnchanged(true)).metricsStore
+
+	newRMs := func(value int64) []*resourceMetrics {
+		return []*resourceMetrics{{
+			metrics: []*metricspb.Metric{{
+				MetricDescriptor: &metricspb.MetricDescriptor{Name: "test.metric"},
+				Timeseries:       []*metricspb.TimeSeries{utils.GetInt64TimeSeries(value)},
+			}},
+		}}
+	}
+	unchanged := &corev1.Pod{ObjectMeta: v1.ObjectMeta{UID: "unchanged"}}
+	changed := &corev1.Pod{ObjectMeta: v1.ObjectMeta{UID: "changed"}}
+
+	require.NoError(t, ms.update(unchanged, newRMs(1)))
+	require.NoError(t, ms.update(changed, newRMs(1)))
+	require.Equal(t, 2, len(ms.getMetricData(time.Now())))
+
+	// Nothing is collected if no object has changed, even if updated.
+	require.NoError(t, ms.update(unchanged, newRMs(1)))
+	require.Equal(t, 0, len(ms.getMetricData(time.Now())))
+
+	require.NoError(t, ms.update(changed, newRMs(2)))
+	mds := ms.getMetricData(time.Now())
+	require.Equal(t, 1, len(mds))
+	require.Equal(t, int64(2), mds[0].Metrics[0].Timeseries[0].Points[0].GetInt64Value())
+	require.Equal(t, 0, len(ms.getMetricData(time.Now())))
+
+	// Objects that are deleted and recreated are collected again.
+	require.NoError(t, ms.remove(unchanged))
+	require.NoError(t, ms.update(unchanged, newRMs(1)))
+	require.Equal(t, 1, len(ms.getMetricData(time.Now())))
+}
+
+func TestMetricsStoreFullResync(t *testing.T) {
+	ms := NewDataCollector(zap.NewNop(), nil,
+		WithSkipUnchanged(true), WithFullResyncInterval(10*time.Minute)).metricsStore
+
+	rms := []*resourceMetrics{{
+		metrics: []*metricspb.Metric{{
+			MetricDescriptor: &metricspb.MetricDescriptor{Name: "test.metric"},
+			Timeseries:       []*metricspb.TimeSeries{utils.GetInt64TimeSeries(1)},
+		}},
+	}}
+	require.NoError(t, ms.update(&corev1.Pod{ObjectMeta: v1.ObjectMeta{UID: "unchanged"}}, rms))
+
+	now := time.Now()
+	require.Equal(t, 1, len(ms.getMetricData(now)))
+	require.Equal(t, 0, len(ms.getMetricData(now.Add(5*time.Minute))))
+
+	// Unchanged objects are collected again once per interval.
+	require.Equal(t, 1, len(ms.getMetricData(now.Add(10*time.Minute))))
+	require.Equal(t, 0, len(ms.getMetricData(now.Add(15*time.Minute))))
+	require.Equal(t, 1, len(ms.getMetricData(now.Add(21*time.Minute))))
+}
+
+func TestMetricsStoreGetMetricDataForObjects(t *testing.T) {
+	ms := NewDataCollector(zap.NewNop(), nil, WithSkipUnchanged(true)).metricsStore
+
+	newRMs := func(value int64) []*resourceMetrics {
+		return []*resourceMetrics{{
+			metrics: []*metricspb.Metric{{
+				MetricDescriptor: &metricspb.MetricDescriptor{Name: "test.metric"},
+				Timeseries:       []*metricspb.TimeSeries{utils.GetInt64TimeSeries(value)},
+			}},
+		}}
+	}
+	pod := &corev1.Pod{ObjectMeta: v1.ObjectMeta{UID: "pod"}}
+	other := &corev1.Pod{ObjectMeta: v1.ObjectMeta{UID: "other"}}
+	removed := &corev1.Pod{ObjectMeta: v1.ObjectMeta{UID: "removed"}}
+
+	require.NoError(t, ms.update(pod, newRMs(1)))
+	require.NoError(t, ms.update(other, newRMs(1)))
+	require.Equal(t, 1, len(ms.getMetricDataForObjects([]runtime.Object{pod, removed}, time.Now())))
+
+	// Objects collected since their last change are skipped, whichever way
+	// they were collected.
+	require.Equal(t, 0, len(ms.getMetricDataForObjects([]runtime.Object{pod}, time.Now())))
+	require.Equal(t, 1, len(ms.getMetricData(time.Now())))
+	require.Equal(t, 0, len(ms.getMetricDataForObjects([]runtime.Object{other}, time.Now())))
+
+	require.NoError(t, ms.update(pod, newRMs(2)))
+	mds := ms.getMetricDataForObjects([]runtime.Object{pod, other}, time.Now())
+	require.Equal(t, 1, len(mds))
+	require.Equal(t, int64(2), mds[0].Metrics[0].Timeseries[0].Points[0].GetInt64Value())
+}
+
+func TestMetricsStoreRejectsMalformedMetrics(t *testing.T) {
+	valid := &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{Name: "valid"},
+		Timeseries:       []*metricspb.TimeSeries{utils.GetInt64TimeSeries(1)},
+	}
+	malformed := []*metricspb.Metric{
+		nil,
+		{Timeseries: []*metricspb.TimeSeries{utils.GetInt64TimeSeries(1)}},
+		{MetricDescriptor: &metricspb.MetricDescriptor{Name: "no_timeseries"}},
+		{
+			MetricDescriptor: &metricspb.MetricDescriptor{Name: "nil_timeseries"},
+			Timeseries:       []*metricspb.TimeSeries{utils.GetInt64TimeSeries(1), nil},
+		},
+		{
+			MetricDescriptor: &metricspb.MetricDescriptor{Name: "no_points"},
+			Timeseries:       []*metricspb.TimeSeries{{}},
+		},
+		{
+			MetricDescriptor: &metricspb.MetricDescriptor{Name: "nil_point"},
+			Timeseries:       []*metricspb.TimeSeries{{Points: []*metricspb.Point{nil}}},
+		},
+	}
+
+	dc := NewDataCollector(zap.NewNop(), nil)
+	pod := &corev1.Pod{ObjectMeta: v1.ObjectMeta{UID: "test-pod-uid"}}
+	rms := []*resourceMetrics{
+		{resource: getResourceForCluster(), metrics: append([]*metricspb.Metric{valid}, malformed...)},
+		nil,
+	}
+
+	err := dc.metricsStore.update(pod, rms)
+	require.Error(t, err)
+	require.Equal(t, []string{"", "", "no_timeseries", "nil_timeseries", "no_points", "nil_point"},
+		err.(*malformedMetricsError).names)
+
+	// The valid metric is cached and reading it does not panic.
+	var mds []consumerdata.MetricsData
+	require.NotPanics(t, func() {
+		mds = dc.CollectMetricData(time.Now())
+	})
+	// Along with the age of the oldest cached object.
+	require.Equal(t, 2, len(mds))
+	require.Equal(t, 1, len(mds[0].Metrics))
+	require.Equal(t, "valid", mds[0].Metrics[0].MetricDescriptor.Name)
+
+	// Objects whose metrics are all malformed are cached without metrics.
+	dc.UpdateMetricsStore(pod, []*resourceMetrics{{resource: getResourceForCluster(), metrics: malformed}})
+	require.NotPanics(t, func() {
+		mds = dc.CollectMetricData(time.Now())
+	})
+	require.Equal(t, 2, len(mds))
+	require.Empty(t, mds[0].Metrics)
+}
+
+func TestMetricsStoreMaxObjects(t *testing.T) {
+	dc := NewDataCollector(zap.NewNop(), nil, WithMaxObjects(map[string]int{"pod": 2}))
+	ms := dc.metricsStore
+
+	for i := 0; i < 3; i++ {
+		uid := types.UID(fmt.Sprintf("pod-%d", i))
+		dc.UpdateMetricsStore(&corev1.Pod{ObjectMeta: v1.ObjectMeta{UID: uid}}, []*resourceMetrics{{}})
+		nodeUID := types.UID(fmt.Sprintf("node-%d", i))
+		dc.UpdateMetricsStore(&corev1.Node{ObjectMeta: v1.ObjectMeta{UID: nodeUID}}, []*resourceMetrics{{}})
+	}
+
+	// Caching of pods stops at the limit while nodes are unaffected.
+	require.Equal(t, 5, len(ms.metricsCache))
+	require.NotNil(t, ms.metricsCache["pod-0"])
+	require.NotNil(t, ms.metricsCache["pod-1"])
+	require.Nil(t, ms.metricsCache["pod-2"])
+	for i := 0; i < 3; i++ {
+		require.NotNil(t, ms.metricsCache[types.UID(fmt.Sprintf("node-%d", i))])
+	}
+
+	// Objects already cached are still updated once the limit is reached.
+	require.NoError(t, ms.update(&corev1.Pod{ObjectMeta: v1.ObjectMeta{UID: "pod-1"}}, []*resourceMetrics{{}, {}}))
+	require.Equal(t, 2, len(ms.metricsCache["pod-1"]))
+
+	// Removing a cached object frees up room for a new one.
+	require.NoError(t, ms.remove(&corev1.Pod{ObjectMeta: v1.ObjectMeta{UID: "pod-0"}}))
+	require.NoError(t, ms.update(&corev1.Pod{ObjectMeta: v1.ObjectMeta{UID: "pod-2"}}, []*resourceMetrics{{}}))
+	require.Equal(t, errObjectLimitReached,
+		ms.update(&corev1.Pod{ObjectMeta: v1.ObjectMeta{UID: "pod-3"}}, []*resourceMetrics{{}}))
+}
+
+func TestMetricsStoreDropZeroValues(t *testing.T) {
+	dc := NewDataCollector(zap.NewNop(), nil, WithDropZeroValues([]string{"k8s.container.restarts"}))
+	ms := dc.metricsStore
+
+	newMetric := func(name string, val int64) *metricspb.Metric {
+		return &metricspb.Metric{
+			MetricDescriptor: &metricspb.MetricDescriptor{
+				Name: name,
+				Type: metricspb.MetricDescriptor_GAUGE_INT64,
+			},
+			Timeseries: []*metricspb.TimeSeries{utils.GetInt64TimeSeries(val)},
+		}
+	}
+
+	require.NoError(t, ms.update(&corev1.Pod{ObjectMeta: v1.ObjectMeta{UID: "pod-1"}}, []*resourceMetrics{
+		{
+			metrics: []*metricspb.Metric{
+				newMetric("k8s.container.restarts", 0),
+				newMetric("k8s.container.ready", 0),
+			},
+		},
+		{
+			metrics: []*metricspb.Metric{
+				newMetric("k8s.container.restarts", 3),
+				newMetric("k8s.container.ready", 1),
+			},
+		},
+	}))
+
+	values := map[string][]int64{}
+	for _, md := range ms.getMetricData(time.Now()) {
+		for _, m := range md.Metrics {
+			values[m.MetricDescriptor.Name] = append(values[m.MetricDescriptor.Name],
+				m.Timeseries[0].Points[0].GetInt64Value())
+		}
+	}
+
+	require.ElementsMatch(t, []int64{3}, values["k8s.container.restarts"])
+	require.ElementsMatch(t, []int64{0, 1}, values["k8s.container.ready"])
+
+	// Cached metrics are left untouched.
+	require.Len(t, ms.metricsCache["pod-1"][0].Metrics, 2)
+}
+
+func TestMetricsStoreSnapshotsAreIndependent(t *testing.T) {
+	ms := metricsStore{
+		metricsCache: map[types.UID][]consumerdata.MetricsData{},
+	}
+	pod := newPodWithContainer("1", podSpecWithContainer("container-name"),
+		podStatusWithContainer("container-name", containerIDWithPreifx("container-id")))
+	require.NoError(t, ms.update(pod, getMetricsForPod(pod, nil, FieldExtractionRules{})))
+
+	t1 := time.Unix(1, 0)
+	t2 := time.Unix(2, 0)
+
+	// Snapshots as received by two consumers.
+	first := ms.getMetricData(t1)
+	second := ms.getMetricData(t2)
+	require.Equal(t, len(first), len(second))
+
+	for i := range first {
+		require.Equal(t, first[i].Resource, second[i].Resource)
+		require.Equal(t, len(first[i].Metrics), len(second[i].Metrics))
+		for j := range first[i].Metrics {
+			require.Equal(t, first[i].Metrics[j].MetricDescriptor, second[i].Metrics[j].MetricDescriptor)
+			for k, ts := range first[i].Metrics[j].Timeseries {
+				other := second[i].Metrics[j].Timeseries[k]
+				require.Equal(t, ts.Points[0].Value, other.Points[0].Value)
+				// Retrieving the second snapshot does not change the first.
+				require.Equal(t, t1.Unix(), ts.Points[0].Timestamp.Seconds)
+				require.Equal(t, t2.Unix(), other.Points[0].Timestamp.Seconds)
+			}
+		}
+	}
+
+	// A consumer modifying its snapshot affects neither the other snapshot
+	// nor the cache.
+	first[0].Resource.Labels["k8s.pod.name"] = "modified"
+	first[0].Metrics[0].Timeseries[0].Points[0].Value = &metricspb.Point_Int64Value{Int64Value: 42}
+
+	for _, snapshot := range [][]consumerdata.MetricsData{second, ms.getMetricData(t2)} {
+		for _, md := range snapshot {
+			require.Equal(t, "test-pod-1", md.Resource.Labels["k8s.pod.name"])
+			if md.Resource.Type == k8sType {
+				require.Equal(t, int64(3), md.Metrics[0].Timeseries[0].Points[0].GetInt64Value())
+			}
+		}
+	}
+}
+
+func TestMetricsStoreFlushThreshold(t *testing.T) {
+	ms := metricsStore{
+		metricsCache: map[types.UID][]consumerdata.MetricsData{},
+	}
+	metrics := func() []*metricspb.Metric {
+		out := make([]*metricspb.Metric, 3)
+		for i := range out {
+			out[i] = &metricspb.Metric{
+				MetricDescriptor: &metricspb.MetricDescriptor{Name: fmt.Sprint("metric-", i)},
+				Timeseries:       []*metricspb.TimeSeries{utils.GetInt64TimeSeries(1)},
+			}
+		}
+		return out
+	}
+	for i := 0; i < 5; i++ {
+		require.NoError(t, ms.update(&corev1.Pod{ObjectMeta: v1.ObjectMeta{UID: types.UID(fmt.Sprint("pod-", i))}},
+			[]*resourceMetrics{{metrics: metrics()}}))
+	}
+
+	flushes := func(threshold int) []int {
+		var sizes []int
+		ms.flushMetricData(time.Now(), threshold, nil, func(mds []consumerdata.MetricsData) {
+			numMetrics := 0
+			for _, md := range mds {
+				numMetrics += len(md.Metrics)
+			}
+			sizes = append(sizes, numMetrics)
+		})
+		return sizes
+	}
+
+	// Resources of 3 metrics are flushed as soon as 5 metrics are reached,
+	// i.e. every 2 resources, and the remaining one at the end.
+	require.Equal(t, []int{6, 6, 3}, flushes(5))
+	require.Equal(t, []int{3, 3, 3, 3, 3}, flushes(3))
+	// Without a threshold, all the metrics are flushed at once.
+	require.Equal(t, []int{15}, flushes(0))
+	require.Len(t, ms.getMetricData(time.Now()), 5)
+}
+
+func TestMetricsStoreFlushWithoutLock(t *testing.T) {
+	ms := metricsStore{
+		metricsCache: map[types.UID][]consumerdata.MetricsData{},
+	}
+	newPod := func(i int) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: v1.ObjectMeta{UID: types.UID(fmt.Sprint("pod-", i))}}
+	}
+	rms := func() []*resourceMetrics {
+		return []*resourceMetrics{{metrics: []*metricspb.Metric{{
+			MetricDescriptor: &metricspb.MetricDescriptor{Name: "metric"},
+			Timeseries:       []*metricspb.TimeSeries{utils.GetInt64TimeSeries(1)},
+		}}}}
+	}
+	for i := 0; i < 3; i++ {
+		require.NoError(t, ms.update(newPod(i), rms()))
+	}
+
+	// Objects can be updated and removed while a batch is flushed, rather than
+	// waiting for the flush to return. Objects removed in between are skipped.
+	flushes := 0
+	ms.flushMetricData(time.Now(), 1, nil, func(mds []consumerdata.MetricsData) {
+		flushes++
+		require.Len(t, mds, 1)
+		require.NoError(t, ms.update(newPod(3), rms()))
+		for i := 0; i < 3; i++ {
+			require.NoError(t, ms.remove(newPod(i)))
+		}
+	})
+	require.Equal(t, 1, flushes)
+	require.Len(t, ms.getMetricData(time.Now()), 1)
+}
+
+func TestMetricsStoreWarmup(t *testing.T) {
+	ms := metricsStore{
+		metricsCache:    map[types.UID][]consumerdata.MetricsData{},
+		warmupIntervals: 4,
+	}
+	for i := 0; i < 100; i++ {
+		require.NoError(t, ms.update(&corev1.Pod{ObjectMeta: v1.ObjectMeta{UID: types.UID(fmt.Sprint("pod-", i))}},
+			[]*resourceMetrics{{metrics: []*metricspb.Metric{{
+				MetricDescriptor: &metricspb.MetricDescriptor{Name: "metric"},
+				Timeseries:       []*metricspb.TimeSeries{utils.GetInt64TimeSeries(1)},
+			}}}}))
+	}
+
+	// The first collection only includes about a quarter of the objects, and
+	// each following one more of them, until all are included.
+	first := len(ms.getMetricData(time.Now()))
+	require.Greater(t, first, 0)
+	require.Less(t, first, 50)
+	second := len(ms.getMetricData(time.Now()))
+	require.Greater(t, second, first)
+	third := len(ms.getMetricData(time.Now()))
+	require.Greater(t, third, second)
+	require.Less(t, third, 100)
+	require.Len(t, ms.getMetricData(time.Now()), 100)
+	require.Len(t, ms.getMetricData(time.Now()), 100)
+}
+
+func TestMetricsStoreOldestCachedObjectAge(t *testing.T) {
+	h := newTestHarness(t, nil)
+	h.dc.metricsStore.now = func() time.Time { return h.now }
+	h.requireNoMetric("k8s.cluster.oldest_cached_object_age", nil)
+
+	pods := []*corev1.Pod{
+		newPodWithContainer("0", &corev1.PodSpec{}, &corev1.PodStatus{}),
+		newPodWithContainer("1", &corev1.PodSpec{}, &corev1.PodStatus{}),
+	}
+	h.seed(pods[0])
+	h.advance(time.Minute)
+	h.seed(pods[1])
+	h.advance(time.Minute)
+	h.requireInt64Value("k8s.cluster.oldest_cached_object_age", nil, 120)
+
+	// Updating the oldest object makes the other one the oldest.
+	pods[0].Status.Phase = corev1.PodRunning
+	h.seed(pods[0])
+	h.advance(3 * time.Minute)
+	h.requireInt64Value("k8s.cluster.oldest_cached_object_age", nil, 240)
+
+	h.remove(pods[1])
+	h.requireInt64Value("k8s.cluster.oldest_cached_object_age", nil, 180)
+}
+
+func TestMetricsStoreDeletionGracePeriod(t *testing.T) {
+	h := newTestHarness(t, nil, WithDeletionGracePeriod(time.Minute), WithMaxObjects(map[string]int{"pod": 1}))
+
+	pod := newPodWithContainer("0", &corev1.PodSpec{}, &corev1.PodStatus{Phase: corev1.PodRunning})
+	podRes := map[string]string{"k8s.pod.uid": string(pod.UID)}
+	h.seed(pod)
+	h.remove(pod)
+
+	// The last metrics are emitted until the grace period has elapsed.
+	h.requireInt64Value("k8s.pod.phase", podRes, 2)
+	h.advance(30 * time.Second)
+	h.requireInt64Value("k8s.pod.phase", podRes, 2)
+
+	h.advance(time.Minute)
+	h.requireNoMetric("k8s.pod.phase", podRes)
+	require.Empty(t, h.dc.metricsStore.metricsCache)
+
+	// The object no longer counts towards the object limit once evicted.
+	other := newPodWithContainer("1", &corev1.PodSpec{}, &corev1.PodStatus{})
+	h.seed(other)
+	h.requireMetric("k8s.pod.phase", map[string]string{"k8s.pod.uid": string(other.UID)})
+}
+
+func TestMetricsStoreDeletionMarkers(t *testing.T) {
+	for _, gracePeriod := range []time.Duration{0, time.Minute} {
+		t.Run(gracePeriod.String(), func(t *testing.T) {
+			h := newTestHarness(t, nil, WithDeletionMarkers(true), WithDeletionGracePeriod(gracePeriod))
+
+			pod := newPodWithContainer("0", podSpecWithContainer("container-name"),
+				podStatusWithContainer("container-name", containerIDWithPreifx("container-id")))
+			podRes := map[string]string{"k8s.pod.uid": string(pod.UID)}
+			h.seed(pod)
+			h.requireNoMetric("k8s.object.deleted", nil)
+
+			h.remove(pod)
+			if gracePeriod > 0 {
+				h.requireNoMetric("k8s.object.deleted", nil)
+				h.advance(gracePeriod + time.Second)
+			}
+
+			// The marker metric is emitted exactly once, for both the pod
+			// and its container.
+			markers := h.metrics("k8s.object.deleted", podRes)
+			require.Equal(t, 2, len(markers))
+			for _, m := range markers {
+				require.Equal(t, int64(1), m.metric.Timeseries[0].Points[0].GetInt64Value())
+			}
+			require.ElementsMatch(t, []string{k8sType, containerType},
+				[]string{markers[0].resource.Type, markers[1].resource.Type})
+			h.requireNoMetric("k8s.pod.phase", podRes)
+			h.requireNoMetric("k8s.object.deleted", nil)
+		})
+	}
+}
+
+func TestCollectPeriodicMetricData(t *testing.T) {
+	h := newTestHarness(t, nil, WithDeletionMarkers(true), WithDeletionGracePeriod(time.Minute))
+	pod := newPodWithContainer("0", podSpecWithContainer("container-name"),
+		podStatusWithContainer("container-name", containerIDWithPreifx("container-id")))
+	h.seed(pod)
+
+	hasMetric := func(mds []consumerdata.MetricsData, name string) bool {
+		for _, md := range mds {
+			for _, m := range md.Metrics {
+				if m.MetricDescriptor.Name == name {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	// The cached metrics of objects are left out, unlike metrics computed
+	// across objects.
+	mds := h.dc.CollectPeriodicMetricData(h.now)
+	require.False(t, hasMetric(mds, "k8s.pod.phase"))
+	require.True(t, hasMetric(mds, "k8s.cluster.pending_pods"))
+
+	// Deleted objects are evicted once their grace period has elapsed, and
+	// their deletion markers collected once.
+	h.remove(pod)
+	require.False(t, hasMetric(h.dc.CollectPeriodicMetricData(h.now), "k8s.object.deleted"))
+	h.advance(time.Minute + time.Second)
+	require.True(t, hasMetric(h.dc.CollectPeriodicMetricData(h.now), "k8s.object.deleted"))
+	require.Empty(t, h.dc.metricsStore.metricsCache)
+	require.False(t, hasMetric(h.dc.CollectPeriodicMetricData(h.now), "k8s.object.deleted"))
+}
+
+// BenchmarkMetricsStoreGetMetricData measures collecting the cached metrics
+// of pods with a single container, i.e. a pod and a container resource per
+// object. Copying cached metrics by hand rather than with proto.Clone, with
+// time series and points allocated at once per resource, reduced 10k objects
+// from about 1,020,000 to 180,000 allocs/op and from 270ms to 90ms per
+// collection.
+func BenchmarkMetricsStoreGetMetricData(b *testing.B) {
+	for _, numObjects := range []int{1000, 10000, 50000} {
+		b.Run(fmt.Sprintf("objects=%d", numObjects), func(b *testing.B) {
+			ms := &metricsStore{
+				metricsCache: map[types.UID][]consumerdata.MetricsData{},
+			}
+			for i := 0; i < numObjects; i++ {
+				pod := newPodWithContainer(fmt.Sprint(i), podSpecWithContainer("container-name"),
+					podStatusWithContainer("container-name", containerIDWithPreifx("container-id")))
+				require.NoError(b, ms.update(pod, getMetricsForPod(pod, nil, FieldExtractionRules{})))
+			}
+
+			now := time.Now()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				ms.getMetricData(now)
+			}
+		})
+	}
+}
@@ -23,14 +23,16 @@ import (
 	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
 )
 
+var namespacePhaseMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.namespace.phase",
+	Description: "The current phase of namespaces (1 for active and 0 for terminating)",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
 func getMetricsForNamespace(ns *corev1.Namespace) []*resourceMetrics {
 	metrics := []*metricspb.Metric{
 		{
-			MetricDescriptor: &metricspb.MetricDescriptor{
-				Name:        "k8s.namespace.phase",
-				Description: "The current phase of namespaces (1 for active and 0 for terminating)",
-				Type:        metricspb.MetricDescriptor_GAUGE_INT64,
-			},
+			MetricDescriptor: namespacePhaseMetric,
 			Timeseries: []*metricspb.TimeSeries{
 				utils.GetInt64TimeSeries(int64(namespacePhaseValues[ns.Status.Phase])),
 			},
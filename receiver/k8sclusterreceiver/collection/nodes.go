@@ -16,6 +16,9 @@ package collection
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
@@ -23,6 +26,7 @@ import (
 	"github.com/iancoleman/strcase"
 	"go.opentelemetry.io/collector/translator/conventions"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/common/testing/util"
 	metadataPkg "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/experimentalmetricmetadata"
@@ -34,8 +38,153 @@ const (
 	nodeCreationTime = "node.creation_timestamp"
 )
 
-func getMetricsForNode(node *corev1.Node, nodeConditionTypesToReport []string) []*resourceMetrics {
-	metrics := make([]*metricspb.Metric, len(nodeConditionTypesToReport))
+var nodeUnschedulableMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.node.unschedulable",
+	Description: "Whether the node is cordoned, i.e. unschedulable (1), or not (0)",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var nodeEvictingPodsMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.node.evicting_pods",
+	Description: "Number of pods terminating on the cordoned node, e.g. while it is being drained",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var nodeTaintCountMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.node.taint_count",
+	Description: "Number of taints on the node",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var nodeTaintInfoMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.node.taint.info",
+	Description: "Information about a taint on the node, always 1",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+	LabelKeys:   []*metricspb.LabelKey{{Key: "key"}, {Key: "value"}, {Key: "effect"}},
+})
+
+var nodeSpotMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.node.spot",
+	Description: "Whether the node is a spot or preemptible node (1) or an on-demand node (0)",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+	LabelKeys:   []*metricspb.LabelKey{{Key: "instance_type"}},
+})
+
+var nodeExtendedResourceCapacityMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.node.extended_resource.capacity",
+	Description: "Capacity of the node of an extended resource, e.g. nvidia.com/gpu",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+	LabelKeys:   []*metricspb.LabelKey{{Key: "resource"}},
+})
+
+var nodeExtendedResourceAllocatableMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.node.extended_resource.allocatable",
+	Description: "Amount of an extended resource, e.g. nvidia.com/gpu, of the node allocatable to pods",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+	LabelKeys:   []*metricspb.LabelKey{{Key: "resource"}},
+})
+
+var nodeCapacityMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.node.capacity",
+	Description: "Capacity of the node of a resource, in the unit set as unit",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+	LabelKeys:   []*metricspb.LabelKey{{Key: "resource"}, {Key: "unit"}},
+})
+
+var nodeAllocatableMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.node.allocatable",
+	Description: "Amount of a resource of the node allocatable to pods, in the unit set as unit",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+	LabelKeys:   []*metricspb.LabelKey{{Key: "resource"}, {Key: "unit"}},
+})
+
+var nodeReadyTransitionsMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name: "k8s.node.ready_transitions",
+	Description: "Number of transitions of the Ready condition of the node observed since the " +
+		"receiver started, e.g. while the node is flapping",
+	Unit: "1",
+	Type: metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+// readyTransitions counts the transitions of the Ready condition of nodes,
+// keyed by node UID, as observed from the updates of the nodes.
+type readyTransitions struct {
+	sync.Mutex
+	nodes map[types.UID]*nodeReadyState
+}
+
+type nodeReadyState struct {
+	// lastTransition is the last transition time of the Ready condition.
+	lastTransition time.Time
+	transitions    int64
+}
+
+// observe records the Ready condition of the node and returns the number of
+// transitions observed so far. Transitions are detected by changes of the
+// last transition time of the condition. The current state of a node when it
+// is first observed is not counted, since it may predate the receiver.
+func (rt *readyTransitions) observe(node *corev1.Node) int64 {
+	var lastTransition time.Time
+	for _, c := range node.Status.Conditions {
+		if c.Type == corev1.NodeReady {
+			lastTransition = c.LastTransitionTime.Time
+			break
+		}
+	}
+
+	rt.Lock()
+	defer rt.Unlock()
+	state, ok := rt.nodes[node.UID]
+	if !ok {
+		rt.nodes[node.UID] = &nodeReadyState{lastTransition: lastTransition}
+		return 0
+	}
+	if !lastTransition.IsZero() && !lastTransition.Equal(state.lastTransition) {
+		if !state.lastTransition.IsZero() {
+			state.transitions++
+		}
+		state.lastTransition = lastTransition
+	}
+	return state.transitions
+}
+
+// forget stops tracking the node with the given UID.
+func (rt *readyTransitions) forget(uid types.UID) {
+	rt.Lock()
+	defer rt.Unlock()
+	delete(rt.nodes, uid)
+}
+
+func getReadyTransitionsMetric(transitions int64) *metricspb.Metric {
+	return &metricspb.Metric{
+		MetricDescriptor: nodeReadyTransitionsMetric,
+		Timeseries: []*metricspb.TimeSeries{
+			utils.GetInt64TimeSeries(transitions),
+		},
+	}
+}
+
+// nodeTypeLabels are the node labels from which the type of a node is
+// derived.
+type nodeTypeLabels struct {
+	// spot maps the keys of labels identifying spot or preemptible nodes to
+	// the value of the label on such nodes, compared case-insensitively.
+	spot map[string]string
+	// instanceType is the key of the label holding the instance type.
+	instanceType string
+}
+
+func getMetricsForNode(node *corev1.Node, nodeConditionTypesToReport []string, typeLabels nodeTypeLabels) []*resourceMetrics {
+	metrics := make([]*metricspb.Metric, len(nodeConditionTypesToReport), len(nodeConditionTypesToReport)+4)
 
 	for i, nodeConditionTypeValue := range nodeConditionTypesToReport {
 		nodeConditionMetric := getNodeConditionMetric(nodeConditionTypeValue)
@@ -54,6 +203,22 @@ func getMetricsForNode(node *corev1.Node, nodeConditionTypesToReport []string) [
 		}
 	}
 
+	metrics = append(metrics, &metricspb.Metric{
+		MetricDescriptor: nodeUnschedulableMetric,
+		Timeseries: []*metricspb.TimeSeries{
+			utils.GetInt64TimeSeries(boolToInt64(node.Spec.Unschedulable)),
+		},
+	})
+	metrics = append(metrics, getTaintMetricsForNode(node)...)
+	metrics = append(metrics, &metricspb.Metric{
+		MetricDescriptor: nodeSpotMetric,
+		Timeseries: []*metricspb.TimeSeries{
+			utils.GetInt64TimeSeriesWithLabels(boolToInt64(isSpotNode(node, typeLabels.spot)),
+				[]*metricspb.LabelValue{{Value: node.Labels[typeLabels.instanceType], HasValue: true}}),
+		},
+	})
+	metrics = append(metrics, getExtendedResourceMetricsForNode(node)...)
+
 	return []*resourceMetrics{
 		{
 			resource: getResourceForNode(node),
@@ -62,6 +227,263 @@ func getMetricsForNode(node *corev1.Node, nodeConditionTypesToReport []string) [
 	}
 }
 
+// getEvictionMetricsForNodes returns the number of terminating pods of every
+// cordoned node. Nodes that are not cordoned are not reported since pods
+// terminating on them are not being evicted as part of a drain.
+func getEvictionMetricsForNodes(ms *metadataStore) []*resourceMetrics {
+	if ms.nodes == nil || ms.pods == nil {
+		return nil
+	}
+
+	evicting := map[string]int64{}
+	var cordoned []*corev1.Node
+	for _, obj := range ms.nodes.List() {
+		if node, ok := obj.(*corev1.Node); ok && node.Spec.Unschedulable {
+			cordoned = append(cordoned, node)
+			evicting[node.Name] = 0
+		}
+	}
+	if len(cordoned) == 0 {
+		return nil
+	}
+
+	for _, obj := range ms.pods.List() {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok || pod.DeletionTimestamp == nil {
+			continue
+		}
+		if _, ok := evicting[pod.Spec.NodeName]; ok {
+			evicting[pod.Spec.NodeName]++
+		}
+	}
+
+	out := make([]*resourceMetrics, 0, len(cordoned))
+	for _, node := range cordoned {
+		out = append(out, &resourceMetrics{
+			resource: getResourceForNode(node),
+			metrics: []*metricspb.Metric{
+				{
+					MetricDescriptor: nodeEvictingPodsMetric,
+					Timeseries: []*metricspb.TimeSeries{
+						utils.GetInt64TimeSeries(evicting[node.Name]),
+					},
+				},
+			},
+		})
+	}
+	return out
+}
+
+// getAllocationMetricsForNodes returns, for every node, the cpu and memory
+// requested by the pods scheduled on it along with its allocatable cpu and
+// memory, showing how much room is left for scheduling. Requests are the
+// effective requests of the pods, as computed by the scheduler. Terminal pods
+// are not included since they no longer hold their requests on the node.
+func getAllocationMetricsForNodes(ms *metadataStore, u units) []*resourceMetrics {
+	if ms.nodes == nil || ms.pods == nil {
+		return nil
+	}
+
+	requested := map[string]corev1.ResourceList{}
+	for _, obj := range ms.pods.List() {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok || pod.Spec.NodeName == "" || isPodTerminal(pod) {
+			continue
+		}
+		rl, ok := requested[pod.Spec.NodeName]
+		if !ok {
+			rl = corev1.ResourceList{}
+			requested[pod.Spec.NodeName] = rl
+		}
+		podRequests := getPodResourceList(pod, func(c corev1.Container) corev1.ResourceList {
+			return c.Resources.Requests
+		}, true)
+		for _, rn := range clusterCapacityResources {
+			if q, ok := podRequests[rn]; ok {
+				sum := rl[rn]
+				sum.Add(q)
+				rl[rn] = sum
+			}
+		}
+	}
+
+	nodes := ms.nodes.List()
+	out := make([]*resourceMetrics, 0, len(nodes))
+	for _, obj := range nodes {
+		node, ok := obj.(*corev1.Node)
+		if !ok {
+			continue
+		}
+		metrics := make([]*metricspb.Metric, 0, 2*len(clusterCapacityResources))
+		for _, rn := range clusterCapacityResources {
+			metrics = append(metrics,
+				u.getResourceMetric("k8s.node.allocated_"+string(rn)+"_requests",
+					"Total "+string(rn)+" requested by the pods scheduled on the node", rn, requested[node.Name][rn]),
+				u.getResourceMetric("k8s.node.allocatable_"+string(rn),
+					"Amount of "+string(rn)+" of the node allocatable to pods", rn, node.Status.Allocatable[rn]),
+			)
+		}
+		out = append(out, &resourceMetrics{
+			resource: getResourceForNode(node),
+			metrics:  metrics,
+		})
+	}
+	return out
+}
+
+// getExtendedResourceMetricsForNode returns the capacity and allocatable
+// amount of the extended resources of the node, e.g. GPUs advertised by device
+// plugins, with a timeseries per resource. Nothing is reported for nodes
+// without extended resources.
+func getExtendedResourceMetricsForNode(node *corev1.Node) []*metricspb.Metric {
+	var names []string
+	for rn := range node.Status.Capacity {
+		if isExtendedResourceName(rn) {
+			names = append(names, string(rn))
+		}
+	}
+	for rn := range node.Status.Allocatable {
+		if _, ok := node.Status.Capacity[rn]; !ok && isExtendedResourceName(rn) {
+			names = append(names, string(rn))
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+
+	capacity := make([]*metricspb.TimeSeries, 0, len(names))
+	allocatable := make([]*metricspb.TimeSeries, 0, len(names))
+	for _, name := range names {
+		rn := corev1.ResourceName(name)
+		labels := []*metricspb.LabelValue{{Value: name, HasValue: true}}
+		capacity = append(capacity, utils.GetInt64TimeSeriesWithLabels(getQuantityValue(rn, node.Status.Capacity[rn]), labels))
+		allocatable = append(allocatable, utils.GetInt64TimeSeriesWithLabels(getQuantityValue(rn, node.Status.Allocatable[rn]), labels))
+	}
+
+	return []*metricspb.Metric{
+		{
+			MetricDescriptor: nodeExtendedResourceCapacityMetric,
+			Timeseries:       capacity,
+		},
+		{
+			MetricDescriptor: nodeExtendedResourceAllocatableMetric,
+			Timeseries:       allocatable,
+		},
+	}
+}
+
+// getResourceMetricsForNode returns the capacity and allocatable amount of
+// every resource of the node, including extended resources and hugepages,
+// with a timeseries per resource. Quantities are reported in the default unit
+// of the resource, set as the unit datapoint attribute.
+func getResourceMetricsForNode(node *corev1.Node) []*metricspb.Metric {
+	names := make([]string, 0, len(node.Status.Capacity))
+	for rn := range node.Status.Capacity {
+		names = append(names, string(rn))
+	}
+	for rn := range node.Status.Allocatable {
+		if _, ok := node.Status.Capacity[rn]; !ok {
+			names = append(names, string(rn))
+		}
+	}
+	sort.Strings(names)
+
+	capacity := make([]*metricspb.TimeSeries, 0, len(names))
+	allocatable := make([]*metricspb.TimeSeries, 0, len(names))
+	for _, name := range names {
+		rn := corev1.ResourceName(name)
+		labels := []*metricspb.LabelValue{
+			{Value: name, HasValue: true},
+			{Value: nodeResourceUnit(rn), HasValue: true},
+		}
+		capacity = append(capacity, utils.GetInt64TimeSeriesWithLabels(getQuantityValue(rn, node.Status.Capacity[rn]), labels))
+		allocatable = append(allocatable, utils.GetInt64TimeSeriesWithLabels(getQuantityValue(rn, node.Status.Allocatable[rn]), labels))
+	}
+
+	return []*metricspb.Metric{
+		{
+			MetricDescriptor: nodeCapacityMetric,
+			Timeseries:       capacity,
+		},
+		{
+			MetricDescriptor: nodeAllocatableMetric,
+			Timeseries:       allocatable,
+		},
+	}
+}
+
+// nodeResourceUnit returns the default unit of the quantities of a resource,
+// as reported by getQuantityValue.
+func nodeResourceUnit(name corev1.ResourceName) string {
+	switch {
+	case name == corev1.ResourceCPU:
+		return "millicores"
+	case name == corev1.ResourceMemory || name == corev1.ResourceEphemeralStorage ||
+		strings.HasPrefix(string(name), corev1.ResourceHugePagesPrefix):
+		return "bytes"
+	}
+	return "1"
+}
+
+// isExtendedResourceName returns whether the resource is an extended
+// resource, i.e. a resource whose name is qualified with a domain other than
+// the kubernetes.io one of native resources, e.g. nvidia.com/gpu.
+func isExtendedResourceName(name corev1.ResourceName) bool {
+	n := string(name)
+	if strings.HasPrefix(n, corev1.DefaultResourceRequestsPrefix) {
+		return false
+	}
+	i := strings.Index(n, "/")
+	if i < 0 {
+		return false
+	}
+	domain := n[:i]
+	return domain != "kubernetes.io" && !strings.HasSuffix(domain, ".kubernetes.io")
+}
+
+// getTaintMetricsForNode returns the number of taints on the node and, if
+// there are any, one info timeseries per taint.
+func getTaintMetricsForNode(node *corev1.Node) []*metricspb.Metric {
+	taints := node.Spec.Taints
+	metrics := []*metricspb.Metric{
+		{
+			MetricDescriptor: nodeTaintCountMetric,
+			Timeseries: []*metricspb.TimeSeries{
+				utils.GetInt64TimeSeries(int64(len(taints))),
+			},
+		},
+	}
+	if len(taints) == 0 {
+		return metrics
+	}
+
+	infoSeries := make([]*metricspb.TimeSeries, 0, len(taints))
+	for _, taint := range taints {
+		infoSeries = append(infoSeries, utils.GetInt64TimeSeriesWithLabels(1, []*metricspb.LabelValue{
+			{Value: taint.Key, HasValue: true},
+			{Value: taint.Value, HasValue: true},
+			{Value: string(taint.Effect), HasValue: true},
+		}))
+	}
+
+	return append(metrics, &metricspb.Metric{
+		MetricDescriptor: nodeTaintInfoMetric,
+		Timeseries:       infoSeries,
+	})
+}
+
+// isSpotNode returns true if the node has any of the given spot labels with
+// the corresponding value.
+func isSpotNode(node *corev1.Node, spotLabels map[string]string) bool {
+	for key, value := range spotLabels {
+		if v, ok := node.Labels[key]; ok && strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
 func getNodeConditionMetric(nodeConditionTypeValue string) string {
 	return fmt.Sprintf("k8s.node.condition_%s", strcase.ToSnake(nodeConditionTypeValue))
 }
@@ -16,12 +16,15 @@ package collection
 
 import (
 	"testing"
+	"time"
 
 	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/testutils"
 )
@@ -29,11 +32,11 @@ import (
 func TestNodeMetrics(t *testing.T) {
 	n := newNode("1")
 
-	actualResourceMetrics := getMetricsForNode(n, []string{"Ready", "MemoryPressure"})
+	actualResourceMetrics := getMetricsForNode(n, []string{"Ready", "MemoryPressure"}, nodeTypeLabels{})
 
 	require.Equal(t, 1, len(actualResourceMetrics))
 
-	require.Equal(t, 2, len(actualResourceMetrics[0].metrics))
+	require.Equal(t, 5, len(actualResourceMetrics[0].metrics))
 	testutils.AssertResource(t, actualResourceMetrics[0].resource, k8sType,
 		map[string]string{
 			"k8s.node.uid":     "test-node-1-uid",
@@ -47,6 +50,299 @@ func TestNodeMetrics(t *testing.T) {
 
 	testutils.AssertMetrics(t, actualResourceMetrics[0].metrics[1], "k8s.node.condition_memory_pressure",
 		metricspb.MetricDescriptor_GAUGE_INT64, 0)
+
+	testutils.AssertMetrics(t, actualResourceMetrics[0].metrics[2], "k8s.node.unschedulable",
+		metricspb.MetricDescriptor_GAUGE_INT64, 0)
+
+	testutils.AssertMetrics(t, actualResourceMetrics[0].metrics[3], "k8s.node.taint_count",
+		metricspb.MetricDescriptor_GAUGE_INT64, 0)
+
+	testutils.AssertMetrics(t, actualResourceMetrics[0].metrics[4], "k8s.node.spot",
+		metricspb.MetricDescriptor_GAUGE_INT64, 0)
+}
+
+func TestNodeSpotMetric(t *testing.T) {
+	typeLabels := nodeTypeLabels{
+		spot: map[string]string{
+			"cloud.google.com/gke-spot":      "true",
+			"eks.amazonaws.com/capacityType": "SPOT",
+		},
+		instanceType: "node.kubernetes.io/instance-type",
+	}
+
+	spot := newNode("1")
+	spot.Labels["eks.amazonaws.com/capacityType"] = "spot"
+	spot.Labels["node.kubernetes.io/instance-type"] = "m5.large"
+	onDemand := newNode("2")
+	onDemand.Labels["eks.amazonaws.com/capacityType"] = "ON_DEMAND"
+	onDemand.Labels["node.kubernetes.io/instance-type"] = "m5.xlarge"
+
+	for _, tt := range []struct {
+		node         *corev1.Node
+		expected     int64
+		instanceType string
+	}{
+		{node: spot, expected: 1, instanceType: "m5.large"},
+		{node: onDemand, expected: 0, instanceType: "m5.xlarge"},
+	} {
+		metrics := getMetricsForNode(tt.node, nil, typeLabels)[0].metrics
+		m := metrics[len(metrics)-1]
+		testutils.AssertMetrics(t, m, "k8s.node.spot", metricspb.MetricDescriptor_GAUGE_INT64, tt.expected)
+		require.Equal(t, []*metricspb.LabelKey{{Key: "instance_type"}}, m.MetricDescriptor.LabelKeys)
+		require.Equal(t, tt.instanceType, m.Timeseries[0].LabelValues[0].Value, tt.node.Name)
+	}
+}
+
+func TestNodeTaintMetrics(t *testing.T) {
+	n := newNode("1")
+	n.Spec.Taints = []corev1.Taint{
+		{
+			Key:    "dedicated",
+			Value:  "gpu",
+			Effect: corev1.TaintEffectNoSchedule,
+		},
+		{
+			Key:    "node.kubernetes.io/unreachable",
+			Effect: corev1.TaintEffectNoExecute,
+		},
+	}
+
+	actualResourceMetrics := getMetricsForNode(n, []string{"Ready"}, nodeTypeLabels{})
+
+	require.Equal(t, 1, len(actualResourceMetrics))
+	require.Equal(t, 5, len(actualResourceMetrics[0].metrics))
+
+	testutils.AssertMetrics(t, actualResourceMetrics[0].metrics[2], "k8s.node.taint_count",
+		metricspb.MetricDescriptor_GAUGE_INT64, 2)
+
+	info := actualResourceMetrics[0].metrics[3]
+	require.Equal(t, "k8s.node.taint.info", info.MetricDescriptor.Name)
+	require.Equal(t, []*metricspb.LabelKey{{Key: "key"}, {Key: "value"}, {Key: "effect"}},
+		info.MetricDescriptor.LabelKeys)
+	require.Equal(t, 2, len(info.Timeseries))
+
+	expected := [][]string{
+		{"dedicated", "gpu", "NoSchedule"},
+		{"node.kubernetes.io/unreachable", "", "NoExecute"},
+	}
+	for i, ts := range info.Timeseries {
+		require.Equal(t, int64(1), ts.Points[0].GetInt64Value())
+		values := make([]string, 0, len(ts.LabelValues))
+		for _, lv := range ts.LabelValues {
+			require.True(t, lv.HasValue)
+			values = append(values, lv.Value)
+		}
+		require.Equal(t, expected[i], values)
+	}
+}
+
+func TestNodeExtendedResourceMetrics(t *testing.T) {
+	n := newNode("1")
+	n.Status.Capacity = corev1.ResourceList{
+		corev1.ResourceCPU:              resource.MustParse("8"),
+		corev1.ResourceMemory:           resource.MustParse("32Gi"),
+		corev1.ResourcePods:             resource.MustParse("110"),
+		"hugepages-2Mi":                 resource.MustParse("0"),
+		"nvidia.com/gpu":                resource.MustParse("4"),
+		"example.com/fpga":              resource.MustParse("1"),
+		"kubernetes.io/native-resource": resource.MustParse("1"),
+	}
+	n.Status.Allocatable = corev1.ResourceList{
+		corev1.ResourceCPU: resource.MustParse("7500m"),
+		"nvidia.com/gpu":   resource.MustParse("3"),
+	}
+
+	rms := getMetricsForNode(n, nil, nodeTypeLabels{})
+	require.Equal(t, 1, len(rms))
+	metrics := rms[0].metrics
+	require.Equal(t, 5, len(metrics))
+
+	expected := map[string]map[string]int64{
+		"k8s.node.extended_resource.capacity":    {"example.com/fpga": 1, "nvidia.com/gpu": 4},
+		"k8s.node.extended_resource.allocatable": {"example.com/fpga": 0, "nvidia.com/gpu": 3},
+	}
+	for _, m := range metrics[3:] {
+		require.Equal(t, []*metricspb.LabelKey{{Key: "resource"}}, m.MetricDescriptor.LabelKeys)
+		values := map[string]int64{}
+		for _, ts := range m.Timeseries {
+			values[ts.LabelValues[0].Value] = ts.Points[0].GetInt64Value()
+		}
+		require.Equal(t, expected[m.MetricDescriptor.Name], values, m.MetricDescriptor.Name)
+	}
+
+	// Nodes without extended resources do not have the metrics.
+	require.Equal(t, 3, len(getMetricsForNode(newNode("2"), nil, nodeTypeLabels{})[0].metrics))
+}
+
+func TestNodeResourceMetrics(t *testing.T) {
+	n := newNode("1")
+	n.Status.Capacity = corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("8"),
+		corev1.ResourceMemory: resource.MustParse("32Gi"),
+		corev1.ResourcePods:   resource.MustParse("110"),
+		"hugepages-2Mi":       resource.MustParse("1Gi"),
+		"nvidia.com/gpu":      resource.MustParse("4"),
+	}
+	n.Status.Allocatable = corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("7500m"),
+		corev1.ResourceMemory: resource.MustParse("30Gi"),
+		corev1.ResourcePods:   resource.MustParse("110"),
+		"hugepages-2Mi":       resource.MustParse("512Mi"),
+		"nvidia.com/gpu":      resource.MustParse("3"),
+	}
+
+	metrics := getResourceMetricsForNode(n)
+	require.Equal(t, 2, len(metrics))
+
+	expected := map[string]map[string]int64{
+		"k8s.node.capacity": {
+			"cpu/millicores": 8000, "hugepages-2Mi/bytes": 1 << 30, "memory/bytes": 32 << 30,
+			"nvidia.com/gpu/1": 4, "pods/1": 110,
+		},
+		"k8s.node.allocatable": {
+			"cpu/millicores": 7500, "hugepages-2Mi/bytes": 512 << 20, "memory/bytes": 30 << 30,
+			"nvidia.com/gpu/1": 3, "pods/1": 110,
+		},
+	}
+	for _, m := range metrics {
+		require.Equal(t, []*metricspb.LabelKey{{Key: "resource"}, {Key: "unit"}}, m.MetricDescriptor.LabelKeys)
+		values := map[string]int64{}
+		for _, ts := range m.Timeseries {
+			values[ts.LabelValues[0].Value+"/"+ts.LabelValues[1].Value] = ts.Points[0].GetInt64Value()
+		}
+		require.Equal(t, expected[m.MetricDescriptor.Name], values, m.MetricDescriptor.Name)
+	}
+
+	// The metrics are only reported when enabled.
+	h := newTestHarness(t, nil)
+	h.seed(n)
+	h.requireNoMetric("k8s.node.allocatable", nil)
+	h = newTestHarness(t, nil, WithNodeResources(true))
+	h.seed(n)
+	h.requireMetric("k8s.node.allocatable", nil)
+}
+
+func TestNodeEvictionMetrics(t *testing.T) {
+	cordoned := newNode("1")
+	cordoned.Spec.Unschedulable = true
+	schedulable := newNode("2")
+
+	testutils.AssertMetrics(t, getMetricsForNode(cordoned, nil, nodeTypeLabels{})[0].metrics[0], "k8s.node.unschedulable",
+		metricspb.MetricDescriptor_GAUGE_INT64, 1)
+	testutils.AssertMetrics(t, getMetricsForNode(schedulable, nil, nodeTypeLabels{})[0].metrics[0], "k8s.node.unschedulable",
+		metricspb.MetricDescriptor_GAUGE_INT64, 0)
+
+	newPodOnNode := func(name, nodeName string, terminating bool) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "test-namespace"},
+			Spec:       corev1.PodSpec{NodeName: nodeName},
+		}
+		if terminating {
+			deletionTimestamp := v1.Now()
+			pod.DeletionTimestamp = &deletionTimestamp
+		}
+		return pod
+	}
+
+	ms := &metadataStore{
+		nodes: cache.NewStore(cache.MetaNamespaceKeyFunc),
+		pods:  cache.NewStore(cache.MetaNamespaceKeyFunc),
+	}
+	require.NoError(t, ms.nodes.Add(cordoned))
+	require.NoError(t, ms.nodes.Add(schedulable))
+	for _, pod := range []*corev1.Pod{
+		newPodOnNode("evicted-1", cordoned.Name, true),
+		newPodOnNode("evicted-2", cordoned.Name, true),
+		newPodOnNode("running", cordoned.Name, false),
+		newPodOnNode("deleted", schedulable.Name, true),
+	} {
+		require.NoError(t, ms.pods.Add(pod))
+	}
+
+	rms := getEvictionMetricsForNodes(ms)
+	require.Equal(t, 1, len(rms))
+	require.Equal(t, "test-node-1", rms[0].resource.Labels["k8s.node.name"])
+	require.Equal(t, 1, len(rms[0].metrics))
+	testutils.AssertMetrics(t, rms[0].metrics[0], "k8s.node.evicting_pods",
+		metricspb.MetricDescriptor_GAUGE_INT64, 2)
+
+	// No node is being drained.
+	cordoned.Spec.Unschedulable = false
+	require.Nil(t, getEvictionMetricsForNodes(ms))
+}
+
+func TestNodeAllocationMetrics(t *testing.T) {
+	newPodOnNode := func(name, nodeName, cpu string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "test-namespace", UID: types.UID(name + "-uid")},
+			Spec: corev1.PodSpec{
+				NodeName: nodeName,
+				Containers: []corev1.Container{{
+					Name: "container",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse(cpu),
+							corev1.ResourceMemory: resource.MustParse("256Mi"),
+						},
+					},
+				}},
+			},
+			Status: corev1.PodStatus{Phase: phase},
+		}
+	}
+
+	h := newTestHarness(t, nil)
+	h.seed(
+		newNodeWithResources("node-1", corev1.ConditionTrue, "2", "1Gi"),
+		newNodeWithResources("node-2", corev1.ConditionTrue, "2", "1Gi"),
+		newPodOnNode("pod-1", "node-1", "250m", corev1.PodRunning),
+		newPodOnNode("pod-2", "node-1", "100m", corev1.PodPending),
+		// Terminal and unscheduled pods do not hold their requests.
+		newPodOnNode("succeeded", "node-1", "1", corev1.PodSucceeded),
+		newPodOnNode("unscheduled", "", "1", corev1.PodPending),
+	)
+
+	node1 := map[string]string{"k8s.node.name": "node-1"}
+	h.requireInt64Value("k8s.node.allocated_cpu_requests", node1, 350)
+	h.requireInt64Value("k8s.node.allocated_memory_requests", node1, 512*1024*1024)
+	h.requireInt64Value("k8s.node.allocatable_cpu", node1, 500)
+	h.requireInt64Value("k8s.node.allocatable_memory", node1, 1024*1024*1024)
+
+	node2 := map[string]string{"k8s.node.name": "node-2"}
+	h.requireInt64Value("k8s.node.allocated_cpu_requests", node2, 0)
+	h.requireInt64Value("k8s.node.allocated_memory_requests", node2, 0)
+}
+
+func TestNodeReadyTransitionsMetric(t *testing.T) {
+	h := newTestHarness(t, nil)
+	node := newNode("1")
+	labels := map[string]string{"k8s.node.name": node.Name}
+	start := time.Now().Add(-time.Hour)
+	setReady := func(status corev1.ConditionStatus, transitionedAt time.Time) {
+		node = node.DeepCopy()
+		node.Status.Conditions[0].Status = status
+		node.Status.Conditions[0].LastTransitionTime = v1.NewTime(transitionedAt)
+		h.seed(node)
+	}
+
+	// The state of the node when first observed is not a transition.
+	setReady(corev1.ConditionTrue, start)
+	h.requireInt64Value("k8s.node.ready_transitions", labels, 0)
+
+	// Updates without a transition, e.g. heartbeats, are not counted.
+	setReady(corev1.ConditionTrue, start)
+	h.requireInt64Value("k8s.node.ready_transitions", labels, 0)
+
+	// Toggling Ready twice.
+	setReady(corev1.ConditionFalse, start.Add(time.Minute))
+	h.requireInt64Value("k8s.node.ready_transitions", labels, 1)
+	setReady(corev1.ConditionTrue, start.Add(2*time.Minute))
+	h.requireInt64Value("k8s.node.ready_transitions", labels, 2)
+
+	// Counting starts over for a node that is removed and added again.
+	h.remove(node)
+	setReady(corev1.ConditionTrue, start.Add(3*time.Minute))
+	h.requireInt64Value("k8s.node.ready_transitions", labels, 0)
 }
 
 func newNode(id string) *corev1.Node {
@@ -0,0 +1,93 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"sort"
+	"sync"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
+)
+
+var clusterObjectCountDeltaMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name: "k8s.cluster.object_count_delta",
+	Description: "Change of the number of objects of a kind since the previous collection, " +
+		"e.g. spiking on mass creation or deletion",
+	Unit:      "1",
+	Type:      metricspb.MetricDescriptor_GAUGE_INT64,
+	LabelKeys: []*metricspb.LabelKey{{Key: "kind"}},
+})
+
+// objectCounts tracks the number of objects of each watched kind between
+// collections.
+type objectCounts struct {
+	sync.Mutex
+	// stores are the informer stores of the watched kinds, keyed by kind.
+	stores map[string]cache.Store
+	// last are the numbers of objects at the previous collection.
+	last map[string]int64
+}
+
+func (oc *objectCounts) setupStore(kind string, store cache.Store) {
+	oc.Lock()
+	defer oc.Unlock()
+	oc.stores[kind] = store
+}
+
+// getObjectCountDeltaMetricsForCluster returns the change of the number of
+// objects of every watched kind since the previous call, and remembers the
+// current numbers for the next one. Kinds are only reported from the second
+// call on, since there is nothing to compare their first count to.
+func getObjectCountDeltaMetricsForCluster(oc *objectCounts) []*resourceMetrics {
+	oc.Lock()
+	defer oc.Unlock()
+
+	kinds := make([]string, 0, len(oc.stores))
+	for kind := range oc.stores {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	var series []*metricspb.TimeSeries
+	for _, kind := range kinds {
+		count := int64(len(oc.stores[kind].ListKeys()))
+		last, ok := oc.last[kind]
+		oc.last[kind] = count
+		if !ok {
+			continue
+		}
+		series = append(series, utils.GetInt64TimeSeriesWithLabels(
+			count-last, []*metricspb.LabelValue{{Value: kind, HasValue: true}},
+		))
+	}
+	if len(series) == 0 {
+		return nil
+	}
+
+	return []*resourceMetrics{
+		{
+			resource: getResourceForCluster(),
+			metrics: []*metricspb.Metric{
+				{
+					MetricDescriptor: clusterObjectCountDeltaMetric,
+					Timeseries:       series,
+				},
+			},
+		},
+	}
+}
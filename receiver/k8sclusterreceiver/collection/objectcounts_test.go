@@ -0,0 +1,54 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestObjectCountDeltaMetric(t *testing.T) {
+	h := newTestHarness(t, nil, WithObjectCountDelta(true))
+	pods := []*corev1.Pod{
+		newPendingPod("pod-1", "node-1"),
+		newPendingPod("pod-2", "node-1"),
+		newPendingPod("pod-3", "node-1"),
+		newPendingPod("pod-4", "node-1"),
+	}
+	h.seed(pods[0], newNodeWithResources("node-1", corev1.ConditionTrue, "2", "1Gi"))
+
+	// Every collection, including those of the harness assertions, compares
+	// the counts to the previous one, of which there is none at first.
+	h.requireNoMetric("k8s.cluster.object_count_delta", nil)
+
+	deltas := func() map[string]int64 {
+		m := h.requireMetric("k8s.cluster.object_count_delta", nil)
+		out := map[string]int64{}
+		for _, ts := range m.Timeseries {
+			out[ts.LabelValues[0].Value] = ts.Points[0].GetInt64Value()
+		}
+		return out
+	}
+
+	h.seed(pods[1], pods[2], pods[3])
+	require.Equal(t, map[string]int64{"Node": 0, "Pod": 3}, deltas())
+
+	h.remove(pods[0], pods[1])
+	require.Equal(t, map[string]int64{"Node": 0, "Pod": -2}, deltas())
+
+	require.Equal(t, map[string]int64{"Node": 0, "Pod": 0}, deltas())
+}
@@ -0,0 +1,105 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	"go.opentelemetry.io/collector/translator/conventions"
+)
+
+const (
+	// k8sKeyObjectKind is the resource label key of the kind of the object,
+	// e.g. Pod, a resource was built for.
+	k8sKeyObjectKind = "k8s.object.kind"
+
+	k8sKindContainer                = "Container"
+	k8sKindCluster                  = "Cluster"
+	k8sKindCustomResourceDefinition = "CustomResourceDefinition"
+	k8sKindLease                    = "Lease"
+)
+
+// objectKindLabelKeys maps the resource label keys identifying the object
+// a resource was built for to the kind of the object. Resources of namespaced
+// objects also carry the name of their namespace, which is why keys are
+// checked in order, the namespace name last.
+var objectKindLabelKeys = []struct {
+	key  string
+	kind string
+}{
+	{conventions.AttributeK8sPodUID, k8sKindPod},
+	{k8sKeyNodeUID, k8sKindNode},
+	{conventions.AttributeK8sDeploymentUID, k8sKindDeployment},
+	{conventions.AttributeK8sReplicaSetUID, k8sKindReplicaSet},
+	{conventions.AttributeK8sDaemonSetUID, k8sKindDaemonSet},
+	{conventions.AttributeK8sStatefulSetUID, k8sStatefulSet},
+	{conventions.AttributeK8sJobUID, k8sKindJob},
+	{conventions.AttributeK8sCronJobUID, k8sKindCronJob},
+	{k8sKeyReplicationControllerUID, k8sKindReplicationController},
+	{k8sKeyHPAUID, k8sKindHPA},
+	{k8sKeyResourceQuotaUID, k8sKindResourceQuota},
+	{k8sKeyCRDUID, k8sKindCustomResourceDefinition},
+	{k8sKeyLeaseName, k8sKindLease},
+	{k8sKeyServiceUID, k8sKindService},
+	{k8sKeyIngressUID, k8sKindIngress},
+	{k8sKeyPodDisruptionBudgetUID, k8sKindPodDisruptionBudget},
+	{k8sKeyPriorityClassUID, k8sKindPriorityClass},
+	{k8sKeyPersistentVolumeUID, k8sKindPersistentVolume},
+	{k8sKeyPersistentVolumeClaimUID, k8sKindPersistentVolumeClaim},
+	{k8sKeyNamespaceUID, k8sKindNamespace},
+	// Namespaces not in the store are only known by name.
+	{conventions.AttributeK8sNamespace, k8sKindNamespace},
+}
+
+var webhookConfigurationKinds = map[string]string{
+	webhookConfigurationTypeMutating:   k8sKindMutatingWebhookConfiguration,
+	webhookConfigurationTypeValidating: k8sKindValidatingWebhookConfiguration,
+}
+
+// addObjectKindLabels sets the k8s.object.kind resource label of the given
+// resource metrics. It is expected to be called before resource labels are
+// renamed.
+func addObjectKindLabels(rms []*resourceMetrics) {
+	for _, rm := range rms {
+		if rm == nil || rm.resource == nil {
+			continue
+		}
+		if rm.resource.Labels == nil {
+			rm.resource.Labels = map[string]string{}
+		}
+		rm.resource.Labels[k8sKeyObjectKind] = getObjectKindOfResource(rm.resource)
+	}
+}
+
+// getObjectKindOfResource returns the kind of the object the resource was
+// built for. Resources without any object, i.e. those of metrics computed
+// across objects, are reported as the cluster.
+func getObjectKindOfResource(r *resourcepb.Resource) string {
+	if r.Type == containerType {
+		return k8sKindContainer
+	}
+	// Resources of metrics aggregated per workload carry its kind.
+	if kind, ok := r.Labels[k8sKeyWorkLoadKind]; ok {
+		return kind
+	}
+	if typ, ok := r.Labels[k8sKeyWebhookConfigurationType]; ok {
+		return webhookConfigurationKinds[typ]
+	}
+	for _, k := range objectKindLabelKeys {
+		if _, ok := r.Labels[k.key]; ok {
+			return k.kind
+		}
+	}
+	return k8sKindCluster
+}
@@ -0,0 +1,101 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumerdata"
+	"go.uber.org/zap"
+)
+
+func TestObjectKind(t *testing.T) {
+	kindsByMetric := func(mds []consumerdata.MetricsData) map[string]map[string]bool {
+		out := map[string]map[string]bool{}
+		for _, md := range mds {
+			for _, m := range md.Metrics {
+				name := m.MetricDescriptor.Name
+				if out[name] == nil {
+					out[name] = map[string]bool{}
+				}
+				out[name][md.Resource.Labels[k8sKeyObjectKind]] = true
+			}
+		}
+		return out
+	}
+
+	now := time.Now()
+	collect := func(opts ...Option) map[string]map[string]bool {
+		dc := NewDataCollector(zap.NewNop(), manifestNodeConditions, opts...)
+		seedManifestObjects(dc, manifestObjects(now))
+		return kindsByMetric(append(dc.CollectMetricData(now), dc.CollectSelfMetricData(now)...))
+	}
+
+	// Disabled by default.
+	for name, kinds := range collect() {
+		require.Equal(t, map[string]bool{"": true}, kinds, name)
+	}
+
+	kinds := collect(WithObjectKind(true))
+	for name, kind := range map[string]string{
+		"k8s.pod.phase":                         "Pod",
+		"k8s.container.restarts":                "Container",
+		"k8s.node.condition_ready":              "Node",
+		"k8s.node.allocated_cpu_requests":       "Node",
+		"k8s.namespace.phase":                   "Namespace",
+		"k8s.namespace.configmap_count":         "Namespace",
+		"k8s.deployment.desired":                "Deployment",
+		"k8s.deployment.rollout_stuck_duration": "Deployment",
+		"k8s.replicaset.desired":                "ReplicaSet",
+		"k8s.daemonset.ready_nodes":             "DaemonSet",
+		"k8s.daemonset.pod_not_ready":           "DaemonSet",
+		"k8s.statefulset.desired_pods":          "StatefulSet",
+		"k8s.job.active_pods":                   "Job",
+		"k8s.cronjob.active_jobs":               "CronJob",
+		"k8s.replication_controller.desired":    "ReplicationController",
+		"k8s.hpa.max_replicas":                  "HorizontalPodAutoscaler",
+		"k8s.resource_quota.hard_limit":         "ResourceQuota",
+		"k8s.lease.renew_age":                   "Lease",
+		"k8s.service.ready_endpoints":           "Service",
+		"k8s.crd.instance_count":                "CustomResourceDefinition",
+		"k8s.cluster.capacity_cpu":              "Cluster",
+		"k8s.rbac.role_count":                   "Cluster",
+		"k8s.cluster.last_collection_timestamp": "Cluster",
+	} {
+		require.Equal(t, map[string]bool{kind: true}, kinds[name], name)
+	}
+	require.Equal(t, map[string]bool{
+		"MutatingWebhookConfiguration":   true,
+		"ValidatingWebhookConfiguration": true,
+	}, kinds["k8s.webhook.count"])
+	for kind := range kinds["k8s.workload.pods_scheduled"] {
+		require.Contains(t, workloadResourceLabelKeys, kind)
+	}
+
+	// Metrics aggregated per workload are reported with the workload kind.
+	kinds = collect(WithObjectKind(true), WithPodAggregation(PodAggregationOwner))
+	require.Contains(t, kinds["k8s.workload.pods"], "Deployment")
+	require.NotContains(t, kinds["k8s.workload.pods"], "Pod")
+
+	// The attribute is renamed along with other resource attributes.
+	dc := NewDataCollector(zap.NewNop(), nil, WithObjectKind(true),
+		WithAttributeKeyStyle(AttributeKeyStyleUnderscore))
+	seedManifestObjects(dc, manifestObjects(now))
+	for _, md := range dc.CollectMetricData(now) {
+		require.NotEmpty(t, md.Resource.Labels["k8s_object_kind"])
+	}
+}
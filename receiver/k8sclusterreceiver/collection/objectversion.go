@@ -0,0 +1,54 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"strconv"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// k8sKeyObjectResourceVersion and k8sKeyObjectGeneration are the
+	// resource label keys of the resource version and the generation of the
+	// object a resource was built for.
+	k8sKeyObjectResourceVersion = "k8s.object.resource_version"
+	k8sKeyObjectGeneration      = "k8s.object.generation"
+)
+
+// addObjectVersionLabels sets the k8s.object.resource_version and
+// k8s.object.generation resource labels of the given resource metrics of obj.
+// The generation is only set for kinds tracking it, i.e. if it is not 0. It
+// is expected to be called before resource labels are renamed.
+func addObjectVersionLabels(obj interface{}, rms []*resourceMetrics) {
+	o, ok := obj.(v1.Object)
+	if !ok {
+		return
+	}
+	for _, rm := range rms {
+		if rm == nil || rm.resource == nil {
+			continue
+		}
+		if rm.resource.Labels == nil {
+			rm.resource.Labels = map[string]string{}
+		}
+		if v := o.GetResourceVersion(); v != "" {
+			rm.resource.Labels[k8sKeyObjectResourceVersion] = v
+		}
+		if g := o.GetGeneration(); g != 0 {
+			rm.resource.Labels[k8sKeyObjectGeneration] = strconv.FormatInt(g, 10)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestObjectVersion(t *testing.T) {
+	pod := newPodWithContainer("1", podSpecWithContainer("container-name"),
+		podStatusWithContainer("container-name", containerIDWithPreifx("container-id")))
+	pod.ResourceVersion = "42"
+	pod.Generation = 3
+	// Namespaces do not track their generation.
+	ns := newNamespace("1")
+	ns.ResourceVersion = "7"
+
+	// Disabled by default.
+	h := newTestHarness(t, nil)
+	h.seed(pod, ns)
+	for _, md := range h.collect() {
+		require.NotContains(t, md.Resource.Labels, "k8s.object.resource_version")
+		require.NotContains(t, md.Resource.Labels, "k8s.object.generation")
+	}
+
+	h = newTestHarness(t, nil, WithObjectVersion(true))
+	h.seed(pod, ns)
+	version := map[string]string{"k8s.object.resource_version": "42", "k8s.object.generation": "3"}
+	h.requireMetric("k8s.pod.phase", version)
+	h.requireMetric("k8s.container.restarts", version)
+	nsMetric := h.metrics("k8s.namespace.phase", map[string]string{"k8s.object.resource_version": "7"})
+	require.Len(t, nsMetric, 1)
+	require.NotContains(t, nsMetric[0].resource.Labels, "k8s.object.generation")
+
+	// Metrics that are not about a single object carry no version.
+	for _, m := range h.metrics("k8s.cluster.pending_pods", nil) {
+		require.NotContains(t, m.resource.Labels, "k8s.object.resource_version")
+	}
+
+	// Updates of the object update its version.
+	pod.ResourceVersion = "43"
+	pod.Status.Phase = corev1.PodRunning
+	h.seed(pod)
+	h.requireInt64Value("k8s.pod.phase", map[string]string{"k8s.object.resource_version": "43"}, 2)
+}
@@ -0,0 +1,266 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"sort"
+	"sync"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	"go.opentelemetry.io/collector/translator/conventions"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
+)
+
+const (
+	// Resource labels keys for OpenShift resources.
+	openShiftKeyClusterQuotaUID      = "openshift.clusterquota.uid"
+	openShiftKeyClusterQuotaName     = "openshift.clusterquota.name"
+	openShiftKeyDeploymentConfigUID  = "openshift.deploymentconfig.uid"
+	openShiftKeyDeploymentConfigName = "openshift.deploymentconfig.name"
+)
+
+// The OpenShift resources metrics are reported for. They are watched through
+// the dynamic client since the OpenShift clients are not a dependency.
+var (
+	ClusterResourceQuotaGVR = schema.GroupVersionResource{
+		Group: "quota.openshift.io", Version: "v1", Resource: "clusterresourcequotas",
+	}
+	DeploymentConfigGVR = schema.GroupVersionResource{
+		Group: "apps.openshift.io", Version: "v1", Resource: "deploymentconfigs",
+	}
+)
+
+var clusterQuotaLimitMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name: "openshift.clusterquota.limit",
+	Description: "The upper limit for a particular resource across the namespaces selected by " +
+		"the ClusterResourceQuota. CPU requests/limits will be sent as millicores",
+	Type:      metricspb.MetricDescriptor_GAUGE_INT64,
+	LabelKeys: []*metricspb.LabelKey{{Key: "resource"}},
+})
+
+var clusterQuotaUsedMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name: "openshift.clusterquota.used",
+	Description: "The usage for a particular resource across the namespaces selected by " +
+		"the ClusterResourceQuota. CPU requests/limits will be sent as millicores",
+	Type:      metricspb.MetricDescriptor_GAUGE_INT64,
+	LabelKeys: []*metricspb.LabelKey{{Key: "resource"}},
+})
+
+var appliedClusterQuotaLimitMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name: "openshift.appliedclusterquota.limit",
+	Description: "The upper limit for a particular resource in a specific namespace selected by " +
+		"the ClusterResourceQuota. CPU requests/limits will be sent as millicores",
+	Type:      metricspb.MetricDescriptor_GAUGE_INT64,
+	LabelKeys: []*metricspb.LabelKey{{Key: conventions.AttributeK8sNamespace}, {Key: "resource"}},
+})
+
+var appliedClusterQuotaUsedMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name: "openshift.appliedclusterquota.used",
+	Description: "The usage for a particular resource in a specific namespace selected by " +
+		"the ClusterResourceQuota. CPU requests/limits will be sent as millicores",
+	Type:      metricspb.MetricDescriptor_GAUGE_INT64,
+	LabelKeys: []*metricspb.LabelKey{{Key: conventions.AttributeK8sNamespace}, {Key: "resource"}},
+})
+
+var deploymentConfigDesiredMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "openshift.deploymentconfig.desired",
+	Description: "Number of desired pods in this deploymentconfig",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var deploymentConfigAvailableMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "openshift.deploymentconfig.available",
+	Description: "Total number of available pods (ready for at least minReadySeconds) targeted by this deploymentconfig",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+// clusterResourceQuota holds the fields of an OpenShift
+// quota.openshift.io/v1 ClusterResourceQuota metrics are reported for.
+type clusterResourceQuota struct {
+	v1.ObjectMeta `json:"metadata,omitempty"`
+	Status        struct {
+		// Total is the quota and usage across all the selected namespaces.
+		Total      corev1.ResourceQuotaStatus `json:"total"`
+		Namespaces []struct {
+			Namespace string                     `json:"namespace"`
+			Status    corev1.ResourceQuotaStatus `json:"status"`
+		} `json:"namespaces"`
+	} `json:"status"`
+}
+
+// deploymentConfig holds the fields of an OpenShift apps.openshift.io/v1
+// DeploymentConfig metrics are reported for.
+type deploymentConfig struct {
+	v1.ObjectMeta `json:"metadata,omitempty"`
+	Spec          struct {
+		Replicas int32 `json:"replicas"`
+	} `json:"spec"`
+	Status struct {
+		AvailableReplicas int32 `json:"availableReplicas"`
+	} `json:"status"`
+}
+
+// openShiftStore keeps track of the informer caches of the OpenShift
+// resources, which are only set up on OpenShift clusters.
+type openShiftStore struct {
+	sync.Mutex
+	clusterResourceQuotas cache.Store
+	deploymentConfigs     cache.Store
+}
+
+// getMetricsForOpenShift returns the metrics of the ClusterResourceQuotas
+// and DeploymentConfigs in the informer caches, if set up.
+func (s *openShiftStore) getMetricsForOpenShift() []*resourceMetrics {
+	s.Lock()
+	defer s.Unlock()
+
+	var out []*resourceMetrics
+	for _, obj := range listUnstructuredSorted(s.clusterResourceQuotas) {
+		var crq clusterResourceQuota
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &crq); err != nil {
+			continue
+		}
+		out = append(out, getMetricsForClusterResourceQuota(&crq)...)
+	}
+	for _, obj := range listUnstructuredSorted(s.deploymentConfigs) {
+		var dc deploymentConfig
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &dc); err != nil {
+			continue
+		}
+		out = append(out, getMetricsForDeploymentConfig(&dc)...)
+	}
+	return out
+}
+
+// listUnstructuredSorted returns the objects of the store, if set, sorted by
+// namespace and name to keep output stable across collections.
+func listUnstructuredSorted(store cache.Store) []*unstructured.Unstructured {
+	if store == nil {
+		return nil
+	}
+	var out []*unstructured.Unstructured
+	for _, obj := range store.List() {
+		if u, ok := obj.(*unstructured.Unstructured); ok {
+			out = append(out, u)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return customResourceKey(out[i]) < customResourceKey(out[j])
+	})
+	return out
+}
+
+func getMetricsForClusterResourceQuota(crq *clusterResourceQuota) []*resourceMetrics {
+	var metrics []*metricspb.Metric
+	for _, t := range []struct {
+		metric *metricspb.MetricDescriptor
+		rl     corev1.ResourceList
+	}{
+		{clusterQuotaLimitMetric, crq.Status.Total.Hard},
+		{clusterQuotaUsedMetric, crq.Status.Total.Used},
+	} {
+		for _, name := range sortedResourceNames(t.rl) {
+			metrics = append(metrics, &metricspb.Metric{
+				MetricDescriptor: t.metric,
+				Timeseries: []*metricspb.TimeSeries{
+					utils.GetInt64TimeSeriesWithLabels(quotaValue(name, t.rl[name]),
+						[]*metricspb.LabelValue{{Value: string(name), HasValue: true}}),
+				},
+			})
+		}
+	}
+
+	for _, ns := range crq.Status.Namespaces {
+		for _, t := range []struct {
+			metric *metricspb.MetricDescriptor
+			rl     corev1.ResourceList
+		}{
+			{appliedClusterQuotaLimitMetric, ns.Status.Hard},
+			{appliedClusterQuotaUsedMetric, ns.Status.Used},
+		} {
+			for _, name := range sortedResourceNames(t.rl) {
+				metrics = append(metrics, &metricspb.Metric{
+					MetricDescriptor: t.metric,
+					Timeseries: []*metricspb.TimeSeries{
+						utils.GetInt64TimeSeriesWithLabels(quotaValue(name, t.rl[name]),
+							[]*metricspb.LabelValue{
+								{Value: ns.Namespace, HasValue: true},
+								{Value: string(name), HasValue: true},
+							}),
+					},
+				})
+			}
+		}
+	}
+
+	return []*resourceMetrics{
+		{
+			resource: &resourcepb.Resource{
+				Type: k8sType,
+				Labels: map[string]string{
+					openShiftKeyClusterQuotaUID:     string(crq.UID),
+					openShiftKeyClusterQuotaName:    crq.Name,
+					conventions.AttributeK8sCluster: crq.ClusterName,
+				},
+			},
+			metrics: metrics,
+		},
+	}
+}
+
+func getMetricsForDeploymentConfig(dc *deploymentConfig) []*resourceMetrics {
+	return []*resourceMetrics{
+		{
+			resource: &resourcepb.Resource{
+				Type: k8sType,
+				Labels: map[string]string{
+					openShiftKeyDeploymentConfigUID:   string(dc.UID),
+					openShiftKeyDeploymentConfigName:  dc.Name,
+					conventions.AttributeK8sNamespace: dc.Namespace,
+					conventions.AttributeK8sCluster:   dc.ClusterName,
+				},
+			},
+			metrics: []*metricspb.Metric{
+				{
+					MetricDescriptor: deploymentConfigDesiredMetric,
+					Timeseries:       []*metricspb.TimeSeries{utils.GetInt64TimeSeries(int64(dc.Spec.Replicas))},
+				},
+				{
+					MetricDescriptor: deploymentConfigAvailableMetric,
+					Timeseries:       []*metricspb.TimeSeries{utils.GetInt64TimeSeries(int64(dc.Status.AvailableReplicas))},
+				},
+			},
+		},
+	}
+}
+
+func sortedResourceNames(rl corev1.ResourceList) []corev1.ResourceName {
+	names := make([]corev1.ResourceName, 0, len(rl))
+	for name := range rl {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
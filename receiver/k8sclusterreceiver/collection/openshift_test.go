@@ -0,0 +1,110 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/testutils"
+)
+
+func TestOpenShiftMetrics(t *testing.T) {
+	dc := NewDataCollector(zap.NewNop(), nil)
+
+	// No metrics until the OpenShift resources are watched.
+	require.Empty(t, dc.CollectMetricData(time.Now()))
+
+	quota := func(cpu, pods string) map[string]interface{} {
+		return map[string]interface{}{"requests.cpu": cpu, "pods": pods}
+	}
+	dc.SetupOpenShiftStore(ClusterResourceQuotaGVR, &testutils.MockStore{
+		Cache: map[string]interface{}{
+			"team-a": &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "quota.openshift.io/v1",
+				"kind":       "ClusterResourceQuota",
+				"metadata":   map[string]interface{}{"name": "team-a", "uid": "team-a-uid"},
+				"status": map[string]interface{}{
+					"total": map[string]interface{}{"hard": quota("2", "10"), "used": quota("500m", "3")},
+					"namespaces": []interface{}{
+						map[string]interface{}{
+							"namespace": "team-a-dev",
+							"status":    map[string]interface{}{"hard": quota("2", "10"), "used": quota("500m", "3")},
+						},
+					},
+				},
+			}},
+		},
+	})
+	dc.SetupOpenShiftStore(DeploymentConfigGVR, &testutils.MockStore{
+		Cache: map[string]interface{}{
+			"test/frontend": &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "apps.openshift.io/v1",
+				"kind":       "DeploymentConfig",
+				"metadata":   map[string]interface{}{"namespace": "test", "name": "frontend", "uid": "frontend-uid"},
+				"spec":       map[string]interface{}{"replicas": int64(3)},
+				"status":     map[string]interface{}{"availableReplicas": int64(2)},
+			}},
+		},
+	})
+
+	mds := dc.CollectMetricData(time.Now())
+	require.Len(t, mds, 2)
+
+	testutils.AssertResource(t, mds[0].Resource, k8sType, map[string]string{
+		"openshift.clusterquota.uid":  "team-a-uid",
+		"openshift.clusterquota.name": "team-a",
+		"k8s.cluster.name":            "",
+	})
+	var values []string
+	for _, m := range mds[0].Metrics {
+		ts := m.Timeseries[0]
+		var labels []string
+		for _, lv := range ts.LabelValues {
+			labels = append(labels, lv.Value)
+		}
+		values = append(values, fmt.Sprintf("%s{%s}=%d",
+			m.MetricDescriptor.Name, strings.Join(labels, ","), ts.Points[0].GetInt64Value()))
+	}
+	require.Equal(t, []string{
+		"openshift.clusterquota.limit{pods}=10",
+		"openshift.clusterquota.limit{requests.cpu}=2000",
+		"openshift.clusterquota.used{pods}=3",
+		"openshift.clusterquota.used{requests.cpu}=500",
+		"openshift.appliedclusterquota.limit{team-a-dev,pods}=10",
+		"openshift.appliedclusterquota.limit{team-a-dev,requests.cpu}=2000",
+		"openshift.appliedclusterquota.used{team-a-dev,pods}=3",
+		"openshift.appliedclusterquota.used{team-a-dev,requests.cpu}=500",
+	}, values)
+
+	testutils.AssertResource(t, mds[1].Resource, k8sType, map[string]string{
+		"openshift.deploymentconfig.uid":  "frontend-uid",
+		"openshift.deploymentconfig.name": "frontend",
+		"k8s.namespace.name":              "test",
+		"k8s.cluster.name":                "",
+	})
+	require.Len(t, mds[1].Metrics, 2)
+	testutils.AssertMetrics(t, mds[1].Metrics[0], "openshift.deploymentconfig.desired",
+		metricspb.MetricDescriptor_GAUGE_INT64, 3)
+	testutils.AssertMetrics(t, mds[1].Metrics[1], "openshift.deploymentconfig.available",
+		metricspb.MetricDescriptor_GAUGE_INT64, 2)
+}
@@ -0,0 +1,499 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"strings"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Option represents a configuration option that can be passed to a DataCollector.
+type Option func(*DataCollector)
+
+// WithMaxObjects limits the number of objects cached per kind. Keys are
+// Kubernetes kinds and are matched case-insensitively.
+func WithMaxObjects(maxObjects map[string]int) Option {
+	return func(dc *DataCollector) {
+		dc.metricsStore.maxObjects = make(map[string]int, len(maxObjects))
+		dc.metricsStore.kindCounts = make(map[string]int, len(maxObjects))
+		for kind, limit := range maxObjects {
+			dc.metricsStore.maxObjects[strings.ToLower(kind)] = limit
+		}
+	}
+}
+
+// WithSampling collects only a subset of the objects of the given kinds, as
+// selected by their SamplingRule. Keys are Kubernetes kinds and are matched
+// case-insensitively. Sampling is applied by the caller of SyncMetrics, see
+// DataCollector.IsSampled.
+func WithSampling(rules map[string]SamplingRule) Option {
+	return func(dc *DataCollector) {
+		dc.samplingRules = make(map[string]SamplingRule, len(rules))
+		for kind, rule := range rules {
+			dc.samplingRules[strings.ToLower(kind)] = rule
+		}
+	}
+}
+
+// WithExpressionMetrics reports the given metrics computed from the fields of
+// the objects of their kinds along with the other metrics of each object.
+func WithExpressionMetrics(metrics []ExpressionMetric) Option {
+	return func(dc *DataCollector) {
+		if len(metrics) == 0 {
+			return
+		}
+		dc.expressionMetrics = map[string][]expressionMetric{}
+		for _, m := range metrics {
+			dc.expressionMetrics[m.Kind] = append(dc.expressionMetrics[m.Kind], expressionMetric{
+				metric: m,
+				descriptor: &metricspb.MetricDescriptor{
+					Name:        m.Name,
+					Description: m.Description,
+					Unit:        m.Unit,
+					Type:        metricspb.MetricDescriptor_GAUGE_DOUBLE,
+				},
+			})
+		}
+	}
+}
+
+// WithCustomResourceMetrics reports the given metrics of custom resources,
+// read from their informer caches set up with
+// SetupCustomResourceMetricsStore.
+func WithCustomResourceMetrics(crms []CustomResourceMetrics) Option {
+	return func(dc *DataCollector) {
+		dc.customResourceStore = newCustomResourceStore(crms)
+	}
+}
+
+// WithExcludedPodPhases leaves out the pods in the given phases, e.g.
+// Succeeded pods left behind by completed Jobs. Pods are removed once they
+// reach one of the phases.
+func WithExcludedPodPhases(phases []string) Option {
+	return func(dc *DataCollector) {
+		if len(phases) == 0 {
+			return
+		}
+		dc.excludedPodPhases = make(map[corev1.PodPhase]bool, len(phases))
+		for _, p := range phases {
+			dc.excludedPodPhases[corev1.PodPhase(p)] = true
+		}
+	}
+}
+
+// WithExcludeManagedBy leaves out the objects managed according to the given
+// rule. Exclusion is applied by the caller of SyncMetrics, see
+// DataCollector.IsExcluded.
+func WithExcludeManagedBy(rule ManagedByRule) Option {
+	return func(dc *DataCollector) {
+		if len(rule.Values) == 0 && len(rule.OwnerKinds) == 0 {
+			return
+		}
+		dc.excludeManagedBy = &rule
+	}
+}
+
+// WithDropZeroValues suppresses datapoints with a value of zero for the
+// metrics with the given names.
+func WithDropZeroValues(metricNames []string) Option {
+	return func(dc *DataCollector) {
+		dc.metricsStore.dropZeroValues = make(map[string]bool, len(metricNames))
+		for _, name := range metricNames {
+			dc.metricsStore.dropZeroValues[name] = true
+		}
+	}
+}
+
+// WithDisabledMetrics disables the metrics with the given names. Disabled
+// metrics are neither cached nor emitted, while the other metrics of the same
+// resources still are.
+func WithDisabledMetrics(metricNames []string) Option {
+	return func(dc *DataCollector) {
+		dc.disabledMetrics = make(map[string]bool, len(metricNames))
+		for _, name := range metricNames {
+			dc.disabledMetrics[name] = true
+		}
+	}
+}
+
+// WithMetricOverrides changes how the metrics with the given names are
+// emitted. Options referring to metrics by name, e.g. WithDisabledMetrics,
+// refer to their original names, except for WithDropZeroValues, which is
+// applied as metrics are emitted.
+func WithMetricOverrides(overrides map[string]MetricOverride) Option {
+	return func(dc *DataCollector) {
+		if len(overrides) == 0 {
+			return
+		}
+		dc.metricOverrides = make(map[string]metricOverride, len(overrides))
+		for name, o := range overrides {
+			dc.metricOverrides[name] = newMetricOverride(o)
+		}
+	}
+}
+
+// WithCollectionIntervals collects the cached metrics of the given kinds,
+// keyed by lower-cased kind, at intervals of their own rather than on every
+// collection, expected to run at the shortest of the intervals. The other
+// kinds, and the metrics computed across objects, are collected every
+// defaultInterval.
+func WithCollectionIntervals(defaultInterval time.Duration, intervals map[string]time.Duration) Option {
+	return func(dc *DataCollector) {
+		if len(intervals) == 0 {
+			return
+		}
+		dc.collectionSchedule = newCollectionSchedule(defaultInterval, intervals)
+	}
+}
+
+// WithImageMetadata reports the digest and registry of the images of
+// containers as resource labels of their metrics, resolved from the image ID
+// reported by the container runtime. See SetImageEnricher to resolve further
+// attributes.
+func WithImageMetadata(enabled bool) Option {
+	return func(dc *DataCollector) {
+		dc.reportImageMetadata = enabled
+	}
+}
+
+// WithSpotNodeLabels identifies spot or preemptible nodes by the given labels,
+// keyed by label key with the value of the label on such nodes.
+func WithSpotNodeLabels(labels map[string]string) Option {
+	return func(dc *DataCollector) {
+		dc.nodeTypeLabels.spot = labels
+	}
+}
+
+// WithInstanceTypeLabel sets the key of the node label holding the instance
+// type of nodes.
+func WithInstanceTypeLabel(key string) Option {
+	return func(dc *DataCollector) {
+		dc.nodeTypeLabels.instanceType = key
+	}
+}
+
+// WithUIDFallback caches metrics of objects without a UID under a key derived
+// from their kind, namespace and name. Otherwise, such objects are not
+// collected.
+func WithUIDFallback(enabled bool) Option {
+	return func(dc *DataCollector) {
+		dc.metricsStore.uidFallback = enabled
+	}
+}
+
+// WithFullResyncInterval collects the metrics of all objects once every given
+// interval, even those that have not changed if WithSkipUnchanged is enabled.
+// They are never collected again if the interval is 0.
+func WithFullResyncInterval(interval time.Duration) Option {
+	return func(dc *DataCollector) {
+		dc.metricsStore.fullResyncInterval = interval
+	}
+}
+
+// WithSkipUnchanged omits the metrics of objects that have not changed since
+// they were last collected.
+func WithSkipUnchanged(enabled bool) Option {
+	return func(dc *DataCollector) {
+		dc.metricsStore.skipUnchanged = enabled
+		if enabled {
+			dc.metricsStore.contentHashes = map[types.UID]uint64{}
+			dc.metricsStore.collectedHashes = map[types.UID]uint64{}
+		}
+	}
+}
+
+// WithStringInterning deduplicates the label keys and values and the metric
+// descriptors of cached metrics, which objects otherwise each hold their own
+// copies of, trading some CPU on updates for memory on large clusters.
+func WithStringInterning(enabled bool) Option {
+	return func(dc *DataCollector) {
+		if enabled {
+			dc.metricsStore.interner = newStringInterner()
+		}
+	}
+}
+
+// WithWarmup spreads the collection of cached objects over the given number of
+// first collections: the nth one only includes n out of intervals of the
+// objects, picked by hashing their UIDs, so that the metrics of all the objects
+// listed at start are not pushed at once. When 0 or 1, all the objects are
+// collected right away.
+func WithWarmup(intervals int) Option {
+	return func(dc *DataCollector) {
+		dc.metricsStore.warmupIntervals = intervals
+	}
+}
+
+// WithDeletionGracePeriod keeps collecting the last metrics of deleted objects
+// for the given period after their deletion. When 0, metrics of deleted
+// objects are removed right away.
+func WithDeletionGracePeriod(period time.Duration) Option {
+	return func(dc *DataCollector) {
+		dc.metricsStore.deletionGracePeriod = period
+		if period > 0 {
+			dc.metricsStore.deleted = map[types.UID]deletedObject{}
+		}
+	}
+}
+
+// WithDeletionMarkers collects k8s.object.deleted once for each resource of
+// an object after its metrics have been removed, following its deletion and
+// deletion grace period, so that downstream can close its series.
+func WithDeletionMarkers(enabled bool) Option {
+	return func(dc *DataCollector) {
+		dc.metricsStore.emitDeletionMarkers = enabled
+	}
+}
+
+// WithAntiAffinityViolations reports k8s.pod.anti_affinity_violation for pods
+// with required pod anti-affinity. This compares every such pod with the pods
+// on its node on every collection.
+func WithAntiAffinityViolations(enabled bool) Option {
+	return func(dc *DataCollector) {
+		dc.reportAntiAffinityViolations = enabled
+	}
+}
+
+// WithSecurityContext reports k8s.container.privileged and
+// k8s.container.run_as_root for the containers of pods.
+func WithSecurityContext(enabled bool) Option {
+	return func(dc *DataCollector) {
+		dc.reportSecurityContext = enabled
+	}
+}
+
+// WithObjectCountDelta reports k8s.cluster.object_count_delta, the change of
+// the number of objects of each kind since the previous collection.
+func WithObjectCountDelta(enabled bool) Option {
+	return func(dc *DataCollector) {
+		dc.reportObjectCountDelta = enabled
+	}
+}
+
+// WithInformerCacheMetrics reports k8s.cluster.informer_cache_objects and
+// k8s.cluster.informer_cache_size, the number and estimated size of the
+// objects of each kind in the informer caches.
+func WithInformerCacheMetrics(enabled bool) Option {
+	return func(dc *DataCollector) {
+		dc.reportInformerCache = enabled
+	}
+}
+
+// WithContainerStatusReasons reports k8s.container.status.waiting_reason,
+// k8s.container.status.terminated_reason and
+// k8s.container.status.last_terminated_reason, the reasons containers are
+// waiting or terminated, e.g. CrashLoopBackOff or OOMKilled.
+func WithContainerStatusReasons(enabled bool) Option {
+	return func(dc *DataCollector) {
+		dc.reportContainerStatusReasons = enabled
+	}
+}
+
+// WithTransitionTimes adds the last_transition_time label, the time at which
+// the object entered the reported state, to the datapoints of node
+// conditions, k8s.pod.phase and k8s.pod.readiness_gate.
+func WithTransitionTimes(enabled bool) Option {
+	return func(dc *DataCollector) {
+		dc.reportTransitionTimes = enabled
+	}
+}
+
+// WithContainerRestartRate reports k8s.container.restart_rate, the number of
+// restarts of each container since the previous collection.
+func WithContainerRestartRate(enabled bool) Option {
+	return func(dc *DataCollector) {
+		dc.reportContainerRestartRate = enabled
+	}
+}
+
+// WithNodeResources reports k8s.node.capacity and k8s.node.allocatable, the
+// capacity and allocatable amount of every resource of nodes, e.g. hugepages.
+func WithNodeResources(enabled bool) Option {
+	return func(dc *DataCollector) {
+		dc.reportNodeResources = enabled
+	}
+}
+
+// WithResourceQuotaThreshold sets the utilization ratio, e.g. 0.9, above
+// which ResourceQuotas are counted by k8s.cluster.resource_quotas_over_threshold.
+func WithResourceQuotaThreshold(threshold float64) Option {
+	return func(dc *DataCollector) {
+		dc.resourceQuotaThreshold = threshold
+	}
+}
+
+// WithSkipIrrelevantUpdates only rebuilds the metrics of updated objects if
+// fields they are built from changed, e.g. not on node heartbeats or updates
+// of labels that are not reported.
+func WithSkipIrrelevantUpdates(enabled bool) Option {
+	return func(dc *DataCollector) {
+		dc.skipIrrelevantUpdates = enabled
+	}
+}
+
+// WithObjectKind sets the k8s.object.kind resource attribute of all metrics
+// to the kind of the object they are reported for, e.g. Pod or Deployment.
+// Containers are reported as Container and metrics that are not tied to a
+// single object, e.g. the cluster capacity, as Cluster.
+func WithObjectKind(enabled bool) Option {
+	return func(dc *DataCollector) {
+		dc.reportObjectKind = enabled
+	}
+}
+
+// WithObjectVersion sets the k8s.object.resource_version and
+// k8s.object.generation resource attributes of the metrics of objects to the
+// resource version and generation of the objects. Metrics that are not tied to
+// a single object do not carry them.
+func WithObjectVersion(enabled bool) Option {
+	return func(dc *DataCollector) {
+		dc.reportObjectVersion = enabled
+	}
+}
+
+// WithLabelInfoKinds reports a k8s.<kind>.labels metric carrying all the
+// labels of objects of the given lower-cased kinds (e.g. pod). Kinds are
+// expected to have been checked with ValidateLabelInfoKinds.
+func WithLabelInfoKinds(kinds []string) Option {
+	return func(dc *DataCollector) {
+		dc.labelInfoKinds = make(map[string]bool, len(kinds))
+		for _, kind := range kinds {
+			dc.labelInfoKinds[kind] = true
+		}
+	}
+}
+
+// WithUnits sets the unit in which quantities of each resource are reported,
+// keyed by resource name (e.g. cpu). Units are expected to have been checked
+// with ValidateUnits, unsupported ones are ignored.
+func WithUnits(u map[string]string) Option {
+	return func(dc *DataCollector) {
+		dc.units = make(units, len(u))
+		for name, unit := range u {
+			rn := corev1.ResourceName(name)
+			if ru, ok := supportedUnits[rn].units[unit]; ok {
+				dc.units[rn] = ru
+			}
+		}
+	}
+}
+
+// WithPodAggregation sets how pod metrics are reported. With
+// PodAggregationOwner, metrics of pods managed by a workload are aggregated
+// per workload instead of being reported per pod.
+func WithPodAggregation(mode string) Option {
+	return func(dc *DataCollector) {
+		dc.aggregatePodsByOwner = mode == PodAggregationOwner
+	}
+}
+
+// WithClusterCapacityNodes sets the nodes whose resources are summed up in the
+// cluster capacity metrics, one of the ClusterCapacityNodes constants. Ready
+// nodes are included by default.
+func WithClusterCapacityNodes(nodes string) Option {
+	return func(dc *DataCollector) {
+		dc.clusterCapacityNodes = nodes
+	}
+}
+
+// WithGaugeValueType sets the value type of gauge metrics. With
+// GaugeValueTypeDouble, integer gauges are reported as DOUBLE gauges.
+func WithGaugeValueType(valueType string) Option {
+	return func(dc *DataCollector) {
+		dc.gaugesAsDouble = valueType == GaugeValueTypeDouble
+	}
+}
+
+// WithIncludedResourceAttributes only emits the resource labels with the
+// given default keys (e.g. k8s.pod.name), before they are renamed with
+// WithResourceAttributeKeys. Labels added by WithObjectKind are always
+// emitted. All labels are emitted if keys is empty.
+func WithIncludedResourceAttributes(keys []string) Option {
+	return func(dc *DataCollector) {
+		if len(keys) == 0 {
+			dc.includedResourceAttributes = nil
+			return
+		}
+		dc.includedResourceAttributes = make(map[string]bool, len(keys))
+		for _, k := range keys {
+			dc.includedResourceAttributes[k] = true
+		}
+	}
+}
+
+// WithResourceAttributeKeys renames resource labels of emitted metrics.
+// Keys of the map are the default label keys (e.g. k8s.pod.name) and values
+// the keys to use instead.
+func WithResourceAttributeKeys(keys map[string]string) Option {
+	return func(dc *DataCollector) {
+		dc.resourceAttributeKeys = keys
+	}
+}
+
+// WithAttributeKeyStyle reports the keys of resource labels of emitted
+// metrics in the given style, one of the AttributeKeyStyle constants. Keys
+// renamed with WithResourceAttributeKeys are kept as configured.
+func WithAttributeKeyStyle(style string) Option {
+	return func(dc *DataCollector) {
+		dc.attributeKeyStyle = style
+	}
+}
+
+// WithMaxAttributeValueLength truncates values of resource and datapoint
+// labels, e.g. extracted pod annotations, to the given length in bytes. The
+// truncated values end with TruncatedValueSuffix. A length of 0 disables
+// truncation.
+func WithMaxAttributeValueLength(maxLength int) Option {
+	return func(dc *DataCollector) {
+		dc.maxAttributeValueLength = maxLength
+	}
+}
+
+// WithDatapointAttributes places the resource labels with the given keys on
+// the datapoints of emitted metrics, in addition to or instead of the
+// resource depending on mode, one of the DatapointAttributesMode constants.
+// Keys are matched after renaming with WithResourceAttributeKeys and
+// WithAttributeKeyStyle.
+func WithDatapointAttributes(keys []string, mode string) Option {
+	return func(dc *DataCollector) {
+		dc.datapointAttributeKeys = keys
+		dc.datapointAttributesMode = mode
+	}
+}
+
+// WithFieldExtractionRules extracts resource labels of the metrics of
+// objects from their labels and annotations according to the given rules.
+func WithFieldExtractionRules(rules FieldExtractionRules) Option {
+	return func(dc *DataCollector) {
+		dc.fieldExtractionRules = rules
+	}
+}
+
+// WithSourceNode sets the OpenCensus node of the collected metrics data to
+// one identifying the collector and the given cluster, and the cluster name
+// as the k8s.cluster.name resource label of metrics without one. Neither is
+// set by default.
+func WithSourceNode(clusterName string) Option {
+	return func(dc *DataCollector) {
+		if clusterName != "" {
+			dc.metricsStore.node = newSourceNode(clusterName)
+			dc.clusterName = clusterName
+		}
+	}
+}
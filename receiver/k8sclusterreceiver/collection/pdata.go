@@ -0,0 +1,124 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"sort"
+	"time"
+
+	"go.opentelemetry.io/collector/consumer/consumerdata"
+	"go.opentelemetry.io/collector/consumer/pdata"
+	"go.opentelemetry.io/collector/translator/conventions"
+	"go.opentelemetry.io/collector/translator/internaldata"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// The metrics of objects are built as OpenCensus metrics data by the
+// collectors of each kind, cached as such by the metrics store, and
+// transformed, e.g. renamed or filtered, on their OpenCensus descriptors and
+// labels. The methods below return them as pdata.Metrics, the representation
+// consumers expect, so that the receiver does not depend on OpenCensus and
+// only this file depends on both. The collection is translated once per push,
+// with the translation of the collector.
+
+// toPdataMetrics converts metrics data built with OpenCensus protos.
+func toPdataMetrics(mds []consumerdata.MetricsData) pdata.Metrics {
+	return internaldata.OCSliceToMetrics(mds)
+}
+
+// CollectMetrics returns the metrics collected by CollectMetricData.
+func (dc *DataCollector) CollectMetrics(currentTime time.Time) pdata.Metrics {
+	return toPdataMetrics(dc.CollectMetricData(currentTime))
+}
+
+// FlushMetrics passes the metrics collected by FlushMetricData to flush, in
+// the same batches.
+func (dc *DataCollector) FlushMetrics(currentTime time.Time, flushThreshold int, flush func(pdata.Metrics)) {
+	dc.FlushMetricData(currentTime, flushThreshold, func(mds []consumerdata.MetricsData) {
+		flush(toPdataMetrics(mds))
+	})
+}
+
+// CollectSelfMetrics returns the metrics collected by CollectSelfMetricData.
+func (dc *DataCollector) CollectSelfMetrics(currentTime time.Time) pdata.Metrics {
+	return toPdataMetrics(dc.CollectSelfMetricData(currentTime))
+}
+
+// CollectPeriodicMetrics returns the metrics collected by
+// CollectPeriodicMetricData.
+func (dc *DataCollector) CollectPeriodicMetrics(currentTime time.Time) pdata.Metrics {
+	return toPdataMetrics(dc.CollectPeriodicMetricData(currentTime))
+}
+
+// CollectDeletionMarkers returns the metrics collected by
+// CollectDeletionMarkerData.
+func (dc *DataCollector) CollectDeletionMarkers(currentTime time.Time) pdata.Metrics {
+	return toPdataMetrics(dc.CollectDeletionMarkerData(currentTime))
+}
+
+// CollectMetricsForObjects returns the metrics collected by
+// CollectMetricDataForObjects.
+func (dc *DataCollector) CollectMetricsForObjects(objs []runtime.Object, currentTime time.Time) pdata.Metrics {
+	return toPdataMetrics(dc.CollectMetricDataForObjects(objs, currentTime))
+}
+
+// GroupByNamespace splits collected metrics into batches holding the
+// metrics of a single namespace each, sorted by namespace. Metrics of
+// resources without a namespace, e.g. nodes or the cluster, are returned
+// first in a batch of their own. The namespace is read from the resource
+// attribute the namespace name is reported on, once renamed. The resource
+// metrics of md are moved to the batches.
+func (dc *DataCollector) GroupByNamespace(md pdata.Metrics) []pdata.Metrics {
+	key, ok := dc.resourceAttributeKeys[conventions.AttributeK8sNamespace]
+	if !ok {
+		key = styleAttributeKey(conventions.AttributeK8sNamespace, dc.attributeKeyStyle)
+	}
+
+	clusterScoped := pdata.NewMetrics()
+	byNamespace := map[string]pdata.Metrics{}
+	rms := md.ResourceMetrics()
+	for i := 0; i < rms.Len(); i++ {
+		rm := rms.At(i)
+		var ns string
+		if v, ok := rm.Resource().Attributes().Get(key); ok {
+			ns = v.StringVal()
+		}
+		if ns == "" {
+			clusterScoped.ResourceMetrics().Append(rm)
+			continue
+		}
+		batch, ok := byNamespace[ns]
+		if !ok {
+			batch = pdata.NewMetrics()
+			byNamespace[ns] = batch
+		}
+		batch.ResourceMetrics().Append(rm)
+	}
+
+	namespaces := make([]string, 0, len(byNamespace))
+	for ns := range byNamespace {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	out := make([]pdata.Metrics, 0, len(namespaces)+1)
+	if clusterScoped.ResourceMetrics().Len() > 0 {
+		out = append(out, clusterScoped)
+	}
+	for _, ns := range namespaces {
+		out = append(out, byNamespace[ns])
+	}
+	return out
+}
@@ -0,0 +1,181 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	"go.opentelemetry.io/collector/translator/conventions"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
+)
+
+const (
+	// Resource labels keys for PersistentVolumes and PersistentVolumeClaims.
+	k8sKeyPersistentVolumeUID            = "k8s.persistentvolume.uid"
+	k8sKeyPersistentVolumeName           = "k8s.persistentvolume.name"
+	k8sKeyPersistentVolumeClaimUID       = "k8s.persistentvolumeclaim.uid"
+	k8sKeyPersistentVolumeClaimName      = "k8s.persistentvolumeclaim.name"
+	k8sKeyPersistentVolumeClaimNamespace = "k8s.persistentvolumeclaim.namespace"
+	k8sKeyStorageClassName               = "k8s.storageclass.name"
+)
+
+var persistentVolumePhaseMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name: "k8s.persistentvolume.phase",
+	Description: "Current phase of the persistent volume (1 - Pending, 2 - Available, 3 - Bound, " +
+		"4 - Released, 5 - Failed)",
+	Unit: "1",
+	Type: metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var persistentVolumeCapacityMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.persistentvolume.storage_capacity",
+	Description: "Storage capacity of the persistent volume",
+	Unit:        "By",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var persistentVolumeClaimPhaseMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.persistentvolumeclaim.phase",
+	Description: "Current phase of the persistent volume claim (1 - Pending, 2 - Bound, 3 - Lost)",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var persistentVolumeClaimRequestMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.persistentvolumeclaim.storage_request",
+	Description: "Storage requested by the persistent volume claim",
+	Unit:        "By",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var persistentVolumeClaimCapacityMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name: "k8s.persistentvolumeclaim.storage_capacity",
+	Description: "Storage capacity of the volume bound to the persistent volume claim. " +
+		"Will only be sent once the claim is bound",
+	Unit: "By",
+	Type: metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var persistentVolumePhaseValues = map[corev1.PersistentVolumePhase]int64{
+	corev1.VolumePending:   1,
+	corev1.VolumeAvailable: 2,
+	corev1.VolumeBound:     3,
+	corev1.VolumeReleased:  4,
+	corev1.VolumeFailed:    5,
+}
+
+var persistentVolumeClaimPhaseValues = map[corev1.PersistentVolumeClaimPhase]int64{
+	corev1.ClaimPending: 1,
+	corev1.ClaimBound:   2,
+	corev1.ClaimLost:    3,
+}
+
+func getMetricsForPersistentVolume(pv *corev1.PersistentVolume) []*resourceMetrics {
+	metrics := []*metricspb.Metric{
+		{
+			MetricDescriptor: persistentVolumePhaseMetric,
+			Timeseries: []*metricspb.TimeSeries{
+				utils.GetInt64TimeSeries(persistentVolumePhaseValues[pv.Status.Phase]),
+			},
+		},
+	}
+	if capacity, ok := pv.Spec.Capacity[corev1.ResourceStorage]; ok {
+		metrics = append(metrics, &metricspb.Metric{
+			MetricDescriptor: persistentVolumeCapacityMetric,
+			Timeseries:       []*metricspb.TimeSeries{utils.GetInt64TimeSeries(capacity.Value())},
+		})
+	}
+
+	return []*resourceMetrics{
+		{
+			resource: getResourceForPersistentVolume(pv),
+			metrics:  metrics,
+		},
+	}
+}
+
+// getResourceForPersistentVolume returns the resource of the volume, along
+// with the claim it is bound to, if any.
+func getResourceForPersistentVolume(pv *corev1.PersistentVolume) *resourcepb.Resource {
+	labels := map[string]string{
+		k8sKeyPersistentVolumeUID:       string(pv.UID),
+		k8sKeyPersistentVolumeName:      pv.Name,
+		conventions.AttributeK8sCluster: pv.ClusterName,
+	}
+	if pv.Spec.StorageClassName != "" {
+		labels[k8sKeyStorageClassName] = pv.Spec.StorageClassName
+	}
+	if ref := pv.Spec.ClaimRef; ref != nil && pv.Status.Phase == corev1.VolumeBound {
+		labels[k8sKeyPersistentVolumeClaimName] = ref.Name
+		labels[k8sKeyPersistentVolumeClaimNamespace] = ref.Namespace
+	}
+	return &resourcepb.Resource{
+		Type:   k8sType,
+		Labels: labels,
+	}
+}
+
+func getMetricsForPersistentVolumeClaim(pvc *corev1.PersistentVolumeClaim) []*resourceMetrics {
+	metrics := []*metricspb.Metric{
+		{
+			MetricDescriptor: persistentVolumeClaimPhaseMetric,
+			Timeseries: []*metricspb.TimeSeries{
+				utils.GetInt64TimeSeries(persistentVolumeClaimPhaseValues[pvc.Status.Phase]),
+			},
+		},
+	}
+	if request, ok := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; ok {
+		metrics = append(metrics, &metricspb.Metric{
+			MetricDescriptor: persistentVolumeClaimRequestMetric,
+			Timeseries:       []*metricspb.TimeSeries{utils.GetInt64TimeSeries(request.Value())},
+		})
+	}
+	if capacity, ok := pvc.Status.Capacity[corev1.ResourceStorage]; ok {
+		metrics = append(metrics, &metricspb.Metric{
+			MetricDescriptor: persistentVolumeClaimCapacityMetric,
+			Timeseries:       []*metricspb.TimeSeries{utils.GetInt64TimeSeries(capacity.Value())},
+		})
+	}
+
+	return []*resourceMetrics{
+		{
+			resource: getResourceForPersistentVolumeClaim(pvc),
+			metrics:  metrics,
+		},
+	}
+}
+
+// getResourceForPersistentVolumeClaim returns the resource of the claim, along
+// with the volume it is bound to, if any.
+func getResourceForPersistentVolumeClaim(pvc *corev1.PersistentVolumeClaim) *resourcepb.Resource {
+	labels := map[string]string{
+		k8sKeyPersistentVolumeClaimUID:    string(pvc.UID),
+		k8sKeyPersistentVolumeClaimName:   pvc.Name,
+		conventions.AttributeK8sNamespace: pvc.Namespace,
+		conventions.AttributeK8sCluster:   pvc.ClusterName,
+	}
+	if class := pvc.Spec.StorageClassName; class != nil && *class != "" {
+		labels[k8sKeyStorageClassName] = *class
+	}
+	if pvc.Spec.VolumeName != "" {
+		labels[k8sKeyPersistentVolumeName] = pvc.Spec.VolumeName
+	}
+	return &resourcepb.Resource{
+		Type:   k8sType,
+		Labels: labels,
+	}
+}
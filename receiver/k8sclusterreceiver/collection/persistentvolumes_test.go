@@ -0,0 +1,112 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/testutils"
+)
+
+func TestPersistentVolumeMetrics(t *testing.T) {
+	pv := &corev1.PersistentVolume{
+		ObjectMeta: v1.ObjectMeta{Name: "pv", UID: types.UID("pv-uid"), ClusterName: "test-cluster"},
+		Spec: corev1.PersistentVolumeSpec{
+			Capacity:         corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+			ClaimRef:         &corev1.ObjectReference{Name: "pvc", Namespace: "test-namespace"},
+			StorageClassName: "standard",
+		},
+		Status: corev1.PersistentVolumeStatus{Phase: corev1.VolumeBound},
+	}
+
+	rms := getMetricsForPersistentVolume(pv)
+	require.Equal(t, 1, len(rms))
+	testutils.AssertResource(t, rms[0].resource, k8sType,
+		map[string]string{
+			"k8s.persistentvolume.uid":            "pv-uid",
+			"k8s.persistentvolume.name":           "pv",
+			"k8s.persistentvolumeclaim.name":      "pvc",
+			"k8s.persistentvolumeclaim.namespace": "test-namespace",
+			"k8s.storageclass.name":               "standard",
+			"k8s.cluster.name":                    "test-cluster",
+		},
+	)
+	require.Equal(t, 2, len(rms[0].metrics))
+	testutils.AssertMetrics(t, rms[0].metrics[0], "k8s.persistentvolume.phase",
+		metricspb.MetricDescriptor_GAUGE_INT64, 3)
+	testutils.AssertMetrics(t, rms[0].metrics[1], "k8s.persistentvolume.storage_capacity",
+		metricspb.MetricDescriptor_GAUGE_INT64, 10*1024*1024*1024)
+
+	// Released volumes keep their claim reference but are not bound to it.
+	pv.Status.Phase = corev1.VolumeReleased
+	rms = getMetricsForPersistentVolume(pv)
+	require.NotContains(t, rms[0].resource.Labels, "k8s.persistentvolumeclaim.name")
+	testutils.AssertMetrics(t, rms[0].metrics[0], "k8s.persistentvolume.phase",
+		metricspb.MetricDescriptor_GAUGE_INT64, 4)
+}
+
+func TestPersistentVolumeClaimMetrics(t *testing.T) {
+	class := "standard"
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: v1.ObjectMeta{
+			Name: "pvc", Namespace: "test-namespace", UID: types.UID("pvc-uid"), ClusterName: "test-cluster",
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("5Gi")},
+			},
+			StorageClassName: &class,
+		},
+		Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+	}
+
+	// The capacity is only reported once the claim is bound.
+	rms := getMetricsForPersistentVolumeClaim(pvc)
+	require.Equal(t, 1, len(rms))
+	require.Equal(t, 2, len(rms[0].metrics))
+	testutils.AssertMetrics(t, rms[0].metrics[0], "k8s.persistentvolumeclaim.phase",
+		metricspb.MetricDescriptor_GAUGE_INT64, 1)
+	testutils.AssertMetrics(t, rms[0].metrics[1], "k8s.persistentvolumeclaim.storage_request",
+		metricspb.MetricDescriptor_GAUGE_INT64, 5*1024*1024*1024)
+
+	pvc.Spec.VolumeName = "pv"
+	pvc.Status = corev1.PersistentVolumeClaimStatus{
+		Phase:    corev1.ClaimBound,
+		Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+	}
+	rms = getMetricsForPersistentVolumeClaim(pvc)
+	testutils.AssertResource(t, rms[0].resource, k8sType,
+		map[string]string{
+			"k8s.persistentvolumeclaim.uid":  "pvc-uid",
+			"k8s.persistentvolumeclaim.name": "pvc",
+			"k8s.persistentvolume.name":      "pv",
+			"k8s.storageclass.name":          "standard",
+			"k8s.namespace.name":             "test-namespace",
+			"k8s.cluster.name":               "test-cluster",
+		},
+	)
+	require.Equal(t, 3, len(rms[0].metrics))
+	testutils.AssertMetrics(t, rms[0].metrics[0], "k8s.persistentvolumeclaim.phase",
+		metricspb.MetricDescriptor_GAUGE_INT64, 2)
+	testutils.AssertMetrics(t, rms[0].metrics[2], "k8s.persistentvolumeclaim.storage_capacity",
+		metricspb.MetricDescriptor_GAUGE_INT64, 10*1024*1024*1024)
+}
@@ -0,0 +1,99 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	"go.opentelemetry.io/collector/translator/conventions"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
+)
+
+const (
+	// Resource labels keys for PodDisruptionBudgets.
+	k8sKeyPodDisruptionBudgetUID  = "k8s.poddisruptionbudget.uid"
+	k8sKeyPodDisruptionBudgetName = "k8s.poddisruptionbudget.name"
+)
+
+var podDisruptionBudgetCurrentHealthyMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.pod_disruption_budget.current_healthy",
+	Description: "Number of healthy pods selected by the pod disruption budget",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var podDisruptionBudgetDesiredHealthyMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.pod_disruption_budget.desired_healthy",
+	Description: "Minimum number of healthy pods required by the pod disruption budget",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var podDisruptionBudgetExpectedPodsMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.pod_disruption_budget.expected_pods",
+	Description: "Number of pods selected by the pod disruption budget, whether healthy or not",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var podDisruptionBudgetDisruptionsAllowedMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name: "k8s.pod_disruption_budget.disruptions_allowed",
+	Description: "Number of pod disruptions currently allowed by the pod disruption budget, " +
+		"e.g. evictions during node drains. 0 blocks voluntary disruptions",
+	Unit: "1",
+	Type: metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+func getMetricsForPodDisruptionBudget(pdb *policyv1beta1.PodDisruptionBudget) []*resourceMetrics {
+	return []*resourceMetrics{
+		{
+			resource: getResourceForPodDisruptionBudget(pdb),
+			metrics: []*metricspb.Metric{
+				{
+					MetricDescriptor: podDisruptionBudgetCurrentHealthyMetric,
+					Timeseries:       []*metricspb.TimeSeries{utils.GetInt64TimeSeries(int64(pdb.Status.CurrentHealthy))},
+				},
+				{
+					MetricDescriptor: podDisruptionBudgetDesiredHealthyMetric,
+					Timeseries:       []*metricspb.TimeSeries{utils.GetInt64TimeSeries(int64(pdb.Status.DesiredHealthy))},
+				},
+				{
+					MetricDescriptor: podDisruptionBudgetExpectedPodsMetric,
+					Timeseries:       []*metricspb.TimeSeries{utils.GetInt64TimeSeries(int64(pdb.Status.ExpectedPods))},
+				},
+				{
+					MetricDescriptor: podDisruptionBudgetDisruptionsAllowedMetric,
+					Timeseries: []*metricspb.TimeSeries{
+						utils.GetInt64TimeSeries(int64(pdb.Status.DisruptionsAllowed)),
+					},
+				},
+			},
+		},
+	}
+}
+
+func getResourceForPodDisruptionBudget(pdb *policyv1beta1.PodDisruptionBudget) *resourcepb.Resource {
+	return &resourcepb.Resource{
+		Type: k8sType,
+		Labels: map[string]string{
+			k8sKeyPodDisruptionBudgetUID:      string(pdb.UID),
+			k8sKeyPodDisruptionBudgetName:     pdb.Name,
+			conventions.AttributeK8sNamespace: pdb.Namespace,
+			conventions.AttributeK8sCluster:   pdb.ClusterName,
+		},
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/stretchr/testify/require"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/testutils"
+)
+
+func TestPodDisruptionBudgetMetrics(t *testing.T) {
+	pdb := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: v1.ObjectMeta{
+			Name: "pdb", Namespace: "test-namespace", UID: types.UID("pdb-uid"), ClusterName: "test-cluster",
+		},
+		Status: policyv1beta1.PodDisruptionBudgetStatus{
+			CurrentHealthy:     2,
+			DesiredHealthy:     2,
+			ExpectedPods:       3,
+			DisruptionsAllowed: 0,
+		},
+	}
+
+	rms := getMetricsForPodDisruptionBudget(pdb)
+	require.Equal(t, 1, len(rms))
+	testutils.AssertResource(t, rms[0].resource, k8sType,
+		map[string]string{
+			"k8s.poddisruptionbudget.uid":  "pdb-uid",
+			"k8s.poddisruptionbudget.name": "pdb",
+			"k8s.namespace.name":           "test-namespace",
+			"k8s.cluster.name":             "test-cluster",
+		},
+	)
+	require.Equal(t, 4, len(rms[0].metrics))
+	for i, want := range []struct {
+		name  string
+		value int64
+	}{
+		{"k8s.pod_disruption_budget.current_healthy", 2},
+		{"k8s.pod_disruption_budget.desired_healthy", 2},
+		{"k8s.pod_disruption_budget.expected_pods", 3},
+		{"k8s.pod_disruption_budget.disruptions_allowed", 0},
+	} {
+		testutils.AssertMetrics(t, rms[0].metrics[i], want.name, metricspb.MetricDescriptor_GAUGE_INT64, want.value)
+	}
+}
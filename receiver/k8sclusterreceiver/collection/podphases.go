@@ -0,0 +1,34 @@
+// Copyright 2020 OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ExcludablePodPhases are the terminal phases of the pods that can be left out
+// with WithExcludedPodPhases.
+var ExcludablePodPhases = []string{string(corev1.PodSucceeded), string(corev1.PodFailed)}
+
+// IsExcludedPodPhase returns true if the object is a pod whose phase is one of
+// those of WithExcludedPodPhases, in which case it should be neither synced nor
+// cached.
+func (dc *DataCollector) IsExcludedPodPhase(obj interface{}) bool {
+	if len(dc.excludedPodPhases) == 0 {
+		return false
+	}
+	pod, ok := obj.(*corev1.Pod)
+	return ok && dc.excludedPodPhases[pod.Status.Phase]
+}
@@ -15,6 +15,8 @@
 package collection
 
 import (
+	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -40,13 +42,88 @@ const (
 	podCreationTime = "pod.creation_timestamp"
 )
 
-var podPhaseMetric = &metricspb.MetricDescriptor{
+var podPhaseMetric = registerMetric(&metricspb.MetricDescriptor{
 	Name:        "k8s.pod.phase",
 	Description: "Current phase of the pod (1 - Pending, 2 - Running, 3 - Succeeded, 4 - Failed, 5 - Unknown)",
 	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
-}
+})
+
+var podTerminatingMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.pod.terminating",
+	Description: "Whether the pod has been deleted and is terminating (0 for no, 1 for yes)",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var podReadinessGateMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name: "k8s.pod.readiness_gate",
+	Description: "Whether the condition of a readiness gate of the pod is true (1), " +
+		"false (0) or in an unknown state (-1)",
+	Type:      metricspb.MetricDescriptor_GAUGE_INT64,
+	LabelKeys: []*metricspb.LabelKey{{Key: "condition_type"}},
+})
+
+var podTerminationDurationMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.pod.termination_duration",
+	Description: "Time since the pod was requested to be deleted",
+	Unit:        "s",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var podContainerCountMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.pod.container_count",
+	Description: "Number of app containers in the spec of the pod",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var podInitContainerCountMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.pod.init_container_count",
+	Description: "Number of init containers in the spec of the pod",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
 
-func getMetricsForPod(pod *corev1.Pod) []*resourceMetrics {
+var podEphemeralContainerCountMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.pod.ephemeral_container_count",
+	Description: "Number of ephemeral containers, e.g. added by kubectl debug, in the spec of the pod",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var podSchedulingGatedMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name: "k8s.pod.scheduling_gated",
+	Description: "Whether the pod is kept from being scheduled by its scheduling gates " +
+		"(0 for no, 1 for yes)",
+	Type: metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var podUnschedulableMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name: "k8s.pod.unschedulable",
+	Description: "Whether the scheduler failed to find a node the pod fits on " +
+		"(0 for no, 1 for yes)",
+	Type: metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var podVolumeCountMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.pod.volume_count",
+	Description: "Number of volumes in the spec of the pod",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var podVolumeTypeCountMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.pod.volume_type_count",
+	Description: "Number of volumes in the spec of the pod by their type, e.g. hostPath",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+	LabelKeys:   []*metricspb.LabelKey{{Key: "volume_type"}},
+})
+
+// podReasonSchedulingGated is the reason of the PodScheduled condition of
+// pods with scheduling gates, set by the apiserver of Kubernetes 1.26+.
+const podReasonSchedulingGated = "SchedulingGated"
+
+func getMetricsForPod(pod *corev1.Pod, u units, extractionRules FieldExtractionRules) []*resourceMetrics {
 	metrics := []*metricspb.Metric{
 		{
 			MetricDescriptor: podPhaseMetric,
@@ -54,9 +131,59 @@ func getMetricsForPod(pod *corev1.Pod) []*resourceMetrics {
 				utils.GetInt64TimeSeries(int64(phaseToInt(pod.Status.Phase))),
 			},
 		},
+		{
+			MetricDescriptor: podTerminatingMetric,
+			Timeseries: []*metricspb.TimeSeries{
+				utils.GetInt64TimeSeries(boolToInt64(pod.DeletionTimestamp != nil)),
+			},
+		},
+		{
+			MetricDescriptor: podContainerCountMetric,
+			Timeseries: []*metricspb.TimeSeries{
+				utils.GetInt64TimeSeries(int64(len(pod.Spec.Containers))),
+			},
+		},
+		{
+			MetricDescriptor: podInitContainerCountMetric,
+			Timeseries: []*metricspb.TimeSeries{
+				utils.GetInt64TimeSeries(int64(len(pod.Spec.InitContainers))),
+			},
+		},
+		{
+			MetricDescriptor: podEphemeralContainerCountMetric,
+			Timeseries: []*metricspb.TimeSeries{
+				utils.GetInt64TimeSeries(int64(len(pod.Spec.EphemeralContainers))),
+			},
+		},
+		{
+			MetricDescriptor: podSchedulingGatedMetric,
+			Timeseries: []*metricspb.TimeSeries{
+				utils.GetInt64TimeSeries(boolToInt64(isPodSchedulingGated(pod))),
+			},
+		},
+		{
+			MetricDescriptor: podUnschedulableMetric,
+			Timeseries: []*metricspb.TimeSeries{
+				utils.GetInt64TimeSeries(boolToInt64(isPodUnschedulable(pod))),
+			},
+		},
+		{
+			MetricDescriptor: podVolumeCountMetric,
+			Timeseries: []*metricspb.TimeSeries{
+				utils.GetInt64TimeSeries(int64(len(pod.Spec.Volumes))),
+			},
+		},
+	}
+
+	metrics = append(metrics, getSpecMetricsForPod(pod, u)...)
+	if m := getReadinessGateMetricForPod(pod); m != nil {
+		metrics = append(metrics, m)
+	}
+	if m := getVolumeTypeMetricForPod(pod); m != nil {
+		metrics = append(metrics, m)
 	}
 
-	podRes := getResourceForPod(pod)
+	podRes := getResourceForPod(pod, extractionRules)
 
 	containerResByName := map[string]*resourceMetrics{}
 
@@ -81,7 +208,8 @@ func getMetricsForPod(pod *corev1.Pod) []*resourceMetrics {
 			continue
 		}
 
-		cr.metrics = append(cr.metrics, getSpecMetricsForContainer(c)...)
+		cr.metrics = append(cr.metrics, getSpecMetricsForContainer(c, u)...)
+		cr.metrics = append(cr.metrics, getProbeMetricsForContainer(c)...)
 	}
 
 	out := []*resourceMetrics{
@@ -96,6 +224,254 @@ func getMetricsForPod(pod *corev1.Pod) []*resourceMetrics {
 	return out
 }
 
+// isPodSchedulingGated returns whether the pod is kept from being scheduled
+// by scheduling gates. The gates themselves are not part of the pod spec of
+// the client version in use, so this relies on the PodScheduled condition
+// the apiserver sets on gated pods instead.
+func isPodSchedulingGated(pod *corev1.Pod) bool {
+	if pod.Spec.NodeName != "" {
+		return false
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodScheduled {
+			return cond.Status == corev1.ConditionFalse && cond.Reason == podReasonSchedulingGated
+		}
+	}
+	return false
+}
+
+// isPodUnschedulable returns whether the PodScheduled condition of the pod
+// reports that the scheduler found no node the pod fits on.
+func isPodUnschedulable(pod *corev1.Pod) bool {
+	if pod.Spec.NodeName != "" {
+		return false
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodScheduled {
+			return cond.Status == corev1.ConditionFalse && cond.Reason == corev1.PodReasonUnschedulable
+		}
+	}
+	return false
+}
+
+// getReadinessGateMetricForPod returns the status of the conditions of the
+// readiness gates of the pod, or nil if the pod has no readiness gate. Like
+// Kubernetes, conditions missing from the pod status are treated as unknown.
+func getReadinessGateMetricForPod(pod *corev1.Pod) *metricspb.Metric {
+	if len(pod.Spec.ReadinessGates) == 0 {
+		return nil
+	}
+
+	timeseries := make([]*metricspb.TimeSeries, 0, len(pod.Spec.ReadinessGates))
+	for _, rg := range pod.Spec.ReadinessGates {
+		timeseries = append(timeseries, utils.GetInt64TimeSeriesWithLabels(
+			podConditionValue(pod, rg.ConditionType),
+			[]*metricspb.LabelValue{{Value: string(rg.ConditionType), HasValue: true}},
+		))
+	}
+
+	return &metricspb.Metric{
+		MetricDescriptor: podReadinessGateMetric,
+		Timeseries:       timeseries,
+	}
+}
+
+// Types of volumes reported by k8s.pod.volume_type_count, named after the
+// fields of their source in the volume spec.
+const (
+	volumeTypeConfigMap             = "configMap"
+	volumeTypeSecret                = "secret"
+	volumeTypePersistentVolumeClaim = "persistentVolumeClaim"
+	volumeTypeEmptyDir              = "emptyDir"
+	volumeTypeHostPath              = "hostPath"
+	volumeTypeOther                 = "other"
+)
+
+// getVolumeTypeMetricForPod returns the number of volumes of the pod per
+// type, or nil if the pod has no volume. Only the types having volumes are
+// reported, in the order of the volume type constants.
+func getVolumeTypeMetricForPod(pod *corev1.Pod) *metricspb.Metric {
+	if len(pod.Spec.Volumes) == 0 {
+		return nil
+	}
+
+	counts := map[string]int64{}
+	for _, v := range pod.Spec.Volumes {
+		counts[volumeType(v)]++
+	}
+
+	var timeseries []*metricspb.TimeSeries
+	for _, typ := range []string{
+		volumeTypeConfigMap, volumeTypeSecret, volumeTypePersistentVolumeClaim,
+		volumeTypeEmptyDir, volumeTypeHostPath, volumeTypeOther,
+	} {
+		if n, ok := counts[typ]; ok {
+			timeseries = append(timeseries, utils.GetInt64TimeSeriesWithLabels(
+				n, []*metricspb.LabelValue{{Value: typ, HasValue: true}},
+			))
+		}
+	}
+
+	return &metricspb.Metric{
+		MetricDescriptor: podVolumeTypeCountMetric,
+		Timeseries:       timeseries,
+	}
+}
+
+func volumeType(v corev1.Volume) string {
+	switch {
+	case v.ConfigMap != nil:
+		return volumeTypeConfigMap
+	case v.Secret != nil:
+		return volumeTypeSecret
+	case v.PersistentVolumeClaim != nil:
+		return volumeTypePersistentVolumeClaim
+	case v.EmptyDir != nil:
+		return volumeTypeEmptyDir
+	case v.HostPath != nil:
+		return volumeTypeHostPath
+	default:
+		return volumeTypeOther
+	}
+}
+
+func podConditionValue(pod *corev1.Pod, condType corev1.PodConditionType) int64 {
+	status := corev1.ConditionUnknown
+	for _, c := range pod.Status.Conditions {
+		if c.Type == condType {
+			status = c.Status
+			break
+		}
+	}
+	return nodeConditionValues[status]
+}
+
+// getSpecMetricsForPod returns the effective resource requests and limits of
+// the pod. These follow the scheduler's rules: the larger of the sum over
+// regular containers and the maximum over init containers, plus pod overhead.
+func getSpecMetricsForPod(pod *corev1.Pod, u units) []*metricspb.Metric {
+	metrics := make([]*metricspb.Metric, 0)
+
+	for _, t := range []struct {
+		typ         string
+		description string
+		rl          corev1.ResourceList
+	}{
+		{
+			"request",
+			"Resource requested for the pod, aggregated across its containers. " +
+				"See https://kubernetes.io/docs/reference/generated/kubernetes-api/v1.18/#resourcerequirements-v1-core for details",
+			getPodResourceList(pod, func(c corev1.Container) corev1.ResourceList { return c.Resources.Requests }, true),
+		},
+		{
+			"limit",
+			"Maximum resource limit set for the pod, aggregated across its containers. " +
+				"See https://kubernetes.io/docs/reference/generated/kubernetes-api/v1.18/#resourcerequirements-v1-core for details",
+			getPodResourceList(pod, func(c corev1.Container) corev1.ResourceList { return c.Resources.Limits }, false),
+		},
+	} {
+		names := make([]string, 0, len(t.rl))
+		for k := range t.rl {
+			names = append(names, string(k))
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			k := corev1.ResourceName(name)
+			metrics = append(metrics,
+				u.getResourceMetric(fmt.Sprintf("k8s.pod.%s_%s", k, t.typ), t.description, k, t.rl[k]),
+			)
+		}
+	}
+
+	return metrics
+}
+
+// getPodResourceList aggregates the resource list returned by rl for every
+// container in the pod. Overhead for resources that no container sets is only
+// included when addMissingOverhead is true, since an unset limit is unbounded.
+func getPodResourceList(
+	pod *corev1.Pod,
+	rl func(corev1.Container) corev1.ResourceList,
+	addMissingOverhead bool,
+) corev1.ResourceList {
+	out := corev1.ResourceList{}
+
+	for _, c := range pod.Spec.Containers {
+		for k, v := range rl(c) {
+			q := out[k]
+			q.Add(v)
+			out[k] = q
+		}
+	}
+
+	// Init containers run one at a time before the regular containers,
+	// so only the largest of them matters.
+	for _, c := range pod.Spec.InitContainers {
+		for k, v := range rl(c) {
+			if q, ok := out[k]; !ok || v.Cmp(q) > 0 {
+				out[k] = v.DeepCopy()
+			}
+		}
+	}
+
+	for k, v := range pod.Spec.Overhead {
+		q, ok := out[k]
+		if !ok && !addMissingOverhead {
+			continue
+		}
+		q.Add(v)
+		out[k] = q
+	}
+
+	return out
+}
+
+// getTerminationMetricsForPods returns for how long each terminating pod has
+// been terminating at the given time. This is computed at collection time
+// since terminating pods may not receive any further updates. Pods for which
+// reported returns false are skipped.
+func getTerminationMetricsForPods(
+	pods cache.Store, now time.Time, reported func(*corev1.Pod) bool,
+	extractionRules FieldExtractionRules) []*resourceMetrics {
+	if pods == nil {
+		return nil
+	}
+
+	var terminating []*corev1.Pod
+	for _, obj := range pods.List() {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok || pod.DeletionTimestamp == nil || !reported(pod) {
+			continue
+		}
+		terminating = append(terminating, pod)
+	}
+	sort.Slice(terminating, func(i, j int) bool {
+		return terminating[i].UID < terminating[j].UID
+	})
+
+	out := make([]*resourceMetrics, 0, len(terminating))
+	for _, pod := range terminating {
+		duration := now.Sub(pod.DeletionTimestamp.Time)
+		if duration < 0 {
+			duration = 0
+		}
+
+		out = append(out, &resourceMetrics{
+			resource: getResourceForPod(pod, extractionRules),
+			metrics: []*metricspb.Metric{
+				{
+					MetricDescriptor: podTerminationDurationMetric,
+					Timeseries: []*metricspb.TimeSeries{
+						utils.GetInt64TimeSeries(int64(duration / time.Second)),
+					},
+				},
+			},
+		})
+	}
+	return out
+}
+
 func listResourceMetrics(rms map[string]*resourceMetrics) []*resourceMetrics {
 	out := make([]*resourceMetrics, len(rms))
 
@@ -108,17 +484,21 @@ func listResourceMetrics(rms map[string]*resourceMetrics) []*resourceMetrics {
 	return out
 }
 
-// getResourceForPod returns a proto representation of the pod.
-func getResourceForPod(pod *corev1.Pod) *resourcepb.Resource {
+// getResourceForPod returns a proto representation of the pod. Labels
+// extracted from labels and annotations according to extractionRules never
+// override the labels identifying the pod.
+func getResourceForPod(pod *corev1.Pod, extractionRules FieldExtractionRules) *resourcepb.Resource {
+	labels := getExtractedFields(strings.ToLower(k8sKindPod), pod, extractionRules)
+
+	labels[conventions.AttributeK8sPodUID] = string(pod.UID)
+	labels[conventions.AttributeK8sPod] = pod.Name
+	labels[k8sKeyNodeName] = pod.Spec.NodeName
+	labels[conventions.AttributeK8sNamespace] = pod.Namespace
+	labels[conventions.AttributeK8sCluster] = pod.ClusterName
+
 	return &resourcepb.Resource{
-		Type: k8sType,
-		Labels: map[string]string{
-			conventions.AttributeK8sPodUID:    string(pod.UID),
-			conventions.AttributeK8sPod:       pod.Name,
-			k8sKeyNodeName:                    pod.Spec.NodeName,
-			conventions.AttributeK8sNamespace: pod.Namespace,
-			conventions.AttributeK8sCluster:   pod.ClusterName,
-		},
+		Type:   k8sType,
+		Labels: labels,
 	}
 }
 
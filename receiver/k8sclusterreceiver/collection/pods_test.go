@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
 	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
@@ -29,6 +30,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
 
 	metadata "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/experimentalmetricmetadata"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/testutils"
@@ -49,7 +51,7 @@ func TestPodAndContainerMetrics(t *testing.T) {
 	require.NotNil(t, rms)
 
 	rm := rms[0]
-	require.Equal(t, 1, len(rm.Metrics))
+	require.Equal(t, 10, len(rm.Metrics))
 	testutils.AssertResource(t, rm.Resource, k8sType,
 		map[string]string{
 			"k8s.pod.uid":        "test-pod-1-uid",
@@ -63,9 +65,36 @@ func TestPodAndContainerMetrics(t *testing.T) {
 	testutils.AssertMetrics(t, rm.Metrics[0], "k8s.pod.phase",
 		metricspb.MetricDescriptor_GAUGE_INT64, 3)
 
+	testutils.AssertMetrics(t, rm.Metrics[1], "k8s.pod.terminating",
+		metricspb.MetricDescriptor_GAUGE_INT64, 0)
+
+	testutils.AssertMetrics(t, rm.Metrics[2], "k8s.pod.container_count",
+		metricspb.MetricDescriptor_GAUGE_INT64, 1)
+
+	testutils.AssertMetrics(t, rm.Metrics[3], "k8s.pod.init_container_count",
+		metricspb.MetricDescriptor_GAUGE_INT64, 0)
+
+	testutils.AssertMetrics(t, rm.Metrics[4], "k8s.pod.ephemeral_container_count",
+		metricspb.MetricDescriptor_GAUGE_INT64, 0)
+
+	testutils.AssertMetrics(t, rm.Metrics[5], "k8s.pod.scheduling_gated",
+		metricspb.MetricDescriptor_GAUGE_INT64, 0)
+
+	testutils.AssertMetrics(t, rm.Metrics[6], "k8s.pod.unschedulable",
+		metricspb.MetricDescriptor_GAUGE_INT64, 0)
+
+	testutils.AssertMetrics(t, rm.Metrics[7], "k8s.pod.volume_count",
+		metricspb.MetricDescriptor_GAUGE_INT64, 0)
+
+	testutils.AssertMetrics(t, rm.Metrics[8], "k8s.pod.cpu_request",
+		metricspb.MetricDescriptor_GAUGE_INT64, 10000)
+
+	testutils.AssertMetrics(t, rm.Metrics[9], "k8s.pod.cpu_limit",
+		metricspb.MetricDescriptor_GAUGE_INT64, 20000)
+
 	rm = rms[1]
 
-	require.Equal(t, 4, len(rm.Metrics))
+	require.Equal(t, 7, len(rm.Metrics))
 	testutils.AssertResource(t, rm.Resource, "container",
 		map[string]string{
 			"container.id":         "container-id",
@@ -90,6 +119,182 @@ func TestPodAndContainerMetrics(t *testing.T) {
 
 	testutils.AssertMetrics(t, rm.Metrics[3], "k8s.container.cpu_limit",
 		metricspb.MetricDescriptor_GAUGE_INT64, 20000)
+
+	testutils.AssertMetrics(t, rm.Metrics[4], "k8s.container.has_liveness_probe",
+		metricspb.MetricDescriptor_GAUGE_INT64, 0)
+}
+
+func TestContainerProbeMetrics(t *testing.T) {
+	probe := &corev1.Probe{Handler: corev1.Handler{HTTPGet: &corev1.HTTPGetAction{Path: "/healthz"}}}
+	spec := podSpecWithContainer("probed")
+	spec.Containers[0].LivenessProbe = probe
+	spec.Containers[0].ReadinessProbe = probe
+	spec.Containers[0].StartupProbe = probe
+	unprobed := spec.Containers[0].DeepCopy()
+	unprobed.Name = "unprobed"
+	unprobed.LivenessProbe, unprobed.ReadinessProbe, unprobed.StartupProbe = nil, nil, nil
+	spec.Containers = append(spec.Containers, *unprobed)
+
+	status := podStatusWithContainer("probed", containerIDWithPreifx("probed-id"))
+	unprobedStatus := status.ContainerStatuses[0]
+	unprobedStatus.Name = "unprobed"
+	unprobedStatus.ContainerID = containerIDWithPreifx("unprobed-id")
+	status.ContainerStatuses = append(status.ContainerStatuses, unprobedStatus)
+
+	h := newTestHarness(t, nil)
+	h.seed(newPodWithContainer("1", spec, status))
+
+	for container, want := range map[string]int64{"probed": 1, "unprobed": 0} {
+		labels := map[string]string{"k8s.container.name": container}
+		h.requireInt64Value("k8s.container.has_liveness_probe", labels, want)
+		h.requireInt64Value("k8s.container.has_readiness_probe", labels, want)
+		h.requireInt64Value("k8s.container.has_startup_probe", labels, want)
+	}
+}
+
+func TestPodResourceMetrics(t *testing.T) {
+	container := func(name, cpu, memory string) corev1.Container {
+		return corev1.Container{
+			Name: name,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse(cpu),
+					corev1.ResourceMemory: resource.MustParse(memory),
+				},
+				Limits: corev1.ResourceList{
+					corev1.ResourceMemory: resource.MustParse(memory),
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		spec     corev1.PodSpec
+		expected map[string]int64
+	}{
+		{
+			name: "regular containers are summed",
+			spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{container("init", "100m", "64Mi")},
+				Containers: []corev1.Container{
+					container("c1", "200m", "128Mi"),
+					container("c2", "300m", "128Mi"),
+				},
+			},
+			expected: map[string]int64{
+				"k8s.pod.cpu_request":    500,
+				"k8s.pod.memory_request": 256 * 1024 * 1024,
+				"k8s.pod.memory_limit":   256 * 1024 * 1024,
+			},
+		},
+		{
+			name: "init container exceeds regular containers",
+			spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{
+					container("init1", "1", "64Mi"),
+					container("init2", "250m", "1Gi"),
+				},
+				Containers: []corev1.Container{
+					container("c1", "200m", "128Mi"),
+					container("c2", "300m", "128Mi"),
+				},
+			},
+			expected: map[string]int64{
+				"k8s.pod.cpu_request":    1000,
+				"k8s.pod.memory_request": 1024 * 1024 * 1024,
+				"k8s.pod.memory_limit":   1024 * 1024 * 1024,
+			},
+		},
+		{
+			name: "overhead is added",
+			spec: corev1.PodSpec{
+				Containers: []corev1.Container{container("c1", "200m", "128Mi")},
+				Overhead: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("50m"),
+					corev1.ResourceMemory: resource.MustParse("32Mi"),
+				},
+			},
+			expected: map[string]int64{
+				"k8s.pod.cpu_request":    250,
+				"k8s.pod.memory_request": 160 * 1024 * 1024,
+				"k8s.pod.memory_limit":   160 * 1024 * 1024,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := newPodWithContainer("1", &tt.spec, &corev1.PodStatus{Phase: corev1.PodRunning})
+
+			rms := getMetricsForPod(pod, nil, FieldExtractionRules{})
+			require.Equal(t, 1, len(rms))
+
+			actual := map[string]int64{}
+			for _, m := range rms[0].metrics[8:] {
+				actual[m.MetricDescriptor.Name] = m.Timeseries[0].Points[0].GetInt64Value()
+			}
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestPodContainerCountMetrics(t *testing.T) {
+	pod := newPodWithContainer("1", &corev1.PodSpec{
+		InitContainers: []corev1.Container{{Name: "init-1"}, {Name: "init-2"}},
+		Containers:     []corev1.Container{{Name: "app-1"}, {Name: "app-2"}, {Name: "app-3"}},
+		EphemeralContainers: []corev1.EphemeralContainer{
+			{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debugger"}},
+		},
+	}, &corev1.PodStatus{Phase: corev1.PodRunning})
+
+	rm := getMetricsForPod(pod, nil, FieldExtractionRules{})[0]
+	testutils.AssertMetrics(t, rm.metrics[2], "k8s.pod.container_count",
+		metricspb.MetricDescriptor_GAUGE_INT64, 3)
+	testutils.AssertMetrics(t, rm.metrics[3], "k8s.pod.init_container_count",
+		metricspb.MetricDescriptor_GAUGE_INT64, 2)
+	testutils.AssertMetrics(t, rm.metrics[4], "k8s.pod.ephemeral_container_count",
+		metricspb.MetricDescriptor_GAUGE_INT64, 1)
+}
+
+func TestPodTerminatingMetrics(t *testing.T) {
+	now := time.Now()
+
+	pod := newPodWithContainer("1", podSpecWithContainer("container-name"),
+		podStatusWithContainer("container-name", containerIDWithPreifx("container-id")))
+	terminating := newPodWithContainer("2", podSpecWithContainer("container-name"),
+		podStatusWithContainer("container-name", containerIDWithPreifx("container-id")))
+	deletionTimestamp := v1.NewTime(now.Add(-5 * time.Minute))
+	terminating.DeletionTimestamp = &deletionTimestamp
+
+	testutils.AssertMetrics(t, getMetricsForPod(pod, nil, FieldExtractionRules{})[0].metrics[1], "k8s.pod.terminating",
+		metricspb.MetricDescriptor_GAUGE_INT64, 0)
+	testutils.AssertMetrics(t, getMetricsForPod(terminating, nil, FieldExtractionRules{})[0].metrics[1], "k8s.pod.terminating",
+		metricspb.MetricDescriptor_GAUGE_INT64, 1)
+
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	require.NoError(t, store.Add(pod))
+	require.NoError(t, store.Add(terminating))
+
+	rms := getTerminationMetricsForPods(store, now, func(*corev1.Pod) bool { return true }, FieldExtractionRules{})
+	require.Equal(t, 1, len(rms))
+	require.Equal(t, "test-pod-2-uid", rms[0].resource.Labels["k8s.pod.uid"])
+	require.Equal(t, 1, len(rms[0].metrics))
+	testutils.AssertMetrics(t, rms[0].metrics[0], "k8s.pod.termination_duration",
+		metricspb.MetricDescriptor_GAUGE_INT64, 300)
+
+	rms = getTerminationMetricsForPods(store, now, func(*corev1.Pod) bool { return false }, FieldExtractionRules{})
+	require.Equal(t, 0, len(rms))
+
+	// The duration is computed at collection time.
+	dc := NewDataCollector(zap.NewNop(), []string{})
+	dc.SetupMetadataStore(&corev1.Pod{}, store)
+	dc.SyncMetrics(terminating)
+
+	mds := dc.CollectMetricData(now.Add(time.Minute))
+	md := mds[len(mds)-1]
+	testutils.AssertMetrics(t, md.Metrics[0], "k8s.pod.termination_duration",
+		metricspb.MetricDescriptor_GAUGE_INT64, 360)
 }
 
 func newPodWithContainer(id string, spec *corev1.PodSpec, status *corev1.PodStatus) *corev1.Pod {
@@ -151,6 +356,40 @@ var containerIDWithPreifx = func(containerID string) string {
 	return "docker://" + containerID
 }
 
+func TestPodReadinessGateMetrics(t *testing.T) {
+	pod := newPodWithContainer("1", podSpecWithContainer("container-name"),
+		podStatusWithContainer("container-name", containerIDWithPreifx("container-id")))
+
+	numMetrics := len(getMetricsForPod(pod, nil, FieldExtractionRules{})[0].metrics)
+
+	pod.Spec.ReadinessGates = []corev1.PodReadinessGate{
+		{ConditionType: "example.com/load-balancer-attached"},
+		{ConditionType: "example.com/warmed-up"},
+	}
+	pod.Status.Conditions = []corev1.PodCondition{
+		{Type: corev1.PodReady, Status: corev1.ConditionFalse},
+		{Type: "example.com/load-balancer-attached", Status: corev1.ConditionTrue},
+	}
+
+	metrics := getMetricsForPod(pod, nil, FieldExtractionRules{})[0].metrics
+	require.Equal(t, numMetrics+1, len(metrics))
+
+	m := metrics[len(metrics)-1]
+	require.Equal(t, "k8s.pod.readiness_gate", m.MetricDescriptor.Name)
+	require.Equal(t, metricspb.MetricDescriptor_GAUGE_INT64, m.MetricDescriptor.Type)
+	require.Equal(t, []*metricspb.LabelKey{{Key: "condition_type"}}, m.MetricDescriptor.LabelKeys)
+	require.Equal(t, 2, len(m.Timeseries))
+
+	require.Equal(t, []*metricspb.LabelValue{{Value: "example.com/load-balancer-attached", HasValue: true}},
+		m.Timeseries[0].LabelValues)
+	require.Equal(t, int64(1), m.Timeseries[0].Points[0].GetInt64Value())
+
+	// The condition of the second gate has not been set yet.
+	require.Equal(t, []*metricspb.LabelValue{{Value: "example.com/warmed-up", HasValue: true}},
+		m.Timeseries[1].LabelValues)
+	require.Equal(t, int64(-1), m.Timeseries[1].Points[0].GetInt64Value())
+}
+
 func TestListResourceMetrics(t *testing.T) {
 	rms := map[string]*resourceMetrics{
 		"resource-1": {resource: &resourcepb.Resource{Type: "type-1"}},
@@ -362,3 +601,62 @@ func expectedKubernetesMetadata(to testCaseOptions) map[metadata.ResourceID]*Kub
 	}
 	return out
 }
+
+func TestPodSchedulingGatedMetric(t *testing.T) {
+	h := newTestHarness(t, nil)
+	h.seed(
+		newPendingPod("gated", "", corev1.PodCondition{
+			Type:    corev1.PodScheduled,
+			Status:  corev1.ConditionFalse,
+			Reason:  "SchedulingGated",
+			Message: "Scheduling is blocked due to non-empty scheduling gates",
+		}),
+		newPendingPod("unschedulable", "", unschedulable("0/3 nodes are available: 3 Insufficient cpu.")),
+		newPendingPod("new", ""),
+	)
+
+	h.requireInt64Value("k8s.pod.scheduling_gated", map[string]string{"k8s.pod.name": "gated"}, 1)
+	h.requireInt64Value("k8s.pod.scheduling_gated", map[string]string{"k8s.pod.name": "unschedulable"}, 0)
+	h.requireInt64Value("k8s.pod.scheduling_gated", map[string]string{"k8s.pod.name": "new"}, 0)
+
+	h.requireInt64Value("k8s.pod.unschedulable", map[string]string{"k8s.pod.name": "gated"}, 0)
+	h.requireInt64Value("k8s.pod.unschedulable", map[string]string{"k8s.pod.name": "unschedulable"}, 1)
+	h.requireInt64Value("k8s.pod.unschedulable", map[string]string{"k8s.pod.name": "new"}, 0)
+}
+
+func TestPodVolumeMetrics(t *testing.T) {
+	pod := newPendingPod("volumes", "node-1")
+	pod.Spec.Volumes = []corev1.Volume{
+		{Name: "config", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{}}},
+		{Name: "token", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{}}},
+		{Name: "tls", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{}}},
+		{Name: "data", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{}}},
+		{Name: "cache", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		{Name: "docker-sock", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{}}},
+		{Name: "podinfo", VolumeSource: corev1.VolumeSource{DownwardAPI: &corev1.DownwardAPIVolumeSource{}}},
+	}
+	h := newTestHarness(t, nil)
+	h.seed(pod, newPendingPod("no-volumes", "node-1"))
+
+	h.requireInt64Value("k8s.pod.volume_count", map[string]string{"k8s.pod.name": "volumes"}, 7)
+	m := h.requireMetric("k8s.pod.volume_type_count", map[string]string{"k8s.pod.name": "volumes"})
+	require.Equal(t, []*metricspb.LabelKey{{Key: "volume_type"}}, m.MetricDescriptor.LabelKeys)
+	var types []string
+	counts := map[string]int64{}
+	for _, ts := range m.Timeseries {
+		types = append(types, ts.LabelValues[0].Value)
+		counts[ts.LabelValues[0].Value] = ts.Points[0].GetInt64Value()
+	}
+	require.Equal(t, []string{"configMap", "secret", "persistentVolumeClaim", "emptyDir", "hostPath", "other"}, types)
+	require.Equal(t, map[string]int64{
+		"configMap":             1,
+		"secret":                2,
+		"persistentVolumeClaim": 1,
+		"emptyDir":              1,
+		"hostPath":              1,
+		"other":                 1,
+	}, counts)
+
+	h.requireInt64Value("k8s.pod.volume_count", map[string]string{"k8s.pod.name": "no-volumes"}, 0)
+	h.requireNoMetric("k8s.pod.volume_type_count", map[string]string{"k8s.pod.name": "no-volumes"})
+}
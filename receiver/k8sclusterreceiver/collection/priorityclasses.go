@@ -0,0 +1,82 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	"go.opentelemetry.io/collector/translator/conventions"
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
+)
+
+const (
+	// Resource labels keys for PriorityClasses.
+	k8sKeyPriorityClassUID              = "k8s.priorityclass.uid"
+	k8sKeyPriorityClassName             = "k8s.priorityclass.name"
+	k8sKeyPriorityClassPreemptionPolicy = "k8s.priorityclass.preemption_policy"
+)
+
+var priorityClassValueMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.priority_class.value",
+	Description: "Priority of the pods of the priority class",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var priorityClassGlobalDefaultMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name: "k8s.priority_class.global_default",
+	Description: "Whether the priority class is the default of pods without one (1) or not (0). " +
+		"Of several global defaults, the one with the lowest value applies",
+	Unit: "1",
+	Type: metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+func getMetricsForPriorityClass(pc *schedulingv1.PriorityClass) []*resourceMetrics {
+	return []*resourceMetrics{
+		{
+			resource: getResourceForPriorityClass(pc),
+			metrics: []*metricspb.Metric{
+				{
+					MetricDescriptor: priorityClassValueMetric,
+					Timeseries:       []*metricspb.TimeSeries{utils.GetInt64TimeSeries(int64(pc.Value))},
+				},
+				{
+					MetricDescriptor: priorityClassGlobalDefaultMetric,
+					Timeseries:       []*metricspb.TimeSeries{utils.GetInt64TimeSeries(boolToInt64(pc.GlobalDefault))},
+				},
+			},
+		},
+	}
+}
+
+func getResourceForPriorityClass(pc *schedulingv1.PriorityClass) *resourcepb.Resource {
+	// The API defaults the preemption policy to PreemptLowerPriority.
+	policy := corev1.PreemptLowerPriority
+	if pc.PreemptionPolicy != nil {
+		policy = *pc.PreemptionPolicy
+	}
+	return &resourcepb.Resource{
+		Type: k8sType,
+		Labels: map[string]string{
+			k8sKeyPriorityClassUID:              string(pc.UID),
+			k8sKeyPriorityClassName:             pc.Name,
+			k8sKeyPriorityClassPreemptionPolicy: string(policy),
+			conventions.AttributeK8sCluster:     pc.ClusterName,
+		},
+	}
+}
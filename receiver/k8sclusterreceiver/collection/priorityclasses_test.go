@@ -0,0 +1,59 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/testutils"
+)
+
+func TestPriorityClassMetrics(t *testing.T) {
+	pc := &schedulingv1.PriorityClass{
+		ObjectMeta:    v1.ObjectMeta{Name: "high", UID: types.UID("high-uid"), ClusterName: "test-cluster"},
+		Value:         1000000,
+		GlobalDefault: true,
+	}
+
+	rms := getMetricsForPriorityClass(pc)
+	require.Equal(t, 1, len(rms))
+	testutils.AssertResource(t, rms[0].resource, k8sType,
+		map[string]string{
+			"k8s.priorityclass.uid":               "high-uid",
+			"k8s.priorityclass.name":              "high",
+			"k8s.priorityclass.preemption_policy": "PreemptLowerPriority",
+			"k8s.cluster.name":                    "test-cluster",
+		},
+	)
+	require.Equal(t, 2, len(rms[0].metrics))
+	testutils.AssertMetrics(t, rms[0].metrics[0], "k8s.priority_class.value", metricspb.MetricDescriptor_GAUGE_INT64, 1000000)
+	testutils.AssertMetrics(t, rms[0].metrics[1], "k8s.priority_class.global_default",
+		metricspb.MetricDescriptor_GAUGE_INT64, 1)
+
+	never := corev1.PreemptNever
+	pc.PreemptionPolicy = &never
+	pc.GlobalDefault = false
+	rms = getMetricsForPriorityClass(pc)
+	require.Equal(t, "Never", rms[0].resource.Labels["k8s.priorityclass.preemption_policy"])
+	testutils.AssertMetrics(t, rms[0].metrics[1], "k8s.priority_class.global_default",
+		metricspb.MetricDescriptor_GAUGE_INT64, 0)
+}
@@ -0,0 +1,137 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
+)
+
+// clusterAdminRole is the name of the default ClusterRole granting full
+// access to the cluster.
+const clusterAdminRole = "cluster-admin"
+
+var rbacRoleCountMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.rbac.role_count",
+	Description: "Number of Roles of the cluster",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var rbacClusterRoleCountMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.rbac.cluster_role_count",
+	Description: "Number of ClusterRoles of the cluster",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var rbacRoleBindingCountMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.rbac.role_binding_count",
+	Description: "Number of RoleBindings of the cluster",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var rbacClusterRoleBindingCountMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.rbac.cluster_role_binding_count",
+	Description: "Number of ClusterRoleBindings of the cluster",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var rbacClusterAdminBindingCountMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name: "k8s.rbac.cluster_admin_binding_count",
+	Description: "Number of RoleBindings and ClusterRoleBindings granting the " +
+		"cluster-admin ClusterRole",
+	Unit: "1",
+	Type: metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+// getRBACMetricsForCluster returns the number of Roles, ClusterRoles,
+// RoleBindings and ClusterRoleBindings of the cluster, along with the number
+// of bindings granting cluster-admin. Nothing is reported unless RBAC objects
+// are watched.
+func getRBACMetricsForCluster(ms *metadataStore) []*resourceMetrics {
+	if ms.roles == nil && ms.clusterRoles == nil && ms.roleBindings == nil && ms.clusterRoleBindings == nil {
+		return nil
+	}
+
+	var metrics []*metricspb.Metric
+	for _, c := range []struct {
+		store      cache.Store
+		descriptor *metricspb.MetricDescriptor
+	}{
+		{ms.roles, rbacRoleCountMetric},
+		{ms.clusterRoles, rbacClusterRoleCountMetric},
+		{ms.roleBindings, rbacRoleBindingCountMetric},
+		{ms.clusterRoleBindings, rbacClusterRoleBindingCountMetric},
+	} {
+		if c.store == nil {
+			continue
+		}
+		metrics = append(metrics, &metricspb.Metric{
+			MetricDescriptor: c.descriptor,
+			Timeseries: []*metricspb.TimeSeries{
+				utils.GetInt64TimeSeries(int64(len(c.store.ListKeys()))),
+			},
+		})
+	}
+
+	if ms.roleBindings != nil || ms.clusterRoleBindings != nil {
+		metrics = append(metrics, &metricspb.Metric{
+			MetricDescriptor: rbacClusterAdminBindingCountMetric,
+			Timeseries: []*metricspb.TimeSeries{
+				utils.GetInt64TimeSeries(countClusterAdminBindings(ms)),
+			},
+		})
+	}
+
+	return []*resourceMetrics{
+		{
+			resource: getResourceForCluster(),
+			metrics:  metrics,
+		},
+	}
+}
+
+// countClusterAdminBindings returns the number of bindings whose role ref is
+// the cluster-admin ClusterRole. RoleBindings referencing it only grant its
+// permissions within their namespace, but are counted as well since they
+// still grant full access to that namespace.
+func countClusterAdminBindings(ms *metadataStore) int64 {
+	var count int64
+	if ms.roleBindings != nil {
+		for _, obj := range ms.roleBindings.List() {
+			if rb, ok := obj.(*rbacv1.RoleBinding); ok && isClusterAdminRoleRef(rb.RoleRef) {
+				count++
+			}
+		}
+	}
+	if ms.clusterRoleBindings != nil {
+		for _, obj := range ms.clusterRoleBindings.List() {
+			if crb, ok := obj.(*rbacv1.ClusterRoleBinding); ok && isClusterAdminRoleRef(crb.RoleRef) {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func isClusterAdminRoleRef(ref rbacv1.RoleRef) bool {
+	return ref.Kind == "ClusterRole" && ref.Name == clusterAdminRole
+}
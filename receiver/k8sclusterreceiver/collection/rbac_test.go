@@ -0,0 +1,67 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestRBACMetrics(t *testing.T) {
+	h := newTestHarness(t, nil)
+	h.requireNoMetric("k8s.rbac.cluster_admin_binding_count", nil)
+
+	meta := func(name, namespace string) v1.ObjectMeta {
+		return v1.ObjectMeta{Name: name, Namespace: namespace, UID: types.UID(namespace + name + "-uid")}
+	}
+	clusterRoleRef := func(name string) rbacv1.RoleRef {
+		return rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: name}
+	}
+
+	h.seed(
+		&rbacv1.Role{ObjectMeta: meta("reader", "test-namespace")},
+		&rbacv1.ClusterRole{ObjectMeta: meta("cluster-admin", "")},
+		&rbacv1.ClusterRole{ObjectMeta: meta("view", "")},
+		&rbacv1.RoleBinding{
+			ObjectMeta: meta("reader", "test-namespace"),
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: "reader"},
+		},
+		// Grants cluster-admin within its namespace only, but is counted.
+		&rbacv1.RoleBinding{
+			ObjectMeta: meta("namespace-admin", "test-namespace"),
+			RoleRef:    clusterRoleRef("cluster-admin"),
+		},
+		// A Role named like the ClusterRole is not cluster-admin.
+		&rbacv1.RoleBinding{
+			ObjectMeta: meta("impostor", "test-namespace"),
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: "cluster-admin"},
+		},
+		&rbacv1.ClusterRoleBinding{ObjectMeta: meta("cluster-admin", ""), RoleRef: clusterRoleRef("cluster-admin")},
+		&rbacv1.ClusterRoleBinding{ObjectMeta: meta("viewers", ""), RoleRef: clusterRoleRef("view")},
+	)
+
+	cluster := map[string]string{}
+	h.requireInt64Value("k8s.rbac.role_count", cluster, 1)
+	h.requireInt64Value("k8s.rbac.cluster_role_count", cluster, 2)
+	h.requireInt64Value("k8s.rbac.role_binding_count", cluster, 3)
+	h.requireInt64Value("k8s.rbac.cluster_role_binding_count", cluster, 2)
+	h.requireInt64Value("k8s.rbac.cluster_admin_binding_count", cluster, 2)
+
+	h.remove(&rbacv1.ClusterRoleBinding{ObjectMeta: meta("cluster-admin", "")})
+	h.requireInt64Value("k8s.rbac.cluster_admin_binding_count", cluster, 1)
+}
@@ -0,0 +1,110 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"hash/fnv"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// relevantHashes tracks, for every object whose metrics are cached, a hash
+// of the fields of the object its metrics are built from, so that updates
+// not changing any of them, e.g. node heartbeats, do not rebuild metrics.
+type relevantHashes struct {
+	sync.Mutex
+	hashes map[types.UID]uint64
+}
+
+// unchanged returns whether hash is the hash recorded for the object with
+// the given key.
+func (r *relevantHashes) unchanged(key types.UID, hash uint64) bool {
+	r.Lock()
+	defer r.Unlock()
+	h, ok := r.hashes[key]
+	return ok && h == hash
+}
+
+func (r *relevantHashes) set(key types.UID, hash uint64) {
+	r.Lock()
+	defer r.Unlock()
+	r.hashes[key] = hash
+}
+
+func (r *relevantHashes) forget(key types.UID) {
+	r.Lock()
+	defer r.Unlock()
+	delete(r.hashes, key)
+}
+
+// marshaler is implemented by the generated types of the Kubernetes API.
+type marshaler interface {
+	Marshal() ([]byte, error)
+}
+
+// relevantFieldsHash returns a hash of the fields of obj that its metrics
+// are built from, that is all of them apart from those changing without
+// any effect on metrics: the resource version and managed fields of all
+// objects, the heartbeat and probe times of node and pod conditions, and
+// labels and annotations when they are not read. The second return value
+// is false if obj cannot be hashed.
+func (dc *DataCollector) relevantFieldsHash(obj runtime.Object) (uint64, bool) {
+	o := obj.DeepCopyObject()
+	om, err := meta.Accessor(o)
+	if err != nil {
+		return 0, false
+	}
+	om.SetResourceVersion("")
+	om.SetManagedFields(nil)
+
+	kind := getObjectKind(obj)
+	// Node labels identify the instance type and spot nodes.
+	if kind != k8sKindNode && !dc.labelInfoKinds[strings.ToLower(kind)] &&
+		!dc.fieldExtractionRules.readsLabels(strings.ToLower(kind)) {
+		om.SetLabels(nil)
+	}
+	if !dc.fieldExtractionRules.readsAnnotations(strings.ToLower(kind)) {
+		om.SetAnnotations(nil)
+	}
+
+	switch o := o.(type) {
+	case *corev1.Node:
+		for i := range o.Status.Conditions {
+			o.Status.Conditions[i].LastHeartbeatTime = v1.Time{}
+		}
+	case *corev1.Pod:
+		for i := range o.Status.Conditions {
+			o.Status.Conditions[i].LastProbeTime = v1.Time{}
+		}
+	}
+
+	m, ok := o.(marshaler)
+	if !ok {
+		return 0, false
+	}
+	b, err := m.Marshal()
+	if err != nil {
+		return 0, false
+	}
+	h := fnv.New64a()
+	h.Write(b)
+	return h.Sum64(), true
+}
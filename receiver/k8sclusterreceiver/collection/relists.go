@@ -0,0 +1,83 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"sort"
+	"sync"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
+)
+
+var clusterInformerRelistsMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name: "k8s.cluster.informer_relists",
+	Description: "Number of times the informer of a kind had to list all objects again since " +
+		"the receiver started, e.g. since the resource version it watched from was too old",
+	Unit:      "1",
+	Type:      metricspb.MetricDescriptor_GAUGE_INT64,
+	LabelKeys: []*metricspb.LabelKey{{Key: "kind"}},
+})
+
+// informerRelists counts the relists of informers, keyed by kind.
+type informerRelists struct {
+	sync.Mutex
+	kinds map[string]int64
+}
+
+// RecordInformerRelist records that the informer of the given kind, e.g.
+// Pod, has to list its objects again. Metrics may briefly be inconsistent
+// while it does, which k8s.cluster.informer_relists allows to correlate with.
+func (dc *DataCollector) RecordInformerRelist(kind string) {
+	dc.informerRelists.Lock()
+	defer dc.informerRelists.Unlock()
+	dc.informerRelists.kinds[kind]++
+}
+
+// getInformerRelistsMetricsForCluster returns the number of relists per kind.
+// Nothing is reported until an informer relisted.
+func getInformerRelistsMetricsForCluster(r *informerRelists) []*resourceMetrics {
+	r.Lock()
+	defer r.Unlock()
+	if len(r.kinds) == 0 {
+		return nil
+	}
+
+	kinds := make([]string, 0, len(r.kinds))
+	for kind := range r.kinds {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	series := make([]*metricspb.TimeSeries, 0, len(kinds))
+	for _, kind := range kinds {
+		series = append(series, utils.GetInt64TimeSeriesWithLabels(
+			r.kinds[kind], []*metricspb.LabelValue{{Value: kind, HasValue: true}},
+		))
+	}
+
+	return []*resourceMetrics{
+		{
+			resource: getResourceForCluster(),
+			metrics: []*metricspb.Metric{
+				{
+					MetricDescriptor: clusterInformerRelistsMetric,
+					Timeseries:       series,
+				},
+			},
+		},
+	}
+}
@@ -22,25 +22,46 @@ import (
 	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
 )
 
+// replicaMetrics are the descriptors of the replica metrics of a resource.
+type replicaMetrics struct {
+	desired, available *metricspb.MetricDescriptor
+}
+
+// replicaMetricsByResource are the replica metrics of the resources managing
+// replicas of pods.
+var replicaMetricsByResource = map[string]replicaMetrics{
+	"deployment":             registerReplicaMetrics("deployment"),
+	"replicaset":             registerReplicaMetrics("replicaset"),
+	"replication_controller": registerReplicaMetrics("replication_controller"),
+}
+
+func registerReplicaMetrics(resource string) replicaMetrics {
+	return replicaMetrics{
+		desired: registerMetric(&metricspb.MetricDescriptor{
+			Name:        fmt.Sprintf("k8s.%s.desired", resource),
+			Description: fmt.Sprintf("Number of desired pods in this %s", resource),
+			Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+			Unit:        "1",
+		}),
+		available: registerMetric(&metricspb.MetricDescriptor{
+			Name:        fmt.Sprintf("k8s.%s.available", resource),
+			Description: fmt.Sprintf("Total number of available pods (ready for at least minReadySeconds) targeted by this %s", resource),
+			Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+			Unit:        "1",
+		}),
+	}
+}
+
 func getReplicaMetrics(resource string, desired, available int32) []*metricspb.Metric {
+	descriptors := replicaMetricsByResource[resource]
 	return []*metricspb.Metric{
 		{
-			MetricDescriptor: &metricspb.MetricDescriptor{
-				Name:        fmt.Sprintf("k8s.%s.desired", resource),
-				Description: fmt.Sprintf("Number of desired pods in this %s", resource),
-				Type:        metricspb.MetricDescriptor_GAUGE_INT64,
-				Unit:        "1",
-			},
-			Timeseries: []*metricspb.TimeSeries{utils.GetInt64TimeSeries(int64(desired))},
+			MetricDescriptor: descriptors.desired,
+			Timeseries:       []*metricspb.TimeSeries{utils.GetInt64TimeSeries(int64(desired))},
 		},
 		{
-			MetricDescriptor: &metricspb.MetricDescriptor{
-				Name:        fmt.Sprintf("k8s.%s.available", resource),
-				Description: fmt.Sprintf("Total number of available pods (ready for at least minReadySeconds) targeted by this %s", resource),
-				Type:        metricspb.MetricDescriptor_GAUGE_INT64,
-				Unit:        "1",
-			},
-			Timeseries: []*metricspb.TimeSeries{utils.GetInt64TimeSeries(int64(available))},
+			MetricDescriptor: descriptors.available,
+			Timeseries:       []*metricspb.TimeSeries{utils.GetInt64TimeSeries(int64(available))},
 		},
 	}
 }
@@ -15,29 +15,65 @@
 package collection
 
 import (
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
 	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
 	"go.opentelemetry.io/collector/translator/conventions"
 	corev1 "k8s.io/api/core/v1"
 
 	metadata "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/experimentalmetricmetadata"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
 )
 
+var replicationControllerReadyMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.replication_controller.ready",
+	Description: "Number of ready pods targeted by this replication_controller",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var replicationControllerCurrentMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.replication_controller.current",
+	Description: "Number of pods created by this replication_controller, ready or not",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var replicationControllerFullyLabeledMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.replication_controller.fully_labeled",
+	Description: "Number of pods of this replication_controller with the labels of its pod template",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
 func getMetricsForReplicationController(rc *corev1.ReplicationController) []*resourceMetrics {
 	if rc.Spec.Replicas == nil {
 		return nil
 	}
 
+	metrics := getReplicaMetrics("replication_controller", *rc.Spec.Replicas, rc.Status.AvailableReplicas)
+	metrics = append(metrics,
+		&metricspb.Metric{
+			MetricDescriptor: replicationControllerReadyMetric,
+			Timeseries:       []*metricspb.TimeSeries{utils.GetInt64TimeSeries(int64(rc.Status.ReadyReplicas))},
+		},
+		&metricspb.Metric{
+			MetricDescriptor: replicationControllerCurrentMetric,
+			Timeseries:       []*metricspb.TimeSeries{utils.GetInt64TimeSeries(int64(rc.Status.Replicas))},
+		},
+		&metricspb.Metric{
+			MetricDescriptor: replicationControllerFullyLabeledMetric,
+			Timeseries: []*metricspb.TimeSeries{
+				utils.GetInt64TimeSeries(int64(rc.Status.FullyLabeledReplicas)),
+			},
+		},
+	)
+
 	return []*resourceMetrics{
 		{
 			resource: getResourceForReplicationController(rc),
-			metrics: getReplicaMetrics(
-				"replication_controller",
-				*rc.Spec.Replicas,
-				rc.Status.AvailableReplicas,
-			),
+			metrics:  metrics,
 		},
 	}
-
 }
 
 func getResourceForReplicationController(rc *corev1.ReplicationController) *resourcepb.Resource {
@@ -0,0 +1,71 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/testutils"
+)
+
+func TestReplicationControllerMetrics(t *testing.T) {
+	replicas := int32(3)
+	rc := &corev1.ReplicationController{
+		ObjectMeta: v1.ObjectMeta{
+			Name: "rc", Namespace: "test-namespace", UID: types.UID("rc-uid"), ClusterName: "test-cluster",
+		},
+		Spec: corev1.ReplicationControllerSpec{Replicas: &replicas},
+		Status: corev1.ReplicationControllerStatus{
+			Replicas:             3,
+			FullyLabeledReplicas: 3,
+			ReadyReplicas:        2,
+			AvailableReplicas:    1,
+		},
+	}
+
+	rms := getMetricsForReplicationController(rc)
+	require.Equal(t, 1, len(rms))
+	testutils.AssertResource(t, rms[0].resource, k8sType,
+		map[string]string{
+			"k8s.replicationcontroller.uid":  "rc-uid",
+			"k8s.replicationcontroller.name": "rc",
+			"k8s.namespace.name":             "test-namespace",
+			"k8s.cluster.name":               "test-cluster",
+		},
+	)
+	require.Equal(t, 5, len(rms[0].metrics))
+	for i, want := range []struct {
+		name  string
+		value int64
+	}{
+		{"k8s.replication_controller.desired", 3},
+		{"k8s.replication_controller.available", 1},
+		{"k8s.replication_controller.ready", 2},
+		{"k8s.replication_controller.current", 3},
+		{"k8s.replication_controller.fully_labeled", 3},
+	} {
+		testutils.AssertMetrics(t, rms[0].metrics[i], want.name, metricspb.MetricDescriptor_GAUGE_INT64, want.value)
+	}
+
+	// Nothing is reported without a desired number of replicas.
+	rc.Spec.Replicas = nil
+	require.Nil(t, getMetricsForReplicationController(rc))
+}
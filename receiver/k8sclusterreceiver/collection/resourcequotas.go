@@ -15,17 +15,19 @@
 package collection
 
 import (
+	"sort"
 	"strings"
 
 	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
 	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
 	"go.opentelemetry.io/collector/translator/conventions"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
 )
 
-var resourceQuotaHardLimitMetric = &metricspb.MetricDescriptor{
+var resourceQuotaHardLimitMetric = registerMetric(&metricspb.MetricDescriptor{
 	Name: "k8s.resource_quota.hard_limit",
 	Description: "The upper limit for a particular resource in a specific namespace." +
 		" Will only be sent if a quota is specified. CPU requests/limits will be sent as millicores",
@@ -33,9 +35,9 @@ var resourceQuotaHardLimitMetric = &metricspb.MetricDescriptor{
 	LabelKeys: []*metricspb.LabelKey{{
 		Key: "resource",
 	}},
-}
+})
 
-var resourceQuotaUsedMetric = &metricspb.MetricDescriptor{
+var resourceQuotaUsedMetric = registerMetric(&metricspb.MetricDescriptor{
 	Name: "k8s.resource_quota.used",
 	Description: "The usage for a particular resource in a specific namespace." +
 		" Will only be sent if a quota is specified. CPU requests/limits will be sent as millicores",
@@ -43,7 +45,30 @@ var resourceQuotaUsedMetric = &metricspb.MetricDescriptor{
 	LabelKeys: []*metricspb.LabelKey{{
 		Key: "resource",
 	}},
-}
+})
+
+var resourceQuotaUtilizationRatioMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name: "k8s.resource_quota.utilization_ratio",
+	Description: "The usage of a particular resource in a specific namespace relative to its upper limit." +
+		" Will only be sent if a non-zero quota is specified",
+	Unit: "1",
+	Type: metricspb.MetricDescriptor_GAUGE_DOUBLE,
+	LabelKeys: []*metricspb.LabelKey{{
+		Key: "resource",
+	}},
+})
+
+var clusterResourceQuotasOverThresholdMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name: "k8s.cluster.resource_quotas_over_threshold",
+	Description: "Number of ResourceQuotas of which the utilization ratio of at least one resource " +
+		"is above the configured threshold",
+	Unit: "1",
+	Type: metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+// DefaultResourceQuotaThreshold is the default utilization ratio above which
+// a ResourceQuota is counted as approaching exhaustion.
+const DefaultResourceQuotaThreshold = 0.9
 
 func getMetricsForResourceQuota(rq *corev1.ResourceQuota) []*resourceMetrics {
 	metrics := make([]*metricspb.Metric, 0)
@@ -62,23 +87,33 @@ func getMetricsForResourceQuota(rq *corev1.ResourceQuota) []*resourceMetrics {
 		},
 	} {
 		for k, v := range t.rl {
-
-			val := v.Value()
-			if strings.HasSuffix(string(k), ".cpu") {
-				val = v.MilliValue()
-			}
-
 			metrics = append(metrics,
 				&metricspb.Metric{
 					MetricDescriptor: t.metric,
 					Timeseries: []*metricspb.TimeSeries{
-						utils.GetInt64TimeSeriesWithLabels(val, []*metricspb.LabelValue{{Value: string(k)}}),
+						utils.GetInt64TimeSeriesWithLabels(quotaValue(k, v), []*metricspb.LabelValue{{Value: string(k)}}),
 					},
 				},
 			)
 		}
 	}
 
+	ratios := getResourceQuotaUtilizationRatios(rq)
+	names := make([]string, 0, len(ratios))
+	for name := range ratios {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		metrics = append(metrics, &metricspb.Metric{
+			MetricDescriptor: resourceQuotaUtilizationRatioMetric,
+			Timeseries: []*metricspb.TimeSeries{
+				utils.GetDoubleTimeSeriesWithLabels(ratios[corev1.ResourceName(name)],
+					[]*metricspb.LabelValue{{Value: name}}),
+			},
+		})
+	}
+
 	return []*resourceMetrics{
 		{
 			resource: getResourceForResourceQuota(rq),
@@ -87,14 +122,111 @@ func getMetricsForResourceQuota(rq *corev1.ResourceQuota) []*resourceMetrics {
 	}
 }
 
+// quotaValue returns the value of a quota for the resource, in millicores for
+// CPU requests/limits.
+func quotaValue(name corev1.ResourceName, q resource.Quantity) int64 {
+	if strings.HasSuffix(string(name), ".cpu") {
+		return q.MilliValue()
+	}
+	return q.Value()
+}
+
 func getResourceForResourceQuota(rq *corev1.ResourceQuota) *resourcepb.Resource {
+	labels := map[string]string{
+		k8sKeyResourceQuotaUID:            string(rq.UID),
+		k8sKeyResourceQuotaName:           rq.Name,
+		conventions.AttributeK8sNamespace: rq.Namespace,
+		conventions.AttributeK8sCluster:   rq.ClusterName,
+	}
+	scopes, priorityClasses := getResourceQuotaScopes(rq)
+	if len(scopes) > 0 {
+		labels[k8sKeyResourceQuotaScopes] = strings.Join(scopes, ",")
+	}
+	if len(priorityClasses) > 0 {
+		labels[k8sKeyResourceQuotaPriorityClasses] = strings.Join(priorityClasses, ",")
+	}
 	return &resourcepb.Resource{
-		Type: k8sType,
-		Labels: map[string]string{
-			k8sKeyResourceQuotaUID:            string(rq.UID),
-			k8sKeyResourceQuotaName:           rq.Name,
-			conventions.AttributeK8sNamespace: rq.Namespace,
-			conventions.AttributeK8sCluster:   rq.ClusterName,
+		Type:   k8sType,
+		Labels: labels,
+	}
+}
+
+// getResourceQuotaScopes returns the sorted scopes of the quota, e.g.
+// BestEffort or PriorityClass, set by scopes or by the scope selector, and
+// the sorted priority classes the quota is limited to by the scope selector.
+func getResourceQuotaScopes(rq *corev1.ResourceQuota) (scopes []string, priorityClasses []string) {
+	seen := map[string]bool{}
+	add := func(scope corev1.ResourceQuotaScope) {
+		if !seen[string(scope)] {
+			seen[string(scope)] = true
+			scopes = append(scopes, string(scope))
+		}
+	}
+	for _, scope := range rq.Spec.Scopes {
+		add(scope)
+	}
+	if rq.Spec.ScopeSelector != nil {
+		for _, e := range rq.Spec.ScopeSelector.MatchExpressions {
+			add(e.ScopeName)
+			if e.ScopeName == corev1.ResourceQuotaScopePriorityClass && e.Operator == corev1.ScopeSelectorOpIn {
+				priorityClasses = append(priorityClasses, e.Values...)
+			}
+		}
+	}
+	sort.Strings(scopes)
+	sort.Strings(priorityClasses)
+	return scopes, priorityClasses
+}
+
+// getResourceQuotaUtilizationRatios returns the used amount of every resource
+// of the quota relative to its hard limit. Resources with a hard limit of 0
+// are skipped, as are resources without usage reported yet.
+func getResourceQuotaUtilizationRatios(rq *corev1.ResourceQuota) map[corev1.ResourceName]float64 {
+	ratios := map[corev1.ResourceName]float64{}
+	for name, hard := range rq.Status.Hard {
+		used, ok := rq.Status.Used[name]
+		if !ok || hard.IsZero() {
+			continue
+		}
+		ratios[name] = float64(used.MilliValue()) / float64(hard.MilliValue())
+	}
+	return ratios
+}
+
+// getResourceQuotaThresholdMetricsForCluster returns the number of
+// ResourceQuotas of which at least one resource is used above the threshold
+// ratio of its hard limit. Nothing is reported unless ResourceQuotas are
+// watched.
+func getResourceQuotaThresholdMetricsForCluster(ms *metadataStore, threshold float64) []*resourceMetrics {
+	if ms.resourceQuotas == nil {
+		return nil
+	}
+
+	var count int64
+	for _, obj := range ms.resourceQuotas.List() {
+		rq, ok := obj.(*corev1.ResourceQuota)
+		if !ok {
+			continue
+		}
+		for _, ratio := range getResourceQuotaUtilizationRatios(rq) {
+			if ratio > threshold {
+				count++
+				break
+			}
+		}
+	}
+
+	return []*resourceMetrics{
+		{
+			resource: getResourceForCluster(),
+			metrics: []*metricspb.Metric{
+				{
+					MetricDescriptor: clusterResourceQuotasOverThresholdMetric,
+					Timeseries: []*metricspb.TimeSeries{
+						utils.GetInt64TimeSeries(count),
+					},
+				},
+			},
 		},
 	}
 }
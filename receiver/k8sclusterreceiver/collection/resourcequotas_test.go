@@ -34,7 +34,7 @@ func TestRequestQuotaMetrics(t *testing.T) {
 
 	require.Equal(t, 1, len(actualResourceMetrics))
 
-	require.Equal(t, 2, len(actualResourceMetrics[0].metrics))
+	require.Equal(t, 3, len(actualResourceMetrics[0].metrics))
 	testutils.AssertResource(t, actualResourceMetrics[0].resource, k8sType,
 		map[string]string{
 			"k8s.resourcequota.uid":  "test-resourcequota-1-uid",
@@ -49,6 +49,77 @@ func TestRequestQuotaMetrics(t *testing.T) {
 
 	testutils.AssertMetricsWithLabels(t, actualResourceMetrics[0].metrics[1], "k8s.resource_quota.used",
 		metricspb.MetricDescriptor_GAUGE_INT64, map[string]string{"resource": "requests.cpu"}, 1000)
+
+	ratio := actualResourceMetrics[0].metrics[2]
+	require.Equal(t, "k8s.resource_quota.utilization_ratio", ratio.MetricDescriptor.Name)
+	require.Equal(t, "requests.cpu", ratio.Timeseries[0].LabelValues[0].Value)
+	require.Equal(t, 0.5, ratio.Timeseries[0].Points[0].GetDoubleValue())
+}
+
+func TestResourceQuotaUtilization(t *testing.T) {
+	nearlyExhausted := newResourceQuota("1")
+	nearlyExhausted.Status = corev1.ResourceQuotaStatus{
+		Hard: corev1.ResourceList{
+			"requests.memory": resource.MustParse("10Gi"),
+			"pods":            resource.MustParse("20"),
+			"services":        resource.MustParse("0"),
+		},
+		Used: corev1.ResourceList{
+			"requests.memory": resource.MustParse("9728Mi"),
+			"pods":            resource.MustParse("2"),
+			"services":        resource.MustParse("0"),
+		},
+	}
+	h := newTestHarness(t, nil)
+	h.seed(nearlyExhausted, newResourceQuota("2"))
+
+	ratios := map[string]float64{}
+	for _, m := range h.metrics("k8s.resource_quota.utilization_ratio",
+		map[string]string{"k8s.resourcequota.uid": "test-resourcequota-1-uid"}) {
+		ts := m.metric.Timeseries[0]
+		ratios[ts.LabelValues[0].Value] = ts.Points[0].GetDoubleValue()
+	}
+	// Resources with a hard limit of 0 are skipped.
+	require.Equal(t, map[string]float64{"requests.memory": 0.95, "pods": 0.1}, ratios)
+
+	h.requireInt64Value("k8s.cluster.resource_quotas_over_threshold", map[string]string{}, 1)
+
+	h = newTestHarness(t, nil, WithResourceQuotaThreshold(0.4))
+	h.seed(nearlyExhausted, newResourceQuota("2"))
+	h.requireInt64Value("k8s.cluster.resource_quotas_over_threshold", map[string]string{}, 2)
+}
+
+func TestResourceQuotaScopes(t *testing.T) {
+	rq := newResourceQuota("1")
+	rq.Spec = corev1.ResourceQuotaSpec{
+		Scopes: []corev1.ResourceQuotaScope{corev1.ResourceQuotaScopeNotTerminating},
+		ScopeSelector: &corev1.ScopeSelector{
+			MatchExpressions: []corev1.ScopedResourceSelectorRequirement{
+				{
+					ScopeName: corev1.ResourceQuotaScopePriorityClass,
+					Operator:  corev1.ScopeSelectorOpIn,
+					Values:    []string{"high", "critical"},
+				},
+				{
+					ScopeName: corev1.ResourceQuotaScopeNotTerminating,
+					Operator:  corev1.ScopeSelectorOpExists,
+				},
+			},
+		},
+	}
+
+	actualResourceMetrics := getMetricsForResourceQuota(rq)
+	require.Equal(t, 1, len(actualResourceMetrics))
+	testutils.AssertResource(t, actualResourceMetrics[0].resource, k8sType,
+		map[string]string{
+			"k8s.resourcequota.uid":              "test-resourcequota-1-uid",
+			"k8s.resourcequota.name":             "test-resourcequota-1",
+			"k8s.resourcequota.scopes":           "NotTerminating,PriorityClass",
+			"k8s.resourcequota.priority_classes": "critical,high",
+			"k8s.namespace.name":                 "test-namespace",
+			"k8s.cluster.name":                   "test-cluster",
+		},
+	)
 }
 
 func newResourceQuota(id string) *corev1.ResourceQuota {
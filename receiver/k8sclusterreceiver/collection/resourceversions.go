@@ -0,0 +1,93 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
+)
+
+var clusterLastResourceVersionMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name: "k8s.cluster.last_resource_version",
+	Description: "Last resource version observed by the informer of a kind, which keeps " +
+		"increasing as long as its watch is progressing",
+	Unit:      "1",
+	Type:      metricspb.MetricDescriptor_GAUGE_INT64,
+	LabelKeys: []*metricspb.LabelKey{{Key: "kind"}},
+})
+
+// resourceVersionSources returns the last resource version observed by the
+// informers of the receiver, keyed by kind.
+type resourceVersionSources struct {
+	sync.Mutex
+	kinds map[string]func() string
+}
+
+// SetupResourceVersionSource sets the function returning the last resource
+// version observed by the informer of the given kind, e.g. the
+// LastSyncResourceVersion of a SharedIndexInformer.
+func (dc *DataCollector) SetupResourceVersionSource(kind string, lastResourceVersion func() string) {
+	dc.resourceVersionSources.Lock()
+	defer dc.resourceVersionSources.Unlock()
+	dc.resourceVersionSources.kinds[kind] = lastResourceVersion
+}
+
+// getLastResourceVersionMetricsForCluster returns the last resource version
+// observed per kind. Resource versions are opaque strings, only reported if
+// they can be parsed as integers, as they can for the API server backed by
+// etcd. Nothing is reported if none can.
+func getLastResourceVersionMetricsForCluster(s *resourceVersionSources) []*resourceMetrics {
+	s.Lock()
+	defer s.Unlock()
+
+	versions := make(map[string]int64, len(s.kinds))
+	kinds := make([]string, 0, len(s.kinds))
+	for kind, lastResourceVersion := range s.kinds {
+		v, err := strconv.ParseInt(lastResourceVersion(), 10, 64)
+		if err != nil {
+			continue
+		}
+		versions[kind] = v
+		kinds = append(kinds, kind)
+	}
+	if len(kinds) == 0 {
+		return nil
+	}
+	sort.Strings(kinds)
+
+	series := make([]*metricspb.TimeSeries, 0, len(kinds))
+	for _, kind := range kinds {
+		series = append(series, utils.GetInt64TimeSeriesWithLabels(
+			versions[kind], []*metricspb.LabelValue{{Value: kind, HasValue: true}},
+		))
+	}
+
+	return []*resourceMetrics{
+		{
+			resource: getResourceForCluster(),
+			metrics: []*metricspb.Metric{
+				{
+					MetricDescriptor: clusterLastResourceVersionMetric,
+					Timeseries:       series,
+				},
+			},
+		},
+	}
+}
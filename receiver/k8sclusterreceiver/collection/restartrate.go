@@ -0,0 +1,111 @@
+// Copyright 2020 OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"sort"
+	"sync"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
+)
+
+var containerRestartRateMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.container.restart_rate",
+	Description: "Number of times the container has restarted since the previous collection",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+// restartKey identifies a container across its restarts, which change its ID.
+type restartKey struct {
+	pod       types.UID
+	container string
+}
+
+// restartCounts tracks the restart counts of containers between collections.
+type restartCounts struct {
+	sync.Mutex
+	// last are the restart counts at the previous collection.
+	last map[restartKey]int32
+}
+
+// getRestartRateMetricsForContainers returns the number of restarts of each
+// container of the reported pods since the previous call, and remembers the
+// current restart counts for the next one. Containers are only reported from
+// the second call they are seen in on, since there is nothing to compare their
+// first restart count to. A restart count lower than the previous one, e.g.
+// after the kubelet pruned dead containers, is taken as all new restarts.
+func getRestartRateMetricsForContainers(rc *restartCounts, pods cache.Store,
+	reported func(*corev1.Pod) bool, extractionRules FieldExtractionRules) []*resourceMetrics {
+	if pods == nil {
+		return nil
+	}
+
+	rc.Lock()
+	defer rc.Unlock()
+
+	var reportedPods []*corev1.Pod
+	for _, obj := range pods.List() {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok || !reported(pod) {
+			continue
+		}
+		reportedPods = append(reportedPods, pod)
+	}
+	sort.Slice(reportedPods, func(i, j int) bool {
+		return reportedPods[i].UID < reportedPods[j].UID
+	})
+
+	current := make(map[restartKey]int32, len(rc.last))
+	var out []*resourceMetrics
+	for _, pod := range reportedPods {
+		podRes := getResourceForPod(pod, extractionRules)
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.ContainerID == "" {
+				continue
+			}
+			key := restartKey{pod: pod.UID, container: cs.Name}
+			current[key] = cs.RestartCount
+			last, ok := rc.last[key]
+			if !ok {
+				continue
+			}
+			restarts := cs.RestartCount - last
+			if restarts < 0 {
+				restarts = cs.RestartCount
+			}
+
+			out = append(out, &resourceMetrics{
+				resource: getResourceForContainer(getAllContainerLabels(cs, podRes.Labels)),
+				metrics: []*metricspb.Metric{
+					{
+						MetricDescriptor: containerRestartRateMetric,
+						Timeseries: []*metricspb.TimeSeries{
+							utils.GetInt64TimeSeries(int64(restarts)),
+						},
+					},
+				},
+			})
+		}
+	}
+	// Containers no longer seen are forgotten.
+	rc.last = current
+	return out
+}
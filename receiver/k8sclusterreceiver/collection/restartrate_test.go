@@ -0,0 +1,55 @@
+// Copyright 2020 OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestContainerRestartRateMetric(t *testing.T) {
+	h := newTestHarness(t, nil, WithContainerRestartRate(true))
+	pod := newPodWithContainer("0", &corev1.PodSpec{}, &corev1.PodStatus{
+		ContainerStatuses: []corev1.ContainerStatus{
+			{Name: "app", ContainerID: "docker://app-1", RestartCount: 2},
+		},
+	})
+	app := map[string]string{"k8s.pod.uid": string(pod.UID), "k8s.container.name": "app"}
+	h.seed(pod)
+
+	// Every collection, including those of the harness assertions, compares
+	// the restart counts to the previous one, of which there is none at first.
+	h.requireNoMetric("k8s.container.restart_rate", app)
+	h.requireInt64Value("k8s.container.restart_rate", app, 0)
+
+	// Restarts change the ID of the container, but not its name.
+	pod.Status.ContainerStatuses[0].ContainerID = "docker://app-2"
+	pod.Status.ContainerStatuses[0].RestartCount = 5
+	h.seed(pod)
+	h.requireInt64Value("k8s.container.restart_rate", app, 3)
+	h.requireInt64Value("k8s.container.restart_rate", app, 0)
+
+	// A reset restart count is taken as all new restarts.
+	pod.Status.ContainerStatuses[0].RestartCount = 1
+	h.seed(pod)
+	h.requireInt64Value("k8s.container.restart_rate", app, 1)
+
+	// Containers are forgotten along with their pod.
+	h.remove(pod)
+	h.requireNoMetric("k8s.container.restart_rate", app)
+	h.seed(pod)
+	h.requireNoMetric("k8s.container.restart_rate", app)
+}
@@ -0,0 +1,67 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"hash/fnv"
+	"strings"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// SamplingRule selects the subset of the objects of a kind that is collected.
+// Objects are collected if they are kept by both the rate and the selector.
+type SamplingRule struct {
+	// Rate keeps 1 in Rate objects. Objects are chosen by hashing their UID,
+	// so that an object is either always or never kept. Rates of 0 and 1
+	// keep all objects.
+	Rate int
+	// Selector keeps the objects whose labels match it. A nil selector keeps
+	// all objects.
+	Selector labels.Selector
+}
+
+// keeps returns true if the rule keeps the object.
+func (r SamplingRule) keeps(obj v1.Object) bool {
+	if r.Selector != nil && !r.Selector.Matches(labels.Set(obj.GetLabels())) {
+		return false
+	}
+	if r.Rate <= 1 {
+		return true
+	}
+
+	key := string(obj.GetUID())
+	if key == "" {
+		key = obj.GetNamespace() + "/" + obj.GetName()
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return h.Sum32()%uint32(r.Rate) == 0
+}
+
+// IsSampled returns false if the object is left out by the sampling rule of
+// its kind, in which case it should be neither synced nor cached.
+func (dc *DataCollector) IsSampled(obj interface{}) bool {
+	if len(dc.samplingRules) == 0 {
+		return true
+	}
+	rule, ok := dc.samplingRules[strings.ToLower(getObjectKind(obj))]
+	if !ok {
+		return true
+	}
+	o, ok := obj.(v1.Object)
+	return !ok || rule.keeps(o)
+}
@@ -0,0 +1,65 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestSamplingRuleRate(t *testing.T) {
+	const numObjects = 10000
+	for _, rate := range []int{2, 10, 100} {
+		t.Run(fmt.Sprintf("rate=%d", rate), func(t *testing.T) {
+			rule := SamplingRule{Rate: rate}
+			kept := 0
+			for i := 0; i < numObjects; i++ {
+				job := &batchv1.Job{ObjectMeta: v1.ObjectMeta{UID: types.UID(fmt.Sprintf("job-%d-uid", i))}}
+				if rule.keeps(job) {
+					kept++
+				}
+				// An object is consistently kept or not.
+				require.Equal(t, rule.keeps(job), rule.keeps(job.DeepCopy()))
+			}
+
+			expected := numObjects / rate
+			require.InDelta(t, expected, kept, float64(expected)*0.2)
+		})
+	}
+}
+
+func TestDataCollectorIsSampled(t *testing.T) {
+	dc := NewDataCollector(zap.NewNop(), nil, WithSampling(map[string]SamplingRule{
+		"Pod": {Selector: labels.SelectorFromSet(labels.Set{"keep": "true"})},
+		"job": {Rate: 1},
+	}))
+
+	kept := &corev1.Pod{ObjectMeta: v1.ObjectMeta{UID: "kept-uid", Labels: map[string]string{"keep": "true"}}}
+	dropped := &corev1.Pod{ObjectMeta: v1.ObjectMeta{UID: "dropped-uid", Labels: map[string]string{"keep": "false"}}}
+	require.True(t, dc.IsSampled(kept))
+	require.False(t, dc.IsSampled(dropped))
+
+	// A rate of 1 keeps all objects, as do kinds without a rule.
+	require.True(t, dc.IsSampled(&batchv1.Job{ObjectMeta: v1.ObjectMeta{UID: "job-uid"}}))
+	require.True(t, dc.IsSampled(&corev1.Node{ObjectMeta: v1.ObjectMeta{UID: "node-uid"}}))
+}
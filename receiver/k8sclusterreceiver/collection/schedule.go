@@ -0,0 +1,87 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// collectionSchedule tracks when the cached metrics of each kind are next due
+// for collection, when kinds are collected at intervals of their own. Kinds
+// without an interval of their own, as well as the metrics computed across
+// objects, are collected at the default interval.
+type collectionSchedule struct {
+	sync.Mutex
+	defaultInterval time.Duration
+	// intervals are the collection intervals keyed by lower-cased kind.
+	intervals map[string]time.Duration
+	// tolerance is how early a kind can be collected, so that collections
+	// running slightly early are not postponed by a whole tick.
+	tolerance time.Duration
+	// next is when each kind is next due, keyed by lower-cased kind, or by
+	// the empty string for the default interval.
+	next map[string]time.Time
+}
+
+func newCollectionSchedule(defaultInterval time.Duration, intervals map[string]time.Duration) *collectionSchedule {
+	s := &collectionSchedule{
+		defaultInterval: defaultInterval,
+		intervals:       make(map[string]time.Duration, len(intervals)),
+		next:            map[string]time.Time{},
+	}
+	tick := defaultInterval
+	for kind, interval := range intervals {
+		s.intervals[strings.ToLower(kind)] = interval
+		if interval < tick {
+			tick = interval
+		}
+	}
+	s.tolerance = tick / 2
+	return s
+}
+
+// due returns whether the metrics of a kind are collected at currentTime,
+// the empty kind standing for the default interval, and schedules the next
+// collection of the kinds that are. Everything is due when s is nil.
+func (s *collectionSchedule) due(currentTime time.Time) func(kind string) bool {
+	if s == nil {
+		return func(string) bool { return true }
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	due := map[string]bool{}
+	schedule := func(kind string, interval time.Duration) {
+		if next, ok := s.next[kind]; ok && currentTime.Before(next.Add(-s.tolerance)) {
+			return
+		}
+		due[kind] = true
+		s.next[kind] = currentTime.Add(interval)
+	}
+	schedule("", s.defaultInterval)
+	for kind, interval := range s.intervals {
+		schedule(kind, interval)
+	}
+
+	return func(kind string) bool {
+		kind = strings.ToLower(kind)
+		if _, ok := s.intervals[kind]; !ok {
+			kind = ""
+		}
+		return due[kind]
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestCollectionScheduleDue(t *testing.T) {
+	s := newCollectionSchedule(15*time.Second, map[string]time.Duration{"Node": time.Minute})
+	now := time.Now()
+
+	due := s.due(now)
+	require.True(t, due(""))
+	require.True(t, due("Pod"))
+	require.True(t, due("Node"))
+
+	// Collections running slightly early are not postponed.
+	due = s.due(now.Add(14 * time.Second))
+	require.True(t, due("Pod"))
+	require.False(t, due("Node"))
+
+	due = s.due(now.Add(29 * time.Second))
+	require.True(t, due("Pod"))
+	require.False(t, due("node"))
+
+	due = s.due(now.Add(time.Minute))
+	require.True(t, due(""))
+	require.True(t, due("Node"))
+
+	var nilSchedule *collectionSchedule
+	require.True(t, nilSchedule.due(now)("Node"))
+}
+
+func TestDataCollectorCollectionIntervals(t *testing.T) {
+	h := newTestHarness(t, []string{"Ready"},
+		WithCollectionIntervals(15*time.Second, map[string]time.Duration{"node": time.Minute}))
+	h.seed(
+		newNodeWithResources("node-1", corev1.ConditionTrue, "4", "8Gi"),
+		newPodWithContainer("1", podSpecWithContainer("container-name"), podStatusWithContainer("container-name", containerIDWithPreifx("container-id"))),
+	)
+
+	collected := func() map[string]bool {
+		names := map[string]bool{}
+		for _, md := range h.collect() {
+			for _, m := range md.Metrics {
+				names[m.MetricDescriptor.Name] = true
+			}
+		}
+		return names
+	}
+	requireCollected := func(names map[string]bool, node bool) {
+		require.True(t, names["k8s.pod.phase"])
+		// Metrics computed across objects follow collection_interval.
+		require.True(t, names["k8s.cluster.pending_pods"])
+		require.Equal(t, node, names["k8s.node.condition_ready"])
+	}
+
+	requireCollected(collected(), true)
+	for i := 0; i < 3; i++ {
+		h.advance(15 * time.Second)
+		requireCollected(collected(), false)
+	}
+	h.advance(15 * time.Second)
+	requireCollected(collected(), true)
+}
@@ -0,0 +1,106 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"go.opentelemetry.io/collector/translator/conventions"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
+)
+
+var containerPrivilegedMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.container.privileged",
+	Description: "Whether the container runs privileged (1) or not (0)",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var containerRunAsRootMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name: "k8s.container.run_as_root",
+	Description: "Whether the container may run as root (1) or not (0), as set by its " +
+		"security context or the one of its pod",
+	Unit: "1",
+	Type: metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+// addSecurityContextMetrics adds the security context metrics of the
+// containers of the pod to their resource metrics, as returned by
+// getMetricsForPod.
+func addSecurityContextMetrics(pod *corev1.Pod, rms []*resourceMetrics) {
+	containers := make(map[string]corev1.Container, len(pod.Spec.Containers))
+	for _, c := range pod.Spec.Containers {
+		containers[c.Name] = c
+	}
+	for _, rm := range rms {
+		name, ok := rm.resource.Labels[conventions.AttributeK8sContainer]
+		if !ok {
+			continue
+		}
+		if c, ok := containers[name]; ok {
+			rm.metrics = append(rm.metrics, getSecurityContextMetricsForContainer(pod, c)...)
+		}
+	}
+}
+
+// getSecurityContextMetricsForContainer returns whether the container runs
+// privileged and whether it may run as root. Settings of the security context
+// of the container take precedence over those of the pod, like they do for
+// the kubelet.
+func getSecurityContextMetricsForContainer(pod *corev1.Pod, c corev1.Container) []*metricspb.Metric {
+	privileged := c.SecurityContext != nil && c.SecurityContext.Privileged != nil && *c.SecurityContext.Privileged
+
+	return []*metricspb.Metric{
+		{
+			MetricDescriptor: containerPrivilegedMetric,
+			Timeseries: []*metricspb.TimeSeries{
+				utils.GetInt64TimeSeries(boolToInt64(privileged)),
+			},
+		},
+		{
+			MetricDescriptor: containerRunAsRootMetric,
+			Timeseries: []*metricspb.TimeSeries{
+				utils.GetInt64TimeSeries(boolToInt64(mayRunAsRoot(pod.Spec.SecurityContext, c.SecurityContext))),
+			},
+		},
+	}
+}
+
+// mayRunAsRoot returns whether a container with the given security contexts
+// may run as root. Containers required to run as non-root are refused to
+// start by the kubelet otherwise. Without a user set, containers run as the
+// user of their image, which cannot be told from the spec and is root unless
+// the image sets another one, so they are considered to possibly run as root.
+func mayRunAsRoot(podSC *corev1.PodSecurityContext, sc *corev1.SecurityContext) bool {
+	var runAsUser *int64
+	var runAsNonRoot *bool
+	if podSC != nil {
+		runAsUser, runAsNonRoot = podSC.RunAsUser, podSC.RunAsNonRoot
+	}
+	if sc != nil {
+		if sc.RunAsUser != nil {
+			runAsUser = sc.RunAsUser
+		}
+		if sc.RunAsNonRoot != nil {
+			runAsNonRoot = sc.RunAsNonRoot
+		}
+	}
+
+	if runAsNonRoot != nil && *runAsNonRoot {
+		return false
+	}
+	return runAsUser == nil || *runAsUser == 0
+}
@@ -0,0 +1,86 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestContainerSecurityContextMetrics(t *testing.T) {
+	boolPtr := func(b bool) *bool { return &b }
+	int64Ptr := func(i int64) *int64 { return &i }
+
+	pod := &corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{Name: "pod", Namespace: "test-namespace", UID: types.UID("pod-uid")},
+		Spec: corev1.PodSpec{
+			// Containers run as a non-root user unless they override it.
+			SecurityContext: &corev1.PodSecurityContext{RunAsUser: int64Ptr(1000)},
+			Containers: []corev1.Container{
+				{
+					Name: "privileged",
+					SecurityContext: &corev1.SecurityContext{
+						Privileged: boolPtr(true),
+						RunAsUser:  int64Ptr(0),
+					},
+				},
+				{
+					Name:            "constrained",
+					SecurityContext: &corev1.SecurityContext{Privileged: boolPtr(false)},
+				},
+				{
+					Name:            "non-root",
+					SecurityContext: &corev1.SecurityContext{RunAsUser: int64Ptr(0), RunAsNonRoot: boolPtr(true)},
+				},
+			},
+		},
+	}
+	for _, c := range pod.Spec.Containers {
+		pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, corev1.ContainerStatus{
+			Name:        c.Name,
+			ContainerID: "containerd://" + c.Name,
+		})
+	}
+	// Without a user set anywhere, containers run as the user of their image.
+	unset := pod.DeepCopy()
+	unset.Name, unset.UID = "unset", types.UID("unset-uid")
+	unset.Spec.SecurityContext = nil
+
+	h := newTestHarness(t, nil, WithSecurityContext(true))
+	h.seed(pod, unset)
+
+	for _, tt := range []struct {
+		pod, container     string
+		privileged, asRoot int64
+	}{
+		{"pod", "privileged", 1, 1},
+		{"pod", "constrained", 0, 0},
+		{"pod", "non-root", 0, 0},
+		{"unset", "constrained", 0, 1},
+	} {
+		container := map[string]string{"k8s.pod.name": tt.pod, "k8s.container.name": tt.container}
+		h.requireInt64Value("k8s.container.privileged", container, tt.privileged)
+		h.requireInt64Value("k8s.container.run_as_root", container, tt.asRoot)
+	}
+
+	// The metrics are only reported if enabled.
+	h = newTestHarness(t, nil)
+	h.seed(pod)
+	h.requireNoMetric("k8s.container.privileged", nil)
+	h.requireNoMetric("k8s.container.run_as_root", nil)
+}
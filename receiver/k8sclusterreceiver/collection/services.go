@@ -0,0 +1,120 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"strconv"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
+)
+
+var serviceTypeMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.service.type",
+	Description: "Type of the service (1 - ClusterIP, 2 - NodePort, 3 - LoadBalancer, 4 - ExternalName)",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var serviceClusterIPMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.service.cluster_ip",
+	Description: "Whether the service has a cluster IP (1) or not (0), e.g. for headless services",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var servicePortMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.service.port",
+	Description: "Port exposed by the service, with the port of its pods it targets and its node port, if any",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+	LabelKeys: []*metricspb.LabelKey{
+		{Key: "port_name"}, {Key: "protocol"}, {Key: "target_port"}, {Key: "node_port"},
+	},
+})
+
+var serviceLoadBalancerIngressMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name: "k8s.service.load_balancer_ingress",
+	Description: "Number of ingress points of the load balancer of the service. " +
+		"Will only be sent for LoadBalancer services",
+	Unit: "1",
+	Type: metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var serviceTypeValues = map[corev1.ServiceType]int64{
+	corev1.ServiceTypeClusterIP:    1,
+	corev1.ServiceTypeNodePort:     2,
+	corev1.ServiceTypeLoadBalancer: 3,
+	corev1.ServiceTypeExternalName: 4,
+}
+
+func getMetricsForService(svc *corev1.Service) []*resourceMetrics {
+	// The API defaults the type to ClusterIP.
+	typ := svc.Spec.Type
+	if typ == "" {
+		typ = corev1.ServiceTypeClusterIP
+	}
+	hasClusterIP := svc.Spec.ClusterIP != "" && svc.Spec.ClusterIP != corev1.ClusterIPNone
+
+	metrics := []*metricspb.Metric{
+		{
+			MetricDescriptor: serviceTypeMetric,
+			Timeseries:       []*metricspb.TimeSeries{utils.GetInt64TimeSeries(serviceTypeValues[typ])},
+		},
+		{
+			MetricDescriptor: serviceClusterIPMetric,
+			Timeseries:       []*metricspb.TimeSeries{utils.GetInt64TimeSeries(boolToInt64(hasClusterIP))},
+		},
+	}
+
+	if len(svc.Spec.Ports) > 0 {
+		timeseries := make([]*metricspb.TimeSeries, 0, len(svc.Spec.Ports))
+		for _, port := range svc.Spec.Ports {
+			var nodePort string
+			if port.NodePort != 0 {
+				nodePort = strconv.Itoa(int(port.NodePort))
+			}
+			timeseries = append(timeseries, utils.GetInt64TimeSeriesWithLabels(int64(port.Port),
+				[]*metricspb.LabelValue{
+					{Value: port.Name, HasValue: true},
+					{Value: string(port.Protocol), HasValue: true},
+					{Value: port.TargetPort.String(), HasValue: true},
+					{Value: nodePort, HasValue: true},
+				}))
+		}
+		metrics = append(metrics, &metricspb.Metric{
+			MetricDescriptor: servicePortMetric,
+			Timeseries:       timeseries,
+		})
+	}
+
+	if typ == corev1.ServiceTypeLoadBalancer {
+		metrics = append(metrics, &metricspb.Metric{
+			MetricDescriptor: serviceLoadBalancerIngressMetric,
+			Timeseries: []*metricspb.TimeSeries{
+				utils.GetInt64TimeSeries(int64(len(svc.Status.LoadBalancer.Ingress))),
+			},
+		})
+	}
+
+	return []*resourceMetrics{
+		{
+			resource: getResourceForService(svc),
+			metrics:  metrics,
+		},
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/testutils"
+)
+
+func TestServiceMetrics(t *testing.T) {
+	svc := &corev1.Service{
+		ObjectMeta: v1.ObjectMeta{
+			Name: "svc", Namespace: "test-namespace", UID: types.UID("svc-uid"), ClusterName: "test-cluster",
+		},
+		Spec: corev1.ServiceSpec{
+			Type:      corev1.ServiceTypeLoadBalancer,
+			ClusterIP: "10.0.0.1",
+			Ports: []corev1.ServicePort{
+				{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80, TargetPort: intstr.FromString("web"), NodePort: 30080},
+				{Name: "dns", Protocol: corev1.ProtocolUDP, Port: 53, TargetPort: intstr.FromInt(5353)},
+			},
+		},
+		Status: corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{
+			Ingress: []corev1.LoadBalancerIngress{{IP: "192.0.2.1"}, {Hostname: "lb.example.com"}},
+		}},
+	}
+
+	rms := getMetricsForService(svc)
+	require.Equal(t, 1, len(rms))
+	testutils.AssertResource(t, rms[0].resource, k8sType,
+		map[string]string{
+			"k8s.service.uid":    "svc-uid",
+			"k8s.service.name":   "svc",
+			"k8s.namespace.name": "test-namespace",
+			"k8s.cluster.name":   "test-cluster",
+		},
+	)
+	require.Equal(t, 4, len(rms[0].metrics))
+	testutils.AssertMetrics(t, rms[0].metrics[0], "k8s.service.type", metricspb.MetricDescriptor_GAUGE_INT64, 3)
+	testutils.AssertMetrics(t, rms[0].metrics[1], "k8s.service.cluster_ip", metricspb.MetricDescriptor_GAUGE_INT64, 1)
+	ports := rms[0].metrics[2]
+	require.Equal(t, "k8s.service.port", ports.MetricDescriptor.Name)
+	require.Equal(t, 2, len(ports.Timeseries))
+	for i, want := range []struct {
+		port   int64
+		labels []string
+	}{
+		{80, []string{"http", "TCP", "web", "30080"}},
+		{53, []string{"dns", "UDP", "5353", ""}},
+	} {
+		ts := ports.Timeseries[i]
+		require.Equal(t, want.port, ts.Points[0].GetInt64Value())
+		var labels []string
+		for _, lv := range ts.LabelValues {
+			labels = append(labels, lv.Value)
+		}
+		require.Equal(t, want.labels, labels)
+	}
+	testutils.AssertMetrics(t, rms[0].metrics[3], "k8s.service.load_balancer_ingress",
+		metricspb.MetricDescriptor_GAUGE_INT64, 2)
+
+	// Headless services have no cluster IP. The type defaults to ClusterIP
+	// and only LoadBalancer services report their ingress points.
+	svc.Spec = corev1.ServiceSpec{ClusterIP: corev1.ClusterIPNone}
+	rms = getMetricsForService(svc)
+	require.Equal(t, 2, len(rms[0].metrics))
+	testutils.AssertMetrics(t, rms[0].metrics[0], "k8s.service.type", metricspb.MetricDescriptor_GAUGE_INT64, 1)
+	testutils.AssertMetrics(t, rms[0].metrics[1], "k8s.service.cluster_ip", metricspb.MetricDescriptor_GAUGE_INT64, 0)
+}
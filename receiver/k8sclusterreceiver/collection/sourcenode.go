@@ -0,0 +1,66 @@
+// Copyright 2020 OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"os"
+
+	commonpb "github.com/census-instrumentation/opencensus-proto/gen-go/agent/common/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	"go.opentelemetry.io/collector/translator/conventions"
+)
+
+// newSourceNode returns the OpenCensus node identifying the collector the
+// metrics of the given cluster are collected by, i.e. the host and process of
+// the collector, along with the name of the cluster.
+func newSourceNode(clusterName string) *commonpb.Node {
+	// The hostname is left empty if it cannot be determined, the cluster
+	// name still identifies the source.
+	hostname, _ := os.Hostname()
+	return &commonpb.Node{
+		Identifier: &commonpb.ProcessIdentifier{
+			HostName: hostname,
+			Pid:      uint32(os.Getpid()),
+		},
+		ServiceInfo: &commonpb.ServiceInfo{Name: "k8s_cluster"},
+		Attributes: map[string]string{
+			conventions.AttributeK8sCluster: clusterName,
+		},
+	}
+}
+
+// setClusterNameLabels sets the k8s.cluster.name resource label of the given
+// resource metrics to clusterName where it is not set, which it is not by
+// Kubernetes on objects, so that the metrics of several clusters can be told
+// apart. It is expected to be called before resource labels are filtered.
+func setClusterNameLabels(rms []*resourceMetrics, clusterName string) {
+	if clusterName == "" {
+		return
+	}
+	for _, rm := range rms {
+		if rm == nil {
+			continue
+		}
+		if rm.resource == nil {
+			rm.resource = &resourcepb.Resource{Type: k8sType}
+		}
+		if rm.resource.Labels == nil {
+			rm.resource.Labels = map[string]string{}
+		}
+		if rm.resource.Labels[conventions.AttributeK8sCluster] == "" {
+			rm.resource.Labels[conventions.AttributeK8sCluster] = clusterName
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"strings"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/observability"
+)
+
+// EvictStaleObjects evicts the cached metrics of objects that are no longer
+// in the informer cache of their kind, e.g. since the event of their deletion
+// was missed while the informer relisted, which would otherwise be reported
+// forever. Objects of kinds without an informer cache are kept. It returns the
+// number of evicted objects.
+func (dc *DataCollector) EvictStaleObjects() int {
+	dc.objectCounts.Lock()
+	watched := make(map[string]bool, len(dc.objectCounts.stores))
+	live := map[types.UID]bool{}
+	for kind, store := range dc.objectCounts.stores {
+		watched[strings.ToLower(kind)] = true
+		for _, obj := range store.List() {
+			o, ok := obj.(runtime.Object)
+			if !ok {
+				continue
+			}
+			if key, err := dc.metricsStore.getKeyForObject(o); err == nil {
+				live[key] = true
+			}
+		}
+	}
+	dc.objectCounts.Unlock()
+
+	evicted := dc.metricsStore.evictStale(watched, live)
+	counts := map[string]int64{}
+	for key, kind := range evicted {
+		dc.relevantHashes.forget(key)
+		if kind == k8sKindNode {
+			dc.readyTransitions.forget(key)
+		}
+		counts[kind]++
+	}
+	for kind, count := range counts {
+		dc.logger.Info("Evicted metrics of objects no longer in the informer cache",
+			zap.String("kind", kind), zap.Int64("count", count))
+		observability.RecordStaleObjectsEvicted(kind, count)
+	}
+	return len(evicted)
+}
+
+// evictStale removes the objects of the watched lower-cased kinds whose keys
+// are not live from the cache, as remove does, and returns their kinds.
+// Objects already marked as deleted are left for their grace period to
+// elapse.
+func (ms *metricsStore) evictStale(watched map[string]bool, live map[types.UID]bool) map[types.UID]string {
+	ms.Lock()
+	defer ms.Unlock()
+
+	evicted := map[types.UID]string{}
+	for key := range ms.metricsCache {
+		if live[key] {
+			continue
+		}
+		if _, deleted := ms.deleted[key]; deleted {
+			continue
+		}
+		kind, ok := ms.kinds[key]
+		if !ok || !watched[strings.ToLower(kind)] {
+			continue
+		}
+		ms.removeKey(key, strings.ToLower(kind))
+		evicted[key] = kind
+	}
+	return evicted
+}
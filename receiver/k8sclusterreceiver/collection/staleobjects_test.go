@@ -0,0 +1,60 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestEvictStaleObjects(t *testing.T) {
+	h := newTestHarness(t, []string{"Ready"})
+	pods := []*corev1.Pod{newPendingPod("pod-1", "node-1"), newPendingPod("pod-2", "node-1")}
+	h.seed(pods[0], pods[1])
+	// Nodes have no informer cache in this test, their metrics are kept.
+	h.dc.SyncMetrics(newNodeWithResources("node-1", corev1.ConditionTrue, "2", "1Gi"))
+
+	require.Equal(t, 0, h.dc.EvictStaleObjects())
+
+	// The deletion of pod-1 is missed, it is only removed from its store.
+	require.NoError(t, h.store(pods[0]).Delete(pods[0]))
+	require.Len(t, h.metrics("k8s.pod.phase", map[string]string{"k8s.pod.name": "pod-1"}), 1)
+
+	require.Equal(t, 1, h.dc.EvictStaleObjects())
+	h.requireNoMetric("k8s.pod.phase", map[string]string{"k8s.pod.name": "pod-1"})
+	h.requireMetric("k8s.pod.phase", map[string]string{"k8s.pod.name": "pod-2"})
+	h.requireMetric("k8s.node.condition_ready", nil)
+
+	require.Equal(t, 0, h.dc.EvictStaleObjects())
+}
+
+func TestEvictStaleObjectsDeletionGracePeriod(t *testing.T) {
+	h := newTestHarness(t, nil, WithDeletionGracePeriod(time.Minute))
+	pod := newPendingPod("pod-1", "node-1")
+	h.seed(pod)
+
+	require.NoError(t, h.store(pod).Delete(pod))
+	require.Equal(t, 1, h.dc.EvictStaleObjects())
+	// Stale objects are marked as deleted and only evicted once their
+	// deletion grace period has elapsed, like deleted objects.
+	h.requireMetric("k8s.pod.phase", map[string]string{"k8s.pod.name": "pod-1"})
+	require.Equal(t, 0, h.dc.EvictStaleObjects())
+
+	h.advance(2 * time.Minute)
+	h.requireNoMetric("k8s.pod.phase", map[string]string{"k8s.pod.name": "pod-1"})
+}
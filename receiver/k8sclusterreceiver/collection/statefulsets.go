@@ -15,10 +15,14 @@
 package collection
 
 import (
+	"fmt"
+
 	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
 	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
 	"go.opentelemetry.io/collector/translator/conventions"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
 
 	metadata "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/experimentalmetricmetadata"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
@@ -30,33 +34,41 @@ const (
 	statefulSetUpdateVersion  = "update_revision"
 )
 
-var statefulSetReplicasDesiredMetric = &metricspb.MetricDescriptor{
+var statefulSetReplicasDesiredMetric = registerMetric(&metricspb.MetricDescriptor{
 	Name:        "k8s.statefulset.desired_pods",
 	Description: "Number of desired pods in the stateful set (the `spec.replicas` field)",
 	Unit:        "1",
 	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
-}
+})
 
-var statefulSetReplicasReadyMetric = &metricspb.MetricDescriptor{
+var statefulSetReplicasReadyMetric = registerMetric(&metricspb.MetricDescriptor{
 	Name:        "k8s.statefulset.ready_pods",
 	Description: "Number of pods created by the stateful set that have the `Ready` condition",
 	Unit:        "1",
 	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
-}
+})
 
-var statefulSetReplicasCurrentMetric = &metricspb.MetricDescriptor{
+var statefulSetReplicasCurrentMetric = registerMetric(&metricspb.MetricDescriptor{
 	Name:        "k8s.statefulset.current_pods",
 	Description: "The number of pods created by the StatefulSet controller from the StatefulSet version",
 	Unit:        "1",
 	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
-}
+})
 
-var statefulSetReplicasUpdatedMetric = &metricspb.MetricDescriptor{
+var statefulSetReplicasUpdatedMetric = registerMetric(&metricspb.MetricDescriptor{
 	Name:        "k8s.statefulset.updated_pods",
 	Description: "Number of pods created by the StatefulSet controller from the StatefulSet version",
 	Unit:        "1",
 	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
-}
+})
+
+var statefulSetUnboundPVCCountMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name: "k8s.statefulset.unbound_pvc_count",
+	Description: "Number of PersistentVolumeClaims of the pods of the stateful set, created from " +
+		"its volume claim templates, that are not bound to a volume",
+	Unit: "1",
+	Type: metricspb.MetricDescriptor_GAUGE_INT64,
+})
 
 func getMetricsForStatefulSet(ss *appsv1.StatefulSet) []*resourceMetrics {
 	if ss.Spec.Replicas == nil {
@@ -98,6 +110,60 @@ func getMetricsForStatefulSet(ss *appsv1.StatefulSet) []*resourceMetrics {
 	}
 }
 
+// getUnboundPVCMetricsForStatefulSets returns, for every StatefulSet with
+// volume claim templates, the number of claims of its desired pods that exist
+// but are not bound, e.g. pending since no volume can be provisioned, which
+// blocks the pods from starting. Claims are named <template>-<statefulset>-
+// <ordinal> by the StatefulSet controller. Claims yet to be created are not
+// counted.
+func getUnboundPVCMetricsForStatefulSets(ms *metadataStore) []*resourceMetrics {
+	if ms.statefulSets == nil || ms.persistentVolumeClaims == nil {
+		return nil
+	}
+
+	var out []*resourceMetrics
+	for _, obj := range ms.statefulSets.List() {
+		ss, ok := obj.(*appsv1.StatefulSet)
+		if !ok || ss.Spec.Replicas == nil || len(ss.Spec.VolumeClaimTemplates) == 0 {
+			continue
+		}
+
+		unbound := 0
+		for ordinal := int32(0); ordinal < *ss.Spec.Replicas; ordinal++ {
+			for _, template := range ss.Spec.VolumeClaimTemplates {
+				name := fmt.Sprintf("%s-%s-%d", template.Name, ss.Name, ordinal)
+				if isClaimUnbound(ms.persistentVolumeClaims, ss.Namespace, name) {
+					unbound++
+				}
+			}
+		}
+
+		out = append(out, &resourceMetrics{
+			resource: getResourceForStatefulSet(ss),
+			metrics: []*metricspb.Metric{
+				{
+					MetricDescriptor: statefulSetUnboundPVCCountMetric,
+					Timeseries: []*metricspb.TimeSeries{
+						utils.GetInt64TimeSeries(int64(unbound)),
+					},
+				},
+			},
+		})
+	}
+	return out
+}
+
+// isClaimUnbound returns whether the PersistentVolumeClaim with the given
+// namespace and name exists in the store and is not bound.
+func isClaimUnbound(store cache.Store, namespace, name string) bool {
+	obj, exists, err := store.GetByKey(namespace + "/" + name)
+	if err != nil || !exists {
+		return false
+	}
+	pvc, ok := obj.(*corev1.PersistentVolumeClaim)
+	return ok && pvc.Status.Phase != corev1.ClaimBound
+}
+
 func getResourceForStatefulSet(ss *appsv1.StatefulSet) *resourcepb.Resource {
 	return &resourcepb.Resource{
 		Type: k8sType,
@@ -20,6 +20,7 @@ import (
 	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
 	"github.com/stretchr/testify/require"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 
@@ -82,6 +83,45 @@ func TestStatefulsetMetadata(t *testing.T) {
 	)
 }
 
+func TestStatefulSetUnboundPVCCount(t *testing.T) {
+	h := newTestHarness(t, nil)
+	ss := newStatefulset("1")
+	replicas := int32(3)
+	ss.Spec.Replicas = &replicas
+	ss.Spec.VolumeClaimTemplates = []corev1.PersistentVolumeClaim{{ObjectMeta: v1.ObjectMeta{Name: "data"}}}
+	ssRes := map[string]string{"k8s.statefulset.name": ss.Name}
+
+	newClaim := func(ordinal string, phase corev1.PersistentVolumeClaimPhase) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "data-" + ss.Name + "-" + ordinal,
+				Namespace: ss.Namespace,
+				UID:       types.UID("pvc-" + ordinal + "-uid"),
+			},
+			Status: corev1.PersistentVolumeClaimStatus{Phase: phase},
+		}
+	}
+
+	// Nothing is reported without the PersistentVolumeClaim store.
+	h.seed(ss)
+	h.requireNoMetric("k8s.statefulset.unbound_pvc_count", ssRes)
+
+	// The claim of the third replica is yet to be created and the one of a
+	// fourth replica is left over from a scale down, neither is counted.
+	pending := newClaim("1", corev1.ClaimPending)
+	h.seed(newClaim("0", corev1.ClaimBound), pending, newClaim("3", corev1.ClaimPending))
+	h.requireInt64Value("k8s.statefulset.unbound_pvc_count", ssRes, 1)
+
+	pending.Status.Phase = corev1.ClaimBound
+	h.seed(pending)
+	h.requireInt64Value("k8s.statefulset.unbound_pvc_count", ssRes, 0)
+
+	// StatefulSets without volume claim templates have no claims to bind.
+	other := newStatefulset("2")
+	h.seed(other)
+	h.requireNoMetric("k8s.statefulset.unbound_pvc_count", map[string]string{"k8s.statefulset.name": other.Name})
+}
+
 func newStatefulset(id string) *appsv1.StatefulSet {
 	desired := int32(10)
 	return &appsv1.StatefulSet{
@@ -0,0 +1,140 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"google.golang.org/protobuf/proto"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// transitionTimeLabelKey is the key of the label holding the time at which
+// an object entered the state reported by a datapoint.
+const transitionTimeLabelKey = "last_transition_time"
+
+// addPodTransitionTimeLabels adds the time at which the pod entered its
+// phase, and at which the conditions of its readiness gates got their status, to the datapoints of
+// k8s.pod.phase and k8s.pod.readiness_gate.
+func addPodTransitionTimeLabels(pod *corev1.Pod, rms []*resourceMetrics) {
+	if len(rms) == 0 {
+		return
+	}
+	// The metrics of the pod itself come first, followed by those of its
+	// containers.
+	for _, m := range rms[0].metrics {
+		switch m.MetricDescriptor {
+		case podPhaseMetric:
+			addTransitionTimeLabel(m, []time.Time{podPhaseTransitionTime(pod)})
+		case podReadinessGateMetric:
+			times := make([]time.Time, 0, len(pod.Spec.ReadinessGates))
+			for _, rg := range pod.Spec.ReadinessGates {
+				times = append(times, podConditionTransitionTime(pod, rg.ConditionType))
+			}
+			addTransitionTimeLabel(m, times)
+		}
+	}
+}
+
+// addNodeTransitionTimeLabels adds the last transition time of the
+// conditions of the node to the datapoints of their metrics.
+func addNodeTransitionTimeLabels(node *corev1.Node, rms []*resourceMetrics, nodeConditionTypesToReport []string) {
+	if len(rms) == 0 {
+		return
+	}
+	conditions := make(map[string]time.Time, len(nodeConditionTypesToReport))
+	for _, condType := range nodeConditionTypesToReport {
+		var t time.Time
+		for _, c := range node.Status.Conditions {
+			if string(c.Type) == condType {
+				t = c.LastTransitionTime.Time
+				break
+			}
+		}
+		conditions[getNodeConditionMetric(condType)] = t
+	}
+	for _, m := range rms[0].metrics {
+		if t, ok := conditions[m.MetricDescriptor.GetName()]; ok {
+			addTransitionTimeLabel(m, []time.Time{t})
+		}
+	}
+}
+
+// addTransitionTimeLabel adds the given transition times, one per time
+// series, as a label of the time series of m. Unknown times are reported
+// without a value.
+func addTransitionTimeLabel(m *metricspb.Metric, times []time.Time) {
+	if len(times) != len(m.Timeseries) {
+		return
+	}
+
+	// Descriptors may be shared between the metrics of all objects of a
+	// kind, so they are copied before being changed.
+	descriptor := proto.Clone(m.MetricDescriptor).(*metricspb.MetricDescriptor)
+	descriptor.LabelKeys = append(descriptor.LabelKeys, &metricspb.LabelKey{Key: transitionTimeLabelKey})
+	m.MetricDescriptor = descriptor
+
+	for i, ts := range m.Timeseries {
+		lv := &metricspb.LabelValue{}
+		if !times[i].IsZero() {
+			lv.Value = times[i].UTC().Format(time.RFC3339)
+			lv.HasValue = true
+		}
+		// Label values may be shared between time series, so they are not
+		// appended to in place.
+		ts.LabelValues = append(ts.LabelValues[:len(ts.LabelValues):len(ts.LabelValues)], lv)
+	}
+}
+
+// podPhaseTransitionTime returns when the pod entered its phase, as far as
+// can be told from its status since pods have no transition time for their
+// phase: its creation for pending pods, the earliest start of its running
+// containers for running pods, and the latest termination of its containers
+// for terminated pods.
+func podPhaseTransitionTime(pod *corev1.Pod) time.Time {
+	var t time.Time
+	switch pod.Status.Phase {
+	case corev1.PodPending:
+		return pod.CreationTimestamp.Time
+	case corev1.PodRunning:
+		for _, cs := range pod.Status.ContainerStatuses {
+			if r := cs.State.Running; r != nil && !r.StartedAt.IsZero() && (t.IsZero() || r.StartedAt.Time.Before(t)) {
+				t = r.StartedAt.Time
+			}
+		}
+		if t.IsZero() && pod.Status.StartTime != nil {
+			t = pod.Status.StartTime.Time
+		}
+	case corev1.PodSucceeded, corev1.PodFailed:
+		for _, cs := range pod.Status.ContainerStatuses {
+			if term := cs.State.Terminated; term != nil && term.FinishedAt.Time.After(t) {
+				t = term.FinishedAt.Time
+			}
+		}
+	}
+	return t
+}
+
+// podConditionTransitionTime returns the last transition time of the
+// condition of the pod, or the zero time if the pod does not have it.
+func podConditionTransitionTime(pod *corev1.Pod, condType corev1.PodConditionType) time.Time {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == condType {
+			return c.LastTransitionTime.Time
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,148 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodPhaseTransitionTime(t *testing.T) {
+	created := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	started := created.Add(time.Minute)
+	finished := created.Add(time.Hour)
+
+	running := func(at time.Time) corev1.ContainerStatus {
+		return corev1.ContainerStatus{State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: v1.NewTime(at)}}}
+	}
+	terminated := func(at time.Time) corev1.ContainerStatus {
+		return corev1.ContainerStatus{State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{FinishedAt: v1.NewTime(at)}}}
+	}
+	startTime := v1.NewTime(created.Add(time.Second))
+
+	tests := []struct {
+		name   string
+		status corev1.PodStatus
+		want   time.Time
+	}{
+		{
+			name:   "pending",
+			status: corev1.PodStatus{Phase: corev1.PodPending},
+			want:   created,
+		},
+		{
+			name: "running",
+			status: corev1.PodStatus{
+				Phase:             corev1.PodRunning,
+				StartTime:         &startTime,
+				ContainerStatuses: []corev1.ContainerStatus{running(started.Add(time.Minute)), running(started)},
+			},
+			want: started,
+		},
+		{
+			name:   "running without running containers",
+			status: corev1.PodStatus{Phase: corev1.PodRunning, StartTime: &startTime},
+			want:   startTime.Time,
+		},
+		{
+			name: "failed",
+			status: corev1.PodStatus{
+				Phase:             corev1.PodFailed,
+				ContainerStatuses: []corev1.ContainerStatus{terminated(finished), terminated(started)},
+			},
+			want: finished,
+		},
+		{
+			name:   "unknown",
+			status: corev1.PodStatus{Phase: corev1.PodUnknown},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: v1.ObjectMeta{CreationTimestamp: v1.NewTime(created)}, Status: tt.status}
+			require.Equal(t, tt.want, podPhaseTransitionTime(pod))
+		})
+	}
+}
+
+func TestDataCollectorTransitionTimes(t *testing.T) {
+	transition := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	newNodeWithTransition := func() *corev1.Node {
+		node := newNode("1")
+		node.Status.Conditions[0].LastTransitionTime = v1.NewTime(transition)
+		return node
+	}
+	newPodWithReadinessGates := func() *corev1.Pod {
+		pod := newPodWithContainer("1", podSpecWithContainer("container-name"), podStatusWithContainer("container-name", containerIDWithPreifx("container-id")))
+		pod.CreationTimestamp = v1.NewTime(transition)
+		pod.Status.Phase = corev1.PodPending
+		pod.Spec.ReadinessGates = []corev1.PodReadinessGate{{ConditionType: "gate-a"}, {ConditionType: "gate-b"}}
+		pod.Status.Conditions = []corev1.PodCondition{
+			{Type: "gate-a", Status: corev1.ConditionTrue, LastTransitionTime: v1.NewTime(transition.Add(time.Minute))},
+		}
+		return pod
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		h := newTestHarness(t, []string{"Ready"})
+		h.seed(newNodeWithTransition(), newPodWithReadinessGates())
+		for _, name := range []string{"k8s.node.condition_ready", "k8s.pod.phase", "k8s.pod.readiness_gate"} {
+			found := h.metrics(name, nil)
+			require.Equal(t, 1, len(found), name)
+			for _, k := range found[0].metric.MetricDescriptor.LabelKeys {
+				require.NotEqual(t, transitionTimeLabelKey, k.Key, name)
+			}
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		h := newTestHarness(t, []string{"Ready", "MemoryPressure"}, WithTransitionTimes(true))
+		h.seed(newNodeWithTransition(), newPodWithReadinessGates())
+
+		found := h.metrics("k8s.node.condition_ready", nil)
+		require.Equal(t, 1, len(found))
+		m := found[0].metric
+		require.Equal(t, transitionTimeLabelKey, m.MetricDescriptor.LabelKeys[len(m.MetricDescriptor.LabelKeys)-1].Key)
+		lvs := m.Timeseries[0].LabelValues
+		require.Equal(t, "2021-03-01T12:00:00Z", lvs[len(lvs)-1].Value)
+
+		// Conditions without a transition time have the label without a value.
+		found = h.metrics("k8s.node.condition_memory_pressure", nil)
+		require.Equal(t, 1, len(found))
+		lvs = found[0].metric.Timeseries[0].LabelValues
+		require.False(t, lvs[len(lvs)-1].HasValue)
+
+		found = h.metrics("k8s.pod.phase", nil)
+		require.Equal(t, 1, len(found))
+		lvs = found[0].metric.Timeseries[0].LabelValues
+		require.Equal(t, "2021-03-01T12:00:00Z", lvs[len(lvs)-1].Value)
+
+		found = h.metrics("k8s.pod.readiness_gate", nil)
+		require.Equal(t, 1, len(found))
+		m = found[0].metric
+		require.Equal(t, []string{"condition_type", transitionTimeLabelKey}, []string{m.MetricDescriptor.LabelKeys[0].Key, m.MetricDescriptor.LabelKeys[1].Key})
+		require.Equal(t, 2, len(m.Timeseries))
+		require.Equal(t, "2021-03-01T12:01:00Z", m.Timeseries[0].LabelValues[1].Value)
+		require.False(t, m.Timeseries[1].LabelValues[1].HasValue)
+
+		// Shared descriptors are left as is.
+		require.Equal(t, 1, len(podReadinessGateMetric.LabelKeys))
+		require.Equal(t, 0, len(podPhaseMetric.LabelKeys))
+	})
+}
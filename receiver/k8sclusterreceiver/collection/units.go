@@ -0,0 +1,146 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
+)
+
+// resourceUnit is a unit in which quantities of a resource can be reported.
+type resourceUnit struct {
+	// symbol is set as the unit of the metric descriptor.
+	symbol string
+	// divisor converts a quantity from the default unit of the resource,
+	// millicores for CPU and bytes otherwise.
+	divisor int64
+}
+
+var (
+	cpuUnits = map[string]resourceUnit{
+		"millicores": {"{millicores}", 1},
+		"cores":      {"{cores}", 1000},
+	}
+	byteUnits = map[string]resourceUnit{
+		"bytes":     {"By", 1},
+		"kibibytes": {"KiBy", 1 << 10},
+		"mebibytes": {"MiBy", 1 << 20},
+		"gibibytes": {"GiBy", 1 << 30},
+	}
+)
+
+// supportedUnits lists, per resource, the units it can be reported in and
+// the unit it is reported in by default.
+var supportedUnits = map[corev1.ResourceName]struct {
+	units       map[string]resourceUnit
+	defaultUnit string
+}{
+	corev1.ResourceCPU:              {cpuUnits, "millicores"},
+	corev1.ResourceMemory:           {byteUnits, "bytes"},
+	corev1.ResourceEphemeralStorage: {byteUnits, "bytes"},
+}
+
+// units maps resources to the unit their quantities are reported in.
+// Resources without an entry are reported in their default unit.
+type units map[corev1.ResourceName]resourceUnit
+
+// ValidateUnits returns an error if any of the given resource units is
+// not supported.
+func ValidateUnits(u map[string]string) error {
+	for name, unit := range u {
+		su, ok := supportedUnits[corev1.ResourceName(name)]
+		if !ok {
+			return fmt.Errorf("units cannot be configured for resource %q", name)
+		}
+		if _, ok := su.units[unit]; !ok {
+			return fmt.Errorf("unsupported unit %q for resource %q, must be one of: %s",
+				unit, name, strings.Join(unitNames(su.units), ", "))
+		}
+	}
+	return nil
+}
+
+func unitNames(u map[string]resourceUnit) []string {
+	out := make([]string, 0, len(u))
+	for name := range u {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// unitFor returns the unit quantities of the given resource are reported in.
+func (u units) unitFor(name corev1.ResourceName) resourceUnit {
+	if ru, ok := u[name]; ok {
+		return ru
+	}
+	if su, ok := supportedUnits[name]; ok {
+		return su.units[su.defaultUnit]
+	}
+	return resourceUnit{divisor: 1}
+}
+
+// getResourceMetric returns a gauge reporting the quantity of the given
+// resource in its configured unit. Quantities are reported as integers in
+// the default unit and as doubles otherwise, since conversion may result
+// in fractional values.
+func (u units) getResourceMetric(
+	metricName, description string, name corev1.ResourceName, q resource.Quantity) *metricspb.Metric {
+	ru := u.unitFor(name)
+	val := getQuantityValue(name, q)
+
+	if ru.divisor == 1 {
+		return &metricspb.Metric{
+			MetricDescriptor: &metricspb.MetricDescriptor{
+				Name:        metricName,
+				Description: description,
+				Unit:        ru.symbol,
+				Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+			},
+			Timeseries: []*metricspb.TimeSeries{
+				utils.GetInt64TimeSeries(val),
+			},
+		}
+	}
+
+	return &metricspb.Metric{
+		MetricDescriptor: &metricspb.MetricDescriptor{
+			Name:        metricName,
+			Description: description,
+			Unit:        ru.symbol,
+			Type:        metricspb.MetricDescriptor_GAUGE_DOUBLE,
+		},
+		Timeseries: []*metricspb.TimeSeries{
+			utils.GetDoubleTimeSeries(float64(val) / float64(ru.divisor)),
+		},
+	}
+}
+
+// getQuantityValue returns the value of a resource quantity in the default
+// unit of the resource. CPU is reported in millicores, everything else in
+// its base unit.
+func getQuantityValue(name corev1.ResourceName, q resource.Quantity) int64 {
+	if name == corev1.ResourceCPU {
+		return q.MilliValue()
+	}
+	return q.Value()
+}
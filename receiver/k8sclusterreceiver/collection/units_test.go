@@ -0,0 +1,185 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestResourceMetricUnits(t *testing.T) {
+	tests := []struct {
+		name         string
+		units        map[string]string
+		resource     corev1.ResourceName
+		quantity     string
+		expectedUnit string
+		expectedType metricspb.MetricDescriptor_Type
+		expected     interface{}
+	}{
+		{
+			name:         "default cpu",
+			resource:     corev1.ResourceCPU,
+			quantity:     "1500m",
+			expectedUnit: "{millicores}",
+			expectedType: metricspb.MetricDescriptor_GAUGE_INT64,
+			expected:     int64(1500),
+		},
+		{
+			name:         "millicores",
+			units:        map[string]string{"cpu": "millicores"},
+			resource:     corev1.ResourceCPU,
+			quantity:     "1500m",
+			expectedUnit: "{millicores}",
+			expectedType: metricspb.MetricDescriptor_GAUGE_INT64,
+			expected:     int64(1500),
+		},
+		{
+			name:         "cores",
+			units:        map[string]string{"cpu": "cores"},
+			resource:     corev1.ResourceCPU,
+			quantity:     "1500m",
+			expectedUnit: "{cores}",
+			expectedType: metricspb.MetricDescriptor_GAUGE_DOUBLE,
+			expected:     1.5,
+		},
+		{
+			name:         "default memory",
+			resource:     corev1.ResourceMemory,
+			quantity:     "3Mi",
+			expectedUnit: "By",
+			expectedType: metricspb.MetricDescriptor_GAUGE_INT64,
+			expected:     int64(3 * 1024 * 1024),
+		},
+		{
+			name:         "bytes",
+			units:        map[string]string{"memory": "bytes"},
+			resource:     corev1.ResourceMemory,
+			quantity:     "3Mi",
+			expectedUnit: "By",
+			expectedType: metricspb.MetricDescriptor_GAUGE_INT64,
+			expected:     int64(3 * 1024 * 1024),
+		},
+		{
+			name:         "kibibytes",
+			units:        map[string]string{"memory": "kibibytes"},
+			resource:     corev1.ResourceMemory,
+			quantity:     "1536",
+			expectedUnit: "KiBy",
+			expectedType: metricspb.MetricDescriptor_GAUGE_DOUBLE,
+			expected:     1.5,
+		},
+		{
+			name:         "mebibytes",
+			units:        map[string]string{"memory": "mebibytes"},
+			resource:     corev1.ResourceMemory,
+			quantity:     "512Ki",
+			expectedUnit: "MiBy",
+			expectedType: metricspb.MetricDescriptor_GAUGE_DOUBLE,
+			expected:     0.5,
+		},
+		{
+			name:         "gibibytes",
+			units:        map[string]string{"memory": "gibibytes"},
+			resource:     corev1.ResourceMemory,
+			quantity:     "2Gi",
+			expectedUnit: "GiBy",
+			expectedType: metricspb.MetricDescriptor_GAUGE_DOUBLE,
+			expected:     2.0,
+		},
+		{
+			name:         "ephemeral storage",
+			units:        map[string]string{"ephemeral-storage": "gibibytes"},
+			resource:     corev1.ResourceEphemeralStorage,
+			quantity:     "1Gi",
+			expectedUnit: "GiBy",
+			expectedType: metricspb.MetricDescriptor_GAUGE_DOUBLE,
+			expected:     1.0,
+		},
+		{
+			name:         "memory unit does not apply to cpu",
+			units:        map[string]string{"memory": "mebibytes"},
+			resource:     corev1.ResourceCPU,
+			quantity:     "2",
+			expectedUnit: "{millicores}",
+			expectedType: metricspb.MetricDescriptor_GAUGE_INT64,
+			expected:     int64(2000),
+		},
+		{
+			name:         "resource without units",
+			resource:     "nvidia.com/gpu",
+			quantity:     "2",
+			expectedUnit: "",
+			expectedType: metricspb.MetricDescriptor_GAUGE_INT64,
+			expected:     int64(2),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, ValidateUnits(tt.units))
+			dc := NewDataCollector(zap.NewNop(), []string{}, WithUnits(tt.units))
+
+			m := dc.units.getResourceMetric("metric", "", tt.resource, resource.MustParse(tt.quantity))
+			require.Equal(t, tt.expectedUnit, m.MetricDescriptor.Unit)
+			require.Equal(t, tt.expectedType, m.MetricDescriptor.Type)
+
+			point := m.Timeseries[0].Points[0]
+			if tt.expectedType == metricspb.MetricDescriptor_GAUGE_INT64 {
+				require.Equal(t, tt.expected, point.GetInt64Value())
+			} else {
+				require.Equal(t, tt.expected, point.GetDoubleValue())
+			}
+		})
+	}
+}
+
+func TestContainerMetricsWithUnits(t *testing.T) {
+	pod := newPodWithContainer(
+		"1",
+		podSpecWithContainer("container-name"),
+		podStatusWithContainer("container-name", containerIDWithPreifx("container-id")),
+	)
+
+	rms := getMetricsForPod(pod, units{corev1.ResourceCPU: cpuUnits["cores"]}, FieldExtractionRules{})
+	require.Equal(t, 2, len(rms))
+
+	for _, rm := range rms {
+		for _, m := range rm.metrics {
+			switch m.MetricDescriptor.Name {
+			case "k8s.pod.cpu_request", "k8s.container.cpu_request":
+				require.Equal(t, "{cores}", m.MetricDescriptor.Unit)
+				require.Equal(t, 10.0, m.Timeseries[0].Points[0].GetDoubleValue())
+			case "k8s.pod.cpu_limit", "k8s.container.cpu_limit":
+				require.Equal(t, "{cores}", m.MetricDescriptor.Unit)
+				require.Equal(t, 20.0, m.Timeseries[0].Points[0].GetDoubleValue())
+			}
+		}
+	}
+}
+
+func TestValidateUnits(t *testing.T) {
+	require.NoError(t, ValidateUnits(nil))
+	require.NoError(t, ValidateUnits(map[string]string{"cpu": "cores", "memory": "mebibytes"}))
+	require.EqualError(t, ValidateUnits(map[string]string{"memory": "cores"}),
+		`unsupported unit "cores" for resource "memory", must be one of: bytes, gibibytes, kibibytes, mebibytes`)
+	require.EqualError(t, ValidateUnits(map[string]string{"pods": "bytes"}),
+		`units cannot be configured for resource "pods"`)
+}
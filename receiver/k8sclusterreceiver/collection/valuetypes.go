@@ -0,0 +1,54 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Value types of gauge metrics.
+const (
+	// GaugeValueTypeInt reports integer gauges as INT64, their native type.
+	GaugeValueTypeInt = "int"
+	// GaugeValueTypeDouble reports all gauges as DOUBLE.
+	GaugeValueTypeDouble = "double"
+)
+
+// convertGaugesToDouble converts the INT64 gauges of metrics to DOUBLE gauges
+// in place. Metrics are expected not to be shared with the metrics store.
+func convertGaugesToDouble(metrics []*metricspb.Metric) {
+	for _, metric := range metrics {
+		if metric == nil || metric.MetricDescriptor.GetType() != metricspb.MetricDescriptor_GAUGE_INT64 {
+			continue
+		}
+
+		// Descriptors are shared between metrics, so they are copied
+		// rather than modified.
+		descriptor := proto.Clone(metric.MetricDescriptor).(*metricspb.MetricDescriptor)
+		descriptor.Type = metricspb.MetricDescriptor_GAUGE_DOUBLE
+		metric.MetricDescriptor = descriptor
+
+		for _, ts := range metric.Timeseries {
+			for _, point := range ts.Points {
+				v, ok := point.Value.(*metricspb.Point_Int64Value)
+				if !ok {
+					continue
+				}
+				point.Value = &metricspb.Point_DoubleValue{DoubleValue: float64(v.Int64Value)}
+			}
+		}
+	}
+}
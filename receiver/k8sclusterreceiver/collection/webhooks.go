@@ -0,0 +1,126 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	"go.opentelemetry.io/collector/translator/conventions"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
+)
+
+const (
+	// Resource labels keys for webhook configurations.
+	k8sKeyWebhookConfigurationUID  = "k8s.webhookconfiguration.uid"
+	k8sKeyWebhookConfigurationName = "k8s.webhookconfiguration.name"
+	k8sKeyWebhookConfigurationType = "k8s.webhookconfiguration.type"
+
+	// Values of k8sKeyWebhookConfigurationType.
+	webhookConfigurationTypeMutating   = "mutating"
+	webhookConfigurationTypeValidating = "validating"
+)
+
+var webhookCountMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.webhook.count",
+	Description: "Number of admission webhooks in the webhook configuration",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var webhookInfoMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.webhook.info",
+	Description: "Information about an admission webhook of the webhook configuration, always 1",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+	LabelKeys:   []*metricspb.LabelKey{{Key: "name"}, {Key: "failure_policy"}},
+})
+
+func getMetricsForMutatingWebhookConfiguration(
+	wc *admissionregistrationv1.MutatingWebhookConfiguration) []*resourceMetrics {
+	policies := make([]webhookPolicy, 0, len(wc.Webhooks))
+	for _, wh := range wc.Webhooks {
+		policies = append(policies, webhookPolicy{wh.Name, wh.FailurePolicy})
+	}
+	return getMetricsForWebhookConfiguration(&wc.ObjectMeta, webhookConfigurationTypeMutating, policies)
+}
+
+func getMetricsForValidatingWebhookConfiguration(
+	wc *admissionregistrationv1.ValidatingWebhookConfiguration) []*resourceMetrics {
+	policies := make([]webhookPolicy, 0, len(wc.Webhooks))
+	for _, wh := range wc.Webhooks {
+		policies = append(policies, webhookPolicy{wh.Name, wh.FailurePolicy})
+	}
+	return getMetricsForWebhookConfiguration(&wc.ObjectMeta, webhookConfigurationTypeValidating, policies)
+}
+
+// webhookPolicy holds the fields common to mutating and validating webhooks.
+type webhookPolicy struct {
+	name          string
+	failurePolicy *admissionregistrationv1.FailurePolicyType
+}
+
+func getMetricsForWebhookConfiguration(
+	om *v1.ObjectMeta, typ string, webhooks []webhookPolicy) []*resourceMetrics {
+	infoSeries := make([]*metricspb.TimeSeries, 0, len(webhooks))
+	for _, wh := range webhooks {
+		// Fail is the default failure policy of admissionregistration.k8s.io/v1.
+		failurePolicy := admissionregistrationv1.Fail
+		if wh.failurePolicy != nil {
+			failurePolicy = *wh.failurePolicy
+		}
+
+		infoSeries = append(infoSeries, utils.GetInt64TimeSeriesWithLabels(1, []*metricspb.LabelValue{
+			{Value: wh.name, HasValue: true},
+			{Value: string(failurePolicy), HasValue: true},
+		}))
+	}
+
+	metrics := []*metricspb.Metric{
+		{
+			MetricDescriptor: webhookCountMetric,
+			Timeseries: []*metricspb.TimeSeries{
+				utils.GetInt64TimeSeries(int64(len(webhooks))),
+			},
+		},
+	}
+	if len(infoSeries) > 0 {
+		metrics = append(metrics, &metricspb.Metric{
+			MetricDescriptor: webhookInfoMetric,
+			Timeseries:       infoSeries,
+		})
+	}
+
+	return []*resourceMetrics{
+		{
+			resource: getResourceForWebhookConfiguration(om, typ),
+			metrics:  metrics,
+		},
+	}
+}
+
+func getResourceForWebhookConfiguration(om *v1.ObjectMeta, typ string) *resourcepb.Resource {
+	return &resourcepb.Resource{
+		Type: k8sType,
+		Labels: map[string]string{
+			k8sKeyWebhookConfigurationUID:   string(om.UID),
+			k8sKeyWebhookConfigurationName:  om.Name,
+			k8sKeyWebhookConfigurationType:  typ,
+			conventions.AttributeK8sCluster: om.ClusterName,
+		},
+	}
+}
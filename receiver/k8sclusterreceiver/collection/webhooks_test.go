@@ -0,0 +1,117 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"testing"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/stretchr/testify/require"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/testutils"
+)
+
+func TestMutatingWebhookConfigurationMetrics(t *testing.T) {
+	fail := admissionregistrationv1.Fail
+	ignore := admissionregistrationv1.Ignore
+	wc := &admissionregistrationv1.MutatingWebhookConfiguration{
+		ObjectMeta: newWebhookConfigurationMeta("1"),
+		Webhooks: []admissionregistrationv1.MutatingWebhook{
+			{Name: "fail.webhook.test", FailurePolicy: &fail},
+			{Name: "ignore.webhook.test", FailurePolicy: &ignore},
+			{Name: "default.webhook.test"},
+		},
+	}
+
+	rms := getMetricsForMutatingWebhookConfiguration(wc)
+	require.Equal(t, 1, len(rms))
+
+	rm := rms[0]
+	testutils.AssertResource(t, rm.resource, k8sType,
+		map[string]string{
+			"k8s.webhookconfiguration.uid":  "test-webhookconfiguration-1-uid",
+			"k8s.webhookconfiguration.name": "test-webhookconfiguration-1",
+			"k8s.webhookconfiguration.type": "mutating",
+			"k8s.cluster.name":              "test-cluster",
+		},
+	)
+
+	require.Equal(t, 2, len(rm.metrics))
+	testutils.AssertMetrics(t, rm.metrics[0], "k8s.webhook.count",
+		metricspb.MetricDescriptor_GAUGE_INT64, 3)
+
+	testutils.AssertMetricsWithLabels(t, rm.metrics[1], "k8s.webhook.info",
+		metricspb.MetricDescriptor_GAUGE_INT64,
+		map[string]string{"name": "fail.webhook.test", "failure_policy": "Fail"}, 1)
+	require.Equal(t, [][]string{
+		{"fail.webhook.test", "Fail"},
+		{"ignore.webhook.test", "Ignore"},
+		{"default.webhook.test", "Fail"},
+	}, webhookInfoLabelValues(rm.metrics[1]))
+}
+
+func TestValidatingWebhookConfigurationMetrics(t *testing.T) {
+	ignore := admissionregistrationv1.Ignore
+	wc := &admissionregistrationv1.ValidatingWebhookConfiguration{
+		ObjectMeta: newWebhookConfigurationMeta("2"),
+		Webhooks: []admissionregistrationv1.ValidatingWebhook{
+			{Name: "ignore.webhook.test", FailurePolicy: &ignore},
+		},
+	}
+
+	rms := getMetricsForValidatingWebhookConfiguration(wc)
+	require.Equal(t, 1, len(rms))
+	require.Equal(t, "validating", rms[0].resource.Labels["k8s.webhookconfiguration.type"])
+
+	require.Equal(t, 2, len(rms[0].metrics))
+	testutils.AssertMetrics(t, rms[0].metrics[0], "k8s.webhook.count",
+		metricspb.MetricDescriptor_GAUGE_INT64, 1)
+	require.Equal(t, [][]string{{"ignore.webhook.test", "Ignore"}}, webhookInfoLabelValues(rms[0].metrics[1]))
+
+	// Configurations without webhooks only report the count.
+	wc.Webhooks = nil
+	rms = getMetricsForValidatingWebhookConfiguration(wc)
+	require.Equal(t, 1, len(rms[0].metrics))
+	testutils.AssertMetrics(t, rms[0].metrics[0], "k8s.webhook.count",
+		metricspb.MetricDescriptor_GAUGE_INT64, 0)
+}
+
+func newWebhookConfigurationMeta(id string) v1.ObjectMeta {
+	return v1.ObjectMeta{
+		Name:        "test-webhookconfiguration-" + id,
+		UID:         types.UID("test-webhookconfiguration-" + id + "-uid"),
+		ClusterName: "test-cluster",
+	}
+}
+
+// webhookInfoLabelValues returns the label values of the timeseries of
+// k8s.webhook.info whose value is 1.
+func webhookInfoLabelValues(m *metricspb.Metric) [][]string {
+	out := make([][]string, 0, len(m.Timeseries))
+	for _, ts := range m.Timeseries {
+		if ts.Points[0].GetInt64Value() != 1 {
+			continue
+		}
+		values := make([]string, 0, len(ts.LabelValues))
+		for _, lv := range ts.LabelValues {
+			values = append(values, lv.Value)
+		}
+		out = append(out, values)
+	}
+	return out
+}
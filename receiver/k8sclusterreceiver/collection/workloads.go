@@ -0,0 +1,500 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	resourcepb "github.com/census-instrumentation/opencensus-proto/gen-go/resource/v1"
+	"go.opentelemetry.io/collector/translator/conventions"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
+)
+
+// Pod aggregation modes.
+const (
+	// PodAggregationNone reports metrics for every pod.
+	PodAggregationNone = "none"
+	// PodAggregationOwner reports metrics of pods managed by a workload
+	// aggregated per workload instead.
+	PodAggregationOwner = "owner"
+)
+
+var workloadPodsMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.workload.pods",
+	Description: "Number of pods of the workload in each phase",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+	LabelKeys:   []*metricspb.LabelKey{{Key: "phase"}},
+})
+
+var workloadContainerRestartsMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name: "k8s.workload.container_restarts",
+	Description: "Sum of the restarts of all containers in pods of the workload. " +
+		"See k8s.container.restarts for caveats on how this value behaves.",
+	Unit: "1",
+	Type: metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var workloadPodsScheduledMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.workload.pods_scheduled",
+	Description: "Number of non-terminated pods of the workload that are scheduled to a node",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var workloadPodsDesiredMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.workload.pods_desired",
+	Description: "Number of desired pods of the workload",
+	Unit:        "1",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var workloadMaxUnreadyPodAgeMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name:        "k8s.workload.max_unready_pod_age",
+	Description: "Time for which the longest unready pod of the workload has been unready, 0 if all its pods are ready",
+	Unit:        "s",
+	Type:        metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var workloadImageVersionsMetric = registerMetric(&metricspb.MetricDescriptor{
+	Name: "k8s.workload.image_versions",
+	Description: "Number of distinct sets of container images among the running pods of the workload, " +
+		"more than 1 when pods run different versions, e.g. during a rollout",
+	Unit: "1",
+	Type: metricspb.MetricDescriptor_GAUGE_INT64,
+})
+
+var podPhases = []corev1.PodPhase{
+	corev1.PodPending,
+	corev1.PodRunning,
+	corev1.PodSucceeded,
+	corev1.PodFailed,
+	corev1.PodUnknown,
+}
+
+// workloadResourceLabelKeys maps workload kinds to the resource label keys
+// of their name and UID, matching the resources of the workload's own metrics.
+var workloadResourceLabelKeys = map[string][2]string{
+	k8sKindCronJob:               {conventions.AttributeK8sCronJob, conventions.AttributeK8sCronJobUID},
+	k8sKindDaemonSet:             {conventions.AttributeK8sDaemonSet, conventions.AttributeK8sDaemonSetUID},
+	k8sKindDeployment:            {conventions.AttributeK8sDeployment, conventions.AttributeK8sDeploymentUID},
+	k8sKindJob:                   {conventions.AttributeK8sJob, conventions.AttributeK8sJobUID},
+	k8sKindReplicaSet:            {conventions.AttributeK8sReplicaSet, conventions.AttributeK8sReplicaSetUID},
+	k8sKindReplicationController: {k8sKeyReplicationControllerName, k8sKeyReplicationControllerUID},
+	k8sStatefulSet:               {conventions.AttributeK8sStatefulSet, conventions.AttributeK8sStatefulSetUID},
+}
+
+// workload accumulates metrics of the pods managed by a workload.
+type workload struct {
+	owner     *v1.OwnerReference
+	namespace string
+	cluster   string
+	phases    map[corev1.PodPhase]int64
+	restarts  int64
+}
+
+// getMetricsForWorkloads returns pod metrics aggregated per owning workload.
+// Pods without a controller are skipped since their metrics are reported
+// per pod.
+func getMetricsForWorkloads(ms *metadataStore) []*resourceMetrics {
+	if ms.pods == nil {
+		return nil
+	}
+
+	workloads := map[string]*workload{}
+	for _, obj := range ms.pods.List() {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok {
+			continue
+		}
+		owner := resolvePodWorkload(pod, ms)
+		if owner == nil {
+			continue
+		}
+
+		key := fmt.Sprintf("%s/%s/%s", pod.Namespace, owner.Kind, owner.Name)
+		w, ok := workloads[key]
+		if !ok {
+			w = &workload{
+				owner:     owner,
+				namespace: pod.Namespace,
+				cluster:   pod.ClusterName,
+				phases:    map[corev1.PodPhase]int64{},
+			}
+			workloads[key] = w
+		}
+
+		w.phases[pod.Status.Phase]++
+		for _, cs := range pod.Status.ContainerStatuses {
+			w.restarts += int64(cs.RestartCount)
+		}
+	}
+
+	keys := make([]string, 0, len(workloads))
+	for key := range workloads {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	out := make([]*resourceMetrics, 0, len(keys))
+	for _, key := range keys {
+		out = append(out, getMetricsForWorkload(workloads[key]))
+	}
+	return out
+}
+
+func getMetricsForWorkload(w *workload) *resourceMetrics {
+	phaseSeries := make([]*metricspb.TimeSeries, 0, len(podPhases))
+	for _, phase := range podPhases {
+		phaseSeries = append(phaseSeries, utils.GetInt64TimeSeriesWithLabels(
+			w.phases[phase], []*metricspb.LabelValue{{Value: string(phase), HasValue: true}},
+		))
+	}
+
+	return &resourceMetrics{
+		resource: getResourceForWorkload(w),
+		metrics: []*metricspb.Metric{
+			{
+				MetricDescriptor: workloadPodsMetric,
+				Timeseries:       phaseSeries,
+			},
+			{
+				MetricDescriptor: workloadContainerRestartsMetric,
+				Timeseries: []*metricspb.TimeSeries{
+					utils.GetInt64TimeSeries(w.restarts),
+				},
+			},
+		},
+	}
+}
+
+func getResourceForWorkload(w *workload) *resourcepb.Resource {
+	labels := map[string]string{
+		k8sKeyWorkLoadKind:                w.owner.Kind,
+		k8sKeyWorkLoadName:                w.owner.Name,
+		conventions.AttributeK8sNamespace: w.namespace,
+		conventions.AttributeK8sCluster:   w.cluster,
+	}
+	if keys, ok := workloadResourceLabelKeys[w.owner.Kind]; ok {
+		labels[keys[0]] = w.owner.Name
+		labels[keys[1]] = string(w.owner.UID)
+	}
+
+	return &resourcepb.Resource{
+		Type:   k8sType,
+		Labels: labels,
+	}
+}
+
+// resolvePodWorkload returns the top-level workload managing the pod, or nil
+// if the pod has no controller. Pods of ReplicaSets and Jobs are attributed
+// to the owning Deployment or CronJob, if any.
+func resolvePodWorkload(pod *corev1.Pod, ms *metadataStore) *v1.OwnerReference {
+	owner := v1.GetControllerOf(pod)
+	if owner == nil {
+		return nil
+	}
+
+	switch owner.Kind {
+	case k8sKindReplicaSet:
+		if ms.replicaSets == nil {
+			break
+		}
+		obj, exists, err := ms.replicaSets.GetByKey(utils.GetIDForCache(pod.Namespace, owner.Name))
+		if err != nil || !exists {
+			break
+		}
+		if rs, ok := obj.(*appsv1.ReplicaSet); ok {
+			if ref := utils.FindOwnerWithKind(rs.OwnerReferences, k8sKindDeployment); ref != nil {
+				return ref
+			}
+		}
+	case k8sKindJob:
+		if ms.jobs == nil {
+			break
+		}
+		obj, exists, err := ms.jobs.GetByKey(utils.GetIDForCache(pod.Namespace, owner.Name))
+		if err != nil || !exists {
+			break
+		}
+		if job, ok := obj.(*batchv1.Job); ok {
+			if ref := utils.FindOwnerWithKind(job.OwnerReferences, k8sKindCronJob); ref != nil {
+				return ref
+			}
+		}
+	}
+
+	return owner
+}
+
+// addOwnerLabels adds the name and UID of the controller of the pod, and of
+// the Deployment or CronJob managing it if any, to the resources of its
+// metrics, as the k8sattributes processor does. Should the ReplicaSet of the
+// pod not be cached yet, the name of its Deployment is derived from the name
+// of the ReplicaSet, which is suffixed with the pod-template-hash label.
+func addOwnerLabels(pod *corev1.Pod, rms []*resourceMetrics, ms *metadataStore) {
+	owner := v1.GetControllerOf(pod)
+	if owner == nil {
+		return
+	}
+
+	labels := map[string]string{}
+	setOwner := func(ref *v1.OwnerReference) {
+		if keys, ok := workloadResourceLabelKeys[ref.Kind]; ok {
+			labels[keys[0]] = ref.Name
+			labels[keys[1]] = string(ref.UID)
+		}
+	}
+	setOwner(owner)
+	if workload := resolvePodWorkload(pod, ms); workload.UID != owner.UID {
+		setOwner(workload)
+	} else if hash, ok := pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey]; ok &&
+		owner.Kind == k8sKindReplicaSet && strings.HasSuffix(owner.Name, "-"+hash) {
+		labels[conventions.AttributeK8sDeployment] = strings.TrimSuffix(owner.Name, "-"+hash)
+	}
+
+	for _, rm := range rms {
+		for k, v := range labels {
+			if _, ok := rm.resource.Labels[k]; !ok {
+				rm.resource.Labels[k] = v
+			}
+		}
+	}
+}
+
+// getSchedulingMetricsForWorkloads returns the number of scheduled pods of
+// Deployments and of ReplicaSets not managed by a Deployment along with their
+// desired number of pods, so that scheduling shortfalls, e.g. due to
+// insufficient resources, can be detected. Pods that are terminating or have
+// terminated are not counted. During rollouts, pods of all the ReplicaSets of
+// a Deployment are counted.
+func getSchedulingMetricsForWorkloads(ms *metadataStore) []*resourceMetrics {
+	if ms.pods == nil {
+		return nil
+	}
+
+	scheduled := map[types.UID]int64{}
+	for _, obj := range ms.pods.List() {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok || pod.Spec.NodeName == "" || pod.DeletionTimestamp != nil || isPodTerminal(pod) {
+			continue
+		}
+		if owner := resolvePodWorkload(pod, ms); owner != nil {
+			scheduled[owner.UID]++
+		}
+	}
+
+	var workloads []*workload
+	desired := map[types.UID]int32{}
+	addWorkload := func(kind string, om v1.ObjectMeta, replicas *int32) {
+		if replicas == nil {
+			return
+		}
+		desired[om.UID] = *replicas
+		workloads = append(workloads, &workload{
+			owner:     &v1.OwnerReference{Kind: kind, Name: om.Name, UID: om.UID},
+			namespace: om.Namespace,
+			cluster:   om.ClusterName,
+		})
+	}
+	if ms.deployments != nil {
+		for _, obj := range ms.deployments.List() {
+			if d, ok := obj.(*appsv1.Deployment); ok {
+				addWorkload(k8sKindDeployment, d.ObjectMeta, d.Spec.Replicas)
+			}
+		}
+	}
+	if ms.replicaSets != nil {
+		for _, obj := range ms.replicaSets.List() {
+			rs, ok := obj.(*appsv1.ReplicaSet)
+			if !ok || utils.FindOwnerWithKind(rs.OwnerReferences, k8sKindDeployment) != nil {
+				continue
+			}
+			addWorkload(k8sKindReplicaSet, rs.ObjectMeta, rs.Spec.Replicas)
+		}
+	}
+	sort.Slice(workloads, func(i, j int) bool {
+		return workloads[i].owner.UID < workloads[j].owner.UID
+	})
+
+	out := make([]*resourceMetrics, 0, len(workloads))
+	for _, w := range workloads {
+		out = append(out, &resourceMetrics{
+			resource: getResourceForWorkload(w),
+			metrics: []*metricspb.Metric{
+				{
+					MetricDescriptor: workloadPodsScheduledMetric,
+					Timeseries: []*metricspb.TimeSeries{
+						utils.GetInt64TimeSeries(scheduled[w.owner.UID]),
+					},
+				},
+				{
+					MetricDescriptor: workloadPodsDesiredMetric,
+					Timeseries: []*metricspb.TimeSeries{
+						utils.GetInt64TimeSeries(int64(desired[w.owner.UID])),
+					},
+				},
+			},
+		})
+	}
+	return out
+}
+
+// getMaxUnreadyPodAgeMetricsForWorkloads returns, for every workload managing
+// pods, for how long its longest unready pod has been unready at the given
+// time. A pod is considered unready since the last transition of its Ready
+// condition or, if it has none, since its creation. Pods that are terminating
+// or have terminated are not taken into account.
+func getMaxUnreadyPodAgeMetricsForWorkloads(ms *metadataStore, now time.Time) []*resourceMetrics {
+	if ms.pods == nil {
+		return nil
+	}
+
+	workloads := map[types.UID]*workload{}
+	maxAge := map[types.UID]time.Duration{}
+	for _, obj := range ms.pods.List() {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok || pod.DeletionTimestamp != nil || isPodTerminal(pod) {
+			continue
+		}
+		owner := resolvePodWorkload(pod, ms)
+		if owner == nil {
+			continue
+		}
+		if _, ok := workloads[owner.UID]; !ok {
+			workloads[owner.UID] = &workload{
+				owner:     owner,
+				namespace: pod.Namespace,
+				cluster:   pod.ClusterName,
+			}
+			maxAge[owner.UID] = 0
+		}
+
+		if podConditionValue(pod, corev1.PodReady) == 1 {
+			continue
+		}
+		if age := now.Sub(podUnreadySince(pod)); age > maxAge[owner.UID] {
+			maxAge[owner.UID] = age
+		}
+	}
+
+	uids := make([]types.UID, 0, len(workloads))
+	for uid := range workloads {
+		uids = append(uids, uid)
+	}
+	sort.Slice(uids, func(i, j int) bool { return uids[i] < uids[j] })
+
+	out := make([]*resourceMetrics, 0, len(uids))
+	for _, uid := range uids {
+		out = append(out, &resourceMetrics{
+			resource: getResourceForWorkload(workloads[uid]),
+			metrics: []*metricspb.Metric{
+				{
+					MetricDescriptor: workloadMaxUnreadyPodAgeMetric,
+					Timeseries: []*metricspb.TimeSeries{
+						utils.GetInt64TimeSeries(int64(maxAge[uid] / time.Second)),
+					},
+				},
+			},
+		})
+	}
+	return out
+}
+
+// getImageVersionMetricsForWorkloads returns, for every workload managing
+// running pods, the number of distinct sets of container images in the spec
+// of these pods. Sets rather than images are counted so that sidecars do not
+// count as versions of their own: a value above 1 means that pods of the
+// workload run different versions, e.g. while a rollout is in progress.
+// Pods that are terminating are not taken into account.
+func getImageVersionMetricsForWorkloads(ms *metadataStore) []*resourceMetrics {
+	if ms.pods == nil {
+		return nil
+	}
+
+	workloads := map[types.UID]*workload{}
+	versions := map[types.UID]map[string]bool{}
+	for _, obj := range ms.pods.List() {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok || pod.Status.Phase != corev1.PodRunning || pod.DeletionTimestamp != nil {
+			continue
+		}
+		owner := resolvePodWorkload(pod, ms)
+		if owner == nil {
+			continue
+		}
+		if _, ok := workloads[owner.UID]; !ok {
+			workloads[owner.UID] = &workload{
+				owner:     owner,
+				namespace: pod.Namespace,
+				cluster:   pod.ClusterName,
+			}
+			versions[owner.UID] = map[string]bool{}
+		}
+		versions[owner.UID][podImageVersion(pod)] = true
+	}
+
+	uids := make([]types.UID, 0, len(workloads))
+	for uid := range workloads {
+		uids = append(uids, uid)
+	}
+	sort.Slice(uids, func(i, j int) bool { return uids[i] < uids[j] })
+
+	out := make([]*resourceMetrics, 0, len(uids))
+	for _, uid := range uids {
+		out = append(out, &resourceMetrics{
+			resource: getResourceForWorkload(workloads[uid]),
+			metrics: []*metricspb.Metric{
+				{
+					MetricDescriptor: workloadImageVersionsMetric,
+					Timeseries: []*metricspb.TimeSeries{
+						utils.GetInt64TimeSeries(int64(len(versions[uid]))),
+					},
+				},
+			},
+		})
+	}
+	return out
+}
+
+// podImageVersion returns a key identifying the images of the app containers
+// of the pod by container name.
+func podImageVersion(pod *corev1.Pod) string {
+	images := make([]string, 0, len(pod.Spec.Containers))
+	for _, c := range pod.Spec.Containers {
+		images = append(images, c.Name+"="+c.Image)
+	}
+	sort.Strings(images)
+	return strings.Join(images, ",")
+}
+
+func podUnreadySince(pod *corev1.Pod) time.Time {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady && !c.LastTransitionTime.IsZero() {
+			return c.LastTransitionTime.Time
+		}
+	}
+	return pod.CreationTimestamp.Time
+}
@@ -0,0 +1,366 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collection
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	metricspb "github.com/census-instrumentation/opencensus-proto/gen-go/metrics/v1"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumerdata"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/testutils"
+)
+
+func TestPodAggregation(t *testing.T) {
+	isController := true
+	rs := withOwnerReferences([]v1.OwnerReference{{
+		Kind:       "Deployment",
+		Name:       "test-deployment-0",
+		UID:        "test-deployment-0-uid",
+		Controller: &isController,
+	}}, newReplicaSet("0")).(*appsv1.ReplicaSet)
+
+	var pods []*corev1.Pod
+	for i, phase := range []corev1.PodPhase{corev1.PodRunning, corev1.PodRunning, corev1.PodPending} {
+		id := fmt.Sprint(i)
+		pod := newPodWithContainer(id, podSpecWithContainer("container-name"),
+			podStatusWithContainer("container-name", containerIDWithPreifx("container-"+id)))
+		pod.Status.Phase = phase
+		pod.OwnerReferences = []v1.OwnerReference{{
+			Kind:       "ReplicaSet",
+			Name:       rs.Name,
+			UID:        rs.UID,
+			Controller: &isController,
+		}}
+		pods = append(pods, pod)
+	}
+
+	// A pod without a controller is always reported individually.
+	unowned := newPodWithContainer("unowned", podSpecWithContainer("container-name"),
+		podStatusWithContainer("container-name", containerIDWithPreifx("container-unowned")))
+
+	collect := func(opts ...Option) []consumerdata.MetricsData {
+		dc := NewDataCollector(zap.NewNop(), []string{}, opts...)
+		podStore := cache.NewStore(cache.MetaNamespaceKeyFunc)
+		rsStore := cache.NewStore(cache.MetaNamespaceKeyFunc)
+		require.NoError(t, rsStore.Add(rs))
+		dc.SetupMetadataStore(&corev1.Pod{}, podStore)
+		dc.SetupMetadataStore(&appsv1.ReplicaSet{}, rsStore)
+
+		for _, pod := range append(pods, unowned) {
+			require.NoError(t, podStore.Add(pod))
+			dc.SyncMetrics(pod)
+		}
+		return dc.CollectMetricData(time.Now())
+	}
+
+	t.Run("none", func(t *testing.T) {
+		mds := collect(WithPodAggregation(PodAggregationNone))
+
+		// A pod and a container resource for each of the 4 pods, the max
+		// unready pod age and the image versions of the deployment and the
+		// pending pods, the containers by registry, the pods by priority
+		// class, the container restarts and the oldest cached object age of
+		// the cluster and the container restarts of the namespace.
+		require.Equal(t, 16, len(mds))
+		podUIDs := map[string]bool{}
+		for _, md := range mds[:8] {
+			require.NotContains(t, md.Resource.Labels, k8sKeyWorkLoadKind)
+			podUIDs[md.Resource.Labels["k8s.pod.uid"]] = true
+		}
+		require.Equal(t, 4, len(podUIDs))
+	})
+
+	t.Run("owner", func(t *testing.T) {
+		mds := collect(WithPodAggregation(PodAggregationOwner))
+
+		// The unowned pod and its container, the max unready pod age and the
+		// image versions of the deployment, the pending pods, the containers
+		// by registry, the pods by priority class, the container restarts and
+		// the oldest cached object age of the cluster, the container restarts
+		// of the namespace and the deployment.
+		require.Equal(t, 11, len(mds))
+		for _, md := range mds[:2] {
+			require.Equal(t, "test-pod-unowned-uid", md.Resource.Labels["k8s.pod.uid"])
+		}
+
+		md := mds[10]
+		testutils.AssertResource(t, md.Resource, k8sType,
+			map[string]string{
+				"k8s.workload.kind":   "Deployment",
+				"k8s.workload.name":   "test-deployment-0",
+				"k8s.deployment.name": "test-deployment-0",
+				"k8s.deployment.uid":  "test-deployment-0-uid",
+				"k8s.namespace.name":  "test-namespace",
+				"k8s.cluster.name":    "test-cluster",
+			},
+		)
+
+		require.Equal(t, 2, len(md.Metrics))
+		phases := map[string]int64{}
+		for _, ts := range md.Metrics[0].Timeseries {
+			phases[ts.LabelValues[0].Value] = ts.Points[0].GetInt64Value()
+		}
+		require.Equal(t, "k8s.workload.pods", md.Metrics[0].MetricDescriptor.Name)
+		require.Equal(t, map[string]int64{
+			"Pending":   1,
+			"Running":   2,
+			"Succeeded": 0,
+			"Failed":    0,
+			"Unknown":   0,
+		}, phases)
+
+		testutils.AssertMetrics(t, md.Metrics[1], "k8s.workload.container_restarts",
+			metricspb.MetricDescriptor_GAUGE_INT64, 9)
+	})
+}
+
+func TestResolvePodWorkload(t *testing.T) {
+	isController := true
+	pod := &corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "test-pod",
+			Namespace: "test-namespace",
+			UID:       types.UID("test-pod-uid"),
+		},
+	}
+	require.Nil(t, resolvePodWorkload(pod, &metadataStore{}))
+
+	pod.OwnerReferences = []v1.OwnerReference{{
+		Kind:       "StatefulSet",
+		Name:       "test-statefulset",
+		Controller: &isController,
+	}}
+	require.Equal(t, "test-statefulset", resolvePodWorkload(pod, &metadataStore{}).Name)
+
+	// Falls back to the ReplicaSet if it is not cached.
+	pod.OwnerReferences = []v1.OwnerReference{{
+		Kind:       "ReplicaSet",
+		Name:       "test-replicaset-0",
+		Controller: &isController,
+	}}
+	require.Equal(t, "ReplicaSet", resolvePodWorkload(pod, &metadataStore{}).Kind)
+
+	ms := mockMetadataStore(testCaseOptions{kind: "ReplicaSet", withParentOR: true})
+	require.Equal(t, "Deployment", resolvePodWorkload(pod, ms).Kind)
+}
+
+func TestAddOwnerLabels(t *testing.T) {
+	isController := true
+	rs := withOwnerReferences([]v1.OwnerReference{{
+		Kind: "Deployment", Name: "test-deployment-0", UID: "test-deployment-0-uid", Controller: &isController,
+	}}, newReplicaSet("0")).(*appsv1.ReplicaSet)
+	job := withOwnerReferences([]v1.OwnerReference{{
+		Kind: "CronJob", Name: "test-cronjob-0", UID: "test-cronjob-0-uid", Controller: &isController,
+	}}, newJob("0")).(*batchv1.Job)
+	rsStore := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	require.NoError(t, rsStore.Add(rs))
+	jobStore := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	require.NoError(t, jobStore.Add(job))
+	ms := &metadataStore{replicaSets: rsStore, jobs: jobStore}
+
+	// ownerLabels returns the labels added to the resources of the metrics
+	// of a pod with the given controller and labels.
+	ownerLabels := func(owner v1.OwnerReference, labels map[string]string) []map[string]string {
+		pod := newPodWithContainer("0", podSpecWithContainer("container-name"),
+			podStatusWithContainer("container-name", containerIDWithPreifx("container-id")))
+		owner.Controller = &isController
+		pod.OwnerReferences = []v1.OwnerReference{owner}
+		pod.Labels = labels
+		rms := getMetricsForPod(pod, nil, FieldExtractionRules{})
+		addOwnerLabels(pod, rms, ms)
+		out := make([]map[string]string, 0, len(rms))
+		for _, rm := range rms {
+			out = append(out, rm.resource.Labels)
+		}
+		return out
+	}
+
+	// The labels are set on the resources of the pod and of its containers.
+	for _, labels := range ownerLabels(v1.OwnerReference{Kind: "ReplicaSet", Name: rs.Name, UID: rs.UID}, nil) {
+		require.Equal(t, rs.Name, labels["k8s.replicaset.name"])
+		require.Equal(t, "test-deployment-0", labels["k8s.deployment.name"])
+		require.Equal(t, "test-deployment-0-uid", labels["k8s.deployment.uid"])
+	}
+	for _, labels := range ownerLabels(v1.OwnerReference{Kind: "Job", Name: job.Name, UID: job.UID}, nil) {
+		require.Equal(t, job.Name, labels["k8s.job.name"])
+		require.Equal(t, "test-cronjob-0", labels["k8s.cronjob.name"])
+	}
+	for _, labels := range ownerLabels(v1.OwnerReference{Kind: "StatefulSet", Name: "test-statefulset", UID: "test-statefulset-uid"}, nil) {
+		require.Equal(t, "test-statefulset", labels["k8s.statefulset.name"])
+		require.Equal(t, "test-statefulset-uid", labels["k8s.statefulset.uid"])
+	}
+
+	// The Deployment of a ReplicaSet that is not cached is derived from its name.
+	labels := ownerLabels(v1.OwnerReference{Kind: "ReplicaSet", Name: "web-5d8f7c9b4", UID: "web-rs-uid"},
+		map[string]string{"pod-template-hash": "5d8f7c9b4"})[0]
+	require.Equal(t, "web", labels["k8s.deployment.name"])
+	require.NotContains(t, labels, "k8s.deployment.uid")
+	labels = ownerLabels(v1.OwnerReference{Kind: "ReplicaSet", Name: "web-5d8f7c9b4", UID: "web-rs-uid"}, nil)[0]
+	require.NotContains(t, labels, "k8s.deployment.name")
+}
+
+func TestWorkloadSchedulingMetrics(t *testing.T) {
+	isController := true
+	h := newTestHarness(t, nil)
+
+	deployment := newDeployment("0")
+	desired := int32(5)
+	deployment.Spec.Replicas = &desired
+	rs := withOwnerReferences([]v1.OwnerReference{{
+		Kind:       "Deployment",
+		Name:       deployment.Name,
+		UID:        deployment.UID,
+		Controller: &isController,
+	}}, newReplicaSet("0")).(*appsv1.ReplicaSet)
+	standalone := newReplicaSet("1")
+	h.seed(deployment, rs, standalone)
+
+	newOwnedPod := func(id string, owner *appsv1.ReplicaSet, nodeName string) *corev1.Pod {
+		pod := newPodWithContainer(id, &corev1.PodSpec{NodeName: nodeName}, &corev1.PodStatus{})
+		pod.OwnerReferences = []v1.OwnerReference{{
+			Kind:       "ReplicaSet",
+			Name:       owner.Name,
+			UID:        owner.UID,
+			Controller: &isController,
+		}}
+		return pod
+	}
+
+	// 3 of the 5 pods of the Deployment are scheduled.
+	for i := 0; i < 5; i++ {
+		nodeName := ""
+		if i < 3 {
+			nodeName = "test-node"
+		}
+		h.seed(newOwnedPod(fmt.Sprint(i), rs, nodeName))
+	}
+	// Terminating and terminated pods are not counted.
+	terminating := newOwnedPod("terminating", rs, "test-node")
+	deletionTimestamp := v1.Now()
+	terminating.DeletionTimestamp = &deletionTimestamp
+	failed := newOwnedPod("failed", rs, "test-node")
+	failed.Status.Phase = corev1.PodFailed
+	h.seed(terminating, failed, newOwnedPod("standalone", standalone, "test-node"))
+
+	deploymentRes := map[string]string{
+		"k8s.workload.kind":   "Deployment",
+		"k8s.deployment.name": deployment.Name,
+		"k8s.deployment.uid":  string(deployment.UID),
+	}
+	h.requireInt64Value("k8s.workload.pods_scheduled", deploymentRes, 3)
+	h.requireInt64Value("k8s.workload.pods_desired", deploymentRes, 5)
+
+	// ReplicaSets managed by a Deployment are reported with the Deployment.
+	h.requireNoMetric("k8s.workload.pods_scheduled", map[string]string{"k8s.replicaset.name": rs.Name})
+
+	standaloneRes := map[string]string{"k8s.workload.kind": "ReplicaSet", "k8s.replicaset.name": standalone.Name}
+	h.requireInt64Value("k8s.workload.pods_scheduled", standaloneRes, 1)
+	h.requireInt64Value("k8s.workload.pods_desired", standaloneRes, 3)
+}
+
+func TestWorkloadMaxUnreadyPodAgeMetric(t *testing.T) {
+	isController := true
+	h := newTestHarness(t, nil)
+
+	rs := newReplicaSet("0")
+	h.seed(rs)
+
+	newOwnedPod := func(id string, ready corev1.ConditionStatus, since time.Time) *corev1.Pod {
+		pod := newPodWithContainer(id, &corev1.PodSpec{NodeName: "test-node"}, &corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{
+				Type:               corev1.PodReady,
+				Status:             ready,
+				LastTransitionTime: v1.NewTime(since),
+			}},
+		})
+		pod.OwnerReferences = []v1.OwnerReference{{
+			Kind:       "ReplicaSet",
+			Name:       rs.Name,
+			UID:        rs.UID,
+			Controller: &isController,
+		}}
+		return pod
+	}
+
+	h.seed(
+		newOwnedPod("ready", corev1.ConditionTrue, h.now.Add(-time.Hour)),
+		newOwnedPod("recent", corev1.ConditionFalse, h.now.Add(-time.Minute)),
+		newOwnedPod("long", corev1.ConditionFalse, h.now.Add(-10*time.Minute)),
+	)
+
+	rsRes := map[string]string{"k8s.workload.kind": "ReplicaSet", "k8s.replicaset.uid": string(rs.UID)}
+	h.requireInt64Value("k8s.workload.max_unready_pod_age", rsRes, 600)
+
+	// The age is computed at collection time.
+	h.advance(time.Minute)
+	h.requireInt64Value("k8s.workload.max_unready_pod_age", rsRes, 660)
+
+	h.remove(newOwnedPod("recent", "", time.Time{}), newOwnedPod("long", "", time.Time{}))
+	h.requireInt64Value("k8s.workload.max_unready_pod_age", rsRes, 0)
+}
+
+func TestWorkloadImageVersionsMetric(t *testing.T) {
+	isController := true
+	h := newTestHarness(t, nil)
+
+	rs := newReplicaSet("0")
+	h.seed(rs)
+
+	newOwnedPod := func(id string, phase corev1.PodPhase, images ...string) *corev1.Pod {
+		spec := &corev1.PodSpec{NodeName: "test-node"}
+		for i, image := range images {
+			spec.Containers = append(spec.Containers, corev1.Container{
+				Name:  fmt.Sprintf("container-%d", i),
+				Image: image,
+			})
+		}
+		pod := newPodWithContainer(id, spec, &corev1.PodStatus{Phase: phase})
+		pod.OwnerReferences = []v1.OwnerReference{{
+			Kind:       "ReplicaSet",
+			Name:       rs.Name,
+			UID:        rs.UID,
+			Controller: &isController,
+		}}
+		return pod
+	}
+
+	rsRes := map[string]string{"k8s.workload.kind": "ReplicaSet", "k8s.replicaset.uid": string(rs.UID)}
+
+	// Sidecars do not count as versions of their own.
+	h.seed(
+		newOwnedPod("0", corev1.PodRunning, "app:1.0", "proxy:1.8"),
+		newOwnedPod("1", corev1.PodRunning, "app:1.0", "proxy:1.8"),
+		newOwnedPod("2", corev1.PodPending, "app:1.1", "proxy:1.8"),
+	)
+	h.requireInt64Value("k8s.workload.image_versions", rsRes, 1)
+
+	h.seed(newOwnedPod("2", corev1.PodRunning, "app:1.1", "proxy:1.8"))
+	h.requireInt64Value("k8s.workload.image_versions", rsRes, 2)
+
+	h.remove(newOwnedPod("0", ""), newOwnedPod("1", ""))
+	h.requireInt64Value("k8s.workload.image_versions", rsRes, 1)
+}
@@ -15,12 +15,24 @@
 package k8sclusterreceiver
 
 import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/iancoleman/strcase"
 	"go.opentelemetry.io/collector/config/configmodels"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
 	k8s "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/k8sconfig"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/collection"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/observability"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
 )
 
 // Config defines configuration for kubernetes cluster receiver.
@@ -28,22 +40,1409 @@ type Config struct {
 	configmodels.ReceiverSettings `mapstructure:",squash"`
 	k8sconfig.APIConfig           `mapstructure:",squash"`
 
+	// Address of the K8s API server, overriding the one discovered from the
+	// environment or read from the kubeconfig.
+	APIServerHost string `mapstructure:"api_server_host"`
+	// Path to the CA certificate used to verify the certificate of the K8s
+	// API server.
+	CAFile string `mapstructure:"ca_file"`
+	// Whether to skip verifying the certificate of the K8s API server.
+	InsecureSkipVerify bool `mapstructure:"insecure_skip_verify"`
+	// Context of the kubeconfig to connect with when auth_type is
+	// kubeConfig, e.g. to collect a cluster other than the current one.
+	KubeConfigContext string `mapstructure:"kubeconfig_context"`
+	// Clusters to collect, each through a context of the kubeconfig, with
+	// one set of informers per cluster. Requires auth_type kubeConfig, and
+	// replaces kubeconfig_context and cluster_name.
+	Clusters []ClusterConfig `mapstructure:"clusters"`
+	// Sustained number of requests per second the receiver sends to the K8s
+	// API server, and the number of requests allowed at once above it. When
+	// 0, the defaults of client-go, 5 and 10, are used.
+	APIQPS   float32 `mapstructure:"api_qps"`
+	APIBurst int     `mapstructure:"api_burst"`
+	// Timeout of each request to the K8s API server, including watches. When
+	// 0, requests do not time out.
+	APITimeout time.Duration `mapstructure:"api_timeout"`
+
 	// Collection interval for metrics.
 	CollectionInterval time.Duration `mapstructure:"collection_interval"`
+	// Collection intervals of the metrics of objects of the given kinds,
+	// keyed by lower-cased kind (e.g. node), overriding collection_interval.
+	CollectionIntervals map[string]time.Duration `mapstructure:"collection_intervals"`
+	// Name of the cluster, set along with the hostname of the collector on
+	// the OpenCensus node of the emitted metrics so that OpenCensus consumers
+	// can identify their source, and as the k8s.cluster.name resource
+	// attribute of the emitted metrics. The node is not set when empty.
+	ClusterName string `mapstructure:"cluster_name"`
 
 	// Node condition types to report. See all condition types, see
 	// here: https://kubernetes.io/docs/concepts/architecture/nodes/#condition.
 	NodeConditionTypesToReport []string `mapstructure:"node_conditions_to_report"`
-	// List of exporters to which metadata from this receiver should be forwarded to.
+	// List of exporters, of pipelines of any data type, or extensions to
+	// which metadata from this receiver should be forwarded to.
 	MetadataExporters []string `mapstructure:"metadata_exporters"`
+	// Maximum number of objects to collect per kind, keyed by lower-cased
+	// Kubernetes kind (e.g. pod). Once the limit is reached, new objects
+	// of that kind are not collected. Kinds without an entry are not limited.
+	MaxObjects map[string]int `mapstructure:"max_objects"`
+	// Sampling of the objects of high-cardinality kinds, keyed by lower-cased
+	// Kubernetes kind (e.g. job). Only the sampled subset of the objects of
+	// those kinds is collected. Kinds without an entry are not sampled.
+	Sampling map[string]SamplingConfig `mapstructure:"sampling"`
+	// Objects managed by the given tools or operators are not collected,
+	// e.g. to leave out the noise of operator-generated objects.
+	ExcludeManagedBy ManagedByConfig `mapstructure:"exclude_managed_by"`
+	// Terminal phases (Succeeded or Failed) of the pods that are not
+	// collected, e.g. to leave out the pods of completed Jobs.
+	ExcludePodPhases []string `mapstructure:"exclude_pod_phases"`
+	// Names of metrics for which datapoints with a value of zero should not
+	// be emitted.
+	DropZeroValues []string `mapstructure:"drop_zero_values"`
+	// Node labels identifying spot or preemptible nodes, keyed by label key
+	// with the value of the label on such nodes. When not set, the labels
+	// used by GKE, EKS and AKS are used.
+	SpotNodeLabels map[string]string `mapstructure:"spot_node_labels"`
+	// Key of the node label holding the instance type of nodes.
+	InstanceTypeLabel string `mapstructure:"instance_type_label"`
+	// Settings of individual metrics, keyed by metric name (e.g.
+	// k8s.container.restarts). Metrics without an entry are enabled.
+	Metrics map[string]MetricConfig `mapstructure:"metrics"`
+	// Changes to how individual metrics are emitted, keyed by metric name,
+	// e.g. to report them under the names of kube-state-metrics.
+	MetricOverrides map[string]MetricOverrideConfig `mapstructure:"metric_overrides"`
+	// Whether objects without a UID are collected, keyed by their kind,
+	// namespace and name instead. When false, objects without a UID are not
+	// collected.
+	UIDFallback bool `mapstructure:"uid_fallback"`
+	// Whether to skip pushing the metrics of objects that have not changed
+	// since the previous collection interval.
+	SkipUnchanged bool `mapstructure:"skip_unchanged"`
+	// How often the metrics of all objects are pushed with skip_unchanged,
+	// including those of objects that have not changed. When 0, metrics of
+	// unchanged objects are never pushed again.
+	FullResyncInterval time.Duration `mapstructure:"full_resync_interval"`
+	// Whether to deduplicate the strings of cached metrics, e.g. namespace and
+	// node names, which objects otherwise each hold their own copies of.
+	InternStrings bool `mapstructure:"intern_strings"`
+	// Whether to skip rebuilding the metrics of updated objects when none of
+	// the fields metrics are built from changed, e.g. on node heartbeats.
+	SkipIrrelevantUpdates bool `mapstructure:"skip_irrelevant_updates"`
+	// Period for which the last metrics of deleted objects are still emitted
+	// after their deletion, smoothing the transition to no data. When 0,
+	// metrics of deleted objects are no longer emitted right away.
+	DeletionGracePeriod time.Duration `mapstructure:"deletion_grace_period"`
+	// Whether to emit k8s.object.deleted once for each resource of a deleted
+	// object once its metrics are no longer emitted.
+	EmitDeletionMarkers bool `mapstructure:"emit_deletion_markers"`
+	// Whether to report if pods with required pod anti-affinity run on the
+	// same node as a pod they are anti-affine to.
+	ReportAntiAffinityViolations bool `mapstructure:"report_anti_affinity_violations"`
+	// Whether to report if containers run privileged or may run as root,
+	// from their security context and the one of their pod.
+	ReportSecurityContext bool `mapstructure:"report_security_context"`
+	// Whether to report the kind of the object metrics are about, e.g. Pod or
+	// Deployment, as the k8s.object.kind resource attribute.
+	ReportObjectKind bool `mapstructure:"report_object_kind"`
+	// Whether to report the resource version and generation of the object
+	// metrics are about as the k8s.object.resource_version and
+	// k8s.object.generation resource attributes, e.g. to track changes.
+	ReportObjectVersion bool `mapstructure:"report_object_version"`
+	// Whether to report the change of the number of objects of each kind
+	// since the previous collection, e.g. to spot mass creations.
+	ReportObjectCountDelta bool `mapstructure:"report_object_count_delta"`
+	// Whether to report the number of restarts of each container since the
+	// previous collection, rather than only its cumulative restart count.
+	ReportContainerRestartRate bool `mapstructure:"report_container_restart_rate"`
+	// Whether to report the reasons containers are waiting or terminated,
+	// e.g. CrashLoopBackOff or OOMKilled, as metrics that can be alerted on.
+	ReportContainerStatusReasons bool `mapstructure:"report_container_status_reasons"`
+	// Whether to report the time at which objects entered the state reported
+	// by node condition, pod phase and pod readiness gate metrics.
+	ReportTransitionTimes bool `mapstructure:"report_transition_times"`
+	// Settings of the attributes describing the images of containers.
+	ImageMetadata ImageMetadataConfig `mapstructure:"image_metadata"`
+	// Whether to report the capacity and allocatable amount of every
+	// resource of nodes, including extended resources and hugepages.
+	ReportNodeResources bool `mapstructure:"report_node_resources"`
+	// Whether to report the number and estimated size of the objects of each
+	// kind in the informer caches, e.g. to plan the memory of the collector.
+	ReportInformerCache bool `mapstructure:"report_informer_cache"`
+	// Number of workers processing informer events concurrently. Events of a
+	// given object are always processed in order. When 0, events are processed
+	// by the informers directly.
+	EventWorkers int `mapstructure:"event_workers"`
+	// Number of informer events each worker can hold before informers are
+	// blocked. Only applies when event_workers is set.
+	EventQueueSize int `mapstructure:"event_queue_size"`
+	// Number of collected batches of metrics that can wait to be pushed to
+	// the next consumer, decoupling collection from slow consumers. When 0,
+	// metrics are pushed as part of collection.
+	PushQueueSize int `mapstructure:"push_queue_size"`
+	// What to do with a collected batch of metrics when the push queue is
+	// full, either "block" until there is room in the queue or "drop" the
+	// batch. Only applies when push_queue_size is set.
+	PushQueuePolicy string `mapstructure:"push_queue_policy"`
+	// Maximum time to wait for the next consumer to accept a batch of
+	// metrics, after which the batch is dropped. When 0, there is no timeout.
+	PushTimeout time.Duration `mapstructure:"push_timeout"`
+	// Retry of the pushes that fail, e.g. while the next consumer is briefly
+	// unavailable.
+	PushRetry PushRetryConfig `mapstructure:"push_retry"`
+	// Election of a single replica collecting metrics among several ones.
+	LeaderElection LeaderElectionConfig `mapstructure:"leader_election"`
+	// Settings of the log records of Kubernetes Events, pushed when the
+	// receiver is used in a logs pipeline.
+	Events EventsConfig `mapstructure:"events"`
+	// Settings of the log records of the relationships between objects,
+	// pushed when the receiver is used in a logs pipeline.
+	Relationships RelationshipsConfig `mapstructure:"relationships"`
+	// When metrics are pushed. With "interval", the metrics of all objects
+	// are pushed every collection interval. With "on_change", the metrics of
+	// changed objects only are pushed as they change, after an initial
+	// snapshot of all metrics, while the metrics not belonging to a single
+	// object are still pushed every collection interval.
+	PushMode string `mapstructure:"push_mode"`
+	// Period during which changes are batched before the metrics of the
+	// changed objects are pushed. Only applies when push_mode is on_change.
+	PushDebounce time.Duration `mapstructure:"push_debounce"`
+	// Number of collected metrics after which they are pushed to the next
+	// consumer while collection is still in progress, bounding the metrics
+	// held at once on large clusters. When 0, the metrics of a collection are
+	// pushed once they have all been collected.
+	FlushThreshold int `mapstructure:"flush_threshold"`
+	// Number of first collection intervals over which the metrics of the
+	// objects listed at start are spread, the nth collection only including
+	// n out of warmup_intervals of the objects, to smooth the burst of the
+	// first collections on large clusters. When 0, all the objects are
+	// collected from the first collection. Only applies when push_mode is
+	// interval.
+	WarmupIntervals int `mapstructure:"warmup_intervals"`
+	// How the metrics collected at once are split into the batches pushed to
+	// the next consumer. With "none", they are pushed in a single batch. With
+	// "namespace", each batch holds the metrics of a single namespace, and
+	// those of cluster-scoped objects and of the cluster are pushed in a
+	// batch of their own.
+	GroupBy string `mapstructure:"group_by"`
+	// Settings for collecting metrics about CustomResourceDefinitions.
+	CustomResourceDefinitions CRDConfig `mapstructure:"custom_resource_definitions"`
+	// Experimental. Gauges and resource attributes read from the fields of
+	// custom resources with JSONPath expressions, e.g. from the status of
+	// the resources of operators.
+	CustomResources []CustomResourceConfig `mapstructure:"custom_resources"`
+	// The Kubernetes distribution of the cluster, "kubernetes" or
+	// "openshift". On OpenShift, metrics are also reported for
+	// ClusterResourceQuotas and DeploymentConfigs.
+	Distribution string `mapstructure:"distribution"`
+	// Backoff between attempts of informers to list and watch objects again
+	// after failing to, e.g. while the API server is unavailable.
+	RelistBackoff RelistBackoffConfig `mapstructure:"relist_backoff"`
+
+	// Unit in which quantities of a resource are reported, keyed by resource
+	// name. Supported are millicores (default) and cores for cpu and bytes
+	// (default), kibibytes, mebibytes and gibibytes for memory and
+	// ephemeral-storage.
+	Units map[string]string `mapstructure:"units"`
+	// How pod metrics are reported. With "none", metrics are reported for
+	// every pod. With "owner", metrics of pods managed by a workload are
+	// aggregated per workload instead, reducing cardinality.
+	PodAggregation string `mapstructure:"pod_aggregation"`
+	// Nodes whose resources are included in the cluster capacity metrics.
+	// With "all", all nodes are included. With "ready", nodes whose Ready
+	// condition is true are included. With "schedulable", Ready nodes that
+	// are not cordoned are included.
+	ClusterCapacityNodes string `mapstructure:"cluster_capacity_nodes"`
+	// Value type of gauge metrics. With "int", gauges are reported with their
+	// native value type. With "double", integer gauges are reported as
+	// doubles instead.
+	GaugeValueType string `mapstructure:"gauge_value_type"`
+	// Default keys of the resource labels of emitted metrics to report (e.g.
+	// k8s.pod.name), to limit cardinality. Other labels are not reported.
+	// When empty, all labels are reported.
+	IncludeResourceAttributes []string `mapstructure:"include_resource_attributes"`
+	// Keys with which resource labels of emitted metrics are reported, keyed
+	// by the default label key (e.g. k8s.pod.name: pod). Labels without an
+	// entry keep their default key.
+	ResourceAttributeKeys map[string]string `mapstructure:"resource_attribute_keys"`
+	// Style of the keys of resource labels of emitted metrics that are not
+	// renamed with resource_attribute_keys. With "dot", keys are reported as
+	// is (e.g. k8s.pod.name). With "underscore" (k8s_pod_name) or
+	// "camel_case" (k8sPodName), their words are joined accordingly.
+	AttributeKeyStyle string `mapstructure:"attribute_key_style"`
+	// Maximum length in bytes of attribute values, e.g. extracted
+	// annotations. Longer values are truncated and end with "...". When 0,
+	// values are not truncated.
+	MaxAttributeValueLength int `mapstructure:"max_attribute_value_length"`
+	// Resource attributes of emitted metrics to place on their datapoints as
+	// well, or instead, for backends filtering on datapoint attributes only.
+	DatapointAttributes DatapointAttributesConfig `mapstructure:"datapoint_attributes"`
+	// Utilization ratio of a resource of a ResourceQuota, e.g. 0.9, above
+	// which the quota is counted by k8s.cluster.resource_quotas_over_threshold.
+	ResourceQuotaThreshold float64 `mapstructure:"resource_quota_threshold"`
+	// Lower-cased Kubernetes kinds (e.g. pod) for which a k8s.<kind>.labels
+	// metric carrying all the labels of each object is reported.
+	LabelInfoKinds []string `mapstructure:"label_info_kinds"`
+	// Pod annotations to extract as resource attributes of pods and their
+	// containers.
+	ExtractAnnotations []AnnotationExtractionConfig `mapstructure:"extract_annotations"`
+	// Keys of pod annotations that are never extracted, e.g. annotations that
+	// are too large to be useful as attributes. When not set, a default list
+	// of noisy annotations is excluded. An empty list excludes none.
+	ExcludeAnnotations []string `mapstructure:"exclude_annotations"`
+	// Labels and annotations of objects to extract as resource attributes of
+	// their metrics, in the syntax of the k8sattributes processor.
+	Extract ExtractConfig `mapstructure:"extract"`
+	// Experimental. Gauges computed from the fields of the objects of a kind
+	// with an arithmetic expression, reported along with the other metrics of
+	// each object.
+	ExpressionMetrics []ExpressionMetricConfig `mapstructure:"expression_metrics"`
+	// Keys of node labels (e.g. node-role.kubernetes.io/control-plane) for
+	// which nodes having the label are not watched, regardless of its value.
+	ExcludeNodeLabels []string `mapstructure:"exclude_node_labels"`
+	// Kinds that are not watched by default since they require additional
+	// permissions, as lower-cased Kubernetes kinds (e.g. endpointslice).
+	OptionalKinds []string `mapstructure:"optional_kinds"`
+	// Which of the kinds watched by default are watched.
+	Resources ResourcesConfig `mapstructure:"resources"`
+	// Namespaces to watch, for receivers only permitted to list objects in
+	// some namespaces. Cluster-scoped kinds, e.g. nodes, are not watched then.
+	// When empty, all namespaces are watched.
+	Namespaces []string `mapstructure:"namespaces"`
+	// Splits the namespaces to watch across several replicas of the
+	// receiver, for clusters too large for a single replica.
+	Sharding ShardingConfig `mapstructure:"sharding"`
+	// Period at which informers replay the objects in their caches to the
+	// receiver. When 0, objects are only received when they change.
+	InformerResyncPeriod time.Duration `mapstructure:"informer_resync_period"`
+	// Maximum number of objects returned by each request listing objects,
+	// so that the initial lists of large clusters are paginated. When 0,
+	// objects are listed in a single request.
+	ListPageSize int64 `mapstructure:"list_page_size"`
+	// API group versions (e.g. autoscaling/v2beta2) at which to watch kinds,
+	// keyed by lower-cased Kubernetes kind. Kinds not set are watched at the
+	// default version. The versions are checked to be served by the API
+	// server when the receiver starts.
+	APIVersions map[string]string `mapstructure:"api_versions"`
+	// How objects received from more than one informer, e.g. since
+	// informers overlap, are handled. With "first_source", only the events
+	// of the informer an object was first received from are processed, and
+	// a warning is logged. With "all_sources", the events of all informers
+	// are processed, so that an object deleted from one of them is removed
+	// even if still present in another.
+	DuplicateObjects string `mapstructure:"duplicate_objects"`
+	// Maximum time to wait for the initial sync of the informer caches, before
+	// which no metrics are pushed. The receiver reports a fatal error if the
+	// caches have not synced by then.
+	InitialSyncTimeout time.Duration `mapstructure:"initial_sync_timeout"`
+	// Address (e.g. localhost:13134) on which to serve readiness probes. The
+	// endpoint responds with 503 until the initial sync of the informer caches
+	// has completed and 200 afterwards. Disabled when empty.
+	ReadinessEndpoint string `mapstructure:"readiness_endpoint"`
+	// Address (e.g. localhost:13135) on which to serve the hooks pausing and
+	// resuming metric collection, POST /pause and POST /resume. Disabled when
+	// empty.
+	ControlEndpoint string `mapstructure:"control_endpoint"`
 
 	// For mocking.
-	makeClient func(apiConf k8sconfig.APIConfig) (k8s.Interface, error)
+	makeClient        func(apiConf k8sconfig.APIConfig) (k8s.Interface, error)
+	makeDynamicClient func(apiConf k8sconfig.APIConfig) (dynamic.Interface, error)
+}
+
+// CRDConfig defines configuration for collecting metrics about CustomResourceDefinitions.
+type CRDConfig struct {
+	// Whether to collect metrics about CustomResourceDefinitions. Requires
+	// permission to watch customresourcedefinitions in apiextensions.k8s.io.
+	Enabled bool `mapstructure:"enabled"`
+	// Names of CustomResourceDefinitions (e.g. certificates.cert-manager.io)
+	// for which the number of custom resources should be reported. Requires
+	// permission to watch the corresponding custom resources.
+	CountInstances []string `mapstructure:"count_instances"`
+}
+
+// CustomResourceConfig defines the metrics reported for every custom resource
+// of a group, version and resource. Requires permission to watch the custom
+// resources.
+type CustomResourceConfig struct {
+	// API group of the custom resources, e.g. cert-manager.io.
+	Group string `mapstructure:"group"`
+	// Version of the custom resources, e.g. v1.
+	Version string `mapstructure:"version"`
+	// Plural resource name of the custom resources, e.g. certificates.
+	Resource string `mapstructure:"resource"`
+	// Gauges whose values are read from the fields of each custom resource.
+	Metrics []CustomResourceMetricConfig `mapstructure:"metrics"`
+	// JSONPath expressions of the fields of each custom resource reported as
+	// resource attributes, keyed by attribute key.
+	Attributes map[string]string `mapstructure:"attributes"`
+}
+
+// CustomResourceMetricConfig defines a gauge read from a field of custom
+// resources.
+type CustomResourceMetricConfig struct {
+	// Name of the metric.
+	Name string `mapstructure:"name"`
+	// Description of the metric.
+	Description string `mapstructure:"description"`
+	// Unit of the metric. Defaults to 1.
+	Unit string `mapstructure:"unit"`
+	// JSONPath expression of the field, e.g. {.status.replicas}. Booleans
+	// and the "True" and "False" strings of conditions are reported as 1
+	// and 0.
+	Path string `mapstructure:"path"`
+}
+
+// RelistBackoffConfig defines the backoff of informers between failed
+// attempts to list and watch objects. The backoff is added to the one of
+// client-go, which cannot be configured, and starts from Min, doubling on
+// every consecutive failure up to Max. When Max is 0, no backoff is added,
+// otherwise Min must be set as well.
+type RelistBackoffConfig struct {
+	// Backoff after a first failure.
+	Min time.Duration `mapstructure:"min"`
+	// Maximum backoff after consecutive failures.
+	Max time.Duration `mapstructure:"max"`
+}
+
+// ShardingConfig defines the share of namespaces watched by a replica of the
+// receiver among Count replicas. Each namespace is watched by the replica
+// whose Index is the hash of its name modulo Count. The namespaces are
+// listed when the receiver starts, and cluster-scoped kinds are not watched.
+type ShardingConfig struct {
+	// Number of replicas. When 0, namespaces are not sharded.
+	Count int `mapstructure:"count"`
+	// Index of this replica, from 0 to Count - 1.
+	Index int `mapstructure:"index"`
+}
+
+// PushRetryConfig defines the retry of failed pushes of metrics. Failed
+// batches are held in a queue and pushed again, oldest first, waiting from
+// InitialBackoff before the first retry, doubling on every consecutive
+// failure up to MaxBackoff. Pushes that fail with a permanent error or time
+// out are not retried.
+type PushRetryConfig struct {
+	// Number of failed batches held to be pushed again. Once the queue is
+	// full, the oldest batch is dropped. When 0, failed pushes are not
+	// retried.
+	QueueSize int `mapstructure:"queue_size"`
+	// Backoff before the first retry.
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"`
+	// Maximum backoff between consecutive retries.
+	MaxBackoff time.Duration `mapstructure:"max_backoff"`
+}
+
+// ClusterConfig defines a cluster collected by the receiver along with the
+// others of clusters.
+type ClusterConfig struct {
+	// Context of the kubeconfig to connect to the cluster with.
+	KubeConfigContext string `mapstructure:"kubeconfig_context"`
+	// Name of the cluster, set as k8s.cluster.name like cluster_name.
+	Name string `mapstructure:"cluster_name"`
+}
+
+// LeaderElectionConfig defines the election, through a Lease, of the replica
+// that collects metrics among several replicas of the receiver. The others
+// stand by and take over once the Lease is not renewed.
+type LeaderElectionConfig struct {
+	// Whether to elect a leader. When false, every replica collects metrics.
+	Enabled bool `mapstructure:"enabled"`
+	// Name of the Lease.
+	LeaseName string `mapstructure:"lease_name"`
+	// Namespace of the Lease, which must be set when enabled.
+	LeaseNamespace string `mapstructure:"lease_namespace"`
+	// How long replicas standing by wait since the Lease was last renewed
+	// before taking over.
+	LeaseDuration time.Duration `mapstructure:"lease_duration"`
+	// How long the leader tries to renew the Lease before it gives up.
+	RenewDeadline time.Duration `mapstructure:"renew_deadline"`
+	// Interval between attempts to acquire or renew the Lease.
+	RetryPeriod time.Duration `mapstructure:"retry_period"`
+}
+
+// EventsConfig defines how Kubernetes Events are converted to log records.
+type EventsConfig struct {
+	// Severity of the log records, keyed by lower-cased event type, "normal"
+	// or "warning". Severities are one of "trace", "debug", "info", "warn",
+	// "error" or "fatal". Events of types not listed have an undefined
+	// severity.
+	Severity map[string]string `mapstructure:"severity"`
+}
+
+// RelationshipsConfig defines how the relationships between objects, e.g. of
+// pods with the node they are scheduled on, are pushed as log records.
+type RelationshipsConfig struct {
+	// Whether the relationships are pushed.
+	Enabled bool `mapstructure:"enabled"`
+	// Interval at which the relationships of all watched objects are pushed.
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+// DatapointAttributesConfig defines the resource attributes placed on the
+// datapoints of emitted metrics.
+type DatapointAttributesConfig struct {
+	// Keys of the attributes, as reported after resource_attribute_keys and
+	// attribute_key_style are applied.
+	Keys []string `mapstructure:"keys"`
+	// With "copy", the attributes are kept on the resource as well. With
+	// "move", they are removed from the resource.
+	Mode string `mapstructure:"mode"`
+}
+
+// SamplingConfig defines which objects of a kind are collected. Objects are
+// collected if they are kept by both rate and selector.
+type SamplingConfig struct {
+	// Keep 1 in rate objects, chosen consistently by their UID. When 0 or 1,
+	// all objects are kept.
+	Rate int `mapstructure:"rate"`
+	// Label selector (e.g. team=payments) of the objects to keep. When empty,
+	// all objects are kept.
+	Selector string `mapstructure:"selector"`
+}
+
+// ManagedByConfig identifies the objects managed by tools or operators,
+// either by a label naming their manager or by the kind of their owners.
+type ManagedByConfig struct {
+	// Key of the label naming the manager of objects.
+	Label string `mapstructure:"label"`
+	// Values of the label (e.g. Helm) of the managed objects.
+	Values []string `mapstructure:"values"`
+	// Kinds of owners (e.g. Prometheus) of the managed objects.
+	OwnerKinds []string `mapstructure:"owner_kinds"`
+}
+
+// MetricConfig defines the settings of a metric.
+type MetricConfig struct {
+	// Whether the metric is emitted.
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// MetricOverrideConfig defines how a metric is emitted. Empty fields leave the
+// metric as is.
+type MetricOverrideConfig struct {
+	// Name the metric is emitted with.
+	Name string `mapstructure:"name"`
+	// Unit the metric is emitted with. Values are not converted.
+	Unit string `mapstructure:"unit"`
+	// Attributes with constant values added to every datapoint of the metric.
+	Attributes map[string]string `mapstructure:"attributes"`
+	// Whether the metric is dropped, as with enabled: false in metrics.
+	Drop bool `mapstructure:"drop"`
+}
+
+// ImageMetadataConfig defines the attributes describing the images of
+// containers reported on their resources.
+type ImageMetadataConfig struct {
+	// Whether the digest and registry of images are reported.
+	Enabled bool `mapstructure:"enabled"`
+	// Name of an extension implementing collection.ImageEnricher, resolving
+	// further attributes of images, e.g. their age. Disabled when empty.
+	Enricher string `mapstructure:"enricher"`
+}
+
+// AnnotationExtractionConfig defines how resource attributes are extracted
+// from pod annotations. Exactly one of Key and KeyPrefix must be set.
+type AnnotationExtractionConfig struct {
+	// Key of the annotation to extract.
+	Key string `mapstructure:"key"`
+	// Extracts all annotations whose key starts with this prefix. Attributes
+	// are named after the rest of the annotation key.
+	KeyPrefix string `mapstructure:"key_prefix"`
+	// Name of the attribute the annotation selected with key is extracted
+	// to. Defaults to the annotation key.
+	Attribute string `mapstructure:"attribute"`
+	// Prefix of the names of attributes extracted with key_prefix or json.
+	AttributePrefix string `mapstructure:"attribute_prefix"`
+	// Whether the annotation selected with key holds a JSON object whose
+	// fields are each extracted to an attribute named after the field.
+	JSON bool `mapstructure:"json"`
+}
+
+// ExtractConfig defines the labels and annotations of objects extracted as
+// resource attributes.
+type ExtractConfig struct {
+	Labels      []FieldExtractConfig `mapstructure:"labels"`
+	Annotations []FieldExtractConfig `mapstructure:"annotations"`
+}
+
+// FieldExtractConfig defines how a resource attribute is extracted from a
+// label or an annotation. Exactly one of Key and KeyRegex must be set.
+type FieldExtractConfig struct {
+	// Name of the attribute. With key_regex, submatches of the key can be
+	// referenced, e.g. as $1. Defaults to k8s.<kind>.labels.<key> or
+	// k8s.<kind>.annotations.<key>.
+	TagName string `mapstructure:"tag_name"`
+	// Key of the label or annotation to extract.
+	Key string `mapstructure:"key"`
+	// Extracts all labels or annotations whose key matches this regular
+	// expression.
+	KeyRegex string `mapstructure:"key_regex"`
+	// Regular expression with a submatch named value, e.g.
+	// env=(?P<value>[^,]+), extracting only part of the value.
+	Regex string `mapstructure:"regex"`
+	// Lower-cased kind of the objects to extract from. Defaults to pod.
+	From string `mapstructure:"from"`
+}
+
+// defaultExtractFrom is the kind labels and annotations are extracted from
+// when not set, as for the k8sattributes processor.
+const defaultExtractFrom = "pod"
+
+// ExpressionMetricConfig defines a gauge computed from the fields of objects.
+type ExpressionMetricConfig struct {
+	// Name of the metric.
+	Name string `mapstructure:"name"`
+	// Description of the metric.
+	Description string `mapstructure:"description"`
+	// Unit of the metric. Defaults to 1.
+	Unit string `mapstructure:"unit"`
+	// Lower-cased Kubernetes kind (e.g. deployment) of the objects the
+	// metric is reported for.
+	Kind string `mapstructure:"kind"`
+	// Arithmetic expression over the numeric fields of the objects, named
+	// by their JSON path, e.g. status.replicas - status.readyReplicas.
+	Expression string `mapstructure:"expression"`
+}
+
+// Supported Kubernetes distributions.
+const (
+	distributionKubernetes = "kubernetes"
+	distributionOpenShift  = "openshift"
+)
+
+// Kinds that can be enabled with optional_kinds.
+const (
+	optionalKindConfigMap                      = "configmap"
+	optionalKindEndpointSlice                  = "endpointslice"
+	optionalKindIngress                        = "ingress"
+	optionalKindLease                          = "lease"
+	optionalKindMutatingWebhookConfiguration   = "mutatingwebhookconfiguration"
+	optionalKindPersistentVolume               = "persistentvolume"
+	optionalKindPersistentVolumeClaim          = "persistentvolumeclaim"
+	optionalKindPodDisruptionBudget            = "poddisruptionbudget"
+	optionalKindPriorityClass                  = "priorityclass"
+	optionalKindRBAC                           = "rbac"
+	optionalKindSecret                         = "secret"
+	optionalKindValidatingWebhookConfiguration = "validatingwebhookconfiguration"
+)
+
+var supportedOptionalKinds = []string{
+	optionalKindConfigMap,
+	optionalKindEndpointSlice,
+	optionalKindIngress,
+	optionalKindLease,
+	optionalKindMutatingWebhookConfiguration,
+	optionalKindPersistentVolume,
+	optionalKindPersistentVolumeClaim,
+	optionalKindPodDisruptionBudget,
+	optionalKindPriorityClass,
+	optionalKindRBAC,
+	optionalKindSecret,
+	optionalKindValidatingWebhookConfiguration,
+}
+
+// ResourcesConfig selects the kinds watched by default that are watched, as
+// lower-cased Kubernetes kinds (e.g. pod). No informer is set up for the other
+// kinds, so that their objects are neither listed nor cached.
+type ResourcesConfig struct {
+	// Kinds to watch. When empty, all the kinds watched by default are.
+	Include []string `mapstructure:"include"`
+	// Kinds not to watch, even if included.
+	Exclude []string `mapstructure:"exclude"`
+}
+
+// defaultKinds are the lower-cased kinds watched by default, which can be
+// selected with resources.
+var defaultKinds = []string{
+	"cronjob", "daemonset", "deployment", "horizontalpodautoscaler", "job", "namespace", "node", "pod",
+	"replicaset", "replicationcontroller", "resourcequota", "service", "statefulset",
+}
+
+// isKindWatched returns whether the given lower-cased kind is selected by
+// resources. Kinds not watched by default are enabled by optional_kinds
+// instead and are always selected.
+func (cfg *Config) isKindWatched(kind string) bool {
+	if !utils.StringSliceToMap(defaultKinds)[kind] {
+		return true
+	}
+	if len(cfg.Resources.Include) > 0 && !utils.StringSliceToMap(cfg.Resources.Include)[kind] {
+		return false
+	}
+	return !utils.StringSliceToMap(cfg.Resources.Exclude)[kind]
+}
+
+func (cfg *Config) isOptionalKindEnabled(kind string) bool {
+	for _, k := range cfg.OptionalKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// spotNodeLabels returns the labels identifying spot nodes. The default is
+// applied here since configured labels would be merged into a default map when
+// unmarshalled rather than replace it.
+func (cfg *Config) spotNodeLabels() map[string]string {
+	if cfg.SpotNodeLabels == nil {
+		return defaultSpotNodeLabels
+	}
+	return cfg.SpotNodeLabels
+}
+
+// nodeLabelSelector returns the label selector of the nodes to watch, or an
+// empty string if all nodes are watched.
+func (cfg *Config) nodeLabelSelector() string {
+	requirements := make([]string, 0, len(cfg.ExcludeNodeLabels))
+	for _, key := range cfg.ExcludeNodeLabels {
+		requirements = append(requirements, "!"+key)
+	}
+	return strings.Join(requirements, ",")
+}
+
+// samplingRules returns the sampling rules of the configured kinds. Selectors
+// are expected to have been validated.
+func (cfg *Config) samplingRules() map[string]collection.SamplingRule {
+	rules := make(map[string]collection.SamplingRule, len(cfg.Sampling))
+	for kind, sc := range cfg.Sampling {
+		rule := collection.SamplingRule{Rate: sc.Rate}
+		if sc.Selector != "" {
+			rule.Selector, _ = labels.Parse(sc.Selector)
+		}
+		rules[kind] = rule
+	}
+	return rules
+}
+
+// excludeManagedByRule returns the rule selecting the managed objects that
+// are not collected.
+func (cfg *Config) excludeManagedByRule() collection.ManagedByRule {
+	return collection.ManagedByRule{
+		Label:      cfg.ExcludeManagedBy.Label,
+		Values:     cfg.ExcludeManagedBy.Values,
+		OwnerKinds: cfg.ExcludeManagedBy.OwnerKinds,
+	}
+}
+
+// disabledMetrics returns the sorted names of the metrics that are disabled.
+func (cfg *Config) disabledMetrics() []string {
+	var names []string
+	for name, mc := range cfg.Metrics {
+		if !mc.Enabled {
+			names = append(names, name)
+		}
+	}
+	for name, mo := range cfg.MetricOverrides {
+		if mo.Drop {
+			if mc, ok := cfg.Metrics[name]; !ok || mc.Enabled {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateKind returns an error if kind, a key of the setting, is not one of
+// the kinds of the objects whose metrics are cached, matched case-insensitively.
+func validateKind(setting, kind string) error {
+	if !utils.StringSliceToMap(collection.CachedKinds)[strings.ToLower(kind)] {
+		return fmt.Errorf("%s: unsupported kind %q, must be one of: %s",
+			setting, kind, strings.Join(collection.CachedKinds, ", "))
+	}
+	return nil
+}
+
+func (cfg *Config) validateMetricOverrides() error {
+	names := make([]string, 0, len(cfg.MetricOverrides))
+	for name := range cfg.MetricOverrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	renamedFrom := map[string]string{}
+	for _, name := range names {
+		mo := cfg.MetricOverrides[name]
+		if mo.Drop && (mo.Name != "" || mo.Unit != "" || len(mo.Attributes) > 0) {
+			return fmt.Errorf("metric_overrides[%s]: drop cannot be combined with name, unit or attributes", name)
+		}
+		if _, ok := mo.Attributes[""]; ok {
+			return fmt.Errorf("metric_overrides[%s]: attribute keys must not be empty", name)
+		}
+		if mo.Name == "" {
+			continue
+		}
+		if other, ok := renamedFrom[mo.Name]; ok {
+			return fmt.Errorf("metric_overrides: %s and %s are both renamed to %s", other, name, mo.Name)
+		}
+		renamedFrom[mo.Name] = name
+	}
+	return nil
+}
+
+// collectionTick returns the interval at which collections run, the shortest
+// of collection_interval and collection_intervals.
+func (cfg *Config) collectionTick() time.Duration {
+	tick := cfg.CollectionInterval
+	for _, interval := range cfg.CollectionIntervals {
+		if interval < tick {
+			tick = interval
+		}
+	}
+	return tick
+}
+
+// metricOverrides returns the overrides of the metrics that are not dropped.
+func (cfg *Config) metricOverrides() map[string]collection.MetricOverride {
+	overrides := make(map[string]collection.MetricOverride, len(cfg.MetricOverrides))
+	for name, mo := range cfg.MetricOverrides {
+		if !mo.Drop {
+			overrides[name] = collection.MetricOverride{Name: mo.Name, Unit: mo.Unit, Attributes: mo.Attributes}
+		}
+	}
+	return overrides
+}
+
+// dropZeroValues returns the names of the metrics whose zero values are
+// dropped. Since they are dropped as metrics are emitted, names are those
+// set by metric_overrides, if any.
+func (cfg *Config) dropZeroValues() []string {
+	names := make([]string, 0, len(cfg.DropZeroValues))
+	for _, name := range cfg.DropZeroValues {
+		if mo, ok := cfg.MetricOverrides[name]; ok && mo.Name != "" {
+			name = mo.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// fieldExtractionRules returns the rules extracting labels and annotations
+// of objects. The rules of extract_annotations are rules extracting pod
+// annotations as well, applied before those of extract. Regular expressions
+// are expected to have been validated.
+func (cfg *Config) fieldExtractionRules() collection.FieldExtractionRules {
+	// The default is applied here rather than set in the default config since
+	// a shorter configured list would not fully replace it when unmarshalled.
+	excluded := cfg.ExcludeAnnotations
+	if excluded == nil {
+		excluded = defaultExcludedAnnotations
+	}
+
+	annotations := make([]collection.FieldExtractionRule, 0, len(cfg.ExtractAnnotations)+len(cfg.Extract.Annotations))
+	for _, ec := range cfg.ExtractAnnotations {
+		annotations = append(annotations, ec.toFieldExtractionRule())
+	}
+	return collection.FieldExtractionRules{
+		Labels:              toFieldExtractionRules(cfg.Extract.Labels),
+		Annotations:         append(annotations, toFieldExtractionRules(cfg.Extract.Annotations)...),
+		ExcludedAnnotations: excluded,
+	}
+}
+
+// toFieldExtractionRule returns the rule extracting pod annotations as
+// configured with extract_annotations: key_prefix selects annotations with a
+// regular expression whose submatch is the rest of the key, and attribute
+// names default to the annotation key rather than to
+// k8s.pod.annotations.<key>.
+func (ec AnnotationExtractionConfig) toFieldExtractionRule() collection.FieldExtractionRule {
+	rule := collection.FieldExtractionRule{Kind: defaultExtractFrom, Key: ec.Key, JSON: ec.JSON}
+	switch {
+	case ec.KeyPrefix != "":
+		rule.Key = ""
+		rule.KeyRegex = regexp.MustCompile("^" + regexp.QuoteMeta(ec.KeyPrefix) + "(.*)$")
+		rule.TagName = strings.ReplaceAll(ec.AttributePrefix, "$", "$$") + "${1}"
+	case ec.JSON:
+		rule.TagName = ec.AttributePrefix
+	case ec.Attribute != "":
+		rule.TagName = ec.Attribute
+	default:
+		rule.TagName = ec.Key
+	}
+	return rule
+}
+
+func toFieldExtractionRules(fcs []FieldExtractConfig) []collection.FieldExtractionRule {
+	rules := make([]collection.FieldExtractionRule, 0, len(fcs))
+	for _, fc := range fcs {
+		rule := collection.FieldExtractionRule{
+			Kind:    fc.From,
+			TagName: fc.TagName,
+			Key:     fc.Key,
+		}
+		if rule.Kind == "" {
+			rule.Kind = defaultExtractFrom
+		}
+		if fc.KeyRegex != "" {
+			rule.KeyRegex = regexp.MustCompile(fc.KeyRegex)
+		}
+		if fc.Regex != "" {
+			rule.Regex = regexp.MustCompile(fc.Regex)
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// expressionMetrics returns the metrics computed from the fields of objects.
+// Expressions are expected to have been validated.
+func (cfg *Config) expressionMetrics() []collection.ExpressionMetric {
+	metrics := make([]collection.ExpressionMetric, 0, len(cfg.ExpressionMetrics))
+	for _, mc := range cfg.ExpressionMetrics {
+		expr, err := collection.ParseExpression(mc.Expression)
+		if err != nil {
+			continue
+		}
+		unit := mc.Unit
+		if unit == "" {
+			unit = "1"
+		}
+		metrics = append(metrics, collection.ExpressionMetric{
+			Kind:        mc.Kind,
+			Name:        mc.Name,
+			Description: mc.Description,
+			Unit:        unit,
+			Expression:  expr,
+		})
+	}
+	return metrics
+}
+
+// customResourceMetrics returns the metrics read from the fields of custom
+// resources. Paths are expected to have been validated.
+func (cfg *Config) customResourceMetrics() []collection.CustomResourceMetrics {
+	crms := make([]collection.CustomResourceMetrics, 0, len(cfg.CustomResources))
+	for _, cr := range cfg.CustomResources {
+		crm := collection.CustomResourceMetrics{
+			GVR:        schema.GroupVersionResource{Group: cr.Group, Version: cr.Version, Resource: cr.Resource},
+			Attributes: make(map[string]*collection.FieldPath, len(cr.Attributes)),
+		}
+		for _, mc := range cr.Metrics {
+			path, err := collection.ParseFieldPath(mc.Path)
+			if err != nil {
+				continue
+			}
+			unit := mc.Unit
+			if unit == "" {
+				unit = "1"
+			}
+			crm.Metrics = append(crm.Metrics, collection.CustomResourceMetric{
+				Name:        mc.Name,
+				Description: mc.Description,
+				Unit:        unit,
+				Path:        path,
+			})
+		}
+		for key, attributePath := range cr.Attributes {
+			path, err := collection.ParseFieldPath(attributePath)
+			if err != nil {
+				continue
+			}
+			crm.Attributes[key] = path
+		}
+		crms = append(crms, crm)
+	}
+	return crms
+}
+
+func (cfg *Config) validate() error {
+	if err := cfg.apiConfig().Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.validateClusters(); err != nil {
+		return err
+	}
+
+	if _, err := labels.Parse(cfg.nodeLabelSelector()); err != nil {
+		return fmt.Errorf("exclude_node_labels: %w", err)
+	}
+
+	conditions := map[string]string{}
+	for _, condition := range cfg.NodeConditionTypesToReport {
+		if condition == "" {
+			return fmt.Errorf("node_conditions_to_report: condition must not be empty")
+		}
+		// Conditions are reported under their snake-cased name.
+		name := strcase.ToSnake(condition)
+		if other, ok := conditions[name]; ok {
+			return fmt.Errorf("node_conditions_to_report: %q and %q are both reported as k8s.node.condition_%s",
+				other, condition, name)
+		}
+		conditions[name] = condition
+	}
+
+	maxObjectsKinds := make([]string, 0, len(cfg.MaxObjects))
+	for kind := range cfg.MaxObjects {
+		maxObjectsKinds = append(maxObjectsKinds, kind)
+	}
+	sort.Strings(maxObjectsKinds)
+	for _, kind := range maxObjectsKinds {
+		if err := validateKind("max_objects", kind); err != nil {
+			return err
+		}
+		if limit := cfg.MaxObjects[kind]; limit < 0 {
+			return fmt.Errorf("max_objects[%s] must not be negative, got %d", kind, limit)
+		}
+	}
+
+	samplingKinds := make([]string, 0, len(cfg.Sampling))
+	for kind := range cfg.Sampling {
+		samplingKinds = append(samplingKinds, kind)
+	}
+	sort.Strings(samplingKinds)
+	for _, kind := range samplingKinds {
+		if err := validateKind("sampling", kind); err != nil {
+			return err
+		}
+		sc := cfg.Sampling[kind]
+		if sc.Rate < 0 {
+			return fmt.Errorf("sampling: rate of %q must not be negative, got %d", kind, sc.Rate)
+		}
+		if _, err := labels.Parse(sc.Selector); err != nil {
+			return fmt.Errorf("sampling: selector of %q: %w", kind, err)
+		}
+	}
+
+	if cfg.ExcludeManagedBy.Label == "" && len(cfg.ExcludeManagedBy.Values) > 0 {
+		return fmt.Errorf("exclude_managed_by: label must be set along with values")
+	}
+	for _, phase := range cfg.ExcludePodPhases {
+		if !utils.StringSliceToMap(collection.ExcludablePodPhases)[phase] {
+			return fmt.Errorf("exclude_pod_phases: unsupported phase %q, must be one of: %s",
+				phase, strings.Join(collection.ExcludablePodPhases, ", "))
+		}
+	}
+
+	if cfg.DeletionGracePeriod < 0 {
+		return fmt.Errorf("deletion_grace_period must not be negative, got %s", cfg.DeletionGracePeriod)
+	}
+
+	if cfg.FullResyncInterval < 0 {
+		return fmt.Errorf("full_resync_interval must not be negative, got %s", cfg.FullResyncInterval)
+	}
+	if cfg.FullResyncInterval > 0 && !cfg.SkipUnchanged {
+		return fmt.Errorf("full_resync_interval requires skip_unchanged")
+	}
+
+	if cfg.FlushThreshold < 0 {
+		return fmt.Errorf("flush_threshold must not be negative, got %d", cfg.FlushThreshold)
+	}
+
+	if cfg.PushQueueSize < 0 {
+		return fmt.Errorf("push_queue_size must not be negative, got %d", cfg.PushQueueSize)
+	}
+	switch cfg.PushQueuePolicy {
+	case pushQueuePolicyBlock, pushQueuePolicyDrop:
+	default:
+		return fmt.Errorf("push_queue_policy must be one of %q or %q, got %q",
+			pushQueuePolicyBlock, pushQueuePolicyDrop, cfg.PushQueuePolicy)
+	}
+	if cfg.PushTimeout < 0 {
+		return fmt.Errorf("push_timeout must not be negative, got %s", cfg.PushTimeout)
+	}
+
+	if r := cfg.PushRetry; r.QueueSize < 0 {
+		return fmt.Errorf("push_retry queue_size must not be negative, got %d", r.QueueSize)
+	} else if r.QueueSize > 0 && (r.InitialBackoff <= 0 || r.MaxBackoff < r.InitialBackoff) {
+		return fmt.Errorf("push_retry must have 0 < initial_backoff <= max_backoff, got initial_backoff %s and max_backoff %s",
+			r.InitialBackoff, r.MaxBackoff)
+	}
+
+	switch cfg.PushMode {
+	case pushModeInterval, pushModeOnChange:
+	default:
+		return fmt.Errorf("push_mode must be one of %q or %q, got %q",
+			pushModeInterval, pushModeOnChange, cfg.PushMode)
+	}
+	if cfg.WarmupIntervals < 0 {
+		return fmt.Errorf("warmup_intervals must not be negative, got %d", cfg.WarmupIntervals)
+	}
+	if cfg.WarmupIntervals > 0 && cfg.PushMode == pushModeOnChange {
+		return fmt.Errorf("warmup_intervals can only be set with push_mode %q", pushModeInterval)
+	}
+	switch cfg.GroupBy {
+	case groupByNone, groupByNamespace:
+	default:
+		return fmt.Errorf("group_by must be one of %q or %q, got %q",
+			groupByNone, groupByNamespace, cfg.GroupBy)
+	}
+	switch cfg.Distribution {
+	case distributionKubernetes, distributionOpenShift:
+	default:
+		return fmt.Errorf("distribution must be one of %q or %q, got %q",
+			distributionKubernetes, distributionOpenShift, cfg.Distribution)
+	}
+	if le := cfg.LeaderElection; le.Enabled {
+		if le.LeaseName == "" || le.LeaseNamespace == "" {
+			return fmt.Errorf("leader_election: lease_name and lease_namespace must be set")
+		}
+		if le.RetryPeriod <= 0 || le.RenewDeadline <= time.Duration(leaderelection.JitterFactor*float64(le.RetryPeriod)) ||
+			le.LeaseDuration <= le.RenewDeadline {
+			return fmt.Errorf("leader_election must have lease_duration > renew_deadline > %v * retry_period > 0, "+
+				"got lease_duration %s, renew_deadline %s and retry_period %s",
+				leaderelection.JitterFactor, le.LeaseDuration, le.RenewDeadline, le.RetryPeriod)
+		}
+	}
+	eventTypes := make([]string, 0, len(cfg.Events.Severity))
+	for eventType := range cfg.Events.Severity {
+		eventTypes = append(eventTypes, eventType)
+	}
+	sort.Strings(eventTypes)
+	for _, eventType := range eventTypes {
+		if eventType != "normal" && eventType != "warning" {
+			return fmt.Errorf("events.severity: unsupported event type %q, must be one of: normal, warning", eventType)
+		}
+		if severity := cfg.Events.Severity[eventType]; eventSeverities[severity] == 0 {
+			return fmt.Errorf("events.severity: unsupported severity %q for event type %q, must be one of: %s",
+				severity, eventType, strings.Join(eventSeverityNames, ", "))
+		}
+	}
+	if cfg.Relationships.Enabled && cfg.Relationships.Interval <= 0 {
+		return fmt.Errorf("relationships.interval must be positive, got %s", cfg.Relationships.Interval)
+	}
+	kinds := make([]string, 0, len(cfg.CollectionIntervals))
+	for kind := range cfg.CollectionIntervals {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		if err := validateKind("collection_intervals", kind); err != nil {
+			return err
+		}
+		if interval := cfg.CollectionIntervals[kind]; interval <= 0 {
+			return fmt.Errorf("collection_intervals[%s] must be positive, got %s", kind, interval)
+		}
+	}
+	if cfg.ImageMetadata.Enricher != "" && !cfg.ImageMetadata.Enabled {
+		return fmt.Errorf("image_metadata.enricher requires image_metadata.enabled")
+	}
+	if cfg.InitialSyncTimeout <= 0 {
+		return fmt.Errorf("initial_sync_timeout must be positive, got %s", cfg.InitialSyncTimeout)
+	}
+	if err := cfg.validateMetricOverrides(); err != nil {
+		return err
+	}
+	if cfg.PushDebounce < 0 {
+		return fmt.Errorf("push_debounce must not be negative, got %s", cfg.PushDebounce)
+	}
+	if b := cfg.RelistBackoff; b.Max != 0 && (b.Min <= 0 || b.Max < b.Min) {
+		return fmt.Errorf("relist_backoff must have 0 < min <= max, got min %s and max %s", b.Min, b.Max)
+	}
+
+	switch cfg.PodAggregation {
+	case collection.PodAggregationNone, collection.PodAggregationOwner:
+	default:
+		return fmt.Errorf("pod_aggregation must be one of %q or %q, got %q",
+			collection.PodAggregationNone, collection.PodAggregationOwner, cfg.PodAggregation)
+	}
+
+	switch cfg.ClusterCapacityNodes {
+	case collection.ClusterCapacityNodesAll, collection.ClusterCapacityNodesReady,
+		collection.ClusterCapacityNodesSchedulable:
+	default:
+		return fmt.Errorf("cluster_capacity_nodes must be one of %q, %q or %q, got %q",
+			collection.ClusterCapacityNodesAll, collection.ClusterCapacityNodesReady,
+			collection.ClusterCapacityNodesSchedulable, cfg.ClusterCapacityNodes)
+	}
+
+	switch cfg.GaugeValueType {
+	case collection.GaugeValueTypeInt, collection.GaugeValueTypeDouble:
+	default:
+		return fmt.Errorf("gauge_value_type must be one of %q or %q, got %q",
+			collection.GaugeValueTypeInt, collection.GaugeValueTypeDouble, cfg.GaugeValueType)
+	}
+
+	for _, key := range cfg.IncludeResourceAttributes {
+		if key == "" {
+			return fmt.Errorf("include_resource_attributes: empty key")
+		}
+	}
+
+	froms := make([]string, 0, len(cfg.ResourceAttributeKeys))
+	for from := range cfg.ResourceAttributeKeys {
+		froms = append(froms, from)
+	}
+	sort.Strings(froms)
+	renamedFrom := map[string]string{}
+	for _, from := range froms {
+		to := cfg.ResourceAttributeKeys[from]
+		if to == "" {
+			return fmt.Errorf("resource_attribute_keys: empty key for %q", from)
+		}
+		if other, ok := renamedFrom[to]; ok {
+			return fmt.Errorf("resource_attribute_keys: both %q and %q are renamed to %q", other, from, to)
+		}
+		renamedFrom[to] = from
+	}
+
+	switch cfg.AttributeKeyStyle {
+	case collection.AttributeKeyStyleDot, collection.AttributeKeyStyleUnderscore, collection.AttributeKeyStyleCamelCase:
+	default:
+		return fmt.Errorf("attribute_key_style must be one of %q, %q or %q, got %q",
+			collection.AttributeKeyStyleDot, collection.AttributeKeyStyleUnderscore,
+			collection.AttributeKeyStyleCamelCase, cfg.AttributeKeyStyle)
+	}
+
+	if cfg.MaxAttributeValueLength < 0 ||
+		(cfg.MaxAttributeValueLength > 0 && cfg.MaxAttributeValueLength <= len(collection.TruncatedValueSuffix)) {
+		return fmt.Errorf("max_attribute_value_length must be 0 or greater than %d, got %d",
+			len(collection.TruncatedValueSuffix), cfg.MaxAttributeValueLength)
+	}
+
+	switch cfg.DatapointAttributes.Mode {
+	case collection.DatapointAttributesModeCopy, collection.DatapointAttributesModeMove:
+	default:
+		return fmt.Errorf("datapoint_attributes: mode must be one of %q or %q, got %q",
+			collection.DatapointAttributesModeCopy, collection.DatapointAttributesModeMove, cfg.DatapointAttributes.Mode)
+	}
+	for _, k := range cfg.DatapointAttributes.Keys {
+		if k == "" {
+			return fmt.Errorf("datapoint_attributes: empty key")
+		}
+	}
+
+	if cfg.ResourceQuotaThreshold <= 0 {
+		return fmt.Errorf("resource_quota_threshold must be positive, got %v", cfg.ResourceQuotaThreshold)
+	}
+
+	// Rules of extract_annotations and of extract.annotations extracting
+	// from pods are applied together, the former first, and the first rule
+	// extracting an attribute wins. Both leave out exclude_annotations.
+	for i, ec := range cfg.ExtractAnnotations {
+		switch {
+		case (ec.Key == "") == (ec.KeyPrefix == ""):
+			return fmt.Errorf("extract_annotations[%d]: exactly one of key or key_prefix must be set", i)
+		case ec.JSON && ec.Key == "":
+			return fmt.Errorf("extract_annotations[%d]: json requires key", i)
+		case ec.Attribute != "" && (ec.Key == "" || ec.JSON):
+			return fmt.Errorf("extract_annotations[%d]: attribute can only be set with key", i)
+		}
+	}
+
+	for i, fc := range cfg.Extract.Labels {
+		if err := fc.validate(); err != nil {
+			return fmt.Errorf("extract.labels[%d]: %w", i, err)
+		}
+	}
+	for i, fc := range cfg.Extract.Annotations {
+		if err := fc.validate(); err != nil {
+			return fmt.Errorf("extract.annotations[%d]: %w", i, err)
+		}
+	}
+
+	for i, mc := range cfg.ExpressionMetrics {
+		if mc.Name == "" {
+			return fmt.Errorf("expression_metrics[%d]: name must be set", i)
+		}
+		if !utils.StringSliceToMap(collection.ExpressionMetricKinds)[mc.Kind] {
+			return fmt.Errorf("expression_metrics[%d]: unsupported kind %q, must be one of: %s",
+				i, mc.Kind, strings.Join(collection.ExpressionMetricKinds, ", "))
+		}
+		if _, err := collection.ParseExpression(mc.Expression); err != nil {
+			return fmt.Errorf("expression_metrics[%d]: invalid expression %q: %w", i, mc.Expression, err)
+		}
+	}
+
+	for _, kind := range cfg.OptionalKinds {
+		if !utils.StringSliceToMap(supportedOptionalKinds)[kind] {
+			return fmt.Errorf("optional_kinds: unsupported kind %q, must be one of: %s",
+				kind, strings.Join(supportedOptionalKinds, ", "))
+		}
+	}
+
+	for _, kind := range cfg.Resources.Include {
+		if !utils.StringSliceToMap(defaultKinds)[kind] {
+			return fmt.Errorf("resources.include: unsupported kind %q, must be one of: %s",
+				kind, strings.Join(defaultKinds, ", "))
+		}
+	}
+	for _, kind := range cfg.Resources.Exclude {
+		if !utils.StringSliceToMap(defaultKinds)[kind] {
+			return fmt.Errorf("resources.exclude: unsupported kind %q, must be one of: %s",
+				kind, strings.Join(defaultKinds, ", "))
+		}
+	}
+
+	seenNamespaces := make(map[string]bool, len(cfg.Namespaces))
+	for _, namespace := range cfg.Namespaces {
+		if namespace == "" {
+			return fmt.Errorf("namespaces: empty namespace")
+		}
+		if seenNamespaces[namespace] {
+			return fmt.Errorf("namespaces: duplicate namespace %q", namespace)
+		}
+		seenNamespaces[namespace] = true
+	}
+	if cfg.Sharding.Count < 0 {
+		return fmt.Errorf("sharding.count must not be negative, got %d", cfg.Sharding.Count)
+	}
+	if cfg.Sharding.Count > 0 && (cfg.Sharding.Index < 0 || cfg.Sharding.Index >= cfg.Sharding.Count) {
+		return fmt.Errorf("sharding.index must be between 0 and %d, got %d",
+			cfg.Sharding.Count-1, cfg.Sharding.Index)
+	}
+	if cfg.InformerResyncPeriod < 0 {
+		return fmt.Errorf("informer_resync_period must not be negative, got %s", cfg.InformerResyncPeriod)
+	}
+	if cfg.ListPageSize < 0 {
+		return fmt.Errorf("list_page_size must not be negative, got %d", cfg.ListPageSize)
+	}
+	seenGVRs := make(map[schema.GroupVersionResource]bool, len(cfg.CustomResources))
+	for i, cr := range cfg.CustomResources {
+		if cr.Version == "" || cr.Resource == "" {
+			return fmt.Errorf("custom_resources[%d]: version and resource must be set", i)
+		}
+		gvr := schema.GroupVersionResource{Group: cr.Group, Version: cr.Version, Resource: cr.Resource}
+		if seenGVRs[gvr] {
+			return fmt.Errorf("custom_resources[%d]: duplicate resource %q", i, gvr.String())
+		}
+		seenGVRs[gvr] = true
+		if len(cr.Metrics) == 0 {
+			return fmt.Errorf("custom_resources[%d]: at least one metric must be set", i)
+		}
+		for j, mc := range cr.Metrics {
+			if mc.Name == "" || mc.Path == "" {
+				return fmt.Errorf("custom_resources[%d].metrics[%d]: name and path must be set", i, j)
+			}
+			if _, err := collection.ParseFieldPath(mc.Path); err != nil {
+				return fmt.Errorf("custom_resources[%d].metrics[%d]: invalid path %q: %w", i, j, mc.Path, err)
+			}
+		}
+		for key, path := range cr.Attributes {
+			if _, err := collection.ParseFieldPath(path); err != nil {
+				return fmt.Errorf("custom_resources[%d].attributes[%q]: invalid path %q: %w", i, key, path, err)
+			}
+		}
+	}
+	if len(cfg.Namespaces) > 0 && cfg.CustomResourceDefinitions.Enabled {
+		return fmt.Errorf("custom_resource_definitions cannot be enabled along with namespaces, " +
+			"CustomResourceDefinitions are cluster-scoped")
+	}
+	if cfg.Sharding.Count > 0 && cfg.CustomResourceDefinitions.Enabled {
+		return fmt.Errorf("custom_resource_definitions cannot be enabled along with sharding, " +
+			"CustomResourceDefinitions are cluster-scoped")
+	}
+
+	if err := validateAPIVersions(cfg.APIVersions); err != nil {
+		return err
+	}
+	switch cfg.DuplicateObjects {
+	case duplicateObjectsFirstSource, duplicateObjectsAllSources:
+	default:
+		return fmt.Errorf("duplicate_objects must be one of %q or %q, got %q",
+			duplicateObjectsFirstSource, duplicateObjectsAllSources, cfg.DuplicateObjects)
+	}
+
+	if err := collection.ValidateLabelInfoKinds(cfg.LabelInfoKinds); err != nil {
+		return fmt.Errorf("label_info_kinds: %w", err)
+	}
+
+	return collection.ValidateUnits(cfg.Units)
+}
+
+// validateClusters validates clusters, whose receivers share the settings of
+// the collector they run in, e.g. the endpoints it listens on.
+func (cfg *Config) validateClusters() error {
+	if len(cfg.Clusters) == 0 {
+		return nil
+	}
+	if cfg.AuthType != k8sconfig.AuthTypeKubeConfig {
+		return fmt.Errorf("clusters can only be set with auth_type=%s", k8sconfig.AuthTypeKubeConfig)
+	}
+	for _, setting := range []struct{ name, value string }{
+		{"api_server_host", cfg.APIServerHost},
+		{"kubeconfig_context", cfg.KubeConfigContext},
+		{"cluster_name", cfg.ClusterName},
+		{"readiness_endpoint", cfg.ReadinessEndpoint},
+		{"control_endpoint", cfg.ControlEndpoint},
+	} {
+		if setting.value != "" {
+			return fmt.Errorf("%s cannot be set along with clusters", setting.name)
+		}
+	}
+	contexts, names := map[string]bool{}, map[string]bool{}
+	for i, c := range cfg.Clusters {
+		if c.KubeConfigContext == "" || c.Name == "" {
+			return fmt.Errorf("clusters[%d]: kubeconfig_context and cluster_name must be set", i)
+		}
+		if contexts[c.KubeConfigContext] {
+			return fmt.Errorf("clusters[%d]: context %q is already collected", i, c.KubeConfigContext)
+		}
+		if names[c.Name] {
+			return fmt.Errorf("clusters[%d]: cluster name %q is already used", i, c.Name)
+		}
+		contexts[c.KubeConfigContext], names[c.Name] = true, true
+	}
+	return nil
+}
+
+// clusterConfigs returns the configuration of the receiver of each cluster of
+// clusters, or the configuration itself if clusters is not set.
+func (cfg *Config) clusterConfigs() []*Config {
+	if len(cfg.Clusters) == 0 {
+		return []*Config{cfg}
+	}
+	cfgs := make([]*Config, 0, len(cfg.Clusters))
+	for _, c := range cfg.Clusters {
+		clusterCfg := *cfg
+		clusterCfg.Clusters = nil
+		clusterCfg.KubeConfigContext = c.KubeConfigContext
+		clusterCfg.ClusterName = c.Name
+		cfgs = append(cfgs, &clusterCfg)
+	}
+	return cfgs
 }
 
 func (cfg *Config) getK8sClient() (k8s.Interface, error) {
 	if cfg.makeClient == nil {
 		cfg.makeClient = k8sconfig.MakeClient
 	}
-	return cfg.makeClient(cfg.APIConfig)
+	return cfg.makeClient(cfg.apiConfig())
+}
+
+func (cfg *Config) getDynamicClient() (dynamic.Interface, error) {
+	if cfg.makeDynamicClient == nil {
+		cfg.makeDynamicClient = k8sconfig.MakeDynamicClient
+	}
+	return cfg.makeDynamicClient(cfg.apiConfig())
+}
+
+// apiConfig returns the APIConfig along with the API server and client
+// settings of the receiver.
+func (cfg *Config) apiConfig() k8sconfig.APIConfig {
+	apiConf := cfg.APIConfig
+	apiConf.Server = k8sconfig.ServerConfig{
+		Host:               cfg.APIServerHost,
+		CAFile:             cfg.CAFile,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+		Context:            cfg.KubeConfigContext,
+	}
+	apiConf.Client = k8sconfig.ClientConfig{
+		QPS:        cfg.APIQPS,
+		Burst:      cfg.APIBurst,
+		Timeout:    cfg.APITimeout,
+		OnThrottle: observability.RecordClientThrottled,
+	}
+	return apiConf
+}
+
+func (fc FieldExtractConfig) validate() error {
+	if (fc.Key == "") == (fc.KeyRegex == "") {
+		return fmt.Errorf("exactly one of key or key_regex must be set")
+	}
+	if fc.From != "" && !utils.StringSliceToMap(collection.FieldExtractionKinds)[fc.From] {
+		return fmt.Errorf("unsupported kind %q, must be one of: %s",
+			fc.From, strings.Join(collection.FieldExtractionKinds, ", "))
+	}
+	if fc.KeyRegex != "" {
+		if _, err := regexp.Compile(fc.KeyRegex); err != nil {
+			return fmt.Errorf("invalid key_regex %q: %w", fc.KeyRegex, err)
+		}
+	}
+	if fc.Regex != "" {
+		r, err := regexp.Compile(fc.Regex)
+		if err != nil {
+			return fmt.Errorf("invalid regex %q: %w", fc.Regex, err)
+		}
+		if !utils.StringSliceToMap(r.SubexpNames())["value"] {
+			return fmt.Errorf("regex %q must have a submatch named value", fc.Regex)
+		}
+	}
+	return nil
 }
@@ -24,8 +24,10 @@ import (
 	"go.opentelemetry.io/collector/component/componenttest"
 	"go.opentelemetry.io/collector/config/configmodels"
 	"go.opentelemetry.io/collector/config/configtest"
+	"k8s.io/client-go/kubernetes"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/k8sconfig"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/collection"
 )
 
 func TestLoadConfig(t *testing.T) {
@@ -57,6 +59,32 @@ func TestLoadConfig(t *testing.T) {
 			CollectionInterval:         30 * time.Second,
 			NodeConditionTypesToReport: []string{"Ready", "MemoryPressure"},
 			MetadataExporters:          []string{"exampleexporter"},
+			EventQueueSize:             1000,
+			PodAggregation:             "none",
+			ClusterCapacityNodes:       "ready",
+			GaugeValueType:             "int",
+			AttributeKeyStyle:          "dot",
+			MaxAttributeValueLength:    4096,
+			ExcludeManagedBy:           ManagedByConfig{Label: "app.kubernetes.io/managed-by"},
+			DatapointAttributes:        DatapointAttributesConfig{Mode: "copy"},
+			ResourceQuotaThreshold:     0.9,
+			InstanceTypeLabel:          "node.kubernetes.io/instance-type",
+			PushQueuePolicy:            "block",
+			PushMode:                   "interval",
+			DuplicateObjects:           "first_source",
+			InitialSyncTimeout:         10 * time.Minute,
+			PushDebounce:               time.Second,
+			PushRetry:                  PushRetryConfig{InitialBackoff: time.Second, MaxBackoff: 30 * time.Second},
+			GroupBy:                    "none",
+			Distribution:               "kubernetes",
+			Events:                     EventsConfig{Severity: map[string]string{"normal": "info", "warning": "warn"}},
+			Relationships:              RelationshipsConfig{Interval: time.Minute},
+			LeaderElection: LeaderElectionConfig{
+				LeaseName:     "k8s-cluster-receiver",
+				LeaseDuration: 15 * time.Second,
+				RenewDeadline: 10 * time.Second,
+				RetryPeriod:   2 * time.Second,
+			},
 			APIConfig: k8sconfig.APIConfig{
 				AuthType: k8sconfig.AuthTypeServiceAccount,
 			},
@@ -71,8 +99,947 @@ func TestLoadConfig(t *testing.T) {
 			},
 			CollectionInterval:         30 * time.Second,
 			NodeConditionTypesToReport: []string{"Ready"},
+			EventQueueSize:             1000,
+			PodAggregation:             "none",
+			ClusterCapacityNodes:       "ready",
+			GaugeValueType:             "int",
+			AttributeKeyStyle:          "dot",
+			MaxAttributeValueLength:    4096,
+			ExcludeManagedBy:           ManagedByConfig{Label: "app.kubernetes.io/managed-by"},
+			DatapointAttributes:        DatapointAttributesConfig{Mode: "copy"},
+			ResourceQuotaThreshold:     0.9,
+			InstanceTypeLabel:          "node.kubernetes.io/instance-type",
+			PushQueuePolicy:            "block",
+			PushMode:                   "interval",
+			DuplicateObjects:           "first_source",
+			InitialSyncTimeout:         10 * time.Minute,
+			PushDebounce:               time.Second,
+			PushRetry:                  PushRetryConfig{InitialBackoff: time.Second, MaxBackoff: 30 * time.Second},
+			GroupBy:                    "none",
+			Distribution:               "kubernetes",
+			Events:                     EventsConfig{Severity: map[string]string{"normal": "info", "warning": "warn"}},
+			Relationships:              RelationshipsConfig{Interval: time.Minute},
+			LeaderElection: LeaderElectionConfig{
+				LeaseName:     "k8s-cluster-receiver",
+				LeaseDuration: 15 * time.Second,
+				RenewDeadline: 10 * time.Second,
+				RetryPeriod:   2 * time.Second,
+			},
 			APIConfig: k8sconfig.APIConfig{
 				AuthType: k8sconfig.AuthTypeServiceAccount,
 			},
 		})
 }
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      func(cfg *Config)
+		expectedErr string
+	}{
+		{
+			name:   "default",
+			config: func(cfg *Config) {},
+		},
+		{
+			name: "api_server_host",
+			config: func(cfg *Config) {
+				cfg.APIServerHost = "https://proxy.example.com:8443"
+				cfg.CAFile = "/etc/proxy/ca.crt"
+			},
+		},
+		{
+			name: "ca_file with insecure_skip_verify",
+			config: func(cfg *Config) {
+				cfg.CAFile = "/etc/proxy/ca.crt"
+				cfg.InsecureSkipVerify = true
+			},
+			expectedErr: "ca_file cannot be set along with insecure_skip_verify",
+		},
+		{
+			name: "kubeconfig_context",
+			config: func(cfg *Config) {
+				cfg.AuthType = k8sconfig.AuthTypeKubeConfig
+				cfg.KubeConfigContext = "staging"
+			},
+		},
+		{
+			name: "kubeconfig_context without kubeConfig",
+			config: func(cfg *Config) {
+				cfg.KubeConfigContext = "staging"
+			},
+			expectedErr: "a kubeconfig context can only be set with auth_type=kubeConfig",
+		},
+		{
+			name: "clusters",
+			config: func(cfg *Config) {
+				cfg.AuthType = k8sconfig.AuthTypeKubeConfig
+				cfg.Clusters = []ClusterConfig{
+					{KubeConfigContext: "production", Name: "production"},
+					{KubeConfigContext: "staging", Name: "staging"},
+				}
+			},
+		},
+		{
+			name: "clusters without kubeConfig",
+			config: func(cfg *Config) {
+				cfg.Clusters = []ClusterConfig{{KubeConfigContext: "production", Name: "production"}}
+			},
+			expectedErr: "clusters can only be set with auth_type=kubeConfig",
+		},
+		{
+			name: "clusters with cluster_name",
+			config: func(cfg *Config) {
+				cfg.AuthType = k8sconfig.AuthTypeKubeConfig
+				cfg.ClusterName = "production"
+				cfg.Clusters = []ClusterConfig{{KubeConfigContext: "production", Name: "production"}}
+			},
+			expectedErr: "cluster_name cannot be set along with clusters",
+		},
+		{
+			name: "clusters with readiness_endpoint",
+			config: func(cfg *Config) {
+				cfg.AuthType = k8sconfig.AuthTypeKubeConfig
+				cfg.ReadinessEndpoint = "localhost:13134"
+				cfg.Clusters = []ClusterConfig{{KubeConfigContext: "production", Name: "production"}}
+			},
+			expectedErr: "readiness_endpoint cannot be set along with clusters",
+		},
+		{
+			name: "cluster without name",
+			config: func(cfg *Config) {
+				cfg.AuthType = k8sconfig.AuthTypeKubeConfig
+				cfg.Clusters = []ClusterConfig{{KubeConfigContext: "production"}}
+			},
+			expectedErr: "clusters[0]: kubeconfig_context and cluster_name must be set",
+		},
+		{
+			name: "clusters with the same context",
+			config: func(cfg *Config) {
+				cfg.AuthType = k8sconfig.AuthTypeKubeConfig
+				cfg.Clusters = []ClusterConfig{
+					{KubeConfigContext: "production", Name: "production"},
+					{KubeConfigContext: "production", Name: "staging"},
+				}
+			},
+			expectedErr: `clusters[1]: context "production" is already collected`,
+		},
+		{
+			name: "clusters with the same name",
+			config: func(cfg *Config) {
+				cfg.AuthType = k8sconfig.AuthTypeKubeConfig
+				cfg.Clusters = []ClusterConfig{
+					{KubeConfigContext: "production", Name: "production"},
+					{KubeConfigContext: "staging", Name: "production"},
+				}
+			},
+			expectedErr: `clusters[1]: cluster name "production" is already used`,
+		},
+		{
+			name: "api_qps",
+			config: func(cfg *Config) {
+				cfg.APIQPS = 50
+				cfg.APIBurst = 100
+			},
+		},
+		{
+			name: "negative api_burst",
+			config: func(cfg *Config) {
+				cfg.APIBurst = -1
+			},
+			expectedErr: "api_burst must not be negative, got -1",
+		},
+		{
+			name: "exclude_node_labels",
+			config: func(cfg *Config) {
+				cfg.ExcludeNodeLabels = []string{"node-role.kubernetes.io/control-plane", "node-role.kubernetes.io/master"}
+			},
+		},
+		{
+			name: "invalid exclude_node_labels",
+			config: func(cfg *Config) {
+				cfg.ExcludeNodeLabels = []string{"node role"}
+			},
+			expectedErr: "exclude_node_labels: ",
+		},
+		{
+			name: "negative deletion_grace_period",
+			config: func(cfg *Config) {
+				cfg.DeletionGracePeriod = -time.Second
+			},
+			expectedErr: "deletion_grace_period must not be negative, got -1s",
+		},
+		{
+			name: "max_objects",
+			config: func(cfg *Config) {
+				cfg.MaxObjects = map[string]int{"pod": 50000, "Job": 0}
+			},
+		},
+		{
+			name: "max_objects with unsupported kind",
+			config: func(cfg *Config) {
+				cfg.MaxObjects = map[string]int{"pods": 50000}
+			},
+			expectedErr: `max_objects: unsupported kind "pods", must be one of: cronjob, daemonset, `,
+		},
+		{
+			name: "negative max_objects",
+			config: func(cfg *Config) {
+				cfg.MaxObjects = map[string]int{"job": -1}
+			},
+			expectedErr: "max_objects[job] must not be negative, got -1",
+		},
+		{
+			name: "sampling with unsupported kind",
+			config: func(cfg *Config) {
+				cfg.Sampling = map[string]SamplingConfig{"jobs": {Rate: 10}}
+			},
+			expectedErr: `sampling: unsupported kind "jobs", must be one of: `,
+		},
+		{
+			name: "negative sampling rate",
+			config: func(cfg *Config) {
+				cfg.Sampling = map[string]SamplingConfig{"job": {Rate: -1}}
+			},
+			expectedErr: `sampling: rate of "job" must not be negative, got -1`,
+		},
+		{
+			name: "invalid sampling selector",
+			config: func(cfg *Config) {
+				cfg.Sampling = map[string]SamplingConfig{"pod": {Selector: "team in (payments"}}
+			},
+			expectedErr: `sampling: selector of "pod": `,
+		},
+		{
+			name: "negative flush_threshold",
+			config: func(cfg *Config) {
+				cfg.FlushThreshold = -1
+			},
+			expectedErr: "flush_threshold must not be negative, got -1",
+		},
+		{
+			name: "negative push_queue_size",
+			config: func(cfg *Config) {
+				cfg.PushQueueSize = -1
+			},
+			expectedErr: "push_queue_size must not be negative, got -1",
+		},
+		{
+			name: "invalid push_queue_policy",
+			config: func(cfg *Config) {
+				cfg.PushQueuePolicy = "retry"
+			},
+			expectedErr: `push_queue_policy must be one of "block" or "drop", got "retry"`,
+		},
+		{
+			name: "negative push_timeout",
+			config: func(cfg *Config) {
+				cfg.PushTimeout = -time.Second
+			},
+			expectedErr: "push_timeout must not be negative, got -1s",
+		},
+		{
+			name: "invalid push_mode",
+			config: func(cfg *Config) {
+				cfg.PushMode = "batch"
+			},
+			expectedErr: `push_mode must be one of "interval" or "on_change", got "batch"`,
+		},
+		{
+			name: "negative warmup_intervals",
+			config: func(cfg *Config) {
+				cfg.WarmupIntervals = -1
+			},
+			expectedErr: "warmup_intervals must not be negative, got -1",
+		},
+		{
+			name: "warmup_intervals with push_mode on_change",
+			config: func(cfg *Config) {
+				cfg.WarmupIntervals = 3
+				cfg.PushMode = pushModeOnChange
+			},
+			expectedErr: `warmup_intervals can only be set with push_mode "interval"`,
+		},
+		{
+			name: "invalid group_by",
+			config: func(cfg *Config) {
+				cfg.GroupBy = "node"
+			},
+			expectedErr: `group_by must be one of "none" or "namespace", got "node"`,
+		},
+		{
+			name: "relist_backoff without min",
+			config: func(cfg *Config) {
+				cfg.RelistBackoff = RelistBackoffConfig{Max: time.Minute}
+			},
+			expectedErr: "relist_backoff must have 0 < min <= max, got min 0s and max 1m0s",
+		},
+		{
+			name: "relist_backoff max below min",
+			config: func(cfg *Config) {
+				cfg.RelistBackoff = RelistBackoffConfig{Min: time.Minute, Max: time.Second}
+			},
+			expectedErr: "relist_backoff must have 0 < min <= max, got min 1m0s and max 1s",
+		},
+		{
+			name: "non-positive resource_quota_threshold",
+			config: func(cfg *Config) {
+				cfg.ResourceQuotaThreshold = 0
+			},
+			expectedErr: "resource_quota_threshold must be positive, got 0",
+		},
+		{
+			name: "negative push_debounce",
+			config: func(cfg *Config) {
+				cfg.PushDebounce = -time.Second
+			},
+			expectedErr: "push_debounce must not be negative, got -1s",
+		},
+		{
+			name: "invalid pod_aggregation",
+			config: func(cfg *Config) {
+				cfg.PodAggregation = "namespace"
+			},
+			expectedErr: `pod_aggregation must be one of "none" or "owner", got "namespace"`,
+		},
+		{
+			name: "invalid cluster_capacity_nodes",
+			config: func(cfg *Config) {
+				cfg.ClusterCapacityNodes = "cordoned"
+			},
+			expectedErr: `cluster_capacity_nodes must be one of "all", "ready" or "schedulable", got "cordoned"`,
+		},
+		{
+			name: "invalid gauge_value_type",
+			config: func(cfg *Config) {
+				cfg.GaugeValueType = "float"
+			},
+			expectedErr: `gauge_value_type must be one of "int" or "double", got "float"`,
+		},
+		{
+			name: "invalid attribute_key_style",
+			config: func(cfg *Config) {
+				cfg.AttributeKeyStyle = "kebab_case"
+			},
+			expectedErr: `attribute_key_style must be one of "dot", "underscore" or "camel_case", got "kebab_case"`,
+		},
+		{
+			name: "invalid units",
+			config: func(cfg *Config) {
+				cfg.Units = map[string]string{"memory": "cores"}
+			},
+			expectedErr: `unsupported unit "cores" for resource "memory", must be one of: bytes, gibibytes, kibibytes, mebibytes`,
+		},
+		{
+			name: "resource_attribute_keys",
+			config: func(cfg *Config) {
+				cfg.ResourceAttributeKeys = map[string]string{
+					"k8s.namespace.name": "namespace",
+					"k8s.pod.name":       "pod",
+				}
+			},
+		},
+		{
+			name: "empty included resource attribute",
+			config: func(cfg *Config) {
+				cfg.IncludeResourceAttributes = []string{"k8s.pod.name", ""}
+			},
+			expectedErr: "include_resource_attributes: empty key",
+		},
+		{
+			name: "empty resource attribute key",
+			config: func(cfg *Config) {
+				cfg.ResourceAttributeKeys = map[string]string{"k8s.pod.name": ""}
+			},
+			expectedErr: `resource_attribute_keys: empty key for "k8s.pod.name"`,
+		},
+		{
+			name: "duplicate resource attribute key",
+			config: func(cfg *Config) {
+				cfg.ResourceAttributeKeys = map[string]string{
+					"k8s.pod.name":  "name",
+					"k8s.node.name": "name",
+				}
+			},
+			expectedErr: `resource_attribute_keys: both "k8s.node.name" and "k8s.pod.name" are renamed to "name"`,
+		},
+		{
+			name: "negative max_attribute_value_length",
+			config: func(cfg *Config) {
+				cfg.MaxAttributeValueLength = -1
+			},
+			expectedErr: "max_attribute_value_length must be 0 or greater than 3, got -1",
+		},
+		{
+			name: "max_attribute_value_length shorter than suffix",
+			config: func(cfg *Config) {
+				cfg.MaxAttributeValueLength = 3
+			},
+			expectedErr: "max_attribute_value_length must be 0 or greater than 3, got 3",
+		},
+		{
+			name: "max_attribute_value_length disabled",
+			config: func(cfg *Config) {
+				cfg.MaxAttributeValueLength = 0
+			},
+		},
+		{
+			name: "exclude_managed_by",
+			config: func(cfg *Config) {
+				cfg.ExcludeManagedBy.Values = []string{"Helm"}
+				cfg.ExcludeManagedBy.OwnerKinds = []string{"Prometheus"}
+			},
+		},
+		{
+			name: "exclude_managed_by without label",
+			config: func(cfg *Config) {
+				cfg.ExcludeManagedBy = ManagedByConfig{Values: []string{"Helm"}}
+			},
+			expectedErr: "exclude_managed_by: label must be set along with values",
+		},
+		{
+			name: "datapoint_attributes",
+			config: func(cfg *Config) {
+				cfg.DatapointAttributes = DatapointAttributesConfig{
+					Keys: []string{"k8s.namespace.name"},
+					Mode: "move",
+				}
+			},
+		},
+		{
+			name: "invalid datapoint_attributes mode",
+			config: func(cfg *Config) {
+				cfg.DatapointAttributes.Mode = "replace"
+			},
+			expectedErr: `datapoint_attributes: mode must be one of "copy" or "move", got "replace"`,
+		},
+		{
+			name: "empty datapoint_attributes key",
+			config: func(cfg *Config) {
+				cfg.DatapointAttributes.Keys = []string{""}
+			},
+			expectedErr: "datapoint_attributes: empty key",
+		},
+		{
+			name: "extract_annotations",
+			config: func(cfg *Config) {
+				cfg.ExtractAnnotations = []AnnotationExtractionConfig{
+					{Key: "mycompany.com/owner", Attribute: "owner"},
+					{Key: "mycompany.com/telemetry", JSON: true},
+					{KeyPrefix: "telemetry.mycompany.com/", AttributePrefix: "telemetry."},
+				}
+			},
+		},
+		{
+			name: "label_info_kinds",
+			config: func(cfg *Config) {
+				cfg.LabelInfoKinds = []string{"deployment", "pod"}
+			},
+		},
+		{
+			name: "unsupported label info kind",
+			config: func(cfg *Config) {
+				cfg.LabelInfoKinds = []string{"Pod"}
+			},
+			expectedErr: `label_info_kinds: unsupported kind "Pod", must be one of: cronjob, daemonset, deployment, horizontalpodautoscaler, job, namespace, node, pod, replicaset, replicationcontroller, resourcequota, statefulset`,
+		},
+		{
+			name: "optional_kinds",
+			config: func(cfg *Config) {
+				cfg.OptionalKinds = []string{"endpointslice"}
+			},
+		},
+		{
+			name: "unsupported optional kind",
+			config: func(cfg *Config) {
+				cfg.OptionalKinds = []string{"pod"}
+			},
+			expectedErr: `optional_kinds: unsupported kind "pod", must be one of: configmap, endpointslice, ingress, lease, mutatingwebhookconfiguration, persistentvolume, persistentvolumeclaim, poddisruptionbudget, priorityclass, rbac, secret, validatingwebhookconfiguration`,
+		},
+		{
+			name: "unsupported api_versions kind",
+			config: func(cfg *Config) {
+				cfg.APIVersions = map[string]string{"configmap": "v1"}
+			},
+			expectedErr: `api_versions: unsupported kind "configmap", must be one of: cronjob, daemonset, deployment, horizontalpodautoscaler, job, namespace, node, pod, replicaset, replicationcontroller, resourcequota, service, statefulset`,
+		},
+		{
+			name: "unsupported api_versions version",
+			config: func(cfg *Config) {
+				cfg.APIVersions = map[string]string{"horizontalpodautoscaler": "autoscaling/v3"}
+			},
+			expectedErr: `api_versions: unsupported version "autoscaling/v3" of "horizontalpodautoscaler", must be one of: autoscaling/v2beta1, autoscaling/v1, autoscaling/v2beta2`,
+		},
+		{
+			name: "invalid duplicate_objects",
+			config: func(cfg *Config) {
+				cfg.DuplicateObjects = "latest_source"
+			},
+			expectedErr: `duplicate_objects must be one of "first_source" or "all_sources", got "latest_source"`,
+		},
+		{
+			name: "unsupported exclude_pod_phases phase",
+			config: func(cfg *Config) {
+				cfg.ExcludePodPhases = []string{"Running"}
+			},
+			expectedErr: `exclude_pod_phases: unsupported phase "Running", must be one of: Succeeded, Failed`,
+		},
+		{
+			name: "expression_metrics without name",
+			config: func(cfg *Config) {
+				cfg.ExpressionMetrics = []ExpressionMetricConfig{{Kind: "deployment", Expression: "spec.replicas"}}
+			},
+			expectedErr: "expression_metrics[0]: name must be set",
+		},
+		{
+			name: "unsupported expression_metrics kind",
+			config: func(cfg *Config) {
+				cfg.ExpressionMetrics = []ExpressionMetricConfig{
+					{Name: "k8s.service.ports", Kind: "service", Expression: "spec.ports"},
+				}
+			},
+			expectedErr: `expression_metrics[0]: unsupported kind "service", must be one of: cronjob, daemonset, deployment, horizontalpodautoscaler, job, namespace, node, pod, replicaset, replicationcontroller, resourcequota, statefulset`,
+		},
+		{
+			name: "invalid expression_metrics expression",
+			config: func(cfg *Config) {
+				cfg.ExpressionMetrics = []ExpressionMetricConfig{
+					{Name: "k8s.deployment.unavailable", Kind: "deployment", Expression: "status.replicas - (status.readyReplicas"},
+				}
+			},
+			expectedErr: `expression_metrics[0]: invalid expression "status.replicas - (status.readyReplicas": expected ")" at offset 39, got "end of expression"`,
+		},
+		{
+			name: "negative push_retry queue_size",
+			config: func(cfg *Config) {
+				cfg.PushRetry.QueueSize = -1
+			},
+			expectedErr: "push_retry queue_size must not be negative, got -1",
+		},
+		{
+			name: "push_retry max_backoff below initial_backoff",
+			config: func(cfg *Config) {
+				cfg.PushRetry = PushRetryConfig{QueueSize: 1, InitialBackoff: time.Minute, MaxBackoff: time.Second}
+			},
+			expectedErr: "push_retry must have 0 < initial_backoff <= max_backoff, got initial_backoff 1m0s and max_backoff 1s",
+		},
+		{
+			name: "empty namespace",
+			config: func(cfg *Config) {
+				cfg.Namespaces = []string{"default", ""}
+			},
+			expectedErr: "namespaces: empty namespace",
+		},
+		{
+			name: "duplicate namespace",
+			config: func(cfg *Config) {
+				cfg.Namespaces = []string{"default", "default"}
+			},
+			expectedErr: `namespaces: duplicate namespace "default"`,
+		},
+		{
+			name: "namespaces with custom_resource_definitions",
+			config: func(cfg *Config) {
+				cfg.Namespaces = []string{"default"}
+				cfg.CustomResourceDefinitions.Enabled = true
+			},
+			expectedErr: "custom_resource_definitions cannot be enabled along with namespaces, " +
+				"CustomResourceDefinitions are cluster-scoped",
+		},
+		{
+			name: "unsupported resources.include kind",
+			config: func(cfg *Config) {
+				cfg.Resources.Include = []string{"pod", "pods"}
+			},
+			expectedErr: `resources.include: unsupported kind "pods", must be one of: cronjob, daemonset, deployment, ` +
+				"horizontalpodautoscaler, job, namespace, node, pod, replicaset, replicationcontroller, resourcequota, " +
+				"service, statefulset",
+		},
+		{
+			name: "optional kind in resources.exclude",
+			config: func(cfg *Config) {
+				cfg.Resources.Exclude = []string{"secret"}
+			},
+			expectedErr: `resources.exclude: unsupported kind "secret", must be one of: cronjob, daemonset, deployment, ` +
+				"horizontalpodautoscaler, job, namespace, node, pod, replicaset, replicationcontroller, resourcequota, " +
+				"service, statefulset",
+		},
+		{
+			name: "leader_election without lease_namespace",
+			config: func(cfg *Config) {
+				cfg.LeaderElection.Enabled = true
+			},
+			expectedErr: "leader_election: lease_name and lease_namespace must be set",
+		},
+		{
+			name: "leader_election renew_deadline above lease_duration",
+			config: func(cfg *Config) {
+				cfg.LeaderElection.Enabled = true
+				cfg.LeaderElection.LeaseNamespace = "observability"
+				cfg.LeaderElection.RenewDeadline = time.Minute
+			},
+			expectedErr: "leader_election must have lease_duration > renew_deadline > 1.2 * retry_period > 0, " +
+				"got lease_duration 15s, renew_deadline 1m0s and retry_period 2s",
+		},
+		{
+			name: "unsupported events severity",
+			config: func(cfg *Config) {
+				cfg.Events.Severity = map[string]string{"warning": "critical"}
+			},
+			expectedErr: `events.severity: unsupported severity "critical" for event type "warning", ` +
+				"must be one of: trace, debug, info, warn, error, fatal",
+		},
+		{
+			name: "unsupported events type",
+			config: func(cfg *Config) {
+				cfg.Events.Severity = map[string]string{"normal": "info", "error": "error"}
+			},
+			expectedErr: `events.severity: unsupported event type "error", must be one of: normal, warning`,
+		},
+		{
+			name: "image_metadata enricher without enabled",
+			config: func(cfg *Config) {
+				cfg.ImageMetadata = ImageMetadataConfig{Enricher: "imagescanner"}
+			},
+			expectedErr: "image_metadata.enricher requires image_metadata.enabled",
+		},
+		{
+			name: "collection_intervals with unsupported kind",
+			config: func(cfg *Config) {
+				cfg.CollectionIntervals = map[string]time.Duration{"nodes": 5 * time.Minute}
+			},
+			expectedErr: `collection_intervals: unsupported kind "nodes", must be one of: `,
+		},
+		{
+			name: "collection_intervals not positive",
+			config: func(cfg *Config) {
+				cfg.CollectionIntervals = map[string]time.Duration{"node": 5 * time.Minute, "pod": 0}
+			},
+			expectedErr: "collection_intervals[pod] must be positive, got 0s",
+		},
+		{
+			name: "initial_sync_timeout not positive",
+			config: func(cfg *Config) {
+				cfg.InitialSyncTimeout = 0
+			},
+			expectedErr: "initial_sync_timeout must be positive, got 0s",
+		},
+		{
+			name: "metric_overrides dropping renamed metric",
+			config: func(cfg *Config) {
+				cfg.MetricOverrides = map[string]MetricOverrideConfig{
+					"k8s.pod.phase": {Name: "kube_pod_status_phase", Drop: true},
+				}
+			},
+			expectedErr: "metric_overrides[k8s.pod.phase]: drop cannot be combined with name, unit or attributes",
+		},
+		{
+			name: "metric_overrides with empty attribute key",
+			config: func(cfg *Config) {
+				cfg.MetricOverrides = map[string]MetricOverrideConfig{
+					"k8s.pod.phase": {Attributes: map[string]string{"": "value"}},
+				}
+			},
+			expectedErr: "metric_overrides[k8s.pod.phase]: attribute keys must not be empty",
+		},
+		{
+			name: "metric_overrides renaming metrics to the same name",
+			config: func(cfg *Config) {
+				cfg.MetricOverrides = map[string]MetricOverrideConfig{
+					"k8s.container.cpu_limit":    {Name: "kube_pod_container_resource_limits"},
+					"k8s.container.memory_limit": {Name: "kube_pod_container_resource_limits"},
+				}
+			},
+			expectedErr: "metric_overrides: k8s.container.cpu_limit and k8s.container.memory_limit are both renamed to " +
+				"kube_pod_container_resource_limits",
+		},
+		{
+			name: "relationships without interval",
+			config: func(cfg *Config) {
+				cfg.Relationships = RelationshipsConfig{Enabled: true}
+			},
+			expectedErr: "relationships.interval must be positive, got 0s",
+		},
+		{
+			name: "custom_resources without resource",
+			config: func(cfg *Config) {
+				cfg.CustomResources = []CustomResourceConfig{{Group: "cert-manager.io", Version: "v1"}}
+			},
+			expectedErr: "custom_resources[0]: version and resource must be set",
+		},
+		{
+			name: "duplicate custom_resources",
+			config: func(cfg *Config) {
+				cr := CustomResourceConfig{
+					Group:    "cert-manager.io",
+					Version:  "v1",
+					Resource: "certificates",
+					Metrics:  []CustomResourceMetricConfig{{Name: "certmanager.certificate.ready", Path: ".status.ready"}},
+				}
+				cfg.CustomResources = []CustomResourceConfig{cr, cr}
+			},
+			expectedErr: `custom_resources[1]: duplicate resource "cert-manager.io/v1, Resource=certificates"`,
+		},
+		{
+			name: "custom_resources without metrics",
+			config: func(cfg *Config) {
+				cfg.CustomResources = []CustomResourceConfig{{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}}
+			},
+			expectedErr: "custom_resources[0]: at least one metric must be set",
+		},
+		{
+			name: "custom_resources metric with invalid path",
+			config: func(cfg *Config) {
+				cfg.CustomResources = []CustomResourceConfig{{
+					Group:    "cert-manager.io",
+					Version:  "v1",
+					Resource: "certificates",
+					Metrics:  []CustomResourceMetricConfig{{Name: "certmanager.certificate.ready", Path: "{.status.ready"}},
+				}}
+			},
+			expectedErr: `custom_resources[0].metrics[0]: invalid path "{.status.ready": unclosed action`,
+		},
+		{
+			name: "node_conditions_to_report with empty condition",
+			config: func(cfg *Config) {
+				cfg.NodeConditionTypesToReport = []string{"Ready", ""}
+			},
+			expectedErr: "node_conditions_to_report: condition must not be empty",
+		},
+		{
+			name: "node_conditions_to_report with duplicate condition",
+			config: func(cfg *Config) {
+				cfg.NodeConditionTypesToReport = []string{"MemoryPressure", "memory_pressure"}
+			},
+			expectedErr: `node_conditions_to_report: "MemoryPressure" and "memory_pressure" are both reported as k8s.node.condition_memory_pressure`,
+		},
+		{
+			name: "unsupported distribution",
+			config: func(cfg *Config) {
+				cfg.Distribution = "rancher"
+			},
+			expectedErr: `distribution must be one of "kubernetes" or "openshift", got "rancher"`,
+		},
+		{
+			name: "negative full_resync_interval",
+			config: func(cfg *Config) {
+				cfg.SkipUnchanged = true
+				cfg.FullResyncInterval = -time.Minute
+			},
+			expectedErr: "full_resync_interval must not be negative, got -1m0s",
+		},
+		{
+			name: "full_resync_interval without skip_unchanged",
+			config: func(cfg *Config) {
+				cfg.FullResyncInterval = 10 * time.Minute
+			},
+			expectedErr: "full_resync_interval requires skip_unchanged",
+		},
+		{
+			name: "sharding index out of range",
+			config: func(cfg *Config) {
+				cfg.Sharding = ShardingConfig{Count: 3, Index: 3}
+			},
+			expectedErr: "sharding.index must be between 0 and 2, got 3",
+		},
+		{
+			name: "sharding with custom_resource_definitions",
+			config: func(cfg *Config) {
+				cfg.Sharding = ShardingConfig{Count: 2}
+				cfg.CustomResourceDefinitions.Enabled = true
+			},
+			expectedErr: "custom_resource_definitions cannot be enabled along with sharding, " +
+				"CustomResourceDefinitions are cluster-scoped",
+		},
+		{
+			name: "negative list_page_size",
+			config: func(cfg *Config) {
+				cfg.ListPageSize = -1
+			},
+			expectedErr: "list_page_size must not be negative, got -1",
+		},
+		{
+			name: "extract",
+			config: func(cfg *Config) {
+				cfg.Extract = ExtractConfig{
+					Labels: []FieldExtractConfig{
+						{Key: "team"},
+						{KeyRegex: `^app\.kubernetes\.io/(.+)$`, TagName: "app.$1", From: "deployment"},
+					},
+					Annotations: []FieldExtractConfig{{Key: "mycompany.com/deploy", TagName: "env", Regex: `env=(?P<value>[^,]+)`}},
+				}
+			},
+		},
+		{
+			name: "extract without key",
+			config: func(cfg *Config) {
+				cfg.Extract.Labels = []FieldExtractConfig{{TagName: "team"}}
+			},
+			expectedErr: "extract.labels[0]: exactly one of key or key_regex must be set",
+		},
+		{
+			name: "extract from unsupported kind",
+			config: func(cfg *Config) {
+				cfg.Extract.Annotations = []FieldExtractConfig{{Key: "team", From: "secret"}}
+			},
+			expectedErr: `extract.annotations[0]: unsupported kind "secret"`,
+		},
+		{
+			name: "extract with invalid key_regex",
+			config: func(cfg *Config) {
+				cfg.Extract.Labels = []FieldExtractConfig{{KeyRegex: "("}}
+			},
+			expectedErr: `extract.labels[0]: invalid key_regex "("`,
+		},
+		{
+			name: "extract regex without value submatch",
+			config: func(cfg *Config) {
+				cfg.Extract.Labels = []FieldExtractConfig{{Key: "team", Regex: "team-(.+)"}}
+			},
+			expectedErr: `extract.labels[0]: regex "team-(.+)" must have a submatch named value`,
+		},
+		{
+			name: "extract_annotations without key",
+			config: func(cfg *Config) {
+				cfg.ExtractAnnotations = []AnnotationExtractionConfig{{Attribute: "owner"}}
+			},
+			expectedErr: "extract_annotations[0]: exactly one of key or key_prefix must be set",
+		},
+		{
+			name: "extract_annotations with key and key_prefix",
+			config: func(cfg *Config) {
+				cfg.ExtractAnnotations = []AnnotationExtractionConfig{{Key: "a", KeyPrefix: "b"}}
+			},
+			expectedErr: "extract_annotations[0]: exactly one of key or key_prefix must be set",
+		},
+		{
+			name: "extract_annotations json with key_prefix",
+			config: func(cfg *Config) {
+				cfg.ExtractAnnotations = []AnnotationExtractionConfig{{KeyPrefix: "b", JSON: true}}
+			},
+			expectedErr: "extract_annotations[0]: json requires key",
+		},
+		{
+			name: "extract_annotations attribute with key_prefix",
+			config: func(cfg *Config) {
+				cfg.ExtractAnnotations = []AnnotationExtractionConfig{{KeyPrefix: "b", Attribute: "c"}}
+			},
+			expectedErr: "extract_annotations[0]: attribute can only be set with key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewFactory().CreateDefaultConfig().(*Config)
+			tt.config(cfg)
+
+			err := cfg.validate()
+			if tt.expectedErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tt.expectedErr)
+		})
+	}
+}
+
+func TestFieldExtractionRulesFromExtractAnnotations(t *testing.T) {
+	cfg := NewFactory().CreateDefaultConfig().(*Config)
+	cfg.ExtractAnnotations = []AnnotationExtractionConfig{
+		{KeyPrefix: "mycompany.com/", AttributePrefix: "mycompany."},
+		{Key: "team", Attribute: "owner"},
+		{Key: "config", JSON: true, AttributePrefix: "config."},
+	}
+	cfg.Extract.Annotations = []FieldExtractConfig{{Key: "team"}}
+	rules := cfg.fieldExtractionRules()
+	require.Len(t, rules.Annotations, 4)
+	require.Equal(t, "pod", rules.Annotations[0].Kind)
+	require.Equal(t, "^mycompany\\.com/(.*)$", rules.Annotations[0].KeyRegex.String())
+	require.Equal(t, "mycompany.${1}", rules.Annotations[0].TagName)
+	require.Equal(t, collection.FieldExtractionRule{Kind: "pod", Key: "team", TagName: "owner"}, rules.Annotations[1])
+	require.Equal(t, collection.FieldExtractionRule{Kind: "pod", Key: "config", TagName: "config.", JSON: true}, rules.Annotations[2])
+	require.Equal(t, "team", rules.Annotations[3].Key)
+	require.Equal(t, defaultExcludedAnnotations, rules.ExcludedAnnotations)
+	require.Contains(t, rules.ExcludedAnnotations, "kubectl.kubernetes.io/last-applied-configuration")
+
+	cfg.ExcludeAnnotations = []string{"mycompany.com/large"}
+	require.Equal(t, []string{"mycompany.com/large"}, cfg.fieldExtractionRules().ExcludedAnnotations)
+
+	cfg.ExcludeAnnotations = []string{}
+	require.Empty(t, cfg.fieldExtractionRules().ExcludedAnnotations)
+}
+
+func TestAPIServerSettingsPassedToClient(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.APIServerHost = "https://proxy.example.com:8443"
+	cfg.InsecureSkipVerify = true
+	cfg.KubeConfigContext = "staging"
+	cfg.APIQPS = 50
+	cfg.APIBurst = 100
+	cfg.APITimeout = time.Minute
+
+	var got k8sconfig.APIConfig
+	cfg.makeClient = func(apiConf k8sconfig.APIConfig) (kubernetes.Interface, error) {
+		got = apiConf
+		return nil, nil
+	}
+	_, err := cfg.getK8sClient()
+	require.NoError(t, err)
+	require.Equal(t, k8sconfig.ServerConfig{
+		Host:               "https://proxy.example.com:8443",
+		InsecureSkipVerify: true,
+		Context:            "staging",
+	}, got.Server)
+	require.Zero(t, cfg.APIConfig.Server)
+	require.Equal(t, float32(50), got.Client.QPS)
+	require.Equal(t, 100, got.Client.Burst)
+	require.Equal(t, time.Minute, got.Client.Timeout)
+	require.NotNil(t, got.Client.OnThrottle)
+}
+
+func TestDisabledMetrics(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	require.Empty(t, cfg.disabledMetrics())
+
+	cfg.Metrics = map[string]MetricConfig{
+		"k8s.pod.phase":            {Enabled: true},
+		"k8s.container.restarts":   {Enabled: false},
+		"k8s.cluster.capacity_cpu": {},
+	}
+	require.Equal(t, []string{"k8s.cluster.capacity_cpu", "k8s.container.restarts"}, cfg.disabledMetrics())
+}
+
+func TestCollectionTick(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	require.Equal(t, 10*time.Second, cfg.collectionTick())
+
+	cfg.CollectionInterval = time.Minute
+	cfg.CollectionIntervals = map[string]time.Duration{"node": 5 * time.Minute, "pod": 15 * time.Second}
+	require.Equal(t, 15*time.Second, cfg.collectionTick())
+}
+
+func TestMetricOverrides(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.Metrics = map[string]MetricConfig{"k8s.container.restarts": {Enabled: false}}
+	cfg.DropZeroValues = []string{"k8s.container.restarts", "k8s.pod.phase"}
+	cfg.MetricOverrides = map[string]MetricOverrideConfig{
+		"k8s.pod.phase":          {Name: "kube_pod_status_phase", Attributes: map[string]string{"team": "platform"}},
+		"k8s.container.restarts": {Drop: true},
+		"k8s.node.allocatable":   {Drop: true},
+	}
+
+	require.Equal(t, []string{"k8s.container.restarts", "k8s.node.allocatable"}, cfg.disabledMetrics())
+	require.Equal(t, map[string]collection.MetricOverride{
+		"k8s.pod.phase": {Name: "kube_pod_status_phase", Attributes: map[string]string{"team": "platform"}},
+	}, cfg.metricOverrides())
+	require.Equal(t, []string{"k8s.container.restarts", "kube_pod_status_phase"}, cfg.dropZeroValues())
+}
+
+func TestSpotNodeLabels(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	require.Equal(t, defaultSpotNodeLabels, cfg.spotNodeLabels())
+
+	cfg.SpotNodeLabels = map[string]string{"node.example.com/lifecycle": "spot"}
+	require.Equal(t, map[string]string{"node.example.com/lifecycle": "spot"}, cfg.spotNodeLabels())
+}
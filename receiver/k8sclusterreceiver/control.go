@@ -0,0 +1,100 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclusterreceiver
+
+import (
+	"errors"
+	"net"
+	"net/http"
+
+	"go.opentelemetry.io/collector/component"
+	"go.uber.org/zap"
+)
+
+const (
+	controlPathPause  = "/pause"
+	controlPathResume = "/resume"
+)
+
+// pause stops the receiver from pushing metrics on subsequent collection
+// intervals. The informers keep running so that the caches are up to date
+// when collection resumes.
+func (kr *kubernetesReceiver) pause() {
+	if !kr.paused.Swap(true) {
+		kr.logger.Info("Paused metric collection.")
+	}
+}
+
+// resume restarts pushing metrics from the next collection interval on.
+func (kr *kubernetesReceiver) resume() {
+	if kr.paused.Swap(false) {
+		kr.logger.Info("Resumed metric collection.")
+	}
+}
+
+func (kr *kubernetesReceiver) isPaused() bool {
+	return kr.paused.Load()
+}
+
+// newControlHandler returns the handler pausing and resuming collection on
+// POST requests to /pause and /resume respectively.
+func (kr *kubernetesReceiver) newControlHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(controlPathPause, kr.controlHandlerFunc(kr.pause))
+	mux.HandleFunc(controlPathResume, kr.controlHandlerFunc(kr.resume))
+	return mux
+}
+
+func (kr *kubernetesReceiver) controlHandlerFunc(action func()) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		action()
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// startControlServer starts serving the pause and resume hooks on the
+// configured endpoint. It is a no-op if no endpoint has been configured.
+func (kr *kubernetesReceiver) startControlServer(host component.Host) error {
+	if kr.config.ControlEndpoint == "" {
+		return nil
+	}
+
+	ln, err := net.Listen("tcp", kr.config.ControlEndpoint)
+	if err != nil {
+		return err
+	}
+
+	kr.controlServer = &http.Server{Handler: kr.newControlHandler()}
+	go func() {
+		if err := kr.controlServer.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			kr.logger.Error("Control server failed", zap.Error(err))
+			host.ReportFatalError(err)
+		}
+	}()
+
+	return nil
+}
+
+func (kr *kubernetesReceiver) stopControlServer() error {
+	if kr.controlServer == nil {
+		return nil
+	}
+	return kr.controlServer.Close()
+}
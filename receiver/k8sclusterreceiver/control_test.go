@@ -0,0 +1,94 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclusterreceiver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/testutil"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPauseAndResume(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	consumer := new(consumertest.MetricsSink)
+	endpoint := testutil.GetAvailableLocalAddress(t)
+	r := setupReceiverWithDynamicClient(client, nil, consumer, 10*time.Second,
+		func(cfg *Config) {
+			cfg.CollectionInterval = 100 * time.Millisecond
+			cfg.ControlEndpoint = endpoint
+		})
+
+	createNodes(t, client, 1)
+
+	ctx := context.Background()
+	require.NoError(t, r.Start(ctx, componenttest.NewNopHost()))
+	defer func() { require.NoError(t, r.Shutdown(ctx)) }()
+
+	require.Eventually(t, func() bool {
+		return len(consumer.AllMetrics()) > 0
+	}, 10*time.Second, 100*time.Millisecond, "metrics not pushed before pausing")
+
+	require.Eventually(t, func() bool {
+		resp, err := http.Post("http://"+endpoint+controlPathPause, "", nil)
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, 10*time.Second, 100*time.Millisecond)
+	require.True(t, r.isPaused())
+
+	// A push may have been in flight while pausing.
+	time.Sleep(200 * time.Millisecond)
+	numPushes := len(consumer.AllMetrics())
+	time.Sleep(500 * time.Millisecond)
+	require.Equal(t, numPushes, len(consumer.AllMetrics()), "metrics pushed while paused")
+
+	resp, err := http.Post("http://"+endpoint+controlPathResume, "", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.False(t, r.isPaused())
+
+	require.Eventually(t, func() bool {
+		return len(consumer.AllMetrics()) > numPushes
+	}, 10*time.Second, 100*time.Millisecond, "metrics not pushed after resuming")
+}
+
+func TestControlHandlerRejectsNonPost(t *testing.T) {
+	r := setupReceiver(fake.NewSimpleClientset(), new(consumertest.MetricsSink), 10*time.Second)
+
+	rec := httptest.NewRecorder()
+	r.newControlHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, controlPathPause, nil))
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	require.False(t, r.isPaused())
+}
+
+func TestControlServerDisabledByDefault(t *testing.T) {
+	r := setupReceiver(fake.NewSimpleClientset(), new(consumertest.MetricsSink), 10*time.Second)
+
+	ctx := context.Background()
+	require.NoError(t, r.Start(ctx, componenttest.NewNopHost()))
+	require.Nil(t, r.controlServer)
+	require.NoError(t, r.Shutdown(ctx))
+}
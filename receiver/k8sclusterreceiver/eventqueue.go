@@ -0,0 +1,83 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclusterreceiver
+
+import (
+	"context"
+	"hash/fnv"
+
+	"go.uber.org/atomic"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/observability"
+)
+
+// eventQueue distributes the processing of informer events across a pool
+// of workers. Events are assigned to workers based on the UID of the object
+// they pertain to, so that events of a given object are always processed in
+// the order they were received.
+type eventQueue struct {
+	workers []chan func()
+	depth   *atomic.Int64
+	ctx     context.Context
+}
+
+func newEventQueue(numWorkers int, queueSize int) *eventQueue {
+	q := &eventQueue{
+		workers: make([]chan func(), numWorkers),
+		depth:   atomic.NewInt64(0),
+	}
+	for i := range q.workers {
+		q.workers[i] = make(chan func(), queueSize)
+	}
+	return q
+}
+
+// start starts the workers, which run until the context is cancelled.
+func (q *eventQueue) start(ctx context.Context) {
+	q.ctx = ctx
+	for _, w := range q.workers {
+		go q.runWorker(w)
+	}
+}
+
+func (q *eventQueue) runWorker(events <-chan func()) {
+	for {
+		select {
+		case process := <-events:
+			observability.RecordEventQueueDepth(q.depth.Dec())
+			process()
+		case <-q.ctx.Done():
+			return
+		}
+	}
+}
+
+// enqueue schedules an event of the object with the given UID to be processed.
+// It blocks while the queue of the worker assigned to the object is full.
+func (q *eventQueue) enqueue(uid types.UID, process func()) {
+	h := fnv.New32a()
+	h.Write([]byte(uid))
+	worker := q.workers[h.Sum32()%uint32(len(q.workers))]
+
+	// The depth is raised before the send, so that it cannot be lowered by the
+	// worker first, and restored if the event is dropped.
+	observability.RecordEventQueueDepth(q.depth.Inc())
+	select {
+	case worker <- process:
+	case <-q.ctx.Done():
+		observability.RecordEventQueueDepth(q.depth.Dec())
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclusterreceiver
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/collection"
+)
+
+func TestEventQueuePreservesOrderPerUID(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	q := newEventQueue(4, 10)
+	q.start(ctx)
+
+	const numObjects = 20
+	const numEvents = 100
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	processed := map[types.UID][]int{}
+
+	wg.Add(numObjects * numEvents)
+	for i := 0; i < numEvents; i++ {
+		for j := 0; j < numObjects; j++ {
+			uid := types.UID(fmt.Sprintf("uid-%d", j))
+			seq := i
+			q.enqueue(uid, func() {
+				defer wg.Done()
+				mu.Lock()
+				defer mu.Unlock()
+				processed[uid] = append(processed[uid], seq)
+			})
+		}
+	}
+	wg.Wait()
+
+	require.Len(t, processed, numObjects)
+	for uid, seqs := range processed {
+		require.Len(t, seqs, numEvents)
+		for i, seq := range seqs {
+			require.Equal(t, i, seq, "events of %s processed out of order", uid)
+		}
+	}
+	require.Equal(t, int64(0), q.depth.Load())
+}
+
+func TestEventQueueStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	q := newEventQueue(1, 1)
+	q.start(ctx)
+	cancel()
+
+	// Enqueueing does not block once the queue is stopped.
+	for i := 0; i < 10; i++ {
+		q.enqueue("uid", func() {})
+	}
+	// Only the event sent to the queue of the worker, if any, is counted.
+	require.LessOrEqual(t, q.depth.Load(), int64(1))
+}
+
+func BenchmarkEventProcessing(b *testing.B) {
+	pods := make([]*corev1.Pod, 1000)
+	for i := range pods {
+		pods[i] = &corev1.Pod{
+			ObjectMeta: v1.ObjectMeta{
+				UID:       types.UID("pod" + strconv.Itoa(i)),
+				Name:      strconv.Itoa(i),
+				Namespace: "test",
+			},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{Name: "container", ContainerID: "docker://container" + strconv.Itoa(i)},
+				},
+			},
+		}
+	}
+
+	for _, workers := range []int{0, 1, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			dc := collection.NewDataCollector(zap.NewNop(), nil)
+			var q *eventQueue
+			if workers > 0 {
+				q = newEventQueue(workers, 1000)
+				q.start(ctx)
+			}
+
+			var wg sync.WaitGroup
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				wg.Add(len(pods))
+				for _, pod := range pods {
+					pod := pod
+					process := func() {
+						defer wg.Done()
+						dc.SyncMetrics(pod)
+					}
+					if q == nil {
+						process()
+						continue
+					}
+					q.enqueue(pod.UID, process)
+				}
+				wg.Wait()
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclusterreceiver
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/consumer/pdata"
+	"go.opentelemetry.io/collector/obsreport"
+	"go.opentelemetry.io/collector/translator/conventions"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// Keys of the attributes of log records of events.
+	k8sKeyEventName            = "k8s.event.name"
+	k8sKeyEventUID             = "k8s.event.uid"
+	k8sKeyEventReason          = "k8s.event.reason"
+	k8sKeyEventAction          = "k8s.event.action"
+	k8sKeyEventCount           = "k8s.event.count"
+	k8sKeyEventFirstTimestamp  = "k8s.event.first_timestamp"
+	k8sKeyEventSourceComponent = "k8s.event.source.component"
+
+	// Keys of the resource attributes of log records of events, describing
+	// the object involved in the event.
+	k8sKeyObjectAPIVersion = "k8s.object.api_version"
+	k8sKeyObjectKind       = "k8s.object.kind"
+	k8sKeyObjectName       = "k8s.object.name"
+	k8sKeyObjectUID        = "k8s.object.uid"
+	k8sKeyObjectFieldPath  = "k8s.object.fieldpath"
+)
+
+// eventSeverities are the severities events can be mapped to through
+// events.severity.
+var eventSeverities = map[string]pdata.SeverityNumber{
+	"trace": pdata.SeverityNumberTRACE,
+	"debug": pdata.SeverityNumberDEBUG,
+	"info":  pdata.SeverityNumberINFO,
+	"warn":  pdata.SeverityNumberWARN,
+	"error": pdata.SeverityNumberERROR,
+	"fatal": pdata.SeverityNumberFATAL,
+}
+
+// eventSeverityNames are the keys of eventSeverities, by increasing severity.
+var eventSeverityNames = []string{"trace", "debug", "info", "warn", "error", "fatal"}
+
+// defaultEventSeverity is the default of events.severity, keyed by lower-cased
+// event type.
+var defaultEventSeverity = map[string]string{
+	"normal":  "info",
+	"warning": "warn",
+}
+
+var _ component.LogsReceiver = (*eventsReceiver)(nil)
+
+// eventsReceiver watches Kubernetes Events and pushes them as log records,
+// along with the relationships between objects if enabled.
+type eventsReceiver struct {
+	client   kubernetes.Interface
+	config   *Config
+	logger   *zap.Logger
+	consumer consumer.LogsConsumer
+	cancel   context.CancelFunc
+	// Events last seen before startTime are not pushed, so that the events
+	// listed when starting, which were likely pushed before a restart, are
+	// not pushed again. Since event timestamps have a precision of a second,
+	// it is truncated to the second.
+	startTime time.Time
+}
+
+// newEventsReceiver creates the receiver of Kubernetes Events with the given
+// configuration.
+func newEventsReceiver(
+	logger *zap.Logger, config *Config, consumer consumer.LogsConsumer,
+	client kubernetes.Interface) (component.LogsReceiver, error) {
+	return &eventsReceiver{
+		client:   client,
+		config:   config,
+		logger:   logger,
+		consumer: consumer,
+	}, nil
+}
+
+func (er *eventsReceiver) Start(ctx context.Context, _ component.Host) error {
+	var c context.Context
+	c, er.cancel = context.WithCancel(obsreport.ReceiverContext(ctx, er.config.Name(), transport))
+	er.startTime = time.Now().Truncate(time.Second)
+
+	namespaces := er.config.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{corev1.NamespaceAll}
+	}
+	handlers := cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			er.onEvent(c, obj)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			er.onEvent(c, newObj)
+		},
+	}
+	for _, namespace := range namespaces {
+		factory := informers.NewSharedInformerFactoryWithOptions(er.client, 0, informers.WithNamespace(namespace))
+		factory.Core().V1().Events().Informer().AddEventHandler(handlers)
+		factory.Start(c.Done())
+	}
+	if er.config.Relationships.Enabled {
+		newRelationshipsReporter(er.logger, er.config, er.consumer).start(c, er.client, namespaces)
+	}
+	return nil
+}
+
+func (er *eventsReceiver) Shutdown(context.Context) error {
+	er.cancel()
+	return nil
+}
+
+// onEvent pushes an added or updated event, e.g. whose count was increased,
+// unless it was last seen before the receiver started.
+func (er *eventsReceiver) onEvent(ctx context.Context, obj interface{}) {
+	ev, ok := obj.(*corev1.Event)
+	if !ok || eventTimestamp(ev).Before(er.startTime) {
+		return
+	}
+
+	ld := er.eventToLogs(ev)
+	c := obsreport.StartLogsReceiveOp(ctx, typeStr, transport)
+	err := er.consumer.ConsumeLogs(c, ld)
+	obsreport.EndLogsReceiveOp(c, typeStr, ld.LogRecordCount(), err)
+	if err != nil {
+		er.logger.Debug("Pushing event failed.", zap.String("event", ev.Name), zap.Error(err))
+	}
+}
+
+// eventToLogs converts an event to a log record, whose resource is the object
+// involved in the event.
+func (er *eventsReceiver) eventToLogs(ev *corev1.Event) pdata.Logs {
+	ld := pdata.NewLogs()
+	ld.ResourceLogs().Resize(1)
+	rl := ld.ResourceLogs().At(0)
+
+	resourceAttrs := rl.Resource().Attributes()
+	if er.config.ClusterName != "" {
+		resourceAttrs.InsertString(conventions.AttributeK8sCluster, er.config.ClusterName)
+	}
+	involved := ev.InvolvedObject
+	if involved.Namespace != "" {
+		resourceAttrs.InsertString(conventions.AttributeK8sNamespace, involved.Namespace)
+	}
+	resourceAttrs.InsertString(k8sKeyObjectKind, involved.Kind)
+	resourceAttrs.InsertString(k8sKeyObjectName, involved.Name)
+	insertStringIfSet(resourceAttrs, k8sKeyObjectAPIVersion, involved.APIVersion)
+	insertStringIfSet(resourceAttrs, k8sKeyObjectUID, string(involved.UID))
+	insertStringIfSet(resourceAttrs, k8sKeyObjectFieldPath, involved.FieldPath)
+
+	rl.InstrumentationLibraryLogs().Resize(1)
+	logs := rl.InstrumentationLibraryLogs().At(0).Logs()
+	logs.Resize(1)
+	lr := logs.At(0)
+	lr.SetTimestamp(pdata.TimestampUnixNano(eventTimestamp(ev).UnixNano()))
+	lr.SetName(ev.Reason)
+	lr.SetSeverityText(ev.Type)
+	lr.SetSeverityNumber(er.eventSeverity(ev.Type))
+	lr.Body().SetStringVal(ev.Message)
+
+	attrs := lr.Attributes()
+	attrs.InsertString(k8sKeyEventName, ev.Name)
+	attrs.InsertString(k8sKeyEventUID, string(ev.UID))
+	attrs.InsertString(k8sKeyEventReason, ev.Reason)
+	insertStringIfSet(attrs, k8sKeyEventAction, ev.Action)
+	attrs.InsertInt(k8sKeyEventCount, int64(ev.Count))
+	if !ev.FirstTimestamp.IsZero() {
+		attrs.InsertString(k8sKeyEventFirstTimestamp, ev.FirstTimestamp.UTC().Format(time.RFC3339))
+	}
+	insertStringIfSet(attrs, k8sKeyEventSourceComponent, eventSourceComponent(ev))
+	insertStringIfSet(attrs, conventions.AttributeK8sNodeName, ev.Source.Host)
+	return ld
+}
+
+// eventSeverity returns the severity set by events.severity for events of the
+// given type, or undefined for types it does not list.
+func (er *eventsReceiver) eventSeverity(eventType string) pdata.SeverityNumber {
+	if severity, ok := er.config.Events.Severity[strings.ToLower(eventType)]; ok {
+		return eventSeverities[severity]
+	}
+	return pdata.SeverityNumberUNDEFINED
+}
+
+// eventTimestamp returns when the event was last seen, falling back to when it
+// was first seen or created for events not setting it.
+func eventTimestamp(ev *corev1.Event) time.Time {
+	switch {
+	case !ev.LastTimestamp.IsZero():
+		return ev.LastTimestamp.Time
+	case !ev.EventTime.IsZero():
+		return ev.EventTime.Time
+	case !ev.FirstTimestamp.IsZero():
+		return ev.FirstTimestamp.Time
+	}
+	return ev.CreationTimestamp.Time
+}
+
+// eventSourceComponent returns the component reporting the event, set by the
+// events.k8s.io API as the reporting controller.
+func eventSourceComponent(ev *corev1.Event) string {
+	if ev.Source.Component != "" {
+		return ev.Source.Component
+	}
+	return ev.ReportingController
+}
+
+func insertStringIfSet(attrs pdata.AttributeMap, key, value string) {
+	if value != "" {
+		attrs.InsertString(key, value)
+	}
+}
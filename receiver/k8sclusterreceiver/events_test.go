@@ -0,0 +1,138 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclusterreceiver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/consumer/pdata"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestEventsReceiver(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	// Events last seen before the receiver starts are not pushed.
+	_, err := client.CoreV1().Events("test-namespace").Create(context.Background(),
+		newEvent("old", time.Now().Add(-time.Hour), 1), v1.CreateOptions{})
+	require.NoError(t, err)
+
+	config := createDefaultConfig().(*Config)
+	config.ClusterName = "test-cluster"
+	consumer := new(consumertest.LogsSink)
+	r, err := newEventsReceiver(zap.NewNop(), config, consumer, client)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, r.Start(ctx, componenttest.NewNopHost()))
+	defer func() { require.NoError(t, r.Shutdown(ctx)) }()
+
+	ev := newEvent("backoff", time.Now(), 1)
+	ev, err = client.CoreV1().Events("test-namespace").Create(context.Background(), ev, v1.CreateOptions{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return consumer.LogRecordsCount() == 1
+	}, 10*time.Second, 10*time.Millisecond, "event not pushed")
+
+	rl := consumer.AllLogs()[0].ResourceLogs().At(0)
+	require.Equal(t, map[string]string{
+		"k8s.cluster.name":       "test-cluster",
+		"k8s.namespace.name":     "test-namespace",
+		"k8s.object.api_version": "v1",
+		"k8s.object.kind":        "Pod",
+		"k8s.object.name":        "test-pod",
+		"k8s.object.uid":         "test-pod-uid",
+		"k8s.object.fieldpath":   "spec.containers{app}",
+	}, attributesToMap(rl.Resource().Attributes()))
+
+	lr := rl.InstrumentationLibraryLogs().At(0).Logs().At(0)
+	require.Equal(t, "BackOff", lr.Name())
+	require.Equal(t, "Warning", lr.SeverityText())
+	require.Equal(t, pdata.SeverityNumberWARN, lr.SeverityNumber())
+	require.Equal(t, "Back-off restarting failed container", lr.Body().StringVal())
+	require.Equal(t, pdata.TimestampUnixNano(ev.LastTimestamp.UnixNano()), lr.Timestamp())
+	count, ok := lr.Attributes().Get("k8s.event.count")
+	require.True(t, ok)
+	require.EqualValues(t, 1, count.IntVal())
+	require.Equal(t, map[string]string{
+		"k8s.event.name":             "backoff",
+		"k8s.event.uid":              "backoff-uid",
+		"k8s.event.reason":           "BackOff",
+		"k8s.event.count":            "",
+		"k8s.event.first_timestamp":  ev.FirstTimestamp.UTC().Format(time.RFC3339),
+		"k8s.event.source.component": "kubelet",
+		"k8s.node.name":              "test-node",
+	}, attributesToMap(lr.Attributes()))
+
+	// Events seen again are pushed again.
+	ev.Count = 2
+	_, err = client.CoreV1().Events("test-namespace").Update(context.Background(), ev, v1.UpdateOptions{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return consumer.LogRecordsCount() == 2
+	}, 10*time.Second, 10*time.Millisecond, "updated event not pushed")
+}
+
+func TestEventSeverity(t *testing.T) {
+	config := createDefaultConfig().(*Config)
+	config.Events.Severity = map[string]string{"normal": "debug"}
+	er := &eventsReceiver{config: config}
+
+	require.Equal(t, pdata.SeverityNumberDEBUG, er.eventSeverity(corev1.EventTypeNormal))
+	require.Equal(t, pdata.SeverityNumberUNDEFINED, er.eventSeverity(corev1.EventTypeWarning))
+}
+
+func newEvent(name string, lastTimestamp time.Time, count int32) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: "test-namespace",
+			UID:       types.UID(name + "-uid"),
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: "v1",
+			Kind:       "Pod",
+			Name:       "test-pod",
+			Namespace:  "test-namespace",
+			UID:        "test-pod-uid",
+			FieldPath:  "spec.containers{app}",
+		},
+		Reason:         "BackOff",
+		Message:        "Back-off restarting failed container",
+		Type:           corev1.EventTypeWarning,
+		Count:          count,
+		FirstTimestamp: v1.NewTime(lastTimestamp),
+		LastTimestamp:  v1.NewTime(lastTimestamp),
+		Source:         corev1.EventSource{Component: "kubelet", Host: "test-node"},
+	}
+}
+
+// attributesToMap returns the string values of attrs, with empty strings for
+// values of other types.
+func attributesToMap(attrs pdata.AttributeMap) map[string]string {
+	out := make(map[string]string, attrs.Len())
+	attrs.ForEach(func(k string, v pdata.AttributeValue) {
+		out[k] = v.StringVal()
+	})
+	return out
+}
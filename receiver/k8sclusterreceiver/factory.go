@@ -22,8 +22,12 @@ import (
 	"go.opentelemetry.io/collector/config/configmodels"
 	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/receiver/receiverhelper"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/dynamic"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/k8sconfig"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/collection"
 )
 
 const (
@@ -31,11 +35,34 @@ const (
 	typeStr = "k8s_cluster"
 
 	// Default config values.
-	defaultCollectionInterval = 10 * time.Second
+	defaultCollectionInterval      = 10 * time.Second
+	defaultEventQueueSize          = 1000
+	defaultMaxAttributeValueLength = 4096
+	defaultRelationshipsInterval   = time.Minute
 )
 
 var defaultNodeConditionsToReport = []string{"Ready"}
 
+// defaultExcludedAnnotations are pod annotations that are never extracted
+// unless exclude_annotations is configured.
+var defaultExcludedAnnotations = []string{
+	corev1.LastAppliedConfigAnnotation,
+	"control-plane.alpha.kubernetes.io/leader",
+	"kubernetes.io/config.hash",
+	"kubernetes.io/config.mirror",
+	"kubernetes.io/config.seen",
+	"kubernetes.io/config.source",
+}
+
+// defaultSpotNodeLabels identify spot or preemptible nodes on GKE, EKS and AKS
+// unless spot_node_labels is configured.
+var defaultSpotNodeLabels = map[string]string{
+	"cloud.google.com/gke-preemptible":      "true",
+	"cloud.google.com/gke-spot":             "true",
+	"eks.amazonaws.com/capacityType":        "SPOT",
+	"kubernetes.azure.com/scalesetpriority": "spot",
+}
+
 func createDefaultConfig() configmodels.Receiver {
 	return &Config{
 		ReceiverSettings: configmodels.ReceiverSettings{
@@ -44,6 +71,27 @@ func createDefaultConfig() configmodels.Receiver {
 		},
 		CollectionInterval:         defaultCollectionInterval,
 		NodeConditionTypesToReport: defaultNodeConditionsToReport,
+		EventQueueSize:             defaultEventQueueSize,
+		PushQueuePolicy:            pushQueuePolicyBlock,
+		PushMode:                   pushModeInterval,
+		DuplicateObjects:           duplicateObjectsFirstSource,
+		PushDebounce:               defaultPushDebounce,
+		InitialSyncTimeout:         defaultInitialSyncTimeout,
+		PushRetry:                  PushRetryConfig{InitialBackoff: defaultPushRetryInitialBackoff, MaxBackoff: defaultPushRetryMaxBackoff},
+		LeaderElection:             defaultLeaderElection,
+		Events:                     EventsConfig{Severity: defaultEventSeverity},
+		Relationships:              RelationshipsConfig{Interval: defaultRelationshipsInterval},
+		GroupBy:                    groupByNone,
+		Distribution:               distributionKubernetes,
+		PodAggregation:             collection.PodAggregationNone,
+		ClusterCapacityNodes:       collection.ClusterCapacityNodesReady,
+		GaugeValueType:             collection.GaugeValueTypeInt,
+		AttributeKeyStyle:          collection.AttributeKeyStyleDot,
+		MaxAttributeValueLength:    defaultMaxAttributeValueLength,
+		ExcludeManagedBy:           ManagedByConfig{Label: collection.DefaultManagedByLabel},
+		DatapointAttributes:        DatapointAttributesConfig{Mode: collection.DatapointAttributesModeCopy},
+		ResourceQuotaThreshold:     collection.DefaultResourceQuotaThreshold,
+		InstanceTypeLabel:          corev1.LabelInstanceTypeStable,
 		APIConfig: k8sconfig.APIConfig{
 			AuthType: k8sconfig.AuthTypeServiceAccount,
 		},
@@ -55,11 +103,46 @@ func createMetricsReceiver(
 	consumer consumer.MetricsConsumer) (component.MetricsReceiver, error) {
 	rCfg := cfg.(*Config)
 
-	k8sClient, err := rCfg.getK8sClient()
-	if err != nil {
+	if err := rCfg.validate(); err != nil {
+		return nil, err
+	}
+
+	return newClusterReceivers(params.Logger, rCfg, func(logger *zap.Logger, cfg *Config) (component.Receiver, error) {
+		k8sClient, err := cfg.getK8sClient()
+		if err != nil {
+			return nil, err
+		}
+
+		var dynamicClient dynamic.Interface
+		if cfg.CustomResourceDefinitions.Enabled || len(cfg.CustomResources) > 0 ||
+			cfg.Distribution == distributionOpenShift {
+			dynamicClient, err = cfg.getDynamicClient()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return newReceiver(logger, cfg, consumer, k8sClient, dynamicClient)
+	})
+}
+
+func createLogsReceiver(
+	_ context.Context, params component.ReceiverCreateParams, cfg configmodels.Receiver,
+	consumer consumer.LogsConsumer) (component.LogsReceiver, error) {
+	rCfg := cfg.(*Config)
+
+	if err := rCfg.validate(); err != nil {
 		return nil, err
 	}
-	return newReceiver(params.Logger, rCfg, consumer, k8sClient)
+
+	return newClusterReceivers(params.Logger, rCfg, func(logger *zap.Logger, cfg *Config) (component.Receiver, error) {
+		k8sClient, err := cfg.getK8sClient()
+		if err != nil {
+			return nil, err
+		}
+
+		return newEventsReceiver(logger, cfg, consumer, k8sClient)
+	})
 }
 
 // NewFactory creates a factory for k8s_cluster receiver.
@@ -67,5 +150,6 @@ func NewFactory() component.ReceiverFactory {
 	return receiverhelper.NewFactory(
 		typeStr,
 		createDefaultConfig,
-		receiverhelper.WithMetrics(createMetricsReceiver))
+		receiverhelper.WithMetrics(createMetricsReceiver),
+		receiverhelper.WithLogs(createLogsReceiver))
 }
@@ -25,6 +25,7 @@ import (
 	"go.opentelemetry.io/collector/consumer/consumertest"
 	"go.uber.org/zap"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/k8sconfig"
 )
@@ -44,6 +45,32 @@ func TestFactory(t *testing.T) {
 		},
 		CollectionInterval:         10 * time.Second,
 		NodeConditionTypesToReport: defaultNodeConditionsToReport,
+		EventQueueSize:             1000,
+		PodAggregation:             "none",
+		ClusterCapacityNodes:       "ready",
+		GaugeValueType:             "int",
+		AttributeKeyStyle:          "dot",
+		MaxAttributeValueLength:    4096,
+		ExcludeManagedBy:           ManagedByConfig{Label: "app.kubernetes.io/managed-by"},
+		DatapointAttributes:        DatapointAttributesConfig{Mode: "copy"},
+		ResourceQuotaThreshold:     0.9,
+		InstanceTypeLabel:          "node.kubernetes.io/instance-type",
+		PushQueuePolicy:            "block",
+		PushMode:                   "interval",
+		DuplicateObjects:           "first_source",
+		InitialSyncTimeout:         10 * time.Minute,
+		PushDebounce:               time.Second,
+		PushRetry:                  PushRetryConfig{InitialBackoff: time.Second, MaxBackoff: 30 * time.Second},
+		GroupBy:                    "none",
+		Distribution:               "kubernetes",
+		Events:                     EventsConfig{Severity: map[string]string{"normal": "info", "warning": "warn"}},
+		Relationships:              RelationshipsConfig{Interval: time.Minute},
+		LeaderElection: LeaderElectionConfig{
+			LeaseName:     "k8s-cluster-receiver",
+			LeaseDuration: 15 * time.Second,
+			RenewDeadline: 10 * time.Second,
+			RetryPeriod:   2 * time.Second,
+		},
 		APIConfig: k8sconfig.APIConfig{
 			AuthType: k8sconfig.AuthTypeServiceAccount,
 		},
@@ -66,7 +93,7 @@ func TestFactory(t *testing.T) {
 
 	// Override for tests.
 	rCfg.makeClient = func(apiConf k8sconfig.APIConfig) (kubernetes.Interface, error) {
-		return nil, nil
+		return fake.NewSimpleClientset(), nil
 	}
 	r, err = f.CreateMetricsReceiver(
 		context.Background(), component.ReceiverCreateParams{Logger: zap.NewNop()},
@@ -75,6 +102,13 @@ func TestFactory(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, r)
 
+	lr, err := f.CreateLogsReceiver(
+		context.Background(), component.ReceiverCreateParams{Logger: zap.NewNop()},
+		rCfg, consumertest.NewLogsNop(),
+	)
+	require.NoError(t, err)
+	require.NotNil(t, lr)
+
 	// Test metadata exporters setup.
 	ctx := context.Background()
 	require.NoError(t, r.Start(ctx, nopHostWithExporters{}))
@@ -83,8 +117,72 @@ func TestFactory(t *testing.T) {
 	require.Error(t, r.Start(context.Background(), nopHostWithExporters{}))
 }
 
-// nopHostWithExporters mocks a receiver.ReceiverHost for test purposes.
+func TestFactoryInvalidUnits(t *testing.T) {
+	f := NewFactory()
+	rCfg := f.CreateDefaultConfig().(*Config)
+	rCfg.makeClient = func(apiConf k8sconfig.APIConfig) (kubernetes.Interface, error) {
+		return nil, nil
+	}
+	rCfg.Units = map[string]string{"cpu": "bytes"}
+
+	r, err := f.CreateMetricsReceiver(
+		context.Background(), component.ReceiverCreateParams{Logger: zap.NewNop()},
+		rCfg, consumertest.NewMetricsNop(),
+	)
+	require.EqualError(t, err, `unsupported unit "bytes" for resource "cpu", must be one of: cores, millicores`)
+	require.Nil(t, r)
+}
+
+func TestFactoryClusters(t *testing.T) {
+	f := NewFactory()
+	rCfg := f.CreateDefaultConfig().(*Config)
+	rCfg.AuthType = k8sconfig.AuthTypeKubeConfig
+	rCfg.Clusters = []ClusterConfig{
+		{KubeConfigContext: "production-context", Name: "production"},
+		{KubeConfigContext: "staging-context", Name: "staging"},
+	}
+	var contexts []string
+	rCfg.makeClient = func(apiConf k8sconfig.APIConfig) (kubernetes.Interface, error) {
+		contexts = append(contexts, apiConf.Server.Context)
+		return fake.NewSimpleClientset(), nil
+	}
+
+	r, err := f.CreateMetricsReceiver(
+		context.Background(), component.ReceiverCreateParams{Logger: zap.NewNop()},
+		rCfg, consumertest.NewMetricsNop(),
+	)
+	require.NoError(t, err)
+	require.Equal(t, []string{"production-context", "staging-context"}, contexts)
+
+	// Each cluster is watched by a receiver of its own, reporting its name.
+	mr, ok := r.(*multiClusterReceiver)
+	require.True(t, ok)
+	require.Len(t, mr.receivers, 2)
+	for i, name := range []string{"production", "staging"} {
+		kr := mr.receivers[i].(*kubernetesReceiver)
+		require.Equal(t, name, kr.config.ClusterName)
+	}
+	require.Len(t, rCfg.Clusters, 2)
+	require.Empty(t, rCfg.ClusterName)
+
+	ctx := context.Background()
+	require.NoError(t, r.Start(ctx, nopHostWithExporters{}))
+	require.NoError(t, r.Shutdown(ctx))
+
+	contexts = nil
+	lr, err := f.CreateLogsReceiver(
+		context.Background(), component.ReceiverCreateParams{Logger: zap.NewNop()},
+		rCfg, consumertest.NewLogsNop(),
+	)
+	require.NoError(t, err)
+	require.Len(t, lr.(*multiClusterReceiver).receivers, 2)
+	require.Equal(t, []string{"production-context", "staging-context"}, contexts)
+}
+
+// nopHostWithExporters mocks a receiver.ReceiverHost for test purposes. It
+// has the given extensions, or an extension receiving metadata if nil.
 type nopHostWithExporters struct {
+	extensions map[configmodels.Extension]component.ServiceExtension
 }
 
 var _ component.Host = (*nopHostWithExporters)(nil)
@@ -97,7 +195,12 @@ func (n nopHostWithExporters) GetFactory(component.Kind, configmodels.Type) comp
 }
 
 func (n nopHostWithExporters) GetExtensions() map[configmodels.Extension]component.ServiceExtension {
-	return nil
+	if n.extensions != nil {
+		return n.extensions
+	}
+	return map[configmodels.Extension]component.ServiceExtension{
+		&configmodels.ExtensionSettings{TypeVal: "exampleextension", NameVal: "exampleextension/withmetadata"}: mockExporterWithK8sMetadata{},
+	}
 }
 
 func (n nopHostWithExporters) GetExporters() map[configmodels.DataType]map[configmodels.Exporter]component.Exporter {
@@ -106,5 +209,9 @@ func (n nopHostWithExporters) GetExporters() map[configmodels.DataType]map[confi
 			&configmodels.ExporterSettings{TypeVal: "exampleexporter", NameVal: "exampleexporter/withoutmetadata"}: MockExporter{},
 			&configmodels.ExporterSettings{TypeVal: "exampleexporter", NameVal: "exampleexporter/withmetadata"}:    mockExporterWithK8sMetadata{},
 		},
+		configmodels.LogsDataType: {
+			&configmodels.ExporterSettings{TypeVal: "exampleexporter", NameVal: "exampleexporter/withmetadata"}: mockExporterWithK8sMetadata{},
+			&configmodels.ExporterSettings{TypeVal: "exampleexporter", NameVal: "exampleexporter/logs"}:         MockExporter{},
+		},
 	}
 }
@@ -0,0 +1,73 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclusterreceiver
+
+import (
+	"errors"
+	"net"
+	"net/http"
+
+	"go.opentelemetry.io/collector/component"
+	"go.uber.org/zap"
+)
+
+// isReady returns true once the initial sync of the informer caches has
+// completed. With leader_election, replicas standing by are ready as well.
+func (kr *kubernetesReceiver) isReady() bool {
+	if kr.config.LeaderElection.Enabled && !kr.leading.Load() {
+		return true
+	}
+	return kr.resourceWatcher.initialSyncDone.Load()
+}
+
+// ServeHTTP responds to readiness probes, returning 503 until the informer
+// caches have synced.
+func (kr *kubernetesReceiver) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	if !kr.isReady() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// startReadinessServer starts serving readiness probes on the configured
+// endpoint. It is a no-op if no endpoint has been configured.
+func (kr *kubernetesReceiver) startReadinessServer(host component.Host) error {
+	if kr.config.ReadinessEndpoint == "" {
+		return nil
+	}
+
+	ln, err := net.Listen("tcp", kr.config.ReadinessEndpoint)
+	if err != nil {
+		return err
+	}
+
+	kr.readinessServer = &http.Server{Handler: kr}
+	go func() {
+		if err := kr.readinessServer.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			kr.logger.Error("Readiness server failed", zap.Error(err))
+			host.ReportFatalError(err)
+		}
+	}()
+
+	return nil
+}
+
+func (kr *kubernetesReceiver) stopReadinessServer() error {
+	if kr.readinessServer == nil {
+		return nil
+	}
+	return kr.readinessServer.Close()
+}
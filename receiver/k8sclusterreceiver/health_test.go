@@ -0,0 +1,65 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclusterreceiver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/testutil"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestReadinessReflectsInitialSync(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	endpoint := testutil.GetAvailableLocalAddress(t)
+	r := setupReceiverWithDynamicClient(client, nil, new(consumertest.MetricsSink), 10*time.Second,
+		func(cfg *Config) {
+			cfg.ReadinessEndpoint = endpoint
+		})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	ctx := context.Background()
+	require.NoError(t, r.Start(ctx, componenttest.NewNopHost()))
+
+	require.Eventually(t, func() bool {
+		resp, err := http.Get("http://" + endpoint)
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, 10*time.Second, 100*time.Millisecond)
+
+	require.NoError(t, r.Shutdown(ctx))
+}
+
+func TestReadinessServerDisabledByDefault(t *testing.T) {
+	r := setupReceiver(fake.NewSimpleClientset(), new(consumertest.MetricsSink), 10*time.Second)
+
+	ctx := context.Background()
+	require.NoError(t, r.Start(ctx, componenttest.NewNopHost()))
+	require.Nil(t, r.readinessServer)
+	require.NoError(t, r.Shutdown(ctx))
+}
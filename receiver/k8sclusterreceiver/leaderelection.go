@@ -0,0 +1,110 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclusterreceiver
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.uber.org/zap"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// defaultLeaderElection is the default of leader_election, with the durations
+// commonly used by Kubernetes components.
+var defaultLeaderElection = LeaderElectionConfig{
+	LeaseName:     "k8s-cluster-receiver",
+	LeaseDuration: 15 * time.Second,
+	RenewDeadline: 10 * time.Second,
+	RetryPeriod:   2 * time.Second,
+}
+
+// startLeaderElection runs the receiver as a candidate for the lease set by
+// leader_election, so that of several replicas only the one holding the lease
+// watches resources and pushes metrics. The others wait to take over once the
+// lease expires, e.g. since the leader died. A leader that loses the lease
+// stops collecting and reports a fatal error, so that it is restarted as a
+// candidate with fresh informer caches.
+func (kr *kubernetesReceiver) startLeaderElection(ctx context.Context, host component.Host) error {
+	cfg := kr.config.LeaderElection
+	identity, err := leaderElectionIdentity()
+	if err != nil {
+		return fmt.Errorf("failed to determine leader election identity: %w", err)
+	}
+
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock: &resourcelock.LeaseLock{
+			LeaseMeta:  v1.ObjectMeta{Name: cfg.LeaseName, Namespace: cfg.LeaseNamespace},
+			Client:     kr.resourceWatcher.client.CoordinationV1(),
+			LockConfig: resourcelock.ResourceLockConfig{Identity: identity},
+		},
+		LeaseDuration:   cfg.LeaseDuration,
+		RenewDeadline:   cfg.RenewDeadline,
+		RetryPeriod:     cfg.RetryPeriod,
+		ReleaseOnCancel: true,
+		Name:            cfg.LeaseName,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(leaderCtx context.Context) {
+				kr.logger.Info("Acquired leader election lease, starting collection.",
+					zap.String("identity", identity))
+				kr.leading.Store(true)
+				kr.run(leaderCtx, host)
+			},
+			OnStoppedLeading: func() {
+				if !kr.leading.Load() || ctx.Err() != nil {
+					return
+				}
+				kr.logger.Error("Lost leader election lease, stopping collection.",
+					zap.String("identity", identity))
+				host.ReportFatalError(fmt.Errorf("receiver %s lost leader election lease %s/%s",
+					kr.config.NameVal, cfg.LeaseNamespace, cfg.LeaseName))
+			},
+			OnNewLeader: func(leader string) {
+				if leader != identity {
+					kr.logger.Info("Another replica holds the leader election lease, standing by.",
+						zap.String("leader", leader))
+				}
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	go elector.Run(ctx)
+	return nil
+}
+
+// leaderElectionIdentity returns the identity of the receiver as a candidate,
+// the hostname, i.e. the name of the pod, along with a random suffix telling
+// apart receivers of the same host.
+func leaderElectionIdentity() (string, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", err
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return hostname + "_" + hex.EncodeToString(suffix), nil
+}
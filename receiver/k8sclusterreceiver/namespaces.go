@@ -0,0 +1,129 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclusterreceiver
+
+import (
+	"errors"
+	"strconv"
+
+	"k8s.io/client-go/tools/cache"
+)
+
+// errReadOnlyStore is returned when modifying a namespacedStore, whose objects
+// are only modified by the informers of its stores.
+var errReadOnlyStore = errors.New("store of a kind watched in several namespaces is read-only")
+
+// namespacedInformers are the informers of a kind watched in several
+// namespaces when namespaces is set, one per namespace.
+type namespacedInformers struct {
+	informers []cache.SharedIndexInformer
+	// store reads the objects of all the namespaces.
+	store *namespacedStore
+}
+
+func newNamespacedInformers() *namespacedInformers {
+	return &namespacedInformers{store: &namespacedStore{}}
+}
+
+func (ni *namespacedInformers) add(informer cache.SharedIndexInformer) {
+	ni.informers = append(ni.informers, informer)
+	ni.store.stores = append(ni.store.stores, informer.GetStore())
+}
+
+// lastSyncResourceVersion returns the highest resource version last observed
+// by the informers. Resource versions that cannot be parsed as integers are
+// opaque and cannot be compared, the first one is returned then.
+func (ni *namespacedInformers) lastSyncResourceVersion() string {
+	var last string
+	var highest int64 = -1
+	for _, informer := range ni.informers {
+		version := informer.LastSyncResourceVersion()
+		v, err := strconv.ParseInt(version, 10, 64)
+		if err != nil {
+			if last == "" {
+				last = version
+			}
+			continue
+		}
+		if v > highest {
+			highest = v
+			last = version
+		}
+	}
+	return last
+}
+
+// namespacedStore is a read-only cache.Store over the informer caches of a
+// kind in several namespaces. Objects of different namespaces have different
+// keys, so the stores never have keys in common.
+type namespacedStore struct {
+	stores []cache.Store
+}
+
+var _ cache.Store = (*namespacedStore)(nil)
+
+func (s *namespacedStore) Add(interface{}) error {
+	return errReadOnlyStore
+}
+
+func (s *namespacedStore) Update(interface{}) error {
+	return errReadOnlyStore
+}
+
+func (s *namespacedStore) Delete(interface{}) error {
+	return errReadOnlyStore
+}
+
+func (s *namespacedStore) Replace([]interface{}, string) error {
+	return errReadOnlyStore
+}
+
+func (s *namespacedStore) Resync() error {
+	return errReadOnlyStore
+}
+
+func (s *namespacedStore) List() []interface{} {
+	var out []interface{}
+	for _, store := range s.stores {
+		out = append(out, store.List()...)
+	}
+	return out
+}
+
+func (s *namespacedStore) ListKeys() []string {
+	var out []string
+	for _, store := range s.stores {
+		out = append(out, store.ListKeys()...)
+	}
+	return out
+}
+
+func (s *namespacedStore) Get(obj interface{}) (item interface{}, exists bool, err error) {
+	for _, store := range s.stores {
+		if item, exists, err = store.Get(obj); exists || err != nil {
+			return item, exists, err
+		}
+	}
+	return nil, false, nil
+}
+
+func (s *namespacedStore) GetByKey(key string) (item interface{}, exists bool, err error) {
+	for _, store := range s.stores {
+		if item, exists, err = store.GetByKey(key); exists || err != nil {
+			return item, exists, err
+		}
+	}
+	return nil, false, nil
+}
@@ -0,0 +1,315 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package observability
+
+import (
+	"context"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+// TODO: re-think if receiver should register it's own telemetry views or if some other
+// mechanism should be used by the collector to discover views from all components
+
+func init() {
+	view.Register(
+		viewObjectsThrottled,
+		viewEventQueueDepth,
+		viewPushBatchesDropped,
+		viewKindsDisabled,
+		viewMetricsRejected,
+		viewWatchErrors,
+		viewInformerRelists,
+		viewMetadataSyncFailures,
+		viewCachedObjects,
+		viewMetricsStoreObjects,
+		viewLastEventTimestamp,
+		viewStaleObjectsEvicted,
+		viewClientRequestsThrottled,
+		viewClientThrottleWait,
+	)
+}
+
+var (
+	tagKind, _ = tag.NewKey("kind")
+
+	mObjectsThrottled = stats.Int64("otelsvc/k8s_cluster/objects_throttled",
+		"Number of objects not cached because the per-kind object limit was reached", "1")
+
+	mEventQueueDepth = stats.Int64("otelsvc/k8s_cluster/event_queue_depth",
+		"Number of informer events waiting to be processed", "1")
+
+	mPushBatchesDropped = stats.Int64("otelsvc/k8s_cluster/push_batches_dropped",
+		"Number of collected batches of metrics dropped because the push queue or the push retry queue was full, "+
+			"the push timed out or a retried push failed permanently", "1")
+
+	mKindsDisabled = stats.Int64("otelsvc/k8s_cluster/kind_disabled",
+		"Whether a kind is not collected because the receiver is not permitted to list it", "1")
+
+	mMetricsRejected = stats.Int64("otelsvc/k8s_cluster/metrics_rejected",
+		"Number of metrics of objects not cached because they are malformed", "1")
+
+	mWatchErrors = stats.Int64("otelsvc/k8s_cluster/watch_errors",
+		"Number of errors ending the list and watch of the informer of a kind, e.g. since the receiver "+
+			"is no longer permitted to list it or is throttled by the API server", "1")
+
+	mInformerRelists = stats.Int64("otelsvc/k8s_cluster/informer_relists",
+		"Number of times the informer of a kind had to list all objects again", "1")
+
+	mMetadataSyncFailures = stats.Int64("otelsvc/k8s_cluster/metadata_sync_failures",
+		"Number of metadata updates that metadata_exporters failed to consume", "1")
+
+	mCachedObjects = stats.Int64("otelsvc/k8s_cluster/cached_objects",
+		"Number of objects of a kind held in the informer cache", "1")
+
+	mMetricsStoreObjects = stats.Int64("otelsvc/k8s_cluster/metrics_store_objects",
+		"Number of objects whose metrics are cached", "1")
+
+	mLastEventTimestamp = stats.Int64("otelsvc/k8s_cluster/last_event_timestamp",
+		"Time, in seconds since the Unix epoch, of the last event received by the informer of a kind", "s")
+
+	mStaleObjectsEvicted = stats.Int64("otelsvc/k8s_cluster/stale_objects_evicted",
+		"Number of objects whose metrics were evicted since they are no longer in the informer cache, "+
+			"e.g. since their deletion was missed", "1")
+
+	mClientRequestsThrottled = stats.Int64("otelsvc/k8s_cluster/client_requests_throttled",
+		"Number of requests to the K8s API server delayed by the client-side rate limit set by api_qps and api_burst", "1")
+
+	mClientThrottleWait = stats.Int64("otelsvc/k8s_cluster/client_throttle_wait",
+		"Time requests to the K8s API server waited for the client-side rate limit", "ms")
+)
+
+var viewObjectsThrottled = &view.View{
+	Name:        mObjectsThrottled.Name(),
+	Description: mObjectsThrottled.Description(),
+	Measure:     mObjectsThrottled,
+	TagKeys:     []tag.Key{tagKind},
+	Aggregation: view.Sum(),
+}
+
+var viewEventQueueDepth = &view.View{
+	Name:        mEventQueueDepth.Name(),
+	Description: mEventQueueDepth.Description(),
+	Measure:     mEventQueueDepth,
+	Aggregation: view.LastValue(),
+}
+
+var viewPushBatchesDropped = &view.View{
+	Name:        mPushBatchesDropped.Name(),
+	Description: mPushBatchesDropped.Description(),
+	Measure:     mPushBatchesDropped,
+	Aggregation: view.Sum(),
+}
+
+var viewKindsDisabled = &view.View{
+	Name:        mKindsDisabled.Name(),
+	Description: mKindsDisabled.Description(),
+	Measure:     mKindsDisabled,
+	TagKeys:     []tag.Key{tagKind},
+	Aggregation: view.LastValue(),
+}
+
+var viewMetricsRejected = &view.View{
+	Name:        mMetricsRejected.Name(),
+	Description: mMetricsRejected.Description(),
+	Measure:     mMetricsRejected,
+	TagKeys:     []tag.Key{tagKind},
+	Aggregation: view.Sum(),
+}
+
+var viewWatchErrors = &view.View{
+	Name:        mWatchErrors.Name(),
+	Description: mWatchErrors.Description(),
+	Measure:     mWatchErrors,
+	TagKeys:     []tag.Key{tagKind},
+	Aggregation: view.Sum(),
+}
+
+var viewInformerRelists = &view.View{
+	Name:        mInformerRelists.Name(),
+	Description: mInformerRelists.Description(),
+	Measure:     mInformerRelists,
+	TagKeys:     []tag.Key{tagKind},
+	Aggregation: view.Sum(),
+}
+
+var viewMetadataSyncFailures = &view.View{
+	Name:        mMetadataSyncFailures.Name(),
+	Description: mMetadataSyncFailures.Description(),
+	Measure:     mMetadataSyncFailures,
+	Aggregation: view.Sum(),
+}
+
+var viewCachedObjects = &view.View{
+	Name:        mCachedObjects.Name(),
+	Description: mCachedObjects.Description(),
+	Measure:     mCachedObjects,
+	TagKeys:     []tag.Key{tagKind},
+	Aggregation: view.LastValue(),
+}
+
+var viewMetricsStoreObjects = &view.View{
+	Name:        mMetricsStoreObjects.Name(),
+	Description: mMetricsStoreObjects.Description(),
+	Measure:     mMetricsStoreObjects,
+	Aggregation: view.LastValue(),
+}
+
+var viewLastEventTimestamp = &view.View{
+	Name:        mLastEventTimestamp.Name(),
+	Description: mLastEventTimestamp.Description(),
+	Measure:     mLastEventTimestamp,
+	TagKeys:     []tag.Key{tagKind},
+	Aggregation: view.LastValue(),
+}
+
+var viewStaleObjectsEvicted = &view.View{
+	Name:        mStaleObjectsEvicted.Name(),
+	Description: mStaleObjectsEvicted.Description(),
+	Measure:     mStaleObjectsEvicted,
+	TagKeys:     []tag.Key{tagKind},
+	Aggregation: view.Sum(),
+}
+
+var viewClientRequestsThrottled = &view.View{
+	Name:        mClientRequestsThrottled.Name(),
+	Description: mClientRequestsThrottled.Description(),
+	Measure:     mClientRequestsThrottled,
+	Aggregation: view.Sum(),
+}
+
+var viewClientThrottleWait = &view.View{
+	Name:        mClientThrottleWait.Name(),
+	Description: mClientThrottleWait.Description(),
+	Measure:     mClientThrottleWait,
+	Aggregation: view.Sum(),
+}
+
+// RecordObjectThrottled increments the metric that records objects of the given
+// kind that were not cached due to the per-kind object limit.
+func RecordObjectThrottled(kind string) {
+	_ = stats.RecordWithTags(
+		context.Background(),
+		[]tag.Mutator{tag.Upsert(tagKind, kind)},
+		mObjectsThrottled.M(int64(1)),
+	)
+}
+
+// RecordEventQueueDepth records the number of informer events waiting to be processed.
+func RecordEventQueueDepth(depth int64) {
+	stats.Record(context.Background(), mEventQueueDepth.M(depth))
+}
+
+// RecordPushBatchDropped increments the metric that records batches of
+// collected metrics dropped because the push queue or the push retry queue was
+// full, the push timed out or a retried push failed permanently.
+func RecordPushBatchDropped() {
+	stats.Record(context.Background(), mPushBatchesDropped.M(int64(1)))
+}
+
+// RecordKindDisabled records that the given kind is not collected because the
+// receiver is not permitted to list it.
+func RecordKindDisabled(kind string) {
+	_ = stats.RecordWithTags(
+		context.Background(),
+		[]tag.Mutator{tag.Upsert(tagKind, kind)},
+		mKindsDisabled.M(int64(1)),
+	)
+}
+
+// RecordMetricsRejected adds count to the metric that records malformed
+// metrics of objects of the given kind that were not cached.
+func RecordMetricsRejected(kind string, count int64) {
+	_ = stats.RecordWithTags(
+		context.Background(),
+		[]tag.Mutator{tag.Upsert(tagKind, kind)},
+		mMetricsRejected.M(count),
+	)
+}
+
+// RecordWatchError increments the metric that records errors ending the list
+// and watch of the informer of the given kind.
+func RecordWatchError(kind string) {
+	_ = stats.RecordWithTags(
+		context.Background(),
+		[]tag.Mutator{tag.Upsert(tagKind, kind)},
+		mWatchErrors.M(int64(1)),
+	)
+}
+
+// RecordInformerRelist increments the metric that records relists of the
+// informer of the given kind.
+func RecordInformerRelist(kind string) {
+	_ = stats.RecordWithTags(
+		context.Background(),
+		[]tag.Mutator{tag.Upsert(tagKind, kind)},
+		mInformerRelists.M(int64(1)),
+	)
+}
+
+// RecordMetadataSyncFailure increments the metric that records metadata
+// updates that failed to be consumed.
+func RecordMetadataSyncFailure() {
+	stats.Record(context.Background(), mMetadataSyncFailures.M(int64(1)))
+}
+
+// RecordCachedObjects records the number of objects of the given kind held in
+// the informer cache.
+func RecordCachedObjects(kind string, count int64) {
+	_ = stats.RecordWithTags(
+		context.Background(),
+		[]tag.Mutator{tag.Upsert(tagKind, kind)},
+		mCachedObjects.M(count),
+	)
+}
+
+// RecordMetricsStoreObjects records the number of objects whose metrics are
+// cached.
+func RecordMetricsStoreObjects(count int64) {
+	stats.Record(context.Background(), mMetricsStoreObjects.M(count))
+}
+
+// RecordEvent records the time of the last event received by the informer of
+// the given kind.
+func RecordEvent(kind string, now time.Time) {
+	_ = stats.RecordWithTags(
+		context.Background(),
+		[]tag.Mutator{tag.Upsert(tagKind, kind)},
+		mLastEventTimestamp.M(now.Unix()),
+	)
+}
+
+// RecordStaleObjectsEvicted adds count to the metric that records objects of
+// the given kind whose metrics were evicted since they are no longer in the
+// informer cache.
+func RecordStaleObjectsEvicted(kind string, count int64) {
+	_ = stats.RecordWithTags(
+		context.Background(),
+		[]tag.Mutator{tag.Upsert(tagKind, kind)},
+		mStaleObjectsEvicted.M(count),
+	)
+}
+
+// RecordClientThrottled records a request to the K8s API server that waited
+// for the client-side rate limit for the given time.
+func RecordClientThrottled(wait time.Duration) {
+	stats.Record(context.Background(),
+		mClientRequestsThrottled.M(int64(1)),
+		mClientThrottleWait.M(wait.Milliseconds()),
+	)
+}
@@ -0,0 +1,161 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package observability
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+)
+
+func TestRecordObjectThrottled(t *testing.T) {
+	RecordObjectThrottled("Pod")
+	RecordObjectThrottled("Pod")
+	RecordObjectThrottled("Job")
+
+	rows, err := view.RetrieveData(viewObjectsThrottled.Name)
+	require.NoError(t, err)
+
+	sums := map[string]float64{}
+	for _, row := range rows {
+		require.Len(t, row.Tags, 1)
+		sums[row.Tags[0].Value] = row.Data.(*view.SumData).Value
+	}
+	require.Equal(t, map[string]float64{"Pod": 2, "Job": 1}, sums)
+}
+
+func TestRecordEventQueueDepth(t *testing.T) {
+	RecordEventQueueDepth(5)
+	RecordEventQueueDepth(3)
+
+	rows, err := view.RetrieveData(viewEventQueueDepth.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, float64(3), rows[0].Data.(*view.LastValueData).Value)
+}
+
+func TestRecordPushBatchDropped(t *testing.T) {
+	RecordPushBatchDropped()
+	RecordPushBatchDropped()
+
+	rows, err := view.RetrieveData(viewPushBatchesDropped.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, float64(2), rows[0].Data.(*view.SumData).Value)
+}
+
+func TestRecordKindDisabled(t *testing.T) {
+	RecordKindDisabled("Node")
+
+	rows, err := view.RetrieveData(viewKindsDisabled.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Len(t, rows[0].Tags, 1)
+	require.Equal(t, "Node", rows[0].Tags[0].Value)
+	require.Equal(t, float64(1), rows[0].Data.(*view.LastValueData).Value)
+}
+
+func TestRecordMetricsRejected(t *testing.T) {
+	RecordMetricsRejected("Pod", 2)
+	RecordMetricsRejected("Pod", 1)
+
+	rows, err := view.RetrieveData(viewMetricsRejected.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, "Pod", rows[0].Tags[0].Value)
+	require.Equal(t, float64(3), rows[0].Data.(*view.SumData).Value)
+}
+
+func TestRecordWatchError(t *testing.T) {
+	RecordWatchError("Pod")
+	RecordInformerRelist("Pod")
+	RecordWatchError("Pod")
+
+	rows, err := view.RetrieveData(viewWatchErrors.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, "Pod", rows[0].Tags[0].Value)
+	require.Equal(t, float64(2), rows[0].Data.(*view.SumData).Value)
+
+	rows, err = view.RetrieveData(viewInformerRelists.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, float64(1), rows[0].Data.(*view.SumData).Value)
+}
+
+func TestRecordMetadataSyncFailure(t *testing.T) {
+	RecordMetadataSyncFailure()
+
+	rows, err := view.RetrieveData(viewMetadataSyncFailures.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, float64(1), rows[0].Data.(*view.SumData).Value)
+}
+
+func TestRecordCachedObjects(t *testing.T) {
+	RecordCachedObjects("Pod", 10)
+	RecordCachedObjects("Pod", 7)
+	RecordMetricsStoreObjects(5)
+
+	rows, err := view.RetrieveData(viewCachedObjects.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, "Pod", rows[0].Tags[0].Value)
+	require.Equal(t, float64(7), rows[0].Data.(*view.LastValueData).Value)
+
+	rows, err = view.RetrieveData(viewMetricsStoreObjects.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, float64(5), rows[0].Data.(*view.LastValueData).Value)
+}
+
+func TestRecordEvent(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	RecordEvent("Node", now)
+
+	rows, err := view.RetrieveData(viewLastEventTimestamp.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, "Node", rows[0].Tags[0].Value)
+	require.Equal(t, float64(now.Unix()), rows[0].Data.(*view.LastValueData).Value)
+}
+
+func TestRecordStaleObjectsEvicted(t *testing.T) {
+	RecordStaleObjectsEvicted("Pod", 2)
+	RecordStaleObjectsEvicted("Pod", 1)
+
+	rows, err := view.RetrieveData(viewStaleObjectsEvicted.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, "Pod", rows[0].Tags[0].Value)
+	require.Equal(t, float64(3), rows[0].Data.(*view.SumData).Value)
+}
+
+func TestRecordClientThrottled(t *testing.T) {
+	RecordClientThrottled(200 * time.Millisecond)
+	RecordClientThrottled(time.Second)
+
+	rows, err := view.RetrieveData(viewClientRequestsThrottled.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, float64(2), rows[0].Data.(*view.SumData).Value)
+
+	rows, err = view.RetrieveData(viewClientThrottleWait.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, float64(1200), rows[0].Data.(*view.SumData).Value)
+}
@@ -0,0 +1,148 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclusterreceiver
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/consumer/pdata"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/observability"
+)
+
+// pushRetrier holds batches of metrics whose push failed in a bounded queue
+// and pushes them again, oldest first, until they are accepted. The delay
+// before retrying doubles on consecutive failures, from min up to max, and is
+// reset once a retry succeeds. Once the queue is full, the oldest batch is
+// dropped to make room for the new one.
+type pushRetrier struct {
+	size     int
+	min, max time.Duration
+	push     func(ctx context.Context, md pdata.Metrics) error
+	logger   *zap.Logger
+	// wait waits for d or until ctx is done.
+	wait func(ctx context.Context, d time.Duration)
+
+	mu    sync.Mutex
+	queue []*pdata.Metrics
+	// added is signalled when a batch is added to the queue.
+	added chan struct{}
+}
+
+func newPushRetrier(
+	logger *zap.Logger, size int, min, max time.Duration,
+	push func(ctx context.Context, md pdata.Metrics) error) *pushRetrier {
+	return &pushRetrier{
+		size:   size,
+		min:    min,
+		max:    max,
+		push:   push,
+		logger: logger,
+		wait: func(ctx context.Context, d time.Duration) {
+			t := time.NewTimer(d)
+			defer t.Stop()
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+			}
+		},
+		added: make(chan struct{}, 1),
+	}
+}
+
+// isRetryable returns whether a push that failed with err is to be retried.
+// Permanent errors are not, nor are pushes that timed out, which the consumer
+// may still be processing, or that were cancelled on shutdown.
+func isRetryable(err error) bool {
+	return err != nil && !consumererror.IsPermanent(err) &&
+		!errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled)
+}
+
+// add queues md to be pushed again.
+func (r *pushRetrier) add(md pdata.Metrics) {
+	r.mu.Lock()
+	if len(r.queue) == r.size {
+		r.queue[0] = nil
+		r.queue = r.queue[1:]
+		observability.RecordPushBatchDropped()
+		r.logger.Debug("Push retry queue is full, dropping the oldest failed metrics.")
+	}
+	r.queue = append(r.queue, &md)
+	r.mu.Unlock()
+
+	select {
+	case r.added <- struct{}{}:
+	default:
+	}
+}
+
+// oldest returns the oldest queued batch, or nil if the queue is empty.
+func (r *pushRetrier) oldest() *pdata.Metrics {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.queue) == 0 {
+		return nil
+	}
+	return r.queue[0]
+}
+
+// remove removes md from the queue unless it has already been dropped.
+func (r *pushRetrier) remove(md *pdata.Metrics) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.queue) > 0 && r.queue[0] == md {
+		r.queue[0] = nil
+		r.queue = r.queue[1:]
+	}
+}
+
+// run pushes the queued batches again until ctx is done.
+func (r *pushRetrier) run(ctx context.Context) {
+	delay := r.min
+	for {
+		md := r.oldest()
+		if md == nil {
+			select {
+			case <-r.added:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		r.wait(ctx, delay)
+		if ctx.Err() != nil {
+			return
+		}
+		err := r.push(ctx, *md)
+		if isRetryable(err) {
+			r.logger.Debug("Retrying to push metrics failed.", zap.Error(err), zap.Duration("delay", delay))
+			if delay *= 2; delay > r.max {
+				delay = r.max
+			}
+			continue
+		}
+		if err != nil {
+			observability.RecordPushBatchDropped()
+		}
+		r.remove(md)
+		delay = r.min
+	}
+}
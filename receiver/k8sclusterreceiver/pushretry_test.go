@@ -0,0 +1,97 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclusterreceiver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/consumer/pdata"
+	"go.uber.org/zap"
+)
+
+func TestIsRetryable(t *testing.T) {
+	require.False(t, isRetryable(nil))
+	require.True(t, isRetryable(errors.New("consumer unavailable")))
+	require.False(t, isRetryable(consumererror.Permanent(errors.New("invalid metrics"))))
+	require.False(t, isRetryable(context.DeadlineExceeded))
+	require.False(t, isRetryable(context.Canceled))
+}
+
+func TestPushRetrier(t *testing.T) {
+	// The first four pushes fail, batches are told apart by their number of
+	// resources.
+	failures := 4
+	pushed := make(chan int, 10)
+	r := newPushRetrier(zap.NewNop(), 2, time.Second, 4*time.Second, func(_ context.Context, md pdata.Metrics) error {
+		if failures > 0 {
+			failures--
+			return errors.New("consumer unavailable")
+		}
+		pushed <- md.ResourceMetrics().Len()
+		return nil
+	})
+	// Each wait blocks until its delay is received.
+	delays := make(chan time.Duration)
+	r.wait = func(_ context.Context, d time.Duration) { delays <- d }
+	requireDelay := func(want time.Duration) {
+		select {
+		case d := <-delays:
+			require.Equal(t, want, d)
+		case <-time.After(10 * time.Second):
+			t.Fatal("push not retried")
+		}
+	}
+	requirePushed := func(want int) {
+		select {
+		case n := <-pushed:
+			require.Equal(t, want, n)
+		case <-time.After(10 * time.Second):
+			t.Fatal("batch not pushed")
+		}
+	}
+
+	droppedBefore := pushBatchesDropped(t)
+	for i := 1; i <= 3; i++ {
+		md := pdata.NewMetrics()
+		md.ResourceMetrics().Resize(i)
+		r.add(md)
+	}
+	// The oldest batch is dropped to make room for the third one.
+	require.Equal(t, droppedBefore+1, pushBatchesDropped(t))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.run(ctx)
+
+	// The delay doubles on consecutive failures up to the maximum.
+	for _, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		requireDelay(want)
+	}
+
+	// Once pushes succeed, the queued batches are pushed oldest first, the
+	// delay being reset after the first success.
+	requireDelay(4 * time.Second)
+	requirePushed(2)
+	requireDelay(time.Second)
+	requirePushed(3)
+	require.Eventually(t, func() bool {
+		return r.oldest() == nil
+	}, 10*time.Second, 10*time.Millisecond, "pushed batch still queued")
+}
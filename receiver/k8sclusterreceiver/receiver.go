@@ -17,27 +17,60 @@ package k8sclusterreceiver
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"time"
 
 	"go.opentelemetry.io/collector/component"
-	"go.opentelemetry.io/collector/config/configmodels"
+	"go.opentelemetry.io/collector/component/componenterror"
 	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/consumer/pdata"
 	"go.opentelemetry.io/collector/obsreport"
-	"go.opentelemetry.io/collector/translator/internaldata"
+	"go.uber.org/atomic"
 	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/observability"
 )
 
 const (
 	transport = "http"
 
 	defaultInitialSyncTimeout = 10 * time.Minute
+	defaultPushDebounce       = time.Second
+
+	defaultPushRetryInitialBackoff = time.Second
+	defaultPushRetryMaxBackoff     = 30 * time.Second
+
+	// Values of push_queue_policy.
+	pushQueuePolicyBlock = "block"
+	pushQueuePolicyDrop  = "drop"
+
+	// Values of push_mode.
+	pushModeInterval = "interval"
+	pushModeOnChange = "on_change"
+
+	// Values of group_by.
+	groupByNone      = "none"
+	groupByNamespace = "namespace"
 )
 
 var _ component.MetricsReceiver = (*kubernetesReceiver)(nil)
 
 type kubernetesReceiver struct {
 	resourceWatcher *resourceWatcher
+	readinessServer *http.Server
+	controlServer   *http.Server
+	// Whether pushing metrics has been paused through the control endpoint.
+	paused *atomic.Bool
+	// Whether the receiver holds the lease of leader_election, if enabled.
+	leading *atomic.Bool
+	// Batches of collected metrics waiting to be pushed, only set if
+	// push_queue_size is configured.
+	pushQueue chan pdata.Metrics
+	// Pushes failed metrics again, only set if push_retry is configured.
+	pushRetrier *pushRetrier
 
 	config   *Config
 	logger   *zap.Logger
@@ -49,76 +82,288 @@ func (kr *kubernetesReceiver) Start(ctx context.Context, host component.Host) er
 	var c context.Context
 	c, kr.cancel = context.WithCancel(obsreport.ReceiverContext(ctx, kr.config.Name(), transport))
 
-	exporters := host.GetExporters()
-	if err := kr.resourceWatcher.setupMetadataExporters(
-		exporters[configmodels.MetricsDataType], kr.config.MetadataExporters); err != nil {
-		return err
+	if len(kr.config.APIVersions) > 0 {
+		if err := checkServedAPIVersions(kr.resourceWatcher.client.Discovery(), kr.config.APIVersions); err != nil {
+			return err
+		}
 	}
 
-	go func() {
-		kr.logger.Info("Starting shared informers and wait for initial cache sync.")
-		kr.resourceWatcher.startWatchingResources(c)
-
-		// Wait till either the initial cache sync times out or until the cancel method
-		// corresponding to this context is called.
-		<-kr.resourceWatcher.timedContextForInitialSync.Done()
-
-		// If the context times out, set initialSyncTimedOut and report a fatal error. Currently
-		// this timeout is 10 minutes, which appears to be long enough.
-		if kr.resourceWatcher.timedContextForInitialSync.Err() == context.DeadlineExceeded {
-			kr.resourceWatcher.initialSyncTimedOut.Store(true)
-			kr.logger.Error("Timed out waiting for initial cache sync.")
-			host.ReportFatalError(fmt.Errorf("failed to start receiver: %s", kr.config.NameVal))
-			return
+	if kr.config.Sharding.Count > 0 {
+		namespaces, err := shardNamespaces(ctx, kr.resourceWatcher.client, kr.config.Sharding, kr.config.Namespaces)
+		if err != nil {
+			return fmt.Errorf("failed to list the namespaces to shard: %v", err)
 		}
+		kr.logger.Info("Watching the namespaces of the shard",
+			zap.Int("shard", kr.config.Sharding.Index), zap.Int("shards", kr.config.Sharding.Count),
+			zap.Int("namespaces", len(namespaces)))
+		kr.resourceWatcher.namespaces = namespaces
+	}
 
-		kr.logger.Info("Completed syncing shared informer caches.")
-		kr.resourceWatcher.initialSyncDone.Store(true)
+	candidates, err := metadataExporterCandidates(host)
+	if err != nil {
+		return fmt.Errorf("failed to configure metadata_exporters: %v", err)
+	}
+	if err := kr.resourceWatcher.setupMetadataExporters(candidates, kr.config.MetadataExporters); err != nil {
+		return err
+	}
+	if err := kr.resourceWatcher.setupImageEnricher(host, kr.config.ImageMetadata.Enricher); err != nil {
+		return err
+	}
 
-		ticker := time.NewTicker(kr.config.CollectionInterval)
-		defer ticker.Stop()
+	if err := kr.startReadinessServer(host); err != nil {
+		return err
+	}
 
-		for {
-			select {
-			case <-ticker.C:
-				kr.dispatchMetrics(c)
-			case <-c.Done():
-				return
-			}
-		}
-	}()
+	if err := kr.startControlServer(host); err != nil {
+		_ = kr.stopReadinessServer()
+		return err
+	}
+
+	if kr.config.PushQueueSize > 0 {
+		kr.pushQueue = make(chan pdata.Metrics, kr.config.PushQueueSize)
+		go kr.runPushQueue(c)
+	}
 
+	if r := kr.config.PushRetry; r.QueueSize > 0 {
+		kr.pushRetrier = newPushRetrier(kr.logger, r.QueueSize, r.InitialBackoff, r.MaxBackoff, kr.pushPdataMetrics)
+		go kr.pushRetrier.run(c)
+	}
+
+	if kr.config.PushMode == pushModeOnChange {
+		kr.resourceWatcher.changeBatcher = newChangeBatcher(kr.config.PushDebounce, kr.dispatchChangedMetrics)
+	}
+
+	if kr.config.LeaderElection.Enabled {
+		return kr.startLeaderElection(c, host)
+	}
+	go kr.run(c, host)
 	return nil
 }
 
+// run watches resources and pushes their metrics until ctx is done.
+func (kr *kubernetesReceiver) run(ctx context.Context, host component.Host) {
+	kr.logger.Info("Starting shared informers and wait for initial cache sync.")
+	kr.resourceWatcher.startWatchingResources(ctx)
+
+	// Wait till either the initial cache sync times out or until the cancel method
+	// corresponding to this context is called.
+	<-kr.resourceWatcher.timedContextForInitialSync.Done()
+
+	// If the context times out, set initialSyncTimedOut and report a fatal
+	// error, as of initial_sync_timeout, 10 minutes by default.
+	if kr.resourceWatcher.timedContextForInitialSync.Err() == context.DeadlineExceeded {
+		kr.resourceWatcher.initialSyncTimedOut.Store(true)
+		kr.logger.Error("Timed out waiting for initial cache sync.",
+			zap.Duration("initial_sync_timeout", kr.config.InitialSyncTimeout))
+		host.ReportFatalError(fmt.Errorf("failed to start receiver: %s", kr.config.NameVal))
+		return
+	}
+
+	kr.logger.Info("Completed syncing shared informer caches.")
+	kr.resourceWatcher.initialSyncDone.Store(true)
+
+	// In on_change mode, the metrics of objects are pushed as they change,
+	// so that only the metrics that do not belong to a single object are
+	// collected every collection_interval, when deleted objects are evicted
+	// as well.
+	tick, dispatch := kr.config.collectionTick(), kr.dispatchMetrics
+	if batcher := kr.resourceWatcher.changeBatcher; batcher != nil {
+		// Changes are recorded before pushing the initial snapshot so
+		// that none is missed. Events of objects whose metrics are part
+		// of the snapshot are not pushed again since their metrics have
+		// not changed.
+		batcher.start(ctx)
+		kr.dispatchMetrics(ctx)
+		tick, dispatch = kr.config.CollectionInterval, kr.dispatchPeriodicMetrics
+	}
+
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			dispatch(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
 func (kr *kubernetesReceiver) Shutdown(context.Context) error {
 	kr.cancel()
-	return nil
+
+	var errs []error
+	if err := kr.stopControlServer(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := kr.stopReadinessServer(); err != nil {
+		errs = append(errs, err)
+	}
+	return componenterror.CombineErrors(errs)
 }
 
 func (kr *kubernetesReceiver) dispatchMetrics(ctx context.Context) {
+	if kr.isPaused() {
+		kr.logger.Debug("Metric collection is paused, skipping.")
+		return
+	}
+
+	now := time.Now()
+	dc := kr.resourceWatcher.dataCollector
+	if kr.config.FlushThreshold > 0 {
+		dc.FlushMetrics(now, kr.config.FlushThreshold, func(md pdata.Metrics) {
+			kr.dispatchGrouped(ctx, md)
+		})
+		kr.dispatchGrouped(ctx, dc.CollectSelfMetrics(now))
+		return
+	}
+	md := dc.CollectMetrics(now)
+	dc.CollectSelfMetrics(now).ResourceMetrics().MoveAndAppendTo(md.ResourceMetrics())
+	kr.dispatchGrouped(ctx, md)
+}
+
+// dispatchPeriodicMetrics pushes the metrics that are not pushed along with
+// changed objects in on_change mode: deletion markers, the metrics computed
+// across objects and those about the collection itself.
+func (kr *kubernetesReceiver) dispatchPeriodicMetrics(ctx context.Context) {
+	if kr.isPaused() {
+		kr.logger.Debug("Metric collection is paused, skipping.")
+		return
+	}
+
 	now := time.Now()
-	mds := kr.resourceWatcher.dataCollector.CollectMetricData(now)
-	resourceMetrics := internaldata.OCSliceToMetrics(mds)
+	dc := kr.resourceWatcher.dataCollector
+	md := dc.CollectPeriodicMetrics(now)
+	dc.CollectSelfMetrics(now).ResourceMetrics().MoveAndAppendTo(md.ResourceMetrics())
+	kr.dispatchGrouped(ctx, md)
+}
 
+// dispatchChangedMetrics pushes the metrics of the given changed objects only,
+// along with the deletion markers of the objects removed since the previous
+// push.
+func (kr *kubernetesReceiver) dispatchChangedMetrics(ctx context.Context, objs []runtime.Object) {
+	if kr.isPaused() {
+		kr.logger.Debug("Metric collection is paused, skipping.")
+		return
+	}
+
+	now := time.Now()
+	dc := kr.resourceWatcher.dataCollector
+	md := dc.CollectDeletionMarkers(now)
+	dc.CollectMetricsForObjects(objs, now).ResourceMetrics().MoveAndAppendTo(md.ResourceMetrics())
+	if md.ResourceMetrics().Len() == 0 {
+		return
+	}
+	kr.dispatchGrouped(ctx, md)
+}
+
+// dispatchGrouped pushes collected metrics in the batches set by group_by.
+func (kr *kubernetesReceiver) dispatchGrouped(ctx context.Context, md pdata.Metrics) {
+	if kr.config.GroupBy != groupByNamespace {
+		kr.dispatch(ctx, md)
+		return
+	}
+	for _, batch := range kr.resourceWatcher.dataCollector.GroupByNamespace(md) {
+		kr.dispatch(ctx, batch)
+	}
+}
+
+// dispatch pushes collected metrics, through the push queue if configured.
+func (kr *kubernetesReceiver) dispatch(ctx context.Context, md pdata.Metrics) {
+	if kr.pushQueue == nil {
+		kr.pushMetrics(ctx, md)
+		return
+	}
+
+	if kr.config.PushQueuePolicy == pushQueuePolicyDrop {
+		select {
+		case kr.pushQueue <- md:
+		default:
+			observability.RecordPushBatchDropped()
+			kr.logger.Debug("Push queue is full, dropping collected metrics.")
+		}
+		return
+	}
+
+	select {
+	case kr.pushQueue <- md:
+	case <-ctx.Done():
+	}
+}
+
+// runPushQueue pushes the batches of metrics of the push queue to the next
+// consumer until ctx is done.
+func (kr *kubernetesReceiver) runPushQueue(ctx context.Context) {
+	for {
+		select {
+		case md := <-kr.pushQueue:
+			kr.pushMetrics(ctx, md)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (kr *kubernetesReceiver) pushMetrics(ctx context.Context, md pdata.Metrics) {
+	if err := kr.pushPdataMetrics(ctx, md); kr.pushRetrier != nil && isRetryable(err) {
+		kr.logger.Debug("Pushing metrics failed, retrying.", zap.Error(err))
+		kr.pushRetrier.add(md)
+	}
+}
+
+// pushPdataMetrics pushes metrics to the next consumer, recording the push.
+func (kr *kubernetesReceiver) pushPdataMetrics(ctx context.Context, resourceMetrics pdata.Metrics) error {
 	c := obsreport.StartMetricsReceiveOp(ctx, typeStr, transport)
 
 	_, numPoints := resourceMetrics.MetricAndDataPointCount()
 
-	err := kr.consumer.ConsumeMetrics(c, resourceMetrics)
+	err := kr.consumeMetrics(c, resourceMetrics)
 	obsreport.EndMetricsReceiveOp(c, typeStr, numPoints, err)
+	return err
+}
+
+// consumeMetrics pushes metrics to the next consumer. If push_timeout is set,
+// it gives up waiting for the consumer once the timeout elapses, so that a
+// hung consumer does not block the following pushes. The consumer is expected
+// to abort the push once the context is done, otherwise it keeps running in
+// the background.
+func (kr *kubernetesReceiver) consumeMetrics(ctx context.Context, md pdata.Metrics) error {
+	if kr.config.PushTimeout <= 0 {
+		return kr.consumer.ConsumeMetrics(ctx, md)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, kr.config.PushTimeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- kr.consumer.ConsumeMetrics(ctx, md)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		observability.RecordPushBatchDropped()
+		kr.logger.Warn("Pushing metrics to the next consumer timed out, dropping collected metrics.",
+			zap.Duration("push_timeout", kr.config.PushTimeout))
+		return ctx.Err()
+	}
 }
 
 // newReceiver creates the Kubernetes cluster receiver with the given configuration.
 func newReceiver(
 	logger *zap.Logger, config *Config, consumer consumer.MetricsConsumer,
-	client kubernetes.Interface) (component.MetricsReceiver, error) {
-	resourceWatcher := newResourceWatcher(logger, client, config.NodeConditionTypesToReport, defaultInitialSyncTimeout)
+	client kubernetes.Interface, dynamicClient dynamic.Interface) (component.MetricsReceiver, error) {
+	resourceWatcher := newResourceWatcher(logger, client, dynamicClient, config, config.InitialSyncTimeout)
 
 	return &kubernetesReceiver{
 		resourceWatcher: resourceWatcher,
 		logger:          logger,
 		config:          config,
 		consumer:        consumer,
+		paused:          atomic.NewBool(false),
+		leading:         atomic.NewBool(false),
 	}, nil
 }
@@ -16,22 +16,66 @@ package k8sclusterreceiver
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
 	"go.opentelemetry.io/collector/component/componenttest"
 	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/consumer/pdata"
 	"go.uber.org/atomic"
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/collection"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/testutils"
 )
 
+// metricsPerPod is the number of metrics reported for each of the pods
+// created by createPods, k8s.pod.phase, k8s.pod.terminating and the container
+// counts of k8s.pod.container_count, k8s.pod.init_container_count and
+// k8s.pod.ephemeral_container_count, k8s.pod.scheduling_gated,
+// k8s.pod.unschedulable and k8s.pod.volume_count.
+const metricsPerPod = 8
+
+// metricsPerNode is the number of metrics reported for each of the nodes
+// created by createNodes, k8s.node.condition_ready, k8s.node.unschedulable,
+// k8s.node.taint_count, k8s.node.spot, k8s.node.ready_transitions and, as long
+// as pods are watched, the allocated requests and allocatable cpu and memory.
+const metricsPerNode = 9
+
+// clusterMetrics is the number of metrics reported for the cluster as long as
+// nodes are watched, the capacity and allocatable cpu and memory.
+const clusterMetrics = 4
+
+// clusterPodMetrics is the number of metrics reported for the cluster as long
+// as pods are watched, k8s.cluster.pending_pods,
+// k8s.cluster.pods_by_priority_class, k8s.cluster.total_container_restarts,
+// k8s.namespace.container_restarts for the single namespace of the pods and,
+// since pods are cached, k8s.cluster.oldest_cached_object_age.
+const clusterPodMetrics = 5
+
+// clusterQuotaMetrics is the number of metrics reported for the cluster as
+// long as ResourceQuotas are watched,
+// k8s.cluster.resource_quotas_over_threshold.
+const clusterQuotaMetrics = 1
+
+// collectionMetrics is the number of metrics reported on every collection,
+// k8s.cluster.last_collection_timestamp and k8s.cluster.scrape_heartbeat.
+const collectionMetrics = 2
+
 func TestReceiver(t *testing.T) {
 	client := fake.NewSimpleClientset()
 	consumer := new(consumertest.MetricsSink)
@@ -49,7 +93,7 @@ func TestReceiver(t *testing.T) {
 
 	// Expects metric data from nodes and pods where each metric data
 	// struct corresponds to one resource.
-	expectedNumMetrics := numPods + numNodes
+	expectedNumMetrics := numPods*metricsPerPod + numNodes*metricsPerNode + clusterMetrics + clusterPodMetrics + clusterQuotaMetrics + collectionMetrics
 	var initialMetricsCount int
 	require.Eventually(t, func() bool {
 		initialMetricsCount = consumer.MetricsCount()
@@ -61,7 +105,7 @@ func TestReceiver(t *testing.T) {
 	deletePods(t, client, numPodsToDelete)
 
 	// Expects metric data from a node, since other resources were deleted.
-	expectedNumMetrics = (numPods - numPodsToDelete) + numNodes
+	expectedNumMetrics = (numPods-numPodsToDelete)*metricsPerPod + numNodes*metricsPerNode + clusterMetrics + clusterPodMetrics + clusterQuotaMetrics + collectionMetrics
 	var metricsCountDelta int
 	require.Eventually(t, func() bool {
 		metricsCountDelta = consumer.MetricsCount() - initialMetricsCount
@@ -72,6 +116,400 @@ func TestReceiver(t *testing.T) {
 	r.Shutdown(ctx)
 }
 
+func TestReceiverWithEventWorkers(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	consumer := new(consumertest.MetricsSink)
+
+	r := setupReceiverWithDynamicClient(client, nil, consumer, 10*time.Second,
+		func(config *Config) {
+			config.EventWorkers = 4
+			config.EventQueueSize = 10
+		})
+
+	numPods := 100
+	createPods(t, client, numPods)
+
+	ctx := context.Background()
+	require.NoError(t, r.Start(ctx, componenttest.NewNopHost()))
+
+	require.Eventually(t, func() bool {
+		return consumer.MetricsCount() == numPods*metricsPerPod+clusterMetrics+clusterPodMetrics+clusterQuotaMetrics+collectionMetrics
+	}, 10*time.Second, 100*time.Millisecond,
+		"metrics not collected")
+
+	r.Shutdown(ctx)
+}
+
+func TestReceiverWithForbiddenKind(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("list", "nodes", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(
+			schema.GroupResource{Resource: "nodes"}, "", errors.New("not permitted"))
+	})
+	consumer := new(consumertest.MetricsSink)
+
+	r := setupReceiver(client, consumer, 10*time.Second)
+
+	numPods := 2
+	createPods(t, client, numPods)
+	createNodes(t, client, 1)
+
+	ctx := context.Background()
+	require.NoError(t, r.Start(ctx, componenttest.NewNopHost()))
+
+	// Pods are collected even though nodes cannot be listed.
+	require.Eventually(t, func() bool {
+		return consumer.MetricsCount() == numPods*metricsPerPod+clusterPodMetrics+clusterQuotaMetrics+collectionMetrics
+	}, 10*time.Second, 100*time.Millisecond,
+		"metrics not collected")
+	require.True(t, r.resourceWatcher.initialSyncDone.Load())
+
+	rows, err := view.RetrieveData("otelsvc/k8s_cluster/kind_disabled")
+	require.NoError(t, err)
+	disabled := map[string]float64{}
+	for _, row := range rows {
+		disabled[row.Tags[0].Value] = row.Data.(*view.LastValueData).Value
+	}
+	require.Equal(t, float64(1), disabled["Node"])
+	require.NotContains(t, disabled, "Pod")
+
+	require.NoError(t, r.Shutdown(ctx))
+}
+
+// blockingConsumer blocks pushes until released.
+type blockingConsumer struct {
+	consumertest.MetricsSink
+	release chan struct{}
+}
+
+func (bc *blockingConsumer) ConsumeMetrics(ctx context.Context, md pdata.Metrics) error {
+	<-bc.release
+	return bc.MetricsSink.ConsumeMetrics(ctx, md)
+}
+
+func TestReceiverWithPushQueue(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	consumer := &blockingConsumer{release: make(chan struct{})}
+
+	r := setupReceiverWithDynamicClient(client, nil, consumer, 10*time.Second,
+		func(config *Config) {
+			config.CollectionInterval = 50 * time.Millisecond
+			config.PushQueueSize = 1
+			config.PushQueuePolicy = pushQueuePolicyDrop
+		})
+
+	createNodes(t, client, 1)
+
+	droppedBefore := pushBatchesDropped(t)
+
+	ctx := context.Background()
+	require.NoError(t, r.Start(ctx, componenttest.NewNopHost()))
+
+	// With the consumer blocked on the first batch and the second one in
+	// the queue, subsequent batches are dropped instead of blocking the
+	// collection.
+	require.Eventually(t, func() bool {
+		return pushBatchesDropped(t) >= droppedBefore+2
+	}, 10*time.Second, 50*time.Millisecond, "batches not dropped")
+
+	close(consumer.release)
+	require.Eventually(t, func() bool {
+		return len(consumer.AllMetrics()) >= 2
+	}, 10*time.Second, 50*time.Millisecond, "metrics not pushed")
+
+	require.NoError(t, r.Shutdown(ctx))
+}
+
+// hungConsumer ignores the context of its first push, which does not return
+// until released, and accepts the following ones.
+type hungConsumer struct {
+	consumertest.MetricsSink
+	pushes  *atomic.Int32
+	release chan struct{}
+}
+
+func (hc *hungConsumer) ConsumeMetrics(ctx context.Context, md pdata.Metrics) error {
+	if hc.pushes.Inc() == 1 {
+		<-hc.release
+		return nil
+	}
+	return hc.MetricsSink.ConsumeMetrics(ctx, md)
+}
+
+func TestReceiverWithPushTimeout(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	consumer := &hungConsumer{pushes: atomic.NewInt32(0), release: make(chan struct{})}
+	defer close(consumer.release)
+
+	r := setupReceiverWithDynamicClient(client, nil, consumer, 10*time.Second,
+		func(config *Config) {
+			config.CollectionInterval = 50 * time.Millisecond
+			config.PushTimeout = 100 * time.Millisecond
+		})
+
+	createNodes(t, client, 1)
+
+	droppedBefore := pushBatchesDropped(t)
+
+	ctx := context.Background()
+	require.NoError(t, r.Start(ctx, componenttest.NewNopHost()))
+
+	// The hung push is dropped once it times out and the following pushes
+	// go on.
+	require.Eventually(t, func() bool {
+		return len(consumer.AllMetrics()) >= 2
+	}, 10*time.Second, 50*time.Millisecond, "metrics not pushed after the hung push")
+	require.Equal(t, droppedBefore+1, pushBatchesDropped(t))
+
+	require.NoError(t, r.Shutdown(ctx))
+}
+
+// flakyConsumer fails its first push and accepts the following ones.
+type flakyConsumer struct {
+	consumertest.MetricsSink
+	pushes *atomic.Int32
+}
+
+func (fc *flakyConsumer) ConsumeMetrics(ctx context.Context, md pdata.Metrics) error {
+	if fc.pushes.Inc() == 1 {
+		return errors.New("consumer unavailable")
+	}
+	return fc.MetricsSink.ConsumeMetrics(ctx, md)
+}
+
+func TestReceiverWithPushRetry(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	consumer := &flakyConsumer{pushes: atomic.NewInt32(0)}
+
+	// The initial snapshot is the only push without changes.
+	r := setupReceiverWithDynamicClient(client, nil, consumer, 10*time.Second,
+		func(config *Config) {
+			config.PushMode = pushModeOnChange
+			config.PushRetry = PushRetryConfig{QueueSize: 1, InitialBackoff: 10 * time.Millisecond, MaxBackoff: time.Second}
+		})
+
+	createNodes(t, client, 1)
+
+	ctx := context.Background()
+	require.NoError(t, r.Start(ctx, componenttest.NewNopHost()))
+
+	// The failed snapshot is pushed again once.
+	require.Eventually(t, func() bool {
+		return len(consumer.AllMetrics()) == 1
+	}, 10*time.Second, 10*time.Millisecond, "failed push not retried")
+	require.Never(t, func() bool {
+		return consumer.pushes.Load() > 2
+	}, 100*time.Millisecond, 10*time.Millisecond, "accepted push retried")
+
+	require.NoError(t, r.Shutdown(ctx))
+}
+
+func TestReceiverWithOnChangePush(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	consumer := new(consumertest.MetricsSink)
+
+	// The metrics that do not belong to a single object are not pushed
+	// within the test.
+	r := setupReceiverWithDynamicClient(client, nil, consumer, 10*time.Second,
+		func(config *Config) {
+			config.CollectionInterval = time.Hour
+			config.PushMode = pushModeOnChange
+			config.PushDebounce = 10 * time.Millisecond
+		})
+
+	numPods := 2
+	pods := createPods(t, client, numPods)
+
+	ctx := context.Background()
+	require.NoError(t, r.Start(ctx, componenttest.NewNopHost()))
+
+	// A snapshot of all metrics is pushed once the initial sync completes.
+	require.Eventually(t, func() bool {
+		return len(consumer.AllMetrics()) == 1
+	}, 10*time.Second, 10*time.Millisecond, "initial snapshot not pushed")
+	require.Equal(t, numPods*metricsPerPod+clusterMetrics+clusterPodMetrics+clusterQuotaMetrics+collectionMetrics, consumer.MetricsCount())
+
+	// Nothing is pushed for the events of the objects that are part of the
+	// snapshot.
+	require.Never(t, func() bool {
+		return len(consumer.AllMetrics()) > 1
+	}, 200*time.Millisecond, 10*time.Millisecond, "metrics pushed without changes")
+
+	updated := getUpdatedPod(pods[0]).(*corev1.Pod)
+	updated.Status.Phase = corev1.PodRunning
+	_, err := client.CoreV1().Pods(updated.Namespace).Update(ctx, updated, v1.UpdateOptions{})
+	require.NoError(t, err)
+
+	// The update triggers a single push of the metrics of the updated pod.
+	require.Eventually(t, func() bool {
+		return len(consumer.AllMetrics()) == 2
+	}, 10*time.Second, 10*time.Millisecond, "changed metrics not pushed")
+	require.Never(t, func() bool {
+		return len(consumer.AllMetrics()) > 2
+	}, 100*time.Millisecond, 10*time.Millisecond, "changed metrics pushed more than once")
+
+	pushed := consumer.AllMetrics()[1]
+	require.Equal(t, metricsPerPod, pushed.MetricCount())
+	require.Equal(t, 1, pushed.ResourceMetrics().Len())
+	uid, ok := pushed.ResourceMetrics().At(0).Resource().Attributes().Get("k8s.pod.uid")
+	require.True(t, ok)
+	require.Equal(t, string(pods[0].UID), uid.StringVal())
+
+	require.NoError(t, r.Shutdown(ctx))
+}
+
+// countMetrics returns the number of metrics with the given name pushed to
+// the consumer so far.
+func countMetrics(consumer *consumertest.MetricsSink, name string) int {
+	count := 0
+	for _, md := range consumer.AllMetrics() {
+		rms := md.ResourceMetrics()
+		for i := 0; i < rms.Len(); i++ {
+			ilms := rms.At(i).InstrumentationLibraryMetrics()
+			for j := 0; j < ilms.Len(); j++ {
+				metrics := ilms.At(j).Metrics()
+				for k := 0; k < metrics.Len(); k++ {
+					if metrics.At(k).Name() == name {
+						count++
+					}
+				}
+			}
+		}
+	}
+	return count
+}
+
+func TestReceiverWithOnChangePushAndDeletionMarkers(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	consumer := new(consumertest.MetricsSink)
+
+	r := setupReceiverWithDynamicClient(client, nil, consumer, 10*time.Second,
+		func(config *Config) {
+			config.CollectionInterval = 10 * time.Millisecond
+			config.PushMode = pushModeOnChange
+			config.PushDebounce = 10 * time.Millisecond
+			config.EmitDeletionMarkers = true
+		})
+
+	createPods(t, client, 2)
+
+	ctx := context.Background()
+	require.NoError(t, r.Start(ctx, componenttest.NewNopHost()))
+
+	// The metrics about the collection are pushed every collection interval
+	// after the initial snapshot.
+	require.Eventually(t, func() bool {
+		return countMetrics(consumer, "k8s.cluster.scrape_heartbeat") > 2
+	}, 10*time.Second, 10*time.Millisecond, "periodic metrics not pushed")
+
+	// The removal of a pod triggers a push of its deletion markers, which
+	// are pushed exactly once.
+	deletePods(t, client, 1)
+	require.Eventually(t, func() bool {
+		return countMetrics(consumer, "k8s.object.deleted") > 0
+	}, 10*time.Second, 10*time.Millisecond, "deletion markers not pushed")
+	// Markers are pushed for the pod and its container.
+	markers := countMetrics(consumer, "k8s.object.deleted")
+	require.Never(t, func() bool {
+		return countMetrics(consumer, "k8s.object.deleted") != markers
+	}, 200*time.Millisecond, 10*time.Millisecond, "deletion markers pushed more than once")
+
+	require.NoError(t, r.Shutdown(ctx))
+}
+
+func TestReceiverWithOnChangePushAndDeletionGracePeriod(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	consumer := new(consumertest.MetricsSink)
+
+	r := setupReceiverWithDynamicClient(client, nil, consumer, 10*time.Second,
+		func(config *Config) {
+			config.CollectionInterval = 10 * time.Millisecond
+			config.PushMode = pushModeOnChange
+			config.PushDebounce = 10 * time.Millisecond
+			config.DeletionGracePeriod = 200 * time.Millisecond
+			config.EmitDeletionMarkers = true
+		})
+
+	createPods(t, client, 2)
+
+	ctx := context.Background()
+	require.NoError(t, r.Start(ctx, componenttest.NewNopHost()))
+	require.Eventually(t, func() bool {
+		return len(consumer.AllMetrics()) > 0
+	}, 10*time.Second, 10*time.Millisecond, "initial snapshot not pushed")
+
+	// The deleted pod is only evicted, and its deletion markers pushed, once
+	// the grace period has elapsed, even though no object changes by then.
+	deletePods(t, client, 1)
+	require.Never(t, func() bool {
+		return countMetrics(consumer, "k8s.object.deleted") > 0
+	}, 100*time.Millisecond, 10*time.Millisecond, "deleted pod evicted within the grace period")
+	require.Eventually(t, func() bool {
+		return countMetrics(consumer, "k8s.object.deleted") > 0
+	}, 10*time.Second, 10*time.Millisecond, "deleted pod not evicted")
+
+	require.NoError(t, r.Shutdown(ctx))
+}
+
+func pushBatchesDropped(t *testing.T) float64 {
+	rows, err := view.RetrieveData("otelsvc/k8s_cluster/push_batches_dropped")
+	require.NoError(t, err)
+	if len(rows) == 0 {
+		return 0
+	}
+	return rows[0].Data.(*view.SumData).Value
+}
+
+func TestReceiverWithLeaderElection(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	createNodes(t, client, 1)
+
+	withLeaderElection := func(config *Config) {
+		config.CollectionInterval = 10 * time.Millisecond
+		config.LeaderElection = LeaderElectionConfig{
+			Enabled:        true,
+			LeaseName:      "k8s-cluster-receiver",
+			LeaseNamespace: "default",
+			LeaseDuration:  time.Second,
+			RenewDeadline:  500 * time.Millisecond,
+			RetryPeriod:    10 * time.Millisecond,
+		}
+	}
+	consumers := []*consumertest.MetricsSink{new(consumertest.MetricsSink), new(consumertest.MetricsSink)}
+	receivers := make([]*kubernetesReceiver, len(consumers))
+	ctx := context.Background()
+	for i, consumer := range consumers {
+		receivers[i] = setupReceiverWithDynamicClient(client, nil, consumer, 10*time.Second, withLeaderElection)
+		require.NoError(t, receivers[i].Start(ctx, componenttest.NewNopHost()))
+	}
+
+	// Only the replica holding the lease pushes metrics, the other one is
+	// ready while standing by.
+	var leader int
+	require.Eventually(t, func() bool {
+		for i, consumer := range consumers {
+			if len(consumer.AllMetrics()) > 0 {
+				leader = i
+				return true
+			}
+		}
+		return false
+	}, 10*time.Second, 10*time.Millisecond, "metrics not pushed")
+	standby := 1 - leader
+	require.Never(t, func() bool {
+		return len(consumers[standby].AllMetrics()) > 0
+	}, 200*time.Millisecond, 10*time.Millisecond, "metrics pushed by the replica standing by")
+	require.True(t, receivers[standby].isReady())
+
+	// The replica standing by takes over once the leader is gone.
+	require.NoError(t, receivers[leader].Shutdown(ctx))
+	require.Eventually(t, func() bool {
+		return len(consumers[standby].AllMetrics()) > 0
+	}, 10*time.Second, 10*time.Millisecond, "metrics not pushed after failover")
+
+	require.NoError(t, receivers[standby].Shutdown(ctx))
+}
+
 func TestReceiverTimesOutAfterStartup(t *testing.T) {
 	client := fake.NewSimpleClientset()
 	consumer := new(consumertest.MetricsSink)
@@ -102,7 +540,7 @@ func TestReceiverWithManyResources(t *testing.T) {
 	require.NoError(t, r.Start(ctx, componenttest.NewNopHost()))
 
 	require.Eventually(t, func() bool {
-		return consumer.MetricsCount() == numPods
+		return consumer.MetricsCount() == numPods*metricsPerPod+clusterMetrics+clusterPodMetrics+clusterQuotaMetrics+collectionMetrics
 	}, 10*time.Second, 100*time.Millisecond,
 		"metrics not collected")
 
@@ -151,6 +589,181 @@ func TestReceiverWithMetadata(t *testing.T) {
 	r.Shutdown(ctx)
 }
 
+func TestReceiverWithCRDs(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	certificatesGVR := schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(
+		runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			crdGVR:          "CustomResourceDefinitionList",
+			certificatesGVR: "CertificateList",
+		},
+		newUnstructured("apiextensions.k8s.io/v1", "CustomResourceDefinition", "", "certificates.cert-manager.io",
+			map[string]interface{}{
+				"group": "cert-manager.io",
+				"names": map[string]interface{}{"kind": "Certificate", "plural": "certificates"},
+				"versions": []interface{}{
+					map[string]interface{}{"name": "v1", "served": true, "storage": true},
+				},
+			}),
+		newUnstructured("apiextensions.k8s.io/v1", "CustomResourceDefinition", "", "issuers.cert-manager.io",
+			map[string]interface{}{
+				"group": "cert-manager.io",
+				"names": map[string]interface{}{"kind": "Issuer", "plural": "issuers"},
+				"versions": []interface{}{
+					map[string]interface{}{"name": "v1", "served": true, "storage": true},
+				},
+			}),
+		newUnstructured("cert-manager.io/v1", "Certificate", "test", "cert-1", nil),
+		newUnstructured("cert-manager.io/v1", "Certificate", "test", "cert-2", nil),
+	)
+	consumer := new(consumertest.MetricsSink)
+
+	r := setupReceiverWithDynamicClient(client, dynamicClient, consumer, 10*time.Second,
+		func(config *Config) {
+			config.CustomResourceDefinitions = CRDConfig{
+				Enabled:        true,
+				CountInstances: []string{"certificates.cert-manager.io"},
+			}
+		})
+
+	ctx := context.Background()
+	require.NoError(t, r.Start(ctx, componenttest.NewNopHost()))
+	defer r.Shutdown(ctx)
+
+	require.Eventually(t, func() bool {
+		values := map[string]int64{}
+		for _, md := range consumer.AllMetrics() {
+			rms := md.ResourceMetrics()
+			for i := 0; i < rms.Len(); i++ {
+				ilms := rms.At(i).InstrumentationLibraryMetrics()
+				for j := 0; j < ilms.Len(); j++ {
+					ms := ilms.At(j).Metrics()
+					for k := 0; k < ms.Len(); k++ {
+						values[ms.At(k).Name()] = ms.At(k).IntGauge().DataPoints().At(0).Value()
+					}
+				}
+			}
+		}
+		return values["k8s.crd.count"] == 2 && values["k8s.crd.instance_count"] == 2
+	}, 10*time.Second, 100*time.Millisecond,
+		"crd metrics not collected")
+}
+
+func TestReceiverWithCustomResources(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	certificatesGVR := schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(
+		runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{certificatesGVR: "CertificateList"},
+		newUnstructured("cert-manager.io/v1", "Certificate", "test", "cert-1",
+			map[string]interface{}{"renewBefore": "720h", "duration": int64(2160)}),
+	)
+	consumer := new(consumertest.MetricsSink)
+
+	r := setupReceiverWithDynamicClient(client, dynamicClient, consumer, 10*time.Second,
+		func(config *Config) {
+			config.CustomResources = []CustomResourceConfig{
+				{
+					Group:    "cert-manager.io",
+					Version:  "v1",
+					Resource: "certificates",
+					Metrics: []CustomResourceMetricConfig{
+						{Name: "certmanager.certificate.duration", Unit: "h", Path: "{.spec.duration}"},
+					},
+					Attributes: map[string]string{"certmanager.certificate.renew_before": "spec.renewBefore"},
+				},
+			}
+		})
+
+	ctx := context.Background()
+	require.NoError(t, r.Start(ctx, componenttest.NewNopHost()))
+	defer r.Shutdown(ctx)
+
+	require.Eventually(t, func() bool {
+		for _, md := range consumer.AllMetrics() {
+			rms := md.ResourceMetrics()
+			for i := 0; i < rms.Len(); i++ {
+				renewBefore, ok := rms.At(i).Resource().Attributes().Get("certmanager.certificate.renew_before")
+				if !ok || renewBefore.StringVal() != "720h" {
+					continue
+				}
+				m := rms.At(i).InstrumentationLibraryMetrics().At(0).Metrics().At(0)
+				return m.Name() == "certmanager.certificate.duration" &&
+					m.DoubleGauge().DataPoints().At(0).Value() == 2160
+			}
+		}
+		return false
+	}, 10*time.Second, 100*time.Millisecond,
+		"custom resource metrics not collected")
+}
+
+func TestReceiverWithOpenShift(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	// ClusterResourceQuotas are not served, they are skipped.
+	client.Resources = []*v1.APIResourceList{
+		{
+			GroupVersion: "apps.openshift.io/v1",
+			APIResources: []v1.APIResource{{Name: "deploymentconfigs", Namespaced: true}},
+		},
+	}
+	dc := newUnstructured("apps.openshift.io/v1", "DeploymentConfig", "test", "frontend",
+		map[string]interface{}{"replicas": int64(3)})
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(
+		runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			collection.DeploymentConfigGVR:     "DeploymentConfigList",
+			collection.ClusterResourceQuotaGVR: "ClusterResourceQuotaList",
+		},
+		dc,
+	)
+	consumer := new(consumertest.MetricsSink)
+
+	r := setupReceiverWithDynamicClient(client, dynamicClient, consumer, 10*time.Second,
+		func(config *Config) {
+			config.Distribution = distributionOpenShift
+		})
+
+	ctx := context.Background()
+	require.NoError(t, r.Start(ctx, componenttest.NewNopHost()))
+	defer r.Shutdown(ctx)
+
+	require.Eventually(t, func() bool {
+		for _, md := range consumer.AllMetrics() {
+			rms := md.ResourceMetrics()
+			for i := 0; i < rms.Len(); i++ {
+				name, ok := rms.At(i).Resource().Attributes().Get("openshift.deploymentconfig.name")
+				if !ok || name.StringVal() != "frontend" {
+					continue
+				}
+				m := rms.At(i).InstrumentationLibraryMetrics().At(0).Metrics().At(0)
+				return m.Name() == "openshift.deploymentconfig.desired" &&
+					m.IntGauge().DataPoints().At(0).Value() == 3
+			}
+		}
+		return false
+	}, 10*time.Second, 100*time.Millisecond,
+		"deploymentconfig metrics not collected")
+}
+
+func newUnstructured(apiVersion, kind, namespace, name string, spec map[string]interface{}) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata": map[string]interface{}{
+				"namespace": namespace,
+				"name":      name,
+				"uid":       name + "-uid",
+			},
+		},
+	}
+	if spec != nil {
+		u.Object["spec"] = spec
+	}
+	return u
+}
+
 func getUpdatedPod(pod *corev1.Pod) interface{} {
 	return &corev1.Pod{
 		ObjectMeta: v1.ObjectMeta{
@@ -168,14 +781,26 @@ func setupReceiver(
 	client *fake.Clientset,
 	consumer consumer.MetricsConsumer,
 	initialSyncTimeout time.Duration) *kubernetesReceiver {
+	return setupReceiverWithDynamicClient(client, nil, consumer, initialSyncTimeout)
+}
+
+func setupReceiverWithDynamicClient(
+	client *fake.Clientset,
+	dynamicClient dynamic.Interface,
+	consumer consumer.MetricsConsumer,
+	initialSyncTimeout time.Duration,
+	configOverrides ...func(*Config)) *kubernetesReceiver {
 
 	logger := zap.NewNop()
 	config := &Config{
 		CollectionInterval:         1 * time.Second,
 		NodeConditionTypesToReport: []string{"Ready"},
 	}
+	for _, override := range configOverrides {
+		override(config)
+	}
 
-	rw := newResourceWatcher(logger, client, config.NodeConditionTypesToReport, initialSyncTimeout)
+	rw := newResourceWatcher(logger, client, dynamicClient, config, initialSyncTimeout)
 	rw.dataCollector.SetupMetadataStore(&corev1.Service{}, &testutils.MockStore{})
 
 	return &kubernetesReceiver{
@@ -183,5 +808,7 @@ func setupReceiver(
 		logger:          logger,
 		config:          config,
 		consumer:        consumer,
+		paused:          atomic.NewBool(false),
+		leading:         atomic.NewBool(false),
 	}
 }
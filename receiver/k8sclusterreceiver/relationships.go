@@ -0,0 +1,320 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclusterreceiver
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/consumer/pdata"
+	"go.opentelemetry.io/collector/obsreport"
+	"go.opentelemetry.io/collector/translator/conventions"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// Name of the log records of relationships.
+	relationshipLogName = "k8s.relationship"
+
+	// Keys of the attributes of log records of relationships.
+	k8sKeyRelationshipType       = "k8s.relationship.type"
+	k8sKeyRelationshipSourceKind = "k8s.relationship.source.kind"
+	k8sKeyRelationshipSourceName = "k8s.relationship.source.name"
+	k8sKeyRelationshipSourceUID  = "k8s.relationship.source.uid"
+	k8sKeyRelationshipTargetKind = "k8s.relationship.target.kind"
+	k8sKeyRelationshipTargetName = "k8s.relationship.target.name"
+	k8sKeyRelationshipTargetUID  = "k8s.relationship.target.uid"
+
+	// Types of relationships.
+	relationshipOwnedBy     = "owned_by"
+	relationshipScheduledOn = "scheduled_on"
+	relationshipSelects     = "selects"
+)
+
+// relationship is a directed edge between two objects. Targets are in the
+// namespace of their source, except for nodes.
+type relationship struct {
+	typ        string
+	namespace  string
+	sourceKind string
+	sourceName string
+	sourceUID  types.UID
+	targetKind string
+	targetName string
+	targetUID  types.UID
+}
+
+// relationshipStores are the informer caches relationships are built from,
+// one per watched namespace.
+type relationshipStores struct {
+	pods        []cache.Store
+	replicaSets []cache.Store
+	jobs        []cache.Store
+	services    []cache.Store
+	endpoints   []cache.Store
+}
+
+// relationshipsReporter periodically pushes the relationships between the
+// objects of the watched namespaces as log records: of objects with their
+// owners, of pods with the node they are scheduled on and of services with the
+// pods their endpoints target.
+type relationshipsReporter struct {
+	config   *Config
+	logger   *zap.Logger
+	consumer consumer.LogsConsumer
+	stores   relationshipStores
+}
+
+func newRelationshipsReporter(logger *zap.Logger, config *Config, consumer consumer.LogsConsumer) *relationshipsReporter {
+	return &relationshipsReporter{
+		config:   config,
+		logger:   logger,
+		consumer: consumer,
+	}
+}
+
+// start watches the objects of the given namespaces and pushes their
+// relationships every relationships.interval once the informer caches are
+// synced, until ctx is done.
+func (rr *relationshipsReporter) start(ctx context.Context, client kubernetes.Interface, namespaces []string) {
+	var synced []cache.InformerSynced
+	for _, namespace := range namespaces {
+		factory := informers.NewSharedInformerFactoryWithOptions(client, 0, informers.WithNamespace(namespace))
+		for _, inf := range []struct {
+			informer cache.SharedIndexInformer
+			stores   *[]cache.Store
+		}{
+			{factory.Core().V1().Pods().Informer(), &rr.stores.pods},
+			{factory.Apps().V1().ReplicaSets().Informer(), &rr.stores.replicaSets},
+			{factory.Batch().V1().Jobs().Informer(), &rr.stores.jobs},
+			{factory.Core().V1().Services().Informer(), &rr.stores.services},
+			{factory.Core().V1().Endpoints().Informer(), &rr.stores.endpoints},
+		} {
+			*inf.stores = append(*inf.stores, inf.informer.GetStore())
+			synced = append(synced, inf.informer.HasSynced)
+		}
+		factory.Start(ctx.Done())
+	}
+
+	go func() {
+		if !cache.WaitForCacheSync(ctx.Done(), synced...) {
+			return
+		}
+		ticker := time.NewTicker(rr.config.Relationships.Interval)
+		defer ticker.Stop()
+		for {
+			rr.push(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// push pushes the current relationships of the watched objects.
+func (rr *relationshipsReporter) push(ctx context.Context) {
+	rels := rr.relationships()
+	if len(rels) == 0 {
+		return
+	}
+
+	ld := rr.relationshipsToLogs(rels, time.Now())
+	c := obsreport.StartLogsReceiveOp(ctx, typeStr, transport)
+	err := rr.consumer.ConsumeLogs(c, ld)
+	obsreport.EndLogsReceiveOp(c, typeStr, ld.LogRecordCount(), err)
+	if err != nil {
+		rr.logger.Debug("Pushing relationships failed.", zap.Error(err))
+	}
+}
+
+// relationships returns the relationships of the objects in the informer
+// caches, sorted so that consecutive pushes list them in the same order.
+func (rr *relationshipsReporter) relationships() []relationship {
+	var rels []relationship
+	for _, stores := range [][]cache.Store{rr.stores.pods, rr.stores.replicaSets, rr.stores.jobs} {
+		for _, store := range stores {
+			for _, obj := range store.List() {
+				if o, ok := obj.(v1.Object); ok {
+					rels = append(rels, ownerRelationships(o)...)
+				}
+			}
+		}
+	}
+	for _, store := range rr.stores.pods {
+		for _, obj := range store.List() {
+			if pod, ok := obj.(*corev1.Pod); ok && pod.Spec.NodeName != "" {
+				rels = append(rels, relationship{
+					typ:        relationshipScheduledOn,
+					namespace:  pod.Namespace,
+					sourceKind: "Pod",
+					sourceName: pod.Name,
+					sourceUID:  pod.UID,
+					targetKind: "Node",
+					targetName: pod.Spec.NodeName,
+				})
+			}
+		}
+	}
+	for _, store := range rr.stores.endpoints {
+		for _, obj := range store.List() {
+			if ep, ok := obj.(*corev1.Endpoints); ok {
+				rels = append(rels, rr.serviceRelationships(ep)...)
+			}
+		}
+	}
+
+	sort.Slice(rels, func(i, j int) bool {
+		a, b := rels[i], rels[j]
+		if a.namespace != b.namespace {
+			return a.namespace < b.namespace
+		}
+		if a.sourceKind != b.sourceKind {
+			return a.sourceKind < b.sourceKind
+		}
+		if a.sourceName != b.sourceName {
+			return a.sourceName < b.sourceName
+		}
+		if a.typ != b.typ {
+			return a.typ < b.typ
+		}
+		if a.targetKind != b.targetKind {
+			return a.targetKind < b.targetKind
+		}
+		return a.targetName < b.targetName
+	})
+	return rels
+}
+
+// ownerRelationships returns the relationships of an object with its owners,
+// e.g. of a pod with its replica set and of the latter with its deployment.
+func ownerRelationships(o v1.Object) []relationship {
+	refs := o.GetOwnerReferences()
+	if len(refs) == 0 {
+		return nil
+	}
+	kind := ownedObjectKind(o)
+	rels := make([]relationship, 0, len(refs))
+	for _, ref := range refs {
+		rels = append(rels, relationship{
+			typ:        relationshipOwnedBy,
+			namespace:  o.GetNamespace(),
+			sourceKind: kind,
+			sourceName: o.GetName(),
+			sourceUID:  o.GetUID(),
+			targetKind: ref.Kind,
+			targetName: ref.Name,
+			targetUID:  ref.UID,
+		})
+	}
+	return rels
+}
+
+// serviceRelationships returns the relationships of the service of the
+// endpoints with the pods they target, ready or not.
+func (rr *relationshipsReporter) serviceRelationships(ep *corev1.Endpoints) []relationship {
+	var serviceUID types.UID
+	key := ep.Namespace + "/" + ep.Name
+	for _, store := range rr.stores.services {
+		if obj, exists, err := store.GetByKey(key); err == nil && exists {
+			if svc, ok := obj.(*corev1.Service); ok {
+				serviceUID = svc.UID
+			}
+			break
+		}
+	}
+
+	var rels []relationship
+	seen := map[types.UID]bool{}
+	for _, subset := range ep.Subsets {
+		for _, addresses := range [][]corev1.EndpointAddress{subset.Addresses, subset.NotReadyAddresses} {
+			for _, address := range addresses {
+				ref := address.TargetRef
+				if ref == nil || ref.Kind != "Pod" || seen[ref.UID] {
+					continue
+				}
+				seen[ref.UID] = true
+				rels = append(rels, relationship{
+					typ:        relationshipSelects,
+					namespace:  ep.Namespace,
+					sourceKind: "Service",
+					sourceName: ep.Name,
+					sourceUID:  serviceUID,
+					targetKind: ref.Kind,
+					targetName: ref.Name,
+					targetUID:  ref.UID,
+				})
+			}
+		}
+	}
+	return rels
+}
+
+// ownedObjectKind returns the kind of an object whose owners are reported,
+// since the type meta of objects from informer caches is not set.
+func ownedObjectKind(o v1.Object) string {
+	switch o.(type) {
+	case *corev1.Pod:
+		return "Pod"
+	case *appsv1.ReplicaSet:
+		return "ReplicaSet"
+	case *batchv1.Job:
+		return "Job"
+	}
+	return ""
+}
+
+// relationshipsToLogs converts relationships to log records observed at the
+// given time, whose resource is the cluster.
+func (rr *relationshipsReporter) relationshipsToLogs(rels []relationship, now time.Time) pdata.Logs {
+	ld := pdata.NewLogs()
+	ld.ResourceLogs().Resize(1)
+	rl := ld.ResourceLogs().At(0)
+	if rr.config.ClusterName != "" {
+		rl.Resource().Attributes().InsertString(conventions.AttributeK8sCluster, rr.config.ClusterName)
+	}
+
+	rl.InstrumentationLibraryLogs().Resize(1)
+	logs := rl.InstrumentationLibraryLogs().At(0).Logs()
+	logs.Resize(len(rels))
+	timestamp := pdata.TimestampUnixNano(now.UnixNano())
+	for i, rel := range rels {
+		lr := logs.At(i)
+		lr.SetTimestamp(timestamp)
+		lr.SetName(relationshipLogName)
+
+		attrs := lr.Attributes()
+		attrs.InsertString(k8sKeyRelationshipType, rel.typ)
+		insertStringIfSet(attrs, conventions.AttributeK8sNamespace, rel.namespace)
+		attrs.InsertString(k8sKeyRelationshipSourceKind, rel.sourceKind)
+		attrs.InsertString(k8sKeyRelationshipSourceName, rel.sourceName)
+		insertStringIfSet(attrs, k8sKeyRelationshipSourceUID, string(rel.sourceUID))
+		attrs.InsertString(k8sKeyRelationshipTargetKind, rel.targetKind)
+		attrs.InsertString(k8sKeyRelationshipTargetName, rel.targetName)
+		insertStringIfSet(attrs, k8sKeyRelationshipTargetUID, string(rel.targetUID))
+	}
+	return ld
+}
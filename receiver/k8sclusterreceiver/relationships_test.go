@@ -0,0 +1,173 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclusterreceiver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestRelationships(t *testing.T) {
+	newStore := func(objs ...interface{}) []cache.Store {
+		store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+		for _, obj := range objs {
+			require.NoError(t, store.Add(obj))
+		}
+		return []cache.Store{store}
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "web-abc",
+			Namespace: "test-namespace",
+			UID:       "web-abc-uid",
+			OwnerReferences: []v1.OwnerReference{
+				{Kind: "ReplicaSet", Name: "web-5d4f", UID: "web-5d4f-uid"},
+			},
+		},
+		Spec: corev1.PodSpec{NodeName: "test-node"},
+	}
+	pendingPod := &corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{Name: "pending", Namespace: "test-namespace", UID: "pending-uid"},
+	}
+	rs := &appsv1.ReplicaSet{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "web-5d4f",
+			Namespace: "test-namespace",
+			UID:       "web-5d4f-uid",
+			OwnerReferences: []v1.OwnerReference{
+				{Kind: "Deployment", Name: "web", UID: "web-uid"},
+			},
+		},
+	}
+	svc := &corev1.Service{
+		ObjectMeta: v1.ObjectMeta{Name: "web", Namespace: "test-namespace", UID: "web-svc-uid"},
+	}
+	podRef := &corev1.ObjectReference{Kind: "Pod", Name: "web-abc", UID: "web-abc-uid"}
+	ep := &corev1.Endpoints{
+		ObjectMeta: v1.ObjectMeta{Name: "web", Namespace: "test-namespace"},
+		Subsets: []corev1.EndpointSubset{
+			{
+				Addresses:         []corev1.EndpointAddress{{IP: "10.0.0.1", TargetRef: podRef}},
+				NotReadyAddresses: []corev1.EndpointAddress{{IP: "10.0.0.2"}},
+			},
+			// Pods listed in several subsets, e.g. for each of their ports,
+			// are only reported once.
+			{Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1", TargetRef: podRef}}},
+		},
+	}
+
+	config := createDefaultConfig().(*Config)
+	config.ClusterName = "test-cluster"
+	consumer := new(consumertest.LogsSink)
+	rr := newRelationshipsReporter(zap.NewNop(), config, consumer)
+	rr.stores = relationshipStores{
+		pods:        newStore(pod, pendingPod),
+		replicaSets: newStore(rs),
+		jobs:        newStore(),
+		services:    newStore(svc),
+		endpoints:   newStore(ep),
+	}
+	rr.push(context.Background())
+
+	require.Len(t, consumer.AllLogs(), 1)
+	rl := consumer.AllLogs()[0].ResourceLogs().At(0)
+	require.Equal(t, map[string]string{"k8s.cluster.name": "test-cluster"},
+		attributesToMap(rl.Resource().Attributes()))
+
+	logs := rl.InstrumentationLibraryLogs().At(0).Logs()
+	var records []map[string]string
+	for i := 0; i < logs.Len(); i++ {
+		require.Equal(t, "k8s.relationship", logs.At(i).Name())
+		records = append(records, attributesToMap(logs.At(i).Attributes()))
+	}
+	require.Equal(t, []map[string]string{
+		{
+			"k8s.relationship.type":        "owned_by",
+			"k8s.namespace.name":           "test-namespace",
+			"k8s.relationship.source.kind": "Pod",
+			"k8s.relationship.source.name": "web-abc",
+			"k8s.relationship.source.uid":  "web-abc-uid",
+			"k8s.relationship.target.kind": "ReplicaSet",
+			"k8s.relationship.target.name": "web-5d4f",
+			"k8s.relationship.target.uid":  "web-5d4f-uid",
+		},
+		{
+			"k8s.relationship.type":        "scheduled_on",
+			"k8s.namespace.name":           "test-namespace",
+			"k8s.relationship.source.kind": "Pod",
+			"k8s.relationship.source.name": "web-abc",
+			"k8s.relationship.source.uid":  "web-abc-uid",
+			"k8s.relationship.target.kind": "Node",
+			"k8s.relationship.target.name": "test-node",
+		},
+		{
+			"k8s.relationship.type":        "owned_by",
+			"k8s.namespace.name":           "test-namespace",
+			"k8s.relationship.source.kind": "ReplicaSet",
+			"k8s.relationship.source.name": "web-5d4f",
+			"k8s.relationship.source.uid":  "web-5d4f-uid",
+			"k8s.relationship.target.kind": "Deployment",
+			"k8s.relationship.target.name": "web",
+			"k8s.relationship.target.uid":  "web-uid",
+		},
+		{
+			"k8s.relationship.type":        "selects",
+			"k8s.namespace.name":           "test-namespace",
+			"k8s.relationship.source.kind": "Service",
+			"k8s.relationship.source.name": "web",
+			"k8s.relationship.source.uid":  "web-svc-uid",
+			"k8s.relationship.target.kind": "Pod",
+			"k8s.relationship.target.name": "web-abc",
+			"k8s.relationship.target.uid":  "web-abc-uid",
+		},
+	}, records)
+}
+
+func TestEventsReceiverRelationships(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	config := createDefaultConfig().(*Config)
+	config.Relationships = RelationshipsConfig{Enabled: true, Interval: 10 * time.Millisecond}
+	consumer := new(consumertest.LogsSink)
+	r, err := newEventsReceiver(zap.NewNop(), config, consumer, client)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, r.Start(ctx, componenttest.NewNopHost()))
+	defer func() { require.NoError(t, r.Shutdown(ctx)) }()
+
+	_, err = client.CoreV1().Pods("test-namespace").Create(context.Background(), &corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{Name: "test-pod", Namespace: "test-namespace", UID: types.UID("test-pod-uid")},
+		Spec:       corev1.PodSpec{NodeName: "test-node"},
+	}, v1.CreateOptions{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return consumer.LogRecordsCount() > 0
+	}, 10*time.Second, 10*time.Millisecond, "relationships not pushed")
+
+	attrs := consumer.AllLogs()[0].ResourceLogs().At(0).InstrumentationLibraryLogs().At(0).Logs().At(0).Attributes()
+	require.Equal(t, "scheduled_on", attributesToMap(attrs)["k8s.relationship.type"])
+}
@@ -0,0 +1,80 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclusterreceiver
+
+import (
+	"sync"
+	"time"
+)
+
+// relistBackoff delays the next attempt of a reflector to list and watch
+// objects after it failed to. The reflectors of shared informers back off
+// between attempts with fixed settings, so the delay is added on top of
+// theirs from the watch error handler, which reflectors call before backing
+// off. The delay doubles on consecutive failures, from min up to max, and is
+// reset once no failure occurred for twice max.
+type relistBackoff struct {
+	min, max time.Duration
+	stopCh   <-chan struct{}
+	now      func() time.Time
+	// wait waits for d or until stopCh is closed.
+	wait func(d time.Duration, stopCh <-chan struct{})
+
+	mu        sync.Mutex
+	delay     time.Duration
+	lastError time.Time
+}
+
+func newRelistBackoff(min, max time.Duration, stopCh <-chan struct{}) *relistBackoff {
+	return &relistBackoff{
+		min:    min,
+		max:    max,
+		stopCh: stopCh,
+		now:    time.Now,
+		wait: func(d time.Duration, stopCh <-chan struct{}) {
+			t := time.NewTimer(d)
+			defer t.Stop()
+			select {
+			case <-t.C:
+			case <-stopCh:
+			}
+		},
+	}
+}
+
+// backOff waits for the delay following a failure, as called from the watch
+// error handler of the informer.
+func (b *relistBackoff) backOff() {
+	b.wait(b.next(), b.stopCh)
+}
+
+// next returns the delay to back off for following a failure.
+func (b *relistBackoff) next() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	switch {
+	case b.delay == 0 || now.Sub(b.lastError) > 2*b.max:
+		b.delay = b.min
+	case b.delay < b.max:
+		b.delay *= 2
+	}
+	if b.delay > b.max {
+		b.delay = b.max
+	}
+	b.lastError = now
+	return b.delay
+}
@@ -0,0 +1,82 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclusterreceiver
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestRelistBackoffNext(t *testing.T) {
+	now := time.Now()
+	b := newRelistBackoff(time.Second, 5*time.Second, nil)
+	b.now = func() time.Time { return now }
+
+	var delays []time.Duration
+	for i := 0; i < 5; i++ {
+		delays = append(delays, b.next())
+		now = now.Add(time.Second)
+	}
+	require.Equal(t, []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second,
+	}, delays)
+
+	// The backoff is reset once failures stop for twice the maximum.
+	now = now.Add(11 * time.Second)
+	require.Equal(t, time.Second, b.next())
+}
+
+func TestRelistBackoffAppliedToReflector(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("list", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("apiserver unavailable")
+	})
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	rw := newResourceWatcher(zap.NewNop(), client, nil, &Config{
+		RelistBackoff: RelistBackoffConfig{Min: 10 * time.Second, Max: time.Minute},
+	}, time.Minute)
+	rw.stopCh = stopCh
+
+	// Waits are recorded rather than waited for.
+	delays := make(chan time.Duration, 10)
+	informer := informers.NewSharedInformerFactory(client, 0).Core().V1().Pods().Informer()
+	b := rw.setupWatchErrorHandler(informer, "Pod")
+	require.NotNil(t, b)
+	b.wait = func(d time.Duration, _ <-chan struct{}) { delays <- d }
+
+	go informer.Run(stopCh)
+	for _, want := range []time.Duration{10 * time.Second, 20 * time.Second} {
+		select {
+		case d := <-delays:
+			require.Equal(t, want, d)
+		case <-time.After(10 * time.Second):
+			t.Fatal("reflector did not back off")
+		}
+	}
+
+	// Informers are left as is without a relist backoff.
+	rw.config.RelistBackoff = RelistBackoffConfig{}
+	require.Nil(t, rw.setupWatchErrorHandler(informers.NewSharedInformerFactory(client, 0).Core().V1().Nodes().Informer(), "Node"))
+}
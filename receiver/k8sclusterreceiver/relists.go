@@ -0,0 +1,107 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclusterreceiver
+
+import (
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/informers/admissionregistration"
+	"k8s.io/client-go/informers/apps"
+	"k8s.io/client-go/informers/autoscaling"
+	"k8s.io/client-go/informers/batch"
+	"k8s.io/client-go/informers/core"
+	"k8s.io/client-go/informers/discovery"
+	"k8s.io/client-go/informers/internalinterfaces"
+	"k8s.io/client-go/informers/networking"
+	"k8s.io/client-go/informers/policy"
+	"k8s.io/client-go/informers/rbac"
+	"k8s.io/client-go/informers/scheduling"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/observability"
+)
+
+// listOptionsTweak returns the function tweaking the list and watch options of
+// the informer of objects of the given kind, which records the relists of the
+// informer besides applying tweakListOptions.
+//
+// Reflectors only hand some of the errors ending their watch to the watch
+// error handler, and retry lists from an expired resource version on their
+// own, so relists are recorded when they happen instead: reflectors list
+// from the resource version of their last sync when listing again, e.g. after
+// their watch ended because that resource version was too old, whereas
+// initial lists are from resource version "0" and lists retried after the
+// resource version expired are from "". Pages following the first one of a
+// list are not counted either.
+func (rw *resourceWatcher) listOptionsTweak(kind string) func(options *v1.ListOptions) {
+	return func(options *v1.ListOptions) {
+		// Watches of informers allow bookmarks, lists do not.
+		if !options.AllowWatchBookmarks && options.Continue == "" &&
+			options.ResourceVersion != "" && options.ResourceVersion != "0" {
+			rw.dataCollector.RecordInformerRelist(kind)
+			observability.RecordInformerRelist(kind)
+		}
+		rw.tweakListOptions(options)
+	}
+}
+
+// kindInformerFactory is a view of a shared informer factory whose informers
+// tweak their list and watch options with a function of their own, so that
+// the relists of each kind can be told apart. Informers are still shared with
+// the factory, which starts them.
+type kindInformerFactory struct {
+	informers.SharedInformerFactory
+	namespace string
+	tweak     internalinterfaces.TweakListOptionsFunc
+}
+
+func (f *kindInformerFactory) Admissionregistration() admissionregistration.Interface {
+	return admissionregistration.New(f, f.namespace, f.tweak)
+}
+
+func (f *kindInformerFactory) Apps() apps.Interface {
+	return apps.New(f, f.namespace, f.tweak)
+}
+
+func (f *kindInformerFactory) Autoscaling() autoscaling.Interface {
+	return autoscaling.New(f, f.namespace, f.tweak)
+}
+
+func (f *kindInformerFactory) Batch() batch.Interface {
+	return batch.New(f, f.namespace, f.tweak)
+}
+
+func (f *kindInformerFactory) Core() core.Interface {
+	return core.New(f, f.namespace, f.tweak)
+}
+
+func (f *kindInformerFactory) Discovery() discovery.Interface {
+	return discovery.New(f, f.namespace, f.tweak)
+}
+
+func (f *kindInformerFactory) Networking() networking.Interface {
+	return networking.New(f, f.namespace, f.tweak)
+}
+
+func (f *kindInformerFactory) Policy() policy.Interface {
+	return policy.New(f, f.namespace, f.tweak)
+}
+
+func (f *kindInformerFactory) Rbac() rbac.Interface {
+	return rbac.New(f, f.namespace, f.tweak)
+}
+
+func (f *kindInformerFactory) Scheduling() scheduling.Interface {
+	return scheduling.New(f, f.namespace, f.tweak)
+}
@@ -0,0 +1,113 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclusterreceiver
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestInformerRelistsRecorded(t *testing.T) {
+	tests := []struct {
+		name string
+		// firstWatch returns the first watch of pods, or the error it fails
+		// with.
+		firstWatch func() (watch.Interface, error)
+	}{
+		{
+			name: "watch failing",
+			firstWatch: func() (watch.Interface, error) {
+				return nil, apierrors.NewResourceExpired("too old resource version: 1 (2)")
+			},
+		},
+		{
+			// Reflectors end such watches without calling the watch error
+			// handler.
+			name: "watch ended by an error event",
+			firstWatch: func() (watch.Interface, error) {
+				w := watch.NewFake()
+				go w.Error(&v1.Status{
+					Status:  v1.StatusFailure,
+					Code:    http.StatusGone,
+					Reason:  v1.StatusReasonExpired,
+					Message: "too old resource version: 1 (2)",
+				})
+				return w, nil
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			lists := atomic.NewInt32(0)
+			client.PrependReactor("list", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+				lists.Inc()
+				return true, &corev1.PodList{ListMeta: v1.ListMeta{ResourceVersion: "1"}}, nil
+			})
+			watches := atomic.NewInt32(0)
+			client.PrependWatchReactor("pods", func(k8stesting.Action) (bool, watch.Interface, error) {
+				if watches.Inc() == 1 {
+					w, err := tt.firstWatch()
+					return true, w, err
+				}
+				return false, nil, nil
+			})
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+			rw := newResourceWatcher(zap.NewNop(), client, nil, &Config{}, time.Minute)
+			rw.stopCh = stopCh
+
+			f := &kindInformerFactory{
+				SharedInformerFactory: informers.NewSharedInformerFactory(client, 0),
+				tweak:                 rw.listOptionsTweak("Pod"),
+			}
+			informer := f.Core().V1().Pods().Informer()
+			rw.setupWatchErrorHandler(informer, "Pod")
+			go informer.Run(stopCh)
+
+			relists := func() int64 {
+				for _, md := range rw.dataCollector.CollectMetricData(time.Now()) {
+					for _, m := range md.Metrics {
+						if m.MetricDescriptor.Name == "k8s.cluster.informer_relists" {
+							require.Equal(t, "Pod", m.Timeseries[0].LabelValues[0].Value)
+							return m.Timeseries[0].Points[0].GetInt64Value()
+						}
+					}
+				}
+				return 0
+			}
+			require.Eventually(t, func() bool {
+				return watches.Load() > 1
+			}, 10*time.Second, 10*time.Millisecond, "informer did not watch again")
+			// The initial list is not a relist.
+			require.EqualValues(t, 2, lists.Load())
+			require.EqualValues(t, 1, relists())
+		})
+	}
+}
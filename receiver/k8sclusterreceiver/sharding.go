@@ -0,0 +1,55 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclusterreceiver
+
+import (
+	"context"
+	"hash/fnv"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// shardNamespaces returns the namespaces watched by the replica set by
+// sharding, among the given namespaces or, if none, all the namespaces of the
+// cluster. The result is never nil, so that a replica whose shard is empty
+// watches no namespace.
+func shardNamespaces(ctx context.Context, client kubernetes.Interface, sharding ShardingConfig, namespaces []string) ([]string, error) {
+	if len(namespaces) == 0 {
+		list, err := client.CoreV1().Namespaces().List(ctx, v1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range list.Items {
+			namespaces = append(namespaces, ns.Name)
+		}
+	}
+
+	out := []string{}
+	for _, namespace := range namespaces {
+		if namespaceShard(namespace, sharding.Count) == sharding.Index {
+			out = append(out, namespace)
+		}
+	}
+	return out, nil
+}
+
+// namespaceShard returns the index of the replica, among count, watching the
+// namespace.
+func namespaceShard(namespace string, count int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(namespace))
+	return int(h.Sum32() % uint32(count))
+}
@@ -0,0 +1,81 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclusterreceiver
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestShardNamespaces(t *testing.T) {
+	var objects []runtime.Object
+	var all []string
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("namespace-%d", i)
+		objects = append(objects, &corev1.Namespace{ObjectMeta: v1.ObjectMeta{Name: name}})
+		all = append(all, name)
+	}
+	client := fake.NewSimpleClientset(objects...)
+
+	// Every namespace is watched by exactly one replica.
+	var watched []string
+	for i := 0; i < 3; i++ {
+		namespaces, err := shardNamespaces(context.Background(), client, ShardingConfig{Count: 3, Index: i}, nil)
+		require.NoError(t, err)
+		require.NotEmpty(t, namespaces)
+		watched = append(watched, namespaces...)
+	}
+	sort.Strings(watched)
+	sort.Strings(all)
+	require.Equal(t, all, watched)
+
+	// The configured namespaces are sharded without being listed.
+	namespaces, err := shardNamespaces(context.Background(), fake.NewSimpleClientset(),
+		ShardingConfig{Count: 1, Index: 0}, []string{"a", "b"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, namespaces)
+
+	// A replica whose shard is empty watches no namespace.
+	namespaces, err = shardNamespaces(context.Background(), fake.NewSimpleClientset(),
+		ShardingConfig{Count: 2, Index: 1 - namespaceShard("a", 2)}, []string{"a"})
+	require.NoError(t, err)
+	require.NotNil(t, namespaces)
+	require.Empty(t, namespaces)
+}
+
+func TestTweakListOptions(t *testing.T) {
+	rw := &resourceWatcher{config: &Config{ListPageSize: 500}}
+
+	list := v1.ListOptions{ResourceVersion: "0"}
+	rw.tweakListOptions(&list)
+	require.Equal(t, v1.ListOptions{Limit: 500}, list)
+
+	watch := v1.ListOptions{ResourceVersion: "10", AllowWatchBookmarks: true}
+	rw.tweakListOptions(&watch)
+	require.Equal(t, v1.ListOptions{ResourceVersion: "10", AllowWatchBookmarks: true}, watch)
+
+	rw.config.ListPageSize = 0
+	list = v1.ListOptions{ResourceVersion: "0"}
+	rw.tweakListOptions(&list)
+	require.Equal(t, v1.ListOptions{ResourceVersion: "0"}, list)
+}
@@ -0,0 +1,87 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8sclusterreceiver
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// Values of duplicate_objects.
+	duplicateObjectsFirstSource = "first_source"
+	duplicateObjectsAllSources  = "all_sources"
+)
+
+// sourceTracker records the informer each object was first received from, so
+// that events of an object received from more than one informer, e.g. from
+// overlapping informers, are only processed for one of them. Informers are
+// identified by the order in which they were set up.
+type sourceTracker struct {
+	sync.Mutex
+	// sources is the informer each object is received from, keyed by UID.
+	sources map[types.UID]*objectSource
+}
+
+type objectSource struct {
+	informer int
+	// duplicate is set once the object has been received from another
+	// informer.
+	duplicate bool
+}
+
+func newSourceTracker() *sourceTracker {
+	return &sourceTracker{sources: map[types.UID]*objectSource{}}
+}
+
+// claim returns whether events of the object with the given UID received from
+// the given informer are to be processed, i.e. whether the object was first
+// received from that informer. duplicate is only true the first time the
+// object is received from another informer, so that it is reported once.
+func (t *sourceTracker) claim(uid types.UID, informer int) (ok bool, duplicate bool) {
+	t.Lock()
+	defer t.Unlock()
+
+	s, seen := t.sources[uid]
+	if !seen {
+		t.sources[uid] = &objectSource{informer: informer}
+		return true, false
+	}
+	if s.informer == informer {
+		return true, false
+	}
+	duplicate = !s.duplicate
+	s.duplicate = true
+	return false, duplicate
+}
+
+// release forgets the informer of the object with the given UID once it has
+// been deleted from it. It returns whether the deletion is to be processed,
+// which is only the case if the object was received from that informer.
+func (t *sourceTracker) release(uid types.UID, informer int) bool {
+	t.Lock()
+	defer t.Unlock()
+
+	s, seen := t.sources[uid]
+	if !seen {
+		return true
+	}
+	if s.informer != informer {
+		return false
+	}
+	delete(t.sources, uid)
+	return true
+}
@@ -26,3 +26,14 @@ func GetInt64TimeSeriesWithLabels(val int64, labelVals []*v1.LabelValue) *v1.Tim
 		Points:      []*v1.Point{{Value: &v1.Point_Int64Value{Int64Value: val}}},
 	}
 }
+
+func GetDoubleTimeSeries(val float64) *v1.TimeSeries {
+	return GetDoubleTimeSeriesWithLabels(val, nil)
+}
+
+func GetDoubleTimeSeriesWithLabels(val float64, labelVals []*v1.LabelValue) *v1.TimeSeries {
+	return &v1.TimeSeries{
+		LabelValues: labelVals,
+		Points:      []*v1.Point{{Value: &v1.Point_DoubleValue{DoubleValue: val}}},
+	}
+}
@@ -37,3 +37,20 @@ func TestGetInt64TimeSeriesWithLabels(t *testing.T) {
 	require.Equal(t, dpVal, ts.Points[0].GetInt64Value())
 	require.Equal(t, labelVals, ts.LabelValues)
 }
+
+func TestGetDoubleTimeSeries(t *testing.T) {
+	dpVal := 10.5
+	ts := GetDoubleTimeSeries(dpVal)
+
+	require.Equal(t, dpVal, ts.Points[0].GetDoubleValue())
+}
+
+func TestGetDoubleTimeSeriesWithLabels(t *testing.T) {
+	dpVal := 10.5
+	labelVals := []*v1.LabelValue{{Value: "value1"}, {Value: "value2"}}
+
+	ts := GetDoubleTimeSeriesWithLabels(dpVal, labelVals)
+
+	require.Equal(t, dpVal, ts.Points[0].GetDoubleValue())
+	require.Equal(t, labelVals, ts.LabelValues)
+}
@@ -17,91 +17,594 @@ package k8sclusterreceiver
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"strings"
 	"time"
 
 	"go.opentelemetry.io/collector/component"
-	"go.opentelemetry.io/collector/config/configmodels"
 	"go.uber.org/atomic"
 	"go.uber.org/zap"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	"k8s.io/api/autoscaling/v2beta1"
+	"k8s.io/api/autoscaling/v2beta2"
 	batchv1 "k8s.io/api/batch/v1"
 	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	coordinationv1 "k8s.io/api/coordination/v1"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1beta1 "k8s.io/api/discovery/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/informers"
+	coordinationinformers "k8s.io/client-go/informers/coordination/v1"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/informers/internalinterfaces"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 
 	metadata "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/experimentalmetricmetadata"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/collection"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/observability"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/utils"
 )
 
 type resourceWatcher struct {
-	client                     kubernetes.Interface
-	sharedInformerFactory      informers.SharedInformerFactory
+	client                  kubernetes.Interface
+	dynamicClient           dynamic.Interface
+	sharedInformerFactories []informers.SharedInformerFactory
+	dynamicInformerFactory  dynamicinformer.DynamicSharedInformerFactory
+	// countedResourceFactory watches the custom resources counted for
+	// custom_resource_definitions, if any.
+	countedResourceFactory dynamicinformer.DynamicSharedInformerFactory
+	// customResourceFactories watch custom and OpenShift resources, one
+	// factory per resource and namespace so that each informer records its
+	// own relists.
+	customResourceFactories    []dynamicinformer.DynamicSharedInformerFactory
 	dataCollector              *collection.DataCollector
 	logger                     *zap.Logger
+	config                     *Config
 	metadataConsumers          []metadataConsumer
 	initialTimeout             time.Duration
 	timedContextForInitialSync context.Context
 	initialSyncDone            *atomic.Bool
 	initialSyncTimedOut        *atomic.Bool
+	stopCh                     <-chan struct{}
+	eventQueue                 *eventQueue
+	// Records changed objects whose metrics are pushed on change, only set
+	// if push_mode is on_change.
+	changeBatcher *changeBatcher
+	// Caches of the informers whose objects are collected.
+	informerStores []cache.Store
+	// Namespaces to watch, set by namespaces or by sharding. When nil, all
+	// namespaces are watched.
+	namespaces []string
+	// Informers of each kind, keyed by kind, only set if namespaces is set.
+	namespacedInformers map[string]*namespacedInformers
+	// Records the informer each object is received from, only set if
+	// duplicate_objects is first_source.
+	sources *sourceTracker
+
+	// Names of CustomResourceDefinitions whose custom resources are counted.
+	crdInstancesToCount map[string]bool
+	// Names of CustomResourceDefinitions whose custom resources are being
+	// counted. Only accessed from the CRD informer's event handlers.
+	countedCRDs map[string]bool
+}
+
+var crdGVR = schema.GroupVersionResource{
+	Group:    "apiextensions.k8s.io",
+	Version:  "v1",
+	Resource: "customresourcedefinitions",
 }
 
 type metadataConsumer func(metadata []*metadata.MetadataUpdate) error
 
 // newResourceWatcher creates a Kubernetes resource watcher.
 func newResourceWatcher(
-	logger *zap.Logger, client kubernetes.Interface,
-	nodeConditionTypesToReport []string, initialSyncTimeout time.Duration) *resourceWatcher {
+	logger *zap.Logger, client kubernetes.Interface, dynamicClient dynamic.Interface,
+	config *Config, initialSyncTimeout time.Duration) *resourceWatcher {
 	rw := &resourceWatcher{
-		client:              client,
-		logger:              logger,
-		dataCollector:       collection.NewDataCollector(logger, nodeConditionTypesToReport),
+		client:        client,
+		dynamicClient: dynamicClient,
+		logger:        logger,
+		config:        config,
+		dataCollector: collection.NewDataCollector(logger, config.NodeConditionTypesToReport,
+			collection.WithMaxObjects(config.MaxObjects),
+			collection.WithSampling(config.samplingRules()),
+			collection.WithExcludeManagedBy(config.excludeManagedByRule()),
+			collection.WithExcludedPodPhases(config.ExcludePodPhases),
+			collection.WithDropZeroValues(config.dropZeroValues()),
+			collection.WithDisabledMetrics(config.disabledMetrics()),
+			collection.WithMetricOverrides(config.metricOverrides()),
+			collection.WithCollectionIntervals(config.CollectionInterval, config.CollectionIntervals),
+			collection.WithSpotNodeLabels(config.spotNodeLabels()),
+			collection.WithInstanceTypeLabel(config.InstanceTypeLabel),
+			collection.WithUIDFallback(config.UIDFallback),
+			// Pushing on change relies on skipping objects whose metrics
+			// have not changed, e.g. on resyncs.
+			collection.WithDeletionGracePeriod(config.DeletionGracePeriod),
+			collection.WithWarmup(config.WarmupIntervals),
+			collection.WithDeletionMarkers(config.EmitDeletionMarkers),
+			collection.WithSkipUnchanged(config.SkipUnchanged || config.PushMode == pushModeOnChange),
+			collection.WithFullResyncInterval(config.FullResyncInterval),
+			collection.WithSkipIrrelevantUpdates(config.SkipIrrelevantUpdates),
+			collection.WithStringInterning(config.InternStrings),
+			collection.WithAntiAffinityViolations(config.ReportAntiAffinityViolations),
+			collection.WithSecurityContext(config.ReportSecurityContext),
+			collection.WithObjectKind(config.ReportObjectKind),
+			collection.WithObjectVersion(config.ReportObjectVersion),
+			collection.WithObjectCountDelta(config.ReportObjectCountDelta),
+			collection.WithContainerRestartRate(config.ReportContainerRestartRate),
+			collection.WithContainerStatusReasons(config.ReportContainerStatusReasons),
+			collection.WithTransitionTimes(config.ReportTransitionTimes),
+			collection.WithImageMetadata(config.ImageMetadata.Enabled),
+			collection.WithNodeResources(config.ReportNodeResources),
+			collection.WithInformerCacheMetrics(config.ReportInformerCache),
+			collection.WithUnits(config.Units),
+			collection.WithPodAggregation(config.PodAggregation),
+			collection.WithClusterCapacityNodes(config.ClusterCapacityNodes),
+			collection.WithGaugeValueType(config.GaugeValueType),
+			collection.WithIncludedResourceAttributes(config.IncludeResourceAttributes),
+			collection.WithResourceAttributeKeys(config.ResourceAttributeKeys),
+			collection.WithAttributeKeyStyle(config.AttributeKeyStyle),
+			collection.WithLabelInfoKinds(config.LabelInfoKinds),
+			collection.WithMaxAttributeValueLength(config.MaxAttributeValueLength),
+			collection.WithDatapointAttributes(config.DatapointAttributes.Keys, config.DatapointAttributes.Mode),
+			collection.WithResourceQuotaThreshold(config.ResourceQuotaThreshold),
+			collection.WithFieldExtractionRules(config.fieldExtractionRules()),
+			collection.WithExpressionMetrics(config.expressionMetrics()),
+			collection.WithCustomResourceMetrics(config.customResourceMetrics()),
+			collection.WithSourceNode(config.ClusterName),
+		),
 		initialSyncDone:     atomic.NewBool(false),
 		initialSyncTimedOut: atomic.NewBool(false),
 		initialTimeout:      initialSyncTimeout,
+		crdInstancesToCount: utils.StringSliceToMap(config.CustomResourceDefinitions.CountInstances),
+		countedCRDs:         map[string]bool{},
+	}
+
+	if len(config.Namespaces) > 0 {
+		rw.namespaces = config.Namespaces
+	}
+
+	if config.DuplicateObjects != duplicateObjectsAllSources {
+		rw.sources = newSourceTracker()
 	}
 
-	rw.prepareSharedInformerFactory()
+	if config.EventWorkers > 0 {
+		rw.eventQueue = newEventQueue(config.EventWorkers, config.EventQueueSize)
+	}
+
+	if dynamicClient != nil && config.CustomResourceDefinitions.Enabled {
+		rw.prepareDynamicInformerFactory()
+	}
 
 	return rw
 }
 
-func (rw *resourceWatcher) prepareSharedInformerFactory() {
-	factory := informers.NewSharedInformerFactoryWithOptions(rw.client, 0)
+// prepareSharedInformerFactories sets up the informer factories of the kinds
+// to watch, in every namespace or in each of the namespaces set by namespaces
+// or sharding.
+func (rw *resourceWatcher) prepareSharedInformerFactories(ctx context.Context) {
+	if rw.namespaces == nil {
+		rw.prepareSharedInformerFactory(ctx, v1.NamespaceAll)
+		return
+	}
+
+	rw.logger.Info("Watching the configured namespaces only, cluster-scoped kinds are not collected",
+		zap.Strings("namespaces", rw.namespaces))
+	rw.namespacedInformers = map[string]*namespacedInformers{}
+	for _, namespace := range rw.namespaces {
+		rw.prepareSharedInformerFactory(ctx, namespace)
+	}
+}
+
+// prepareSharedInformerFactory sets up the informers of the kinds to watch in
+// the given namespace, or in all namespaces if v1.NamespaceAll. Cluster-scoped
+// kinds are only watched in all namespaces. Kinds the receiver is not
+// permitted to list are skipped, so that missing permissions for some kinds do
+// not prevent collecting the others.
+func (rw *resourceWatcher) prepareSharedInformerFactory(ctx context.Context, namespace string) {
+	config := rw.config
+	factory := informers.NewSharedInformerFactoryWithOptions(rw.client, config.InformerResyncPeriod,
+		informers.WithNamespace(namespace), informers.WithTweakListOptions(rw.tweakListOptions))
+	allNamespaces := namespace == v1.NamespaceAll
+	setup := func(o runtime.Object, newInformer func(f *kindInformerFactory) cache.SharedIndexInformer) {
+		kind := reflect.TypeOf(o).Elem().Name()
+		if !config.isKindWatched(strings.ToLower(kind)) {
+			rw.logger.Debug("Kind not selected by resources, its metrics will not be collected",
+				zap.String("kind", kind))
+			return
+		}
+		f := &kindInformerFactory{SharedInformerFactory: factory, namespace: namespace, tweak: rw.listOptionsTweak(kind)}
+		rw.setupInformersIfPermitted(ctx, o, namespace, func() cache.SharedIndexInformer {
+			return newInformer(f)
+		})
+	}
 
 	// Add shared informers for each resource type that has to be watched.
-	rw.setupInformers(&corev1.Pod{}, factory.Core().V1().Pods().Informer())
-	rw.setupInformers(&corev1.Node{}, factory.Core().V1().Nodes().Informer())
-	rw.setupInformers(&corev1.Namespace{}, factory.Core().V1().Namespaces().Informer())
-	rw.setupInformers(&corev1.ReplicationController{},
-		factory.Core().V1().ReplicationControllers().Informer(),
-	)
-	rw.setupInformers(&corev1.ResourceQuota{}, factory.Core().V1().ResourceQuotas().Informer())
-	rw.setupInformers(&corev1.Service{}, factory.Core().V1().Services().Informer())
-	rw.setupInformers(&appsv1.DaemonSet{}, factory.Apps().V1().DaemonSets().Informer())
-	rw.setupInformers(&appsv1.Deployment{}, factory.Apps().V1().Deployments().Informer())
-	rw.setupInformers(&appsv1.ReplicaSet{}, factory.Apps().V1().ReplicaSets().Informer())
-	rw.setupInformers(&appsv1.StatefulSet{}, factory.Apps().V1().StatefulSets().Informer())
-	rw.setupInformers(&batchv1.Job{}, factory.Batch().V1().Jobs().Informer())
-	rw.setupInformers(&batchv1beta1.CronJob{}, factory.Batch().V1beta1().CronJobs().Informer())
-	rw.setupInformers(&v2beta1.HorizontalPodAutoscaler{},
-		factory.Autoscaling().V2beta1().HorizontalPodAutoscalers().Informer(),
-	)
-
-	rw.sharedInformerFactory = factory
+	setup(&corev1.Pod{}, func(f *kindInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().Pods().Informer()
+	})
+	if allNamespaces {
+		if selector := config.nodeLabelSelector(); selector != "" {
+			setup(&corev1.Node{}, func(f *kindInformerFactory) cache.SharedIndexInformer {
+				return factory.InformerFor(&corev1.Node{}, newFilteredNodeInformer(selector, f.tweak))
+			})
+		} else {
+			setup(&corev1.Node{}, func(f *kindInformerFactory) cache.SharedIndexInformer {
+				return f.Core().V1().Nodes().Informer()
+			})
+		}
+		setup(&corev1.Namespace{}, func(f *kindInformerFactory) cache.SharedIndexInformer {
+			return f.Core().V1().Namespaces().Informer()
+		})
+	}
+	setup(&corev1.ReplicationController{}, func(f *kindInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().ReplicationControllers().Informer()
+	})
+	setup(&corev1.ResourceQuota{}, func(f *kindInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().ResourceQuotas().Informer()
+	})
+	setup(&corev1.Service{}, func(f *kindInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().Services().Informer()
+	})
+	setup(&appsv1.DaemonSet{}, func(f *kindInformerFactory) cache.SharedIndexInformer {
+		return f.Apps().V1().DaemonSets().Informer()
+	})
+	setup(&appsv1.Deployment{}, func(f *kindInformerFactory) cache.SharedIndexInformer {
+		return f.Apps().V1().Deployments().Informer()
+	})
+	setup(&appsv1.ReplicaSet{}, func(f *kindInformerFactory) cache.SharedIndexInformer {
+		return f.Apps().V1().ReplicaSets().Informer()
+	})
+	setup(&appsv1.StatefulSet{}, func(f *kindInformerFactory) cache.SharedIndexInformer {
+		return f.Apps().V1().StatefulSets().Informer()
+	})
+	setup(&batchv1.Job{}, func(f *kindInformerFactory) cache.SharedIndexInformer {
+		return f.Batch().V1().Jobs().Informer()
+	})
+	setup(&batchv1beta1.CronJob{}, func(f *kindInformerFactory) cache.SharedIndexInformer {
+		return f.Batch().V1beta1().CronJobs().Informer()
+	})
+	switch config.apiVersion("horizontalpodautoscaler") {
+	case apiVersionAutoscalingV1:
+		setup(&autoscalingv1.HorizontalPodAutoscaler{}, func(f *kindInformerFactory) cache.SharedIndexInformer {
+			return f.Autoscaling().V1().HorizontalPodAutoscalers().Informer()
+		})
+	case apiVersionAutoscalingV2beta2:
+		setup(&v2beta2.HorizontalPodAutoscaler{}, func(f *kindInformerFactory) cache.SharedIndexInformer {
+			return f.Autoscaling().V2beta2().HorizontalPodAutoscalers().Informer()
+		})
+	default:
+		setup(&v2beta1.HorizontalPodAutoscaler{}, func(f *kindInformerFactory) cache.SharedIndexInformer {
+			return f.Autoscaling().V2beta1().HorizontalPodAutoscalers().Informer()
+		})
+	}
+
+	if config.isOptionalKindEnabled(optionalKindConfigMap) {
+		setup(&corev1.ConfigMap{}, func(f *kindInformerFactory) cache.SharedIndexInformer {
+			return f.Core().V1().ConfigMaps().Informer()
+		})
+	}
+	if config.isOptionalKindEnabled(optionalKindSecret) {
+		setup(&corev1.Secret{}, func(f *kindInformerFactory) cache.SharedIndexInformer {
+			return factory.InformerFor(&corev1.Secret{}, newSecretMetadataInformer(namespace, f.tweak))
+		})
+	}
+	if config.isOptionalKindEnabled(optionalKindEndpointSlice) {
+		setup(&discoveryv1beta1.EndpointSlice{}, func(f *kindInformerFactory) cache.SharedIndexInformer {
+			return f.Discovery().V1beta1().EndpointSlices().Informer()
+		})
+	}
+	if config.isOptionalKindEnabled(optionalKindIngress) {
+		setup(&networkingv1.Ingress{}, func(f *kindInformerFactory) cache.SharedIndexInformer {
+			return f.Networking().V1().Ingresses().Informer()
+		})
+	}
+	if config.isOptionalKindEnabled(optionalKindPersistentVolume) && allNamespaces {
+		setup(&corev1.PersistentVolume{}, func(f *kindInformerFactory) cache.SharedIndexInformer {
+			return f.Core().V1().PersistentVolumes().Informer()
+		})
+	}
+	if config.isOptionalKindEnabled(optionalKindPersistentVolumeClaim) {
+		setup(&corev1.PersistentVolumeClaim{}, func(f *kindInformerFactory) cache.SharedIndexInformer {
+			return f.Core().V1().PersistentVolumeClaims().Informer()
+		})
+	}
+	if config.isOptionalKindEnabled(optionalKindPodDisruptionBudget) {
+		setup(&policyv1beta1.PodDisruptionBudget{}, func(f *kindInformerFactory) cache.SharedIndexInformer {
+			return f.Policy().V1beta1().PodDisruptionBudgets().Informer()
+		})
+	}
+	if config.isOptionalKindEnabled(optionalKindPriorityClass) && allNamespaces {
+		setup(&schedulingv1.PriorityClass{}, func(f *kindInformerFactory) cache.SharedIndexInformer {
+			return f.Scheduling().V1().PriorityClasses().Informer()
+		})
+	}
+	// Node leases are only watched in their namespace.
+	if config.isOptionalKindEnabled(optionalKindLease) && (allNamespaces || namespace == corev1.NamespaceNodeLease) {
+		setup(&coordinationv1.Lease{}, func(f *kindInformerFactory) cache.SharedIndexInformer {
+			return factory.InformerFor(&coordinationv1.Lease{}, newNodeLeaseInformer(f.tweak))
+		})
+	}
+	if config.isOptionalKindEnabled(optionalKindMutatingWebhookConfiguration) && allNamespaces {
+		setup(&admissionregistrationv1.MutatingWebhookConfiguration{}, func(f *kindInformerFactory) cache.SharedIndexInformer {
+			return f.Admissionregistration().V1().MutatingWebhookConfigurations().Informer()
+		})
+	}
+	if config.isOptionalKindEnabled(optionalKindValidatingWebhookConfiguration) && allNamespaces {
+		setup(&admissionregistrationv1.ValidatingWebhookConfiguration{}, func(f *kindInformerFactory) cache.SharedIndexInformer {
+			return f.Admissionregistration().V1().ValidatingWebhookConfigurations().Informer()
+		})
+	}
+	if config.isOptionalKindEnabled(optionalKindRBAC) {
+		setup(&rbacv1.Role{}, func(f *kindInformerFactory) cache.SharedIndexInformer {
+			return f.Rbac().V1().Roles().Informer()
+		})
+		setup(&rbacv1.RoleBinding{}, func(f *kindInformerFactory) cache.SharedIndexInformer {
+			return f.Rbac().V1().RoleBindings().Informer()
+		})
+		if allNamespaces {
+			setup(&rbacv1.ClusterRole{}, func(f *kindInformerFactory) cache.SharedIndexInformer {
+				return f.Rbac().V1().ClusterRoles().Informer()
+			})
+			setup(&rbacv1.ClusterRoleBinding{}, func(f *kindInformerFactory) cache.SharedIndexInformer {
+				return f.Rbac().V1().ClusterRoleBindings().Informer()
+			})
+		}
+	}
+
+	rw.sharedInformerFactories = append(rw.sharedInformerFactories, factory)
+}
+
+// newSecretMetadataInformer returns a constructor of informers for the Secrets
+// of the given namespace that drop their data before they are cached, so that
+// secret values are never retained nor read by the receiver.
+func newSecretMetadataInformer(namespace string, tweakListOptions internalinterfaces.TweakListOptionsFunc) internalinterfaces.NewInformerFunc {
+	return func(client kubernetes.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+		secrets := client.CoreV1().Secrets(namespace)
+		return cache.NewSharedIndexInformer(
+			&cache.ListWatch{
+				ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+					tweakListOptions(&options)
+					list, err := secrets.List(context.Background(), options)
+					if err != nil {
+						return nil, err
+					}
+					// The list may be shared with its source, so the secrets
+					// are stripped from a copy.
+					list = list.DeepCopy()
+					for i := range list.Items {
+						stripSecretData(&list.Items[i])
+					}
+					return list, nil
+				},
+				WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+					w, err := secrets.Watch(context.Background(), options)
+					if err != nil {
+						return nil, err
+					}
+					return watch.Filter(w, func(e watch.Event) (watch.Event, bool) {
+						if secret, ok := e.Object.(*corev1.Secret); ok {
+							secret = secret.DeepCopy()
+							stripSecretData(secret)
+							e.Object = secret
+						}
+						return e, true
+					}), nil
+				},
+			},
+			&corev1.Secret{},
+			resyncPeriod,
+			cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+		)
+	}
+}
+
+// newNodeLeaseInformer returns a constructor of informers for the Leases of
+// the node lease namespace only, since other Leases, e.g. those used for
+// leader election, are not collected and may be updated frequently.
+func newNodeLeaseInformer(tweakListOptions internalinterfaces.TweakListOptionsFunc) internalinterfaces.NewInformerFunc {
+	return func(client kubernetes.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+		return coordinationinformers.NewFilteredLeaseInformer(client, corev1.NamespaceNodeLease, resyncPeriod,
+			cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, tweakListOptions)
+	}
+}
+
+// newFilteredNodeInformer returns a constructor of informers for the Nodes
+// matching the given label selector only.
+func newFilteredNodeInformer(labelSelector string, tweakListOptions internalinterfaces.TweakListOptionsFunc) internalinterfaces.NewInformerFunc {
+	return func(client kubernetes.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+		return coreinformers.NewFilteredNodeInformer(client, resyncPeriod,
+			cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+			func(options *v1.ListOptions) {
+				options.LabelSelector = labelSelector
+				tweakListOptions(options)
+			})
+	}
+}
+
+// tweakListOptions sets the page size of the requests of informers listing
+// objects, if list_page_size is set.
+func (rw *resourceWatcher) tweakListOptions(options *v1.ListOptions) {
+	// Watches of informers allow bookmarks, lists do not.
+	if rw.config.ListPageSize <= 0 || options.AllowWatchBookmarks {
+		return
+	}
+	options.Limit = rw.config.ListPageSize
+	// Lists at resource version "0" are served from the cache of the API
+	// server, which ignores the page size.
+	if options.ResourceVersion == "0" {
+		options.ResourceVersion = ""
+	}
+}
+
+// stripSecretData removes the data of the secret, including the copy kept in
+// the annotation set by kubectl apply.
+func stripSecretData(secret *corev1.Secret) {
+	secret.Data = nil
+	secret.StringData = nil
+	if _, ok := secret.Annotations[corev1.LastAppliedConfigAnnotation]; ok {
+		annotations := make(map[string]string, len(secret.Annotations))
+		for k, v := range secret.Annotations {
+			if k != corev1.LastAppliedConfigAnnotation {
+				annotations[k] = v
+			}
+		}
+		secret.Annotations = annotations
+	}
+}
+
+func (rw *resourceWatcher) prepareDynamicInformerFactory() {
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(rw.dynamicClient,
+		rw.config.InformerResyncPeriod, v1.NamespaceAll, rw.listOptionsTweak("CustomResourceDefinition"))
+
+	crdInformer := factory.ForResource(crdGVR).Informer()
+	crdInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: rw.onCRDAdd,
+		UpdateFunc: func(_, newObj interface{}) {
+			rw.onCRDAdd(newObj)
+		},
+		DeleteFunc: rw.onCRDDelete,
+	})
+	rw.dataCollector.SetupCRDStore(crdInformer.GetStore())
+	rw.setupWatchErrorHandler(crdInformer, "CustomResourceDefinition")
+	rw.dataCollector.SetupResourceVersionSource("CustomResourceDefinition", crdInformer.LastSyncResourceVersion)
+
+	rw.dynamicInformerFactory = factory
+	rw.countedResourceFactory = dynamicinformer.NewFilteredDynamicSharedInformerFactory(rw.dynamicClient,
+		rw.config.InformerResyncPeriod, v1.NamespaceAll, rw.tweakListOptions)
+}
+
+// prepareCustomResourceInformers sets up the informers of the custom resources
+// metrics are reported for, in every namespace or in each of the namespaces
+// set by namespaces.
+func (rw *resourceWatcher) prepareCustomResourceInformers() {
+	if rw.dynamicClient == nil || len(rw.config.CustomResources) == 0 {
+		return
+	}
+
+	for _, cr := range rw.config.CustomResources {
+		gvr := schema.GroupVersionResource{Group: cr.Group, Version: cr.Version, Resource: cr.Resource}
+		ni := newNamespacedInformers()
+		for _, factory := range rw.namespacedDynamicFactories(gvr.String()) {
+			informer := factory.ForResource(gvr).Informer()
+			rw.setupWatchErrorHandler(informer, gvr.String())
+			ni.add(informer)
+		}
+		if len(ni.informers) == 1 {
+			rw.dataCollector.SetupCustomResourceMetricsStore(gvr, ni.informers[0].GetStore())
+		} else {
+			rw.dataCollector.SetupCustomResourceMetricsStore(gvr, ni.store)
+		}
+		rw.logger.Info("Reporting metrics of custom resources", zap.String("resource", gvr.String()))
+	}
+}
+
+// namespacedDynamicFactories returns new dynamic informer factories of the
+// namespaced resource of the given kind, one for every namespace or for each
+// of the namespaces set by namespaces or sharding.
+func (rw *resourceWatcher) namespacedDynamicFactories(kind string) []dynamicinformer.DynamicSharedInformerFactory {
+	namespaces := rw.namespaces
+	if namespaces == nil {
+		namespaces = []string{v1.NamespaceAll}
+	}
+	factories := make([]dynamicinformer.DynamicSharedInformerFactory, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		factories = append(factories, rw.newCustomResourceFactory(kind, namespace))
+	}
+	return factories
+}
+
+// newCustomResourceFactory returns a new dynamic informer factory of the
+// resource of the given kind in the given namespace, started along with the
+// factories of the other custom and OpenShift resources.
+func (rw *resourceWatcher) newCustomResourceFactory(kind, namespace string) dynamicinformer.DynamicSharedInformerFactory {
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(rw.dynamicClient,
+		rw.config.InformerResyncPeriod, namespace, rw.listOptionsTweak(kind))
+	rw.customResourceFactories = append(rw.customResourceFactories, factory)
+	return factory
+}
+
+// prepareOpenShiftInformers sets up the informers of the OpenShift resources
+// metrics are reported for when the distribution is openshift. Resources that
+// are not served, e.g. on other distributions, are skipped.
+func (rw *resourceWatcher) prepareOpenShiftInformers() {
+	if rw.dynamicClient == nil || rw.config.Distribution != distributionOpenShift {
+		return
+	}
+
+	for _, gvr := range []schema.GroupVersionResource{collection.ClusterResourceQuotaGVR, collection.DeploymentConfigGVR} {
+		namespaced, err := isResourceServed(rw.client.Discovery(), gvr)
+		if err != nil {
+			rw.logger.Warn("OpenShift resource is not served, skipping it",
+				zap.String("resource", gvr.String()), zap.Error(err))
+			continue
+		}
+
+		// Cluster-scoped resources are watched across the cluster, even if
+		// namespaces is set.
+		var factories []dynamicinformer.DynamicSharedInformerFactory
+		if namespaced {
+			factories = rw.namespacedDynamicFactories(gvr.String())
+		} else {
+			factories = []dynamicinformer.DynamicSharedInformerFactory{
+				rw.newCustomResourceFactory(gvr.String(), v1.NamespaceAll),
+			}
+		}
+
+		ni := newNamespacedInformers()
+		for _, factory := range factories {
+			informer := factory.ForResource(gvr).Informer()
+			rw.setupWatchErrorHandler(informer, gvr.String())
+			ni.add(informer)
+		}
+		if len(ni.informers) == 1 {
+			rw.dataCollector.SetupOpenShiftStore(gvr, ni.informers[0].GetStore())
+		} else {
+			rw.dataCollector.SetupOpenShiftStore(gvr, ni.store)
+		}
+		rw.logger.Info("Reporting metrics of OpenShift resources", zap.String("resource", gvr.String()))
+	}
 }
 
 // startWatchingResources starts up all informers.
 func (rw *resourceWatcher) startWatchingResources(ctx context.Context) {
 	var cancel context.CancelFunc
 	rw.timedContextForInitialSync, cancel = context.WithTimeout(ctx, rw.initialTimeout)
+	rw.stopCh = ctx.Done()
+
+	if rw.eventQueue != nil {
+		rw.eventQueue.start(ctx)
+	}
 
 	// Start off individual informers in the factory.
-	rw.sharedInformerFactory.Start(ctx.Done())
+	rw.prepareSharedInformerFactories(ctx)
+	for _, factory := range rw.sharedInformerFactories {
+		factory.Start(ctx.Done())
+	}
+	if rw.dynamicInformerFactory != nil {
+		rw.dynamicInformerFactory.Start(ctx.Done())
+	}
+	// The initial sync does not wait for custom resources, e.g. whose CRD
+	// is not installed yet, nor for OpenShift resources, their metrics are
+	// reported once listed.
+	rw.prepareCustomResourceInformers()
+	rw.prepareOpenShiftInformers()
+	for _, factory := range rw.customResourceFactories {
+		factory.Start(ctx.Done())
+	}
 
 	// Ensure cache is synced with initial state, once informers are started up.
 	// Note that the event handler can start receiving events as soon as the informers
@@ -109,23 +612,330 @@ func (rw *resourceWatcher) startWatchingResources(ctx context.Context) {
 	// collecting data before the cache sync since all data may not be available.
 	// This method will block either till the timeout set on the context, until
 	// the initial sync is complete or the parent context is cancelled.
-	rw.sharedInformerFactory.WaitForCacheSync(rw.timedContextForInitialSync.Done())
+	synced := true
+	for _, factory := range rw.sharedInformerFactories {
+		synced = allSynced(factory.WaitForCacheSync(rw.timedContextForInitialSync.Done())) && synced
+	}
+	if rw.dynamicInformerFactory != nil {
+		for _, ok := range rw.dynamicInformerFactory.WaitForCacheSync(rw.timedContextForInitialSync.Done()) {
+			synced = synced && ok
+		}
+	}
 	defer cancel()
+
+	if synced {
+		rw.syncInitialSnapshot()
+	}
+	if rw.config.InformerResyncPeriod > 0 {
+		go rw.evictStaleObjects(rw.config.InformerResyncPeriod)
+	}
+}
+
+// evictStaleObjects evicts the metrics of objects no longer in the informer
+// caches once every informer resync period until the receiver stops, should
+// the events of their deletion have been missed.
+func (rw *resourceWatcher) evictStaleObjects(period time.Duration) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-rw.stopCh:
+			return
+		case <-ticker.C:
+			rw.dataCollector.EvictStaleObjects()
+		}
+	}
+}
+
+// syncInitialSnapshot syncs metrics of every object in the informer caches.
+// Event handlers wait for the initial sync to complete, so without this the
+// first collection would depend on how many Add events have been processed.
+func (rw *resourceWatcher) syncInitialSnapshot() {
+	for _, store := range rw.informerStores {
+		for _, obj := range store.List() {
+			if rw.isCollected(obj) {
+				rw.dataCollector.SyncMetrics(obj)
+			}
+		}
+	}
+}
+
+func allSynced(synced map[reflect.Type]bool) bool {
+	for _, ok := range synced {
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// setupInformersIfPermitted sets up the informer returned by newInformer
+// unless the receiver is forbidden from listing objects of the kind of o in
+// the given namespace, in which case the kind is not collected there.
+// newInformer is only called if the kind is permitted since informers of the
+// factory are started once created.
+func (rw *resourceWatcher) setupInformersIfPermitted(
+	ctx context.Context, o runtime.Object, namespace string, newInformer func() cache.SharedIndexInformer) {
+	if err := rw.checkListPermission(ctx, o, namespace); apierrors.IsForbidden(err) {
+		kind := reflect.TypeOf(o).Elem().Name()
+		fields := []zap.Field{zap.String("kind", kind), zap.Error(err)}
+		if namespace != v1.NamespaceAll {
+			fields = append(fields, zap.String("namespace", namespace))
+		}
+		rw.logger.Warn("Not permitted to list kind, its metrics will not be collected", fields...)
+		observability.RecordKindDisabled(kind)
+		return
+	}
+	rw.setupInformers(o, newInformer())
+}
+
+// checkListPermission lists a single object of the kind of o in the given
+// namespace to check that the receiver is permitted to do so. The namespace is
+// ignored for cluster-scoped kinds.
+func (rw *resourceWatcher) checkListPermission(ctx context.Context, o runtime.Object, namespace string) error {
+	opts := v1.ListOptions{Limit: 1}
+	var err error
+	switch o.(type) {
+	case *corev1.Pod:
+		_, err = rw.client.CoreV1().Pods(namespace).List(ctx, opts)
+	case *corev1.Node:
+		_, err = rw.client.CoreV1().Nodes().List(ctx, opts)
+	case *corev1.Namespace:
+		_, err = rw.client.CoreV1().Namespaces().List(ctx, opts)
+	case *corev1.ReplicationController:
+		_, err = rw.client.CoreV1().ReplicationControllers(namespace).List(ctx, opts)
+	case *corev1.ResourceQuota:
+		_, err = rw.client.CoreV1().ResourceQuotas(namespace).List(ctx, opts)
+	case *corev1.Service:
+		_, err = rw.client.CoreV1().Services(namespace).List(ctx, opts)
+	case *corev1.ConfigMap:
+		_, err = rw.client.CoreV1().ConfigMaps(namespace).List(ctx, opts)
+	case *corev1.Secret:
+		_, err = rw.client.CoreV1().Secrets(namespace).List(ctx, opts)
+	case *networkingv1.Ingress:
+		_, err = rw.client.NetworkingV1().Ingresses(namespace).List(ctx, opts)
+	case *policyv1beta1.PodDisruptionBudget:
+		_, err = rw.client.PolicyV1beta1().PodDisruptionBudgets(namespace).List(ctx, opts)
+	case *schedulingv1.PriorityClass:
+		_, err = rw.client.SchedulingV1().PriorityClasses().List(ctx, opts)
+	case *corev1.PersistentVolume:
+		_, err = rw.client.CoreV1().PersistentVolumes().List(ctx, opts)
+	case *corev1.PersistentVolumeClaim:
+		_, err = rw.client.CoreV1().PersistentVolumeClaims(namespace).List(ctx, opts)
+	case *appsv1.DaemonSet:
+		_, err = rw.client.AppsV1().DaemonSets(namespace).List(ctx, opts)
+	case *appsv1.Deployment:
+		_, err = rw.client.AppsV1().Deployments(namespace).List(ctx, opts)
+	case *appsv1.ReplicaSet:
+		_, err = rw.client.AppsV1().ReplicaSets(namespace).List(ctx, opts)
+	case *appsv1.StatefulSet:
+		_, err = rw.client.AppsV1().StatefulSets(namespace).List(ctx, opts)
+	case *batchv1.Job:
+		_, err = rw.client.BatchV1().Jobs(namespace).List(ctx, opts)
+	case *batchv1beta1.CronJob:
+		_, err = rw.client.BatchV1beta1().CronJobs(namespace).List(ctx, opts)
+	case *v2beta1.HorizontalPodAutoscaler:
+		_, err = rw.client.AutoscalingV2beta1().HorizontalPodAutoscalers(namespace).List(ctx, opts)
+	case *autoscalingv1.HorizontalPodAutoscaler:
+		_, err = rw.client.AutoscalingV1().HorizontalPodAutoscalers(namespace).List(ctx, opts)
+	case *v2beta2.HorizontalPodAutoscaler:
+		_, err = rw.client.AutoscalingV2beta2().HorizontalPodAutoscalers(namespace).List(ctx, opts)
+	case *coordinationv1.Lease:
+		_, err = rw.client.CoordinationV1().Leases(corev1.NamespaceNodeLease).List(ctx, opts)
+	case *discoveryv1beta1.EndpointSlice:
+		_, err = rw.client.DiscoveryV1beta1().EndpointSlices(namespace).List(ctx, opts)
+	case *admissionregistrationv1.MutatingWebhookConfiguration:
+		_, err = rw.client.AdmissionregistrationV1().MutatingWebhookConfigurations().List(ctx, opts)
+	case *admissionregistrationv1.ValidatingWebhookConfiguration:
+		_, err = rw.client.AdmissionregistrationV1().ValidatingWebhookConfigurations().List(ctx, opts)
+	case *rbacv1.Role:
+		_, err = rw.client.RbacV1().Roles(namespace).List(ctx, opts)
+	case *rbacv1.ClusterRole:
+		_, err = rw.client.RbacV1().ClusterRoles().List(ctx, opts)
+	case *rbacv1.RoleBinding:
+		_, err = rw.client.RbacV1().RoleBindings(namespace).List(ctx, opts)
+	case *rbacv1.ClusterRoleBinding:
+		_, err = rw.client.RbacV1().ClusterRoleBindings().List(ctx, opts)
+	}
+	return err
 }
 
 // setupInformers adds event handlers to informers and setups a metadataStore.
+// When namespaces is set, the metadata store of each kind reads the caches of
+// the informers of all the namespaces.
 func (rw *resourceWatcher) setupInformers(o runtime.Object, informer cache.SharedIndexInformer) {
-	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    rw.onAdd,
-		UpdateFunc: rw.onUpdate,
-		DeleteFunc: rw.onDelete,
+	informer.AddEventHandler(rw.eventHandlers(len(rw.informerStores)))
+	rw.informerStores = append(rw.informerStores, informer.GetStore())
+	kind := reflect.TypeOf(o).Elem().Name()
+	rw.setupWatchErrorHandler(informer, kind)
+
+	if rw.namespacedInformers == nil {
+		rw.dataCollector.SetupMetadataStore(o, informer.GetStore())
+		rw.dataCollector.SetupResourceVersionSource(kind, informer.LastSyncResourceVersion)
+		return
+	}
+	ni, ok := rw.namespacedInformers[kind]
+	if !ok {
+		ni = newNamespacedInformers()
+		rw.namespacedInformers[kind] = ni
+		rw.dataCollector.SetupMetadataStore(o, ni.store)
+		rw.dataCollector.SetupResourceVersionSource(kind, ni.lastSyncResourceVersion)
+	}
+	ni.add(informer)
+}
+
+// setupWatchErrorHandler sets the watch error handler of the informer of
+// objects of the given kind. Besides logging errors like the default handler,
+// it records watch errors and backs off as set by relist_backoff. It returns
+// the relist backoff of the informer, if any. Each informer backs off on its
+// own. Relists are recorded by the list options tweak of the informer.
+func (rw *resourceWatcher) setupWatchErrorHandler(informer cache.SharedIndexInformer, kind string) *relistBackoff {
+	var b *relistBackoff
+	if rw.config.RelistBackoff.Max != 0 {
+		b = newRelistBackoff(rw.config.RelistBackoff.Min, rw.config.RelistBackoff.Max, rw.stopCh)
+	}
+	err := informer.SetWatchErrorHandler(func(r *cache.Reflector, err error) {
+		observability.RecordWatchError(kind)
+		cache.DefaultWatchErrorHandler(r, err)
+		if b != nil {
+			b.backOff()
+		}
 	})
-	rw.dataCollector.SetupMetadataStore(o, informer.GetStore())
+	if err != nil {
+		rw.logger.Warn("Watch error handler not set, informer already started", zap.Error(err))
+		return nil
+	}
+	return b
+}
+
+// dispatch processes an informer event, using the event queue if configured.
+func (rw *resourceWatcher) dispatch(obj interface{}, process func()) {
+	if rw.eventQueue == nil {
+		process()
+		return
+	}
+
+	// Deletions of objects whose final state is unknown are queued with the
+	// events of the last known state, so that they are processed after them.
+	if d, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = d.Obj
+	}
+	// Objects without a UID cannot be assigned to a worker and are processed
+	// directly.
+	o, ok := obj.(runtime.Object)
+	if !ok {
+		process()
+		return
+	}
+	uid, err := utils.GetUIDForObject(o)
+	if err != nil {
+		process()
+		return
+	}
+	rw.eventQueue.enqueue(uid, process)
+}
+
+// eventHandlers returns the event handlers of the informer set up at the given
+// position. Unless duplicate_objects is all_sources, events of objects first
+// received from another informer are ignored.
+func (rw *resourceWatcher) eventHandlers(informer int) cache.ResourceEventHandlerFuncs {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if rw.isFromSource(obj, informer) {
+				rw.onAdd(obj)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if rw.isFromSource(newObj, informer) {
+				rw.onUpdate(oldObj, newObj)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if rw.isDeletedFromSource(obj, informer) {
+				rw.onDelete(obj)
+			}
+		},
+	}
+}
+
+// isFromSource returns whether the object was first received from the given
+// informer, logging a warning the first time it is received from another one.
+func (rw *resourceWatcher) isFromSource(obj interface{}, informer int) bool {
+	if rw.sources == nil {
+		return true
+	}
+	o, ok := obj.(runtime.Object)
+	if !ok {
+		return true
+	}
+	uid, err := utils.GetUIDForObject(o)
+	if err != nil || uid == "" {
+		return true
+	}
+	ok, duplicate := rw.sources.claim(uid, informer)
+	if duplicate {
+		rw.logger.Warn("Object received from more than one informer, only events of the first one are processed",
+			zap.String("kind", reflect.TypeOf(o).Elem().Name()),
+			zap.String("uid", string(uid)))
+	}
+	return ok
+}
+
+// isDeletedFromSource returns whether the deleted object was received from the
+// given informer.
+func (rw *resourceWatcher) isDeletedFromSource(obj interface{}, informer int) bool {
+	if rw.sources == nil {
+		return true
+	}
+	if d, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = d.Obj
+	}
+	o, ok := obj.(runtime.Object)
+	if !ok {
+		return true
+	}
+	uid, err := utils.GetUIDForObject(o)
+	if err != nil || uid == "" {
+		return true
+	}
+	return rw.sources.release(uid, informer)
 }
 
 func (rw *resourceWatcher) onAdd(obj interface{}) {
+	recordEvent(obj)
+	rw.dispatch(obj, func() { rw.processAdd(obj) })
+}
+
+func (rw *resourceWatcher) onDelete(obj interface{}) {
+	recordEvent(obj)
+	rw.dispatch(obj, func() { rw.processDelete(obj) })
+}
+
+func (rw *resourceWatcher) onUpdate(oldObj, newObj interface{}) {
+	recordEvent(newObj)
+	rw.dispatch(newObj, func() { rw.processUpdate(oldObj, newObj) })
+}
+
+// recordEvent records the time of the event of the informer of the kind of
+// obj, so that informers no longer receiving events can be told apart.
+func recordEvent(obj interface{}) {
+	if d, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = d.Obj
+	}
+	if t := reflect.TypeOf(obj); t != nil && t.Kind() == reflect.Ptr {
+		observability.RecordEvent(t.Elem().Name(), time.Now())
+	}
+}
+
+func (rw *resourceWatcher) processAdd(obj interface{}) {
 	rw.waitForInitialInformerSync()
+	if !rw.isCollected(obj) {
+		return
+	}
 	rw.dataCollector.SyncMetrics(obj)
+	if rw.changeBatcher != nil {
+		rw.changeBatcher.changed(obj)
+	}
 
 	// Sync metadata only if there's at least one destination for it to sent.
 	if len(rw.metadataConsumers) == 0 {
@@ -136,15 +946,36 @@ func (rw *resourceWatcher) onAdd(obj interface{}) {
 	rw.syncMetadataUpdate(map[metadata.ResourceID]*collection.KubernetesMetadata{}, newMetadata)
 }
 
-func (rw *resourceWatcher) onDelete(obj interface{}) {
+// isCollected returns whether the object is neither left out by sampling nor
+// excluded as managed by exclude_managed_by or by the phase of the pod.
+func (rw *resourceWatcher) isCollected(obj interface{}) bool {
+	return rw.dataCollector.IsSampled(obj) && !rw.dataCollector.IsExcluded(obj) &&
+		!rw.dataCollector.IsExcludedPodPhase(obj)
+}
+
+func (rw *resourceWatcher) processDelete(obj interface{}) {
 	rw.waitForInitialInformerSync()
+	if d, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = d.Obj
+	}
 	rw.dataCollector.RemoveFromMetricsStore(obj)
+	if rw.changeBatcher != nil {
+		rw.changeBatcher.removed(obj)
+	}
 }
 
-func (rw *resourceWatcher) onUpdate(oldObj, newObj interface{}) {
+func (rw *resourceWatcher) processUpdate(oldObj, newObj interface{}) {
 	rw.waitForInitialInformerSync()
+	if !rw.isCollected(newObj) {
+		// The object may have been collected before its labels changed.
+		rw.dataCollector.RemoveFromMetricsStore(newObj)
+		return
+	}
 	// Sync metrics from the new object
 	rw.dataCollector.SyncMetrics(newObj)
+	if rw.changeBatcher != nil {
+		rw.changeBatcher.changed(newObj)
+	}
 
 	// Sync metadata only if there's at least one destination for it to sent.
 	if len(rw.metadataConsumers) == 0 {
@@ -157,6 +988,39 @@ func (rw *resourceWatcher) onUpdate(oldObj, newObj interface{}) {
 	rw.syncMetadataUpdate(oldMetadata, newMetadata)
 }
 
+// onCRDAdd starts counting the custom resources of a CustomResourceDefinition
+// if configured to do so.
+func (rw *resourceWatcher) onCRDAdd(obj interface{}) {
+	name, gvr, ok := collection.GetCustomResourceGVR(obj)
+	if !ok || !rw.crdInstancesToCount[name] || rw.countedCRDs[name] {
+		return
+	}
+
+	informer := rw.countedResourceFactory.ForResource(gvr).Informer()
+	rw.dataCollector.SetupCustomResourceStore(name, informer.GetStore())
+	rw.countedCRDs[name] = true
+
+	// Start the newly created informer, informers already running are not affected.
+	rw.countedResourceFactory.Start(rw.stopCh)
+	rw.logger.Info("Counting custom resources", zap.String("crd", name))
+}
+
+func (rw *resourceWatcher) onCRDDelete(obj interface{}) {
+	if d, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = d.Obj
+	}
+
+	name, _, ok := collection.GetCustomResourceGVR(obj)
+	if !ok || !rw.countedCRDs[name] {
+		return
+	}
+
+	// Informers of a shared factory cannot be stopped individually, the
+	// informer keeps running and is reused if the CRD is created again.
+	rw.dataCollector.RemoveCustomResourceStore(name)
+	delete(rw.countedCRDs, name)
+}
+
 func (rw *resourceWatcher) waitForInitialInformerSync() {
 	if rw.initialSyncDone.Load() || rw.initialSyncTimedOut.Load() {
 		return
@@ -171,29 +1035,48 @@ func (rw *resourceWatcher) waitForInitialInformerSync() {
 	}
 }
 
+// metadataExporterCandidates returns the exporters of all pipelines and the
+// extensions of the host by name, the components metadata_exporters can list.
+// The same exporter is returned once even if it is used by pipelines of
+// several data types.
+func metadataExporterCandidates(host component.Host) (map[string]component.Component, error) {
+	out := map[string]component.Component{}
+	for _, exporters := range host.GetExporters() {
+		for cfg, exp := range exporters {
+			out[cfg.Name()] = exp
+		}
+	}
+	for cfg, ext := range host.GetExtensions() {
+		if _, ok := out[cfg.Name()]; ok {
+			return nil, fmt.Errorf("%s is the name of both an exporter and an extension", cfg.Name())
+		}
+		out[cfg.Name()] = ext
+	}
+	return out, nil
+}
+
+// setupMetadataExporters registers the components listed in
+// metadata_exporters, out of the given candidates, to receive the metadata
+// updates of the objects.
 func (rw *resourceWatcher) setupMetadataExporters(
-	exporters map[configmodels.Exporter]component.Exporter,
+	candidates map[string]component.Component,
 	metadataExportersFromConfig []string,
 ) error {
 
 	var out []metadataConsumer
 
-	metadataExportersSet := utils.StringSliceToMap(metadataExportersFromConfig)
-	if err := validateMetadataExporters(metadataExportersSet, exporters); err != nil {
+	if err := validateMetadataExporters(metadataExportersFromConfig, candidates); err != nil {
 		return fmt.Errorf("failed to configure metadata_exporters: %v", err)
 	}
 
-	for cfg, exp := range exporters {
-		if !metadataExportersSet[cfg.Name()] {
-			continue
-		}
-		kme, ok := exp.(metadata.MetadataExporter)
+	for _, name := range metadataExportersFromConfig {
+		kme, ok := candidates[name].(metadata.MetadataExporter)
 		if !ok {
-			return fmt.Errorf("%s exporter does not implement MetadataExporter", cfg.Name())
+			return fmt.Errorf("%s does not implement MetadataExporter", name)
 		}
 		out = append(out, kme.ConsumeMetadata)
 		rw.logger.Info("Configured Kubernetes MetadataExporter",
-			zap.String("exporter_name", cfg.Name()),
+			zap.String("exporter_name", name),
 		)
 	}
 
@@ -201,18 +1084,39 @@ func (rw *resourceWatcher) setupMetadataExporters(
 	return nil
 }
 
-func validateMetadataExporters(metadataExporters map[string]bool,
-	exporters map[configmodels.Exporter]component.Exporter) error {
-
-	configuredExporters := map[string]bool{}
-	for cfg := range exporters {
-		configuredExporters[cfg.Name()] = true
+// setupImageEnricher sets the extension named by image_metadata.enricher as
+// the enricher of container images, if any.
+func (rw *resourceWatcher) setupImageEnricher(host component.Host, name string) error {
+	if name == "" {
+		return nil
 	}
+	for cfg, ext := range host.GetExtensions() {
+		if cfg.Name() != name {
+			continue
+		}
+		enricher, ok := ext.(collection.ImageEnricher)
+		if !ok {
+			return fmt.Errorf("failed to configure image_metadata: %s does not implement ImageEnricher", name)
+		}
+		rw.dataCollector.SetImageEnricher(enricher)
+		rw.logger.Info("Configured image enricher", zap.String("extension_name", name))
+		return nil
+	}
+	return fmt.Errorf("failed to configure image_metadata: %s is not an extension in collector config", name)
+}
 
-	for e := range metadataExporters {
-		if !configuredExporters[e] {
-			return fmt.Errorf("%s exporter is not in collector config", e)
+func validateMetadataExporters(metadataExporters []string,
+	candidates map[string]component.Component) error {
+
+	seen := map[string]bool{}
+	for _, e := range metadataExporters {
+		if _, ok := candidates[e]; !ok {
+			return fmt.Errorf("%s is neither an exporter nor an extension in collector config", e)
+		}
+		if seen[e] {
+			return fmt.Errorf("%s is listed more than once", e)
 		}
+		seen[e] = true
 	}
 
 	return nil
@@ -227,6 +1131,9 @@ func (rw *resourceWatcher) syncMetadataUpdate(oldMetadata,
 	}
 
 	for _, consume := range rw.metadataConsumers {
-		consume(metadataUpdate)
+		if err := consume(metadataUpdate); err != nil {
+			observability.RecordMetadataSyncFailure()
+			rw.logger.Debug("Consuming metadata failed.", zap.Error(err))
+		}
 	}
 }
@@ -15,12 +15,31 @@
 package k8sclusterreceiver
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/config/configmodels"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	appsv1 "k8s.io/api/apps/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/receiver/k8sclusterreceiver/collection"
 )
 
 func TestSetupMetadataExporters(t *testing.T) {
@@ -28,7 +47,7 @@ func TestSetupMetadataExporters(t *testing.T) {
 		metadataConsumers []metadataConsumer
 	}
 	type args struct {
-		exporters                   map[configmodels.Exporter]component.Exporter
+		candidates                  map[string]component.Component
 		metadataExportersFromConfig []string
 	}
 	tests := []struct {
@@ -41,8 +60,8 @@ func TestSetupMetadataExporters(t *testing.T) {
 			"Unsupported exporter",
 			fields{},
 			args{
-				exporters: map[configmodels.Exporter]component.Exporter{
-					&configmodels.ExporterSettings{TypeVal: "exampleexporter", NameVal: "exampleexporter"}: MockExporter{},
+				candidates: map[string]component.Component{
+					"exampleexporter": MockExporter{},
 				},
 				metadataExportersFromConfig: []string{"exampleexporter"},
 			},
@@ -53,32 +72,58 @@ func TestSetupMetadataExporters(t *testing.T) {
 			fields{
 				metadataConsumers: []metadataConsumer{(&mockExporterWithK8sMetadata{}).ConsumeMetadata},
 			},
-			args{exporters: map[configmodels.Exporter]component.Exporter{
-				&configmodels.ExporterSettings{TypeVal: "exampleexporter", NameVal: "exampleexporter"}: mockExporterWithK8sMetadata{},
+			args{candidates: map[string]component.Component{
+				"exampleexporter": mockExporterWithK8sMetadata{},
 			},
 				metadataExportersFromConfig: []string{"exampleexporter"},
 			},
 			false,
 		},
+		{
+			"Supported exporter and extension",
+			fields{
+				metadataConsumers: []metadataConsumer{
+					(&mockExporterWithK8sMetadata{}).ConsumeMetadata,
+					(&mockExporterWithK8sMetadata{}).ConsumeMetadata,
+				},
+			},
+			args{candidates: map[string]component.Component{
+				"exampleexporter":  mockExporterWithK8sMetadata{},
+				"exampleextension": mockExporterWithK8sMetadata{},
+			},
+				metadataExportersFromConfig: []string{"exampleexporter", "exampleextension"},
+			},
+			false,
+		},
 		{
 			"Non-existent exporter",
 			fields{
 				metadataConsumers: []metadataConsumer{},
 			},
-			args{exporters: map[configmodels.Exporter]component.Exporter{
-				&configmodels.ExporterSettings{TypeVal: "exampleexporter", NameVal: "exampleexporter"}: mockExporterWithK8sMetadata{},
+			args{candidates: map[string]component.Component{
+				"exampleexporter": mockExporterWithK8sMetadata{},
 			},
 				metadataExportersFromConfig: []string{"exampleexporter/1"},
 			},
 			true,
 		},
+		{
+			"Exporter listed twice",
+			fields{},
+			args{candidates: map[string]component.Component{
+				"exampleexporter": mockExporterWithK8sMetadata{},
+			},
+				metadataExportersFromConfig: []string{"exampleexporter", "exampleexporter"},
+			},
+			true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			rw := &resourceWatcher{
 				logger: zap.NewNop(),
 			}
-			if err := rw.setupMetadataExporters(tt.args.exporters, tt.args.metadataExportersFromConfig); (err != nil) != tt.wantErr {
+			if err := rw.setupMetadataExporters(tt.args.candidates, tt.args.metadataExportersFromConfig); (err != nil) != tt.wantErr {
 				t.Errorf("setupMetadataExporters() error = %v, wantErr %v", err, tt.wantErr)
 			}
 
@@ -86,3 +131,553 @@ func TestSetupMetadataExporters(t *testing.T) {
 		})
 	}
 }
+
+func TestMetadataExporterCandidates(t *testing.T) {
+	candidates, err := metadataExporterCandidates(nopHostWithExporters{})
+	require.NoError(t, err)
+	require.Len(t, candidates, 4)
+	// Exporters of every pipeline and extensions can receive metadata.
+	for _, name := range []string{
+		"exampleexporter/withoutmetadata",
+		"exampleexporter/withmetadata",
+		"exampleexporter/logs",
+		"exampleextension/withmetadata",
+	} {
+		require.Contains(t, candidates, name)
+	}
+
+	_, err = metadataExporterCandidates(nopHostWithExporters{
+		extensions: map[configmodels.Extension]component.ServiceExtension{
+			&configmodels.ExtensionSettings{TypeVal: "exampleexporter", NameVal: "exampleexporter/withmetadata"}: mockExporterWithK8sMetadata{},
+		},
+	})
+	require.EqualError(t, err, "exampleexporter/withmetadata is the name of both an exporter and an extension")
+}
+
+// mockImageEnricher is an extension resolving no attributes of images.
+type mockImageEnricher struct {
+	MockExporter
+}
+
+func (mockImageEnricher) EnrichImage(collection.ImageInfo) map[string]string {
+	return nil
+}
+
+func TestSetupImageEnricher(t *testing.T) {
+	host := nopHostWithExporters{
+		extensions: map[configmodels.Extension]component.ServiceExtension{
+			&configmodels.ExtensionSettings{TypeVal: "imageenricher", NameVal: "imageenricher"}:       mockImageEnricher{},
+			&configmodels.ExtensionSettings{TypeVal: "exampleextension", NameVal: "exampleextension"}: MockExporter{},
+		},
+	}
+	rw := newResourceWatcher(zap.NewNop(), fake.NewSimpleClientset(), nil, &Config{}, 10*time.Second)
+
+	require.NoError(t, rw.setupImageEnricher(host, ""))
+	require.NoError(t, rw.setupImageEnricher(host, "imageenricher"))
+	require.EqualError(t, rw.setupImageEnricher(host, "exampleextension"),
+		"failed to configure image_metadata: exampleextension does not implement ImageEnricher")
+	require.EqualError(t, rw.setupImageEnricher(host, "unknown"),
+		"failed to configure image_metadata: unknown is not an extension in collector config")
+}
+
+func TestInitialSnapshot(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	numPods := 10
+	numNodes := 2
+	createPods(t, client, numPods)
+	createNodes(t, client, numNodes)
+
+	rw := newResourceWatcher(zap.NewNop(), client, nil, &Config{}, 10*time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Event handlers wait for initialSyncDone, which is never set here, so
+	// objects can only be collected from the initial snapshot.
+	rw.startWatchingResources(ctx)
+	defer rw.initialSyncDone.Store(true)
+
+	// Metrics computed at collection time may be reported on resources of
+	// the same objects, so distinct objects are counted.
+	podResources, nodeResources := map[string]bool{}, map[string]bool{}
+	for _, md := range rw.dataCollector.CollectMetricData(time.Now()) {
+		if uid, ok := md.Resource.Labels["k8s.pod.uid"]; ok {
+			podResources[uid] = true
+		}
+		if uid, ok := md.Resource.Labels["k8s.node.uid"]; ok {
+			nodeResources[uid] = true
+		}
+	}
+	require.Equal(t, numPods, len(podResources))
+	require.Equal(t, numNodes, len(nodeResources))
+}
+
+func TestLastResourceVersionMetric(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	rw := newResourceWatcher(zap.NewNop(), client, nil, &Config{}, 10*time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rw.startWatchingResources(ctx)
+	defer rw.initialSyncDone.Store(true)
+
+	lastPodResourceVersion := func() int64 {
+		for _, md := range rw.dataCollector.CollectMetricData(time.Now()) {
+			for _, m := range md.Metrics {
+				if m.MetricDescriptor.Name != "k8s.cluster.last_resource_version" {
+					continue
+				}
+				for _, ts := range m.Timeseries {
+					if ts.LabelValues[0].Value == "Pod" {
+						return ts.Points[0].GetInt64Value()
+					}
+				}
+			}
+		}
+		return 0
+	}
+
+	// The fake client does not set resource versions, so they are set here
+	// as the API server would.
+	pod := &corev1.Pod{ObjectMeta: v1.ObjectMeta{
+		Name:            "pod",
+		Namespace:       "test",
+		UID:             types.UID("pod-uid"),
+		ResourceVersion: "41",
+	}}
+	pod, err := client.CoreV1().Pods("test").Create(ctx, pod, v1.CreateOptions{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return lastPodResourceVersion() == 41
+	}, 5*time.Second, 10*time.Millisecond)
+
+	pod.ResourceVersion = "42"
+	_, err = client.CoreV1().Pods("test").Update(ctx, pod, v1.UpdateOptions{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return lastPodResourceVersion() == 42
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestOptionalKinds(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	_, err := client.CoreV1().Services("test").Create(context.Background(), &corev1.Service{
+		ObjectMeta: v1.ObjectMeta{Name: "svc", Namespace: "test", UID: "svc-uid"},
+	}, v1.CreateOptions{})
+	require.NoError(t, err)
+	_, err = client.CoreV1().ConfigMaps("test").Create(context.Background(), &corev1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{Name: "cm", Namespace: "test", UID: "cm-uid"},
+	}, v1.CreateOptions{})
+	require.NoError(t, err)
+	_, err = client.CoreV1().Secrets("test").Create(context.Background(), &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{Name: "secret", Namespace: "test", UID: "secret-uid"},
+	}, v1.CreateOptions{})
+	require.NoError(t, err)
+	replicas := int32(1)
+	_, err = client.AppsV1().StatefulSets("test").Create(context.Background(), &appsv1.StatefulSet{
+		ObjectMeta: v1.ObjectMeta{Name: "db", Namespace: "test", UID: "db-uid"},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:             &replicas,
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{ObjectMeta: v1.ObjectMeta{Name: "data"}}},
+		},
+	}, v1.CreateOptions{})
+	require.NoError(t, err)
+	for _, ns := range []string{corev1.NamespaceNodeLease, "kube-system"} {
+		_, err = client.CoordinationV1().Leases(ns).Create(context.Background(), &coordinationv1.Lease{
+			ObjectMeta: v1.ObjectMeta{Name: "lease", Namespace: ns, UID: types.UID(ns + "-lease-uid")},
+			Spec:       coordinationv1.LeaseSpec{RenewTime: &v1.MicroTime{Time: time.Now()}},
+		}, v1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	metricNames := func(config *Config) map[string]bool {
+		rw := newResourceWatcher(zap.NewNop(), client, nil, config, 10*time.Second)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		rw.startWatchingResources(ctx)
+		defer rw.initialSyncDone.Store(true)
+
+		out := map[string]bool{}
+		for _, md := range rw.dataCollector.CollectMetricData(time.Now()) {
+			for _, m := range md.Metrics {
+				out[m.MetricDescriptor.Name] = true
+			}
+		}
+		return out
+	}
+
+	require.False(t, metricNames(&Config{})["k8s.cluster.services_with_no_ready_endpoints"])
+	require.True(t, metricNames(&Config{
+		OptionalKinds: []string{optionalKindEndpointSlice},
+	})["k8s.cluster.services_with_no_ready_endpoints"])
+
+	require.False(t, metricNames(&Config{})["k8s.namespace.configmap_count"])
+	require.False(t, metricNames(&Config{})["k8s.namespace.secret_count"])
+	require.True(t, metricNames(&Config{
+		OptionalKinds: []string{optionalKindConfigMap},
+	})["k8s.namespace.configmap_count"])
+	require.True(t, metricNames(&Config{
+		OptionalKinds: []string{optionalKindSecret},
+	})["k8s.namespace.secret_count"])
+
+	require.False(t, metricNames(&Config{})["k8s.lease.renew_age"])
+	require.True(t, metricNames(&Config{
+		OptionalKinds: []string{optionalKindLease},
+	})["k8s.lease.renew_age"])
+
+	require.False(t, metricNames(&Config{})["k8s.statefulset.unbound_pvc_count"])
+	require.True(t, metricNames(&Config{
+		OptionalKinds: []string{optionalKindPersistentVolumeClaim},
+	})["k8s.statefulset.unbound_pvc_count"])
+
+	require.False(t, metricNames(&Config{})["k8s.rbac.cluster_admin_binding_count"])
+	require.True(t, metricNames(&Config{
+		OptionalKinds: []string{optionalKindRBAC},
+	})["k8s.rbac.cluster_admin_binding_count"])
+}
+
+func TestDuplicateObjectsFromTwoInformers(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: v1.ObjectMeta{Name: "pod", Namespace: "test", UID: "pod-uid"}}
+	podPhases := func(rw *resourceWatcher) int {
+		n := 0
+		for _, md := range rw.dataCollector.CollectMetricData(time.Now()) {
+			for _, m := range md.Metrics {
+				if m.MetricDescriptor.Name == "k8s.pod.phase" {
+					n++
+				}
+			}
+		}
+		return n
+	}
+
+	logger, logs := observer.New(zapcore.WarnLevel)
+	rw := newResourceWatcher(zap.New(logger), fake.NewSimpleClientset(), nil,
+		&Config{DuplicateObjects: duplicateObjectsFirstSource}, 10*time.Second)
+	rw.initialSyncDone.Store(true)
+	first, second := rw.eventHandlers(0), rw.eventHandlers(1)
+
+	first.OnAdd(pod)
+	second.OnAdd(pod)
+	second.OnUpdate(pod, pod)
+	require.Equal(t, 1, podPhases(rw))
+	require.Equal(t, 1, logs.FilterMessageSnippet("more than one informer").Len())
+
+	// The object is only removed once deleted from the informer it was
+	// first received from.
+	second.OnDelete(pod)
+	require.Equal(t, 1, podPhases(rw))
+	first.OnDelete(pod)
+	require.Equal(t, 0, podPhases(rw))
+
+	// With all_sources, a deletion from either informer removes the object.
+	rw = newResourceWatcher(zap.NewNop(), fake.NewSimpleClientset(), nil,
+		&Config{DuplicateObjects: duplicateObjectsAllSources}, 10*time.Second)
+	rw.initialSyncDone.Store(true)
+	first, second = rw.eventHandlers(0), rw.eventHandlers(1)
+	first.OnAdd(pod)
+	second.OnAdd(pod)
+	require.Equal(t, 1, podPhases(rw))
+	second.OnDelete(pod)
+	require.Equal(t, 0, podPhases(rw))
+}
+
+func TestOnlyNodeLeasesAreWatched(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	for _, ns := range []string{corev1.NamespaceNodeLease, "kube-system"} {
+		_, err := client.CoordinationV1().Leases(ns).Create(context.Background(), &coordinationv1.Lease{
+			ObjectMeta: v1.ObjectMeta{Name: "lease", Namespace: ns, UID: types.UID(ns + "-lease-uid")},
+		}, v1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	rw := newResourceWatcher(zap.NewNop(), client, nil,
+		&Config{OptionalKinds: []string{optionalKindLease}}, 10*time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rw.startWatchingResources(ctx)
+	defer rw.initialSyncDone.Store(true)
+
+	informer := rw.sharedInformerFactories[0].InformerFor(&coordinationv1.Lease{}, nil)
+	leases := informer.GetStore().List()
+	require.Equal(t, 1, len(leases))
+	require.Equal(t, corev1.NamespaceNodeLease, leases[0].(*coordinationv1.Lease).Namespace)
+}
+
+func TestExcludedNodesAreNotWatched(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	for name, labels := range map[string]map[string]string{
+		"worker":        {"node-role.kubernetes.io/worker": ""},
+		"control-plane": {"node-role.kubernetes.io/control-plane": ""},
+		"master":        {"node-role.kubernetes.io/master": "true"},
+	} {
+		_, err := client.CoreV1().Nodes().Create(context.Background(), &corev1.Node{
+			ObjectMeta: v1.ObjectMeta{Name: name, UID: types.UID(name + "-uid"), Labels: labels},
+		}, v1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	rw := newResourceWatcher(zap.NewNop(), client, nil, &Config{
+		ExcludeNodeLabels: []string{"node-role.kubernetes.io/control-plane", "node-role.kubernetes.io/master"},
+	}, 10*time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rw.startWatchingResources(ctx)
+	defer rw.initialSyncDone.Store(true)
+
+	informer := rw.sharedInformerFactories[0].InformerFor(&corev1.Node{}, nil)
+	nodes := informer.GetStore().List()
+	require.Equal(t, 1, len(nodes))
+	require.Equal(t, "worker", nodes[0].(*corev1.Node).Name)
+}
+
+func TestOnlyConfiguredNamespacesAreWatched(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	for _, ns := range []string{"a", "b", "c"} {
+		_, err := client.CoreV1().Pods(ns).Create(context.Background(), &corev1.Pod{
+			ObjectMeta: v1.ObjectMeta{Name: "pod", Namespace: ns, UID: types.UID(ns + "-pod-uid")},
+		}, v1.CreateOptions{})
+		require.NoError(t, err)
+	}
+	createNodes(t, client, 1)
+	client.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetNamespace() != "b" {
+			return false, nil, nil
+		}
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "", errors.New("not permitted"))
+	})
+
+	rw := newResourceWatcher(zap.NewNop(), client, nil, &Config{Namespaces: []string{"a", "b"}}, 10*time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rw.startWatchingResources(ctx)
+	defer rw.initialSyncDone.Store(true)
+	require.Equal(t, 2, len(rw.sharedInformerFactories))
+
+	// Pods of namespaces that are not configured or cannot be listed are not
+	// collected, nor are cluster-scoped kinds.
+	pods := map[string]bool{}
+	for _, md := range rw.dataCollector.CollectMetricData(time.Now()) {
+		if uid, ok := md.Resource.Labels["k8s.pod.uid"]; ok {
+			pods[uid] = true
+		}
+		require.NotContains(t, md.Resource.Labels, "k8s.node.uid")
+		require.NotContains(t, md.Resource.Labels, "k8s.namespace.uid")
+	}
+	require.Equal(t, map[string]bool{"a-pod-uid": true}, pods)
+}
+
+func TestOnlySelectedKindsAreWatched(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	rw := newResourceWatcher(zap.NewNop(), client, nil, &Config{
+		Resources:     ResourcesConfig{Include: []string{"pod", "node", "deployment"}, Exclude: []string{"deployment"}},
+		OptionalKinds: []string{optionalKindConfigMap},
+	}, 10*time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rw.startWatchingResources(ctx)
+	defer rw.initialSyncDone.Store(true)
+
+	// Optional kinds are enabled by optional_kinds only.
+	var kinds []string
+	for kind := range rw.sharedInformerFactories[0].WaitForCacheSync(ctx.Done()) {
+		kinds = append(kinds, kind.Elem().Name())
+	}
+	require.ElementsMatch(t, []string{"Pod", "Node", "ConfigMap"}, kinds)
+}
+
+func TestSampledObjectsAreCollected(t *testing.T) {
+	const numPods, rate = 2000, 10
+	rw := newResourceWatcher(zap.NewNop(), fake.NewSimpleClientset(), nil, &Config{
+		Sampling: map[string]SamplingConfig{"pod": {Rate: rate}},
+	}, 10*time.Second)
+	rw.initialSyncDone.Store(true)
+
+	pods := make([]*corev1.Pod, numPods)
+	for i := range pods {
+		pods[i] = &corev1.Pod{ObjectMeta: v1.ObjectMeta{
+			Name:      fmt.Sprintf("pod-%d", i),
+			Namespace: "test",
+			UID:       types.UID(fmt.Sprintf("pod-%d-uid", i)),
+			Labels:    map[string]string{"app": "test"},
+		}}
+		rw.processAdd(pods[i])
+	}
+
+	collected := map[string]bool{}
+	for _, md := range rw.dataCollector.CollectMetricData(time.Now()) {
+		if uid, ok := md.Resource.Labels["k8s.pod.uid"]; ok {
+			collected[uid] = true
+		}
+	}
+	// Roughly 1 in rate pods are collected.
+	require.InDelta(t, numPods/rate, len(collected), numPods/rate*0.3)
+
+	// A collected pod no longer matching the selector is no longer collected.
+	rw = newResourceWatcher(zap.NewNop(), fake.NewSimpleClientset(), nil, &Config{
+		Sampling: map[string]SamplingConfig{"pod": {Selector: "app=test"}},
+	}, 10*time.Second)
+	rw.initialSyncDone.Store(true)
+	rw.processAdd(pods[0])
+	require.NotEmpty(t, rw.dataCollector.CollectMetricData(time.Now()))
+
+	updated := pods[0].DeepCopy()
+	updated.Labels["app"] = "other"
+	rw.processUpdate(pods[0], updated)
+	require.Empty(t, rw.dataCollector.CollectMetricData(time.Now()))
+}
+
+func TestManagedObjectsAreExcluded(t *testing.T) {
+	rw := newResourceWatcher(zap.NewNop(), fake.NewSimpleClientset(), nil, &Config{
+		ExcludeManagedBy: ManagedByConfig{
+			Label:      "app.kubernetes.io/managed-by",
+			Values:     []string{"Helm"},
+			OwnerKinds: []string{"Prometheus"},
+		},
+	}, 10*time.Second)
+	rw.initialSyncDone.Store(true)
+
+	newPod := func(name string, labels map[string]string, owners ...v1.OwnerReference) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: v1.ObjectMeta{
+			Name:            name,
+			Namespace:       "test",
+			UID:             types.UID(name + "-uid"),
+			Labels:          labels,
+			OwnerReferences: owners,
+		}}
+	}
+	unmanaged := newPod("unmanaged", map[string]string{"app.kubernetes.io/managed-by": "kustomize"})
+	rw.processAdd(unmanaged)
+	rw.processAdd(newPod("helm", map[string]string{"app.kubernetes.io/managed-by": "helm"}))
+	rw.processAdd(newPod("operator", nil, v1.OwnerReference{Kind: "Prometheus", Name: "k8s", UID: "prometheus-uid"}))
+
+	collected := func() map[string]bool {
+		out := map[string]bool{}
+		for _, md := range rw.dataCollector.CollectMetricData(time.Now()) {
+			if uid, ok := md.Resource.Labels["k8s.pod.uid"]; ok {
+				out[uid] = true
+			}
+		}
+		return out
+	}
+	require.Equal(t, map[string]bool{"unmanaged-uid": true}, collected())
+
+	// A collected pod getting managed is no longer collected.
+	updated := unmanaged.DeepCopy()
+	updated.Labels["app.kubernetes.io/managed-by"] = "Helm"
+	rw.processUpdate(unmanaged, updated)
+	require.Empty(t, collected())
+}
+
+func TestTerminalPodsAreExcluded(t *testing.T) {
+	newPod := func(name string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "test", UID: types.UID(name + "-uid")},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+	collected := func(config *Config) map[string]bool {
+		rw := newResourceWatcher(zap.NewNop(), fake.NewSimpleClientset(), nil, config, 10*time.Second)
+		rw.initialSyncDone.Store(true)
+
+		running := newPod("running", corev1.PodRunning)
+		rw.processAdd(running)
+		rw.processAdd(newPod("succeeded", corev1.PodSucceeded))
+		rw.processAdd(newPod("failed", corev1.PodFailed))
+		// A collected pod completing is no longer collected.
+		completing := newPod("completing", corev1.PodRunning)
+		rw.processAdd(completing)
+		rw.processUpdate(completing, newPod("completing", corev1.PodSucceeded))
+
+		out := map[string]bool{}
+		for _, md := range rw.dataCollector.CollectMetricData(time.Now()) {
+			if uid, ok := md.Resource.Labels["k8s.pod.uid"]; ok {
+				out[uid] = true
+			}
+		}
+		return out
+	}
+
+	require.Equal(t, map[string]bool{
+		"running-uid": true, "succeeded-uid": true, "failed-uid": true, "completing-uid": true,
+	}, collected(&Config{}))
+	require.Equal(t, map[string]bool{"running-uid": true, "failed-uid": true}, collected(&Config{
+		ExcludePodPhases: []string{"Succeeded"},
+	}))
+	require.Equal(t, map[string]bool{"running-uid": true}, collected(&Config{
+		ExcludePodPhases: []string{"Succeeded", "Failed"},
+	}))
+}
+
+func TestTombstoneDeleteQueuedBehindUpdate(t *testing.T) {
+	rw := newResourceWatcher(zap.NewNop(), fake.NewSimpleClientset(), nil,
+		&Config{EventWorkers: 1, EventQueueSize: 10}, 10*time.Second)
+	rw.initialSyncDone.Store(true)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rw.eventQueue.start(ctx)
+
+	pod := &corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{Name: "pod", Namespace: "test", UID: "pod-uid"},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	rw.processAdd(pod)
+
+	// The update is held in the queue while the worker is busy, and the
+	// deletion of the pod, whose final state is unknown, arrives behind it.
+	release := make(chan struct{})
+	rw.eventQueue.enqueue(pod.UID, func() { <-release })
+	updated := pod.DeepCopy()
+	updated.ResourceVersion = "2"
+	rw.onUpdate(pod, updated)
+	rw.onDelete(cache.DeletedFinalStateUnknown{Key: "test/pod", Obj: updated})
+	processed := make(chan struct{})
+	rw.eventQueue.enqueue(pod.UID, func() { close(processed) })
+	close(release)
+	<-processed
+
+	for _, md := range rw.dataCollector.CollectMetricData(time.Now()) {
+		require.NotEqual(t, "pod-uid", md.Resource.Labels["k8s.pod.uid"])
+	}
+}
+
+func TestSecretDataIsNotCached(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	_, err := client.CoreV1().Secrets("test").Create(context.Background(), &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "secret",
+			Namespace: "test",
+			UID:       "secret-uid",
+			Annotations: map[string]string{
+				corev1.LastAppliedConfigAnnotation: `{"data":{"password":"czNjcjN0"}}`,
+				"owner":                            "team",
+			},
+		},
+		Data:       map[string][]byte{"password": []byte("s3cr3t")},
+		StringData: map[string]string{"token": "s3cr3t"},
+	}, v1.CreateOptions{})
+	require.NoError(t, err)
+
+	rw := newResourceWatcher(zap.NewNop(), client, nil,
+		&Config{OptionalKinds: []string{optionalKindSecret}}, 10*time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rw.startWatchingResources(ctx)
+
+	informer := rw.sharedInformerFactories[0].InformerFor(&corev1.Secret{}, nil)
+	secrets := informer.GetStore().List()
+	require.Equal(t, 1, len(secrets))
+	secret := secrets[0].(*corev1.Secret)
+	require.Equal(t, "secret", secret.Name)
+	require.Nil(t, secret.Data)
+	require.Nil(t, secret.StringData)
+	require.Equal(t, map[string]string{"owner": "team"}, secret.Annotations)
+
+	// Secrets received through the watch are stripped as well.
+	_, err = client.CoreV1().Secrets("test").Create(context.Background(), &corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{Name: "other", Namespace: "test", UID: "other-uid"},
+		Data:       map[string][]byte{"password": []byte("s3cr3t")},
+	}, v1.CreateOptions{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		obj, ok, err := informer.GetStore().GetByKey("test/other")
+		return err == nil && ok && obj.(*corev1.Secret).Data == nil
+	}, 10*time.Second, 100*time.Millisecond)
+}